@@ -0,0 +1,74 @@
+package main
+
+import (
+	"e-document-backend/internal/config"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// tusHeaders lists the headers of the TUS protocol and the extensions tusd supports
+// Browsers must be allowed to send them and to read them back, so both CORS lists include them
+func tusHeaders() []string {
+	return []string{
+		"Tus-Resumable",
+		"Tus-Version",
+		"Tus-Max-Size",
+		"Tus-Extension",
+		"Upload-Offset",
+		"Upload-Length",
+		"Upload-Metadata",
+		"Upload-Defer-Length",
+		"Upload-Concat",
+		"Upload-Expires",
+	}
+}
+
+// appRequestHeaders are the non-TUS request headers the API reads
+var appRequestHeaders = []string{
+	echo.HeaderOrigin,
+	echo.HeaderContentType,
+	echo.HeaderAccept,
+	echo.HeaderAuthorization,
+	"If-None-Match", // conditional requests against ETags
+	echo.HeaderXRequestID,
+	"X-HTTP-Method-Override", // TUS clients send PATCH as POST behind proxies that drop it
+}
+
+// appResponseHeaders are the non-TUS response headers clients need to read
+var appResponseHeaders = []string{
+	echo.HeaderLocation, // URL of a created TUS upload
+	echo.HeaderContentDisposition,
+	"ETag",
+	echo.HeaderXRequestID,
+	"X-Barcode", // generated document barcode value
+}
+
+// corsConfig builds the CORS configuration from the TUS headers, the API's own headers and the
+// extra headers configured for the deployment
+func corsConfig(server config.ServerConfig) middleware.CORSConfig {
+	return middleware.CORSConfig{
+		AllowOrigins:     server.CORSAllowedOrigins, // ⚠️ ต้องระบุ origin ชัดเจน ไม่ใช่ "*"
+		AllowMethods:     []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodPatch, http.MethodOptions, http.MethodHead},
+		AllowHeaders:     mergeHeaders(appRequestHeaders, tusHeaders(), server.CORSAllowHeaders),
+		AllowCredentials: true,
+		ExposeHeaders:    mergeHeaders(appResponseHeaders, tusHeaders(), server.CORSExposeHeaders),
+	}
+}
+
+// mergeHeaders joins header lists in order, dropping names already listed in any spelling
+func mergeHeaders(lists ...[]string) []string {
+	seen := make(map[string]bool)
+	var headers []string
+	for _, list := range lists {
+		for _, header := range list {
+			header = http.CanonicalHeaderKey(header)
+			if header != "" && !seen[header] {
+				seen[header] = true
+				headers = append(headers, header)
+			}
+		}
+	}
+	return headers
+}
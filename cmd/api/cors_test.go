@@ -0,0 +1,73 @@
+package main
+
+import (
+	"e-document-backend/internal/config"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestMergeHeaders(t *testing.T) {
+	tests := []struct {
+		name  string
+		lists [][]string
+		want  []string
+	}{
+		{name: "keeps the order of the lists", lists: [][]string{{"Authorization", "Accept"}, {"Upload-Offset"}}, want: []string{"Authorization", "Accept", "Upload-Offset"}},
+		{name: "canonicalizes names", lists: [][]string{{"x-request-id", "upload-offset"}}, want: []string{"X-Request-Id", "Upload-Offset"}},
+		{name: "drops repeats in any spelling", lists: [][]string{{"ETag"}, {"etag", "ETAG", "Location"}}, want: []string{"Etag", "Location"}},
+		{name: "drops empty names", lists: [][]string{{"", "Accept"}, nil}, want: []string{"Accept"}},
+		{name: "no headers", lists: nil, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mergeHeaders(tt.lists...)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Fatalf("mergeHeaders() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCORSConfigExposeHeaders(t *testing.T) {
+	// Everything a browser client reads back: TUS upload state, the created upload's URL,
+	// download names, cache validators, request IDs and generated barcodes
+	base := []string{
+		"Content-Disposition", "Etag", "Location", "Tus-Extension", "Tus-Max-Size", "Tus-Resumable",
+		"Tus-Version", "Upload-Concat", "Upload-Defer-Length", "Upload-Expires", "Upload-Length",
+		"Upload-Metadata", "Upload-Offset", "X-Barcode", "X-Request-Id",
+	}
+
+	tests := []struct {
+		name   string
+		expose []string
+		want   []string
+	}{
+		{name: "default", want: base},
+		{name: "configured extra header", expose: []string{"x-trace-id"}, want: append([]string{"X-Trace-Id"}, base...)},
+		{name: "configured header already exposed", expose: []string{"upload-offset", "ETag"}, want: base},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := corsConfig(config.ServerConfig{CORSExposeHeaders: tt.expose})
+
+			got := append([]string(nil), cfg.ExposeHeaders...)
+			sort.Strings(got)
+			want := append([]string(nil), tt.want...)
+			sort.Strings(want)
+			if strings.Join(got, ",") != strings.Join(want, ",") {
+				t.Fatalf("ExposeHeaders = %v, want %v", got, want)
+			}
+		})
+	}
+
+	// Request-only and never-readable headers stay out of the exposed list
+	for _, header := range corsConfig(config.ServerConfig{}).ExposeHeaders {
+		switch header {
+		case "Authorization", "Set-Cookie", "If-None-Match", "X-Http-Method-Override":
+			t.Errorf("ExposeHeaders includes %s", header)
+		}
+	}
+}
@@ -2,20 +2,31 @@ package main
 
 import (
 	"context"
+	"e-document-backend/internal/app/admin"
 	"e-document-backend/internal/app/auth"
+	"e-document-backend/internal/app/department"
 	"e-document-backend/internal/app/file"
+	"e-document-backend/internal/app/health"
+	"e-document-backend/internal/app/quota"
+	"e-document-backend/internal/app/sector"
+	"e-document-backend/internal/app/sharelink"
 	"e-document-backend/internal/app/upload"
 	"e-document-backend/internal/app/user"
 	"e-document-backend/internal/config"
+	"e-document-backend/internal/domain"
 	"e-document-backend/internal/logger"
 	customMiddleware "e-document-backend/internal/middleware"
+	"e-document-backend/internal/pkg/metrics"
 	"e-document-backend/internal/pkg/seed"
+	"e-document-backend/internal/pkg/shutdown"
 	"e-document-backend/internal/pkg/storage"
 	"e-document-backend/internal/platform/postgres"
+	"e-document-backend/internal/util"
 	"e-document-backend/internal/app/folder_file_manage"
 	"net/http"
 	"os"
 	"os/signal"
+	"syscall"
 	"time"
 
 	_ "e-document-backend/docs" // Import generated docs
@@ -55,71 +66,111 @@ func main() {
 		TimeFormat: time.RFC3339,
 	})
 
+	// Allowed origins are sent with credentials, so a wildcard would be rejected by browsers
+	if err := cfg.Server.ValidateCORS(); err != nil {
+		logger.FatalWithErr("Invalid CORS configuration", err)
+	}
+
+	if err := cfg.Server.ValidateBodyLimits(); err != nil {
+		logger.FatalWithErr("Invalid request body limit configuration", err)
+	}
+
+	if err := cfg.Database.Validate(); err != nil {
+		logger.FatalWithErr("Invalid database configuration", err)
+	}
+
+	if err := cfg.JWT.Validate(); err != nil {
+		logger.FatalWithErr("Invalid JWT configuration", err)
+	}
+
+	// Password rules are enforced by the "password" validation tag on user requests
+	if err := cfg.Password.Validate(); err != nil {
+		logger.FatalWithErr("Invalid password configuration", err)
+	}
+	util.SetPasswordPolicy(util.PasswordPolicy{
+		MinLength:    cfg.Password.MinLength,
+		RequireMixed: cfg.Password.RequireMixed,
+	})
+
+	// Page sizes of listings without their own defaults
+	if err := cfg.Pagination.Validate(); err != nil {
+		logger.FatalWithErr("Invalid pagination configuration", err)
+	}
+	util.SetPaginationLimits(util.PaginationLimits{
+		DefaultLimit: cfg.Pagination.DefaultLimit,
+		MaxLimit:     cfg.Pagination.MaxLimit,
+	})
+
+	// Presigned URL expiries requested by clients are checked against these bounds
+	if err := cfg.Presign.Validate(); err != nil {
+		logger.FatalWithErr("Invalid presign configuration", err)
+	}
+
+	if err := cfg.Compress.Validate(); err != nil {
+		logger.FatalWithErr("Invalid compression configuration", err)
+	}
+
+	if err := cfg.Logger.Validate(); err != nil {
+		logger.FatalWithErr("Invalid logger configuration", err)
+	}
+
+	if err := cfg.User.Validate(); err != nil {
+		logger.FatalWithErr("Invalid user configuration", err)
+	}
+
 	// Create Echo instance
 	e := echo.New()
 
 	// Middleware
 	e.Use(middleware.Recover())
-	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
-		AllowOrigins: []string{"http://localhost:5173"}, // ⚠️ ต้องระบุ origin ชัดเจน ไม่ใช่ "*"
-		AllowMethods: []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodPatch, http.MethodOptions, http.MethodHead},
-		AllowHeaders: []string{
-			echo.HeaderOrigin,
-			echo.HeaderContentType,
-			echo.HeaderAccept,
-			echo.HeaderAuthorization,
-			"ngrok-skip-browser-warning",
-			// TUS protocol headers
-			"Upload-Offset",
-			"Upload-Length",
-			"Upload-Metadata",
-			"Upload-Defer-Length",
-			"Upload-Concat",
-			"Tus-Resumable",
-			"Tus-Version",
-			"Tus-Max-Size",
-			"Tus-Extension",
-		},
-		AllowCredentials: true,
-		ExposeHeaders: []string{
-			"Set-Cookie",
-			// TUS protocol headers
-			"Upload-Offset",
-			"Upload-Length",
-			"Location",
-			"Tus-Resumable",
-			"Tus-Version",
-			"Tus-Max-Size",
-			"Tus-Extension",
-		},
-	}))
+	e.Use(middleware.CORSWithConfig(corsConfig(cfg.Server)))
 
 	// Request ID middleware (adds unique ID to each request)
 	e.Use(customMiddleware.RequestIDMiddleware())
 
 	// Logger middleware (logs all requests and responses)
+	loggerConfig := customMiddleware.LoggerConfig{
+		SensitiveKeys: cfg.Logger.SensitiveKeys,
+		SampleRate:    cfg.Logger.SampleRate,
+	}
 	if cfg.Logger.Level == "debug" {
 		// Detailed logging with request/response body for development
-		e.Use(customMiddleware.DetailedLoggerMiddleware())
+		e.Use(customMiddleware.DetailedLoggerMiddleware(loggerConfig))
 	} else {
 		// Standard logging for production
-		e.Use(customMiddleware.LoggerMiddleware())
+		e.Use(customMiddleware.LoggerMiddleware(loggerConfig))
 	}
 
+	// Metrics middleware (request counts and latencies for Prometheus)
+	e.Use(customMiddleware.MetricsMiddleware())
+
 	// Rate limiting middleware
 	e.Use(customMiddleware.RateLimitMiddleware(customMiddleware.RateLimitConfig{
 		RequestsPerSecond: 20,
 		BurstSize:         50,
 	}))
 
+	// Gzip compression of JSON and text responses (downloads and TUS routes are skipped)
+	if cfg.Compress.Enabled {
+		e.Use(customMiddleware.CompressMiddleware(customMiddleware.CompressConfig{
+			Level:     cfg.Compress.Level,
+			MinLength: cfg.Compress.MinLength,
+		}))
+	}
+
 	// Connect to PostgreSQL
 	ctx := context.Background()
 	println("Connecting to PostgreSQL...", cfg.Database.PostgresDSN)
-	pgClient, err := postgres.NewClient(ctx, cfg.Database.PostgresDSN)
+	pgClient, err := postgres.NewClient(ctx, cfg.Database.PostgresDSN, postgres.PoolConfig{
+		MaxConns:          int32(cfg.Database.MaxConns),
+		MinConns:          int32(cfg.Database.MinConns),
+		MaxConnLifetime:   time.Duration(cfg.Database.MaxConnLifetime) * time.Second,
+		MaxConnIdleTime:   time.Duration(cfg.Database.MaxConnIdleTime) * time.Second,
+		HealthCheckPeriod: time.Duration(cfg.Database.HealthCheckPeriod) * time.Second,
+	})
 	if err != nil {
 		logger.FatalWithErr("Failed to connect to PostgreSQL", err)
 	}
-	defer pgClient.Close()
 
 	// Initialize MinIO client for file storage
 	minioConfig := storage.LoadConfigFromEnv()
@@ -131,18 +182,50 @@ func main() {
 
 	// Initialize user module (Handler-Service-Repository)
 	userRepo := user.NewPostgresRepository(pgClient.Pool)
-	userService := user.NewService(userRepo)
-	userHandler := user.NewHandler(userService, minioClient)
+
+	// Initialize auth module (Handler-Service); it also issues email verification tokens for new users
+	authService, err := auth.NewService(userRepo, auth.NewImpersonationRepository(pgClient.Pool), cfg, auth.NewLogVerificationNotifier())
+	if err != nil {
+		logger.FatalWithErr("Failed to load JWT signing keys", err)
+	}
+	authHandler := auth.NewHandler(authService)
+
+	// Initialize sector module (org hierarchy: sectors contain departments); validates user assignments
+	sectorRepo := sector.NewRepository(pgClient.Pool)
+	sectorService := sector.NewService(sectorRepo, userRepo)
+	sectorHandler := sector.NewHandler(sectorService)
+
+	userService := user.NewService(userRepo, cfg.Password.BcryptCost, domain.UserRole(cfg.User.DefaultRole), authService, sectorService, authService)
+	userHandler := user.NewHandler(userService, minioClient, cfg.Presign)
+
+	// Initialize department module (reuses the user and sector repositories)
+	departmentService := department.NewService(userRepo, sectorRepo)
+	departmentHandler := department.NewHandler(departmentService)
+
+	// Initialize admin module (database maintenance)
+	adminRepo := admin.NewRepository(pgClient.Pool)
+	adminService := admin.NewService(adminRepo, userRepo, cfg.Database.MaintenanceEnabled)
+	adminHandler := admin.NewHandler(adminService)
 
 	// Initialize file module (Service-Handler) for generating presigned URLs for files in MinIO
-	fileService := file.NewService(minioClient)
+	fileRepo := file.NewRepository(pgClient.Pool)
+	fileService := file.NewService(fileRepo, minioClient, cfg.Presign)
 	fileHandler := file.NewHandler(fileService)
 
+	// Initialize quota module (per-role default storage quota with per-user override)
+	quotaRepo := quota.NewRepository(pgClient.Pool)
+	quotaService := quota.NewService(quotaRepo, userRepo, quota.LoadConfigFromEnv())
+	quotaHandler := quota.NewHandler(quotaService)
+
+	// Background jobs run until the shutdown sequence stops them
+	jobCtx, stopJobs := context.WithCancel(ctx)
+	defer stopJobs()
+
 	// Initialize upload module (Resumable upload with tusd)
 	uploadRepo := upload.NewPostgresRepository(pgClient.Pool)
 	uploadService := upload.NewService(uploadRepo)
 	tusConfig := upload.LoadTusConfigFromEnv()
-	uploadHandler, err := upload.NewHandler(uploadService, tusConfig)
+	uploadHandler, err := upload.NewHandler(jobCtx, uploadService, tusConfig, quotaService)
 	if err != nil {
 		logger.FatalWithErr("Failed to initialize upload handler", err)
 	}
@@ -150,18 +233,22 @@ func main() {
 
 	// Initialize storage module (for browsing folders/documents)
 	storageRepo := folder_file_manage.NewRepository(pgClient.Pool)
-	storageService := folder_file_manage.NewService(storageRepo)
+	storageService := folder_file_manage.NewService(storageRepo, quotaService, uploadService)
 	storageHandler := folder_file_manage.NewHandler(storageService)
 	logger.Info("Storage module initialized successfully")
 
+	// Initialize share link module (public read-only links, expiry reminders and cleanup)
+	shareLinkConfig := sharelink.LoadConfigFromEnv()
+	shareLinkRepo := sharelink.NewRepository(pgClient.Pool)
+	shareLinkService := sharelink.NewService(shareLinkRepo, userRepo, minioClient, sharelink.NewLogNotifier(), shareLinkConfig)
+	shareLinkHandler := sharelink.NewHandler(shareLinkService)
+	sharelink.StartJob(jobCtx, shareLinkService, shareLinkConfig)
+
 	// Seed admin user if it doesn't exist
 	if err := seed.SeedAdmin(ctx, userRepo, cfg); err != nil {
 		logger.Warnf("Failed to seed admin user: %v", err)
 	}
 
-	// Initialize auth module (Handler-Service)
-	authService := auth.NewService(userRepo, cfg)
-	authHandler := auth.NewHandler(authService)
 
 	// API routes
 	api := e.Group("/api")
@@ -169,28 +256,43 @@ func main() {
 	// Swagger documentation
 	e.GET("/swagger/*", echoSwagger.WrapHandler)
 
-	// Health check endpoint
-	api.GET("/health", func(c echo.Context) error {
-		return c.JSON(http.StatusOK, map[string]interface{}{
-			"success": true,
-			"message": "Server is running",
-			"data": map[string]string{
-				"status": "healthy",
-				"time":   time.Now().Format(time.RFC3339),
-			},
-		})
-	})
+	// Prometheus metrics endpoint
+	e.GET("/metrics", echo.WrapHandler(metrics.Handler()))
 
-	// Register user routes
-	userHandler.RegisterRoutes(api, customMiddleware.AuthMiddleware(authService))
+	// Health check endpoints (liveness, and readiness verifying PostgreSQL and both MinIO buckets)
+	healthHandler := health.NewHandler(map[string]health.Checker{
+		"postgres": pgClient,
+		"minio":    minioClient,
+		"upload":   uploadHandler,
+	})
+	healthHandler.RegisterRoutes(api)
+
+	// Request body limits per route group (oversized bodies get a 413 PAYLOAD_TOO_LARGE)
+	authAPI := api.Group("", customMiddleware.BodyLimitMiddleware(cfg.Server.AuthBodyLimit))
+	jsonAPI := api.Group("", customMiddleware.BodyLimitMiddleware(cfg.Server.JSONBodyLimit))
+	formAPI := api.Group("", customMiddleware.BodyLimitMiddleware(cfg.Server.FormBodyLimit))
+
+	// Register user routes (multipart forms with profile pictures)
+	userHandler.RegisterRoutes(formAPI, customMiddleware.AuthMiddleware(authService))
+	// Register department routes
+	departmentHandler.RegisterRoutes(jsonAPI, customMiddleware.AuthMiddleware(authService))
+	// Register sector routes
+	sectorHandler.RegisterRoutes(jsonAPI, customMiddleware.AuthMiddleware(authService))
+	// Register admin routes
+	adminHandler.RegisterRoutes(jsonAPI, customMiddleware.AuthMiddleware(authService))
 	// Register file routes
-	fileHandler.RegisterRoutes(api, customMiddleware.AuthMiddleware(authService))
+	fileHandler.RegisterRoutes(jsonAPI, customMiddleware.AuthMiddleware(authService))
 	// Register storage routes (browse folders/documents)
-	storageHandler.RegisterRoutes(api, customMiddleware.AuthMiddleware(authService))
-	// Register upload routes (resumable upload with tusd)
-	uploadHandler.RegisterRoutes(api, customMiddleware.AuthMiddleware(authService))
+	storageHandler.RegisterRoutes(jsonAPI, customMiddleware.AuthMiddleware(authService))
+	// Register quota routes
+	quotaHandler.RegisterRoutes(jsonAPI, customMiddleware.AuthMiddleware(authService))
+	// Register share link routes
+	shareLinkHandler.RegisterRoutes(jsonAPI, customMiddleware.AuthMiddleware(authService))
+	// Register upload routes (resumable upload with tusd); tusd streams file chunks to storage,
+	// so only the JSON endpoints next to it get a body limit
+	uploadHandler.RegisterRoutes(api, customMiddleware.AuthMiddleware(authService), customMiddleware.BodyLimitMiddleware(cfg.Server.JSONBodyLimit))
 	// Register auth routes (with middleware for protected routes)
-	authHandler.RegisterRoutes(api, customMiddleware.AuthMiddleware(authService))
+	authHandler.RegisterRoutes(authAPI, customMiddleware.AuthMiddleware(authService))
 
 	// Start server
 	go func() {
@@ -203,17 +305,36 @@ func main() {
 
 	// Wait for interrupt signal to gracefully shutdown the server
 	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, os.Interrupt)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
 	<-quit
 
 	logger.Info("Shutting down server...")
 
-	// Gracefully shutdown the server with a timeout of 10 seconds
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	if err := e.Shutdown(ctx); err != nil {
-		logger.FatalWithErr("Server forced to shutdown", err)
+	// Long-lived SSE streams would otherwise hold the HTTP shutdown until it times out
+	e.Server.RegisterOnShutdown(uploadHandler.CloseEventStreams)
+
+	// Ordered shutdown: each step gets its own budget and runs after the previous one finished
+	seconds := func(n int64) time.Duration { return time.Duration(n) * time.Second }
+	failed := shutdown.NewSequence().
+		Add("http server", seconds(cfg.Shutdown.HTTPTimeout), e.Shutdown).
+		Add("background jobs", seconds(cfg.Shutdown.DrainTimeout), func(ctx context.Context) error {
+			stopJobs()
+			return uploadHandler.Drain(ctx)
+		}).
+		Add("minio", seconds(cfg.Shutdown.StorageTimeout), func(ctx context.Context) error {
+			uploadHandler.Close()
+			minioClient.Close()
+			return nil
+		}).
+		Add("postgres", seconds(cfg.Shutdown.DBTimeout), func(ctx context.Context) error {
+			pgClient.Close()
+			return nil
+		}).
+		Run()
+
+	if failed > 0 {
+		logger.Warnf("Server exited after %d shutdown step(s) failed", failed)
+		return
 	}
 
 	logger.Info("Server exited")
@@ -28,7 +28,16 @@ func main() {
 	defer cancel()
 
 	// Connect to PostgreSQL
-	pgClient, err := postgres.NewClient(ctx, cfg.Database.PostgresDSN)
+	if err := cfg.Database.Validate(); err != nil {
+		logger.FatalWithErr("Invalid database configuration", err)
+	}
+	pgClient, err := postgres.NewClient(ctx, cfg.Database.PostgresDSN, postgres.PoolConfig{
+		MaxConns:          int32(cfg.Database.MaxConns),
+		MinConns:          int32(cfg.Database.MinConns),
+		MaxConnLifetime:   time.Duration(cfg.Database.MaxConnLifetime) * time.Second,
+		MaxConnIdleTime:   time.Duration(cfg.Database.MaxConnIdleTime) * time.Second,
+		HealthCheckPeriod: time.Duration(cfg.Database.HealthCheckPeriod) * time.Second,
+	})
 	if err != nil {
 		logger.FatalWithErr("Failed to connect to PostgreSQL", err)
 	}
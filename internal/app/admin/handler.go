@@ -0,0 +1,58 @@
+package admin
+
+import (
+	"e-document-backend/internal/util"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Handler handles HTTP requests for administrative operations
+type Handler struct {
+	service Service
+}
+
+// NewHandler creates a new admin handler
+func NewHandler(service Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// RegisterRoutes registers admin routes
+func (h *Handler) RegisterRoutes(e *echo.Group, authMiddleware echo.MiddlewareFunc) {
+	admin := e.Group("/v1/admin", authMiddleware)
+	admin.POST("/db/maintenance", h.RunMaintenance)
+}
+
+// RunMaintenance godoc
+//
+//	@Summary		Run database maintenance
+//	@Description	Runs ANALYZE (and VACUUM when requested) on the hot tables and returns their size and dead tuple statistics. Directors only; requires DB_MAINTENANCE_ENABLED.
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			body	body		MaintenanceRequest	false	"Maintenance options"
+//	@Success		200		{object}	util.Response{data=MaintenanceResult}
+//	@Failure		401		{object}	util.ErrorEnvelope
+//	@Failure		403		{object}	util.ErrorEnvelope
+//	@Failure		500		{object}	util.ErrorEnvelope
+//	@Router			/v1/admin/db/maintenance [post]
+func (h *Handler) RunMaintenance(c echo.Context) error {
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	var req MaintenanceRequest
+	if err := c.Bind(&req); err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid request body", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	result, err := h.service.RunMaintenance(c.Request().Context(), userID, req)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	return util.OKResponse(c, "Database maintenance completed", result)
+}
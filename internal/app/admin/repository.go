@@ -0,0 +1,110 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository defines database maintenance operations
+type Repository interface {
+	Analyze(ctx context.Context, table string) error
+	Vacuum(ctx context.Context, table string) error
+	GetTableStats(ctx context.Context, tables []string) ([]*TableStats, error)
+}
+
+// TableStats reports size and dead tuple statistics for a table
+type TableStats struct {
+	Table           string     `json:"table"`
+	LiveTuples      int64      `json:"live_tuples"`
+	DeadTuples      int64      `json:"dead_tuples"`
+	DeadRatio       float64    `json:"dead_ratio"` // dead / (live + dead), a rough bloat indicator
+	TotalSizeBytes  int64      `json:"total_size_bytes"`
+	LastVacuum      *time.Time `json:"last_vacuum,omitempty"`
+	LastAutoVacuum  *time.Time `json:"last_autovacuum,omitempty"`
+	LastAnalyze     *time.Time `json:"last_analyze,omitempty"`
+	LastAutoAnalyze *time.Time `json:"last_autoanalyze,omitempty"`
+}
+
+// repository implements the Repository interface for PostgreSQL
+type repository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRepository creates a new admin repository
+func NewRepository(pool *pgxpool.Pool) Repository {
+	return &repository{
+		pool: pool,
+	}
+}
+
+// Analyze refreshes planner statistics for a table
+func (r *repository) Analyze(ctx context.Context, table string) error {
+	_, err := r.pool.Exec(ctx, "ANALYZE "+pgx.Identifier{table}.Sanitize())
+	if err != nil {
+		return fmt.Errorf("failed to analyze %s: %w", table, err)
+	}
+	return nil
+}
+
+// Vacuum reclaims dead tuples of a table and refreshes its statistics
+// VACUUM cannot run inside a transaction, so it is executed directly on the pool
+func (r *repository) Vacuum(ctx context.Context, table string) error {
+	_, err := r.pool.Exec(ctx, "VACUUM (ANALYZE) "+pgx.Identifier{table}.Sanitize())
+	if err != nil {
+		return fmt.Errorf("failed to vacuum %s: %w", table, err)
+	}
+	return nil
+}
+
+// GetTableStats retrieves statistics for the given tables of the current schema
+func (r *repository) GetTableStats(ctx context.Context, tables []string) ([]*TableStats, error) {
+	query := `
+		SELECT
+			relname,
+			n_live_tup,
+			n_dead_tup,
+			CASE WHEN n_live_tup + n_dead_tup = 0 THEN 0
+				ELSE n_dead_tup::float8 / (n_live_tup + n_dead_tup) END,
+			pg_total_relation_size(relid),
+			last_vacuum, last_autovacuum, last_analyze, last_autoanalyze
+		FROM pg_stat_user_tables
+		WHERE schemaname = current_schema() AND relname = ANY($1)
+		ORDER BY relname
+	`
+
+	rows, err := r.pool.Query(ctx, query, tables)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get table stats: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []*TableStats
+	for rows.Next() {
+		var s TableStats
+		err := rows.Scan(
+			&s.Table,
+			&s.LiveTuples,
+			&s.DeadTuples,
+			&s.DeadRatio,
+			&s.TotalSizeBytes,
+			&s.LastVacuum,
+			&s.LastAutoVacuum,
+			&s.LastAnalyze,
+			&s.LastAutoAnalyze,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan table stats: %w", err)
+		}
+		stats = append(stats, &s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating table stats: %w", err)
+	}
+
+	return stats, nil
+}
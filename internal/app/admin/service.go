@@ -0,0 +1,119 @@
+package admin
+
+import (
+	"context"
+	"e-document-backend/internal/app/user"
+	"e-document-backend/internal/domain"
+	"e-document-backend/internal/util"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	dbTimeout          = 5 * time.Second  // Database operation timeout
+	maintenanceTimeout = 10 * time.Minute // VACUUM on a large table can take a while
+)
+
+// maintainedTables are the hot tables that accumulate dead tuples from updates and versioning
+var maintainedTables = []string{
+	"users",
+	"folders",
+	"documents",
+	"document_attachments",
+	"share_links",
+}
+
+// MaintenanceRequest selects which maintenance to run
+type MaintenanceRequest struct {
+	Vacuum bool `json:"vacuum"` // also VACUUM the tables; ANALYZE always runs
+}
+
+// MaintenanceResult reports what ran and the resulting table statistics
+type MaintenanceResult struct {
+	Analyzed []string      `json:"analyzed"`
+	Vacuumed []string      `json:"vacuumed"`
+	Duration string        `json:"duration"`
+	Tables   []*TableStats `json:"tables"`
+}
+
+// Service defines business logic for administrative operations
+type Service interface {
+	RunMaintenance(ctx context.Context, requesterID string, req MaintenanceRequest) (*MaintenanceResult, error)
+}
+
+// service implements Service
+type service struct {
+	repo               Repository
+	userRepo           user.Repository
+	maintenanceEnabled bool
+}
+
+// NewService creates a new admin service
+func NewService(repo Repository, userRepo user.Repository, maintenanceEnabled bool) Service {
+	return &service{
+		repo:               repo,
+		userRepo:           userRepo,
+		maintenanceEnabled: maintenanceEnabled,
+	}
+}
+
+// RunMaintenance runs ANALYZE (and optionally VACUUM) on the hot tables and reports their stats
+// Only Directors may run it, and only when DB_MAINTENANCE_ENABLED is set
+func (s *service) RunMaintenance(ctx context.Context, requesterID string, req MaintenanceRequest) (*MaintenanceResult, error) {
+	if !s.maintenanceEnabled {
+		return nil, util.NewForbiddenError("database maintenance is disabled on this server")
+	}
+
+	dbCtx, cancel := context.WithTimeout(ctx, dbTimeout)
+	requester, err := s.userRepo.FindByID(dbCtx, requesterID)
+	cancel()
+	if err != nil {
+		return nil, util.NewUnauthorizedError("requesting user not found")
+	}
+
+	if requester.Role != domain.RoleDirector {
+		return nil, util.NewForbiddenError("only directors can run database maintenance")
+	}
+
+	maintenanceCtx, cancel := context.WithTimeout(ctx, maintenanceTimeout)
+	defer cancel()
+
+	start := time.Now()
+	result := &MaintenanceResult{
+		Analyzed: []string{},
+		Vacuumed: []string{},
+	}
+
+	for _, table := range maintainedTables {
+		if req.Vacuum {
+			if err := s.repo.Vacuum(maintenanceCtx, table); err != nil {
+				return nil, util.NewDatabaseError("vacuum "+table, err)
+			}
+			result.Vacuumed = append(result.Vacuumed, table)
+			result.Analyzed = append(result.Analyzed, table) // VACUUM (ANALYZE)
+			continue
+		}
+
+		if err := s.repo.Analyze(maintenanceCtx, table); err != nil {
+			return nil, util.NewDatabaseError("analyze "+table, err)
+		}
+		result.Analyzed = append(result.Analyzed, table)
+	}
+
+	result.Duration = time.Since(start).String()
+
+	stats, err := s.repo.GetTableStats(maintenanceCtx, maintainedTables)
+	if err != nil {
+		return nil, util.NewDatabaseError("fetch table stats", err)
+	}
+	result.Tables = stats
+
+	log.Info().
+		Str("requested_by", requesterID).
+		Bool("vacuum", req.Vacuum).
+		Str("duration", result.Duration).
+		Msg("Database maintenance completed")
+
+	return result, nil
+}
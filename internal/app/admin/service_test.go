@@ -0,0 +1,169 @@
+package admin
+
+import (
+	"context"
+	"e-document-backend/internal/app/user/usertest"
+	"e-document-backend/internal/domain"
+	"e-document-backend/internal/platform/postgres/pgtest"
+	"e-document-backend/internal/util"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// fakeRepository records the maintenance commands it was asked to run
+type fakeRepository struct {
+	analyzed []string
+	vacuumed []string
+}
+
+func (r *fakeRepository) Analyze(ctx context.Context, table string) error {
+	r.analyzed = append(r.analyzed, table)
+	return nil
+}
+
+func (r *fakeRepository) Vacuum(ctx context.Context, table string) error {
+	r.vacuumed = append(r.vacuumed, table)
+	return nil
+}
+
+func (r *fakeRepository) GetTableStats(ctx context.Context, tables []string) ([]*TableStats, error) {
+	stats := make([]*TableStats, len(tables))
+	for i, table := range tables {
+		stats[i] = &TableStats{Table: table}
+	}
+	return stats, nil
+}
+
+func TestRunMaintenanceAccess(t *testing.T) {
+	director := domain.User{ID: uuid.New(), Role: domain.RoleDirector}
+	manager := domain.User{ID: uuid.New(), Role: domain.RoleDepartmentManager}
+
+	tests := []struct {
+		name         string
+		enabled      bool
+		requester    uuid.UUID
+		vacuum       bool
+		wantStatus   int
+		wantVacuumed int
+	}{
+		{name: "director analyzes", enabled: true, requester: director.ID},
+		{name: "director vacuums", enabled: true, requester: director.ID, vacuum: true, wantVacuumed: len(maintainedTables)},
+		{name: "disabled on this server", enabled: false, requester: director.ID, wantStatus: 403},
+		{name: "manager is refused", enabled: true, requester: manager.ID, wantStatus: 403},
+		{name: "unknown requester", enabled: true, requester: uuid.New(), wantStatus: 401},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeRepository{}
+			svc := NewService(repo, usertest.NewRepository(director, manager), tt.enabled)
+
+			result, err := svc.RunMaintenance(context.Background(), tt.requester.String(), MaintenanceRequest{Vacuum: tt.vacuum})
+			if tt.wantStatus != 0 {
+				assertStatus(t, err, tt.wantStatus)
+				if len(repo.analyzed)+len(repo.vacuumed) != 0 {
+					t.Fatal("maintenance ran for a refused request")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RunMaintenance() error = %v", err)
+			}
+			if len(result.Analyzed) != len(maintainedTables) || len(result.Vacuumed) != tt.wantVacuumed {
+				t.Fatalf("RunMaintenance() analyzed %v, vacuumed %v", result.Analyzed, result.Vacuumed)
+			}
+			if len(repo.vacuumed) != tt.wantVacuumed || len(repo.analyzed)+len(repo.vacuumed) != len(maintainedTables) {
+				t.Fatalf("repository ran ANALYZE on %v and VACUUM on %v", repo.analyzed, repo.vacuumed)
+			}
+		})
+	}
+}
+
+// seedTables fills the hot tables and deletes part of them so there are dead tuples to report
+func seedTables(t *testing.T, pool *pgxpool.Pool) {
+	t.Helper()
+	ctx := context.Background()
+	statements := []string{
+		`INSERT INTO users (username, email, phone, first_name, last_name, password, role)
+		 SELECT 'user' || i, 'user' || i || '@example.com', '+66812345678', 'Somchai', 'Jaidee', 'hash', 'Employee'
+		 FROM generate_series(1, 50) AS i`,
+		`INSERT INTO folders (name, path, is_root_folder, owner_id)
+		 SELECT 'folder' || row_number() OVER (), '/folder' || row_number() OVER (), true, id FROM users`,
+		`INSERT INTO documents (title, folder_id, registrant_id)
+		 SELECT 'document', id, owner_id FROM folders`,
+		`DELETE FROM documents WHERE id IN (SELECT id FROM documents LIMIT 20)`,
+	}
+	for _, statement := range statements {
+		if _, err := pool.Exec(ctx, statement); err != nil {
+			t.Fatalf("failed to seed tables: %v", err)
+		}
+	}
+}
+
+func TestRunMaintenanceOnDatabase(t *testing.T) {
+	pool := pgtest.NewPool(t)
+	seedTables(t, pool)
+
+	director := domain.User{ID: uuid.New(), Role: domain.RoleDirector}
+	svc := NewService(NewRepository(pool), usertest.NewRepository(director), true)
+
+	for _, vacuum := range []bool{false, true} {
+		result, err := svc.RunMaintenance(context.Background(), director.ID.String(), MaintenanceRequest{Vacuum: vacuum})
+		if err != nil {
+			t.Fatalf("RunMaintenance(vacuum=%v) error = %v", vacuum, err)
+		}
+
+		tables := make([]string, 0, len(result.Tables))
+		for _, stats := range result.Tables {
+			tables = append(tables, stats.Table)
+			if stats.TotalSizeBytes <= 0 {
+				t.Errorf("%s reports size %d", stats.Table, stats.TotalSizeBytes)
+			}
+			if stats.DeadRatio < 0 || stats.DeadRatio > 1 {
+				t.Errorf("%s reports dead ratio %v", stats.Table, stats.DeadRatio)
+			}
+		}
+		want := append([]string(nil), maintainedTables...)
+		sort.Strings(want)
+		if len(tables) != len(want) {
+			t.Fatalf("stats cover %v, want %v", tables, want)
+		}
+		for i := range want {
+			if tables[i] != want[i] {
+				t.Fatalf("stats cover %v, want %v", tables, want)
+			}
+		}
+	}
+
+	// Statistics reach pg_stat_user_tables asynchronously
+	repo := NewRepository(pool)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		stats, err := repo.GetTableStats(context.Background(), []string{"documents"})
+		if err != nil {
+			t.Fatalf("GetTableStats() error = %v", err)
+		}
+		if len(stats) == 1 && stats[0].LastAnalyze != nil && stats[0].LastVacuum != nil && stats[0].LiveTuples == 30 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("documents stats = %+v, want 30 live tuples, analyzed and vacuumed", stats)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func assertStatus(t *testing.T, err error, status int) {
+	t.Helper()
+	customErr, ok := err.(*util.CustomError)
+	if !ok {
+		t.Fatalf("error = %v, want a CustomError with status %d", err, status)
+	}
+	if customErr.StatusCode != status {
+		t.Fatalf("status = %d, want %d (%s)", customErr.StatusCode, status, customErr.Detail)
+	}
+}
@@ -28,23 +28,34 @@ func (h *Handler) RegisterRoutes(e *echo.Group, authMiddleware echo.MiddlewareFu
 	auth.POST("/login", h.Login)
 	auth.POST("/refresh", h.RefreshToken)
 	auth.POST("/logout", h.Logout)
+	auth.GET("/verify-email", h.VerifyEmail)
+	auth.POST("/verify-email/resend", h.ResendEmailVerification)
 
 	// Protected routes (requires authentication)
 	auth.GET("/profile", h.GetProfile, authMiddleware)
+	auth.POST("/logout-all", h.LogoutAll, authMiddleware)
+	auth.POST("/2fa/enable", h.EnableTwoFactor, authMiddleware)
+	auth.POST("/2fa/verify", h.VerifyTwoFactor, authMiddleware)
+
+	// Support impersonation is an admin feature but signs tokens, so it lives with the auth routes
+	impersonate := e.Group("/v1/admin/impersonate", authMiddleware)
+	impersonate.POST("/stop", h.StopImpersonation)
+	impersonate.POST("/sessions/:id/stop", h.StopImpersonationSession)
+	impersonate.POST("/:userId", h.Impersonate)
 }
 
 // Login godoc
 //
 //	@Summary		User login
-//	@Description	Authenticate user with username/email and password. Always sets httpOnly cookies. Returns tokens in response body ONLY for mobile clients (when X-Client-Type: mobile header is present)
+//	@Description	Authenticate user with username/email and password. Always sets httpOnly cookies. Returns tokens and their lifetimes (expires_in, refresh_expires_in in seconds) in response body ONLY for mobile clients (when X-Client-Type: mobile header is present); web clients receive the user
 //	@Tags			Auth
 //	@Accept			json
 //	@Produce		json
 //	@Param			X-Client-Type	header	string				false	"Client type (use 'mobile' for mobile apps)"
 //	@Param			body			body	domain.LoginRequest	true	"Login credentials"
 //	@Success		200				{object}	util.Response{data=domain.AuthResponse}
-//	@Failure		400				{object}	util.Response
-//	@Failure		401				{object}	util.Response
+//	@Failure		400				{object}	util.ErrorEnvelope
+//	@Failure		401				{object}	util.ErrorEnvelope	"Invalid credentials, or 2FA_REQUIRED / INVALID_2FA_CODE when two-factor is enabled"
 //	@Router			/v1/auth/login [post]
 //
 // NOTE - Login handles user login requests
@@ -66,50 +77,38 @@ func (h *Handler) Login(c echo.Context) error {
 	}
 
 	// Set cookies for web browsers
-	h.setCookies(c, result.AccessToken, result.RefreshToken)
+	h.setCookies(c, result)
 
-	// Prepare response based on client type
-	response := &domain.AuthResponse{
-		User: result.Response.User,
-	}
-
-	// Only include tokens in response body for mobile clients
+	// Only include tokens and their lifetimes in the response body for mobile clients
 	// Mobile apps should send header: X-Client-Type: mobile
-	clientType := c.Request().Header.Get("X-Client-Type")
-	if clientType == "mobile" {
+	// Web clients keep receiving the user; their token lifetimes are carried by the cookies
+	if isMobileClient(c) {
+		response := *result.Response
 		response.AccessToken = result.AccessToken
 		response.RefreshToken = result.RefreshToken
+		return util.OKResponse(c, "Login successful", response)
 	}
 
-	return util.OKResponse(c, "Login successful", response.User)
+	return util.OKResponse(c, "Login successful", result.Response.User)
 }
 
 // RefreshToken godoc
 //
 //	@Summary		Refresh access token
-//	@Description	Get new access and refresh tokens using a valid refresh token. Accepts token from body or cookie. Always sets httpOnly cookies. Returns tokens in response body ONLY for mobile clients (when X-Client-Type: mobile header is present)
+//	@Description	Get new access and refresh tokens using a valid refresh token. Mobile clients (X-Client-Type: mobile) send the token in the body, web clients use the refreshToken cookie; each falls back to the other source. Returns MISSING_TOKEN when no token is provided and INVALID_TOKEN when it is rejected. Always sets httpOnly cookies. Returns tokens and their lifetimes (expires_in, refresh_expires_in in seconds) in response body ONLY for mobile clients (when X-Client-Type: mobile header is present); web clients receive the user
 //	@Tags			Auth
 //	@Accept			json
 //	@Produce		json
 //	@Param			X-Client-Type	header	string						false	"Client type (use 'mobile' for mobile apps)"
 //	@Param			body			body	domain.RefreshTokenRequest	false	"Refresh token (optional if using cookie)"
 //	@Success		200				{object}	util.Response{data=domain.AuthResponse}
-//	@Failure		400				{object}	util.Response
-//	@Failure		401				{object}	util.Response
+//	@Failure		400				{object}	util.ErrorEnvelope	"Malformed request body"
+//	@Failure		401				{object}	util.ErrorEnvelope	"MISSING_TOKEN or INVALID_TOKEN"
 //	@Router			/v1/auth/refresh [post]
 func (h *Handler) RefreshToken(c echo.Context) error {
-	// Try to get refresh token from cookie first, then from body
-	refreshToken := h.getRefreshTokenFromCookie(c)
-
-	if refreshToken == "" {
-		var req domain.RefreshTokenRequest
-		if err := c.Bind(&req); err == nil && req.RefreshToken != "" {
-			refreshToken = req.RefreshToken
-		}
-	}
-
-	if refreshToken == "" {
-		return util.HandleError(c, util.ErrorResponse("Validation failed", util.MISSING_REQUIRED_FIELD, 400, "Refresh token is required"))
+	refreshToken, err := h.getRefreshToken(c)
+	if err != nil {
+		return util.HandleError(c, err)
 	}
 
 	result, err := h.service.RefreshToken(c.Request().Context(), refreshToken)
@@ -118,22 +117,19 @@ func (h *Handler) RefreshToken(c echo.Context) error {
 	}
 
 	// Set new cookies for web browsers
-	h.setCookies(c, result.AccessToken, result.RefreshToken)
+	h.setCookies(c, result)
 
-	// Prepare response based on client type
-	response := &domain.AuthResponse{
-		User: result.Response.User,
-	}
-
-	// Only include tokens in response body for mobile clients
+	// Only include tokens and their lifetimes in the response body for mobile clients
 	// Mobile apps should send header: X-Client-Type: mobile
-	clientType := c.Request().Header.Get("X-Client-Type")
-	if clientType == "mobile" {
+	// Web clients keep receiving the user; their token lifetimes are carried by the cookies
+	if isMobileClient(c) {
+		response := *result.Response
 		response.AccessToken = result.AccessToken
 		response.RefreshToken = result.RefreshToken
+		return util.OKResponse(c, "Token refreshed successfully", response)
 	}
 
-	return util.OKResponse(c, "Token refreshed successfully", response.User)
+	return util.OKResponse(c, "Token refreshed successfully", result.Response.User)
 }
 
 // GetProfile godoc
@@ -145,14 +141,14 @@ func (h *Handler) RefreshToken(c echo.Context) error {
 //	@Produce		json
 //	@Security		BearerAuth
 //	@Success		200	{object}	util.Response{data=domain.UserResponse}
-//	@Failure		401	{object}	util.Response
-//	@Failure		404	{object}	util.Response
+//	@Failure		401	{object}	util.ErrorEnvelope
+//	@Failure		404	{object}	util.ErrorEnvelope
 //	@Router			/v1/auth/profile [get]
 func (h *Handler) GetProfile(c echo.Context) error {
 	// Get user ID from context (set by auth middleware)
-	userID, ok := c.Get("user_id").(string)
-	if !ok {
-		return util.HandleError(c, util.ErrorResponse("Unauthorized", util.UNAUTHORIZED, 401, "user not authenticated"))
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
 	}
 
 	result, err := h.service.GetProfile(c.Request().Context(), userID)
@@ -179,28 +175,183 @@ func (h *Handler) Logout(c echo.Context) error {
 	return util.OKResponse(c, "Logged out successfully", nil)
 }
 
+// LogoutAll godoc
+//
+//	@Summary		Logout from all devices
+//	@Description	Revoke every refresh token of the authenticated user and clear authentication cookies. Access tokens issued before are rejected on their next request too, so every device must log in again
+//	@Tags			Auth
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	util.Response
+//	@Failure		401	{object}	util.ErrorEnvelope
+//	@Failure		500	{object}	util.ErrorEnvelope
+//	@Router			/v1/auth/logout-all [post]
+func (h *Handler) LogoutAll(c echo.Context) error {
+	// Get user ID from context (set by auth middleware)
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+	if err := refuseWhileImpersonating(c); err != nil {
+		return util.HandleError(c, err)
+	}
+
+	if err := h.service.LogoutAll(c.Request().Context(), userID); err != nil {
+		return util.HandleError(c, err)
+	}
+
+	// Clear cookies
+	h.clearCookies(c)
+
+	return util.OKResponse(c, "Logged out from all devices successfully", nil)
+}
+
+// EnableTwoFactor godoc
+//
+//	@Summary		Start two-factor setup
+//	@Description	Generate a TOTP secret and otpauth URL for an authenticator app. Two-factor is enabled once a code is confirmed with /v1/auth/2fa/verify
+//	@Tags			Auth
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	util.Response{data=domain.EnableTwoFactorResponse}
+//	@Failure		400	{object}	util.ErrorEnvelope
+//	@Failure		401	{object}	util.ErrorEnvelope
+//	@Failure		500	{object}	util.ErrorEnvelope
+//	@Router			/v1/auth/2fa/enable [post]
+func (h *Handler) EnableTwoFactor(c echo.Context) error {
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	if err := refuseWhileImpersonating(c); err != nil {
+		return util.HandleError(c, err)
+	}
+
+	result, err := h.service.EnableTwoFactor(c.Request().Context(), userID)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	return util.OKResponse(c, "Scan the secret with your authenticator app and confirm a code", result)
+}
+
+// VerifyTwoFactor godoc
+//
+//	@Summary		Confirm two-factor setup
+//	@Description	Confirm a code from the authenticator app to enable two-factor authentication. Returns one-time backup codes that are shown only once
+//	@Tags			Auth
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			body	body		domain.VerifyTwoFactorRequest	true	"Code from the authenticator app"
+//	@Success		200		{object}	util.Response{data=domain.VerifyTwoFactorResponse}
+//	@Failure		400		{object}	util.ErrorEnvelope
+//	@Failure		401		{object}	util.ErrorEnvelope
+//	@Router			/v1/auth/2fa/verify [post]
+func (h *Handler) VerifyTwoFactor(c echo.Context) error {
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	if err := refuseWhileImpersonating(c); err != nil {
+		return util.HandleError(c, err)
+	}
+
+	var req domain.VerifyTwoFactorRequest
+	if err := c.Bind(&req); err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid request body", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	if err := util.ValidateStruct(&req); err != nil {
+		return util.HandleError(c, err)
+	}
+
+	result, err := h.service.VerifyTwoFactor(c.Request().Context(), userID, req.Code)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	return util.OKResponse(c, "Two-factor authentication enabled", result)
+}
+
+// VerifyEmail godoc
+//
+//	@Summary		Verify email address
+//	@Description	Confirm a user's email address with the verification token issued when the account was created
+//	@Tags			Auth
+//	@Produce		json
+//	@Param			token	query		string	true	"Verification token"
+//	@Success		200		{object}	util.Response
+//	@Failure		400		{object}	util.ErrorEnvelope
+//	@Failure		404		{object}	util.ErrorEnvelope
+//	@Router			/v1/auth/verify-email [get]
+func (h *Handler) VerifyEmail(c echo.Context) error {
+	token := c.QueryParam("token")
+	if token == "" {
+		return util.HandleError(c, util.ErrorResponse("Validation failed", util.MISSING_REQUIRED_FIELD, 400, "Verification token is required"))
+	}
+
+	if err := h.service.VerifyEmail(c.Request().Context(), token); err != nil {
+		return util.HandleError(c, err)
+	}
+
+	return util.OKResponse(c, "Email verified successfully", nil)
+}
+
+// ResendEmailVerification godoc
+//
+//	@Summary		Resend email verification
+//	@Description	Send a new verification token to an unverified account's email address. The response is the same whether or not the address belongs to an unverified account
+//	@Tags			Auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		domain.ResendVerificationRequest	true	"Email address to verify"
+//	@Success		200		{object}	util.Response
+//	@Failure		400		{object}	util.ErrorEnvelope
+//	@Router			/v1/auth/verify-email/resend [post]
+func (h *Handler) ResendEmailVerification(c echo.Context) error {
+	var req domain.ResendVerificationRequest
+	if err := c.Bind(&req); err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid request body", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	if err := util.ValidateStruct(&req); err != nil {
+		return util.HandleError(c, err)
+	}
+
+	if err := h.service.ResendEmailVerification(c.Request().Context(), req.Email); err != nil {
+		return util.HandleError(c, err)
+	}
+
+	return util.OKResponse(c, "If the account exists and is not verified yet, a verification email has been sent", nil)
+}
+
 // setCookies sets access and refresh tokens as HTTP-only cookies
-func (h *Handler) setCookies(c echo.Context, accessToken, refreshToken string) {
-	// Set access token cookie (1 hour)
+// Cookie lifetimes match the token expiries from configuration
+func (h *Handler) setCookies(c echo.Context, result *AuthResult) {
+	// Set access token cookie
 	accessCookie := &http.Cookie{
 		Name:     "accessToken",
-		Value:    accessToken,
+		Value:    result.AccessToken,
 		Path:     "/",
 		HttpOnly: false,
 		Secure:   false, // Set to true in production with HTTPS
 		SameSite: http.SameSiteLaxMode,
-		MaxAge:   3600, // 1 hour
+		MaxAge:   int(result.Response.ExpiresIn),
 	}
 
-	// Set refresh token cookie (7 days)
+	// Set refresh token cookie
 	refreshCookie := &http.Cookie{
 		Name:     "refreshToken",
-		Value:    refreshToken,
+		Value:    result.RefreshToken,
 		Path:     "/",
 		HttpOnly: true,
 		Secure:   false, // Set to true in production with HTTPS
 		SameSite: http.SameSiteLaxMode,
-		MaxAge:   604800, // 7 days
+		MaxAge:   int(result.Response.RefreshExpiresIn),
 	}
 
 	c.SetCookie(accessCookie)
@@ -229,6 +380,45 @@ func (h *Handler) clearCookies(c echo.Context) {
 	c.SetCookie(refreshCookie)
 }
 
+// isMobileClient reports whether the request comes from a mobile app (X-Client-Type: mobile)
+// Mobile apps keep tokens from the response body instead of cookies
+func isMobileClient(c echo.Context) bool {
+	return c.Request().Header.Get("X-Client-Type") == "mobile"
+}
+
+// getRefreshToken reads the refresh token from where the client keeps it
+// Mobile clients send it in the body and usually have no cookie; web clients rely on the cookie
+// The body is bound at most once, and the other source is only a fallback
+func (h *Handler) getRefreshToken(c echo.Context) (string, error) {
+	var refreshToken string
+
+	if isMobileClient(c) {
+		var req domain.RefreshTokenRequest
+		if err := c.Bind(&req); err != nil {
+			return "", util.ErrorResponse("Invalid request body", util.INVALID_INPUT, 400, err.Error())
+		}
+		refreshToken = req.RefreshToken
+		if refreshToken == "" {
+			refreshToken = h.getRefreshTokenFromCookie(c)
+		}
+	} else {
+		refreshToken = h.getRefreshTokenFromCookie(c)
+		if refreshToken == "" {
+			// Web requests often carry no body at all, so a bind failure just means no token
+			var req domain.RefreshTokenRequest
+			if err := c.Bind(&req); err == nil {
+				refreshToken = req.RefreshToken
+			}
+		}
+	}
+
+	if refreshToken == "" {
+		return "", util.ErrorResponse("Refresh token not provided", util.MISSING_TOKEN, 401, "no refresh token was found in the request body or cookie")
+	}
+
+	return refreshToken, nil
+}
+
 // getRefreshTokenFromCookie extracts refresh token from cookie
 func (h *Handler) getRefreshTokenFromCookie(c echo.Context) string {
 	cookie, err := c.Cookie("refreshToken")
@@ -0,0 +1,426 @@
+package auth
+
+import (
+	"context"
+	"e-document-backend/internal/domain"
+	"e-document-backend/internal/platform/postgres"
+	"e-document-backend/internal/util"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// ImpersonationRepository stores the audit trail of impersonation sessions
+type ImpersonationRepository interface {
+	CreateSessionWithinLimit(ctx context.Context, session *domain.ImpersonationSession, since time.Time, limit int64) (bool, error)
+	GetSession(ctx context.Context, sessionID string) (*domain.ImpersonationSession, error)
+	EndSession(ctx context.Context, sessionID string) (bool, error)
+}
+
+// impersonationRepository implements ImpersonationRepository for PostgreSQL
+type impersonationRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewImpersonationRepository creates a new PostgreSQL impersonation repository
+func NewImpersonationRepository(pool *pgxpool.Pool) ImpersonationRepository {
+	return &impersonationRepository{
+		pool: pool,
+	}
+}
+
+// CreateSessionWithinLimit records the start of an impersonation unless the director already
+// started limit sessions since the given time; it reports whether the session was created.
+// The director's sessions are counted and inserted under a transaction-scoped advisory lock, so
+// concurrent requests cannot both pass the count
+func (r *impersonationRepository) CreateSessionWithinLimit(ctx context.Context, session *domain.ImpersonationSession, since time.Time, limit int64) (bool, error) {
+	created := false
+	err := postgres.WithTx(ctx, r.pool, func(tx pgx.Tx) error {
+		lockQuery := `SELECT pg_advisory_xact_lock(hashtext($1))`
+		if _, err := tx.Exec(ctx, lockQuery, "impersonation-director:"+session.DirectorID.String()); err != nil {
+			return fmt.Errorf("failed to lock director's impersonation sessions: %w", err)
+		}
+
+		countQuery := `SELECT COUNT(*) FROM impersonation_sessions WHERE director_id = $1 AND started_at >= $2`
+		var count int64
+		if err := tx.QueryRow(ctx, countQuery, session.DirectorID, since).Scan(&count); err != nil {
+			return fmt.Errorf("failed to count impersonation sessions: %w", err)
+		}
+		if count >= limit {
+			return nil
+		}
+
+		insertQuery := `
+			INSERT INTO impersonation_sessions (director_id, target_user_id, reason, ip_address, user_agent, started_at, expires_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			RETURNING id
+		`
+		err := tx.QueryRow(ctx, insertQuery,
+			session.DirectorID,
+			session.TargetUserID,
+			session.Reason,
+			session.IPAddress,
+			session.UserAgent,
+			session.StartedAt,
+			session.ExpiresAt,
+		).Scan(&session.ID)
+		if err != nil {
+			return fmt.Errorf("failed to create impersonation session: %w", err)
+		}
+
+		created = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return created, nil
+}
+
+// GetSession retrieves an impersonation session by its ID
+func (r *impersonationRepository) GetSession(ctx context.Context, sessionID string) (*domain.ImpersonationSession, error) {
+	query := `
+		SELECT id, director_id, target_user_id, reason, ip_address, user_agent, started_at, expires_at, ended_at
+		FROM impersonation_sessions
+		WHERE id = $1
+	`
+
+	var session domain.ImpersonationSession
+	err := r.pool.QueryRow(ctx, query, sessionID).Scan(
+		&session.ID,
+		&session.DirectorID,
+		&session.TargetUserID,
+		&session.Reason,
+		&session.IPAddress,
+		&session.UserAgent,
+		&session.StartedAt,
+		&session.ExpiresAt,
+		&session.EndedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("impersonation session not found")
+		}
+		return nil, fmt.Errorf("failed to get impersonation session: %w", err)
+	}
+
+	return &session, nil
+}
+
+// EndSession marks a session as ended; it reports whether the session was still running
+func (r *impersonationRepository) EndSession(ctx context.Context, sessionID string) (bool, error) {
+	query := `UPDATE impersonation_sessions SET ended_at = NOW() WHERE id = $1 AND ended_at IS NULL`
+
+	result, err := r.pool.Exec(ctx, query, sessionID)
+	if err != nil {
+		return false, fmt.Errorf("failed to end impersonation session: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+// ImpersonationOrigin identifies where an impersonation was requested from, for the audit trail
+type ImpersonationOrigin struct {
+	IPAddress string
+	UserAgent string
+}
+
+// Impersonate issues a short-lived access token acting as the target user on behalf of a director
+// Directors cannot be impersonated, and each director may only start a few sessions an hour
+func (s *service) Impersonate(ctx context.Context, directorID, targetUserID string, req domain.ImpersonateRequest, origin ImpersonationOrigin) (*domain.ImpersonationResponse, error) {
+	if !s.cfg.JWT.ImpersonationEnabled {
+		return nil, util.NewForbiddenError("impersonation is disabled on this server")
+	}
+
+	director, err := s.userRepo.FindByID(ctx, directorID)
+	if err != nil {
+		return nil, util.NewUnauthorizedError("requesting user not found")
+	}
+	if director.Role != domain.RoleDirector || director.Disabled {
+		return nil, util.NewForbiddenError("only directors can impersonate users")
+	}
+
+	if targetUserID == directorID {
+		return nil, util.NewInvalidInputError("userId", "cannot impersonate yourself")
+	}
+	target, err := s.userRepo.FindByID(ctx, targetUserID)
+	if err != nil {
+		return nil, util.NewNotFoundError("User", targetUserID)
+	}
+	if target.Role == domain.RoleDirector {
+		return nil, util.NewForbiddenError("directors cannot be impersonated")
+	}
+	if target.Disabled {
+		return nil, util.NewForbiddenError("disabled users cannot be impersonated")
+	}
+
+	now := time.Now()
+	session := &domain.ImpersonationSession{
+		DirectorID:   director.ID,
+		TargetUserID: target.ID,
+		Reason:       req.Reason,
+		IPAddress:    origin.IPAddress,
+		UserAgent:    origin.UserAgent,
+		StartedAt:    now,
+		ExpiresAt:    now.Add(time.Duration(s.cfg.JWT.ImpersonationExpiry) * time.Second),
+	}
+	created, err := s.impersonations.CreateSessionWithinLimit(ctx, session, now.Add(-time.Hour), s.cfg.JWT.ImpersonationMaxPerHour)
+	if err != nil {
+		return nil, util.NewDatabaseError("create impersonation session", err)
+	}
+	if !created {
+		log.Warn().
+			Str("event", "impersonation_rate_limited").
+			Str("director_id", directorID).
+			Str("target_user_id", targetUserID).
+			Msg("Impersonation refused, hourly limit reached")
+		return nil, util.NewRateLimitedError("impersonation limit reached, try again later")
+	}
+
+	claims := s.buildUserClaims(target, "access", s.cfg.JWT.ImpersonationExpiry)
+	claims["exp"] = session.ExpiresAt.Unix()
+	claims["impersonated_by"] = director.ID.String()
+	claims["imp_sid"] = session.ID.String()
+
+	accessToken, err := s.keys.access.sign(claims)
+	if err != nil {
+		return nil, util.NewInternalError("failed to generate impersonation token: " + err.Error())
+	}
+
+	log.Warn().
+		Str("event", "impersonation_started").
+		Str("session_id", session.ID.String()).
+		Str("director_id", directorID).
+		Str("target_user_id", targetUserID).
+		Str("reason", req.Reason).
+		Str("ip", origin.IPAddress).
+		Time("expires_at", session.ExpiresAt).
+		Msg("Director started impersonating a user")
+
+	return &domain.ImpersonationResponse{
+		AccessToken: accessToken,
+		ExpiresIn:   s.cfg.JWT.ImpersonationExpiry,
+		Session:     session,
+		User:        target.ToResponse(),
+	}, nil
+}
+
+// StopImpersonation ends the impersonation session behind a token so it stops working at once
+func (s *service) StopImpersonation(ctx context.Context, claims *domain.TokenClaims) error {
+	if claims.ImpersonationID == "" {
+		return util.NewInvalidInputError("token", "is not an impersonation token")
+	}
+
+	ended, err := s.impersonations.EndSession(ctx, claims.ImpersonationID)
+	if err != nil {
+		return util.NewDatabaseError("end impersonation session", err)
+	}
+
+	log.Warn().
+		Str("event", "impersonation_stopped").
+		Str("session_id", claims.ImpersonationID).
+		Str("director_id", claims.ImpersonatedBy).
+		Str("target_user_id", claims.UserID).
+		Bool("was_active", ended).
+		Msg("Director stopped impersonating a user")
+
+	return nil
+}
+
+// EndImpersonationSession lets a director end any impersonation session by its ID, for example
+// one whose token was leaked or whose director is unavailable. Ending an ended session is a no-op
+func (s *service) EndImpersonationSession(ctx context.Context, requesterID, sessionID string) (*domain.ImpersonationSession, error) {
+	requester, err := s.userRepo.FindByID(ctx, requesterID)
+	if err != nil {
+		return nil, util.NewUnauthorizedError("requesting user not found")
+	}
+	if requester.Role != domain.RoleDirector || requester.Disabled {
+		return nil, util.NewForbiddenError("only directors can stop impersonation sessions")
+	}
+
+	if _, err := s.impersonations.GetSession(ctx, sessionID); err != nil {
+		return nil, util.NewNotFoundError("Impersonation session", sessionID)
+	}
+
+	ended, err := s.impersonations.EndSession(ctx, sessionID)
+	if err != nil {
+		return nil, util.NewDatabaseError("end impersonation session", err)
+	}
+
+	session, err := s.impersonations.GetSession(ctx, sessionID)
+	if err != nil {
+		return nil, util.NewDatabaseError("get impersonation session", err)
+	}
+
+	log.Warn().
+		Str("event", "impersonation_stopped").
+		Str("session_id", sessionID).
+		Str("director_id", session.DirectorID.String()).
+		Str("target_user_id", session.TargetUserID.String()).
+		Str("stopped_by", requesterID).
+		Bool("was_active", ended).
+		Msg("Director stopped an impersonation session")
+
+	return session, nil
+}
+
+// verifyImpersonation checks an impersonation token against its session on every request, so
+// stopping the session, disabling the feature, demoting the director or disabling the
+// impersonated user ends it immediately
+func (s *service) verifyImpersonation(ctx context.Context, claims *domain.TokenClaims) error {
+	ended := util.ErrorResponse("Unauthorized", util.INVALID_TOKEN, 401, "impersonation session has ended")
+	if !s.cfg.JWT.ImpersonationEnabled {
+		return ended
+	}
+
+	session, err := s.impersonations.GetSession(ctx, claims.ImpersonationID)
+	if err != nil {
+		return ended
+	}
+	if session.EndedAt != nil || time.Now().After(session.ExpiresAt) ||
+		session.DirectorID.String() != claims.ImpersonatedBy || session.TargetUserID.String() != claims.UserID {
+		return ended
+	}
+
+	director, err := s.userRepo.FindByID(ctx, claims.ImpersonatedBy)
+	if err != nil || director.Role != domain.RoleDirector || director.Disabled {
+		return ended
+	}
+
+	// The target is loaded uncached: a user who could not be impersonated now loses the session
+	target, err := s.userRepo.FindByID(ctx, claims.UserID)
+	if err != nil || target.Role == domain.RoleDirector || target.Disabled {
+		return ended
+	}
+
+	return nil
+}
+
+// refuseWhileImpersonating blocks changes to the impersonated user's credentials and sessions
+// Support may look at what the user sees but must not lock them out
+func refuseWhileImpersonating(c echo.Context) error {
+	if _, _, impersonating := util.GetImpersonation(c); impersonating {
+		return util.NewForbiddenError("not allowed while impersonating a user")
+	}
+	return nil
+}
+
+// Impersonate godoc
+//
+//	@Summary		Impersonate a user
+//	@Description	Directors only; requires JWT_IMPERSONATION_ENABLED. Returns a short-lived access token (JWT_IMPERSONATION_EXPIRY) acting as the user, carrying an impersonated_by claim. No refresh token or cookie is issued, so send it as a Bearer token. Directors and disabled users cannot be impersonated. Every session is recorded with its reason and each request made with the token is logged; directors may start JWT_IMPERSONATION_MAX_PER_HOUR sessions an hour
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			userId	path		string						true	"User ID"
+//	@Param			body	body		domain.ImpersonateRequest	true	"Reason for the impersonation"
+//	@Success		201		{object}	util.Response{data=domain.ImpersonationResponse}
+//	@Failure		400		{object}	util.ErrorEnvelope
+//	@Failure		401		{object}	util.ErrorEnvelope
+//	@Failure		403		{object}	util.ErrorEnvelope
+//	@Failure		404		{object}	util.ErrorEnvelope
+//	@Failure		429		{object}	util.ErrorEnvelope
+//	@Router			/v1/admin/impersonate/{userId} [post]
+func (h *Handler) Impersonate(c echo.Context) error {
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	// An impersonation token cannot start another impersonation
+	if _, _, impersonating := util.GetImpersonation(c); impersonating {
+		return util.HandleError(c, util.NewForbiddenError("stop the current impersonation first"))
+	}
+
+	targetUserID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid user ID", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	var req domain.ImpersonateRequest
+	if err := c.Bind(&req); err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid request body", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	if err := util.ValidateStruct(&req); err != nil {
+		return util.HandleError(c, err)
+	}
+
+	origin := ImpersonationOrigin{IPAddress: c.RealIP(), UserAgent: c.Request().UserAgent()}
+	result, err := h.service.Impersonate(c.Request().Context(), userID, targetUserID.String(), req, origin)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	return util.CreatedResponse(c, "Impersonation started", result)
+}
+
+// StopImpersonation godoc
+//
+//	@Summary		Stop impersonating
+//	@Description	Ends the impersonation session of the token sending the request; the token is rejected from then on
+//	@Tags			Admin
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	util.Response
+//	@Failure		400	{object}	util.ErrorEnvelope
+//	@Failure		401	{object}	util.ErrorEnvelope
+//	@Router			/v1/admin/impersonate/stop [post]
+func (h *Handler) StopImpersonation(c echo.Context) error {
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	directorID, sessionID, impersonating := util.GetImpersonation(c)
+	if !impersonating {
+		return util.HandleError(c, util.NewInvalidInputError("token", "is not an impersonation token"))
+	}
+
+	claims := &domain.TokenClaims{UserID: userID, ImpersonatedBy: directorID, ImpersonationID: sessionID}
+	if err := h.service.StopImpersonation(c.Request().Context(), claims); err != nil {
+		return util.HandleError(c, err)
+	}
+
+	return util.OKResponse(c, "Impersonation stopped", nil)
+}
+
+// StopImpersonationSession godoc
+//
+//	@Summary		Stop an impersonation session
+//	@Description	Directors only. Ends any impersonation session by its ID, so its token is rejected from then on; ending a session that already ended changes nothing
+//	@Tags			Admin
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"Impersonation session ID"
+//	@Success		200	{object}	util.Response{data=domain.ImpersonationSession}
+//	@Failure		400	{object}	util.ErrorEnvelope
+//	@Failure		401	{object}	util.ErrorEnvelope
+//	@Failure		403	{object}	util.ErrorEnvelope
+//	@Failure		404	{object}	util.ErrorEnvelope
+//	@Router			/v1/admin/impersonate/sessions/{id}/stop [post]
+func (h *Handler) StopImpersonationSession(c echo.Context) error {
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid session ID", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	session, err := h.service.EndImpersonationSession(c.Request().Context(), userID, sessionID.String())
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	return util.OKResponse(c, "Impersonation stopped", session)
+}
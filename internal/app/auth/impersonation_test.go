@@ -0,0 +1,230 @@
+package auth
+
+import (
+	"context"
+	"e-document-backend/internal/app/user/usertest"
+	"e-document-backend/internal/domain"
+	"e-document-backend/internal/platform/postgres/pgtest"
+	"e-document-backend/internal/util"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fakeImpersonations keeps impersonation sessions in memory
+type fakeImpersonations struct {
+	mu       sync.Mutex
+	sessions map[string]*domain.ImpersonationSession
+}
+
+func newFakeImpersonations() *fakeImpersonations {
+	return &fakeImpersonations{sessions: make(map[string]*domain.ImpersonationSession)}
+}
+
+func (r *fakeImpersonations) CreateSessionWithinLimit(ctx context.Context, session *domain.ImpersonationSession, since time.Time, limit int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var count int64
+	for _, s := range r.sessions {
+		if s.DirectorID == session.DirectorID && !s.StartedAt.Before(since) {
+			count++
+		}
+	}
+	if count >= limit {
+		return false, nil
+	}
+	session.ID = uuid.New()
+	copied := *session
+	r.sessions[session.ID.String()] = &copied
+	return true, nil
+}
+
+func (r *fakeImpersonations) GetSession(ctx context.Context, sessionID string) (*domain.ImpersonationSession, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.sessions[sessionID]
+	if !ok {
+		return nil, fmt.Errorf("impersonation session not found")
+	}
+	copied := *s
+	return &copied, nil
+}
+
+func (r *fakeImpersonations) EndSession(ctx context.Context, sessionID string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.sessions[sessionID]
+	if !ok || s.EndedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	s.EndedAt = &now
+	return true, nil
+}
+
+// newImpersonationService builds an auth service with impersonation enabled for a director and
+// an employee, allowing maxPerHour sessions an hour
+func newImpersonationService(t *testing.T, maxPerHour int64) (*service, *usertest.Repository, domain.User, domain.User) {
+	t.Helper()
+	cfg := newTestConfig()
+	cfg.JWT.ImpersonationEnabled = true
+	cfg.JWT.ImpersonationExpiry = 300
+	cfg.JWT.ImpersonationMaxPerHour = maxPerHour
+
+	director := newTestUser(t, domain.RoleDirector)
+	employee := newTestUser(t, domain.RoleEmployee)
+	repo := usertest.NewRepository(director, employee)
+	svc, err := NewService(repo, newFakeImpersonations(), cfg, NewLogVerificationNotifier())
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+	return svc.(*service), repo, director, employee
+}
+
+// impersonate starts an impersonation and returns the validated claims of its token
+func impersonate(t *testing.T, svc *service, director, target domain.User) (*domain.ImpersonationResponse, *domain.TokenClaims) {
+	t.Helper()
+	result, err := svc.Impersonate(context.Background(), director.ID.String(), target.ID.String(), domain.ImpersonateRequest{Reason: "support ticket"}, ImpersonationOrigin{})
+	if err != nil {
+		t.Fatalf("Impersonate() error = %v", err)
+	}
+	claims, err := svc.ValidateAccessToken(result.AccessToken)
+	if err != nil {
+		t.Fatalf("ValidateAccessToken() error = %v", err)
+	}
+	return result, claims
+}
+
+func TestImpersonateRateLimit(t *testing.T) {
+	svc, _, director, employee := newImpersonationService(t, 2)
+
+	impersonate(t, svc, director, employee)
+	impersonate(t, svc, director, employee)
+
+	_, err := svc.Impersonate(context.Background(), director.ID.String(), employee.ID.String(), domain.ImpersonateRequest{Reason: "support ticket"}, ImpersonationOrigin{})
+	assertErrorCode(t, err, util.RATE_LIMITED)
+}
+
+func TestVerifyImpersonation(t *testing.T) {
+	tests := []struct {
+		name     string
+		change   func(svc *service, repo *usertest.Repository, director, target domain.User, session *domain.ImpersonationSession)
+		wantCode util.ErrorCode
+	}{
+		{name: "active session"},
+		{name: "session stopped by ID", change: func(svc *service, repo *usertest.Repository, director, target domain.User, session *domain.ImpersonationSession) {
+			if _, err := svc.EndImpersonationSession(context.Background(), director.ID.String(), session.ID.String()); err != nil {
+				t.Fatalf("EndImpersonationSession() error = %v", err)
+			}
+		}, wantCode: util.INVALID_TOKEN},
+		{name: "target disabled", change: func(svc *service, repo *usertest.Repository, director, target domain.User, session *domain.ImpersonationSession) {
+			_ = repo.SetDisabled(context.Background(), target.ID.String(), true)
+		}, wantCode: util.INVALID_TOKEN},
+		{name: "director disabled", change: func(svc *service, repo *usertest.Repository, director, target domain.User, session *domain.ImpersonationSession) {
+			_ = repo.SetDisabled(context.Background(), director.ID.String(), true)
+		}, wantCode: util.INVALID_TOKEN},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo, director, employee := newImpersonationService(t, 5)
+			result, claims := impersonate(t, svc, director, employee)
+
+			if tt.change != nil {
+				tt.change(svc, repo, director, employee, result.Session)
+			}
+
+			// Checked against the session and both users, bypassing the user state cache
+			err := svc.verifyImpersonation(context.Background(), claims)
+			if tt.wantCode == "" {
+				if err != nil {
+					t.Fatalf("verifyImpersonation() error = %v", err)
+				}
+				return
+			}
+			assertErrorCode(t, err, tt.wantCode)
+		})
+	}
+}
+
+func TestEndImpersonationSession(t *testing.T) {
+	svc, _, director, employee := newImpersonationService(t, 5)
+	ctx := context.Background()
+	result, _ := impersonate(t, svc, director, employee)
+	sessionID := result.Session.ID.String()
+
+	_, err := svc.EndImpersonationSession(ctx, employee.ID.String(), sessionID)
+	assertErrorCode(t, err, util.FORBIDDEN)
+	_, err = svc.EndImpersonationSession(ctx, director.ID.String(), uuid.NewString())
+	assertErrorCode(t, err, util.NOT_FOUND)
+
+	session, err := svc.EndImpersonationSession(ctx, director.ID.String(), sessionID)
+	if err != nil {
+		t.Fatalf("EndImpersonationSession() error = %v", err)
+	}
+	if session.EndedAt == nil {
+		t.Fatal("session is still running after it was stopped")
+	}
+
+	// Stopping it again changes nothing
+	again, err := svc.EndImpersonationSession(ctx, director.ID.String(), sessionID)
+	if err != nil {
+		t.Fatalf("EndImpersonationSession() of an ended session error = %v", err)
+	}
+	if again.EndedAt == nil || !again.EndedAt.Equal(*session.EndedAt) {
+		t.Fatalf("ended_at = %v, want it unchanged at %v", again.EndedAt, session.EndedAt)
+	}
+}
+
+func TestCreateSessionWithinLimit(t *testing.T) {
+	pool := pgtest.NewPool(t)
+	repo := NewImpersonationRepository(pool)
+	ctx := context.Background()
+
+	const limit = 3
+	directorID := uuid.New()
+	now := time.Now()
+
+	// Concurrent requests of one director must not all pass the count
+	var wg sync.WaitGroup
+	results := make([]bool, 10)
+	errs := make([]error, len(results))
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			session := &domain.ImpersonationSession{
+				DirectorID:   directorID,
+				TargetUserID: uuid.New(),
+				Reason:       "support ticket",
+				StartedAt:    now,
+				ExpiresAt:    now.Add(5 * time.Minute),
+			}
+			results[i], errs[i] = repo.CreateSessionWithinLimit(ctx, session, now.Add(-time.Hour), limit)
+		}(i)
+	}
+	wg.Wait()
+
+	created := 0
+	for i, ok := range results {
+		if errs[i] != nil {
+			t.Fatalf("CreateSessionWithinLimit() error = %v", errs[i])
+		}
+		if ok {
+			created++
+		}
+	}
+	if created != limit {
+		t.Fatalf("%d sessions were created, want %d", created, limit)
+	}
+
+	// Another director has a limit of their own
+	other := &domain.ImpersonationSession{DirectorID: uuid.New(), TargetUserID: uuid.New(), Reason: "support ticket", StartedAt: now, ExpiresAt: now.Add(5 * time.Minute)}
+	ok, err := repo.CreateSessionWithinLimit(ctx, other, now.Add(-time.Hour), limit)
+	if err != nil || !ok {
+		t.Fatalf("CreateSessionWithinLimit() for another director = %v, %v; want created", ok, err)
+	}
+}
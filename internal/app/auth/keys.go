@@ -0,0 +1,195 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"e-document-backend/internal/config"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// verificationKey is a key tokens may have been signed with
+type verificationKey struct {
+	id         string      // kid header of tokens signed with this key; empty for tokens without one
+	key        interface{} // HMAC secret or public key
+	validUntil time.Time   // zero means no limit
+}
+
+// keyRing signs tokens with the current key and verifies them with the current key or, during a
+// rotation, the previous one
+type keyRing struct {
+	method     jwt.SigningMethod
+	signingKey interface{} // HMAC secret or private key
+	keyID      string
+	keys       []verificationKey
+}
+
+// tokenKeys holds the key rings of each token type
+// With an asymmetric algorithm all types share one ring; the "type" claim tells them apart
+type tokenKeys struct {
+	access  *keyRing
+	refresh *keyRing
+}
+
+// loadTokenKeys builds the key rings from the JWT configuration, reading PEM key files as needed
+func loadTokenKeys(cfg config.JWTConfig) (*tokenKeys, error) {
+	var previousUntil time.Time
+	if cfg.PreviousKeyValidUntil != "" {
+		until, err := time.Parse(time.RFC3339, cfg.PreviousKeyValidUntil)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWT_PREVIOUS_KEY_VALID_UNTIL: %w", err)
+		}
+		previousUntil = until
+	}
+
+	if cfg.Algorithm == config.JWTAlgorithmHS256 {
+		return &tokenKeys{
+			access:  newHMACKeyRing(cfg.KeyID, cfg.AccessTokenSecret, cfg.PreviousKeyID, cfg.PreviousAccessTokenSecret, previousUntil),
+			refresh: newHMACKeyRing(cfg.KeyID, cfg.RefreshTokenSecret, cfg.PreviousKeyID, cfg.PreviousRefreshTokenSecret, previousUntil),
+		}, nil
+	}
+
+	method := jwt.GetSigningMethod(cfg.Algorithm)
+	if method == nil {
+		return nil, fmt.Errorf("unsupported JWT algorithm %q", cfg.Algorithm)
+	}
+
+	privateKey, publicKey, err := loadPrivateKey(cfg.Algorithm, cfg.PrivateKeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	ring := &keyRing{
+		method:     method,
+		signingKey: privateKey,
+		keyID:      cfg.KeyID,
+		keys:       []verificationKey{{id: cfg.KeyID, key: publicKey}},
+	}
+
+	if cfg.PreviousPublicKeyFile != "" {
+		previousKey, err := loadPublicKey(cfg.Algorithm, cfg.PreviousPublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		ring.keys = append(ring.keys, verificationKey{id: cfg.PreviousKeyID, key: previousKey, validUntil: previousUntil})
+	}
+
+	return &tokenKeys{access: ring, refresh: ring}, nil
+}
+
+// newHMACKeyRing builds an HS256 ring; an empty previous secret means no rotation is in progress
+func newHMACKeyRing(keyID, secret, previousKeyID, previousSecret string, previousUntil time.Time) *keyRing {
+	ring := &keyRing{
+		method:     jwt.SigningMethodHS256,
+		signingKey: []byte(secret),
+		keyID:      keyID,
+		keys:       []verificationKey{{id: keyID, key: []byte(secret)}},
+	}
+	if previousSecret != "" {
+		ring.keys = append(ring.keys, verificationKey{id: previousKeyID, key: []byte(previousSecret), validUntil: previousUntil})
+	}
+	return ring
+}
+
+// loadPrivateKey reads the PEM private key for an asymmetric algorithm and returns it with its public key
+func loadPrivateKey(algorithm, path string) (interface{}, interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read JWT private key: %w", err)
+	}
+
+	switch algorithm {
+	case config.JWTAlgorithmRS256:
+		key, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid RS256 private key: %w", err)
+		}
+		return key, &key.PublicKey, nil
+	case config.JWTAlgorithmES256:
+		key, err := jwt.ParseECPrivateKeyFromPEM(data)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid ES256 private key: %w", err)
+		}
+		if err := requireP256(&key.PublicKey); err != nil {
+			return nil, nil, err
+		}
+		return key, &key.PublicKey, nil
+	}
+
+	return nil, nil, fmt.Errorf("unsupported JWT algorithm %q", algorithm)
+}
+
+// loadPublicKey reads the PEM public key of a previous key pair
+func loadPublicKey(algorithm, path string) (interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read previous JWT public key: %w", err)
+	}
+
+	switch algorithm {
+	case config.JWTAlgorithmRS256:
+		key, err := jwt.ParseRSAPublicKeyFromPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid previous RS256 public key: %w", err)
+		}
+		return key, nil
+	case config.JWTAlgorithmES256:
+		key, err := jwt.ParseECPublicKeyFromPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid previous ES256 public key: %w", err)
+		}
+		if err := requireP256(key); err != nil {
+			return nil, err
+		}
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("unsupported JWT algorithm %q", algorithm)
+}
+
+// requireP256 checks an ECDSA key uses the curve ES256 is defined on
+func requireP256(key *ecdsa.PublicKey) error {
+	if key.Curve != elliptic.P256() {
+		return fmt.Errorf("ES256 keys must use the P-256 curve")
+	}
+	return nil
+}
+
+// sign creates a token signed with the current key, naming it in the kid header if it has an ID
+func (r *keyRing) sign(claims jwt.MapClaims) (string, error) {
+	token := jwt.NewWithClaims(r.method, claims)
+	if r.keyID != "" {
+		token.Header["kid"] = r.keyID
+	}
+	return token.SignedString(r.signingKey)
+}
+
+// keyFunc selects the keys a token may be verified with
+// A token naming a key is checked against that key only; a token without kid (issued before
+// key IDs were configured) is checked against every key still valid
+func (r *keyRing) keyFunc(token *jwt.Token) (interface{}, error) {
+	if token.Method.Alg() != r.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+
+	now := time.Now()
+	kid, _ := token.Header["kid"].(string)
+
+	var candidates []jwt.VerificationKey
+	for _, key := range r.keys {
+		if !key.validUntil.IsZero() && now.After(key.validUntil) {
+			continue
+		}
+		if kid == "" || key.id == kid {
+			candidates = append(candidates, key.key)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return jwt.VerificationKeySet{Keys: candidates}, nil
+}
@@ -26,22 +26,47 @@ type Service interface {
 	Login(ctx context.Context, req domain.LoginRequest) (*AuthResult, error)
 	RefreshToken(ctx context.Context, refreshToken string) (*AuthResult, error)
 	GetProfile(ctx context.Context, userID string) (*domain.UserResponse, error)
+	LogoutAll(ctx context.Context, userID string) error
+	SendEmailVerification(ctx context.Context, user *domain.User) error
+	VerifyEmail(ctx context.Context, token string) error
+	ResendEmailVerification(ctx context.Context, email string) error
+	EnableTwoFactor(ctx context.Context, userID string) (*domain.EnableTwoFactorResponse, error)
+	VerifyTwoFactor(ctx context.Context, userID string, code string) (*domain.VerifyTwoFactorResponse, error)
 	ValidateAccessToken(tokenString string) (*domain.TokenClaims, error)
 	ValidateRefreshToken(tokenString string) (*domain.TokenClaims, error)
+	VerifyUserState(ctx context.Context, claims *domain.TokenClaims) error
+	InvalidateUserState(userID string)
+	Impersonate(ctx context.Context, directorID, targetUserID string, req domain.ImpersonateRequest, origin ImpersonationOrigin) (*domain.ImpersonationResponse, error)
+	StopImpersonation(ctx context.Context, claims *domain.TokenClaims) error
+	EndImpersonationSession(ctx context.Context, requesterID, sessionID string) (*domain.ImpersonationSession, error)
 }
 
 // service implements the Service interface
 type service struct {
-	userRepo user.Repository
-	cfg      *config.Config
+	userRepo       user.Repository
+	impersonations ImpersonationRepository
+	cfg            *config.Config
+	userStates     *userStateCache
+	notifier       VerificationNotifier
+	keys           *tokenKeys
 }
 
 // NewService creates a new auth service
-func NewService(userRepo user.Repository, cfg *config.Config) Service {
-	return &service{
-		userRepo: userRepo,
-		cfg:      cfg,
+// It fails if the configured JWT signing keys cannot be loaded
+func NewService(userRepo user.Repository, impersonations ImpersonationRepository, cfg *config.Config, notifier VerificationNotifier) (Service, error) {
+	keys, err := loadTokenKeys(cfg.JWT)
+	if err != nil {
+		return nil, err
 	}
+
+	return &service{
+		userRepo:       userRepo,
+		impersonations: impersonations,
+		cfg:            cfg,
+		userStates:     newUserStateCache(time.Duration(cfg.JWT.UserStateCacheTTL) * time.Second),
+		notifier:       notifier,
+		keys:           keys,
+	}, nil
 }
 
 // Login authenticates a user with username/email and password
@@ -79,6 +104,26 @@ func (s *service) Login(ctx context.Context, req domain.LoginRequest) (*AuthResu
 		)
 	}
 
+	// Checked after the password so disabled and unverified accounts are not revealed to guessers
+	if user.Disabled {
+		return nil, accountDisabledError()
+	}
+
+	if s.cfg.JWT.RequireVerifiedEmail && !user.EmailVerified {
+		return nil, util.ErrorResponse(
+			"Email not verified",
+			util.EMAIL_NOT_VERIFIED,
+			403,
+			"verify your email address before logging in",
+		)
+	}
+
+	if user.TOTPEnabled {
+		if err := s.checkTwoFactor(ctx, user, req.Code); err != nil {
+			return nil, err
+		}
+	}
+
 	// Generate tokens
 	accessToken, err := s.generateAccessToken(user)
 	if err != nil {
@@ -102,7 +147,9 @@ func (s *service) Login(ctx context.Context, req domain.LoginRequest) (*AuthResu
 
 	result := &AuthResult{
 		Response: &domain.AuthResponse{
-			User: user.ToResponse(),
+			User:             user.ToResponse(),
+			ExpiresIn:        s.cfg.JWT.AccessTokenExpiry,
+			RefreshExpiresIn: s.cfg.JWT.RefreshTokenExpiry,
 		},
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
@@ -135,6 +182,20 @@ func (s *service) RefreshToken(ctx context.Context, refreshToken string) (*AuthR
 		)
 	}
 
+	if user.Disabled {
+		return nil, accountDisabledError()
+	}
+
+	// Reject refresh tokens issued before the user logged out everywhere
+	if user.TokenVersion != claims.TokenVersion {
+		return nil, util.ErrorResponse(
+			"Invalid refresh token",
+			util.INVALID_TOKEN,
+			401,
+			"token has been revoked",
+		)
+	}
+
 	// Generate new tokens
 	newAccessToken, err := s.generateAccessToken(user)
 	if err != nil {
@@ -158,7 +219,9 @@ func (s *service) RefreshToken(ctx context.Context, refreshToken string) (*AuthR
 
 	result := &AuthResult{
 		Response: &domain.AuthResponse{
-			User: user.ToResponse(),
+			User:             user.ToResponse(),
+			ExpiresIn:        s.cfg.JWT.AccessTokenExpiry,
+			RefreshExpiresIn: s.cfg.JWT.RefreshTokenExpiry,
 		},
 		AccessToken:  newAccessToken,
 		RefreshToken: newRefreshToken,
@@ -183,40 +246,69 @@ func (s *service) GetProfile(ctx context.Context, userID string) (*domain.UserRe
 	return &response, nil
 }
 
+// LogoutAll revokes every token issued to the user so all devices must log in again
+func (s *service) LogoutAll(ctx context.Context, userID string) error {
+	if err := s.userRepo.IncrementTokenVersion(ctx, userID); err != nil {
+		return util.NewDatabaseError("revoke tokens", err)
+	}
+
+	// Do not let a cached token version keep revoked access tokens alive
+	s.userStates.delete(userID)
+
+	return nil
+}
+
+// InvalidateUserState drops the cached state of a user so the next request reloads it,
+// making changes such as disabling the account take effect immediately
+func (s *service) InvalidateUserState(userID string) {
+	s.userStates.delete(userID)
+}
+
+// accountDisabledError is returned for every authentication attempt of a disabled user
+func accountDisabledError() error {
+	return util.ErrorResponse(
+		"Account disabled",
+		util.ACCOUNT_DISABLED,
+		403,
+		"this account has been disabled by an administrator",
+	)
+}
+
 // buildUserClaims creates JWT claims for a user
+// Only the claims needed for authorization are included unless profile claims are enabled
 func (s *service) buildUserClaims(user *domain.User, tokenType string, expiry int64) jwt.MapClaims {
-	return jwt.MapClaims{
-		"user_id":       user.ID.String(),
-		"username":      user.Username,
-		"email":         user.Email,
-		"phone":         user.Phone,
-		"first_name":    user.FirstName,
-		"last_name":     user.LastName,
-		"role":          user.Role.String(),
-		"department_id": user.DepartmentID,
-		"sector_id":     user.SectorID,
-		"type":          tokenType,
-		"exp":           time.Now().Add(time.Duration(expiry) * time.Second).Unix(),
-		"iat":           time.Now().Unix(),
+	claims := jwt.MapClaims{
+		"user_id": user.ID.String(),
+		"role":    user.Role.String(),
+		"ver":     user.TokenVersion,
+		"type":    tokenType,
+		"exp":     time.Now().Add(time.Duration(expiry) * time.Second).Unix(),
+		"iat":     time.Now().Unix(),
 	}
-}
 
-// generateToken creates and signs a JWT token
-func (s *service) generateToken(claims jwt.MapClaims, secret string) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secret))
+	if s.cfg.JWT.IncludeProfileClaims {
+		claims["username"] = user.Username
+		claims["email"] = user.Email
+		claims["phone"] = user.Phone
+		claims["first_name"] = user.FirstName
+		claims["last_name"] = user.LastName
+		claims["department_id"] = user.DepartmentID
+		claims["sector_id"] = user.SectorID
+	}
+
+	return claims
 }
 
 // generateAccessToken creates a new access token for the user
 func (s *service) generateAccessToken(user *domain.User) (string, error) {
 	claims := s.buildUserClaims(user, "access", s.cfg.JWT.AccessTokenExpiry)
-	return s.generateToken(claims, s.cfg.JWT.AccessTokenSecret)
+	return s.keys.access.sign(claims)
 }
 
 // generateRefreshToken creates a new refresh token for the user
 func (s *service) generateRefreshToken(user *domain.User) (string, error) {
 	claims := s.buildUserClaims(user, "refresh", s.cfg.JWT.RefreshTokenExpiry)
-	return s.generateToken(claims, s.cfg.JWT.RefreshTokenSecret)
+	return s.keys.refresh.sign(claims)
 }
 
 // parseTokenClaims extracts TokenClaims from JWT MapClaims
@@ -231,6 +323,10 @@ func parseTokenClaims(claims jwt.MapClaims) *domain.TokenClaims {
 	departmentID, _ := claims["department_id"].(string)
 	sectorID, _ := claims["sector_id"].(string)
 	tokenType, _ := claims["type"].(string)
+	impersonatedBy, _ := claims["impersonated_by"].(string)
+	impersonationID, _ := claims["imp_sid"].(string)
+	// JSON numbers are decoded as float64
+	tokenVersion, _ := claims["ver"].(float64)
 
 	return &domain.TokenClaims{
 		UserID:       userID,
@@ -242,18 +338,17 @@ func parseTokenClaims(claims jwt.MapClaims) *domain.TokenClaims {
 		Role:         role,
 		DepartmentID: departmentID,
 		SectorID:     sectorID,
+		TokenVersion: int(tokenVersion),
 		Type:         tokenType,
+
+		ImpersonatedBy:  impersonatedBy,
+		ImpersonationID: impersonationID,
 	}
 }
 
-// validateToken validates a JWT token with the given secret and expected type
-func (s *service) validateToken(tokenString, secret, expectedType string) (*domain.TokenClaims, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(secret), nil
-	})
+// validateToken validates a JWT token against a key ring and checks its type
+func (s *service) validateToken(tokenString string, keys *keyRing, expectedType string) (*domain.TokenClaims, error) {
+	token, err := jwt.Parse(tokenString, keys.keyFunc, jwt.WithValidMethods([]string{keys.method.Alg()}))
 
 	if err != nil {
 		return nil, err
@@ -273,10 +368,10 @@ func (s *service) validateToken(tokenString, secret, expectedType string) (*doma
 
 // ValidateAccessToken validates and parses an access token
 func (s *service) ValidateAccessToken(tokenString string) (*domain.TokenClaims, error) {
-	return s.validateToken(tokenString, s.cfg.JWT.AccessTokenSecret, "access")
+	return s.validateToken(tokenString, s.keys.access, "access")
 }
 
 // ValidateRefreshToken validates and parses a refresh token
 func (s *service) ValidateRefreshToken(tokenString string) (*domain.TokenClaims, error) {
-	return s.validateToken(tokenString, s.cfg.JWT.RefreshTokenSecret, "refresh")
+	return s.validateToken(tokenString, s.keys.refresh, "refresh")
 }
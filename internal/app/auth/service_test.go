@@ -0,0 +1,270 @@
+package auth
+
+import (
+	"context"
+	"e-document-backend/internal/app/user/usertest"
+	"e-document-backend/internal/config"
+	"e-document-backend/internal/domain"
+	"e-document-backend/internal/util"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const testPassword = "Secret123"
+
+// newTestConfig returns an HS256 configuration with profile claims and user state checks off
+func newTestConfig() *config.Config {
+	return &config.Config{
+		JWT: config.JWTConfig{
+			AccessTokenSecret:  "test-access-secret",
+			RefreshTokenSecret: "test-refresh-secret",
+			AccessTokenExpiry:  900,
+			RefreshTokenExpiry: 3600,
+			VerifyTokenExpiry:  3600,
+			Algorithm:          config.JWTAlgorithmHS256,
+		},
+	}
+}
+
+// newTestService builds an auth service over an in-memory repository
+func newTestService(t *testing.T, cfg *config.Config, users ...domain.User) (*service, *usertest.Repository) {
+	t.Helper()
+	repo := usertest.NewRepository(users...)
+	svc, err := NewService(repo, nil, cfg, NewLogVerificationNotifier())
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+	return svc.(*service), repo
+}
+
+// newTestUser returns an enabled, verified user whose password is testPassword
+func newTestUser(t *testing.T, role domain.UserRole) domain.User {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := uuid.New()
+	return domain.User{
+		ID:            id,
+		Username:      "user-" + id.String()[:8],
+		Email:         id.String()[:8] + "@example.com",
+		Phone:         "+66812345678",
+		FirstName:     "Somchai",
+		LastName:      "Jaidee",
+		Password:      string(hash),
+		Role:          role,
+		DepartmentID:  "finance",
+		SectorID:      "accounting",
+		EmailVerified: true,
+	}
+}
+
+func assertErrorCode(t *testing.T, err error, code util.ErrorCode) {
+	t.Helper()
+	customErr, ok := err.(*util.CustomError)
+	if !ok {
+		t.Fatalf("error = %v, want error code %s", err, code)
+	}
+	if customErr.ErrorCode != code {
+		t.Fatalf("error code = %s, want %s (%s)", customErr.ErrorCode, code, customErr.Detail)
+	}
+}
+
+func TestTokenClaims(t *testing.T) {
+	tests := []struct {
+		name          string
+		profileClaims bool
+	}{
+		{name: "lean token", profileClaims: false},
+		{name: "token with profile claims", profileClaims: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newTestConfig()
+			cfg.JWT.IncludeProfileClaims = tt.profileClaims
+			u := newTestUser(t, domain.RoleDepartmentManager)
+			svc, _ := newTestService(t, cfg, u)
+
+			result, err := svc.Login(context.Background(), domain.LoginRequest{UsernameOrEmail: u.Username, Password: testPassword})
+			if err != nil {
+				t.Fatalf("Login() error = %v", err)
+			}
+
+			claims, err := svc.ValidateAccessToken(result.AccessToken)
+			if err != nil {
+				t.Fatalf("ValidateAccessToken() error = %v", err)
+			}
+
+			// Authorization only ever needs these
+			if claims.UserID != u.ID.String() || claims.Role != string(u.Role) || claims.Type != "access" {
+				t.Fatalf("claims = %+v, want user %s with role %s", claims, u.ID, u.Role)
+			}
+			hasProfile := claims.Email != "" || claims.Username != "" || claims.DepartmentID != "" || claims.SectorID != ""
+			if hasProfile != tt.profileClaims {
+				t.Fatalf("token carries profile claims = %v, want %v (%+v)", hasProfile, tt.profileClaims, claims)
+			}
+			if err := svc.VerifyUserState(context.Background(), claims); err != nil {
+				t.Fatalf("VerifyUserState() error = %v", err)
+			}
+
+			// The profile endpoint supplies the details either way
+			profile, err := svc.GetProfile(context.Background(), claims.UserID)
+			if err != nil {
+				t.Fatalf("GetProfile() error = %v", err)
+			}
+			if profile.Email != u.Email || profile.DepartmentID != u.DepartmentID || profile.SectorID != u.SectorID || profile.FirstName != u.FirstName {
+				t.Fatalf("GetProfile() = %+v, want the details of %s", profile, u.Username)
+			}
+		})
+	}
+}
+
+func TestLogin(t *testing.T) {
+	active := newTestUser(t, domain.RoleEmployee)
+	disabled := newTestUser(t, domain.RoleEmployee)
+	disabled.Disabled = true
+	unverified := newTestUser(t, domain.RoleEmployee)
+	unverified.EmailVerified = false
+
+	cfg := newTestConfig()
+	cfg.JWT.RequireVerifiedEmail = true
+	svc, _ := newTestService(t, cfg, active, disabled, unverified)
+
+	tests := []struct {
+		name     string
+		login    string
+		password string
+		wantCode util.ErrorCode
+	}{
+		{name: "username", login: active.Username, password: testPassword},
+		{name: "email in upper case", login: "  " + strings.ToUpper(active.Email), password: testPassword},
+		{name: "unknown user", login: "nobody", password: testPassword, wantCode: util.USER_NOT_FOUND},
+		{name: "wrong password", login: active.Username, password: "wrong", wantCode: util.INCORRECT_PASSWORD},
+		{name: "disabled user", login: disabled.Username, password: testPassword, wantCode: util.ACCOUNT_DISABLED},
+		{name: "unverified email", login: unverified.Username, password: testPassword, wantCode: util.EMAIL_NOT_VERIFIED},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := svc.Login(context.Background(), domain.LoginRequest{UsernameOrEmail: tt.login, Password: tt.password})
+			if tt.wantCode != "" {
+				assertErrorCode(t, err, tt.wantCode)
+				return
+			}
+			if err != nil {
+				t.Fatalf("Login() error = %v", err)
+			}
+			if result.AccessToken == "" || result.RefreshToken == "" {
+				t.Fatal("Login() returned empty tokens")
+			}
+		})
+	}
+}
+
+func TestVerifyUserState(t *testing.T) {
+	tests := []struct {
+		name     string
+		change   func(repo *usertest.Repository, id string)
+		wantCode util.ErrorCode
+	}{
+		{name: "unchanged user", change: func(repo *usertest.Repository, id string) {}},
+		{name: "disabled user", change: func(repo *usertest.Repository, id string) {
+			_ = repo.SetDisabled(context.Background(), id, true)
+		}, wantCode: util.ACCOUNT_DISABLED},
+		{name: "deleted user", change: func(repo *usertest.Repository, id string) {
+			_ = repo.Delete(context.Background(), id)
+		}, wantCode: util.INVALID_TOKEN},
+		{name: "role changed", change: func(repo *usertest.Repository, id string) {
+			u := repo.Get(id)
+			u.Role = domain.RoleEmployee
+			_ = repo.Update(context.Background(), id, u)
+		}, wantCode: util.INVALID_TOKEN},
+		{name: "tokens revoked", change: func(repo *usertest.Repository, id string) {
+			_ = repo.IncrementTokenVersion(context.Background(), id)
+		}, wantCode: util.INVALID_TOKEN},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := newTestUser(t, domain.RoleDepartmentManager)
+			svc, repo := newTestService(t, newTestConfig(), u)
+
+			claims := loginClaims(t, svc, u)
+			tt.change(repo, u.ID.String())
+
+			err := svc.VerifyUserState(context.Background(), claims)
+			if tt.wantCode == "" {
+				if err != nil {
+					t.Fatalf("VerifyUserState() error = %v", err)
+				}
+				return
+			}
+			assertErrorCode(t, err, tt.wantCode)
+		})
+	}
+}
+
+func TestVerifyUserStateCache(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.JWT.UserStateCacheTTL = 60
+	u := newTestUser(t, domain.RoleEmployee)
+	svc, repo := newTestService(t, cfg, u)
+	ctx := context.Background()
+
+	claims := loginClaims(t, svc, u)
+	if err := svc.VerifyUserState(ctx, claims); err != nil {
+		t.Fatalf("VerifyUserState() error = %v", err)
+	}
+
+	// The cached state is trusted until it is invalidated, as disabling an account through the service does
+	_ = repo.SetDisabled(ctx, u.ID.String(), true)
+	if err := svc.VerifyUserState(ctx, claims); err != nil {
+		t.Fatalf("VerifyUserState() with cached state error = %v", err)
+	}
+
+	svc.InvalidateUserState(u.ID.String())
+	assertErrorCode(t, svc.VerifyUserState(ctx, claims), util.ACCOUNT_DISABLED)
+}
+
+func TestLogoutAllRevokesAccessTokens(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.JWT.UserStateCacheTTL = 60
+	u := newTestUser(t, domain.RoleEmployee)
+	svc, _ := newTestService(t, cfg, u)
+	ctx := context.Background()
+
+	// Another device holds an access token whose state is already cached
+	otherDevice := loginClaims(t, svc, u)
+	if err := svc.VerifyUserState(ctx, otherDevice); err != nil {
+		t.Fatalf("VerifyUserState() error = %v", err)
+	}
+
+	if err := svc.LogoutAll(ctx, u.ID.String()); err != nil {
+		t.Fatalf("LogoutAll() error = %v", err)
+	}
+	assertErrorCode(t, svc.VerifyUserState(ctx, otherDevice), util.INVALID_TOKEN)
+
+	// Logging in again issues tokens of the new version
+	if err := svc.VerifyUserState(ctx, loginClaims(t, svc, u)); err != nil {
+		t.Fatalf("VerifyUserState() after logging in again error = %v", err)
+	}
+}
+
+// loginClaims logs a user in and returns the claims of the access token
+func loginClaims(t *testing.T, svc *service, u domain.User) *domain.TokenClaims {
+	t.Helper()
+	result, err := svc.Login(context.Background(), domain.LoginRequest{UsernameOrEmail: u.Username, Password: testPassword})
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	claims, err := svc.ValidateAccessToken(result.AccessToken)
+	if err != nil {
+		t.Fatalf("ValidateAccessToken() error = %v", err)
+	}
+	return claims
+}
@@ -0,0 +1,217 @@
+package auth
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"e-document-backend/internal/domain"
+	"e-document-backend/internal/pkg/totp"
+	"e-document-backend/internal/util"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// backupCodeCount is the number of one-time backup codes issued when two-factor is enabled
+const backupCodeCount = 10
+
+// EnableTwoFactor generates a new TOTP secret for the user
+// Two-factor stays off until VerifyTwoFactor confirms a code from the authenticator app
+func (s *service) EnableTwoFactor(ctx context.Context, userID string) (*domain.EnableTwoFactorResponse, error) {
+	if s.cfg.TOTP.EncryptionKey == "" {
+		return nil, util.ErrorResponse("Two-factor authentication is not configured", util.CONFIG_NOT_SET, 500, "TOTP_ENCRYPTION_KEY is not set")
+	}
+
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, util.NewNotFoundError("User", userID)
+	}
+	if user.TOTPEnabled {
+		return nil, util.NewInvalidInputError("2fa", "two-factor authentication is already enabled")
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, util.NewInternalError(err.Error())
+	}
+
+	encrypted, err := s.encryptTOTPSecret(secret)
+	if err != nil {
+		return nil, util.NewInternalError(err.Error())
+	}
+
+	if err := s.userRepo.SetTOTPSecret(ctx, userID, encrypted); err != nil {
+		return nil, util.NewDatabaseError("set totp secret", err)
+	}
+
+	return &domain.EnableTwoFactorResponse{
+		Secret:     secret,
+		OTPAuthURL: totp.URL(s.cfg.TOTP.Issuer, user.Username, secret),
+	}, nil
+}
+
+// VerifyTwoFactor confirms a code for the pending secret, enables two-factor and returns backup codes
+func (s *service) VerifyTwoFactor(ctx context.Context, userID string, code string) (*domain.VerifyTwoFactorResponse, error) {
+	state, err := s.userRepo.GetTOTP(ctx, userID)
+	if err != nil {
+		return nil, util.NewNotFoundError("User", userID)
+	}
+	if state.Enabled {
+		return nil, util.NewInvalidInputError("2fa", "two-factor authentication is already enabled")
+	}
+	if state.Secret == "" {
+		return nil, util.NewInvalidInputError("2fa", "call /v1/auth/2fa/enable first")
+	}
+
+	secret, err := s.decryptTOTPSecret(state.Secret)
+	if err != nil {
+		return nil, util.NewInternalError(err.Error())
+	}
+	counter, ok := totp.Validate(code, secret, time.Now(), state.LastCounter)
+	if !ok {
+		return nil, util.ErrorResponse("Invalid two-factor code", util.INVALID_2FA_CODE, 400, "code does not match the authenticator app")
+	}
+	accepted, err := s.userRepo.AcceptTOTPCounter(ctx, userID, counter)
+	if err != nil {
+		return nil, util.NewDatabaseError("accept totp code", err)
+	}
+	if !accepted {
+		return nil, util.ErrorResponse("Invalid two-factor code", util.INVALID_2FA_CODE, 400, "code was already used")
+	}
+
+	codes := make([]string, 0, backupCodeCount)
+	hashes := make([]string, 0, backupCodeCount)
+	for i := 0; i < backupCodeCount; i++ {
+		code, err := newBackupCode()
+		if err != nil {
+			return nil, util.NewInternalError(err.Error())
+		}
+		hash, err := bcrypt.GenerateFromPassword([]byte(normalizeBackupCode(code)), s.cfg.Password.BcryptCost)
+		if err != nil {
+			return nil, util.NewInternalError(fmt.Sprintf("failed to hash backup code: %v", err))
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, string(hash))
+	}
+
+	if err := s.userRepo.EnableTOTP(ctx, userID, hashes); err != nil {
+		return nil, util.NewDatabaseError("enable totp", err)
+	}
+
+	return &domain.VerifyTwoFactorResponse{BackupCodes: codes}, nil
+}
+
+// checkTwoFactor verifies the login code of a user with two-factor enabled
+// A backup code is accepted in place of a TOTP code and can only be used once
+func (s *service) checkTwoFactor(ctx context.Context, user *domain.User, code string) error {
+	if strings.TrimSpace(code) == "" {
+		return util.ErrorResponse("Two-factor code required", util.TWO_FA_REQUIRED, 401, "enter the code from your authenticator app or a backup code")
+	}
+
+	state, err := s.userRepo.GetTOTP(ctx, user.ID.String())
+	if err != nil {
+		return util.NewDatabaseError("get totp", err)
+	}
+
+	secret, err := s.decryptTOTPSecret(state.Secret)
+	if err != nil {
+		return util.NewInternalError(err.Error())
+	}
+	if counter, ok := totp.Validate(code, secret, time.Now(), state.LastCounter); ok {
+		// Recording the step atomically stops two logins from using the same code
+		accepted, err := s.userRepo.AcceptTOTPCounter(ctx, user.ID.String(), counter)
+		if err != nil {
+			return util.NewDatabaseError("accept totp code", err)
+		}
+		if accepted {
+			return nil
+		}
+		return util.ErrorResponse("Invalid two-factor code", util.INVALID_2FA_CODE, 401, "code is invalid or was already used")
+	}
+
+	normalized := normalizeBackupCode(code)
+	for _, hash := range state.BackupCodes {
+		if bcrypt.CompareHashAndPassword([]byte(hash), []byte(normalized)) != nil {
+			continue
+		}
+		// Removing the hash atomically stops two logins from using the same code
+		used, err := s.userRepo.RemoveTOTPBackupCode(ctx, user.ID.String(), hash)
+		if err != nil {
+			return util.NewDatabaseError("use backup code", err)
+		}
+		if used {
+			return nil
+		}
+	}
+
+	return util.ErrorResponse("Invalid two-factor code", util.INVALID_2FA_CODE, 401, "code is invalid or was already used")
+}
+
+// totpCipher returns an AES-256-GCM cipher keyed from TOTP_ENCRYPTION_KEY
+func (s *service) totpCipher() (cipher.AEAD, error) {
+	key := sha256.Sum256([]byte(s.cfg.TOTP.EncryptionKey))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to create totp cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptTOTPSecret encrypts a secret for storage as base64(nonce || ciphertext)
+func (s *service) encryptTOTPSecret(secret string) (string, error) {
+	gcm, err := s.totpCipher()
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(secret), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decryptTOTPSecret reverses encryptTOTPSecret
+func (s *service) decryptTOTPSecret(encrypted string) (string, error) {
+	gcm, err := s.totpCipher()
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil || len(sealed) < gcm.NonceSize() {
+		return "", fmt.Errorf("stored totp secret is malformed")
+	}
+
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	secret, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt totp secret: %w", err)
+	}
+
+	return string(secret), nil
+}
+
+// newBackupCode returns a random backup code formatted as xxxxx-xxxxx
+func newBackupCode() (string, error) {
+	random := make([]byte, 5)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("failed to generate backup code: %w", err)
+	}
+	code := hex.EncodeToString(random)
+	return code[:5] + "-" + code[5:], nil
+}
+
+// normalizeBackupCode drops separators and case so codes can be typed loosely
+func normalizeBackupCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
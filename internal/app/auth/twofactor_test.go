@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"e-document-backend/internal/domain"
+	"e-document-backend/internal/util"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// currentCode computes the authenticator app's code for secret right now
+func currentCode(t *testing.T, secret string) string {
+	t.Helper()
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(time.Now().Unix()/30))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	return fmt.Sprintf("%06d", (binary.BigEndian.Uint32(sum[offset:offset+4])&0x7fffffff)%1000000)
+}
+
+func TestTwoFactorCodeReplay(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.TOTP.EncryptionKey = "test-totp-key"
+	user := newTestUser(t, domain.RoleEmployee)
+	svc, repo := newTestService(t, cfg, user)
+	ctx := context.Background()
+
+	enabled, err := svc.EnableTwoFactor(ctx, user.ID.String())
+	if err != nil {
+		t.Fatalf("EnableTwoFactor() error = %v", err)
+	}
+	code := currentCode(t, enabled.Secret)
+	if _, err := svc.VerifyTwoFactor(ctx, user.ID.String(), code); err != nil {
+		t.Fatalf("VerifyTwoFactor() error = %v", err)
+	}
+
+	// The code that enabled two-factor cannot be replayed to log in
+	stored := repo.Get(user.ID.String())
+	err = svc.checkTwoFactor(ctx, stored, code)
+	assertErrorCode(t, err, util.INVALID_2FA_CODE)
+
+	// A second login racing for the same step loses
+	state, err := repo.GetTOTP(ctx, user.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if accepted, _ := repo.AcceptTOTPCounter(ctx, user.ID.String(), state.LastCounter); accepted {
+		t.Fatal("AcceptTOTPCounter() accepted the same step twice")
+	}
+}
@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"context"
+	"e-document-backend/internal/domain"
+	"e-document-backend/internal/util"
+	"sync"
+	"time"
+)
+
+// userState is the part of a user record a token must still agree with
+type userState struct {
+	role         domain.UserRole
+	departmentID string
+	sectorID     string
+	tokenVersion int
+	disabled     bool
+	expiresAt    time.Time
+}
+
+// userStateCache keeps recently loaded user state to avoid a query on every request
+type userStateCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]userState
+}
+
+// newUserStateCache creates a cache; a ttl of 0 disables caching
+func newUserStateCache(ttl time.Duration) *userStateCache {
+	return &userStateCache{
+		ttl:     ttl,
+		entries: make(map[string]userState),
+	}
+}
+
+// get returns the cached state for a user if it has not expired
+func (c *userStateCache) get(userID string) (userState, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	state, ok := c.entries[userID]
+	if !ok {
+		return userState{}, false
+	}
+	if time.Now().After(state.expiresAt) {
+		delete(c.entries, userID)
+		return userState{}, false
+	}
+	return state, true
+}
+
+// set stores the state for a user
+func (c *userStateCache) set(userID string, state userState) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	state.expiresAt = time.Now().Add(c.ttl)
+	c.entries[userID] = state
+}
+
+// delete drops the cached state for a user so the next check reloads it
+func (c *userStateCache) delete(userID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, userID)
+}
+
+// VerifyUserState checks that the user behind a validated token still exists and is not disabled,
+// that the role claim matches and that the token version has not been revoked
+// Impersonation tokens are also checked against their session
+// The department and sector claims are replaced with the user's current ones
+func (s *service) VerifyUserState(ctx context.Context, claims *domain.TokenClaims) error {
+	if claims.ImpersonationID != "" {
+		if err := s.verifyImpersonation(ctx, claims); err != nil {
+			return err
+		}
+	}
+
+	state, ok := s.userStates.get(claims.UserID)
+	if !ok {
+		user, err := s.userRepo.FindByID(ctx, claims.UserID)
+		if err != nil {
+			return util.ErrorResponse("Unauthorized", util.INVALID_TOKEN, 401, "user no longer exists")
+		}
+		state = userState{
+			role:         user.Role,
+			departmentID: user.DepartmentID,
+			sectorID:     user.SectorID,
+			tokenVersion: user.TokenVersion,
+			disabled:     user.Disabled,
+		}
+		s.userStates.set(claims.UserID, state)
+	}
+
+	// Checked first since disabling also revokes the user's tokens
+	if state.disabled {
+		return accountDisabledError()
+	}
+
+	if string(state.role) != claims.Role {
+		return util.ErrorResponse("Unauthorized", util.INVALID_TOKEN, 401, "role has changed since the token was issued")
+	}
+
+	if state.tokenVersion != claims.TokenVersion {
+		return util.ErrorResponse("Unauthorized", util.INVALID_TOKEN, 401, "token has been revoked")
+	}
+
+	// Lean tokens carry no profile claims; fill them from the loaded state for the auth context
+	claims.DepartmentID = state.departmentID
+	claims.SectorID = state.sectorID
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"context"
+	"e-document-backend/internal/domain"
+	"e-document-backend/internal/util"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// VerificationNotifier delivers email verification tokens to new users
+type VerificationNotifier interface {
+	NotifyEmailVerification(ctx context.Context, user *domain.User, token string, expiresAt time.Time) error
+}
+
+// logVerificationNotifier records verification requests in the log; used until a mailer is available
+// The token is a credential and never logged, so a user cannot verify until a mailer is configured
+type logVerificationNotifier struct{}
+
+// NewLogVerificationNotifier creates a notifier that only logs verification requests
+func NewLogVerificationNotifier() VerificationNotifier {
+	return logVerificationNotifier{}
+}
+
+// NotifyEmailVerification logs who was asked to verify and until when, without the token
+func (logVerificationNotifier) NotifyEmailVerification(ctx context.Context, user *domain.User, token string, expiresAt time.Time) error {
+	log.Info().
+		Str("user_id", user.ID.String()).
+		Time("expires_at", expiresAt).
+		Msg("Email verification requested")
+	return nil
+}
+
+// SendEmailVerification issues a "verify" token for the user's current email address and delivers it
+func (s *service) SendEmailVerification(ctx context.Context, user *domain.User) error {
+	claims := s.buildUserClaims(user, "verify", s.cfg.JWT.VerifyTokenExpiry)
+	// Bind the token to the address so it cannot confirm an email changed afterwards
+	claims["email"] = user.Email
+
+	token, err := s.keys.access.sign(claims)
+	if err != nil {
+		return util.NewInternalError("failed to generate verification token: " + err.Error())
+	}
+
+	expiresAt := time.Now().Add(time.Duration(s.cfg.JWT.VerifyTokenExpiry) * time.Second)
+	return s.notifier.NotifyEmailVerification(ctx, user, token, expiresAt)
+}
+
+// ResendEmailVerification sends a new verification token to the account with the email address
+// Unknown and already verified addresses are ignored, so the caller cannot tell accounts apart
+func (s *service) ResendEmailVerification(ctx context.Context, email string) error {
+	user, err := s.userRepo.FindByEmail(ctx, strings.ToLower(strings.TrimSpace(email)))
+	if err != nil || user.EmailVerified || user.Disabled {
+		return nil
+	}
+
+	return s.SendEmailVerification(ctx, user)
+}
+
+// VerifyEmail marks the user's email as verified using a token from SendEmailVerification
+func (s *service) VerifyEmail(ctx context.Context, token string) error {
+	claims, err := s.validateToken(token, s.keys.access, "verify")
+	if err != nil {
+		return util.ErrorResponse("Invalid verification token", util.INVALID_TOKEN, 400, err.Error())
+	}
+
+	user, err := s.userRepo.FindByID(ctx, claims.UserID)
+	if err != nil {
+		return util.NewNotFoundError("User", claims.UserID)
+	}
+	if user.Email != claims.Email {
+		return util.ErrorResponse("Invalid verification token", util.INVALID_TOKEN, 400, "email address has changed since the token was issued")
+	}
+	if user.EmailVerified {
+		return nil
+	}
+
+	if err := s.userRepo.MarkEmailVerified(ctx, user.ID.String()); err != nil {
+		return util.NewDatabaseError("verify email", err)
+	}
+
+	return nil
+}
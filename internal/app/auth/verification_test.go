@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"e-document-backend/internal/domain"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// recordingNotifier keeps the verification tokens it was asked to deliver, by user ID
+type recordingNotifier struct {
+	tokens map[string]string
+}
+
+func (n *recordingNotifier) NotifyEmailVerification(ctx context.Context, user *domain.User, token string, expiresAt time.Time) error {
+	n.tokens[user.ID.String()] = token
+	return nil
+}
+
+func TestResendEmailVerification(t *testing.T) {
+	unverified := newTestUser(t, domain.RoleEmployee)
+	unverified.EmailVerified = false
+	verified := newTestUser(t, domain.RoleEmployee)
+	disabled := newTestUser(t, domain.RoleEmployee)
+	disabled.EmailVerified = false
+	disabled.Disabled = true
+
+	svc, repo := newTestService(t, newTestConfig(), unverified, verified, disabled)
+	notifier := &recordingNotifier{tokens: make(map[string]string)}
+	svc.notifier = notifier
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		email    string
+		wantSent string
+	}{
+		{name: "unverified account", email: "  " + strings.ToUpper(unverified.Email) + " ", wantSent: unverified.ID.String()},
+		{name: "verified account", email: verified.Email},
+		{name: "disabled account", email: disabled.Email},
+		{name: "unknown address", email: "nobody@example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clear(notifier.tokens)
+			if err := svc.ResendEmailVerification(ctx, tt.email); err != nil {
+				t.Fatalf("ResendEmailVerification() error = %v, want the same answer for every address", err)
+			}
+			if tt.wantSent == "" && len(notifier.tokens) != 0 {
+				t.Fatalf("sent a token to %v", notifier.tokens)
+			}
+			if tt.wantSent != "" && notifier.tokens[tt.wantSent] == "" {
+				t.Fatal("no token was sent")
+			}
+		})
+	}
+
+	// The resent token verifies the address
+	if err := svc.ResendEmailVerification(ctx, unverified.Email); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.VerifyEmail(ctx, notifier.tokens[unverified.ID.String()]); err != nil {
+		t.Fatalf("VerifyEmail() error = %v", err)
+	}
+	if !repo.Get(unverified.ID.String()).EmailVerified {
+		t.Fatal("email is not verified after using the resent token")
+	}
+}
+
+func TestLogVerificationNotifierOmitsToken(t *testing.T) {
+	var buf bytes.Buffer
+	previous := log.Logger
+	log.Logger = zerolog.New(&buf)
+	t.Cleanup(func() { log.Logger = previous })
+
+	u := newTestUser(t, domain.RoleEmployee)
+	token := "eyJhbGciOiJIUzI1NiJ9.secret.signature"
+	if err := NewLogVerificationNotifier().NotifyEmailVerification(context.Background(), &u, token, time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(buf.String(), token) {
+		t.Fatalf("log line contains the token: %s", buf.String())
+	}
+	if !strings.Contains(buf.String(), u.ID.String()) || !strings.Contains(buf.String(), "expires_at") {
+		t.Fatalf("log line = %s, want the user ID and expiry", buf.String())
+	}
+}
@@ -0,0 +1,58 @@
+package department
+
+import (
+	"e-document-backend/internal/util"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Handler handles HTTP requests for department operations
+type Handler struct {
+	service Service
+}
+
+// NewHandler creates a new department handler
+func NewHandler(service Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// RegisterRoutes registers department routes
+func (h *Handler) RegisterRoutes(e *echo.Group, authMiddleware echo.MiddlewareFunc) {
+	departments := e.Group("/v1/departments", authMiddleware)
+	departments.GET("/:id/members", h.ListMembers)
+}
+
+// ListMembers godoc
+//
+//	@Summary		List department members
+//	@Description	Get the users belonging to a department. Department managers can only list their own department, sector managers any department in their sector; Directors can list any department.
+//	@Tags			Departments
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		string	true	"Department ID"
+//	@Param			page	query		int		false	"Page number"		default(1)
+//	@Param			limit	query		int		false	"Items per page"	default(10)
+//	@Success		200		{object}	util.Response{data=util.PaginatedData}
+//	@Failure		401		{object}	util.ErrorEnvelope
+//	@Failure		403		{object}	util.ErrorEnvelope
+//	@Failure		500		{object}	util.ErrorEnvelope
+//	@Router			/v1/departments/{id}/members [get]
+func (h *Handler) ListMembers(c echo.Context) error {
+	departmentID := c.Param("id")
+
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	pagination := util.ParsePagination(c, util.PaginationDefaults{Limit: 10})
+
+	members, total, err := h.service.ListMembers(c.Request().Context(), departmentID, userID, pagination.Page, pagination.Limit)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	return util.OKResponseWithPagination(c, "Department members retrieved successfully", members, pagination.Info(total))
+}
@@ -0,0 +1,136 @@
+package department
+
+import (
+	"context"
+	"e-document-backend/internal/app/sector"
+	"e-document-backend/internal/app/user"
+	"e-document-backend/internal/domain"
+	"e-document-backend/internal/util"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	dbTimeout = 5 * time.Second // Database operation timeout
+)
+
+// Service defines business logic for department operations
+type Service interface {
+	ListMembers(ctx context.Context, departmentID string, requesterID string, page, limit int) ([]domain.UserSummary, int, error)
+}
+
+// DepartmentFinder looks up registered departments and the sector each belongs to
+type DepartmentFinder interface {
+	FindDepartmentByID(ctx context.Context, id string) (*domain.Department, error)
+}
+
+// service implements Service
+type service struct {
+	userRepo    user.Repository
+	departments DepartmentFinder
+}
+
+// NewService creates a new department service
+func NewService(userRepo user.Repository, departments DepartmentFinder) Service {
+	return &service{
+		userRepo:    userRepo,
+		departments: departments,
+	}
+}
+
+// ListMembers returns the users of a department
+// Directors may list any department, sector managers the departments of their sector, and
+// department managers only their own department
+func (s *service) ListMembers(ctx context.Context, departmentID string, requesterID string, page, limit int) ([]domain.UserSummary, int, error) {
+	dbCtx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	requester, err := s.userRepo.FindByID(dbCtx, requesterID)
+	if err != nil {
+		return nil, 0, util.NewUnauthorizedError("requesting user not found")
+	}
+
+	if err := s.authorizeMemberListing(dbCtx, requester, departmentID); err != nil {
+		return nil, 0, err
+	}
+
+	skip := (page - 1) * limit
+
+	var wg sync.WaitGroup
+	var total int
+	var users []domain.User
+	var countErr, findErr error
+
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+		total, countErr = s.userRepo.CountByDepartment(dbCtx, departmentID)
+	}()
+
+	go func() {
+		defer wg.Done()
+		users, findErr = s.userRepo.FindByDepartment(dbCtx, departmentID, skip, limit)
+	}()
+
+	wg.Wait()
+
+	if countErr != nil {
+		return nil, 0, util.NewDatabaseError("count department members", countErr)
+	}
+	if findErr != nil {
+		return nil, 0, util.NewDatabaseError("fetch department members", findErr)
+	}
+
+	members := make([]domain.UserSummary, len(users))
+	for i, u := range users {
+		members[i] = u.ToSummary()
+	}
+
+	return members, total, nil
+}
+
+// authorizeMemberListing checks whether the requester may see the roster of a department
+func (s *service) authorizeMemberListing(ctx context.Context, requester *domain.User, departmentID string) error {
+	switch requester.Role {
+	case domain.RoleDirector:
+		return nil
+	case domain.RoleSectorManager:
+		if requester.DepartmentID != "" && requester.DepartmentID == departmentID {
+			return nil
+		}
+		inSector, err := s.inSector(ctx, departmentID, requester.SectorID)
+		if err != nil {
+			return err
+		}
+		if !inSector {
+			return util.NewForbiddenError("you can only view members of departments in your own sector")
+		}
+		return nil
+	case domain.RoleDepartmentManager:
+		if requester.DepartmentID != "" && requester.DepartmentID == departmentID {
+			return nil
+		}
+		return util.NewForbiddenError("you can only view members of your own department")
+	default:
+		return util.NewForbiddenError("only managers and directors can view department members")
+	}
+}
+
+// inSector reports whether a registered department belongs to the given sector
+func (s *service) inSector(ctx context.Context, departmentID, sectorID string) (bool, error) {
+	if sectorID == "" {
+		return false, nil
+	}
+
+	department, err := s.departments.FindDepartmentByID(ctx, departmentID)
+	if err != nil {
+		if errors.Is(err, sector.ErrNotFound) {
+			return false, nil
+		}
+		return false, util.NewDatabaseError("find department", err)
+	}
+
+	return department.SectorID.String() == sectorID, nil
+}
@@ -0,0 +1,152 @@
+package department
+
+import (
+	"context"
+	"e-document-backend/internal/app/sector"
+	"e-document-backend/internal/app/user/usertest"
+	"e-document-backend/internal/domain"
+	"e-document-backend/internal/util"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fakeDepartments maps registered department IDs to their sector
+type fakeDepartments map[string]uuid.UUID
+
+func (d fakeDepartments) FindDepartmentByID(ctx context.Context, id string) (*domain.Department, error) {
+	sectorID, ok := d[id]
+	if !ok {
+		return nil, sector.ErrNotFound
+	}
+	return &domain.Department{ID: id, SectorID: sectorID}, nil
+}
+
+func newTestUser(role domain.UserRole, departmentID string, createdAt time.Time) domain.User {
+	id := uuid.New()
+	return domain.User{
+		ID:           id,
+		Username:     "user-" + id.String()[:8],
+		Email:        id.String()[:8] + "@example.com",
+		Role:         role,
+		DepartmentID: departmentID,
+		CreatedAt:    createdAt,
+	}
+}
+
+func TestListMembers(t *testing.T) {
+	now := time.Now()
+	director := newTestUser(domain.RoleDirector, "", now)
+	manager := newTestUser(domain.RoleDepartmentManager, "finance", now)
+	otherManager := newTestUser(domain.RoleDepartmentManager, "legal", now)
+	employee := newTestUser(domain.RoleEmployee, "finance", now)
+
+	// finance and accounting share a sector; legal is in another one
+	financeSector, legalSector := uuid.New(), uuid.New()
+	departments := fakeDepartments{"finance": financeSector, "accounting": financeSector, "legal": legalSector}
+	sectorManager := newTestUser(domain.RoleSectorManager, "finance", now)
+	sectorManager.SectorID = financeSector.String()
+	otherSectorManager := newTestUser(domain.RoleSectorManager, "", now)
+	otherSectorManager.SectorID = legalSector.String()
+	accountant := newTestUser(domain.RoleEmployee, "accounting", now)
+
+	users := []domain.User{director, manager, otherManager, sectorManager, otherSectorManager, employee, accountant}
+	for i := 0; i < 3; i++ {
+		users = append(users, newTestUser(domain.RoleEmployee, "finance", now.Add(-time.Duration(i+1)*time.Minute)))
+	}
+	svc := NewService(usertest.NewRepository(users...), departments)
+
+	departmentOf := make(map[uuid.UUID]string)
+	for _, u := range users {
+		departmentOf[u.ID] = u.DepartmentID
+	}
+
+	tests := []struct {
+		name        string
+		requester   domain.User
+		department  string
+		wantStatus  int
+		wantTotal   int
+		wantMembers int
+	}{
+		{name: "director lists any department", requester: director, department: "finance", wantTotal: 6, wantMembers: 6},
+		{name: "manager lists own department", requester: manager, department: "finance", wantTotal: 6, wantMembers: 6},
+		{name: "sector manager lists own department", requester: sectorManager, department: "finance", wantTotal: 6, wantMembers: 6},
+		{name: "sector manager lists another department of their sector", requester: sectorManager, department: "accounting", wantTotal: 1, wantMembers: 1},
+		{name: "sector manager of another sector is refused", requester: otherSectorManager, department: "finance", wantStatus: 403},
+		{name: "sector manager and an unregistered department", requester: sectorManager, department: "hr", wantStatus: 403},
+		{name: "manager of a department in the same sector is refused", requester: manager, department: "accounting", wantStatus: 403},
+		{name: "manager of another department is refused", requester: otherManager, department: "finance", wantStatus: 403},
+		{name: "employee is refused", requester: employee, department: "finance", wantStatus: 403},
+		{name: "empty department", requester: director, department: "hr", wantTotal: 0, wantMembers: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			members, total, err := svc.ListMembers(context.Background(), tt.department, tt.requester.ID.String(), 1, 10)
+			if tt.wantStatus != 0 {
+				assertStatus(t, err, tt.wantStatus)
+				return
+			}
+			if err != nil {
+				t.Fatalf("ListMembers() error = %v", err)
+			}
+			if total != tt.wantTotal || len(members) != tt.wantMembers {
+				t.Fatalf("ListMembers() = %d members, total %d; want %d, total %d", len(members), total, tt.wantMembers, tt.wantTotal)
+			}
+			for _, m := range members {
+				if departmentOf[m.ID] != tt.department {
+					t.Errorf("member %s belongs to department %q", m.ID, departmentOf[m.ID])
+				}
+			}
+		})
+	}
+}
+
+func TestListMembersPagination(t *testing.T) {
+	now := time.Now()
+	director := newTestUser(domain.RoleDirector, "", now)
+	users := []domain.User{director}
+	for i := 0; i < 5; i++ {
+		users = append(users, newTestUser(domain.RoleEmployee, "finance", now.Add(-time.Duration(i)*time.Minute)))
+	}
+	svc := NewService(usertest.NewRepository(users...), fakeDepartments{})
+
+	seen := make(map[uuid.UUID]bool)
+	for page := 1; page <= 3; page++ {
+		members, total, err := svc.ListMembers(context.Background(), "finance", director.ID.String(), page, 2)
+		if err != nil {
+			t.Fatalf("page %d: %v", page, err)
+		}
+		if total != 5 {
+			t.Fatalf("page %d: total = %d, want 5", page, total)
+		}
+		for _, m := range members {
+			if seen[m.ID] {
+				t.Fatalf("page %d repeats member %s", page, m.ID)
+			}
+			seen[m.ID] = true
+		}
+	}
+	if len(seen) != 5 {
+		t.Fatalf("pages returned %d distinct members, want 5", len(seen))
+	}
+}
+
+func TestListMembersUnknownRequester(t *testing.T) {
+	svc := NewService(usertest.NewRepository(), fakeDepartments{})
+	_, _, err := svc.ListMembers(context.Background(), "finance", uuid.NewString(), 1, 10)
+	assertStatus(t, err, 401)
+}
+
+func assertStatus(t *testing.T, err error, status int) {
+	t.Helper()
+	customErr, ok := err.(*util.CustomError)
+	if !ok {
+		t.Fatalf("error = %v, want a CustomError with status %d", err, status)
+	}
+	if customErr.StatusCode != status {
+		t.Fatalf("status = %d, want %d (%s)", customErr.StatusCode, status, customErr.Detail)
+	}
+}
@@ -25,12 +25,13 @@ func (h *Handler) RegisterRoutes(e *echo.Group, authMiddleware echo.MiddlewareFu
 
 	// Generate presigned URL by object path (key stored in DB)
 	files.GET("/presign", h.GetPresignedURL)
+	files.POST("/presign-batch", h.GetPresignedURLs)
 }
 
 // GetPresignedURLRequest represents query params for presign endpoint
 type GetPresignedURLRequest struct {
 	ObjectPath string `query:"object_path" validate:"required"`
-	Expiry     int64  `query:"expiry"` // seconds, optional (default 3600)
+	Expiry     int64  `query:"expiry"` // seconds, optional (configured default, usually 3600)
 }
 
 // GetPresignedURLResponse represents response for presign endpoint
@@ -39,21 +40,40 @@ type GetPresignedURLResponse struct {
 	ExpiresIn int64  `json:"expires_in"` // seconds
 }
 
+// GetPresignedURLsRequest represents the body of the batch presign endpoint
+type GetPresignedURLsRequest struct {
+	ObjectPaths []string `json:"object_paths" validate:"required,min=1,dive,required"`
+	Expiry      int64    `json:"expiry"` // seconds, optional (configured default, usually 3600)
+}
+
+// GetPresignedURLsResponse represents response for batch presign endpoint
+type GetPresignedURLsResponse struct {
+	URLs      map[string]string `json:"urls"`       // object path -> presigned URL
+	ExpiresIn int64             `json:"expires_in"` // seconds
+}
+
 // GetPresignedURL godoc
 //
 //	@Summary		Generate presigned URL for file
-//	@Description	Generate a temporary presigned URL from MinIO for downloading or viewing a file by its object path (key).
+//	@Description	Generate a temporary presigned URL from MinIO for downloading or viewing a file by its object path (key). The path must be an attachment of a document the caller registered, that is shared with them or that their department holds, or a profile picture; unknown paths return 404 and other users' files 403. An expiry outside PRESIGN_MIN_EXPIRY..PRESIGN_MAX_EXPIRY is rejected with INVALID_INPUT.
 //	@Tags			Files
 //	@Produce		json
 //	@Security		BearerAuth
 //	@Param			object_path	query		string	true	"Object path (key) in MinIO bucket (e.g. documents/12345_file.pdf)"
-//	@Param			expiry		query		int		false	"Expiry time in seconds (default: 3600)"
+//	@Param			expiry		query		int		false	"Expiry time in seconds within the configured bounds (default: PRESIGN_DEFAULT_EXPIRY, 3600)"
 //	@Success		200			{object}	util.Response{data=GetPresignedURLResponse}
-//	@Failure		400			{object}	util.Response
-//	@Failure		401			{object}	util.Response
-//	@Failure		500			{object}	util.Response
+//	@Failure		400			{object}	util.ErrorEnvelope
+//	@Failure		401			{object}	util.ErrorEnvelope
+//	@Failure		403			{object}	util.ErrorEnvelope
+//	@Failure		404			{object}	util.ErrorEnvelope
+//	@Failure		500			{object}	util.ErrorEnvelope
 //	@Router			/v1/files/presign [get]
 func (h *Handler) GetPresignedURL(c echo.Context) error {
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
 	var req GetPresignedURLRequest
 
 	if err := c.Bind(&req); err != nil {
@@ -64,8 +84,11 @@ func (h *Handler) GetPresignedURL(c echo.Context) error {
 		return util.HandleError(c, util.ErrorResponse("Validation failed", util.MISSING_REQUIRED_FIELD, http.StatusBadRequest, "object_path is required"))
 	}
 
-	url, expirySeconds, err := h.service.GeneratePresignedURL(c.Request().Context(), req.ObjectPath, req.Expiry)
+	url, expirySeconds, err := h.service.GeneratePresignedURL(c.Request().Context(), userID, req.ObjectPath, req.Expiry)
 	if err != nil {
+		if util.IsCustomError(err) {
+			return util.HandleError(c, err)
+		}
 		return util.HandleError(c, util.ErrorResponse("Failed to generate presigned URL", util.INTERNAL_SERVER_ERROR, http.StatusInternalServerError, err.Error()))
 	}
 
@@ -76,3 +99,51 @@ func (h *Handler) GetPresignedURL(c echo.Context) error {
 
 	return util.OKResponse(c, "Presigned URL generated successfully", resp)
 }
+
+// GetPresignedURLs godoc
+//
+//	@Summary		Generate presigned URLs for several files
+//	@Description	Generate temporary presigned URLs for up to 100 object paths in one request, all sharing the same expiry (bounded like the single presign endpoint). Every path is authorized like the single presign endpoint and one unreadable path rejects the batch. Useful for list and gallery views.
+//	@Tags			Files
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			body	body		GetPresignedURLsRequest	true	"Object paths and optional expiry"
+//	@Success		200		{object}	util.Response{data=GetPresignedURLsResponse}
+//	@Failure		400		{object}	util.ErrorEnvelope
+//	@Failure		401		{object}	util.ErrorEnvelope
+//	@Failure		403		{object}	util.ErrorEnvelope
+//	@Failure		404		{object}	util.ErrorEnvelope
+//	@Failure		500		{object}	util.ErrorEnvelope
+//	@Router			/v1/files/presign-batch [post]
+func (h *Handler) GetPresignedURLs(c echo.Context) error {
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	var req GetPresignedURLsRequest
+
+	if err := c.Bind(&req); err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid request body", util.INVALID_INPUT, http.StatusBadRequest, err.Error()))
+	}
+
+	if err := util.ValidateStruct(&req); err != nil {
+		return util.HandleError(c, err)
+	}
+
+	urls, expirySeconds, err := h.service.GeneratePresignedURLs(c.Request().Context(), userID, req.ObjectPaths, req.Expiry)
+	if err != nil {
+		if util.IsCustomError(err) {
+			return util.HandleError(c, err)
+		}
+		return util.HandleError(c, util.ErrorResponse("Failed to generate presigned URLs", util.INTERNAL_SERVER_ERROR, http.StatusInternalServerError, err.Error()))
+	}
+
+	resp := GetPresignedURLsResponse{
+		URLs:      urls,
+		ExpiresIn: expirySeconds,
+	}
+
+	return util.OKResponse(c, "Presigned URLs generated successfully", resp)
+}
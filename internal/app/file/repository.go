@@ -0,0 +1,77 @@
+package file
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository defines the database lookups that authorize presigning an object path
+type Repository interface {
+	GetPathAccess(ctx context.Context, requesterID uuid.UUID, objectPaths []string) (map[string]PathAccess, error)
+}
+
+// PathAccess describes what an object path refers to and whether the requester may read it
+type PathAccess struct {
+	Known    bool // the path is an attachment or a profile picture
+	Readable bool // the requester is the registrant, the document is shared with them or held by their department, or it is a profile picture
+}
+
+// repository implements the Repository interface for PostgreSQL
+type repository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRepository creates a new file repository
+func NewRepository(pool *pgxpool.Pool) Repository {
+	return &repository{
+		pool: pool,
+	}
+}
+
+// GetPathAccess resolves each object path to an attachment or a profile picture
+// Profile pictures are readable by any signed-in user, like /v1/users/:id/profile-picture
+func (r *repository) GetPathAccess(ctx context.Context, requesterID uuid.UUID, objectPaths []string) (map[string]PathAccess, error) {
+	query := `
+		SELECT p.path,
+			EXISTS (SELECT 1 FROM document_attachments da WHERE da.file_path = p.path)
+				OR EXISTS (SELECT 1 FROM users u WHERE u.profile_picture = p.path),
+			EXISTS (
+				SELECT 1 FROM document_attachments da
+				JOIN documents d ON d.id = da.document_id
+				WHERE da.file_path = p.path AND (
+					d.registrant_id = $1 OR EXISTS (
+						SELECT 1 FROM document_shares ds
+						WHERE ds.document_id = d.id AND ds.shared_with_user_id = $1
+					) OR EXISTS (
+						SELECT 1 FROM users ru
+						WHERE ru.id = $1 AND ru.department_id = d.current_department_id
+					)
+				)
+			) OR EXISTS (SELECT 1 FROM users u WHERE u.profile_picture = p.path)
+		FROM unnest($2::text[]) AS p(path)
+	`
+
+	rows, err := r.pool.Query(ctx, query, requesterID, objectPaths)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve object paths: %w", err)
+	}
+	defer rows.Close()
+
+	access := make(map[string]PathAccess, len(objectPaths))
+	for rows.Next() {
+		var path string
+		var pathAccess PathAccess
+		if err := rows.Scan(&path, &pathAccess.Known, &pathAccess.Readable); err != nil {
+			return nil, fmt.Errorf("failed to scan object path access: %w", err)
+		}
+		access[path] = pathAccess
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to resolve object paths: %w", err)
+	}
+
+	return access, nil
+}
@@ -2,12 +2,21 @@ package file
 
 import (
 	"context"
+	"e-document-backend/internal/config"
+	"e-document-backend/internal/util"
+	"fmt"
 	"time"
+
+	"github.com/google/uuid"
 )
 
+// MaxPresignBatchSize caps how many object paths one batch request may presign
+const MaxPresignBatchSize = 100
+
 // Service defines business logic for file operations
 type Service interface {
-	GeneratePresignedURL(ctx context.Context, objectPath string, expirySeconds int64) (string, int64, error)
+	GeneratePresignedURL(ctx context.Context, requesterID, objectPath string, expirySeconds int64) (string, int64, error)
+	GeneratePresignedURLs(ctx context.Context, requesterID string, objectPaths []string, expirySeconds int64) (map[string]string, int64, error)
 }
 
 // storageClient defines the minimal interface we need from MinIO client
@@ -17,21 +26,64 @@ type storageClient interface {
 
 // service implements Service
 type service struct {
+	repo    Repository
 	storage storageClient
+	presign config.PresignConfig
 }
 
 // NewService creates a new file service
-func NewService(storage storageClient) Service {
+func NewService(repo Repository, storage storageClient, presign config.PresignConfig) Service {
 	return &service{
+		repo:    repo,
 		storage: storage,
+		presign: presign,
 	}
 }
 
+// resolveExpiry applies the configured default and rejects expiries outside the configured bounds
+func (s *service) resolveExpiry(expirySeconds int64) (int64, error) {
+	expirySeconds, err := s.presign.ResolveExpiry(expirySeconds)
+	if err != nil {
+		return 0, util.NewInvalidInputError("expiry", err.Error())
+	}
+	return expirySeconds, nil
+}
+
+// authorizePaths rejects the batch unless every path is an attachment or profile picture the requester may read
+// Unknown paths are reported as not found, paths owned by someone else as forbidden
+func (s *service) authorizePaths(ctx context.Context, requesterID string, objectPaths []string) error {
+	requesterUUID, err := uuid.Parse(requesterID)
+	if err != nil {
+		return util.NewUnauthorizedError("invalid user ID in token")
+	}
+
+	access, err := s.repo.GetPathAccess(ctx, requesterUUID, objectPaths)
+	if err != nil {
+		return util.NewDatabaseError("resolve object paths", err)
+	}
+
+	for _, objectPath := range objectPaths {
+		pathAccess := access[objectPath]
+		if !pathAccess.Known {
+			return util.NewNotFoundError("File", objectPath)
+		}
+		if !pathAccess.Readable {
+			return util.NewForbiddenError(fmt.Sprintf("you do not have access to %s", objectPath))
+		}
+	}
+
+	return nil
+}
+
 // GeneratePresignedURL contains the main logic for creating a presigned URL
-func (s *service) GeneratePresignedURL(ctx context.Context, objectPath string, expirySeconds int64) (string, int64, error) {
-	// Default expiry: 1 hour
-	if expirySeconds <= 0 {
-		expirySeconds = 3600
+func (s *service) GeneratePresignedURL(ctx context.Context, requesterID, objectPath string, expirySeconds int64) (string, int64, error) {
+	expirySeconds, err := s.resolveExpiry(expirySeconds)
+	if err != nil {
+		return "", 0, err
+	}
+
+	if err := s.authorizePaths(ctx, requesterID, []string{objectPath}); err != nil {
+		return "", 0, err
 	}
 
 	url, err := s.storage.GetPresignedURL(ctx, objectPath, time.Duration(expirySeconds)*time.Second)
@@ -41,3 +93,36 @@ func (s *service) GeneratePresignedURL(ctx context.Context, objectPath string, e
 
 	return url, expirySeconds, nil
 }
+
+// GeneratePresignedURLs creates presigned URLs for several objects with a shared expiry
+// The whole batch is rejected if any path is not readable; duplicate paths are presigned once
+func (s *service) GeneratePresignedURLs(ctx context.Context, requesterID string, objectPaths []string, expirySeconds int64) (map[string]string, int64, error) {
+	if len(objectPaths) > MaxPresignBatchSize {
+		return nil, 0, util.NewInvalidInputError("object_paths", fmt.Sprintf("must contain at most %d paths", MaxPresignBatchSize))
+	}
+
+	expirySeconds, err := s.resolveExpiry(expirySeconds)
+	if err != nil {
+		return nil, 0, err
+	}
+	expiry := time.Duration(expirySeconds) * time.Second
+
+	if err := s.authorizePaths(ctx, requesterID, objectPaths); err != nil {
+		return nil, 0, err
+	}
+
+	urls := make(map[string]string, len(objectPaths))
+	for _, objectPath := range objectPaths {
+		if _, ok := urls[objectPath]; ok {
+			continue
+		}
+
+		url, err := s.storage.GetPresignedURL(ctx, objectPath, expiry)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to presign %s: %w", objectPath, err)
+		}
+		urls[objectPath] = url
+	}
+
+	return urls, expirySeconds, nil
+}
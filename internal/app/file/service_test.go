@@ -0,0 +1,118 @@
+package file
+
+import (
+	"context"
+	"e-document-backend/internal/config"
+	"e-document-backend/internal/util"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fakeRepository grants access from a fixed table of paths per requester
+type fakeRepository struct {
+	owners   map[string]uuid.UUID // attachment path -> registrant
+	pictures map[string]bool
+}
+
+func (r *fakeRepository) GetPathAccess(ctx context.Context, requesterID uuid.UUID, objectPaths []string) (map[string]PathAccess, error) {
+	access := make(map[string]PathAccess, len(objectPaths))
+	for _, path := range objectPaths {
+		if owner, ok := r.owners[path]; ok {
+			access[path] = PathAccess{Known: true, Readable: owner == requesterID}
+		} else if r.pictures[path] {
+			access[path] = PathAccess{Known: true, Readable: true}
+		}
+	}
+	return access, nil
+}
+
+// fakeStorage records which paths were presigned
+type fakeStorage struct {
+	presigned []string
+}
+
+func (s *fakeStorage) GetPresignedURL(ctx context.Context, objectPath string, expiry time.Duration) (string, error) {
+	s.presigned = append(s.presigned, objectPath)
+	return "https://minio.example.com/" + objectPath, nil
+}
+
+func TestGeneratePresignedURLAccess(t *testing.T) {
+	owner := uuid.New()
+	stranger := uuid.New()
+	repo := &fakeRepository{
+		owners:   map[string]uuid.UUID{"uploads/contract.pdf": owner},
+		pictures: map[string]bool{"profile-pictures/avatar.jpg": true},
+	}
+
+	tests := []struct {
+		name        string
+		requesterID string
+		objectPath  string
+		wantStatus  int
+	}{
+		{name: "registrant reads their attachment", requesterID: owner.String(), objectPath: "uploads/contract.pdf"},
+		{name: "anyone reads a profile picture", requesterID: stranger.String(), objectPath: "profile-pictures/avatar.jpg"},
+		{name: "other user's attachment", requesterID: stranger.String(), objectPath: "uploads/contract.pdf", wantStatus: 403},
+		{name: "unknown path", requesterID: owner.String(), objectPath: "exports/other.zip", wantStatus: 404},
+		{name: "invalid requester", requesterID: "not-a-uuid", objectPath: "uploads/contract.pdf", wantStatus: 401},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := &fakeStorage{}
+			svc := NewService(repo, storage, config.PresignConfig{DefaultExpiry: 3600, MinExpiry: 60, MaxExpiry: 86400})
+
+			url, _, err := svc.GeneratePresignedURL(context.Background(), tt.requesterID, tt.objectPath, 0)
+			if tt.wantStatus != 0 {
+				assertStatus(t, err, tt.wantStatus)
+				if len(storage.presigned) != 0 {
+					t.Fatalf("presigned %v for a rejected request", storage.presigned)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GeneratePresignedURL() error = %v", err)
+			}
+			if url != "https://minio.example.com/"+tt.objectPath {
+				t.Fatalf("url = %q", url)
+			}
+		})
+	}
+}
+
+func TestGeneratePresignedURLsRejectsBatch(t *testing.T) {
+	owner := uuid.New()
+	repo := &fakeRepository{owners: map[string]uuid.UUID{
+		"uploads/mine.pdf":   owner,
+		"uploads/theirs.pdf": uuid.New(),
+	}}
+	storage := &fakeStorage{}
+	svc := NewService(repo, storage, config.PresignConfig{DefaultExpiry: 3600, MinExpiry: 60, MaxExpiry: 86400})
+
+	_, _, err := svc.GeneratePresignedURLs(context.Background(), owner.String(), []string{"uploads/mine.pdf", "uploads/theirs.pdf"}, 0)
+	assertStatus(t, err, 403)
+	if len(storage.presigned) != 0 {
+		t.Fatalf("presigned %v although the batch was rejected", storage.presigned)
+	}
+
+	urls, _, err := svc.GeneratePresignedURLs(context.Background(), owner.String(), []string{"uploads/mine.pdf", "uploads/mine.pdf"}, 0)
+	if err != nil {
+		t.Fatalf("GeneratePresignedURLs() error = %v", err)
+	}
+	if len(urls) != 1 || len(storage.presigned) != 1 {
+		t.Fatalf("urls = %v after presigning %v, want one URL", urls, storage.presigned)
+	}
+}
+
+func assertStatus(t *testing.T, err error, status int) {
+	t.Helper()
+	customErr, ok := err.(*util.CustomError)
+	if !ok {
+		t.Fatalf("error = %v, want a CustomError with status %d", err, status)
+	}
+	if customErr.StatusCode != status {
+		t.Fatalf("status = %d, want %d (%s)", customErr.StatusCode, status, customErr.Detail)
+	}
+}
@@ -0,0 +1,117 @@
+package folder_file_manage
+
+import (
+	"context"
+	"crypto/rand"
+	"e-document-backend/internal/domain"
+	"e-document-backend/internal/util"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// maxBarcodeAttempts bounds retries when a generated barcode collides with an existing one
+const maxBarcodeAttempts = 5
+
+// SetDocumentBarcode stores a barcode on a document and marks it as a Barcode document
+func (r *repository) SetDocumentBarcode(ctx context.Context, documentID uuid.UUID, barcode string) error {
+	query := `
+		UPDATE documents
+		SET barcode = $2, type = $3, updated_at = NOW()
+		WHERE id = $1
+	`
+
+	result, err := r.pool.Exec(ctx, query, documentID, barcode, domain.DocumentTypeBarcode)
+	if err != nil {
+		return fmt.Errorf("failed to set document barcode: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("document not found")
+	}
+
+	return nil
+}
+
+// GetDocumentIDByBarcode resolves a barcode to its document ID
+func (r *repository) GetDocumentIDByBarcode(ctx context.Context, barcode string) (uuid.UUID, error) {
+	query := `SELECT id FROM documents WHERE barcode = $1`
+
+	var documentID uuid.UUID
+	if err := r.pool.QueryRow(ctx, query, barcode).Scan(&documentID); err != nil {
+		if err == pgx.ErrNoRows {
+			return uuid.Nil, fmt.Errorf("document not found")
+		}
+		return uuid.Nil, fmt.Errorf("failed to get document by barcode: %w", err)
+	}
+
+	return documentID, nil
+}
+
+// GetDocumentByBarcode retrieves a scanned document with the same access rules as GetDocument
+func (s *service) GetDocumentByBarcode(ctx context.Context, barcode string, requesterID uuid.UUID) (*DocumentWithAttachment, error) {
+	documentID, err := s.repo.GetDocumentIDByBarcode(ctx, barcode)
+	if err != nil {
+		return nil, util.NewNotFoundError("Document", barcode)
+	}
+
+	return s.GetDocument(ctx, documentID, requesterID)
+}
+
+// GenerateDocumentBarcode assigns a unique barcode to a document owned by the requester
+// A document that already has a barcode keeps it, so printed labels stay valid
+func (s *service) GenerateDocumentBarcode(ctx context.Context, documentID, ownerID uuid.UUID) (string, error) {
+	doc, err := s.repo.GetDocumentByID(ctx, documentID)
+	if err != nil {
+		return "", util.NewNotFoundError("Document", documentID.String())
+	}
+	if doc.RegistrantID == nil || *doc.RegistrantID != ownerID {
+		return "", util.NewForbiddenError("you can only generate barcodes for your own documents")
+	}
+
+	if doc.Barcode != nil && *doc.Barcode != "" {
+		if doc.Type != domain.DocumentTypeBarcode {
+			if err := s.repo.SetDocumentBarcode(ctx, documentID, *doc.Barcode); err != nil {
+				return "", util.NewDatabaseError("set document barcode", err)
+			}
+		}
+		return *doc.Barcode, nil
+	}
+
+	for attempt := 0; attempt < maxBarcodeAttempts; attempt++ {
+		barcode, err := newBarcodeValue()
+		if err != nil {
+			return "", util.NewInternalError(err.Error())
+		}
+
+		err = s.repo.SetDocumentBarcode(ctx, documentID, barcode)
+		if err == nil {
+			return barcode, nil
+		}
+
+		// Another document already uses this value, try a new one
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			continue
+		}
+		return "", util.NewDatabaseError("set document barcode", err)
+	}
+
+	return "", util.NewInternalError("failed to generate a unique barcode")
+}
+
+// newBarcodeValue returns a barcode value such as ED2610160A1B2C3D4E: a date prefix and random hex
+func newBarcodeValue() (string, error) {
+	random := make([]byte, 5)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("failed to generate barcode: %w", err)
+	}
+
+	return "ED" + time.Now().Format("060102") + strings.ToUpper(hex.EncodeToString(random)), nil
+}
@@ -0,0 +1,124 @@
+package folder_file_manage
+
+import (
+	"context"
+	"e-document-backend/internal/domain"
+	"e-document-backend/internal/util"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// FolderContentsQuery selects which lists of a folder's contents are loaded and pages each one
+type FolderContentsQuery struct {
+	IncludeSubfolders bool
+	IncludeDocuments  bool
+	Subfolders        util.Pagination
+	Documents         util.Pagination
+}
+
+// parseFolderContentsQuery reads the include, folder_page, doc_page and page size query parameters
+// include lists subfolders and/or documents (comma-separated) and defaults to both; folder_page_size
+// and doc_page_size override the shared page_size for one list
+func parseFolderContentsQuery(c echo.Context) (FolderContentsQuery, error) {
+	query := FolderContentsQuery{IncludeSubfolders: true, IncludeDocuments: true}
+
+	if include := strings.TrimSpace(c.QueryParam("include")); include != "" {
+		query.IncludeSubfolders, query.IncludeDocuments = false, false
+		for _, part := range strings.Split(include, ",") {
+			switch strings.ToLower(strings.TrimSpace(part)) {
+			case "subfolders":
+				query.IncludeSubfolders = true
+			case "documents":
+				query.IncludeDocuments = true
+			case "":
+			default:
+				return query, util.NewInvalidInputError("include", "must list subfolders, documents or both")
+			}
+		}
+		if !query.IncludeSubfolders && !query.IncludeDocuments {
+			return query, util.NewInvalidInputError("include", "must list subfolders, documents or both")
+		}
+	}
+
+	pageSize := util.ParsePagination(c, util.PaginationDefaults{}).Limit
+	query.Subfolders = util.NewPagination(util.ParsePageParam(c, "folder_page"), util.ParsePageSizeParam(c, "folder_page_size", pageSize))
+	query.Documents = util.NewPagination(util.ParsePageParam(c, "doc_page"), util.ParsePageSizeParam(c, "doc_page_size", pageSize))
+
+	return query, nil
+}
+
+// GetFolderContents retrieves the contents of a folder owned by the requester
+// Only the included lists are loaded, each paged on its own; the other list is only counted
+func (s *service) GetFolderContents(ctx context.Context, folderID, requesterID uuid.UUID, query FolderContentsQuery) (*FolderContents, error) {
+	folder, err := s.getOwnedFolder(ctx, folderID, requesterID)
+	if err != nil {
+		return nil, err
+	}
+
+	contents := &FolderContents{Folder: folder}
+	if err := s.loadSubfolders(ctx, contents, query); err != nil {
+		return nil, err
+	}
+	if err := s.loadDocuments(ctx, contents, query); err != nil {
+		return nil, err
+	}
+
+	return contents, nil
+}
+
+// loadSubfolders fills in the subfolder page of the contents, or only their count when excluded
+func (s *service) loadSubfolders(ctx context.Context, contents *FolderContents, query FolderContentsQuery) error {
+	folderID := contents.Folder.ID
+	if !query.IncludeSubfolders {
+		total, err := s.repo.CountSubfolders(ctx, folderID)
+		if err != nil {
+			return util.NewDatabaseError("count subfolders", err)
+		}
+		contents.SubfolderCount = total
+		return nil
+	}
+
+	subfolders, total, err := s.repo.GetSubfolders(ctx, folderID, query.Subfolders.Limit, query.Subfolders.Offset)
+	if err != nil {
+		return util.NewDatabaseError("get subfolders", err)
+	}
+	// An included but empty list is sent as [] so it can be told apart from an excluded one
+	if subfolders == nil {
+		subfolders = []*domain.Folder{}
+	}
+
+	info := query.Subfolders.Info(total)
+	contents.Subfolders = subfolders
+	contents.SubfolderCount = total
+	contents.SubfolderPagination = &info
+	return nil
+}
+
+// loadDocuments fills in the document page of the contents, or only their count when excluded
+func (s *service) loadDocuments(ctx context.Context, contents *FolderContents, query FolderContentsQuery) error {
+	folderID := contents.Folder.ID
+	if !query.IncludeDocuments {
+		total, err := s.repo.CountFolderDocuments(ctx, folderID)
+		if err != nil {
+			return util.NewDatabaseError("count documents", err)
+		}
+		contents.DocumentCount = total
+		return nil
+	}
+
+	documents, total, err := s.repo.GetDocumentsByFolderID(ctx, folderID, query.Documents.Limit, query.Documents.Offset)
+	if err != nil {
+		return util.NewDatabaseError("get documents", err)
+	}
+	if documents == nil {
+		documents = []*DocumentWithAttachment{}
+	}
+
+	info := query.Documents.Info(total)
+	contents.Documents = documents
+	contents.DocumentCount = total
+	contents.DocumentPagination = &info
+	return nil
+}
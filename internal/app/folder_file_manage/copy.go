@@ -0,0 +1,94 @@
+package folder_file_manage
+
+import (
+	"context"
+	"e-document-backend/internal/domain"
+	"e-document-backend/internal/platform/postgres"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrSourceAttachmentGone is returned when the attachment being copied was deleted before the copy
+var ErrSourceAttachmentGone = errors.New("source attachment no longer exists")
+
+// CopyDocument inserts doc as a new document and, if sourceAttachmentID is set, gives it a copy of
+// that attachment as its first version. The copy references the same stored object, which stays
+// until no attachment references it, so nothing is duplicated in MinIO
+func (r *repository) CopyDocument(ctx context.Context, doc *domain.Document, sourceAttachmentID *uuid.UUID, uploadedBy uuid.UUID) (*domain.DocumentAttachment, error) {
+	var attachment *domain.DocumentAttachment
+
+	err := postgres.WithTx(ctx, r.pool, func(tx pgx.Tx) error {
+		documentQuery := `
+			INSERT INTO documents (title, description, type, category_id, folder_id, registrant_id, status)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			RETURNING id, created_at, updated_at
+		`
+		err := tx.QueryRow(ctx, documentQuery,
+			doc.Title,
+			doc.Description,
+			doc.Type,
+			doc.CategoryID,
+			doc.FolderID,
+			doc.RegistrantID,
+			doc.Status,
+		).Scan(&doc.ID, &doc.CreatedAt, &doc.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to create document copy: %w", err)
+		}
+
+		if sourceAttachmentID == nil {
+			return nil
+		}
+
+		// Lock the shared object as postgres.LockObjectPaths does, so a concurrent delete of the
+		// source either sees this copy's reference or removes the source row before it is copied
+		lockQuery := `SELECT pg_advisory_xact_lock(hashtext(file_path)) FROM document_attachments WHERE id = $1`
+		if _, err := tx.Exec(ctx, lockQuery, *sourceAttachmentID); err != nil {
+			return fmt.Errorf("failed to lock attachment file: %w", err)
+		}
+
+		// upload_id stays NULL: it identifies the tusd upload of the original attachment only
+		attachmentQuery := `
+			INSERT INTO document_attachments (
+				document_id, file_name, file_path, file_size, file_type,
+				version, is_current, uploaded_by, content_hash
+			)
+			SELECT $2, file_name, file_path, file_size, file_type, 1, true, $3, content_hash
+			FROM document_attachments
+			WHERE id = $1
+			RETURNING id, document_id, file_name, file_path, file_size, file_type,
+				version, is_current, uploaded_by, created_at, content_hash
+		`
+		var copied domain.DocumentAttachment
+		err = tx.QueryRow(ctx, attachmentQuery, *sourceAttachmentID, doc.ID, uploadedBy).Scan(
+			&copied.ID,
+			&copied.DocumentID,
+			&copied.FileName,
+			&copied.FilePath,
+			&copied.FileSize,
+			&copied.FileType,
+			&copied.Version,
+			&copied.IsCurrent,
+			&copied.UploadedBy,
+			&copied.CreatedAt,
+			&copied.ContentHash,
+		)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrSourceAttachmentGone
+		}
+		if err != nil {
+			return fmt.Errorf("failed to copy attachment: %w", err)
+		}
+		attachment = &copied
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return attachment, nil
+}
@@ -0,0 +1,36 @@
+package folder_file_manage
+
+import (
+	"context"
+	"e-document-backend/internal/domain"
+	"e-document-backend/internal/platform/postgres/pgtest"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestCopyDocumentSourceGone(t *testing.T) {
+	pool := pgtest.NewPool(t)
+	repo := NewRepository(pool)
+	ctx := context.Background()
+
+	ownerID := seedUser(t, pool)
+	folder := seedFolder(t, repo, ownerID, nil, "copies")
+
+	// The source attachment was deleted after the service read it
+	goneID := uuid.New()
+	doc := &domain.Document{Title: "Budget 2026", FolderID: &folder.ID, RegistrantID: &ownerID, Status: domain.DocumentStatusDraft}
+	_, err := repo.CopyDocument(ctx, doc, &goneID, ownerID)
+	if !errors.Is(err, ErrSourceAttachmentGone) {
+		t.Fatalf("CopyDocument() error = %v, want ErrSourceAttachmentGone", err)
+	}
+
+	var documents int
+	if err := pool.QueryRow(ctx, "SELECT COUNT(*) FROM documents WHERE folder_id = $1", folder.ID).Scan(&documents); err != nil {
+		t.Fatal(err)
+	}
+	if documents != 0 {
+		t.Fatalf("folder holds %d documents, want the copy rolled back", documents)
+	}
+}
@@ -0,0 +1,151 @@
+package folder_file_manage
+
+import (
+	"context"
+	"e-document-backend/internal/domain"
+	"e-document-backend/internal/util"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DocumentCursor is a keyset position in the owner's documents, ordered by updated_at then id, newest first
+type DocumentCursor struct {
+	UpdatedAt time.Time
+	ID        uuid.UUID
+}
+
+// String encodes the cursor as "<updated_at>,<id>" with the timestamp in RFC 3339 UTC
+func (dc DocumentCursor) String() string {
+	return dc.UpdatedAt.UTC().Format(time.RFC3339Nano) + "," + dc.ID.String()
+}
+
+// ParseDocumentCursor decodes a cursor produced by DocumentCursor.String
+func ParseDocumentCursor(value string) (*DocumentCursor, error) {
+	updatedAt, id, ok := strings.Cut(value, ",")
+	if !ok {
+		return nil, fmt.Errorf("cursor must be <updated_at>,<id>")
+	}
+
+	parsedTime, err := time.Parse(time.RFC3339Nano, updatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor timestamp: %w", err)
+	}
+
+	parsedID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor id: %w", err)
+	}
+
+	return &DocumentCursor{UpdatedAt: parsedTime, ID: parsedID}, nil
+}
+
+// GetDocumentsAfter retrieves up to limit documents of a user that come after the cursor
+// A nil cursor starts from the most recently updated document
+func (r *repository) GetDocumentsAfter(ctx context.Context, ownerID uuid.UUID, tags DocumentTagFilter, cursor *DocumentCursor, limit int) ([]*DocumentWithAttachment, error) {
+	query := `
+		SELECT 
+			d.id, d.title, d.description, d.type, d.category_id, d.folder_id, 
+			d.barcode, d.registrant_id, d.current_department_id, d.status, 
+			d.created_at, d.updated_at,
+			da.id, da.document_id, da.file_name, da.file_path, da.file_size, 
+			da.file_type, da.version, da.is_current, da.uploaded_by, da.created_at
+		FROM documents d
+		LEFT JOIN document_attachments da ON d.id = da.document_id AND da.is_current = true
+		WHERE d.registrant_id = $1
+		  AND ($2::timestamptz IS NULL OR (d.updated_at, d.id) < ($2, $3))
+		  AND ` + tagFilterCondition(5, 6) + `
+		ORDER BY d.updated_at DESC, d.id DESC
+		LIMIT $4
+	`
+
+	var afterTime *time.Time
+	afterID := uuid.Nil
+	if cursor != nil {
+		afterTime = &cursor.UpdatedAt
+		afterID = cursor.ID
+	}
+
+	rows, err := r.pool.Query(ctx, query, ownerID, afterTime, afterID, limit, tags.Names, tags.MatchAll)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get documents: %w", err)
+	}
+	defer rows.Close()
+
+	var documents []*DocumentWithAttachment
+	for rows.Next() {
+		var doc DocumentWithAttachment
+		doc.Document = &domain.Document{}
+		var attachment domain.DocumentAttachment
+
+		err := rows.Scan(
+			&doc.ID,
+			&doc.Title,
+			&doc.Description,
+			&doc.Type,
+			&doc.CategoryID,
+			&doc.FolderID,
+			&doc.Barcode,
+			&doc.RegistrantID,
+			&doc.CurrentDepartmentID,
+			&doc.Status,
+			&doc.CreatedAt,
+			&doc.UpdatedAt,
+			&attachment.ID,
+			&attachment.DocumentID,
+			&attachment.FileName,
+			&attachment.FilePath,
+			&attachment.FileSize,
+			&attachment.FileType,
+			&attachment.Version,
+			&attachment.IsCurrent,
+			&attachment.UploadedBy,
+			&attachment.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan document: %w", err)
+		}
+
+		// Check if attachment exists
+		if attachment.ID != uuid.Nil {
+			doc.Attachment = &attachment
+		}
+
+		documents = append(documents, &doc)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating documents: %w", err)
+	}
+
+	return documents, nil
+}
+
+// GetDocumentsByCursor retrieves a page of a user's documents using keyset pagination
+// Returns the cursor of the next page, or an empty string on the last page
+func (s *service) GetDocumentsByCursor(ctx context.Context, ownerID uuid.UUID, tags DocumentTagFilter, cursor string, pageSize int) ([]*DocumentWithAttachment, string, error) {
+	var after *DocumentCursor
+	if cursor != "" {
+		parsed, err := ParseDocumentCursor(cursor)
+		if err != nil {
+			return nil, "", util.NewInvalidInputError("cursor", err.Error())
+		}
+		after = parsed
+	}
+
+	// Fetch one extra row to know whether another page exists
+	documents, err := s.repo.GetDocumentsAfter(ctx, ownerID, tags, after, pageSize+1)
+	if err != nil {
+		return nil, "", util.NewDatabaseError("get documents", err)
+	}
+
+	if len(documents) <= pageSize {
+		return documents, "", nil
+	}
+
+	documents = documents[:pageSize]
+	last := documents[pageSize-1]
+	return documents, DocumentCursor{UpdatedAt: last.UpdatedAt, ID: last.ID}.String(), nil
+}
@@ -0,0 +1,47 @@
+package folder_file_manage
+
+import (
+	"e-document-backend/internal/domain"
+	"e-document-backend/internal/util"
+	"strconv"
+)
+
+// documentETagParts lists the values that change whenever a document, its current attachment, its tags or its folders change
+func documentETagParts(doc *DocumentWithAttachment) []string {
+	parts := []string{doc.ID.String(), util.ETagPart(doc.UpdatedAt)}
+	if doc.Attachment != nil {
+		parts = append(parts, doc.Attachment.ID.String(), strconv.Itoa(doc.Attachment.Version))
+	}
+	// Tags, folder names and expanded user names change independently of the document
+	parts = append(parts, doc.Tags...)
+	for _, crumb := range doc.Breadcrumbs {
+		parts = append(parts, crumb.ID.String(), crumb.Name)
+	}
+	for _, ref := range []*domain.UserRef{doc.Registrant, doc.Uploader} {
+		if ref != nil {
+			parts = append(parts, ref.ID.String(), ref.FirstName, ref.LastName)
+		}
+	}
+	return parts
+}
+
+// documentETag builds the weak ETag of a document response
+func documentETag(doc *DocumentWithAttachment) string {
+	return util.WeakETag(documentETagParts(doc)...)
+}
+
+// folderContentsETag builds the weak ETag of a folder contents page from the folder, every
+// listed subfolder and document, and the counts, so adding or removing items changes it too
+func folderContentsETag(contents *FolderContents) string {
+	parts := []string{
+		contents.Folder.ID.String(), util.ETagPart(contents.Folder.UpdatedAt),
+		strconv.Itoa(contents.SubfolderCount), strconv.Itoa(contents.DocumentCount),
+	}
+	for _, folder := range contents.Subfolders {
+		parts = append(parts, folder.ID.String(), util.ETagPart(folder.UpdatedAt))
+	}
+	for _, doc := range contents.Documents {
+		parts = append(parts, documentETagParts(doc)...)
+	}
+	return util.WeakETag(parts...)
+}
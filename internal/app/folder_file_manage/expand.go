@@ -0,0 +1,89 @@
+package folder_file_manage
+
+import (
+	"context"
+	"e-document-backend/internal/domain"
+	"e-document-backend/internal/util"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// expandUploader is the ?expand value that embeds the registrant and uploader of documents
+const expandUploader = "uploader"
+
+// wantsExpand reports whether the comma-separated expand query parameter lists name
+func wantsExpand(c echo.Context, name string) bool {
+	for _, value := range strings.Split(c.QueryParam("expand"), ",") {
+		if strings.TrimSpace(value) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// GetUserRefs loads the minimal view of the given users in one query; unknown IDs are left out
+func (r *repository) GetUserRefs(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]*domain.UserRef, error) {
+	query := `SELECT id, first_name, last_name FROM users WHERE id = ANY($1)`
+
+	rows, err := r.pool.Query(ctx, query, userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users: %w", err)
+	}
+	defer rows.Close()
+
+	refs := make(map[uuid.UUID]*domain.UserRef, len(userIDs))
+	for rows.Next() {
+		var ref domain.UserRef
+		if err := rows.Scan(&ref.ID, &ref.FirstName, &ref.LastName); err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		refs[ref.ID] = &ref
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating users: %w", err)
+	}
+
+	return refs, nil
+}
+
+// ExpandUploaders embeds the registrant and current uploader of each document
+// All users are loaded with a single query however many documents are listed
+func (s *service) ExpandUploaders(ctx context.Context, docs ...*DocumentWithAttachment) error {
+	seen := make(map[uuid.UUID]bool)
+	var userIDs []uuid.UUID
+	collect := func(id *uuid.UUID) {
+		if id != nil && !seen[*id] {
+			seen[*id] = true
+			userIDs = append(userIDs, *id)
+		}
+	}
+	for _, doc := range docs {
+		collect(doc.RegistrantID)
+		if doc.Attachment != nil {
+			collect(doc.Attachment.UploadedBy)
+		}
+	}
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	refs, err := s.repo.GetUserRefs(ctx, userIDs)
+	if err != nil {
+		return util.NewDatabaseError("get uploaders", err)
+	}
+
+	for _, doc := range docs {
+		if doc.RegistrantID != nil {
+			doc.Registrant = refs[*doc.RegistrantID]
+		}
+		if doc.Attachment != nil && doc.Attachment.UploadedBy != nil {
+			doc.Uploader = refs[*doc.Attachment.UploadedBy]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package folder_file_manage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// exportFetchSize is how many rows are fetched from the server-side cursor at a time
+const exportFetchSize = 500
+
+// DocumentExportFilter narrows a document export
+type DocumentExportFilter struct {
+	FolderID *uuid.UUID // only documents directly in this folder
+	Status   *string    // only documents with this status
+}
+
+// DocumentExportRow is one row of a document export
+type DocumentExportRow struct {
+	DocumentID uuid.UUID
+	Title      string
+	Type       string
+	Status     string
+	FolderPath *string
+	FileName   *string
+	FileType   *string
+	FileSize   *int64
+	Version    *int
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// StreamDocuments walks the owner's documents through a server-side cursor, calling fn for each row
+// Only exportFetchSize rows are held in memory at once regardless of how many documents exist
+func (r *repository) StreamDocuments(ctx context.Context, ownerID uuid.UUID, filter DocumentExportFilter, fn func(*DocumentExportRow) error) error {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return fmt.Errorf("failed to begin export transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		DECLARE document_export NO SCROLL CURSOR FOR
+		SELECT
+			d.id, d.title, d.type, d.status, f.path,
+			da.file_name, da.file_type, da.file_size, da.version,
+			d.created_at, d.updated_at
+		FROM documents d
+		LEFT JOIN folders f ON f.id = d.folder_id
+		LEFT JOIN document_attachments da ON d.id = da.document_id AND da.is_current = true
+		WHERE d.registrant_id = $1
+			AND ($2::uuid IS NULL OR d.folder_id = $2)
+			AND ($3::text IS NULL OR d.status::text = $3)
+		ORDER BY d.created_at, d.id
+	`
+
+	if _, err := tx.Exec(ctx, query, ownerID, filter.FolderID, filter.Status); err != nil {
+		return fmt.Errorf("failed to open export cursor: %w", err)
+	}
+
+	fetch := fmt.Sprintf("FETCH %d FROM document_export", exportFetchSize)
+	for {
+		rows, err := tx.Query(ctx, fetch)
+		if err != nil {
+			return fmt.Errorf("failed to fetch export rows: %w", err)
+		}
+
+		fetched := 0
+		for rows.Next() {
+			var row DocumentExportRow
+			err := rows.Scan(
+				&row.DocumentID,
+				&row.Title,
+				&row.Type,
+				&row.Status,
+				&row.FolderPath,
+				&row.FileName,
+				&row.FileType,
+				&row.FileSize,
+				&row.Version,
+				&row.CreatedAt,
+				&row.UpdatedAt,
+			)
+			if err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan export row: %w", err)
+			}
+			fetched++
+
+			if err := fn(&row); err != nil {
+				rows.Close()
+				return err
+			}
+		}
+		rows.Close()
+
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error iterating export rows: %w", err)
+		}
+
+		if fetched < exportFetchSize {
+			return nil
+		}
+	}
+}
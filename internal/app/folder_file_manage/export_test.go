@@ -0,0 +1,132 @@
+package folder_file_manage
+
+import (
+	"bytes"
+	"context"
+	"e-document-backend/internal/platform/postgres/pgtest"
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// streamRecorder is a response writer that discards the body, recording how much of it
+// was written between flushes and how large the live heap was at each flush
+type streamRecorder struct {
+	header        http.Header
+	status        int
+	lines         int
+	linesAtFlush  int
+	maxUnflushed  int
+	flushes       int
+	baseHeap      uint64
+	maxHeapGrowth uint64
+	head          bytes.Buffer
+}
+
+func (r *streamRecorder) Header() http.Header { return r.header }
+
+func (r *streamRecorder) WriteHeader(status int) { r.status = status }
+
+func (r *streamRecorder) Write(p []byte) (int, error) {
+	if r.head.Len() < 4096 {
+		r.head.Write(p)
+	}
+	r.lines += bytes.Count(p, []byte("\n"))
+	return len(p), nil
+}
+
+func (r *streamRecorder) Flush() {
+	r.flushes++
+	if unflushed := r.lines - r.linesAtFlush; unflushed > r.maxUnflushed {
+		r.maxUnflushed = unflushed
+	}
+	r.linesAtFlush = r.lines
+
+	// Sample the live heap, so garbage does not count
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	if stats.HeapAlloc > r.baseHeap && stats.HeapAlloc-r.baseHeap > r.maxHeapGrowth {
+		r.maxHeapGrowth = stats.HeapAlloc - r.baseHeap
+	}
+}
+
+func TestExportDocumentsCSVStreams(t *testing.T) {
+	pool := pgtest.NewPool(t)
+	repo := NewRepository(pool)
+	ctx := context.Background()
+
+	ownerID := seedUser(t, pool)
+	otherID := seedUser(t, pool)
+	folder := seedFolder(t, repo, ownerID, nil, "archive")
+
+	// ~12 MB of CSV: far more than the streaming path may hold at once
+	const documents = 50000
+	title := strings.Repeat("t", 240)
+	if _, err := pool.Exec(ctx, `
+		INSERT INTO documents (title, folder_id, registrant_id, status)
+		SELECT $1 || i, $2, $3, CASE WHEN i % 2 = 0 THEN 'Approved' ELSE 'Draft' END::document_status
+		FROM generate_series(1, $4::int) AS i
+	`, title, folder.ID, ownerID, documents); err != nil {
+		t.Fatalf("failed to seed documents: %v", err)
+	}
+	seedDocuments(t, pool, otherID, seedFolder(t, repo, otherID, nil, "other"), 3)
+
+	h := NewHandler(NewService(repo, nil, nil))
+
+	tests := []struct {
+		name     string
+		query    string
+		wantRows int
+	}{
+		{name: "all documents", query: "", wantRows: documents},
+		{name: "status filter", query: "?status=Approved", wantRows: documents / 2},
+		{name: "folder filter", query: "?folder_id=" + folder.ID.String(), wantRows: documents},
+		{name: "unknown folder", query: "?folder_id=" + uuid.NewString(), wantRows: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runtime.GC()
+			var stats runtime.MemStats
+			runtime.ReadMemStats(&stats)
+			rec := &streamRecorder{header: http.Header{}, baseHeap: stats.HeapAlloc}
+
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/v1/storage/documents/export.csv"+tt.query, nil)
+			c := e.NewContext(req, rec)
+			c.Set("user_id", ownerID.String())
+			if err := h.ExportDocumentsCSV(c); err != nil {
+				t.Fatalf("ExportDocumentsCSV() error = %v", err)
+			}
+
+			if rec.status != http.StatusOK {
+				t.Fatalf("status = %d, want 200", rec.status)
+			}
+			if rows := rec.lines - 1; rows != tt.wantRows {
+				t.Fatalf("CSV has %d rows, want %d", rows, tt.wantRows)
+			}
+			header, err := csv.NewReader(bytes.NewReader(rec.head.Bytes())).Read()
+			if err != nil || strings.Join(header, ",") != strings.Join(documentExportHeader, ",") {
+				t.Fatalf("CSV header = %v (%v), want %v", header, err, documentExportHeader)
+			}
+
+			// Rows reach the client in small batches while the cursor is still being read
+			if tt.wantRows >= exportFlushEvery && rec.flushes < tt.wantRows/exportFlushEvery {
+				t.Fatalf("response flushed %d times for %d rows, want at least %d", rec.flushes, tt.wantRows, tt.wantRows/exportFlushEvery)
+			}
+			if rec.maxUnflushed > exportFlushEvery+1 {
+				t.Fatalf("%d rows were buffered before a flush, want at most %d", rec.maxUnflushed, exportFlushEvery+1)
+			}
+			if limit := uint64(4 << 20); rec.maxHeapGrowth > limit {
+				t.Fatalf("live heap grew by %d bytes while streaming, want at most %d", rec.maxHeapGrowth, limit)
+			}
+		})
+	}
+}
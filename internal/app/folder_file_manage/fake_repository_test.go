@@ -0,0 +1,88 @@
+package folder_file_manage
+
+import (
+	"context"
+	"e-document-backend/internal/domain"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// fakeRepository keeps folders in memory; methods a test does not need fall through to the
+// embedded nil Repository and panic
+type fakeRepository struct {
+	Repository
+
+	mu      sync.Mutex
+	folders map[uuid.UUID]*domain.Folder
+	infos   map[uuid.UUID]*DocumentInfo // by document ID, readable by the owner only
+}
+
+func newFakeRepository(folders ...*domain.Folder) *fakeRepository {
+	r := &fakeRepository{
+		folders: make(map[uuid.UUID]*domain.Folder),
+		infos:   make(map[uuid.UUID]*DocumentInfo),
+	}
+	for _, f := range folders {
+		r.folders[f.ID] = f
+	}
+	return r
+}
+
+func (r *fakeRepository) GetFolderByID(ctx context.Context, folderID uuid.UUID) (*domain.Folder, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f, ok := r.folders[folderID]
+	if !ok {
+		return nil, fmt.Errorf("folder not found")
+	}
+	copied := *f
+	return &copied, nil
+}
+
+func (r *fakeRepository) CreateFolder(ctx context.Context, folder *domain.Folder) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	folder.ID = uuid.New()
+	copied := *folder
+	r.folders[folder.ID] = &copied
+	return nil
+}
+
+func (r *fakeRepository) UpdateFolderAppearance(ctx context.Context, folderID uuid.UUID, color, icon *string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f, ok := r.folders[folderID]
+	if !ok {
+		return fmt.Errorf("folder not found")
+	}
+	f.Color, f.Icon = color, icon
+	return nil
+}
+
+func (r *fakeRepository) GetFolderBreadcrumbs(ctx context.Context, folderID, ownerID uuid.UUID) ([]FolderBreadcrumb, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var breadcrumbs []FolderBreadcrumb
+	for id := &folderID; id != nil; {
+		f, ok := r.folders[*id]
+		if !ok || f.OwnerID != ownerID {
+			return nil, nil
+		}
+		breadcrumbs = append([]FolderBreadcrumb{{ID: f.ID, Name: f.Name}}, breadcrumbs...)
+		id = f.ParentFolderID
+	}
+	return breadcrumbs, nil
+}
+
+func (r *fakeRepository) GetDocumentInfo(ctx context.Context, documentID, requesterID uuid.UUID) (*DocumentInfo, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	info, ok := r.infos[documentID]
+	if !ok {
+		return nil, false, fmt.Errorf("document not found")
+	}
+	copied := *info
+	return &copied, info.OwnerID != nil && *info.OwnerID == requesterID, nil
+}
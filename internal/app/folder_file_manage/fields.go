@@ -0,0 +1,94 @@
+package folder_file_manage
+
+import (
+	"bytes"
+	"e-document-backend/internal/util"
+	"encoding/json"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// documentFields are the JSON fields of a document that ?fields may select
+var documentFields = fieldSet(
+	"id", "title", "description", "type", "category_id", "folder_id", "barcode", "registrant_id",
+	"current_department_id", "status", "created_at", "updated_at", "attachment", "tags", "registrant", "uploader",
+	"folder_path", "breadcrumbs",
+)
+
+// folderFields are the JSON fields of a folder that ?fields may select
+var folderFields = fieldSet(
+	"id", "name", "path", "is_root_folder", "parent_folder_id", "owner_id", "color", "icon", "created_at", "updated_at",
+)
+
+// fieldSet builds a lookup set of field names
+func fieldSet(names ...string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+	return set
+}
+
+// requestedFields returns the allowed fields listed in the comma-separated ?fields parameter
+// Unknown fields are ignored; nil means the full response, including when no known field is listed
+// id is always kept so clients can still tell items apart
+func requestedFields(c echo.Context, allowed map[string]bool) map[string]bool {
+	var fields map[string]bool
+	for _, name := range strings.Split(c.QueryParam("fields"), ",") {
+		name = strings.TrimSpace(name)
+		if !allowed[name] {
+			continue
+		}
+		if fields == nil {
+			fields = map[string]bool{"id": true}
+		}
+		fields[name] = true
+	}
+	return fields
+}
+
+// selectFields projects an object or a list of objects to the fields requested with ?fields
+// The data is returned as is when the request does not narrow the fields
+func selectFields(c echo.Context, allowed map[string]bool, data interface{}) (interface{}, error) {
+	fields := requestedFields(c, allowed)
+	if fields == nil {
+		return data, nil
+	}
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, util.ErrorResponse("Failed to select fields", util.INTERNAL_SERVER_ERROR, 500, err.Error())
+	}
+
+	project := func(object map[string]json.RawMessage) map[string]json.RawMessage {
+		for key := range object {
+			if !fields[key] {
+				delete(object, key)
+			}
+		}
+		return object
+	}
+
+	if bytes.HasPrefix(encoded, []byte("[")) {
+		var objects []map[string]json.RawMessage
+		if err := json.Unmarshal(encoded, &objects); err != nil {
+			return nil, util.ErrorResponse("Failed to select fields", util.INTERNAL_SERVER_ERROR, 500, err.Error())
+		}
+		for _, object := range objects {
+			project(object)
+		}
+		return objects, nil
+	}
+
+	if bytes.HasPrefix(encoded, []byte("{")) {
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(encoded, &object); err != nil {
+			return nil, util.ErrorResponse("Failed to select fields", util.INTERNAL_SERVER_ERROR, 500, err.Error())
+		}
+		return project(object), nil
+	}
+
+	// null and other scalars have no fields to select
+	return data, nil
+}
@@ -1,11 +1,18 @@
 package folder_file_manage
 
 import (
+	"e-document-backend/internal/domain"
+	"e-document-backend/internal/pkg/barcode"
 	"e-document-backend/internal/util"
+	"encoding/csv"
+	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
 )
 
 // Handler handles HTTP requests for storage operations
@@ -25,7 +32,11 @@ func (h *Handler) RegisterRoutes(e *echo.Group, authMiddleware echo.MiddlewareFu
 	storage := e.Group("/v1/storage", authMiddleware)
 
 	// Folder routes
+	storage.POST("/folders", h.CreateFolder)
+	storage.POST("/folders/path", h.EnsureFolderPath)
 	storage.GET("/folders/root", h.GetRootFolders)
+	storage.GET("/folders/tree/counts", h.GetFolderTreeCounts)
+	storage.PATCH("/folders/:id", h.UpdateFolder)
 	storage.GET("/folders/:id", h.GetFolder)
 	storage.GET("/folders/:id/contents", h.GetFolderContents)
 	storage.GET("/folders/:id/subfolders", h.GetSubfolders)
@@ -33,10 +44,27 @@ func (h *Handler) RegisterRoutes(e *echo.Group, authMiddleware echo.MiddlewareFu
 
 	// Document routes
 	storage.GET("/documents", h.GetAllDocuments)
+	storage.GET("/documents/export.csv", h.ExportDocumentsCSV)
+	storage.GET("/documents/by-barcode/:barcode", h.GetDocumentByBarcode)
 	storage.GET("/documents/:id", h.GetDocument)
+	storage.PATCH("/documents/:id", h.UpdateDocument)
+	storage.GET("/documents/:id/info", h.GetDocumentInfo)
+	storage.POST("/documents/:id/barcode", h.GenerateDocumentBarcode)
+	storage.POST("/documents/:id/share", h.ShareDocument)
+	storage.DELETE("/documents/:id/share/:userId", h.UnshareDocument)
+	storage.POST("/documents/:id/route", h.RouteDocument)
+	storage.POST("/documents/:id/copy", h.CopyDocument)
+	storage.POST("/documents/:id/tags", h.AddDocumentTags)
+	storage.DELETE("/documents/:id/tags/:name", h.RemoveDocumentTag)
+
+	// Documents shared with the current user
+	storage.GET("/shared", h.GetSharedDocuments)
 
 	// Recent files
 	storage.GET("/recent", h.GetRecentFiles)
+
+	// Home screen statistics
+	storage.GET("/summary", h.GetSummary)
 }
 
 // GetRootFolders godoc
@@ -47,48 +75,70 @@ func (h *Handler) RegisterRoutes(e *echo.Group, authMiddleware echo.MiddlewareFu
 // @Security	BearerAuth
 // @Param		page		query		int		false	"Page number"		default(1)
 // @Param		page_size	query		int		false	"Items per page"	default(20)
+// @Param		fields		query		string	false	"Comma-separated folder fields to return, e.g. id,name,updated_at; unknown fields are ignored and id is always included"
 // @Success		200			{object}	util.Response
-// @Failure		401			{object}	util.Response
-// @Failure		500			{object}	util.Response
+// @Failure		401			{object}	util.ErrorEnvelope
+// @Failure		500			{object}	util.ErrorEnvelope
 // @Router		/v1/storage/folders/root [get]
 func (h *Handler) GetRootFolders(c echo.Context) error {
 	// Get user ID from context
-	userID := c.Get("user_id").(string)
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
 	ownerID, err := uuid.Parse(userID)
 	if err != nil {
 		return util.HandleError(c, util.ErrorResponse("Invalid user ID", util.INVALID_INPUT, 400, err.Error()))
 	}
 
-	// Get pagination params
-	page := 1
-	pageSize := 20
-	if p := c.QueryParam("page"); p != "" {
-		if parsed, err := strconv.Atoi(p); err == nil && parsed > 0 {
-			page = parsed
-		}
-	}
-	if ps := c.QueryParam("page_size"); ps != "" {
-		if parsed, err := strconv.Atoi(ps); err == nil && parsed > 0 && parsed <= 100 {
-			pageSize = parsed
-		}
-	}
+	pagination := util.ParsePagination(c, util.PaginationDefaults{})
 
 	// Get root folders
-	folders, total, err := h.service.GetRootFolders(c.Request().Context(), ownerID, page, pageSize)
+	folders, total, err := h.service.GetRootFolders(c.Request().Context(), ownerID, pagination.Page, pagination.Limit)
 	if err != nil {
 		return util.HandleError(c, util.ErrorResponse("Failed to get root folders", util.INTERNAL_SERVER_ERROR, 500, err.Error()))
 	}
 
-	// Calculate pagination info
-	totalPages := (total + pageSize - 1) / pageSize
-	pagination := util.PaginationInfo{
-		CurrentPage:  page,
-		TotalPages:   totalPages,
-		TotalItems:   total,
-		ItemsPerPage: pageSize,
+	data, err := selectFields(c, folderFields, folders)
+	if err != nil {
+		return util.HandleError(c, err)
 	}
 
-	return util.OKResponseWithPagination(c, "Root folders retrieved successfully", folders, pagination)
+	return util.OKResponseWithPagination(c, "Root folders retrieved successfully", data, pagination.Info(total))
+}
+
+// GetFolderTreeCounts godoc
+// @Summary		Get document counts for the folder tree
+// @Description	Get every folder of the authenticated user with its direct and recursive document counts, ordered by path
+// @Tags		Storage
+// @Produce		json
+// @Security	BearerAuth
+// @Param		page		query		int		false	"Page number"		default(1)
+// @Param		page_size	query		int		false	"Items per page"	default(500)
+// @Success		200			{object}	util.Response{data=util.PaginatedData}
+// @Failure		401			{object}	util.ErrorEnvelope
+// @Failure		500			{object}	util.ErrorEnvelope
+// @Router		/v1/storage/folders/tree/counts [get]
+func (h *Handler) GetFolderTreeCounts(c echo.Context) error {
+	// Get user ID from context
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+	ownerID, err := uuid.Parse(userID)
+	if err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid user ID", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	// Trees are rendered in one go, so allow larger pages than the other listings
+	pagination := util.ParsePagination(c, util.PaginationDefaults{Limit: 500, MaxLimit: 2000})
+
+	counts, total, err := h.service.GetFolderTreeCounts(c.Request().Context(), ownerID, pagination.Page, pagination.Limit)
+	if err != nil {
+		return util.HandleError(c, util.ErrorResponse("Failed to get folder tree counts", util.INTERNAL_SERVER_ERROR, 500, err.Error()))
+	}
+
+	return util.OKResponseWithPagination(c, "Folder tree counts retrieved successfully", counts, pagination.Info(total))
 }
 
 // GetFolder godoc
@@ -98,46 +148,233 @@ func (h *Handler) GetRootFolders(c echo.Context) error {
 // @Produce		json
 // @Security	BearerAuth
 // @Param		id	path		string	true	"Folder ID"
+// @Param		fields		query		string	false	"Comma-separated folder fields to return, e.g. id,name,updated_at; unknown fields are ignored and id is always included"
 // @Success		200	{object}	util.Response
-// @Failure		400	{object}	util.Response
-// @Failure		401	{object}	util.Response
-// @Failure		404	{object}	util.Response
+// @Failure		400	{object}	util.ErrorEnvelope
+// @Failure		401	{object}	util.ErrorEnvelope
+// @Failure		403	{object}	util.ErrorEnvelope
+// @Failure		404	{object}	util.ErrorEnvelope
 // @Router		/v1/storage/folders/{id} [get]
 func (h *Handler) GetFolder(c echo.Context) error {
+	// Get user ID from context
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+	requesterID, err := uuid.Parse(userID)
+	if err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid user ID", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	folderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid folder ID", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	folder, err := h.service.GetFolder(c.Request().Context(), folderID, requesterID)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	data, err := selectFields(c, folderFields, folder)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	return util.OKResponse(c, "Folder retrieved successfully", data)
+}
+
+// CreateFolder godoc
+// @Summary		Create a folder
+// @Description	Create a root folder or a subfolder, optionally with a color and icon
+// @Tags		Storage
+// @Accept		json
+// @Produce		json
+// @Security	BearerAuth
+// @Param		body	body		domain.CreateFolderRequest	true	"Folder details"
+// @Success		201		{object}	util.Response{data=domain.FolderResponse}
+// @Failure		400		{object}	util.ErrorEnvelope
+// @Failure		401		{object}	util.ErrorEnvelope
+// @Failure		403		{object}	util.ErrorEnvelope
+// @Failure		409		{object}	util.ErrorEnvelope
+// @Router		/v1/storage/folders [post]
+func (h *Handler) CreateFolder(c echo.Context) error {
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+	ownerID, err := uuid.Parse(userID)
+	if err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid user ID", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	var req domain.CreateFolderRequest
+	if err := c.Bind(&req); err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid request body", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	if err := util.ValidateStructFields(&req); err != nil {
+		return util.HandleError(c, err)
+	}
+
+	folder, err := h.service.CreateFolder(c.Request().Context(), ownerID, req)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	return util.CreatedResponse(c, "Folder created successfully", folder.ToResponse())
+}
+
+// EnsureFolderPath godoc
+// @Summary		Create a folder path
+// @Description	Makes sure every folder of a path such as "A/B/C" exists, below parent_folder_id or at the root, creating only the missing ones in one transaction. Returns the last folder's ID to upload into. Responds 201 when any folder was created and 200 when the whole path already existed
+// @Tags		Storage
+// @Accept		json
+// @Produce		json
+// @Security	BearerAuth
+// @Param		body	body		domain.EnsureFolderPathRequest	true	"Folder path"
+// @Success		200		{object}	util.Response{data=domain.EnsureFolderPathResult}
+// @Success		201		{object}	util.Response{data=domain.EnsureFolderPathResult}
+// @Failure		400		{object}	util.ErrorEnvelope
+// @Failure		401		{object}	util.ErrorEnvelope
+// @Failure		403		{object}	util.ErrorEnvelope
+// @Failure		404		{object}	util.ErrorEnvelope
+// @Failure		413		{object}	util.ErrorEnvelope
+// @Failure		500		{object}	util.ErrorEnvelope
+// @Router		/v1/storage/folders/path [post]
+func (h *Handler) EnsureFolderPath(c echo.Context) error {
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+	ownerID, err := uuid.Parse(userID)
+	if err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid user ID", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	var req domain.EnsureFolderPathRequest
+	if err := c.Bind(&req); err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid request body", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	if err := util.ValidateStruct(&req); err != nil {
+		return util.HandleError(c, err)
+	}
+
+	result, err := h.service.EnsureFolderPath(c.Request().Context(), ownerID, req)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	if result.Created > 0 {
+		return util.CreatedResponse(c, "Folder path created successfully", result)
+	}
+	return util.OKResponse(c, "Folder path already exists", result)
+}
+
+// UpdateFolder godoc
+// @Summary		Update folder appearance
+// @Description	Set a folder's color and/or icon. Omitted fields are unchanged; an empty string resets to the default.
+// @Tags		Storage
+// @Accept		json
+// @Produce		json
+// @Security	BearerAuth
+// @Param		id		path		string						true	"Folder ID"
+// @Param		body	body		domain.UpdateFolderRequest	true	"Folder appearance"
+// @Success		200		{object}	util.Response{data=domain.FolderResponse}
+// @Failure		400		{object}	util.ErrorEnvelope
+// @Failure		401		{object}	util.ErrorEnvelope
+// @Failure		403		{object}	util.ErrorEnvelope
+// @Failure		404		{object}	util.ErrorEnvelope
+// @Router		/v1/storage/folders/{id} [patch]
+func (h *Handler) UpdateFolder(c echo.Context) error {
 	folderID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		return util.HandleError(c, util.ErrorResponse("Invalid folder ID", util.INVALID_INPUT, 400, err.Error()))
 	}
 
-	folder, err := h.service.GetFolder(c.Request().Context(), folderID)
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+	ownerID, err := uuid.Parse(userID)
 	if err != nil {
-		return util.HandleError(c, util.ErrorResponse("Folder not found", util.VALIDATION_ERROR, 404, err.Error()))
+		return util.HandleError(c, util.ErrorResponse("Invalid user ID", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	var req domain.UpdateFolderRequest
+	if err := c.Bind(&req); err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid request body", util.INVALID_INPUT, 400, err.Error()))
 	}
 
-	return util.OKResponse(c, "Folder retrieved successfully", folder)
+	folder, err := h.service.UpdateFolder(c.Request().Context(), folderID, ownerID, req)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	return util.OKResponse(c, "Folder updated successfully", folder.ToResponse())
 }
 
 // GetFolderContents godoc
 // @Summary		Get folder contents
-// @Description	Get folder information with subfolders and documents
+// @Description	Get folder information with a page of subfolders and a page of documents. Each list is paged separately and carries its own pagination. include narrows the response to one list: the other one is not fetched, comes back as null without pagination, and only its count is returned. Responses carry a weak ETag; sending it back in If-None-Match returns 304 when nothing changed
 // @Tags		Storage
 // @Produce		json
 // @Security	BearerAuth
-// @Param		id	path		string	true	"Folder ID"
-// @Success		200	{object}	util.Response
-// @Failure		400	{object}	util.Response
-// @Failure		401	{object}	util.Response
-// @Failure		404	{object}	util.Response
+// @Param		id				path		string	true	"Folder ID"
+// @Param		include			query		string	false	"Lists to return, comma-separated: subfolders, documents or both"	default(subfolders,documents)
+// @Param		folder_page		query		int		false	"Subfolder page number"	default(1)
+// @Param		doc_page		query		int		false	"Document page number"	default(1)
+// @Param		page_size		query		int		false	"Items per page for each list"	default(20)
+// @Param		folder_page_size	query	int		false	"Subfolders per page, overriding page_size"
+// @Param		doc_page_size	query		int		false	"Documents per page, overriding page_size"
+// @Param		If-None-Match	header		string	false	"ETag of a previously received response"
+// @Param		expand		query		string	false	"Set to uploader to embed the registrant and uploader (id, first and last name) of each document"
+// @Success		200				{object}	util.Response{data=FolderContents}
+// @Success		304				"Not modified"
+// @Failure		400			{object}	util.ErrorEnvelope
+// @Failure		401			{object}	util.ErrorEnvelope
+// @Failure		403			{object}	util.ErrorEnvelope
+// @Failure		404			{object}	util.ErrorEnvelope
 // @Router		/v1/storage/folders/{id}/contents [get]
 func (h *Handler) GetFolderContents(c echo.Context) error {
+	// Get user ID from context
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+	requesterID, err := uuid.Parse(userID)
+	if err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid user ID", util.INVALID_INPUT, 400, err.Error()))
+	}
+
 	folderID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		return util.HandleError(c, util.ErrorResponse("Invalid folder ID", util.INVALID_INPUT, 400, err.Error()))
 	}
 
-	contents, err := h.service.GetFolderContents(c.Request().Context(), folderID)
+	// Get the included lists and their pagination params
+	query, err := parseFolderContentsQuery(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	contents, err := h.service.GetFolderContents(c.Request().Context(), folderID, requesterID, query)
 	if err != nil {
-		return util.HandleError(c, util.ErrorResponse("Failed to get folder contents", util.INTERNAL_SERVER_ERROR, 500, err.Error()))
+		return util.HandleError(c, err)
+	}
+
+	if err := h.service.AttachTags(c.Request().Context(), contents.Documents...); err != nil {
+		return util.HandleError(c, err)
+	}
+	if wantsExpand(c, expandUploader) {
+		if err := h.service.ExpandUploaders(c.Request().Context(), contents.Documents...); err != nil {
+			return util.HandleError(c, err)
+		}
+	}
+
+	if util.NotModified(c, folderContentsETag(contents)) {
+		return c.NoContent(http.StatusNotModified)
 	}
 
 	return util.OKResponse(c, "Folder contents retrieved successfully", contents)
@@ -152,41 +389,42 @@ func (h *Handler) GetFolderContents(c echo.Context) error {
 // @Param		id			path		string	true	"Folder ID"
 // @Param		page		query		int		false	"Page number"		default(1)
 // @Param		page_size	query		int		false	"Items per page"	default(20)
-// @Success		200			{object}	util.Response
-// @Failure		400			{object}	util.Response
-// @Failure		401			{object}	util.Response
+// @Param		fields		query		string	false	"Comma-separated folder fields to return, e.g. id,name,updated_at; unknown fields are ignored and id is always included"
+// @Success		200			{object}	util.Response{data=util.PaginatedData}
+// @Failure		400			{object}	util.ErrorEnvelope
+// @Failure		401			{object}	util.ErrorEnvelope
+// @Failure		403			{object}	util.ErrorEnvelope
+// @Failure		404			{object}	util.ErrorEnvelope
 // @Router		/v1/storage/folders/{id}/subfolders [get]
 func (h *Handler) GetSubfolders(c echo.Context) error {
+	// Get user ID from context
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+	requesterID, err := uuid.Parse(userID)
+	if err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid user ID", util.INVALID_INPUT, 400, err.Error()))
+	}
+
 	folderID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		return util.HandleError(c, util.ErrorResponse("Invalid folder ID", util.INVALID_INPUT, 400, err.Error()))
 	}
 
-	// Get pagination params
-	page := 1
-	pageSize := 20
-	if p := c.QueryParam("page"); p != "" {
-		if parsed, err := strconv.Atoi(p); err == nil && parsed > 0 {
-			page = parsed
-		}
-	}
-	if ps := c.QueryParam("page_size"); ps != "" {
-		if parsed, err := strconv.Atoi(ps); err == nil && parsed > 0 && parsed <= 100 {
-			pageSize = parsed
-		}
+	pagination := util.ParsePagination(c, util.PaginationDefaults{})
+
+	folders, total, err := h.service.GetSubfolders(c.Request().Context(), folderID, requesterID, pagination.Page, pagination.Limit)
+	if err != nil {
+		return util.HandleError(c, err)
 	}
 
-	folders, pagination, err := h.service.GetSubfolders(c.Request().Context(), folderID, page, pageSize)
+	data, err := selectFields(c, folderFields, folders)
 	if err != nil {
-		return util.HandleError(c, util.ErrorResponse("Failed to get subfolders", util.INTERNAL_SERVER_ERROR, 500, err.Error()))
+		return util.HandleError(c, err)
 	}
 
-	return c.JSON(200, map[string]interface{}{
-		"success":    true,
-		"message":    "Subfolders retrieved successfully",
-		"data":       folders,
-		"pagination": pagination,
-	})
+	return util.OKResponseWithPagination(c, "Subfolders retrieved successfully", data, pagination.Info(total))
 }
 
 // GetDocumentsByFolder godoc
@@ -198,147 +436,811 @@ func (h *Handler) GetSubfolders(c echo.Context) error {
 // @Param		id			path		string	true	"Folder ID"
 // @Param		page		query		int		false	"Page number"		default(1)
 // @Param		page_size	query		int		false	"Items per page"	default(20)
-// @Success		200			{object}	util.Response
-// @Failure		400			{object}	util.Response
-// @Failure		401			{object}	util.Response
+// @Param		expand		query		string	false	"Set to uploader to embed the registrant and uploader (id, first and last name) of each document"
+// @Param		fields		query		string	false	"Comma-separated document fields to return, e.g. id,title,updated_at; unknown fields are ignored and id is always included"
+// @Success		200			{object}	util.Response{data=util.PaginatedData}
+// @Failure		400			{object}	util.ErrorEnvelope
+// @Failure		401			{object}	util.ErrorEnvelope
+// @Failure		403			{object}	util.ErrorEnvelope
+// @Failure		404			{object}	util.ErrorEnvelope
 // @Router		/v1/storage/folders/{id}/documents [get]
 func (h *Handler) GetDocumentsByFolder(c echo.Context) error {
+	// Get user ID from context
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+	requesterID, err := uuid.Parse(userID)
+	if err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid user ID", util.INVALID_INPUT, 400, err.Error()))
+	}
+
 	folderID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		return util.HandleError(c, util.ErrorResponse("Invalid folder ID", util.INVALID_INPUT, 400, err.Error()))
 	}
 
-	// Get pagination params
-	page := 1
-	pageSize := 20
-	if p := c.QueryParam("page"); p != "" {
-		if parsed, err := strconv.Atoi(p); err == nil && parsed > 0 {
-			page = parsed
-		}
+	pagination := util.ParsePagination(c, util.PaginationDefaults{})
+
+	documents, total, err := h.service.GetDocumentsByFolder(c.Request().Context(), folderID, requesterID, pagination.Page, pagination.Limit)
+	if err != nil {
+		return util.HandleError(c, err)
 	}
-	if ps := c.QueryParam("page_size"); ps != "" {
-		if parsed, err := strconv.Atoi(ps); err == nil && parsed > 0 && parsed <= 100 {
-			pageSize = parsed
+
+	if err := h.service.AttachTags(c.Request().Context(), documents...); err != nil {
+		return util.HandleError(c, err)
+	}
+	if wantsExpand(c, expandUploader) {
+		if err := h.service.ExpandUploaders(c.Request().Context(), documents...); err != nil {
+			return util.HandleError(c, err)
 		}
 	}
 
-	documents, pagination, err := h.service.GetDocumentsByFolder(c.Request().Context(), folderID, page, pageSize)
+	data, err := selectFields(c, documentFields, documents)
 	if err != nil {
-		return util.HandleError(c, util.ErrorResponse("Failed to get documents", util.INTERNAL_SERVER_ERROR, 500, err.Error()))
+		return util.HandleError(c, err)
 	}
 
-	return c.JSON(200, map[string]interface{}{
-		"success":    true,
-		"message":    "Documents retrieved successfully",
-		"data":       documents,
-		"pagination": pagination,
-	})
+	return util.OKResponseWithPagination(c, "Documents retrieved successfully", data, pagination.Info(total))
 }
 
 // GetAllDocuments godoc
 // @Summary		Get all documents
-// @Description	Get all documents for the authenticated user with pagination
+// @Description	Get all documents for the authenticated user, most recently updated first, optionally only those carrying the given tags. Uses offset pagination by default; pass cursor (empty for the first page, then next_cursor) for keyset pagination, which stays fast and stable on deep pages and returns util.CursorPaginatedData
 // @Tags		Storage
 // @Produce		json
 // @Security	BearerAuth
-// @Param		page		query		int		false	"Page number"		default(1)
-// @Param		page_size	query		int		false	"Items per page"	default(20)
-// @Success		200			{object}	util.Response
-// @Failure		401			{object}	util.Response
-// @Failure		500			{object}	util.Response
+// @Param		page		query		int		false	"Page number (offset pagination)"	default(1)
+// @Param		page_size	query		int		false	"Items per page"					default(20)
+// @Param		cursor		query		string	false	"Keyset cursor <updated_at>,<id> from next_cursor"
+// @Param		tags		query		string	false	"Comma-separated tag names; only documents carrying them are listed"
+// @Param		tag_match	query		string	false	"all (default) requires every tag, any requires at least one"	Enums(all, any)
+// @Param		expand		query		string	false	"Set to uploader to embed the registrant and uploader (id, first and last name) of each document"
+// @Param		fields		query		string	false	"Comma-separated document fields to return, e.g. id,title,updated_at; unknown fields are ignored and id is always included"
+// @Success		200			{object}	util.Response{data=util.PaginatedData}
+// @Failure		400			{object}	util.ErrorEnvelope
+// @Failure		401			{object}	util.ErrorEnvelope
+// @Failure		500			{object}	util.ErrorEnvelope
 // @Router		/v1/storage/documents [get]
 func (h *Handler) GetAllDocuments(c echo.Context) error {
 	// Get user ID from context
-	userID := c.Get("user_id").(string)
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
 	ownerID, err := uuid.Parse(userID)
 	if err != nil {
 		return util.HandleError(c, util.ErrorResponse("Invalid user ID", util.INVALID_INPUT, 400, err.Error()))
 	}
 
-	// Get pagination params
-	page := 1
-	pageSize := 20
-	if p := c.QueryParam("page"); p != "" {
-		if parsed, err := strconv.Atoi(p); err == nil && parsed > 0 {
-			page = parsed
-		}
+	pagination := util.ParsePagination(c, util.PaginationDefaults{})
+
+	tags, err := parseTagFilter(c)
+	if err != nil {
+		return util.HandleError(c, err)
 	}
-	if ps := c.QueryParam("page_size"); ps != "" {
-		if parsed, err := strconv.Atoi(ps); err == nil && parsed > 0 && parsed <= 100 {
-			pageSize = parsed
+
+	// Keyset pagination is opt-in; offset pagination stays the default for existing clients
+	if cursor, ok := c.QueryParams()["cursor"]; ok {
+		documents, nextCursor, err := h.service.GetDocumentsByCursor(c.Request().Context(), ownerID, tags, cursor[0], pagination.Limit)
+		if err != nil {
+			return util.HandleError(c, err)
+		}
+		if err := h.service.AttachTags(c.Request().Context(), documents...); err != nil {
+			return util.HandleError(c, err)
+		}
+		if wantsExpand(c, expandUploader) {
+			if err := h.service.ExpandUploaders(c.Request().Context(), documents...); err != nil {
+				return util.HandleError(c, err)
+			}
+		}
+		data, err := selectFields(c, documentFields, documents)
+		if err != nil {
+			return util.HandleError(c, err)
 		}
+
+		return util.OKResponseWithCursor(c, "Documents retrieved successfully", data, nextCursor)
 	}
 
-	documents, pagination, err := h.service.GetAllDocuments(c.Request().Context(), ownerID, page, pageSize)
+	documents, total, err := h.service.GetAllDocuments(c.Request().Context(), ownerID, tags, pagination.Page, pagination.Limit)
 	if err != nil {
 		return util.HandleError(c, util.ErrorResponse("Failed to get documents", util.INTERNAL_SERVER_ERROR, 500, err.Error()))
 	}
 
-	return c.JSON(200, map[string]interface{}{
-		"success":    true,
-		"message":    "Documents retrieved successfully",
-		"data":       documents,
-		"pagination": pagination,
-	})
+	if err := h.service.AttachTags(c.Request().Context(), documents...); err != nil {
+		return util.HandleError(c, err)
+	}
+	if wantsExpand(c, expandUploader) {
+		if err := h.service.ExpandUploaders(c.Request().Context(), documents...); err != nil {
+			return util.HandleError(c, err)
+		}
+	}
+
+	data, err := selectFields(c, documentFields, documents)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	return util.OKResponseWithPagination(c, "Documents retrieved successfully", data, pagination.Info(total))
 }
 
 // GetDocument godoc
 // @Summary		Get document details
-// @Description	Get document information with current attachment by ID
+// @Description	Get document information with current attachment by ID. Available to the registrant, users the document is shared with and members of the department holding it. For the owner of its folder the response also carries folder_path and the breadcrumbs from the root folder down. Responses carry a weak ETag; sending it back in If-None-Match returns 304 when nothing changed
 // @Tags		Storage
 // @Produce		json
 // @Security	BearerAuth
-// @Param		id	path		string	true	"Document ID"
-// @Success		200	{object}	util.Response
-// @Failure		400	{object}	util.Response
-// @Failure		401	{object}	util.Response
-// @Failure		404	{object}	util.Response
+// @Param		id				path		string	true	"Document ID"
+// @Param		If-None-Match	header		string	false	"ETag of a previously received response"
+// @Param		expand		query		string	false	"Set to uploader to embed the registrant and uploader (id, first and last name) of each document"
+// @Param		fields		query		string	false	"Comma-separated document fields to return, e.g. id,title,updated_at; unknown fields are ignored and id is always included"
+// @Success		200	{object}	util.Response{data=DocumentWithAttachment}
+// @Success		304	"Not modified"
+// @Failure		400	{object}	util.ErrorEnvelope
+// @Failure		401	{object}	util.ErrorEnvelope
+// @Failure		403	{object}	util.ErrorEnvelope
+// @Failure		404	{object}	util.ErrorEnvelope
 // @Router		/v1/storage/documents/{id} [get]
 func (h *Handler) GetDocument(c echo.Context) error {
+	// Get user ID from context
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+	requesterID, err := uuid.Parse(userID)
+	if err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid user ID", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	documentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid document ID", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	document, err := h.service.GetDocument(c.Request().Context(), documentID, requesterID)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	if err := h.service.AttachTags(c.Request().Context(), document); err != nil {
+		return util.HandleError(c, err)
+	}
+	if err := h.service.AttachLocation(c.Request().Context(), requesterID, document); err != nil {
+		return util.HandleError(c, err)
+	}
+	if wantsExpand(c, expandUploader) {
+		if err := h.service.ExpandUploaders(c.Request().Context(), document); err != nil {
+			return util.HandleError(c, err)
+		}
+	}
+
+	if util.NotModified(c, documentETag(document)) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	data, err := selectFields(c, documentFields, document)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	return util.OKResponse(c, "Document retrieved successfully", data)
+}
+
+// UpdateDocument godoc
+// @Summary		Update document details
+// @Description	Set the title and/or description of a document you own. Omitted fields are unchanged; the title cannot be blank
+// @Tags		Storage
+// @Accept		json
+// @Produce		json
+// @Security	BearerAuth
+// @Param		id		path		string							true	"Document ID"
+// @Param		body	body		domain.UpdateDocumentRequest	true	"Document details"
+// @Success		200		{object}	util.Response{data=DocumentWithAttachment}
+// @Failure		400		{object}	util.ErrorEnvelope
+// @Failure		401		{object}	util.ErrorEnvelope
+// @Failure		403		{object}	util.ErrorEnvelope
+// @Failure		404		{object}	util.ErrorEnvelope
+// @Router		/v1/storage/documents/{id} [patch]
+func (h *Handler) UpdateDocument(c echo.Context) error {
+	documentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid document ID", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+	requesterID, err := uuid.Parse(userID)
+	if err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid user ID", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	var req domain.UpdateDocumentRequest
+	if err := c.Bind(&req); err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid request body", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	if err := util.ValidateStruct(&req); err != nil {
+		return util.HandleError(c, err)
+	}
+
+	document, err := h.service.UpdateDocument(c.Request().Context(), documentID, requesterID, req)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	if err := h.service.AttachTags(c.Request().Context(), document); err != nil {
+		return util.HandleError(c, err)
+	}
+
+	return util.OKResponse(c, "Document updated successfully", document)
+}
+
+// GetDocumentInfo godoc
+// @Summary		Get document info card
+// @Description	Get a compact summary of a document (title, file size and type, version count, last modified, owner) without attachment details or download URLs
+// @Tags		Storage
+// @Produce		json
+// @Security	BearerAuth
+// @Param		id	path		string	true	"Document ID"
+// @Success		200	{object}	util.Response{data=DocumentInfo}
+// @Failure		400	{object}	util.ErrorEnvelope
+// @Failure		401	{object}	util.ErrorEnvelope
+// @Failure		403	{object}	util.ErrorEnvelope
+// @Failure		404	{object}	util.ErrorEnvelope
+// @Router		/v1/storage/documents/{id}/info [get]
+func (h *Handler) GetDocumentInfo(c echo.Context) error {
+	// Get user ID from context
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+	requesterID, err := uuid.Parse(userID)
+	if err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid user ID", util.INVALID_INPUT, 400, err.Error()))
+	}
+
 	documentID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		return util.HandleError(c, util.ErrorResponse("Invalid document ID", util.INVALID_INPUT, 400, err.Error()))
 	}
 
-	document, err := h.service.GetDocument(c.Request().Context(), documentID)
+	info, err := h.service.GetDocumentInfo(c.Request().Context(), documentID, requesterID)
 	if err != nil {
-		return util.HandleError(c, util.ErrorResponse("Document not found", util.VALIDATION_ERROR, 404, err.Error()))
+		return util.HandleError(c, err)
 	}
 
-	return util.OKResponse(c, "Document retrieved successfully", document)
+	return util.OKResponse(c, "Document info retrieved successfully", info)
+}
+
+// GetDocumentByBarcode godoc
+// @Summary		Get document by barcode
+// @Description	Resolve a scanned barcode to its document. Only the registrant, a user the document is shared with or a member of the department holding it can see it; the owner of its folder also gets folder_path and breadcrumbs
+// @Tags		Storage
+// @Produce		json
+// @Security	BearerAuth
+// @Param		barcode	path		string	true	"Document barcode"
+// @Param		expand		query		string	false	"Set to uploader to embed the registrant and uploader (id, first and last name) of each document"
+// @Param		fields		query		string	false	"Comma-separated document fields to return, e.g. id,title,updated_at; unknown fields are ignored and id is always included"
+// @Success		200		{object}	util.Response{data=DocumentWithAttachment}
+// @Failure		400		{object}	util.ErrorEnvelope
+// @Failure		401		{object}	util.ErrorEnvelope
+// @Failure		403		{object}	util.ErrorEnvelope
+// @Failure		404		{object}	util.ErrorEnvelope
+// @Router		/v1/storage/documents/by-barcode/{barcode} [get]
+func (h *Handler) GetDocumentByBarcode(c echo.Context) error {
+	// Get user ID from context
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+	requesterID, err := uuid.Parse(userID)
+	if err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid user ID", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	value := strings.TrimSpace(c.Param("barcode"))
+	if value == "" {
+		return util.HandleError(c, util.NewInvalidInputError("barcode", "is required"))
+	}
+
+	doc, err := h.service.GetDocumentByBarcode(c.Request().Context(), value, requesterID)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	if err := h.service.AttachTags(c.Request().Context(), doc); err != nil {
+		return util.HandleError(c, err)
+	}
+	if err := h.service.AttachLocation(c.Request().Context(), requesterID, doc); err != nil {
+		return util.HandleError(c, err)
+	}
+	if wantsExpand(c, expandUploader) {
+		if err := h.service.ExpandUploaders(c.Request().Context(), doc); err != nil {
+			return util.HandleError(c, err)
+		}
+	}
+
+	data, err := selectFields(c, documentFields, doc)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	return util.OKResponse(c, "Document retrieved successfully", data)
+}
+
+// GenerateDocumentBarcode godoc
+// @Summary		Generate a document barcode
+// @Description	Assign a unique barcode to a document you own, mark it as a Barcode document and return the barcode image. A document that already has a barcode keeps it. The value is also returned in the X-Barcode header
+// @Tags		Storage
+// @Produce		image/svg+xml
+// @Produce		image/png
+// @Security	BearerAuth
+// @Param		id		path		string	true	"Document ID"
+// @Param		format	query		string	false	"Image format: svg or png (default: svg)"
+// @Success		200		{file}		binary
+// @Failure		400		{object}	util.ErrorEnvelope
+// @Failure		401		{object}	util.ErrorEnvelope
+// @Failure		403		{object}	util.ErrorEnvelope
+// @Failure		404		{object}	util.ErrorEnvelope
+// @Router		/v1/storage/documents/{id}/barcode [post]
+func (h *Handler) GenerateDocumentBarcode(c echo.Context) error {
+	// Get user ID from context
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+	ownerID, err := uuid.Parse(userID)
+	if err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid user ID", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	documentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid document ID", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	format := c.QueryParam("format")
+	if format == "" {
+		format = "svg"
+	}
+	if format != "svg" && format != "png" {
+		return util.HandleError(c, util.NewInvalidInputError("format", "must be svg or png"))
+	}
+
+	value, err := h.service.GenerateDocumentBarcode(c.Request().Context(), documentID, ownerID)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	var image []byte
+	contentType := "image/svg+xml"
+	if format == "png" {
+		image, err = barcode.PNG(value)
+		contentType = "image/png"
+	} else {
+		image, err = barcode.SVG(value)
+	}
+	if err != nil {
+		return util.HandleError(c, util.NewInternalError(err.Error()))
+	}
+
+	c.Response().Header().Set("X-Barcode", value)
+	return c.Blob(200, contentType, image)
+}
+
+// ShareDocument godoc
+// @Summary		Share a document with a user
+// @Description	Give another user read-only access to a document you own. Sharing again updates the permission
+// @Tags		Storage
+// @Accept		json
+// @Produce		json
+// @Security	BearerAuth
+// @Param		id		path		string						true	"Document ID"
+// @Param		body	body		domain.ShareDocumentRequest	true	"Share details"
+// @Success		201		{object}	util.Response{data=domain.DocumentShare}
+// @Failure		400		{object}	util.ErrorEnvelope
+// @Failure		401		{object}	util.ErrorEnvelope
+// @Failure		403		{object}	util.ErrorEnvelope
+// @Failure		404		{object}	util.ErrorEnvelope
+// @Router		/v1/storage/documents/{id}/share [post]
+func (h *Handler) ShareDocument(c echo.Context) error {
+	// Get user ID from context
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+	ownerID, err := uuid.Parse(userID)
+	if err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid user ID", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	documentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid document ID", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	var req domain.ShareDocumentRequest
+	if err := c.Bind(&req); err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid request body", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	if err := util.ValidateStruct(&req); err != nil {
+		return util.HandleError(c, err)
+	}
+
+	share, err := h.service.ShareDocument(c.Request().Context(), documentID, ownerID, req)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	return util.CreatedResponse(c, "Document shared successfully", share)
+}
+
+// RouteDocument godoc
+// @Summary		Route a document to another department
+// @Description	Send a document to a registered department and record the transition in its routing history. Optionally moves the document to Pending. Available to the registrant and members of the department currently holding the document
+// @Tags		Storage
+// @Accept		json
+// @Produce		json
+// @Security	BearerAuth
+// @Param		id		path		string						true	"Document ID"
+// @Param		body	body		domain.RouteDocumentRequest	true	"Target department"
+// @Success		200		{object}	util.Response{data=domain.DocumentRoute}
+// @Failure		400		{object}	util.ErrorEnvelope
+// @Failure		401		{object}	util.ErrorEnvelope
+// @Failure		403		{object}	util.ErrorEnvelope
+// @Failure		404		{object}	util.ErrorEnvelope
+// @Failure		409		{object}	util.ErrorEnvelope
+// @Router		/v1/storage/documents/{id}/route [post]
+func (h *Handler) RouteDocument(c echo.Context) error {
+	// Get user ID from context
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+	requesterID, err := uuid.Parse(userID)
+	if err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid user ID", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	documentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid document ID", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	var req domain.RouteDocumentRequest
+	if err := c.Bind(&req); err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid request body", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	if err := util.ValidateStruct(&req); err != nil {
+		return util.HandleError(c, err)
+	}
+
+	route, err := h.service.RouteDocument(c.Request().Context(), documentID, requesterID, req)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	return util.OKResponse(c, "Document routed successfully", route)
+}
+
+// CopyDocument godoc
+// @Summary		Copy a document
+// @Description	Duplicate a document you can read, with its current file, into one of your folders. The copy is a Draft owned by you and shares the stored file with the original
+// @Tags		Storage
+// @Accept		json
+// @Produce		json
+// @Security	BearerAuth
+// @Param		id		path		string						true	"Document ID"
+// @Param		body	body		domain.CopyDocumentRequest	true	"Target folder"
+// @Success		201		{object}	util.Response{data=DocumentWithAttachment}
+// @Failure		400		{object}	util.ErrorEnvelope
+// @Failure		401		{object}	util.ErrorEnvelope
+// @Failure		403		{object}	util.ErrorEnvelope
+// @Failure		404		{object}	util.ErrorEnvelope
+// @Failure		413		{object}	util.ErrorEnvelope
+// @Router		/v1/storage/documents/{id}/copy [post]
+func (h *Handler) CopyDocument(c echo.Context) error {
+	// Get user ID from context
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+	requesterID, err := uuid.Parse(userID)
+	if err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid user ID", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	documentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid document ID", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	var req domain.CopyDocumentRequest
+	if err := c.Bind(&req); err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid request body", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	if err := util.ValidateStruct(&req); err != nil {
+		return util.HandleError(c, err)
+	}
+
+	doc, err := h.service.CopyDocument(c.Request().Context(), documentID, requesterID, req)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	return util.CreatedResponse(c, "Document copied successfully", doc)
+}
+
+// UnshareDocument godoc
+// @Summary		Stop sharing a document with a user
+// @Description	Revoke a user's access to a document you own
+// @Tags		Storage
+// @Produce		json
+// @Security	BearerAuth
+// @Param		id		path		string	true	"Document ID"
+// @Param		userId	path		string	true	"User ID"
+// @Success		200		{object}	util.Response
+// @Failure		400		{object}	util.ErrorEnvelope
+// @Failure		401		{object}	util.ErrorEnvelope
+// @Failure		403		{object}	util.ErrorEnvelope
+// @Failure		404		{object}	util.ErrorEnvelope
+// @Router		/v1/storage/documents/{id}/share/{userId} [delete]
+func (h *Handler) UnshareDocument(c echo.Context) error {
+	// Get user ID from context
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+	ownerID, err := uuid.Parse(userID)
+	if err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid user ID", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	documentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid document ID", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	sharedWithID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid user ID", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	if err := h.service.UnshareDocument(c.Request().Context(), documentID, ownerID, sharedWithID); err != nil {
+		return util.HandleError(c, err)
+	}
+
+	return util.OKResponse(c, "Document unshared successfully", nil)
+}
+
+// GetSharedDocuments godoc
+// @Summary		Get documents shared with me
+// @Description	Get documents other users have shared with the authenticated user, most recently shared first
+// @Tags		Storage
+// @Produce		json
+// @Security	BearerAuth
+// @Param		page		query		int		false	"Page number"		default(1)
+// @Param		page_size	query		int		false	"Items per page"	default(20)
+// @Success		200			{object}	util.Response{data=util.PaginatedData}
+// @Failure		401			{object}	util.ErrorEnvelope
+// @Failure		500			{object}	util.ErrorEnvelope
+// @Router		/v1/storage/shared [get]
+func (h *Handler) GetSharedDocuments(c echo.Context) error {
+	// Get user ID from context
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+	sharedWithID, err := uuid.Parse(userID)
+	if err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid user ID", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	pagination := util.ParsePagination(c, util.PaginationDefaults{})
+
+	documents, total, err := h.service.GetSharedDocuments(c.Request().Context(), sharedWithID, pagination.Page, pagination.Limit)
+	if err != nil {
+		return util.HandleError(c, util.ErrorResponse("Failed to get shared documents", util.INTERNAL_SERVER_ERROR, 500, err.Error()))
+	}
+
+	return util.OKResponseWithPagination(c, "Shared documents retrieved successfully", documents, pagination.Info(total))
 }
 
 // GetRecentFiles godoc
 // @Summary		Get recent files
-// @Description	Get recently modified files for the authenticated user
+// @Description	Get recently modified files for the authenticated user, optionally only those of one type or modified within a time window
 // @Tags		Storage
 // @Produce		json
 // @Security	BearerAuth
-// @Param		limit	query		int		false	"Number of files to return"	default(10)
+// @Param		limit	query		int		false	"Number of files to return (max 50)"	default(10)
+// @Param		type	query		string	false	"MIME type or prefix, e.g. image/ or application/pdf"
+// @Param		since	query		string	false	"Window back from now (24h, 7d) or a point in time (RFC 3339 or YYYY-MM-DD)"
 // @Success		200		{object}	util.Response
-// @Failure		401		{object}	util.Response
-// @Failure		500		{object}	util.Response
+// @Failure		400		{object}	util.ErrorEnvelope
+// @Failure		401		{object}	util.ErrorEnvelope
+// @Failure		500		{object}	util.ErrorEnvelope
 // @Router		/v1/storage/recent [get]
 func (h *Handler) GetRecentFiles(c echo.Context) error {
 	// Get user ID from context
-	userID := c.Get("user_id").(string)
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
 	ownerID, err := uuid.Parse(userID)
 	if err != nil {
 		return util.HandleError(c, util.ErrorResponse("Invalid user ID", util.INVALID_INPUT, 400, err.Error()))
 	}
 
-	// Get limit param
-	limit := 10
-	if l := c.QueryParam("limit"); l != "" {
-		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 50 {
-			limit = parsed
-		}
+	limit := util.ParsePagination(c, util.PaginationDefaults{Limit: 10, MaxLimit: 50}).Limit
+
+	filter, err := parseRecentFileFilter(c)
+	if err != nil {
+		return util.HandleError(c, err)
 	}
 
-	files, err := h.service.GetRecentFiles(c.Request().Context(), ownerID, limit)
+	files, err := h.service.GetRecentFiles(c.Request().Context(), ownerID, filter, limit)
 	if err != nil {
 		return util.HandleError(c, util.ErrorResponse("Failed to get recent files", util.INTERNAL_SERVER_ERROR, 500, err.Error()))
 	}
 
 	return util.OKResponse(c, "Recent files retrieved successfully", files)
 }
+
+// GetSummary godoc
+// @Summary		Get storage summary
+// @Description	Get document and folder counts, storage used, documents by status and recent activity for the authenticated user
+// @Tags		Storage
+// @Produce		json
+// @Security	BearerAuth
+// @Success		200	{object}	util.Response{data=StorageSummary}
+// @Failure		401	{object}	util.ErrorEnvelope
+// @Failure		500	{object}	util.ErrorEnvelope
+// @Router		/v1/storage/summary [get]
+func (h *Handler) GetSummary(c echo.Context) error {
+	// Get user ID from context
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+	ownerID, err := uuid.Parse(userID)
+	if err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid user ID", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	summary, err := h.service.GetSummary(c.Request().Context(), ownerID)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	return util.OKResponse(c, "Storage summary retrieved successfully", summary)
+}
+
+// documentExportHeader is the header row of the documents CSV export
+var documentExportHeader = []string{
+	"document_id", "title", "type", "status", "folder_path",
+	"file_name", "file_type", "file_size", "version", "created_at", "updated_at",
+}
+
+// exportFlushEvery is how many CSV rows are buffered before flushing to the client
+const exportFlushEvery = 100
+
+// ExportDocumentsCSV godoc
+// @Summary		Export documents as CSV
+// @Description	Streams all documents of the authenticated user as CSV, row by row, so memory use stays constant for large accounts. Optional filters match the document list.
+// @Tags		Storage
+// @Produce		text/csv
+// @Security	BearerAuth
+// @Param		folder_id	query		string	false	"Only documents directly in this folder"
+// @Param		status		query		string	false	"Only documents with this status (Draft, Pending, Approved, Rejected)"
+// @Success		200			{file}		binary
+// @Failure		400			{object}	util.ErrorEnvelope
+// @Failure		401			{object}	util.ErrorEnvelope
+// @Failure		500			{object}	util.ErrorEnvelope
+// @Router		/v1/storage/documents/export.csv [get]
+func (h *Handler) ExportDocumentsCSV(c echo.Context) error {
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+	ownerID, err := uuid.Parse(userID)
+	if err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid user ID", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	var filter DocumentExportFilter
+	if folderIDStr := c.QueryParam("folder_id"); folderIDStr != "" {
+		folderID, err := uuid.Parse(folderIDStr)
+		if err != nil {
+			return util.HandleError(c, util.ErrorResponse("Invalid folder ID", util.INVALID_INPUT, 400, err.Error()))
+		}
+		filter.FolderID = &folderID
+	}
+	if status := c.QueryParam("status"); status != "" {
+		filter.Status = &status
+	}
+
+	res := c.Response()
+	writer := csv.NewWriter(res)
+	started := false
+	written := 0
+
+	// Headers are sent with the first row so validation errors can still be returned as JSON
+	start := func() error {
+		started = true
+		res.Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+		res.Header().Set(echo.HeaderContentDisposition, `attachment; filename="documents.csv"`)
+		res.WriteHeader(200)
+		return writer.Write(documentExportHeader)
+	}
+
+	err = h.service.ExportDocuments(c.Request().Context(), ownerID, filter, func(row *DocumentExportRow) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+
+		if err := writer.Write(documentExportRecord(row)); err != nil {
+			return err
+		}
+
+		written++
+		if written%exportFlushEvery == 0 {
+			writer.Flush()
+			res.Flush()
+			return writer.Error()
+		}
+		return nil
+	})
+
+	if err != nil {
+		if !started {
+			return util.HandleError(c, err)
+		}
+		// Response is already streaming; the truncated CSV is all we can give the client
+		log.Error().Err(err).Str("owner_id", userID).Int("rows", written).Msg("Document CSV export aborted")
+		return nil
+	}
+
+	if !started {
+		if err := start(); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// documentExportRecord converts an export row to CSV fields
+func documentExportRecord(row *DocumentExportRow) []string {
+	record := []string{
+		row.DocumentID.String(),
+		row.Title,
+		row.Type,
+		row.Status,
+		"", "", "", "", "",
+		row.CreatedAt.Format(time.RFC3339),
+		row.UpdatedAt.Format(time.RFC3339),
+	}
+	if row.FolderPath != nil {
+		record[4] = *row.FolderPath
+	}
+	if row.FileName != nil {
+		record[5] = *row.FileName
+	}
+	if row.FileType != nil {
+		record[6] = *row.FileType
+	}
+	if row.FileSize != nil {
+		record[7] = strconv.FormatInt(*row.FileSize, 10)
+	}
+	if row.Version != nil {
+		record[8] = strconv.Itoa(*row.Version)
+	}
+	return record
+}
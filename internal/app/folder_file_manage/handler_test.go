@@ -0,0 +1,142 @@
+package folder_file_manage
+
+import (
+	"context"
+	"e-document-backend/internal/domain"
+	"e-document-backend/internal/middleware"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+func TestGetDocumentInfoShape(t *testing.T) {
+	ownerID := uuid.New()
+	documentID := uuid.New()
+	repo := newFakeRepository()
+	repo.infos[documentID] = &DocumentInfo{
+		ID:           documentID,
+		Title:        "Budget 2026",
+		FileName:     ptr("budget.pdf"),
+		FileType:     ptr("application/pdf"),
+		FileSize:     int64Ptr(2048),
+		VersionCount: 3,
+		LastModified: time.Now(),
+		OwnerID:      &ownerID,
+		OwnerName:    ptr("Somchai Jaidee"),
+	}
+	h := NewHandler(NewService(repo, nil, nil))
+
+	tests := []struct {
+		name       string
+		documentID string
+		requester  uuid.UUID
+		wantStatus int
+	}{
+		{name: "owner", documentID: documentID.String(), requester: ownerID, wantStatus: http.StatusOK},
+		{name: "no access", documentID: documentID.String(), requester: uuid.New(), wantStatus: http.StatusForbidden},
+		{name: "unknown document", documentID: uuid.NewString(), requester: ownerID, wantStatus: http.StatusNotFound},
+		{name: "invalid document ID", documentID: "not-a-uuid", requester: ownerID, wantStatus: http.StatusBadRequest},
+	}
+
+	// Everything an info card shows; attachment details, paths and download URLs stay out
+	wantKeys := []string{"file_name", "file_size", "file_type", "id", "last_modified", "owner_id", "owner_name", "title", "version_count"}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/v1/storage/documents/"+tt.documentID+"/info", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(tt.documentID)
+			c.Set("user_id", tt.requester.String())
+
+			if err := h.GetDocumentInfo(c); err != nil {
+				t.Fatalf("GetDocumentInfo() error = %v", err)
+			}
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var body struct {
+				Data map[string]json.RawMessage `json:"data"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("invalid JSON response: %v", err)
+			}
+			keys := make([]string, 0, len(body.Data))
+			for key := range body.Data {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			if strings.Join(keys, ",") != strings.Join(wantKeys, ",") {
+				t.Fatalf("info card fields = %v, want %v", keys, wantKeys)
+			}
+		})
+	}
+}
+
+func int64Ptr(n int64) *int64 { return &n }
+
+// fakeFolderPaths resolves a folder path without a database, creating it on the first call only
+type fakeFolderPaths struct {
+	created map[string]bool
+}
+
+func (f *fakeFolderPaths) EnsureFolderPath(ctx context.Context, ownerID uuid.UUID, req domain.EnsureFolderPathRequest) (*domain.EnsureFolderPathResult, error) {
+	result := &domain.EnsureFolderPathResult{FolderID: uuid.New()}
+	if !f.created[req.Path] {
+		f.created[req.Path] = true
+		result.Created = len(strings.Split(req.Path, "/"))
+	}
+	return result, nil
+}
+
+func TestEnsureFolderPathRoute(t *testing.T) {
+	const bodyLimit = 1 << 10
+	h := NewHandler(NewService(newFakeRepository(), nil, &fakeFolderPaths{created: make(map[string]bool)}))
+
+	// Mounted like the other storage routes, behind the JSON body limit
+	e := echo.New()
+	api := e.Group("/api", middleware.BodyLimitMiddleware(bodyLimit))
+	h.RegisterRoutes(api, func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("user_id", uuid.NewString())
+			return next(c)
+		}
+	})
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{name: "new path", body: `{"path":"A/B/C"}`, wantStatus: http.StatusCreated},
+		{name: "existing path", body: `{"path":"A/B/C"}`, wantStatus: http.StatusOK},
+		{name: "missing path", body: `{}`, wantStatus: http.StatusBadRequest},
+		{name: "body over the limit", body: `{"path":"` + strings.Repeat("a/", bodyLimit) + `"}`, wantStatus: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/storage/folders/path", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+}
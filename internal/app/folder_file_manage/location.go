@@ -0,0 +1,89 @@
+package folder_file_manage
+
+import (
+	"context"
+	"e-document-backend/internal/util"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// maxFolderDepth bounds the walk up a folder's parents in case the tree ever contains a cycle
+const maxFolderDepth = 100
+
+// FolderBreadcrumb is one folder on the way from the root to a document
+type FolderBreadcrumb struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+}
+
+// GetFolderBreadcrumbs walks from a folder of the owner up to its root in one query
+// It returns the folders root first; no rows means the folder does not exist or belongs to someone else
+func (r *repository) GetFolderBreadcrumbs(ctx context.Context, folderID, ownerID uuid.UUID) ([]FolderBreadcrumb, error) {
+	query := `
+		WITH RECURSIVE chain AS (
+			SELECT id, name, parent_folder_id, 0 AS depth
+			FROM folders
+			WHERE id = $1 AND owner_id = $2
+
+			UNION ALL
+
+			SELECT f.id, f.name, f.parent_folder_id, c.depth + 1
+			FROM folders f
+			INNER JOIN chain c ON f.id = c.parent_folder_id
+			WHERE c.depth < $3
+		)
+		SELECT id, name
+		FROM chain
+		ORDER BY depth DESC
+	`
+
+	rows, err := r.pool.Query(ctx, query, folderID, ownerID, maxFolderDepth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get folder breadcrumbs: %w", err)
+	}
+	defer rows.Close()
+
+	var breadcrumbs []FolderBreadcrumb
+	for rows.Next() {
+		var crumb FolderBreadcrumb
+		if err := rows.Scan(&crumb.ID, &crumb.Name); err != nil {
+			return nil, fmt.Errorf("failed to scan folder breadcrumb: %w", err)
+		}
+		breadcrumbs = append(breadcrumbs, crumb)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating folder breadcrumbs: %w", err)
+	}
+
+	return breadcrumbs, nil
+}
+
+// AttachLocation fills in the folder path and breadcrumbs of a document in a folder of the requester
+// Users a document is shared with do not see the owner's folders, so theirs stay empty
+// The path is built from the breadcrumb names, so it always matches the breadcrumbs even where
+// a stored folders.path has gone stale
+func (s *service) AttachLocation(ctx context.Context, requesterID uuid.UUID, doc *DocumentWithAttachment) error {
+	if doc.FolderID == nil {
+		return nil
+	}
+
+	breadcrumbs, err := s.repo.GetFolderBreadcrumbs(ctx, *doc.FolderID, requesterID)
+	if err != nil {
+		return util.NewDatabaseError("get folder breadcrumbs", err)
+	}
+	if len(breadcrumbs) == 0 {
+		return nil
+	}
+
+	names := make([]string, len(breadcrumbs))
+	for i, crumb := range breadcrumbs {
+		names[i] = crumb.Name
+	}
+	folderPath := strings.Join(names, "/")
+	doc.FolderPath = &folderPath
+	doc.Breadcrumbs = breadcrumbs
+	return nil
+}
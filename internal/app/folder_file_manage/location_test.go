@@ -0,0 +1,57 @@
+package folder_file_manage
+
+import (
+	"context"
+	"e-document-backend/internal/domain"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestAttachLocation(t *testing.T) {
+	ownerID := uuid.New()
+	root := &domain.Folder{ID: uuid.New(), Name: "Projects", Path: "Projects", IsRootFolder: true, OwnerID: ownerID}
+	// The stored path still carries the parent's old name
+	child := &domain.Folder{ID: uuid.New(), Name: "2026", Path: "Old Projects/2026", ParentFolderID: &root.ID, OwnerID: ownerID}
+	svc := NewService(newFakeRepository(root, child), nil, nil)
+
+	tests := []struct {
+		name      string
+		folderID  *uuid.UUID
+		requester uuid.UUID
+		wantPath  string
+		wantNames []string
+	}{
+		{name: "nested folder", folderID: &child.ID, requester: ownerID, wantPath: "Projects/2026", wantNames: []string{"Projects", "2026"}},
+		{name: "root folder", folderID: &root.ID, requester: ownerID, wantPath: "Projects", wantNames: []string{"Projects"}},
+		{name: "someone else's folder", folderID: &child.ID, requester: uuid.New()},
+		{name: "no folder", requester: ownerID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := &DocumentWithAttachment{Document: &domain.Document{ID: uuid.New(), FolderID: tt.folderID}}
+			if err := svc.AttachLocation(context.Background(), tt.requester, doc); err != nil {
+				t.Fatalf("AttachLocation() error = %v", err)
+			}
+
+			if tt.wantNames == nil {
+				if doc.FolderPath != nil || doc.Breadcrumbs != nil {
+					t.Fatalf("location = %s %v, want none", deref(doc.FolderPath), doc.Breadcrumbs)
+				}
+				return
+			}
+			if deref(doc.FolderPath) != tt.wantPath {
+				t.Fatalf("folder path = %s, want %s", deref(doc.FolderPath), tt.wantPath)
+			}
+			if len(doc.Breadcrumbs) != len(tt.wantNames) {
+				t.Fatalf("breadcrumbs = %v, want %v", doc.Breadcrumbs, tt.wantNames)
+			}
+			for i, crumb := range doc.Breadcrumbs {
+				if crumb.Name != tt.wantNames[i] {
+					t.Fatalf("breadcrumb %d = %s, want %s", i, crumb.Name, tt.wantNames[i])
+				}
+			}
+		})
+	}
+}
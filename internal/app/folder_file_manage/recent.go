@@ -0,0 +1,51 @@
+package folder_file_manage
+
+import (
+	"e-document-backend/internal/util"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RecentFileFilter narrows the recent files listing
+type RecentFileFilter struct {
+	FileType string     // MIME type prefix such as "image/"; empty means any type
+	Since    *time.Time // only files modified at or after this time; nil means any age
+}
+
+// parseRecentFileFilter reads the type and since query parameters
+// since is either a window back from now ("24h", "7d") or a point in time (RFC 3339 or YYYY-MM-DD)
+func parseRecentFileFilter(c echo.Context) (RecentFileFilter, error) {
+	filter := RecentFileFilter{FileType: strings.ToLower(strings.TrimSpace(c.QueryParam("type")))}
+
+	value := strings.TrimSpace(c.QueryParam("since"))
+	if value == "" {
+		return filter, nil
+	}
+
+	since, err := parseSince(value, time.Now())
+	if err != nil {
+		return filter, util.NewInvalidInputError("since", "must be a duration like 24h or 7d, an RFC 3339 time or a YYYY-MM-DD date")
+	}
+	filter.Since = &since
+
+	return filter, nil
+}
+
+// parseSince resolves a since value relative to now
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
@@ -0,0 +1,80 @@
+package folder_file_manage
+
+import (
+	"context"
+	"e-document-backend/internal/platform/postgres/pgtest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestRecentFilesQueryPlan(t *testing.T) {
+	pool := pgtest.NewPool(t)
+	repo := NewRepository(pool)
+	ctx := context.Background()
+
+	ownerID := seedUser(t, pool)
+	otherID := seedUser(t, pool)
+	folder := seedFolder(t, repo, ownerID, nil, "archive")
+	otherFolder := seedFolder(t, repo, otherID, nil, "archive")
+
+	// Enough history that sorting it would cost more than walking the index
+	for _, seed := range []struct {
+		folderID, registrantID uuid.UUID
+		documents              int
+	}{
+		{folder.ID, ownerID, 20000},
+		{otherFolder.ID, otherID, 20000},
+	} {
+		if _, err := pool.Exec(ctx, `
+			INSERT INTO documents (title, folder_id, registrant_id)
+			SELECT 'doc-' || i, $1, $2 FROM generate_series(1, $3::int) AS i
+		`, seed.folderID, seed.registrantID, seed.documents); err != nil {
+			t.Fatalf("failed to seed documents: %v", err)
+		}
+	}
+	if _, err := pool.Exec(ctx, "ANALYZE documents; ANALYZE document_attachments; ANALYZE folders"); err != nil {
+		t.Fatalf("failed to analyze: %v", err)
+	}
+
+	since := time.Now().Add(-24 * time.Hour)
+	tests := []struct {
+		name     string
+		fileType string
+		since    *time.Time
+	}{
+		{name: "no filter"},
+		{name: "type filter", fileType: "image/"},
+		{name: "since filter", since: &since},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := pool.Query(ctx, "EXPLAIN "+recentFilesQuery, ownerID, 20, tt.fileType, tt.since)
+			if err != nil {
+				t.Fatalf("EXPLAIN error = %v", err)
+			}
+			var lines []string
+			for rows.Next() {
+				var line string
+				if err := rows.Scan(&line); err != nil {
+					t.Fatalf("failed to scan plan: %v", err)
+				}
+				lines = append(lines, line)
+			}
+			if err := rows.Err(); err != nil {
+				t.Fatalf("EXPLAIN error = %v", err)
+			}
+			plan := strings.Join(lines, "\n")
+
+			if !strings.Contains(plan, "idx_documents_registrant_last_modified") {
+				t.Fatalf("plan does not use idx_documents_registrant_last_modified:\n%s", plan)
+			}
+			if strings.Contains(plan, "Sort Key") {
+				t.Fatalf("plan sorts the documents instead of reading them in index order:\n%s", plan)
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package folder_file_manage
+
+import (
+	"context"
+	"e-document-backend/internal/domain"
+	"e-document-backend/internal/platform/postgres/pgtest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// seedUser inserts a user that can own folders and register documents
+func seedUser(t *testing.T, pool *pgxpool.Pool) uuid.UUID {
+	t.Helper()
+	id := uuid.New()
+	_, err := pool.Exec(context.Background(), `
+		INSERT INTO users (id, username, email, phone, first_name, last_name, password, role)
+		VALUES ($1, $2, $3, '+66812345678', 'Somchai', 'Jaidee', 'hash', 'Employee')
+	`, id, "user-"+id.String()[:8], id.String()[:8]+"@example.com")
+	if err != nil {
+		t.Fatalf("failed to seed user: %v", err)
+	}
+	return id
+}
+
+// seedDepartment registers a department in a new sector and moves the given users into it
+func seedDepartment(t *testing.T, pool *pgxpool.Pool, departmentID string, memberIDs ...uuid.UUID) {
+	t.Helper()
+	ctx := context.Background()
+	_, err := pool.Exec(ctx, `
+		WITH sector AS (INSERT INTO sectors (name) VALUES ($2) RETURNING id)
+		INSERT INTO departments (id, name, sector_id) SELECT $1, $1, id FROM sector
+	`, departmentID, "sector-"+uuid.NewString()[:8])
+	if err != nil {
+		t.Fatalf("failed to seed department %s: %v", departmentID, err)
+	}
+	for _, id := range memberIDs {
+		if _, err := pool.Exec(ctx, `UPDATE users SET department_id = $1 WHERE id = $2`, departmentID, id); err != nil {
+			t.Fatalf("failed to move user into %s: %v", departmentID, err)
+		}
+	}
+}
+
+// seedFolder creates a folder under parent (nil for a root folder) through the repository
+func seedFolder(t *testing.T, repo Repository, ownerID uuid.UUID, parent *domain.Folder, name string) *domain.Folder {
+	t.Helper()
+	folder := &domain.Folder{Name: name, Path: "/" + name, IsRootFolder: parent == nil, OwnerID: ownerID}
+	if parent != nil {
+		folder.ParentFolderID = &parent.ID
+		folder.Path = parent.Path + "/" + name
+	}
+	if err := repo.CreateFolder(context.Background(), folder); err != nil {
+		t.Fatalf("failed to seed folder %s: %v", name, err)
+	}
+	return folder
+}
+
+// seedDocuments registers n documents directly in a folder
+func seedDocuments(t *testing.T, pool *pgxpool.Pool, ownerID uuid.UUID, folder *domain.Folder, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		_, err := pool.Exec(context.Background(), `
+			INSERT INTO documents (title, folder_id, registrant_id)
+			VALUES ($1, $2, $3)
+		`, folder.Name+"-doc", folder.ID, ownerID)
+		if err != nil {
+			t.Fatalf("failed to seed documents in %s: %v", folder.Name, err)
+		}
+	}
+}
+
+func TestGetFolderTreeCounts(t *testing.T) {
+	pool := pgtest.NewPool(t)
+	repo := NewRepository(pool)
+	ctx := context.Background()
+
+	ownerID := seedUser(t, pool)
+	otherID := seedUser(t, pool)
+
+	// root(1) ─┬─ a(2) ── a1(3) ── a1x(0)
+	//          └─ b(4)
+	// empty(0)
+	root := seedFolder(t, repo, ownerID, nil, "root")
+	a := seedFolder(t, repo, ownerID, root, "a")
+	a1 := seedFolder(t, repo, ownerID, a, "a1")
+	a1x := seedFolder(t, repo, ownerID, a1, "a1x")
+	b := seedFolder(t, repo, ownerID, root, "b")
+	empty := seedFolder(t, repo, ownerID, nil, "empty")
+	seedDocuments(t, pool, ownerID, root, 1)
+	seedDocuments(t, pool, ownerID, a, 2)
+	seedDocuments(t, pool, ownerID, a1, 3)
+	seedDocuments(t, pool, ownerID, b, 4)
+
+	// Another owner's tree never shows up
+	other := seedFolder(t, repo, otherID, nil, "root")
+	seedDocuments(t, pool, otherID, other, 5)
+
+	counts, total, err := repo.GetFolderTreeCounts(ctx, ownerID, 100, 0)
+	if err != nil {
+		t.Fatalf("GetFolderTreeCounts() error = %v", err)
+	}
+	if total != 6 || len(counts) != 6 {
+		t.Fatalf("GetFolderTreeCounts() = %d folders, total %d; want 6, total 6", len(counts), total)
+	}
+
+	byID := make(map[uuid.UUID]*FolderTreeCount)
+	for _, c := range counts {
+		byID[c.ID] = c
+	}
+
+	tests := []struct {
+		name          string
+		folder        *domain.Folder
+		wantDirect    int
+		wantRecursive int
+	}{
+		{name: "root rolls up every level", folder: root, wantDirect: 1, wantRecursive: 10},
+		{name: "middle folder rolls up its subtree", folder: a, wantDirect: 2, wantRecursive: 5},
+		{name: "folder with an empty child", folder: a1, wantDirect: 3, wantRecursive: 3},
+		{name: "empty leaf", folder: a1x, wantDirect: 0, wantRecursive: 0},
+		{name: "sibling leaf", folder: b, wantDirect: 4, wantRecursive: 4},
+		{name: "empty root", folder: empty, wantDirect: 0, wantRecursive: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := byID[tt.folder.ID]
+			if !ok {
+				t.Fatalf("folder %s missing from the tree", tt.folder.Path)
+			}
+			if got.DirectCount != tt.wantDirect || got.RecursiveCount != tt.wantRecursive {
+				t.Fatalf("%s counts = direct %d, recursive %d; want direct %d, recursive %d",
+					tt.folder.Path, got.DirectCount, got.RecursiveCount, tt.wantDirect, tt.wantRecursive)
+			}
+			if got.Path != tt.folder.Path {
+				t.Fatalf("path = %q, want %q", got.Path, tt.folder.Path)
+			}
+		})
+	}
+
+	// Pages follow the path order without repeating folders
+	seen := make(map[uuid.UUID]bool)
+	for offset := 0; offset < 6; offset += 4 {
+		page, _, err := repo.GetFolderTreeCounts(ctx, ownerID, 4, offset)
+		if err != nil {
+			t.Fatalf("offset %d: %v", offset, err)
+		}
+		for _, c := range page {
+			if seen[c.ID] {
+				t.Fatalf("offset %d repeats folder %s", offset, c.Path)
+			}
+			seen[c.ID] = true
+		}
+	}
+	if len(seen) != 6 {
+		t.Fatalf("pages returned %d distinct folders, want 6", len(seen))
+	}
+}
+
+func TestGetDocumentInfo(t *testing.T) {
+	pool := pgtest.NewPool(t)
+	repo := NewRepository(pool)
+	ctx := context.Background()
+
+	ownerID := seedUser(t, pool)
+	sharedWithID := seedUser(t, pool)
+	memberID := seedUser(t, pool)
+	strangerID := seedUser(t, pool)
+	folder := seedFolder(t, repo, ownerID, nil, "reports")
+	department := "finance-" + uuid.NewString()[:8]
+	seedDepartment(t, pool, department, memberID)
+
+	var documentID uuid.UUID
+	err := pool.QueryRow(ctx, `
+		INSERT INTO documents (title, folder_id, registrant_id) VALUES ('Budget 2026', $1, $2) RETURNING id
+	`, folder.ID, ownerID).Scan(&documentID)
+	if err != nil {
+		t.Fatalf("failed to seed document: %v", err)
+	}
+	_, err = pool.Exec(ctx, `
+		INSERT INTO document_attachments (document_id, file_name, file_path, file_size, file_type, version, is_current, uploaded_by)
+		VALUES ($1, 'budget-v1.pdf', 'uploads/v1', 1024, 'application/pdf', 1, false, $2),
+		       ($1, 'budget-v2.pdf', 'uploads/v2', 2048, 'application/pdf', 2, true, $2);
+	`, documentID, ownerID)
+	if err != nil {
+		t.Fatalf("failed to seed attachments: %v", err)
+	}
+	if _, err := pool.Exec(ctx, `
+		INSERT INTO document_shares (document_id, shared_with_user_id, shared_by) VALUES ($1, $2, $3)
+	`, documentID, sharedWithID, ownerID); err != nil {
+		t.Fatalf("failed to seed share: %v", err)
+	}
+	route := &domain.DocumentRoute{DocumentID: documentID, ToDepartmentID: department, FromStatus: domain.DocumentStatusDraft, ToStatus: domain.DocumentStatusDraft, RoutedBy: ownerID}
+	if err := repo.RouteDocument(ctx, route); err != nil {
+		t.Fatalf("RouteDocument() error = %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		requester      uuid.UUID
+		wantAccessible bool
+	}{
+		{name: "registrant", requester: ownerID, wantAccessible: true},
+		{name: "user it is shared with", requester: sharedWithID, wantAccessible: true},
+		{name: "member of the department holding it", requester: memberID, wantAccessible: true},
+		{name: "other user", requester: strangerID, wantAccessible: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, accessible, err := repo.GetDocumentInfo(ctx, documentID, tt.requester)
+			if err != nil {
+				t.Fatalf("GetDocumentInfo() error = %v", err)
+			}
+			if accessible != tt.wantAccessible {
+				t.Fatalf("accessible = %v, want %v", accessible, tt.wantAccessible)
+			}
+			if info.Title != "Budget 2026" || info.VersionCount != 2 {
+				t.Fatalf("info = %+v, want Budget 2026 with 2 versions", info)
+			}
+			if info.FileName == nil || *info.FileName != "budget-v2.pdf" || info.FileSize == nil || *info.FileSize != 2048 {
+				t.Fatalf("info describes %v (%v bytes), want the current attachment", info.FileName, info.FileSize)
+			}
+			if info.OwnerID == nil || *info.OwnerID != ownerID || info.OwnerName == nil || *info.OwnerName != "Somchai Jaidee" {
+				t.Fatalf("info owner = %v %v, want %s Somchai Jaidee", info.OwnerID, info.OwnerName, ownerID)
+			}
+		})
+	}
+
+	if _, _, err := repo.GetDocumentInfo(ctx, uuid.New(), ownerID); err == nil {
+		t.Fatal("GetDocumentInfo() of an unknown document succeeded")
+	}
+}
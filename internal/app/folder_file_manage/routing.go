@@ -0,0 +1,81 @@
+package folder_file_manage
+
+import (
+	"context"
+	"e-document-backend/internal/domain"
+	"e-document-backend/internal/platform/postgres"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrDocumentMoved is returned when a document changed department while it was being routed
+var ErrDocumentMoved = errors.New("document was routed concurrently")
+
+// GetUserDepartmentID retrieves the department a user belongs to (empty if none)
+func (r *repository) GetUserDepartmentID(ctx context.Context, userID uuid.UUID) (string, error) {
+	query := `SELECT COALESCE(department_id, '') FROM users WHERE id = $1`
+
+	var departmentID string
+	if err := r.pool.QueryRow(ctx, query, userID).Scan(&departmentID); err != nil {
+		if err == pgx.ErrNoRows {
+			return "", fmt.Errorf("user not found")
+		}
+		return "", fmt.Errorf("failed to get user department: %w", err)
+	}
+
+	return departmentID, nil
+}
+
+// DepartmentExists reports whether a department is registered under the given ID
+func (r *repository) DepartmentExists(ctx context.Context, departmentID string) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM departments WHERE id = $1)`
+
+	var exists bool
+	if err := r.pool.QueryRow(ctx, query, departmentID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to find department: %w", err)
+	}
+
+	return exists, nil
+}
+
+// RouteDocument moves a document to the route's target department and status and records the route
+// The document must still be in the route's source department, otherwise ErrDocumentMoved is returned
+func (r *repository) RouteDocument(ctx context.Context, route *domain.DocumentRoute) error {
+	return postgres.WithTx(ctx, r.pool, func(tx pgx.Tx) error {
+		updateQuery := `
+			UPDATE documents
+			SET current_department_id = $2, status = $3, updated_at = NOW()
+			WHERE id = $1 AND current_department_id IS NOT DISTINCT FROM $4
+		`
+		result, err := tx.Exec(ctx, updateQuery, route.DocumentID, route.ToDepartmentID, route.ToStatus, route.FromDepartmentID)
+		if err != nil {
+			return fmt.Errorf("failed to update document department: %w", err)
+		}
+		if result.RowsAffected() == 0 {
+			return ErrDocumentMoved
+		}
+
+		insertQuery := `
+			INSERT INTO document_routes (document_id, from_department_id, to_department_id, from_status, to_status, routed_by, note)
+			VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''))
+			RETURNING id, created_at
+		`
+		err = tx.QueryRow(ctx, insertQuery,
+			route.DocumentID,
+			route.FromDepartmentID,
+			route.ToDepartmentID,
+			route.FromStatus,
+			route.ToStatus,
+			route.RoutedBy,
+			route.Note,
+		).Scan(&route.ID, &route.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to record document route: %w", err)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,116 @@
+package folder_file_manage
+
+import (
+	"context"
+	"e-document-backend/internal/domain"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// routingRepository holds one document, a set of departments by ID and the department of each user
+type routingRepository struct {
+	Repository
+	doc         *domain.Document
+	departments map[string]string // name by ID
+	members     map[uuid.UUID]string
+	routed      *domain.DocumentRoute
+}
+
+func (r *routingRepository) GetDocumentByID(ctx context.Context, documentID uuid.UUID) (*DocumentWithAttachment, error) {
+	return &DocumentWithAttachment{Document: r.doc}, nil
+}
+
+func (r *routingRepository) DepartmentExists(ctx context.Context, departmentID string) (bool, error) {
+	_, ok := r.departments[departmentID]
+	return ok, nil
+}
+
+func (r *routingRepository) GetUserDepartmentID(ctx context.Context, userID uuid.UUID) (string, error) {
+	return r.members[userID], nil
+}
+
+func (r *routingRepository) HasDocumentShare(ctx context.Context, documentID, userID uuid.UUID) (bool, error) {
+	return false, nil
+}
+
+func (r *routingRepository) RouteDocument(ctx context.Context, route *domain.DocumentRoute) error {
+	r.routed = route
+	r.doc.CurrentDepartmentID = &route.ToDepartmentID
+	r.doc.Status = route.ToStatus
+	return nil
+}
+
+func TestRouteDocumentDepartment(t *testing.T) {
+	registrantID := uuid.New()
+	departments := map[string]string{
+		"finance": "Finance",
+		"hr":      "Human Resources",
+	}
+
+	tests := []struct {
+		name       string
+		department string
+		wantID     string
+		wantStatus int
+	}{
+		{name: "by ID", department: "finance", wantID: "finance"},
+		{name: "surrounding spaces", department: " hr ", wantID: "hr"},
+		{name: "unknown department", department: "marketing", wantStatus: 400},
+		{name: "department name", department: "Human Resources", wantStatus: 400},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &routingRepository{
+				doc:         &domain.Document{ID: uuid.New(), RegistrantID: &registrantID, Status: domain.DocumentStatusDraft},
+				departments: departments,
+			}
+			svc := NewService(repo, nil, nil)
+
+			route, err := svc.RouteDocument(context.Background(), repo.doc.ID, registrantID, domain.RouteDocumentRequest{DepartmentID: tt.department})
+			if tt.wantStatus != 0 {
+				assertStatus(t, err, tt.wantStatus)
+				if repo.routed != nil {
+					t.Fatal("document was routed")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RouteDocument() error = %v", err)
+			}
+			if route.ToDepartmentID != tt.wantID || repo.routed == nil {
+				t.Fatalf("routed to %s, want %s", route.ToDepartmentID, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestRoutedDocumentReadableByDepartment(t *testing.T) {
+	registrantID, accountantID, lawyerID := uuid.New(), uuid.New(), uuid.New()
+	repo := &routingRepository{
+		doc:         &domain.Document{ID: uuid.New(), RegistrantID: &registrantID, Status: domain.DocumentStatusDraft},
+		departments: map[string]string{"finance": "Finance", "legal": "Legal"},
+		members:     map[uuid.UUID]string{accountantID: "finance", lawyerID: "legal"},
+	}
+	svc := NewService(repo, nil, nil)
+	ctx := context.Background()
+
+	_, err := svc.GetDocument(ctx, repo.doc.ID, accountantID)
+	assertStatus(t, err, 403)
+
+	if _, err := svc.RouteDocument(ctx, repo.doc.ID, registrantID, domain.RouteDocumentRequest{DepartmentID: "finance"}); err != nil {
+		t.Fatalf("RouteDocument() error = %v", err)
+	}
+
+	doc, err := svc.GetDocument(ctx, repo.doc.ID, accountantID)
+	if err != nil {
+		t.Fatalf("GetDocument() by a member of the target department error = %v", err)
+	}
+	if doc.ID != repo.doc.ID {
+		t.Fatalf("GetDocument() = %s, want %s", doc.ID, repo.doc.ID)
+	}
+
+	_, err = svc.GetDocument(ctx, repo.doc.ID, lawyerID)
+	assertStatus(t, err, 403)
+}
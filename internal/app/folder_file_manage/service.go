@@ -3,42 +3,109 @@ package folder_file_manage
 import (
 	"context"
 	"e-document-backend/internal/domain"
+	"e-document-backend/internal/util"
+	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 // Service defines business logic for storage operations
 type Service interface {
 	// Folder operations
-	GetFolder(ctx context.Context, folderID uuid.UUID) (*domain.Folder, error)
+	GetFolder(ctx context.Context, folderID, requesterID uuid.UUID) (*domain.Folder, error)
 	GetRootFolders(ctx context.Context, ownerID uuid.UUID, page, pageSize int) ([]*domain.Folder, int, error)
-	GetSubfolders(ctx context.Context, parentFolderID uuid.UUID, page, pageSize int) ([]*domain.Folder, int, error)
-	GetFolderContents(ctx context.Context, folderID uuid.UUID) (*FolderContents, error)
+	GetSubfolders(ctx context.Context, parentFolderID, requesterID uuid.UUID, page, pageSize int) ([]*domain.Folder, int, error)
+	GetFolderContents(ctx context.Context, folderID, requesterID uuid.UUID, query FolderContentsQuery) (*FolderContents, error)
+	CreateFolder(ctx context.Context, ownerID uuid.UUID, req domain.CreateFolderRequest) (*domain.Folder, error)
+	EnsureFolderPath(ctx context.Context, ownerID uuid.UUID, req domain.EnsureFolderPathRequest) (*domain.EnsureFolderPathResult, error)
+	UpdateFolder(ctx context.Context, folderID, ownerID uuid.UUID, req domain.UpdateFolderRequest) (*domain.Folder, error)
+	GetFolderTreeCounts(ctx context.Context, ownerID uuid.UUID, page, pageSize int) ([]*FolderTreeCount, int, error)
 
 	// Document operations
-	GetDocument(ctx context.Context, documentID uuid.UUID) (*DocumentWithAttachment, error)
-	GetDocumentsByFolder(ctx context.Context, folderID uuid.UUID, page, pageSize int) ([]*DocumentWithAttachment, int, error)
-	GetAllDocuments(ctx context.Context, ownerID uuid.UUID, page, pageSize int) ([]*DocumentWithAttachment, int, error)
+	GetDocument(ctx context.Context, documentID, requesterID uuid.UUID) (*DocumentWithAttachment, error)
+	GetDocumentInfo(ctx context.Context, documentID, requesterID uuid.UUID) (*DocumentInfo, error)
+	UpdateDocument(ctx context.Context, documentID, requesterID uuid.UUID, req domain.UpdateDocumentRequest) (*DocumentWithAttachment, error)
+	GetDocumentsByFolder(ctx context.Context, folderID, requesterID uuid.UUID, page, pageSize int) ([]*DocumentWithAttachment, int, error)
+	GetAllDocuments(ctx context.Context, ownerID uuid.UUID, tags DocumentTagFilter, page, pageSize int) ([]*DocumentWithAttachment, int, error)
+	GetDocumentsByCursor(ctx context.Context, ownerID uuid.UUID, tags DocumentTagFilter, cursor string, pageSize int) ([]*DocumentWithAttachment, string, error)
+	ExportDocuments(ctx context.Context, ownerID uuid.UUID, filter DocumentExportFilter, fn func(*DocumentExportRow) error) error
+	GenerateDocumentBarcode(ctx context.Context, documentID, ownerID uuid.UUID) (string, error)
+	GetDocumentByBarcode(ctx context.Context, barcode string, requesterID uuid.UUID) (*DocumentWithAttachment, error)
+
+	// Document sharing
+	ShareDocument(ctx context.Context, documentID, ownerID uuid.UUID, req domain.ShareDocumentRequest) (*domain.DocumentShare, error)
+	UnshareDocument(ctx context.Context, documentID, ownerID, userID uuid.UUID) error
+	GetSharedDocuments(ctx context.Context, userID uuid.UUID, page, pageSize int) ([]*SharedDocument, int, error)
+
+	// Document routing
+	RouteDocument(ctx context.Context, documentID, requesterID uuid.UUID, req domain.RouteDocumentRequest) (*domain.DocumentRoute, error)
+
+	// Document duplication
+	CopyDocument(ctx context.Context, documentID, requesterID uuid.UUID, req domain.CopyDocumentRequest) (*DocumentWithAttachment, error)
 
 	// Recent files
-	GetRecentFiles(ctx context.Context, ownerID uuid.UUID, limit int) ([]*RecentFile, error)
+	GetRecentFiles(ctx context.Context, ownerID uuid.UUID, filter RecentFileFilter, limit int) ([]*RecentFile, error)
+
+	// Document tags
+	AddDocumentTags(ctx context.Context, documentID, requesterID uuid.UUID, req domain.AddDocumentTagsRequest) ([]string, error)
+	RemoveDocumentTag(ctx context.Context, documentID, requesterID uuid.UUID, name string) error
+	AttachTags(ctx context.Context, docs ...*DocumentWithAttachment) error
+
+	// Response expansion
+	ExpandUploaders(ctx context.Context, docs ...*DocumentWithAttachment) error
+
+	// Document location
+	AttachLocation(ctx context.Context, requesterID uuid.UUID, doc *DocumentWithAttachment) error
+
+	// Summary statistics
+	GetSummary(ctx context.Context, ownerID uuid.UUID) (*StorageSummary, error)
+}
+
+// QuotaChecker enforces storage quotas before a document copy is stored
+type QuotaChecker interface {
+	CheckUpload(ctx context.Context, ownerID string, size int64) error
+}
+
+// FolderPathEnsurer creates nested folder paths the same way uploads create their folders
+type FolderPathEnsurer interface {
+	EnsureFolderPath(ctx context.Context, ownerID uuid.UUID, req domain.EnsureFolderPathRequest) (*domain.EnsureFolderPathResult, error)
 }
 
 // service implements Service
 type service struct {
-	repo Repository
+	repo        Repository
+	quota       QuotaChecker
+	folderPaths FolderPathEnsurer
 }
 
 // NewService creates a new storage service
-func NewService(repo Repository) Service {
+func NewService(repo Repository, quota QuotaChecker, folderPaths FolderPathEnsurer) Service {
 	return &service{
-		repo: repo,
+		repo:        repo,
+		quota:       quota,
+		folderPaths: folderPaths,
 	}
 }
 
-// GetFolder retrieves folder details
-func (s *service) GetFolder(ctx context.Context, folderID uuid.UUID) (*domain.Folder, error) {
-	return s.repo.GetFolderByID(ctx, folderID)
+// GetFolder retrieves details of a folder owned by the requester
+func (s *service) GetFolder(ctx context.Context, folderID, requesterID uuid.UUID) (*domain.Folder, error) {
+	return s.getOwnedFolder(ctx, folderID, requesterID)
+}
+
+// getOwnedFolder retrieves a folder and checks that it belongs to the given owner
+func (s *service) getOwnedFolder(ctx context.Context, folderID, ownerID uuid.UUID) (*domain.Folder, error) {
+	folder, err := s.repo.GetFolderByID(ctx, folderID)
+	if err != nil {
+		return nil, util.NewNotFoundError("Folder", folderID.String())
+	}
+	if folder.OwnerID != ownerID {
+		return nil, util.NewForbiddenError("you do not own this folder")
+	}
+
+	return folder, nil
 }
 
 // GetRootFolders retrieves root folders with pagination
@@ -55,51 +122,245 @@ func (s *service) GetRootFolders(ctx context.Context, ownerID uuid.UUID, page, p
 	return folders, total, nil
 }
 
-// GetSubfolders retrieves subfolders with pagination
-func (s *service) GetSubfolders(ctx context.Context, parentFolderID uuid.UUID, page, pageSize int) ([]*domain.Folder, int, error) {
+// GetSubfolders retrieves subfolders of a folder owned by the requester with pagination
+func (s *service) GetSubfolders(ctx context.Context, parentFolderID, requesterID uuid.UUID, page, pageSize int) ([]*domain.Folder, int, error) {
+	if _, err := s.getOwnedFolder(ctx, parentFolderID, requesterID); err != nil {
+		return nil, 0, err
+	}
+
 	// Calculate offset
 	offset := (page - 1) * pageSize
 
 	// Get subfolders with count
 	folders, total, err := s.repo.GetSubfolders(ctx, parentFolderID, pageSize, offset)
 	if err != nil {
-		return nil, 0, err
+		return nil, 0, util.NewDatabaseError("get subfolders", err)
 	}
 
 	return folders, total, nil
 }
 
-// GetFolderContents retrieves folder contents (subfolders + documents)
-func (s *service) GetFolderContents(ctx context.Context, folderID uuid.UUID) (*FolderContents, error) {
-	return s.repo.GetFolderContents(ctx, folderID)
+// CreateFolder creates a folder at the root or under a parent owned by the same user
+func (s *service) CreateFolder(ctx context.Context, ownerID uuid.UUID, req domain.CreateFolderRequest) (*domain.Folder, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || strings.ContainsAny(name, "/\\") {
+		return nil, util.NewInvalidInputError("name", "must be non-empty and must not contain path separators")
+	}
+
+	color, icon, err := normalizeFolderAppearance(req.Color, req.Icon)
+	if err != nil {
+		return nil, err
+	}
+
+	folder := &domain.Folder{
+		Name:         name,
+		Path:         name,
+		IsRootFolder: true,
+		OwnerID:      ownerID,
+		Color:        color,
+		Icon:         icon,
+	}
+
+	if req.ParentFolderID != nil {
+		parent, err := s.repo.GetFolderByID(ctx, *req.ParentFolderID)
+		if err != nil {
+			return nil, util.NewNotFoundError("Folder", req.ParentFolderID.String())
+		}
+		if parent.OwnerID != ownerID {
+			return nil, util.NewForbiddenError("you do not own the parent folder")
+		}
+		folder.Path = parent.Path + "/" + name
+		folder.IsRootFolder = false
+		folder.ParentFolderID = &parent.ID
+	}
+
+	if err := s.repo.CreateFolder(ctx, folder); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, util.ErrorResponse(
+				"Folder already exists",
+				util.ALREADY_EXISTS,
+				409,
+				fmt.Sprintf("a folder named '%s' already exists in this location", name),
+			)
+		}
+		return nil, util.NewDatabaseError("create folder", err)
+	}
+
+	return folder, nil
+}
+
+// EnsureFolderPath makes sure every folder of a path exists below an optional parent folder,
+// reusing existing folders like uploads with a relative path do
+func (s *service) EnsureFolderPath(ctx context.Context, ownerID uuid.UUID, req domain.EnsureFolderPathRequest) (*domain.EnsureFolderPathResult, error) {
+	return s.folderPaths.EnsureFolderPath(ctx, ownerID, req)
+}
+
+// UpdateFolder updates the appearance (color/icon) of a folder owned by the user
+func (s *service) UpdateFolder(ctx context.Context, folderID, ownerID uuid.UUID, req domain.UpdateFolderRequest) (*domain.Folder, error) {
+	folder, err := s.repo.GetFolderByID(ctx, folderID)
+	if err != nil {
+		return nil, util.NewNotFoundError("Folder", folderID.String())
+	}
+	if folder.OwnerID != ownerID {
+		return nil, util.NewForbiddenError("you do not own this folder")
+	}
+
+	// Fields that are not provided keep their current value
+	color, icon := folder.Color, folder.Icon
+	if req.Color != nil {
+		color = req.Color
+	}
+	if req.Icon != nil {
+		icon = req.Icon
+	}
+
+	color, icon, err = normalizeFolderAppearance(color, icon)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateFolderAppearance(ctx, folderID, color, icon); err != nil {
+		return nil, util.NewDatabaseError("update folder", err)
+	}
+
+	return s.repo.GetFolderByID(ctx, folderID)
+}
+
+// normalizeFolderAppearance validates color/icon against the allowed sets
+// Empty strings are converted to nil so the folder falls back to defaults
+func normalizeFolderAppearance(color, icon *string) (*string, *string, error) {
+	if color != nil && *color == "" {
+		color = nil
+	}
+	if icon != nil && *icon == "" {
+		icon = nil
+	}
+
+	if color != nil && !domain.FolderColor(*color).IsValid() {
+		return nil, nil, util.NewInvalidInputError("color", "must be one of gray, red, orange, yellow, green, blue, purple, pink")
+	}
+	if icon != nil && !domain.FolderIcon(*icon).IsValid() {
+		return nil, nil, util.NewInvalidInputError("icon", "must be one of folder, star, archive, briefcase, document, image, lock, users")
+	}
+
+	return color, icon, nil
+}
+
+// GetFolderTreeCounts retrieves document counts for the owner's folder tree with pagination
+func (s *service) GetFolderTreeCounts(ctx context.Context, ownerID uuid.UUID, page, pageSize int) ([]*FolderTreeCount, int, error) {
+	// Calculate offset
+	offset := (page - 1) * pageSize
+
+	return s.repo.GetFolderTreeCounts(ctx, ownerID, pageSize, offset)
+}
+
+// GetDocument retrieves document details for its registrant, a user it is shared with, or a member
+// of the department currently holding it
+func (s *service) GetDocument(ctx context.Context, documentID, requesterID uuid.UUID) (*DocumentWithAttachment, error) {
+	doc, err := s.repo.GetDocumentByID(ctx, documentID)
+	if err != nil {
+		return nil, util.NewNotFoundError("Document", documentID.String())
+	}
+
+	if doc.RegistrantID != nil && *doc.RegistrantID == requesterID {
+		return doc, nil
+	}
+
+	if doc.CurrentDepartmentID != nil {
+		departmentID, err := s.repo.GetUserDepartmentID(ctx, requesterID)
+		if err != nil {
+			return nil, util.NewUnauthorizedError("requesting user not found")
+		}
+		if departmentID == *doc.CurrentDepartmentID {
+			return doc, nil
+		}
+	}
+
+	shared, err := s.repo.HasDocumentShare(ctx, documentID, requesterID)
+	if err != nil {
+		return nil, util.NewDatabaseError("check document share", err)
+	}
+	if !shared {
+		return nil, util.NewForbiddenError("you do not have access to this document")
+	}
+
+	return doc, nil
+}
+
+// UpdateDocument edits the title and/or description of a document owned by the requester
+func (s *service) UpdateDocument(ctx context.Context, documentID, requesterID uuid.UUID, req domain.UpdateDocumentRequest) (*DocumentWithAttachment, error) {
+	doc, err := s.repo.GetDocumentByID(ctx, documentID)
+	if err != nil {
+		return nil, util.NewNotFoundError("Document", documentID.String())
+	}
+	if doc.RegistrantID == nil || *doc.RegistrantID != requesterID {
+		return nil, util.NewForbiddenError("you can only edit your own documents")
+	}
+
+	// Fields that are not provided keep their current value
+	title, description := doc.Title, doc.Description
+	if req.Title != nil {
+		title = strings.TrimSpace(*req.Title)
+		if title == "" {
+			return nil, util.NewInvalidInputError("title", "must not be empty")
+		}
+	}
+	if req.Description != nil {
+		description = strings.TrimSpace(*req.Description)
+	}
+
+	if err := s.repo.UpdateDocumentDetails(ctx, documentID, title, description); err != nil {
+		return nil, util.NewDatabaseError("update document", err)
+	}
+
+	// The document can be deleted between the update and the read back
+	updated, err := s.repo.GetDocumentByID(ctx, documentID)
+	if err != nil {
+		return nil, util.NewNotFoundError("Document", documentID.String())
+	}
+
+	return updated, nil
 }
 
-// GetDocument retrieves document details
-func (s *service) GetDocument(ctx context.Context, documentID uuid.UUID) (*DocumentWithAttachment, error) {
-	return s.repo.GetDocumentByID(ctx, documentID)
+// GetDocumentInfo retrieves the compact summary of a document for its registrant or a user it is shared with
+func (s *service) GetDocumentInfo(ctx context.Context, documentID, requesterID uuid.UUID) (*DocumentInfo, error) {
+	info, accessible, err := s.repo.GetDocumentInfo(ctx, documentID, requesterID)
+	if err != nil {
+		return nil, util.NewNotFoundError("Document", documentID.String())
+	}
+	if !accessible {
+		return nil, util.NewForbiddenError("you do not have access to this document")
+	}
+
+	return info, nil
 }
 
-// GetDocumentsByFolder retrieves documents in a folder with pagination
-func (s *service) GetDocumentsByFolder(ctx context.Context, folderID uuid.UUID, page, pageSize int) ([]*DocumentWithAttachment, int, error) {
+// GetDocumentsByFolder retrieves documents in a folder owned by the requester with pagination
+func (s *service) GetDocumentsByFolder(ctx context.Context, folderID, requesterID uuid.UUID, page, pageSize int) ([]*DocumentWithAttachment, int, error) {
+	if _, err := s.getOwnedFolder(ctx, folderID, requesterID); err != nil {
+		return nil, 0, err
+	}
+
 	// Calculate offset
 	offset := (page - 1) * pageSize
 
 	// Get documents with count
 	documents, total, err := s.repo.GetDocumentsByFolderID(ctx, folderID, pageSize, offset)
 	if err != nil {
-		return nil, 0, err
+		return nil, 0, util.NewDatabaseError("get documents", err)
 	}
 
 	return documents, total, nil
 }
 
-// GetAllDocuments retrieves all documents for a user with pagination
-func (s *service) GetAllDocuments(ctx context.Context, ownerID uuid.UUID, page, pageSize int) ([]*DocumentWithAttachment, int, error) {
+// GetAllDocuments retrieves all documents for a user with pagination, optionally only those with the given tags
+func (s *service) GetAllDocuments(ctx context.Context, ownerID uuid.UUID, tags DocumentTagFilter, page, pageSize int) ([]*DocumentWithAttachment, int, error) {
 	// Calculate offset
 	offset := (page - 1) * pageSize
 
 	// Get documents with count
-	documents, total, err := s.repo.GetAllDocuments(ctx, ownerID, pageSize, offset)
+	documents, total, err := s.repo.GetAllDocuments(ctx, ownerID, tags, pageSize, offset)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -107,7 +368,187 @@ func (s *service) GetAllDocuments(ctx context.Context, ownerID uuid.UUID, page,
 	return documents, total, nil
 }
 
+// ExportDocuments streams all of a user's documents matching the filter to fn
+func (s *service) ExportDocuments(ctx context.Context, ownerID uuid.UUID, filter DocumentExportFilter, fn func(*DocumentExportRow) error) error {
+	if filter.Status != nil && !domain.DocumentStatus(*filter.Status).IsValid() {
+		return util.NewInvalidInputError("status", "must be one of Draft, Pending, Approved, Rejected")
+	}
+
+	return s.repo.StreamDocuments(ctx, ownerID, filter, fn)
+}
+
+// ShareDocument gives another user read access to a document owned by the requester
+func (s *service) ShareDocument(ctx context.Context, documentID, ownerID uuid.UUID, req domain.ShareDocumentRequest) (*domain.DocumentShare, error) {
+	permission := req.Permission
+	if permission == "" {
+		permission = domain.SharePermissionRead
+	}
+	if !permission.IsValid() {
+		return nil, util.NewInvalidInputError("permission", "must be read")
+	}
+
+	if req.UserID == ownerID {
+		return nil, util.NewInvalidInputError("user_id", "cannot share a document with yourself")
+	}
+
+	doc, err := s.repo.GetDocumentByID(ctx, documentID)
+	if err != nil {
+		return nil, util.NewNotFoundError("Document", documentID.String())
+	}
+	if doc.RegistrantID == nil || *doc.RegistrantID != ownerID {
+		return nil, util.NewForbiddenError("you can only share your own documents")
+	}
+
+	share := &domain.DocumentShare{
+		DocumentID:       documentID,
+		SharedWithUserID: req.UserID,
+		SharedBy:         ownerID,
+		Permission:       permission,
+	}
+
+	if err := s.repo.CreateDocumentShare(ctx, share); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return nil, util.NewNotFoundError("User", req.UserID.String())
+		}
+		return nil, util.NewDatabaseError("share document", err)
+	}
+
+	return share, nil
+}
+
+// UnshareDocument revokes a user's access to a document owned by the requester
+func (s *service) UnshareDocument(ctx context.Context, documentID, ownerID, userID uuid.UUID) error {
+	doc, err := s.repo.GetDocumentByID(ctx, documentID)
+	if err != nil {
+		return util.NewNotFoundError("Document", documentID.String())
+	}
+	if doc.RegistrantID == nil || *doc.RegistrantID != ownerID {
+		return util.NewForbiddenError("you can only manage sharing of your own documents")
+	}
+
+	deleted, err := s.repo.DeleteDocumentShare(ctx, documentID, userID)
+	if err != nil {
+		return util.NewDatabaseError("unshare document", err)
+	}
+	if !deleted {
+		return util.NewNotFoundError("Document share", userID.String())
+	}
+
+	return nil
+}
+
+// GetSharedDocuments retrieves documents shared with a user with pagination
+func (s *service) GetSharedDocuments(ctx context.Context, userID uuid.UUID, page, pageSize int) ([]*SharedDocument, int, error) {
+	// Calculate offset
+	offset := (page - 1) * pageSize
+
+	return s.repo.GetSharedDocuments(ctx, userID, pageSize, offset)
+}
+
+// RouteDocument sends a document to another department, optionally moving it to Pending
+// The registrant may route a document, and so may members of the department currently holding it
+func (s *service) RouteDocument(ctx context.Context, documentID, requesterID uuid.UUID, req domain.RouteDocumentRequest) (*domain.DocumentRoute, error) {
+	doc, err := s.repo.GetDocumentByID(ctx, documentID)
+	if err != nil {
+		return nil, util.NewNotFoundError("Document", documentID.String())
+	}
+
+	isRegistrant := doc.RegistrantID != nil && *doc.RegistrantID == requesterID
+	if !isRegistrant {
+		departmentID, err := s.repo.GetUserDepartmentID(ctx, requesterID)
+		if err != nil {
+			return nil, util.NewUnauthorizedError("requesting user not found")
+		}
+		if doc.CurrentDepartmentID == nil || departmentID != *doc.CurrentDepartmentID {
+			return nil, util.NewForbiddenError("only the registrant or the department holding the document can route it")
+		}
+	}
+
+	departmentID := strings.TrimSpace(req.DepartmentID)
+	exists, err := s.repo.DepartmentExists(ctx, departmentID)
+	if err != nil {
+		return nil, util.NewDatabaseError("find department", err)
+	}
+	if !exists {
+		return nil, util.NewInvalidInputError("department_id", "department does not exist")
+	}
+	if doc.CurrentDepartmentID != nil && *doc.CurrentDepartmentID == departmentID {
+		return nil, util.NewInvalidInputError("department_id", "document is already in this department")
+	}
+
+	route := &domain.DocumentRoute{
+		DocumentID:       documentID,
+		FromDepartmentID: doc.CurrentDepartmentID,
+		ToDepartmentID:   departmentID,
+		FromStatus:       doc.Status,
+		ToStatus:         doc.Status,
+		RoutedBy:         requesterID,
+		Note:             strings.TrimSpace(req.Note),
+	}
+	if req.SetPending {
+		route.ToStatus = domain.DocumentStatusPending
+	}
+
+	if err := s.repo.RouteDocument(ctx, route); err != nil {
+		if errors.Is(err, ErrDocumentMoved) {
+			return nil, util.NewConflictError("Document was routed by someone else", "the document changed department while it was being routed, reload it and try again")
+		}
+		return nil, util.NewDatabaseError("route document", err)
+	}
+
+	return route, nil
+}
+
+// CopyDocument duplicates a document the requester can read into a folder they own
+// The copy keeps the title, description, type and category, starts as a Draft registered to the
+// requester, and gets the current attachment as its first version. Barcode, department and
+// version history are not copied
+func (s *service) CopyDocument(ctx context.Context, documentID, requesterID uuid.UUID, req domain.CopyDocumentRequest) (*DocumentWithAttachment, error) {
+	source, err := s.GetDocument(ctx, documentID, requesterID)
+	if err != nil {
+		return nil, err
+	}
+
+	folder, err := s.getOwnedFolder(ctx, req.FolderID, requesterID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The copy is charged to the requester's quota like a new upload
+	var sourceAttachmentID *uuid.UUID
+	if source.Attachment != nil {
+		if err := s.quota.CheckUpload(ctx, requesterID.String(), source.Attachment.FileSize); err != nil {
+			return nil, err
+		}
+		sourceAttachmentID = &source.Attachment.ID
+	}
+
+	doc := &domain.Document{
+		Title:        source.Title,
+		Description:  source.Description,
+		Type:         source.Type,
+		CategoryID:   source.CategoryID,
+		FolderID:     &folder.ID,
+		RegistrantID: &requesterID,
+		Status:       domain.DocumentStatusDraft,
+	}
+
+	attachment, err := s.repo.CopyDocument(ctx, doc, sourceAttachmentID, requesterID)
+	if err != nil {
+		if errors.Is(err, ErrSourceAttachmentGone) {
+			return nil, util.NewNotFoundError("Attachment", sourceAttachmentID.String())
+		}
+		return nil, util.NewDatabaseError("copy document", err)
+	}
+
+	return &DocumentWithAttachment{
+		Document:   doc,
+		Attachment: attachment,
+	}, nil
+}
+
 // GetRecentFiles retrieves recently modified files
-func (s *service) GetRecentFiles(ctx context.Context, ownerID uuid.UUID, limit int) ([]*RecentFile, error) {
-	return s.repo.GetRecentFiles(ctx, ownerID, limit)
+func (s *service) GetRecentFiles(ctx context.Context, ownerID uuid.UUID, filter RecentFileFilter, limit int) ([]*RecentFile, error) {
+	return s.repo.GetRecentFiles(ctx, ownerID, filter, limit)
 }
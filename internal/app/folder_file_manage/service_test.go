@@ -0,0 +1,159 @@
+package folder_file_manage
+
+import (
+	"context"
+	"e-document-backend/internal/domain"
+	"e-document-backend/internal/util"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func ptr(s string) *string { return &s }
+
+func deref(s *string) string {
+	if s == nil {
+		return "<nil>"
+	}
+	return *s
+}
+
+func assertStatus(t *testing.T, err error, status int) {
+	t.Helper()
+	customErr, ok := err.(*util.CustomError)
+	if !ok {
+		t.Fatalf("error = %v, want a CustomError with status %d", err, status)
+	}
+	if customErr.StatusCode != status {
+		t.Fatalf("status = %d, want %d (%s)", customErr.StatusCode, status, customErr.Detail)
+	}
+}
+
+func TestCreateFolderAppearance(t *testing.T) {
+	tests := []struct {
+		name      string
+		color     *string
+		icon      *string
+		wantColor *string
+		wantIcon  *string
+		wantField string
+	}{
+		{name: "no appearance", color: nil, icon: nil},
+		{name: "palette color", color: ptr("blue"), wantColor: ptr("blue")},
+		{name: "every palette color is accepted", color: ptr("pink"), icon: ptr("star"), wantColor: ptr("pink"), wantIcon: ptr("star")},
+		{name: "empty strings mean default", color: ptr(""), icon: ptr(""), wantColor: nil, wantIcon: nil},
+		{name: "hex color is refused", color: ptr("#ff0000"), wantField: "color"},
+		{name: "color names are case sensitive", color: ptr("Blue"), wantField: "color"},
+		{name: "unknown color", color: ptr("magenta"), wantField: "color"},
+		{name: "unknown icon", icon: ptr("rocket"), wantField: "icon"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewService(newFakeRepository(), nil, nil)
+			folder, err := svc.CreateFolder(context.Background(), uuid.New(), domain.CreateFolderRequest{
+				Name:  "Reports",
+				Color: tt.color,
+				Icon:  tt.icon,
+			})
+			if tt.wantField != "" {
+				assertStatus(t, err, 400)
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateFolder() error = %v", err)
+			}
+			if deref(folder.Color) != deref(tt.wantColor) || deref(folder.Icon) != deref(tt.wantIcon) {
+				t.Fatalf("appearance = %s/%s, want %s/%s", deref(folder.Color), deref(folder.Icon), deref(tt.wantColor), deref(tt.wantIcon))
+			}
+		})
+	}
+}
+
+func TestUpdateFolderAppearance(t *testing.T) {
+	ownerID := uuid.New()
+
+	tests := []struct {
+		name       string
+		req        domain.UpdateFolderRequest
+		requester  uuid.UUID
+		wantColor  string
+		wantIcon   string
+		wantStatus int
+	}{
+		{name: "change color keeps icon", req: domain.UpdateFolderRequest{Color: ptr("green")}, requester: ownerID, wantColor: "green", wantIcon: "star"},
+		{name: "change icon keeps color", req: domain.UpdateFolderRequest{Icon: ptr("lock")}, requester: ownerID, wantColor: "red", wantIcon: "lock"},
+		{name: "empty color resets it", req: domain.UpdateFolderRequest{Color: ptr("")}, requester: ownerID, wantColor: "<nil>", wantIcon: "star"},
+		{name: "invalid color", req: domain.UpdateFolderRequest{Color: ptr("rgb(0,0,0)")}, requester: ownerID, wantStatus: 400},
+		{name: "invalid icon", req: domain.UpdateFolderRequest{Icon: ptr("FOLDER")}, requester: ownerID, wantStatus: 400},
+		{name: "someone else's folder", req: domain.UpdateFolderRequest{Color: ptr("blue")}, requester: uuid.New(), wantStatus: 403},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			folder := &domain.Folder{ID: uuid.New(), Name: "Reports", Path: "Reports", OwnerID: ownerID, Color: ptr("red"), Icon: ptr("star")}
+			repo := newFakeRepository(folder)
+			svc := NewService(repo, nil, nil)
+
+			updated, err := svc.UpdateFolder(context.Background(), folder.ID, tt.requester, tt.req)
+			if tt.wantStatus != 0 {
+				assertStatus(t, err, tt.wantStatus)
+				stored, _ := repo.GetFolderByID(context.Background(), folder.ID)
+				if deref(stored.Color) != "red" || deref(stored.Icon) != "star" {
+					t.Fatalf("refused update changed the folder to %s/%s", deref(stored.Color), deref(stored.Icon))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UpdateFolder() error = %v", err)
+			}
+			if deref(updated.Color) != tt.wantColor || deref(updated.Icon) != tt.wantIcon {
+				t.Fatalf("appearance = %s/%s, want %s/%s", deref(updated.Color), deref(updated.Icon), tt.wantColor, tt.wantIcon)
+			}
+		})
+	}
+}
+
+// vanishingRepository deletes a document as soon as its details are updated
+type vanishingRepository struct {
+	Repository
+	doc *DocumentWithAttachment
+}
+
+func (r *vanishingRepository) GetDocumentByID(ctx context.Context, documentID uuid.UUID) (*DocumentWithAttachment, error) {
+	if r.doc == nil || r.doc.ID != documentID {
+		return nil, fmt.Errorf("document not found")
+	}
+	return r.doc, nil
+}
+
+func (r *vanishingRepository) UpdateDocumentDetails(ctx context.Context, documentID uuid.UUID, title, description string) error {
+	r.doc = nil
+	return nil
+}
+
+func TestUpdateDocumentDeletedMeanwhile(t *testing.T) {
+	ownerID := uuid.New()
+	doc := &DocumentWithAttachment{Document: &domain.Document{ID: uuid.New(), Title: "Budget", RegistrantID: &ownerID}}
+	svc := NewService(&vanishingRepository{doc: doc}, nil, nil)
+
+	updated, err := svc.UpdateDocument(context.Background(), doc.ID, ownerID, domain.UpdateDocumentRequest{Title: ptr("Budget 2026")})
+	if updated != nil {
+		t.Fatalf("UpdateDocument() = %+v, want no document", updated)
+	}
+	assertStatus(t, err, 404)
+}
+
+func TestFolderColorIsValid(t *testing.T) {
+	for _, color := range []string{"gray", "red", "orange", "yellow", "green", "blue", "purple", "pink"} {
+		if !domain.FolderColor(color).IsValid() {
+			t.Errorf("FolderColor(%q).IsValid() = false, want true", color)
+		}
+	}
+	for _, color := range []string{"", "black", "#000000", "GRAY", " red"} {
+		if domain.FolderColor(color).IsValid() {
+			t.Errorf("FolderColor(%q).IsValid() = true, want false", color)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package folder_file_manage
+
+import (
+	"context"
+	"e-document-backend/internal/domain"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SharedDocument represents a document shared with the current user
+type SharedDocument struct {
+	*DocumentWithAttachment
+	SharedBy   uuid.UUID              `json:"shared_by"`
+	Permission domain.SharePermission `json:"permission"`
+	SharedAt   time.Time              `json:"shared_at"`
+}
+
+// CreateDocumentShare shares a document with a user, updating the permission if it is already shared
+func (r *repository) CreateDocumentShare(ctx context.Context, share *domain.DocumentShare) error {
+	query := `
+		INSERT INTO document_shares (document_id, shared_with_user_id, shared_by, permission)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (document_id, shared_with_user_id)
+		DO UPDATE SET permission = EXCLUDED.permission
+		RETURNING id, created_at
+	`
+
+	err := r.pool.QueryRow(ctx, query,
+		share.DocumentID,
+		share.SharedWithUserID,
+		share.SharedBy,
+		share.Permission,
+	).Scan(&share.ID, &share.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create document share: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteDocumentShare revokes a user's access to a document
+// Returns false if the document was not shared with the user
+func (r *repository) DeleteDocumentShare(ctx context.Context, documentID, userID uuid.UUID) (bool, error) {
+	query := `DELETE FROM document_shares WHERE document_id = $1 AND shared_with_user_id = $2`
+
+	result, err := r.pool.Exec(ctx, query, documentID, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete document share: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+// HasDocumentShare checks if a document is shared with a user
+func (r *repository) HasDocumentShare(ctx context.Context, documentID, userID uuid.UUID) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM document_shares
+			WHERE document_id = $1 AND shared_with_user_id = $2
+		)
+	`
+
+	var exists bool
+	if err := r.pool.QueryRow(ctx, query, documentID, userID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check document share: %w", err)
+	}
+
+	return exists, nil
+}
+
+// GetSharedDocuments retrieves documents shared with a user, most recently shared first
+func (r *repository) GetSharedDocuments(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*SharedDocument, int, error) {
+	countQuery := `
+		SELECT COUNT(*)
+		FROM document_shares
+		WHERE shared_with_user_id = $1
+	`
+
+	var total int
+	err := r.pool.QueryRow(ctx, countQuery, userID).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count shared documents: %w", err)
+	}
+
+	query := `
+		SELECT 
+			d.id, d.title, d.description, d.type, d.category_id, d.folder_id, 
+			d.barcode, d.registrant_id, d.current_department_id, d.status, 
+			d.created_at, d.updated_at,
+			da.id, da.document_id, da.file_name, da.file_path, da.file_size, 
+			da.file_type, da.version, da.is_current, da.uploaded_by, da.created_at,
+			ds.shared_by, ds.permission, ds.created_at
+		FROM document_shares ds
+		JOIN documents d ON d.id = ds.document_id
+		LEFT JOIN document_attachments da ON d.id = da.document_id AND da.is_current = true
+		WHERE ds.shared_with_user_id = $1
+		ORDER BY ds.created_at DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.pool.Query(ctx, query, userID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get shared documents: %w", err)
+	}
+	defer rows.Close()
+
+	var documents []*SharedDocument
+	for rows.Next() {
+		doc := SharedDocument{DocumentWithAttachment: &DocumentWithAttachment{Document: &domain.Document{}}}
+		var attachment domain.DocumentAttachment
+
+		err := rows.Scan(
+			&doc.ID,
+			&doc.Title,
+			&doc.Description,
+			&doc.Type,
+			&doc.CategoryID,
+			&doc.FolderID,
+			&doc.Barcode,
+			&doc.RegistrantID,
+			&doc.CurrentDepartmentID,
+			&doc.Status,
+			&doc.CreatedAt,
+			&doc.UpdatedAt,
+			&attachment.ID,
+			&attachment.DocumentID,
+			&attachment.FileName,
+			&attachment.FilePath,
+			&attachment.FileSize,
+			&attachment.FileType,
+			&attachment.Version,
+			&attachment.IsCurrent,
+			&attachment.UploadedBy,
+			&attachment.CreatedAt,
+			&doc.SharedBy,
+			&doc.Permission,
+			&doc.SharedAt,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan shared document: %w", err)
+		}
+
+		// Check if attachment exists
+		if attachment.ID != uuid.Nil {
+			doc.Attachment = &attachment
+		}
+
+		documents = append(documents, &doc)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating shared documents: %w", err)
+	}
+
+	return documents, total, nil
+}
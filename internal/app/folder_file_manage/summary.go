@@ -0,0 +1,155 @@
+package folder_file_manage
+
+import (
+	"context"
+	"e-document-backend/internal/domain"
+	"e-document-backend/internal/util"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// summaryRecentLimit is how many recent files are included in the storage summary
+const summaryRecentLimit = 5
+
+// StorageSummary aggregates a user's storage statistics for the home screen
+type StorageSummary struct {
+	TotalDocuments    int                           `json:"total_documents"`
+	TotalFolders      int                           `json:"total_folders"`
+	StorageUsedBytes  int64                         `json:"storage_used_bytes"` // all attachment versions
+	DocumentsByStatus map[domain.DocumentStatus]int `json:"documents_by_status"`
+	RecentActivity    []*RecentFile                 `json:"recent_activity"`
+}
+
+// CountDocuments counts the documents registered by a user
+func (r *repository) CountDocuments(ctx context.Context, ownerID uuid.UUID) (int, error) {
+	query := `SELECT COUNT(*) FROM documents WHERE registrant_id = $1`
+
+	var total int
+	if err := r.pool.QueryRow(ctx, query, ownerID).Scan(&total); err != nil {
+		return 0, fmt.Errorf("failed to count documents: %w", err)
+	}
+
+	return total, nil
+}
+
+// CountFolders counts the folders owned by a user
+func (r *repository) CountFolders(ctx context.Context, ownerID uuid.UUID) (int, error) {
+	query := `SELECT COUNT(*) FROM folders WHERE owner_id = $1`
+
+	var total int
+	if err := r.pool.QueryRow(ctx, query, ownerID).Scan(&total); err != nil {
+		return 0, fmt.Errorf("failed to count folders: %w", err)
+	}
+
+	return total, nil
+}
+
+// GetStorageUsed sums the size of every stored attachment version of the user's documents
+func (r *repository) GetStorageUsed(ctx context.Context, ownerID uuid.UUID) (int64, error) {
+	query := `
+		SELECT COALESCE(SUM(da.file_size), 0)
+		FROM document_attachments da
+		INNER JOIN documents d ON d.id = da.document_id
+		WHERE d.registrant_id = $1
+	`
+
+	var used int64
+	if err := r.pool.QueryRow(ctx, query, ownerID).Scan(&used); err != nil {
+		return 0, fmt.Errorf("failed to get used storage: %w", err)
+	}
+
+	return used, nil
+}
+
+// CountDocumentsByStatus counts a user's documents grouped by status
+func (r *repository) CountDocumentsByStatus(ctx context.Context, ownerID uuid.UUID) (map[domain.DocumentStatus]int, error) {
+	query := `
+		SELECT status, COUNT(*)
+		FROM documents
+		WHERE registrant_id = $1
+		GROUP BY status
+	`
+
+	rows, err := r.pool.Query(ctx, query, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count documents by status: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[domain.DocumentStatus]int)
+	for rows.Next() {
+		var status domain.DocumentStatus
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan document status count: %w", err)
+		}
+		counts[status] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating document status counts: %w", err)
+	}
+
+	return counts, nil
+}
+
+// GetSummary retrieves a user's storage statistics, running the independent queries in parallel
+func (s *service) GetSummary(ctx context.Context, ownerID uuid.UUID) (*StorageSummary, error) {
+	var wg sync.WaitGroup
+	var summary StorageSummary
+	var documentsErr, foldersErr, usedErr, statusErr, recentErr error
+
+	wg.Add(5)
+
+	go func() {
+		defer wg.Done()
+		summary.TotalDocuments, documentsErr = s.repo.CountDocuments(ctx, ownerID)
+	}()
+
+	go func() {
+		defer wg.Done()
+		summary.TotalFolders, foldersErr = s.repo.CountFolders(ctx, ownerID)
+	}()
+
+	go func() {
+		defer wg.Done()
+		summary.StorageUsedBytes, usedErr = s.repo.GetStorageUsed(ctx, ownerID)
+	}()
+
+	go func() {
+		defer wg.Done()
+		summary.DocumentsByStatus, statusErr = s.repo.CountDocumentsByStatus(ctx, ownerID)
+	}()
+
+	go func() {
+		defer wg.Done()
+		summary.RecentActivity, recentErr = s.repo.GetRecentFiles(ctx, ownerID, RecentFileFilter{}, summaryRecentLimit)
+	}()
+
+	wg.Wait()
+
+	for _, err := range []error{documentsErr, foldersErr, usedErr, statusErr, recentErr} {
+		if err != nil {
+			return nil, util.NewDatabaseError("get storage summary", err)
+		}
+	}
+
+	// Report every status so clients don't have to treat missing keys as zero
+	for _, status := range []domain.DocumentStatus{
+		domain.DocumentStatusDraft,
+		domain.DocumentStatusPending,
+		domain.DocumentStatusApproved,
+		domain.DocumentStatusRejected,
+	} {
+		if _, ok := summary.DocumentsByStatus[status]; !ok {
+			summary.DocumentsByStatus[status] = 0
+		}
+	}
+	if summary.RecentActivity == nil {
+		summary.RecentActivity = []*RecentFile{}
+	}
+
+	return &summary, nil
+}
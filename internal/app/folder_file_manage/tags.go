@@ -0,0 +1,322 @@
+package folder_file_manage
+
+import (
+	"context"
+	"e-document-backend/internal/domain"
+	"e-document-backend/internal/platform/postgres"
+	"e-document-backend/internal/util"
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+)
+
+// maxTagLength is the longest tag name, matching tags.name
+const maxTagLength = 50
+
+// DocumentTagFilter narrows a document listing to documents carrying tags
+type DocumentTagFilter struct {
+	Names    []string // lowercased tag names; empty means no filtering
+	MatchAll bool     // documents must carry every tag instead of at least one
+}
+
+// tagFilterCondition returns the SQL condition matching documents d against a DocumentTagFilter
+// whose names and match-all flag are bound to the given parameter numbers
+func tagFilterCondition(namesParam, matchAllParam int) string {
+	return fmt.Sprintf(`(COALESCE(cardinality($%[1]d::text[]), 0) = 0 OR (
+			SELECT COUNT(*)
+			FROM document_tags dt
+			JOIN tags t ON t.id = dt.tag_id
+			WHERE dt.document_id = d.id AND lower(t.name) = ANY($%[1]d::text[])
+		) >= CASE WHEN $%[2]d::boolean THEN cardinality($%[1]d::text[]) ELSE 1 END)`, namesParam, matchAllParam)
+}
+
+// parseTagFilter reads the tags (comma-separated) and tag_match (all or any) query parameters
+func parseTagFilter(c echo.Context) (DocumentTagFilter, error) {
+	filter := DocumentTagFilter{MatchAll: true}
+
+	switch c.QueryParam("tag_match") {
+	case "", "all":
+	case "any":
+		filter.MatchAll = false
+	default:
+		return filter, util.NewInvalidInputError("tag_match", "must be all or any")
+	}
+
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(c.QueryParam("tags"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != "" && !seen[name] {
+			seen[name] = true
+			filter.Names = append(filter.Names, name)
+		}
+	}
+
+	return filter, nil
+}
+
+// AddDocumentTags attaches the named tags of the owner to a document, creating missing tags
+func (r *repository) AddDocumentTags(ctx context.Context, documentID, ownerID uuid.UUID, names []string) error {
+	lowered := make([]string, len(names))
+	for i, name := range names {
+		lowered[i] = strings.ToLower(name)
+	}
+
+	return postgres.WithTx(ctx, r.pool, func(tx pgx.Tx) error {
+		// An existing tag keeps the spelling it was created with
+		createQuery := `
+			INSERT INTO tags (owner_id, name)
+			SELECT $1, unnest($2::text[])
+			ON CONFLICT (owner_id, (lower(name))) DO NOTHING
+		`
+		if _, err := tx.Exec(ctx, createQuery, ownerID, names); err != nil {
+			return fmt.Errorf("failed to create tags: %w", err)
+		}
+
+		attachQuery := `
+			INSERT INTO document_tags (document_id, tag_id)
+			SELECT $1, id FROM tags
+			WHERE owner_id = $2 AND lower(name) = ANY($3::text[])
+			ON CONFLICT DO NOTHING
+		`
+		if _, err := tx.Exec(ctx, attachQuery, documentID, ownerID, lowered); err != nil {
+			return fmt.Errorf("failed to tag document: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// RemoveDocumentTag detaches a tag from a document; it reports whether the document had the tag
+func (r *repository) RemoveDocumentTag(ctx context.Context, documentID uuid.UUID, name string) (bool, error) {
+	query := `
+		DELETE FROM document_tags dt
+		USING tags t
+		WHERE dt.tag_id = t.id AND dt.document_id = $1 AND lower(t.name) = lower($2)
+	`
+
+	result, err := r.pool.Exec(ctx, query, documentID, name)
+	if err != nil {
+		return false, fmt.Errorf("failed to remove document tag: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+// GetDocumentTags loads the tag names of the given documents in one query, sorted by name
+func (r *repository) GetDocumentTags(ctx context.Context, documentIDs []uuid.UUID) (map[uuid.UUID][]string, error) {
+	query := `
+		SELECT dt.document_id, t.name
+		FROM document_tags dt
+		JOIN tags t ON t.id = dt.tag_id
+		WHERE dt.document_id = ANY($1)
+		ORDER BY lower(t.name)
+	`
+
+	rows, err := r.pool.Query(ctx, query, documentIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get document tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := make(map[uuid.UUID][]string, len(documentIDs))
+	for rows.Next() {
+		var documentID uuid.UUID
+		var name string
+		if err := rows.Scan(&documentID, &name); err != nil {
+			return nil, fmt.Errorf("failed to scan document tag: %w", err)
+		}
+		tags[documentID] = append(tags[documentID], name)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating document tags: %w", err)
+	}
+
+	return tags, nil
+}
+
+// getOwnedDocument loads a document the requester registered
+func (s *service) getOwnedDocument(ctx context.Context, documentID, requesterID uuid.UUID) (*DocumentWithAttachment, error) {
+	doc, err := s.repo.GetDocumentByID(ctx, documentID)
+	if err != nil {
+		return nil, util.NewNotFoundError("Document", documentID.String())
+	}
+	if doc.RegistrantID == nil || *doc.RegistrantID != requesterID {
+		return nil, util.NewForbiddenError("you can only tag your own documents")
+	}
+	return doc, nil
+}
+
+// AddDocumentTags tags a document owned by the requester and returns all of its tags
+func (s *service) AddDocumentTags(ctx context.Context, documentID, requesterID uuid.UUID, req domain.AddDocumentTagsRequest) ([]string, error) {
+	seen := make(map[string]bool, len(req.Tags))
+	names := make([]string, 0, len(req.Tags))
+	for _, name := range req.Tags {
+		name = strings.TrimSpace(name)
+		if name == "" || utf8.RuneCountInString(name) > maxTagLength {
+			return nil, util.NewInvalidInputError("tags", fmt.Sprintf("names must be 1 to %d characters", maxTagLength))
+		}
+		// Commas separate tags in the ?tags= listing filter
+		if strings.Contains(name, ",") {
+			return nil, util.NewInvalidInputError("tags", "names must not contain commas")
+		}
+		if key := strings.ToLower(name); !seen[key] {
+			seen[key] = true
+			names = append(names, name)
+		}
+	}
+
+	doc, err := s.getOwnedDocument(ctx, documentID, requesterID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.AddDocumentTags(ctx, documentID, requesterID, names); err != nil {
+		return nil, util.NewDatabaseError("tag document", err)
+	}
+
+	if err := s.AttachTags(ctx, doc); err != nil {
+		return nil, err
+	}
+	return doc.Tags, nil
+}
+
+// RemoveDocumentTag removes a tag from a document owned by the requester
+func (s *service) RemoveDocumentTag(ctx context.Context, documentID, requesterID uuid.UUID, name string) error {
+	if _, err := s.getOwnedDocument(ctx, documentID, requesterID); err != nil {
+		return err
+	}
+
+	removed, err := s.repo.RemoveDocumentTag(ctx, documentID, strings.TrimSpace(name))
+	if err != nil {
+		return util.NewDatabaseError("remove document tag", err)
+	}
+	if !removed {
+		return util.NewNotFoundError("Tag", name)
+	}
+
+	return nil
+}
+
+// AttachTags fills in the tags of each document with a single query however many are listed
+func (s *service) AttachTags(ctx context.Context, docs ...*DocumentWithAttachment) error {
+	if len(docs) == 0 {
+		return nil
+	}
+
+	documentIDs := make([]uuid.UUID, len(docs))
+	for i, doc := range docs {
+		documentIDs[i] = doc.ID
+	}
+
+	tags, err := s.repo.GetDocumentTags(ctx, documentIDs)
+	if err != nil {
+		return util.NewDatabaseError("get document tags", err)
+	}
+
+	// Untagged documents get an empty list rather than null
+	for _, doc := range docs {
+		doc.Tags = tags[doc.ID]
+		if doc.Tags == nil {
+			doc.Tags = []string{}
+		}
+	}
+
+	return nil
+}
+
+// AddDocumentTags godoc
+// @Summary		Tag a document
+// @Description	Add free-form tags to a document you own. Tags belong to you and are created on first use; names are 1 to 50 characters without commas and match without regard to case. Returns all tags of the document
+// @Tags		Storage
+// @Accept		json
+// @Produce		json
+// @Security	BearerAuth
+// @Param		id		path		string							true	"Document ID"
+// @Param		body	body		domain.AddDocumentTagsRequest	true	"Tags to add"
+// @Success		200		{object}	util.Response{data=[]string}
+// @Failure		400		{object}	util.ErrorEnvelope
+// @Failure		401		{object}	util.ErrorEnvelope
+// @Failure		403		{object}	util.ErrorEnvelope
+// @Failure		404		{object}	util.ErrorEnvelope
+// @Router		/v1/storage/documents/{id}/tags [post]
+func (h *Handler) AddDocumentTags(c echo.Context) error {
+	// Get user ID from context
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+	requesterID, err := uuid.Parse(userID)
+	if err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid user ID", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	documentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid document ID", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	var req domain.AddDocumentTagsRequest
+	if err := c.Bind(&req); err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid request body", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	if err := util.ValidateStruct(&req); err != nil {
+		return util.HandleError(c, err)
+	}
+
+	tags, err := h.service.AddDocumentTags(c.Request().Context(), documentID, requesterID, req)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	return util.OKResponse(c, "Document tagged successfully", tags)
+}
+
+// RemoveDocumentTag godoc
+// @Summary		Remove a tag from a document
+// @Description	Remove a tag from a document you own; the name matches without regard to case
+// @Tags		Storage
+// @Produce		json
+// @Security	BearerAuth
+// @Param		id		path		string	true	"Document ID"
+// @Param		name	path		string	true	"Tag name"
+// @Success		200		{object}	util.Response
+// @Failure		400		{object}	util.ErrorEnvelope
+// @Failure		401		{object}	util.ErrorEnvelope
+// @Failure		403		{object}	util.ErrorEnvelope
+// @Failure		404		{object}	util.ErrorEnvelope
+// @Router		/v1/storage/documents/{id}/tags/{name} [delete]
+func (h *Handler) RemoveDocumentTag(c echo.Context) error {
+	// Get user ID from context
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+	requesterID, err := uuid.Parse(userID)
+	if err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid user ID", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	documentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid document ID", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	// Tag names may be any text, so the path segment arrives percent-encoded
+	name, err := url.PathUnescape(c.Param("name"))
+	if err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid tag name", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	if err := h.service.RemoveDocumentTag(c.Request().Context(), documentID, requesterID, name); err != nil {
+		return util.HandleError(c, err)
+	}
+
+	return util.OKResponse(c, "Tag removed successfully", nil)
+}
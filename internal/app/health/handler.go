@@ -0,0 +1,128 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	checkTimeout = 2 * time.Second // Timeout for a single dependency check
+)
+
+// Checker is implemented by dependencies that can report their connectivity
+type Checker interface {
+	Ping(ctx context.Context) error
+}
+
+// DependencyStatus represents the health of a single dependency
+type DependencyStatus struct {
+	Status string `json:"status" example:"up"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Handler handles health check requests
+type Handler struct {
+	checks map[string]Checker
+}
+
+// NewHandler creates a new health handler with the named dependency checks
+func NewHandler(checks map[string]Checker) *Handler {
+	return &Handler{
+		checks: checks,
+	}
+}
+
+// RegisterRoutes registers health routes
+func (h *Handler) RegisterRoutes(e *echo.Group) {
+	e.GET("/health", h.Live)
+	e.GET("/health/live", h.Live)
+	e.GET("/health/ready", h.Ready)
+}
+
+// Live godoc
+//
+//	@Summary		Liveness check
+//	@Description	Reports that the server process is up. Does not check dependencies.
+//	@Tags			Health
+//	@Produce		json
+//	@Success		200	{object}	map[string]interface{}
+//	@Router			/health/live [get]
+func (h *Handler) Live(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Server is running",
+		"data": map[string]string{
+			"status": "healthy",
+			"time":   time.Now().Format(time.RFC3339),
+		},
+	})
+}
+
+// Ready godoc
+//
+//	@Summary		Readiness check
+//	@Description	Reports whether PostgreSQL and MinIO are reachable and the storage buckets exist. Returns 503 when any dependency is down.
+//	@Tags			Health
+//	@Produce		json
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		503	{object}	map[string]interface{}
+//	@Router			/health/ready [get]
+func (h *Handler) Ready(c echo.Context) error {
+	dependencies, healthy := h.checkDependencies(c.Request().Context())
+
+	status := "healthy"
+	message := "Server is running"
+	code := http.StatusOK
+	if !healthy {
+		status = "unhealthy"
+		message = "One or more dependencies are unavailable"
+		code = http.StatusServiceUnavailable
+	}
+
+	return c.JSON(code, map[string]interface{}{
+		"success": healthy,
+		"message": message,
+		"data": map[string]interface{}{
+			"status":       status,
+			"time":         time.Now().Format(time.RFC3339),
+			"dependencies": dependencies,
+		},
+	})
+}
+
+// checkDependencies pings all dependencies in parallel
+func (h *Handler) checkDependencies(ctx context.Context) (map[string]DependencyStatus, bool) {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	results := make(map[string]DependencyStatus, len(h.checks))
+	healthy := true
+
+	for name, checker := range h.checks {
+		wg.Add(1)
+		go func(name string, checker Checker) {
+			defer wg.Done()
+
+			result := DependencyStatus{Status: "up"}
+			if err := checker.Ping(ctx); err != nil {
+				result = DependencyStatus{Status: "down", Error: err.Error()}
+			}
+
+			mu.Lock()
+			results[name] = result
+			if result.Status != "up" {
+				healthy = false
+			}
+			mu.Unlock()
+		}(name, checker)
+	}
+
+	wg.Wait()
+	return results, healthy
+}
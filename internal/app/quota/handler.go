@@ -0,0 +1,85 @@
+package quota
+
+import (
+	"e-document-backend/internal/util"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Handler handles HTTP requests for storage quotas
+type Handler struct {
+	service Service
+}
+
+// NewHandler creates a new quota handler
+func NewHandler(service Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// RegisterRoutes registers quota routes
+func (h *Handler) RegisterRoutes(e *echo.Group, authMiddleware echo.MiddlewareFunc) {
+	e.GET("/v1/storage/quota", h.GetQuota, authMiddleware)
+	e.PUT("/v1/users/:id/quota", h.SetUserQuota, authMiddleware)
+}
+
+// GetQuota godoc
+//
+//	@Summary		Get storage quota
+//	@Description	Get the authenticated user's effective storage quota, usage and whether it comes from the role default or a per-user override
+//	@Tags			Storage
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	util.Response{data=QuotaInfo}
+//	@Failure		401	{object}	util.ErrorEnvelope
+//	@Failure		404	{object}	util.ErrorEnvelope
+//	@Router			/v1/storage/quota [get]
+func (h *Handler) GetQuota(c echo.Context) error {
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	info, err := h.service.GetQuota(c.Request().Context(), userID)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	return util.OKResponse(c, "Storage quota retrieved successfully", info)
+}
+
+// SetUserQuota godoc
+//
+//	@Summary		Set a user's storage quota
+//	@Description	Set a per-user quota override in bytes (negative = unlimited) or clear it with null to fall back to the role default. Directors only.
+//	@Tags			Users
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		string			true	"User ID"
+//	@Param			body	body		SetQuotaRequest	true	"Quota override"
+//	@Success		200		{object}	util.Response{data=QuotaInfo}
+//	@Failure		400		{object}	util.ErrorEnvelope
+//	@Failure		401		{object}	util.ErrorEnvelope
+//	@Failure		403		{object}	util.ErrorEnvelope
+//	@Failure		404		{object}	util.ErrorEnvelope
+//	@Router			/v1/users/{id}/quota [put]
+func (h *Handler) SetUserQuota(c echo.Context) error {
+	requesterID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	var req SetQuotaRequest
+	if err := c.Bind(&req); err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid request body", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	info, err := h.service.SetUserQuota(c.Request().Context(), requesterID, c.Param("id"), req)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	return util.OKResponse(c, "Storage quota updated successfully", info)
+}
@@ -0,0 +1,80 @@
+package quota
+
+import (
+	"context"
+	"e-document-backend/internal/domain"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository defines the interface for quota-related database operations
+type Repository interface {
+	GetUserQuota(ctx context.Context, userID string) (domain.UserRole, *int64, error)
+	GetUsedBytes(ctx context.Context, userID string) (int64, error)
+	SetUserQuota(ctx context.Context, userID string, quotaBytes *int64) error
+}
+
+// repository implements the Repository interface for PostgreSQL
+type repository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRepository creates a new quota repository
+func NewRepository(pool *pgxpool.Pool) Repository {
+	return &repository{
+		pool: pool,
+	}
+}
+
+// GetUserQuota retrieves a user's role and quota override (nil when the role default applies)
+func (r *repository) GetUserQuota(ctx context.Context, userID string) (domain.UserRole, *int64, error) {
+	query := `SELECT role, storage_quota_bytes FROM users WHERE id = $1`
+
+	var role domain.UserRole
+	var override *int64
+	err := r.pool.QueryRow(ctx, query, userID).Scan(&role, &override)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return "", nil, fmt.Errorf("user not found")
+		}
+		return "", nil, fmt.Errorf("failed to get user quota: %w", err)
+	}
+
+	return role, override, nil
+}
+
+// GetUsedBytes sums the size of every stored attachment version of the user's documents
+func (r *repository) GetUsedBytes(ctx context.Context, userID string) (int64, error) {
+	query := `
+		SELECT COALESCE(SUM(da.file_size), 0)
+		FROM document_attachments da
+		INNER JOIN documents d ON d.id = da.document_id
+		WHERE d.registrant_id = $1
+	`
+
+	var used int64
+	err := r.pool.QueryRow(ctx, query, userID).Scan(&used)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get used storage: %w", err)
+	}
+
+	return used, nil
+}
+
+// SetUserQuota sets or clears (nil) a user's quota override
+func (r *repository) SetUserQuota(ctx context.Context, userID string, quotaBytes *int64) error {
+	query := `UPDATE users SET storage_quota_bytes = $2 WHERE id = $1`
+
+	result, err := r.pool.Exec(ctx, query, userID, quotaBytes)
+	if err != nil {
+		return fmt.Errorf("failed to set user quota: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,168 @@
+package quota
+
+import (
+	"context"
+	"e-document-backend/internal/app/user"
+	"e-document-backend/internal/domain"
+	"e-document-backend/internal/util"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	dbTimeout = 5 * time.Second // Database operation timeout
+	unlimited = int64(-1)       // any negative quota means no limit
+)
+
+// Quota sources reported by the quota endpoint
+const (
+	SourceRoleDefault  = "role_default"
+	SourceUserOverride = "user_override"
+)
+
+// Config holds the default storage quota of each role in bytes (negative = unlimited)
+type Config struct {
+	RoleDefaults map[domain.UserRole]int64
+}
+
+// LoadConfigFromEnv loads role default quotas from environment variables
+func LoadConfigFromEnv() Config {
+	return Config{
+		RoleDefaults: map[domain.UserRole]int64{
+			domain.RoleDirector:          getEnvAsInt64("QUOTA_DIRECTOR_BYTES", unlimited),
+			domain.RoleDepartmentManager: getEnvAsInt64("QUOTA_DEPARTMENT_MANAGER_BYTES", 20<<30), // 20 GiB
+			domain.RoleSectorManager:     getEnvAsInt64("QUOTA_SECTOR_MANAGER_BYTES", 10<<30),     // 10 GiB
+			domain.RoleEmployee:          getEnvAsInt64("QUOTA_EMPLOYEE_BYTES", 5<<30),            // 5 GiB
+		},
+	}
+}
+
+// getEnvAsInt64 parses an integer from env or returns a default value
+func getEnvAsInt64(key string, defaultValue int64) int64 {
+	if value := os.Getenv(key); value != "" {
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return n
+		}
+	}
+	return defaultValue
+}
+
+// QuotaInfo describes a user's effective storage quota and usage
+type QuotaInfo struct {
+	UserID         string `json:"user_id"`
+	Unlimited      bool   `json:"unlimited"`
+	QuotaBytes     int64  `json:"quota_bytes"` // -1 when unlimited
+	UsedBytes      int64  `json:"used_bytes"`
+	RemainingBytes int64  `json:"remaining_bytes"` // -1 when unlimited
+	Source         string `json:"source" example:"role_default"`
+}
+
+// SetQuotaRequest sets or clears a per-user quota override
+type SetQuotaRequest struct {
+	QuotaBytes *int64 `json:"quota_bytes"` // null restores the role default; negative means unlimited
+}
+
+// Service defines business logic for storage quotas
+type Service interface {
+	GetQuota(ctx context.Context, userID string) (*QuotaInfo, error)
+	SetUserQuota(ctx context.Context, requesterID, userID string, req SetQuotaRequest) (*QuotaInfo, error)
+	CheckUpload(ctx context.Context, ownerID string, size int64) error
+}
+
+// service implements Service
+type service struct {
+	repo     Repository
+	userRepo user.Repository
+	config   Config
+}
+
+// NewService creates a new quota service
+func NewService(repo Repository, userRepo user.Repository, config Config) Service {
+	return &service{
+		repo:     repo,
+		userRepo: userRepo,
+		config:   config,
+	}
+}
+
+// GetQuota resolves the effective quota of a user: the per-user override if set, otherwise the role default
+func (s *service) GetQuota(ctx context.Context, userID string) (*QuotaInfo, error) {
+	dbCtx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	role, override, err := s.repo.GetUserQuota(dbCtx, userID)
+	if err != nil {
+		return nil, util.NewNotFoundError("User", userID)
+	}
+
+	used, err := s.repo.GetUsedBytes(dbCtx, userID)
+	if err != nil {
+		return nil, util.NewDatabaseError("get used storage", err)
+	}
+
+	info := &QuotaInfo{
+		UserID:    userID,
+		UsedBytes: used,
+		Source:    SourceRoleDefault,
+	}
+
+	quota, ok := s.config.RoleDefaults[role]
+	if !ok {
+		quota = unlimited
+	}
+	if override != nil {
+		quota = *override
+		info.Source = SourceUserOverride
+	}
+
+	if quota < 0 {
+		info.Unlimited = true
+		info.QuotaBytes = unlimited
+		info.RemainingBytes = unlimited
+		return info, nil
+	}
+
+	info.QuotaBytes = quota
+	info.RemainingBytes = max(quota-used, 0)
+	return info, nil
+}
+
+// SetUserQuota sets or clears a user's quota override; only Directors may change quotas
+func (s *service) SetUserQuota(ctx context.Context, requesterID, userID string, req SetQuotaRequest) (*QuotaInfo, error) {
+	dbCtx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	requester, err := s.userRepo.FindByID(dbCtx, requesterID)
+	if err != nil {
+		return nil, util.NewUnauthorizedError("requesting user not found")
+	}
+
+	if requester.Role != domain.RoleDirector {
+		return nil, util.NewForbiddenError("only directors can change storage quotas")
+	}
+
+	if err := s.repo.SetUserQuota(dbCtx, userID, req.QuotaBytes); err != nil {
+		return nil, util.NewNotFoundError("User", userID)
+	}
+
+	return s.GetQuota(ctx, userID)
+}
+
+// CheckUpload returns an error if storing size more bytes would exceed the owner's quota
+func (s *service) CheckUpload(ctx context.Context, ownerID string, size int64) error {
+	info, err := s.GetQuota(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+
+	if info.Unlimited || size <= info.RemainingBytes {
+		return nil
+	}
+
+	return util.NewPayloadTooLargeError(
+		"Storage quota exceeded",
+		fmt.Sprintf("upload of %d bytes exceeds the remaining quota of %d bytes", size, info.RemainingBytes),
+	)
+}
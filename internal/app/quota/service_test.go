@@ -0,0 +1,197 @@
+package quota
+
+import (
+	"context"
+	"e-document-backend/internal/app/user/usertest"
+	"e-document-backend/internal/domain"
+	"e-document-backend/internal/util"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// fakeRepository keeps roles, overrides and usage in memory
+type fakeRepository struct {
+	roles     map[string]domain.UserRole
+	overrides map[string]*int64
+	used      map[string]int64
+}
+
+func newFakeRepository() *fakeRepository {
+	return &fakeRepository{
+		roles:     make(map[string]domain.UserRole),
+		overrides: make(map[string]*int64),
+		used:      make(map[string]int64),
+	}
+}
+
+// add stores a user with a role, an optional override and the bytes they already use
+func (r *fakeRepository) add(role domain.UserRole, override *int64, used int64) string {
+	id := uuid.NewString()
+	r.roles[id] = role
+	r.overrides[id] = override
+	r.used[id] = used
+	return id
+}
+
+func (r *fakeRepository) GetUserQuota(ctx context.Context, userID string) (domain.UserRole, *int64, error) {
+	role, ok := r.roles[userID]
+	if !ok {
+		return "", nil, fmt.Errorf("user not found")
+	}
+	return role, r.overrides[userID], nil
+}
+
+func (r *fakeRepository) GetUsedBytes(ctx context.Context, userID string) (int64, error) {
+	return r.used[userID], nil
+}
+
+func (r *fakeRepository) SetUserQuota(ctx context.Context, userID string, quotaBytes *int64) error {
+	if _, ok := r.roles[userID]; !ok {
+		return fmt.Errorf("user not found")
+	}
+	r.overrides[userID] = quotaBytes
+	return nil
+}
+
+func ptr(n int64) *int64 { return &n }
+
+// testConfig leaves SectorManager without a default to cover roles missing from the config
+func testConfig() Config {
+	return Config{RoleDefaults: map[domain.UserRole]int64{
+		domain.RoleDirector:          unlimited,
+		domain.RoleDepartmentManager: 2000,
+		domain.RoleEmployee:          1000,
+	}}
+}
+
+func TestGetQuota(t *testing.T) {
+	repo := newFakeRepository()
+	svc := NewService(repo, usertest.NewRepository(), testConfig())
+
+	tests := []struct {
+		name          string
+		userID        string
+		wantSource    string
+		wantUnlimited bool
+		wantQuota     int64
+		wantRemaining int64
+	}{
+		{name: "employee role default", userID: repo.add(domain.RoleEmployee, nil, 400),
+			wantSource: SourceRoleDefault, wantQuota: 1000, wantRemaining: 600},
+		{name: "manager role default", userID: repo.add(domain.RoleDepartmentManager, nil, 0),
+			wantSource: SourceRoleDefault, wantQuota: 2000, wantRemaining: 2000},
+		{name: "director unlimited by default", userID: repo.add(domain.RoleDirector, nil, 1<<40),
+			wantSource: SourceRoleDefault, wantUnlimited: true, wantQuota: unlimited, wantRemaining: unlimited},
+		{name: "role without a default is unlimited", userID: repo.add(domain.RoleSectorManager, nil, 5000),
+			wantSource: SourceRoleDefault, wantUnlimited: true, wantQuota: unlimited, wantRemaining: unlimited},
+		{name: "override raises the role default", userID: repo.add(domain.RoleEmployee, ptr(5000), 400),
+			wantSource: SourceUserOverride, wantQuota: 5000, wantRemaining: 4600},
+		{name: "override lowers the role default", userID: repo.add(domain.RoleDepartmentManager, ptr(100), 50),
+			wantSource: SourceUserOverride, wantQuota: 100, wantRemaining: 50},
+		{name: "override limits a director", userID: repo.add(domain.RoleDirector, ptr(300), 0),
+			wantSource: SourceUserOverride, wantQuota: 300, wantRemaining: 300},
+		{name: "negative override is unlimited", userID: repo.add(domain.RoleEmployee, ptr(-1), 5000),
+			wantSource: SourceUserOverride, wantUnlimited: true, wantQuota: unlimited, wantRemaining: unlimited},
+		{name: "zero override blocks uploads", userID: repo.add(domain.RoleEmployee, ptr(0), 0),
+			wantSource: SourceUserOverride, wantQuota: 0, wantRemaining: 0},
+		{name: "usage above the quota leaves nothing", userID: repo.add(domain.RoleEmployee, nil, 1500),
+			wantSource: SourceRoleDefault, wantQuota: 1000, wantRemaining: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := svc.GetQuota(context.Background(), tt.userID)
+			if err != nil {
+				t.Fatalf("GetQuota() error = %v", err)
+			}
+			if info.Source != tt.wantSource || info.Unlimited != tt.wantUnlimited ||
+				info.QuotaBytes != tt.wantQuota || info.RemainingBytes != tt.wantRemaining {
+				t.Fatalf("GetQuota() = %+v, want source %s, unlimited %v, quota %d, remaining %d",
+					info, tt.wantSource, tt.wantUnlimited, tt.wantQuota, tt.wantRemaining)
+			}
+		})
+	}
+
+	t.Run("unknown user", func(t *testing.T) {
+		_, err := svc.GetQuota(context.Background(), uuid.NewString())
+		assertStatus(t, err, 404)
+	})
+}
+
+func TestCheckUpload(t *testing.T) {
+	repo := newFakeRepository()
+	svc := NewService(repo, usertest.NewRepository(), testConfig())
+	employee := repo.add(domain.RoleEmployee, nil, 400)
+	overridden := repo.add(domain.RoleEmployee, ptr(5000), 400)
+	director := repo.add(domain.RoleDirector, nil, 1<<40)
+
+	tests := []struct {
+		name       string
+		userID     string
+		size       int64
+		wantStatus int
+	}{
+		{name: "fits the role default", userID: employee, size: 600},
+		{name: "exceeds the role default", userID: employee, size: 601, wantStatus: 413},
+		{name: "fits the override", userID: overridden, size: 4600},
+		{name: "exceeds the override", userID: overridden, size: 4601, wantStatus: 413},
+		{name: "unlimited", userID: director, size: 1 << 40},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := svc.CheckUpload(context.Background(), tt.userID, tt.size)
+			if tt.wantStatus != 0 {
+				assertStatus(t, err, tt.wantStatus)
+				return
+			}
+			if err != nil {
+				t.Fatalf("CheckUpload() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestSetUserQuota(t *testing.T) {
+	repo := newFakeRepository()
+	director := domain.User{ID: uuid.New(), Role: domain.RoleDirector}
+	manager := domain.User{ID: uuid.New(), Role: domain.RoleDepartmentManager}
+	svc := NewService(repo, usertest.NewRepository(director, manager), testConfig())
+	employee := repo.add(domain.RoleEmployee, nil, 0)
+	ctx := context.Background()
+
+	info, err := svc.SetUserQuota(ctx, director.ID.String(), employee, SetQuotaRequest{QuotaBytes: ptr(3000)})
+	if err != nil {
+		t.Fatalf("SetUserQuota() error = %v", err)
+	}
+	if info.Source != SourceUserOverride || info.QuotaBytes != 3000 {
+		t.Fatalf("after setting an override = %+v, want user_override of 3000", info)
+	}
+
+	// Clearing the override falls back to the role default
+	info, err = svc.SetUserQuota(ctx, director.ID.String(), employee, SetQuotaRequest{QuotaBytes: nil})
+	if err != nil {
+		t.Fatalf("SetUserQuota() error = %v", err)
+	}
+	if info.Source != SourceRoleDefault || info.QuotaBytes != 1000 {
+		t.Fatalf("after clearing the override = %+v, want role_default of 1000", info)
+	}
+
+	_, err = svc.SetUserQuota(ctx, manager.ID.String(), employee, SetQuotaRequest{QuotaBytes: ptr(1)})
+	assertStatus(t, err, 403)
+	_, err = svc.SetUserQuota(ctx, director.ID.String(), uuid.NewString(), SetQuotaRequest{QuotaBytes: ptr(1)})
+	assertStatus(t, err, 404)
+}
+
+func assertStatus(t *testing.T, err error, status int) {
+	t.Helper()
+	customErr, ok := err.(*util.CustomError)
+	if !ok {
+		t.Fatalf("error = %v, want a CustomError with status %d", err, status)
+	}
+	if customErr.StatusCode != status {
+		t.Fatalf("status = %d, want %d (%s)", customErr.StatusCode, status, customErr.Detail)
+	}
+}
@@ -0,0 +1,166 @@
+package sector
+
+import (
+	"e-document-backend/internal/domain"
+	"e-document-backend/internal/util"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Handler handles HTTP requests for sectors and their departments
+type Handler struct {
+	service Service
+}
+
+// NewHandler creates a new sector handler
+func NewHandler(service Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// RegisterRoutes registers sector routes
+func (h *Handler) RegisterRoutes(e *echo.Group, authMiddleware echo.MiddlewareFunc) {
+	sectors := e.Group("/v1/sectors", authMiddleware)
+	sectors.GET("", h.ListSectors)
+	sectors.POST("", h.CreateSector)
+	sectors.GET("/:id", h.GetSector)
+	sectors.GET("/:id/departments", h.ListDepartments)
+	sectors.POST("/:id/departments", h.CreateDepartment)
+}
+
+// ListSectors godoc
+//
+//	@Summary		List sectors
+//	@Description	Get every sector of the organization ordered by name
+//	@Tags			Sectors
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	util.Response{data=[]domain.Sector}
+//	@Failure		401	{object}	util.ErrorEnvelope
+//	@Failure		500	{object}	util.ErrorEnvelope
+//	@Router			/v1/sectors [get]
+func (h *Handler) ListSectors(c echo.Context) error {
+	sectors, err := h.service.ListSectors(c.Request().Context())
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	return util.OKResponse(c, "Sectors retrieved successfully", sectors)
+}
+
+// CreateSector godoc
+//
+//	@Summary		Create a sector
+//	@Description	Create a sector. Sector names are unique regardless of case. Directors only.
+//	@Tags			Sectors
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			body	body		domain.CreateSectorRequest	true	"Sector"
+//	@Success		201		{object}	util.Response{data=domain.Sector}
+//	@Failure		400		{object}	util.ErrorEnvelope
+//	@Failure		401		{object}	util.ErrorEnvelope
+//	@Failure		403		{object}	util.ErrorEnvelope
+//	@Router			/v1/sectors [post]
+func (h *Handler) CreateSector(c echo.Context) error {
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	var req domain.CreateSectorRequest
+	if err := c.Bind(&req); err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid request body", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	sector, err := h.service.CreateSector(c.Request().Context(), userID, req)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	return util.CreatedResponse(c, "Sector created successfully", sector)
+}
+
+// GetSector godoc
+//
+//	@Summary		Get a sector
+//	@Description	Get a sector by ID
+//	@Tags			Sectors
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"Sector ID"
+//	@Success		200	{object}	util.Response{data=domain.Sector}
+//	@Failure		400	{object}	util.ErrorEnvelope
+//	@Failure		401	{object}	util.ErrorEnvelope
+//	@Failure		404	{object}	util.ErrorEnvelope
+//	@Router			/v1/sectors/{id} [get]
+func (h *Handler) GetSector(c echo.Context) error {
+	sector, err := h.service.GetSector(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	return util.OKResponse(c, "Sector retrieved successfully", sector)
+}
+
+// ListDepartments godoc
+//
+//	@Summary		List the departments of a sector
+//	@Description	Get the departments belonging to a sector ordered by name
+//	@Tags			Sectors
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		string	true	"Sector ID"
+//	@Param			page	query		int		false	"Page number"		default(1)
+//	@Param			limit	query		int		false	"Items per page"	default(20)
+//	@Success		200		{object}	util.Response{data=util.PaginatedData}
+//	@Failure		400		{object}	util.ErrorEnvelope
+//	@Failure		401		{object}	util.ErrorEnvelope
+//	@Failure		404		{object}	util.ErrorEnvelope
+//	@Router			/v1/sectors/{id}/departments [get]
+func (h *Handler) ListDepartments(c echo.Context) error {
+	pagination := util.ParsePagination(c, util.PaginationDefaults{})
+
+	departments, total, err := h.service.ListDepartments(c.Request().Context(), c.Param("id"), pagination.Page, pagination.Limit)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	return util.OKResponseWithPagination(c, "Departments retrieved successfully", departments, pagination.Info(total))
+}
+
+// CreateDepartment godoc
+//
+//	@Summary		Add a department to a sector
+//	@Description	Register a department under a sector. The ID is the department_id assigned to users. Directors only.
+//	@Tags			Sectors
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		string							true	"Sector ID"
+//	@Param			body	body		domain.CreateDepartmentRequest	true	"Department"
+//	@Success		201		{object}	util.Response{data=domain.Department}
+//	@Failure		400		{object}	util.ErrorEnvelope
+//	@Failure		401		{object}	util.ErrorEnvelope
+//	@Failure		403		{object}	util.ErrorEnvelope
+//	@Failure		404		{object}	util.ErrorEnvelope
+//	@Router			/v1/sectors/{id}/departments [post]
+func (h *Handler) CreateDepartment(c echo.Context) error {
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	var req domain.CreateDepartmentRequest
+	if err := c.Bind(&req); err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid request body", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	department, err := h.service.CreateDepartment(c.Request().Context(), userID, c.Param("id"), req)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	return util.CreatedResponse(c, "Department created successfully", department)
+}
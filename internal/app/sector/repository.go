@@ -0,0 +1,193 @@
+package sector
+
+import (
+	"context"
+	"e-document-backend/internal/domain"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrNotFound is returned when a sector or department does not exist
+var ErrNotFound = errors.New("not found")
+
+// ErrDuplicate is returned when a sector name or department ID is already taken
+var ErrDuplicate = errors.New("already exists")
+
+// Repository defines the interface for sector and department database operations
+type Repository interface {
+	CreateSector(ctx context.Context, sector *domain.Sector) error
+	FindSectorByID(ctx context.Context, id uuid.UUID) (*domain.Sector, error)
+	FindAllSectors(ctx context.Context) ([]*domain.Sector, error)
+	CreateDepartment(ctx context.Context, department *domain.Department) error
+	FindDepartmentByID(ctx context.Context, id string) (*domain.Department, error)
+	FindDepartmentsBySector(ctx context.Context, sectorID uuid.UUID, skip, limit int) ([]*domain.Department, error)
+	CountDepartmentsBySector(ctx context.Context, sectorID uuid.UUID) (int, error)
+}
+
+// repository implements the Repository interface for PostgreSQL
+type repository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRepository creates a new sector repository
+func NewRepository(pool *pgxpool.Pool) Repository {
+	return &repository{
+		pool: pool,
+	}
+}
+
+// isUniqueViolation reports whether err is a PostgreSQL unique-violation
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// CreateSector inserts a sector and fills in its generated ID and timestamps
+func (r *repository) CreateSector(ctx context.Context, sector *domain.Sector) error {
+	query := `
+		INSERT INTO sectors (name)
+		VALUES ($1)
+		RETURNING id, created_at, updated_at
+	`
+
+	err := r.pool.QueryRow(ctx, query, sector.Name).Scan(&sector.ID, &sector.CreatedAt, &sector.UpdatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrDuplicate
+		}
+		return fmt.Errorf("failed to create sector: %w", err)
+	}
+
+	return nil
+}
+
+// FindSectorByID retrieves a sector by ID
+func (r *repository) FindSectorByID(ctx context.Context, id uuid.UUID) (*domain.Sector, error) {
+	query := `SELECT id, name, created_at, updated_at FROM sectors WHERE id = $1`
+
+	var sector domain.Sector
+	err := r.pool.QueryRow(ctx, query, id).Scan(&sector.ID, &sector.Name, &sector.CreatedAt, &sector.UpdatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to find sector: %w", err)
+	}
+
+	return &sector, nil
+}
+
+// FindAllSectors retrieves every sector ordered by name
+func (r *repository) FindAllSectors(ctx context.Context) ([]*domain.Sector, error) {
+	query := `SELECT id, name, created_at, updated_at FROM sectors ORDER BY name`
+
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find sectors: %w", err)
+	}
+	defer rows.Close()
+
+	sectors := []*domain.Sector{}
+	for rows.Next() {
+		var sector domain.Sector
+		if err := rows.Scan(&sector.ID, &sector.Name, &sector.CreatedAt, &sector.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan sector: %w", err)
+		}
+		sectors = append(sectors, &sector)
+	}
+
+	return sectors, rows.Err()
+}
+
+// CreateDepartment inserts a department under its sector and fills in its timestamps
+func (r *repository) CreateDepartment(ctx context.Context, department *domain.Department) error {
+	query := `
+		INSERT INTO departments (id, name, sector_id)
+		VALUES ($1, $2, $3)
+		RETURNING created_at, updated_at
+	`
+
+	err := r.pool.QueryRow(ctx, query, department.ID, department.Name, department.SectorID).
+		Scan(&department.CreatedAt, &department.UpdatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrDuplicate
+		}
+		return fmt.Errorf("failed to create department: %w", err)
+	}
+
+	return nil
+}
+
+// FindDepartmentByID retrieves a department by ID
+func (r *repository) FindDepartmentByID(ctx context.Context, id string) (*domain.Department, error) {
+	query := `SELECT id, name, sector_id, created_at, updated_at FROM departments WHERE id = $1`
+
+	var department domain.Department
+	err := r.pool.QueryRow(ctx, query, id).Scan(
+		&department.ID,
+		&department.Name,
+		&department.SectorID,
+		&department.CreatedAt,
+		&department.UpdatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to find department: %w", err)
+	}
+
+	return &department, nil
+}
+
+// FindDepartmentsBySector retrieves a page of a sector's departments ordered by name
+func (r *repository) FindDepartmentsBySector(ctx context.Context, sectorID uuid.UUID, skip, limit int) ([]*domain.Department, error) {
+	query := `
+		SELECT id, name, sector_id, created_at, updated_at
+		FROM departments
+		WHERE sector_id = $1
+		ORDER BY name, id
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.pool.Query(ctx, query, sectorID, limit, skip)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find departments: %w", err)
+	}
+	defer rows.Close()
+
+	departments := []*domain.Department{}
+	for rows.Next() {
+		var department domain.Department
+		if err := rows.Scan(
+			&department.ID,
+			&department.Name,
+			&department.SectorID,
+			&department.CreatedAt,
+			&department.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan department: %w", err)
+		}
+		departments = append(departments, &department)
+	}
+
+	return departments, rows.Err()
+}
+
+// CountDepartmentsBySector counts the departments of a sector
+func (r *repository) CountDepartmentsBySector(ctx context.Context, sectorID uuid.UUID) (int, error) {
+	query := `SELECT COUNT(*) FROM departments WHERE sector_id = $1`
+
+	var count int
+	if err := r.pool.QueryRow(ctx, query, sectorID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count departments: %w", err)
+	}
+
+	return count, nil
+}
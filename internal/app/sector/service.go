@@ -0,0 +1,212 @@
+package sector
+
+import (
+	"context"
+	"e-document-backend/internal/app/user"
+	"e-document-backend/internal/domain"
+	"e-document-backend/internal/util"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	dbTimeout = 5 * time.Second // Database operation timeout
+)
+
+// Service defines business logic for sectors and their departments
+type Service interface {
+	CreateSector(ctx context.Context, requesterID string, req domain.CreateSectorRequest) (*domain.Sector, error)
+	GetSector(ctx context.Context, id string) (*domain.Sector, error)
+	ListSectors(ctx context.Context) ([]*domain.Sector, error)
+	CreateDepartment(ctx context.Context, requesterID, sectorID string, req domain.CreateDepartmentRequest) (*domain.Department, error)
+	ListDepartments(ctx context.Context, sectorID string, page, limit int) ([]*domain.Department, int, error)
+	ValidateAssignment(ctx context.Context, sectorID, departmentID string) error
+}
+
+// service implements Service
+type service struct {
+	repo     Repository
+	userRepo user.Repository
+}
+
+// NewService creates a new sector service
+func NewService(repo Repository, userRepo user.Repository) Service {
+	return &service{
+		repo:     repo,
+		userRepo: userRepo,
+	}
+}
+
+// requireDirector checks that the requester is a Director; only Directors shape the org hierarchy
+func (s *service) requireDirector(ctx context.Context, requesterID string) error {
+	requester, err := s.userRepo.FindByID(ctx, requesterID)
+	if err != nil {
+		return util.NewUnauthorizedError("requesting user not found")
+	}
+	if requester.Role != domain.RoleDirector {
+		return util.NewForbiddenError("only directors can manage sectors and departments")
+	}
+	return nil
+}
+
+// CreateSector creates a sector; sector names are unique regardless of case
+func (s *service) CreateSector(ctx context.Context, requesterID string, req domain.CreateSectorRequest) (*domain.Sector, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if err := util.ValidateStructFields(&req); err != nil {
+		return nil, err
+	}
+
+	dbCtx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	if err := s.requireDirector(dbCtx, requesterID); err != nil {
+		return nil, err
+	}
+
+	sector := &domain.Sector{Name: req.Name}
+	if err := s.repo.CreateSector(dbCtx, sector); err != nil {
+		if errors.Is(err, ErrDuplicate) {
+			return nil, util.NewAlreadyExistsError("Sector", "name", req.Name)
+		}
+		return nil, util.NewDatabaseError("create sector", err)
+	}
+
+	return sector, nil
+}
+
+// GetSector retrieves a sector by ID
+func (s *service) GetSector(ctx context.Context, id string) (*domain.Sector, error) {
+	dbCtx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	return s.findSector(dbCtx, id)
+}
+
+// findSector parses a sector ID and loads the sector, mapping a missing one to NOT_FOUND
+func (s *service) findSector(ctx context.Context, id string) (*domain.Sector, error) {
+	sectorID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, util.NewInvalidInputError("sector_id", "must be a valid UUID")
+	}
+
+	sector, err := s.repo.FindSectorByID(ctx, sectorID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, util.NewNotFoundError("Sector", id)
+		}
+		return nil, util.NewDatabaseError("find sector", err)
+	}
+
+	return sector, nil
+}
+
+// ListSectors retrieves every sector
+func (s *service) ListSectors(ctx context.Context) ([]*domain.Sector, error) {
+	dbCtx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	sectors, err := s.repo.FindAllSectors(dbCtx)
+	if err != nil {
+		return nil, util.NewDatabaseError("list sectors", err)
+	}
+
+	return sectors, nil
+}
+
+// CreateDepartment registers a department under a sector
+func (s *service) CreateDepartment(ctx context.Context, requesterID, sectorID string, req domain.CreateDepartmentRequest) (*domain.Department, error) {
+	req.ID = strings.TrimSpace(req.ID)
+	req.Name = strings.TrimSpace(req.Name)
+	if err := util.ValidateStructFields(&req); err != nil {
+		return nil, err
+	}
+
+	dbCtx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	if err := s.requireDirector(dbCtx, requesterID); err != nil {
+		return nil, err
+	}
+
+	sector, err := s.findSector(dbCtx, sectorID)
+	if err != nil {
+		return nil, err
+	}
+
+	department := &domain.Department{
+		ID:       req.ID,
+		Name:     req.Name,
+		SectorID: sector.ID,
+	}
+	if err := s.repo.CreateDepartment(dbCtx, department); err != nil {
+		if errors.Is(err, ErrDuplicate) {
+			return nil, util.NewAlreadyExistsError("Department", "id", req.ID)
+		}
+		return nil, util.NewDatabaseError("create department", err)
+	}
+
+	return department, nil
+}
+
+// ListDepartments retrieves a page of the departments of a sector
+func (s *service) ListDepartments(ctx context.Context, sectorID string, page, limit int) ([]*domain.Department, int, error) {
+	dbCtx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	sector, err := s.findSector(dbCtx, sectorID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.repo.CountDepartmentsBySector(dbCtx, sector.ID)
+	if err != nil {
+		return nil, 0, util.NewDatabaseError("count departments", err)
+	}
+
+	departments, err := s.repo.FindDepartmentsBySector(dbCtx, sector.ID, (page-1)*limit, limit)
+	if err != nil {
+		return nil, 0, util.NewDatabaseError("list departments", err)
+	}
+
+	return departments, total, nil
+}
+
+// ValidateAssignment checks the sector and department assigned to a user fit the org hierarchy
+// A department must be registered, and when a sector is given it must exist and hold the department
+func (s *service) ValidateAssignment(ctx context.Context, sectorID, departmentID string) error {
+	var sector *domain.Sector
+	if sectorID != "" {
+		id, err := uuid.Parse(sectorID)
+		if err != nil {
+			return util.NewInvalidInputError("sector_id", "must be a valid UUID")
+		}
+
+		sector, err = s.repo.FindSectorByID(ctx, id)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				return util.NewInvalidInputError("sector_id", "sector does not exist")
+			}
+			return util.NewDatabaseError("find sector", err)
+		}
+	}
+
+	if departmentID == "" {
+		return nil
+	}
+
+	department, err := s.repo.FindDepartmentByID(ctx, departmentID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return util.NewInvalidInputError("department_id", "department does not exist")
+		}
+		return util.NewDatabaseError("find department", err)
+	}
+	if sector != nil && department.SectorID != sector.ID {
+		return util.NewInvalidInputError("department_id", "department belongs to another sector")
+	}
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package sharelink
+
+import (
+	"e-document-backend/internal/domain"
+	"e-document-backend/internal/util"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// Handler handles HTTP requests for share links
+type Handler struct {
+	service Service
+}
+
+// NewHandler creates a new share link handler
+func NewHandler(service Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// RegisterRoutes registers share link routes
+func (h *Handler) RegisterRoutes(e *echo.Group, authMiddleware echo.MiddlewareFunc) {
+	e.POST("/v1/storage/documents/:id/share-links", h.CreateLink, authMiddleware)
+	e.GET("/v1/share-links", h.ListActiveLinks, authMiddleware)
+
+	// Public: anyone holding the token can read the document
+	e.GET("/v1/public/share/:token", h.ResolveLink)
+}
+
+// CreateLink godoc
+// @Summary		Create a read-only share link
+// @Description	Create a public read-only link to a document you own. Defaults to a 7 day lifetime.
+// @Tags		Share Links
+// @Accept		json
+// @Produce		json
+// @Security	BearerAuth
+// @Param		id		path		string							true	"Document ID"
+// @Param		body	body		domain.CreateShareLinkRequest	false	"Link options"
+// @Success		201		{object}	util.Response{data=domain.ShareLinkResponse}
+// @Failure		400		{object}	util.ErrorEnvelope
+// @Failure		401		{object}	util.ErrorEnvelope
+// @Failure		403		{object}	util.ErrorEnvelope
+// @Failure		404		{object}	util.ErrorEnvelope
+// @Router		/v1/storage/documents/{id}/share-links [post]
+func (h *Handler) CreateLink(c echo.Context) error {
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	documentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid document ID", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	var req domain.CreateShareLinkRequest
+	if err := c.Bind(&req); err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid request body", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	if err := util.ValidateStruct(&req); err != nil {
+		return util.HandleError(c, err)
+	}
+
+	link, err := h.service.CreateLink(c.Request().Context(), documentID, userID, &req)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	return util.CreatedResponse(c, "Share link created successfully", link.ToResponse())
+}
+
+// ListActiveLinks godoc
+// @Summary		List active share links
+// @Description	List all share links that have not expired yet, soonest expiry first. Directors only. Tokens are shown by prefix only.
+// @Tags		Share Links
+// @Produce		json
+// @Security	BearerAuth
+// @Param		page	query		int	false	"Page number"		default(1)
+// @Param		limit	query		int	false	"Items per page"	default(20)
+// @Success		200		{object}	util.Response{data=util.PaginatedData}
+// @Failure		401		{object}	util.ErrorEnvelope
+// @Failure		403		{object}	util.ErrorEnvelope
+// @Failure		500		{object}	util.ErrorEnvelope
+// @Router		/v1/share-links [get]
+func (h *Handler) ListActiveLinks(c echo.Context) error {
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	pagination := util.ParsePagination(c, util.PaginationDefaults{})
+
+	links, total, err := h.service.ListActiveLinks(c.Request().Context(), userID, pagination.Page, pagination.Limit)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	responses := make([]domain.ShareLinkSummary, len(links))
+	for i, link := range links {
+		responses[i] = link.ToSummary()
+	}
+
+	return util.OKResponseWithPagination(c, "Share links retrieved successfully", responses, pagination.Info(total))
+}
+
+// ResolveLink godoc
+// @Summary		Open a share link
+// @Description	Redirects to a short-lived download URL for the shared document. No authentication required.
+// @Tags		Share Links
+// @Param		token	path		string	true	"Share link token"
+// @Success		302
+// @Failure		404		{object}	util.ErrorEnvelope
+// @Router		/v1/public/share/{token} [get]
+func (h *Handler) ResolveLink(c echo.Context) error {
+	url, err := h.service.ResolveLink(c.Request().Context(), c.Param("token"))
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	return c.Redirect(http.StatusFound, url)
+}
@@ -0,0 +1,55 @@
+package sharelink
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// StartJob runs the share link maintenance job in the background until ctx is cancelled
+// Each tick sends pre-expiry reminders first, then deletes links that have expired
+func StartJob(ctx context.Context, service Service, config Config) {
+	if config.CleanupInterval <= 0 {
+		log.Info().Msg("Share link cleanup job disabled")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(config.CleanupInterval)
+		defer ticker.Stop()
+
+		log.Info().
+			Dur("interval", config.CleanupInterval).
+			Dur("reminder_lead", config.ReminderLead).
+			Msg("Starting share link cleanup job")
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				runOnce(ctx, service)
+			}
+		}
+	}()
+}
+
+// runOnce performs a single reminder and cleanup pass
+func runOnce(ctx context.Context, service Service) {
+	reminded, err := service.SendExpiryReminders(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Share link reminders failed")
+	}
+
+	removed, err := service.CleanupExpired(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Share link cleanup failed")
+		return
+	}
+
+	log.Info().
+		Int("reminded", reminded).
+		Int64("removed", removed).
+		Msg("Share link cleanup completed")
+}
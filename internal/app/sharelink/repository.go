@@ -0,0 +1,231 @@
+package sharelink
+
+import (
+	"context"
+	"e-document-backend/internal/domain"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository defines the interface for share link database operations
+type Repository interface {
+	Create(ctx context.Context, link *domain.ShareLink) error
+	FindByToken(ctx context.Context, token string) (*domain.ShareLink, error)
+	ListActive(ctx context.Context, limit, offset int) ([]*domain.ShareLink, int, error)
+	FindExpiringWithoutReminder(ctx context.Context, before time.Time) ([]*domain.ShareLink, error)
+	MarkReminderSent(ctx context.Context, linkID uuid.UUID, sentAt time.Time) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+
+	// Document lookups
+	GetSharedDocument(ctx context.Context, documentID uuid.UUID) (*SharedDocument, error)
+}
+
+// SharedDocument holds what is needed to authorize and serve a shared document
+type SharedDocument struct {
+	DocumentID   uuid.UUID
+	Title        string
+	RegistrantID *uuid.UUID
+	FilePath     *string // current attachment, nil if the document has no file
+	FileName     *string
+}
+
+// repository implements the Repository interface for PostgreSQL
+type repository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRepository creates a new share link repository
+func NewRepository(pool *pgxpool.Pool) Repository {
+	return &repository{
+		pool: pool,
+	}
+}
+
+// Create inserts a new share link
+func (r *repository) Create(ctx context.Context, link *domain.ShareLink) error {
+	query := `
+		INSERT INTO share_links (token, document_id, created_by, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+
+	err := r.pool.QueryRow(ctx, query,
+		link.Token,
+		link.DocumentID,
+		link.CreatedBy,
+		link.ExpiresAt,
+	).Scan(&link.ID, &link.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create share link: %w", err)
+	}
+
+	return nil
+}
+
+// FindByToken retrieves a share link by its token
+func (r *repository) FindByToken(ctx context.Context, token string) (*domain.ShareLink, error) {
+	query := `
+		SELECT id, token, document_id, created_by, expires_at, reminder_sent_at, created_at
+		FROM share_links
+		WHERE token = $1
+	`
+
+	var link domain.ShareLink
+	err := r.pool.QueryRow(ctx, query, token).Scan(
+		&link.ID,
+		&link.Token,
+		&link.DocumentID,
+		&link.CreatedBy,
+		&link.ExpiresAt,
+		&link.ReminderSentAt,
+		&link.CreatedAt,
+	)
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("share link not found")
+		}
+		return nil, fmt.Errorf("failed to get share link: %w", err)
+	}
+
+	return &link, nil
+}
+
+// ListActive retrieves share links that have not expired yet, soonest expiry first
+func (r *repository) ListActive(ctx context.Context, limit, offset int) ([]*domain.ShareLink, int, error) {
+	countQuery := `
+		SELECT COUNT(*)
+		FROM share_links
+		WHERE expires_at > NOW()
+	`
+
+	var total int
+	err := r.pool.QueryRow(ctx, countQuery).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count share links: %w", err)
+	}
+
+	query := `
+		SELECT id, token, document_id, created_by, expires_at, reminder_sent_at, created_at
+		FROM share_links
+		WHERE expires_at > NOW()
+		ORDER BY expires_at ASC
+		LIMIT $1 OFFSET $2
+	`
+
+	rows, err := r.pool.Query(ctx, query, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get share links: %w", err)
+	}
+	defer rows.Close()
+
+	links, err := scanShareLinks(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return links, total, nil
+}
+
+// FindExpiringWithoutReminder retrieves active links expiring before the given time
+// whose owner has not been reminded yet
+func (r *repository) FindExpiringWithoutReminder(ctx context.Context, before time.Time) ([]*domain.ShareLink, error) {
+	query := `
+		SELECT id, token, document_id, created_by, expires_at, reminder_sent_at, created_at
+		FROM share_links
+		WHERE expires_at > NOW() AND expires_at <= $1 AND reminder_sent_at IS NULL
+		ORDER BY expires_at ASC
+	`
+
+	rows, err := r.pool.Query(ctx, query, before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expiring share links: %w", err)
+	}
+	defer rows.Close()
+
+	return scanShareLinks(rows)
+}
+
+// MarkReminderSent records that the owner was notified about the upcoming expiry
+func (r *repository) MarkReminderSent(ctx context.Context, linkID uuid.UUID, sentAt time.Time) error {
+	query := `UPDATE share_links SET reminder_sent_at = $2 WHERE id = $1`
+
+	_, err := r.pool.Exec(ctx, query, linkID, sentAt)
+	if err != nil {
+		return fmt.Errorf("failed to mark share link reminder: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteExpired removes all links that expired before now and returns how many were removed
+func (r *repository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	query := `DELETE FROM share_links WHERE expires_at <= $1`
+
+	result, err := r.pool.Exec(ctx, query, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired share links: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// GetSharedDocument retrieves a document with its current attachment
+func (r *repository) GetSharedDocument(ctx context.Context, documentID uuid.UUID) (*SharedDocument, error) {
+	query := `
+		SELECT d.id, d.title, d.registrant_id, da.file_path, da.file_name
+		FROM documents d
+		LEFT JOIN document_attachments da ON d.id = da.document_id AND da.is_current = true
+		WHERE d.id = $1
+	`
+
+	var doc SharedDocument
+	err := r.pool.QueryRow(ctx, query, documentID).Scan(
+		&doc.DocumentID,
+		&doc.Title,
+		&doc.RegistrantID,
+		&doc.FilePath,
+		&doc.FileName,
+	)
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("document not found")
+		}
+		return nil, fmt.Errorf("failed to get document: %w", err)
+	}
+
+	return &doc, nil
+}
+
+// scanShareLinks scans share link rows
+func scanShareLinks(rows pgx.Rows) ([]*domain.ShareLink, error) {
+	var links []*domain.ShareLink
+	for rows.Next() {
+		var link domain.ShareLink
+		err := rows.Scan(
+			&link.ID,
+			&link.Token,
+			&link.DocumentID,
+			&link.CreatedBy,
+			&link.ExpiresAt,
+			&link.ReminderSentAt,
+			&link.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan share link: %w", err)
+		}
+		links = append(links, &link)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating share links: %w", err)
+	}
+
+	return links, nil
+}
@@ -0,0 +1,261 @@
+package sharelink
+
+import (
+	"context"
+	"crypto/rand"
+	"e-document-backend/internal/app/user"
+	"e-document-backend/internal/domain"
+	"e-document-backend/internal/util"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	dbTimeout           = 5 * time.Second    // Database operation timeout
+	defaultLinkLifetime = 7 * 24 * time.Hour // Used when the request does not set expires_in
+	downloadURLExpiry   = 15 * time.Minute   // Lifetime of the presigned URL a share link redirects to
+	tokenBytes          = 32                 // 64 hex characters
+)
+
+// Config holds share link job configuration
+type Config struct {
+	CleanupInterval time.Duration // how often expired links are purged; 0 disables the job
+	ReminderLead    time.Duration // how long before expiry the owner is reminded; 0 disables reminders
+}
+
+// LoadConfigFromEnv loads share link configuration from environment variables
+func LoadConfigFromEnv() Config {
+	return Config{
+		CleanupInterval: getEnvAsDuration("SHARE_LINK_CLEANUP_INTERVAL", time.Hour),
+		ReminderLead:    getEnvAsDuration("SHARE_LINK_REMINDER_LEAD", 24*time.Hour),
+	}
+}
+
+// getEnvAsDuration parses a duration (e.g. "30m", "6h") from env or returns a default value
+func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
+
+// Notifier delivers expiry reminders to link owners
+type Notifier interface {
+	NotifyShareLinkExpiring(ctx context.Context, owner *domain.User, link *domain.ShareLink) error
+}
+
+// logNotifier writes reminders to the log; used until a mailer is available
+type logNotifier struct{}
+
+// NewLogNotifier creates a notifier that only logs reminders
+func NewLogNotifier() Notifier {
+	return logNotifier{}
+}
+
+// NotifyShareLinkExpiring logs the reminder
+func (logNotifier) NotifyShareLinkExpiring(ctx context.Context, owner *domain.User, link *domain.ShareLink) error {
+	log.Info().
+		Str("user_id", owner.ID.String()).
+		Str("email", owner.Email).
+		Str("share_link_id", link.ID.String()).
+		Time("expires_at", link.ExpiresAt).
+		Msg("Share link is about to expire")
+	return nil
+}
+
+// storageClient defines the minimal interface we need from MinIO client
+type storageClient interface {
+	GetPresignedURL(ctx context.Context, objectPath string, expiry time.Duration) (string, error)
+}
+
+// Service defines business logic for share links
+type Service interface {
+	CreateLink(ctx context.Context, documentID uuid.UUID, requesterID string, req *domain.CreateShareLinkRequest) (*domain.ShareLink, error)
+	ResolveLink(ctx context.Context, token string) (string, error)
+	ListActiveLinks(ctx context.Context, requesterID string, page, limit int) ([]*domain.ShareLink, int, error)
+	CleanupExpired(ctx context.Context) (int64, error)
+	SendExpiryReminders(ctx context.Context) (int, error)
+}
+
+// service implements Service
+type service struct {
+	repo     Repository
+	userRepo user.Repository
+	storage  storageClient
+	notifier Notifier
+	config   Config
+}
+
+// NewService creates a new share link service
+func NewService(repo Repository, userRepo user.Repository, storage storageClient, notifier Notifier, config Config) Service {
+	return &service{
+		repo:     repo,
+		userRepo: userRepo,
+		storage:  storage,
+		notifier: notifier,
+		config:   config,
+	}
+}
+
+// CreateLink creates a read-only link to a document owned by the requester
+func (s *service) CreateLink(ctx context.Context, documentID uuid.UUID, requesterID string, req *domain.CreateShareLinkRequest) (*domain.ShareLink, error) {
+	dbCtx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	doc, err := s.repo.GetSharedDocument(dbCtx, documentID)
+	if err != nil {
+		return nil, util.NewNotFoundError("Document", documentID.String())
+	}
+
+	if doc.RegistrantID == nil || doc.RegistrantID.String() != requesterID {
+		return nil, util.NewForbiddenError("you can only share your own documents")
+	}
+
+	if doc.FilePath == nil {
+		return nil, util.NewInvalidInputError("document", "document has no file to share")
+	}
+
+	createdBy, err := uuid.Parse(requesterID)
+	if err != nil {
+		return nil, util.NewUnauthorizedError("invalid user ID")
+	}
+
+	lifetime := defaultLinkLifetime
+	if req.ExpiresIn > 0 {
+		lifetime = time.Duration(req.ExpiresIn) * time.Second
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		return nil, util.NewInternalError("failed to generate share link token")
+	}
+
+	link := &domain.ShareLink{
+		Token:      token,
+		DocumentID: documentID,
+		CreatedBy:  createdBy,
+		ExpiresAt:  time.Now().Add(lifetime),
+	}
+
+	if err := s.repo.Create(dbCtx, link); err != nil {
+		return nil, util.NewDatabaseError("create share link", err)
+	}
+
+	return link, nil
+}
+
+// ResolveLink returns a short-lived download URL for the document behind a share link
+func (s *service) ResolveLink(ctx context.Context, token string) (string, error) {
+	dbCtx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	link, err := s.repo.FindByToken(dbCtx, token)
+	if err != nil || link.IsExpired() {
+		// Expired links look the same as unknown ones until the cleanup job removes them
+		return "", util.NewNotFoundError("Share link", token)
+	}
+
+	doc, err := s.repo.GetSharedDocument(dbCtx, link.DocumentID)
+	if err != nil || doc.FilePath == nil {
+		return "", util.NewNotFoundError("Document", link.DocumentID.String())
+	}
+
+	url, err := s.storage.GetPresignedURL(ctx, *doc.FilePath, downloadURLExpiry)
+	if err != nil {
+		return "", util.NewInternalError("failed to generate download URL")
+	}
+
+	return url, nil
+}
+
+// ListActiveLinks returns all links that have not expired; only Directors may list them
+func (s *service) ListActiveLinks(ctx context.Context, requesterID string, page, limit int) ([]*domain.ShareLink, int, error) {
+	dbCtx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	requester, err := s.userRepo.FindByID(dbCtx, requesterID)
+	if err != nil {
+		return nil, 0, util.NewUnauthorizedError("requesting user not found")
+	}
+
+	if requester.Role != domain.RoleDirector {
+		return nil, 0, util.NewForbiddenError("only directors can view active share links")
+	}
+
+	offset := (page - 1) * limit
+	links, total, err := s.repo.ListActive(dbCtx, limit, offset)
+	if err != nil {
+		return nil, 0, util.NewDatabaseError("fetch share links", err)
+	}
+
+	return links, total, nil
+}
+
+// CleanupExpired deletes all expired share links
+func (s *service) CleanupExpired(ctx context.Context) (int64, error) {
+	dbCtx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	return s.repo.DeleteExpired(dbCtx, time.Now())
+}
+
+// SendExpiryReminders notifies owners of links expiring within the reminder lead time
+// Each link is reminded at most once
+func (s *service) SendExpiryReminders(ctx context.Context) (int, error) {
+	if s.config.ReminderLead <= 0 {
+		return 0, nil
+	}
+
+	dbCtx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	links, err := s.repo.FindExpiringWithoutReminder(dbCtx, time.Now().Add(s.config.ReminderLead))
+	if err != nil {
+		return 0, err
+	}
+
+	owners := make(map[string]*domain.User)
+	sent := 0
+
+	for _, link := range links {
+		ownerID := link.CreatedBy.String()
+
+		owner, ok := owners[ownerID]
+		if !ok {
+			owner, err = s.userRepo.FindByID(dbCtx, ownerID)
+			if err != nil {
+				log.Warn().Err(err).Str("user_id", ownerID).Msg("Share link owner not found, skipping reminder")
+				continue
+			}
+			owners[ownerID] = owner
+		}
+
+		if err := s.notifier.NotifyShareLinkExpiring(ctx, owner, link); err != nil {
+			log.Error().Err(err).Str("share_link_id", link.ID.String()).Msg("Failed to send share link reminder")
+			continue
+		}
+
+		if err := s.repo.MarkReminderSent(dbCtx, link.ID, time.Now()); err != nil {
+			return sent, err
+		}
+		sent++
+	}
+
+	return sent, nil
+}
+
+// generateToken creates a random, URL-safe share link token
+func generateToken() (string, error) {
+	b := make([]byte, tokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to read random bytes: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,284 @@
+package sharelink
+
+import (
+	"context"
+	"e-document-backend/internal/app/user/usertest"
+	"e-document-backend/internal/domain"
+	"e-document-backend/internal/util"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fakeRepository keeps share links in memory
+type fakeRepository struct {
+	Repository
+	mu    sync.Mutex
+	links map[uuid.UUID]*domain.ShareLink
+}
+
+func newFakeRepository(links ...*domain.ShareLink) *fakeRepository {
+	r := &fakeRepository{links: make(map[uuid.UUID]*domain.ShareLink)}
+	for _, link := range links {
+		r.links[link.ID] = link
+	}
+	return r
+}
+
+func (r *fakeRepository) FindExpiringWithoutReminder(ctx context.Context, before time.Time) ([]*domain.ShareLink, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var links []*domain.ShareLink
+	now := time.Now()
+	for _, link := range r.links {
+		if link.ReminderSentAt == nil && link.ExpiresAt.After(now) && !link.ExpiresAt.After(before) {
+			copied := *link
+			links = append(links, &copied)
+		}
+	}
+	return links, nil
+}
+
+func (r *fakeRepository) MarkReminderSent(ctx context.Context, linkID uuid.UUID, sentAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	link, ok := r.links[linkID]
+	if !ok {
+		return fmt.Errorf("share link not found")
+	}
+	link.ReminderSentAt = &sentAt
+	return nil
+}
+
+func (r *fakeRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var removed int64
+	for id, link := range r.links {
+		if !link.ExpiresAt.After(now) {
+			delete(r.links, id)
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+func (r *fakeRepository) ListActive(ctx context.Context, limit, offset int) ([]*domain.ShareLink, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var links []*domain.ShareLink
+	for _, link := range r.links {
+		links = append(links, link)
+	}
+	return links, len(links), nil
+}
+
+func (r *fakeRepository) has(id uuid.UUID) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.links[id]
+	return ok
+}
+
+// recordingNotifier remembers which links owners were reminded of
+type recordingNotifier struct {
+	mu       sync.Mutex
+	reminded []uuid.UUID
+	fail     bool
+}
+
+func (n *recordingNotifier) NotifyShareLinkExpiring(ctx context.Context, owner *domain.User, link *domain.ShareLink) error {
+	if n.fail {
+		return fmt.Errorf("mailer unavailable")
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.reminded = append(n.reminded, link.ID)
+	return nil
+}
+
+func newLink(owner uuid.UUID, expiresIn time.Duration) *domain.ShareLink {
+	return &domain.ShareLink{
+		ID:         uuid.New(),
+		Token:      strings.Repeat("ab", tokenBytes),
+		DocumentID: uuid.New(),
+		CreatedBy:  owner,
+		ExpiresAt:  time.Now().Add(expiresIn),
+		CreatedAt:  time.Now(),
+	}
+}
+
+func TestCleanupExpired(t *testing.T) {
+	owner := uuid.New()
+	expired := newLink(owner, -time.Minute)
+	longExpired := newLink(owner, -30*24*time.Hour)
+	active := newLink(owner, time.Hour)
+	repo := newFakeRepository(expired, longExpired, active)
+	svc := NewService(repo, usertest.NewRepository(), nil, &recordingNotifier{}, Config{})
+
+	removed, err := svc.CleanupExpired(context.Background())
+	if err != nil {
+		t.Fatalf("CleanupExpired() error = %v", err)
+	}
+	if removed != 2 {
+		t.Fatalf("CleanupExpired() removed %d links, want 2", removed)
+	}
+
+	tests := []struct {
+		name string
+		link *domain.ShareLink
+		kept bool
+	}{
+		{name: "expired link", link: expired, kept: false},
+		{name: "long expired link", link: longExpired, kept: false},
+		{name: "active link", link: active, kept: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if repo.has(tt.link.ID) != tt.kept {
+				t.Fatalf("link kept = %v, want %v", !tt.kept, tt.kept)
+			}
+		})
+	}
+}
+
+func TestSendExpiryReminders(t *testing.T) {
+	ownerUser := domain.User{ID: uuid.New(), Email: "owner@example.com"}
+	sentAt := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name     string
+		lead     time.Duration
+		link     func() *domain.ShareLink
+		fail     bool
+		wantSent bool
+	}{
+		{name: "expiring within the lead time", lead: 24 * time.Hour, link: func() *domain.ShareLink { return newLink(ownerUser.ID, time.Hour) }, wantSent: true},
+		{name: "expiring after the lead time", lead: 24 * time.Hour, link: func() *domain.ShareLink { return newLink(ownerUser.ID, 48*time.Hour) }},
+		{name: "already expired", lead: 24 * time.Hour, link: func() *domain.ShareLink { return newLink(ownerUser.ID, -time.Minute) }},
+		{name: "already reminded", lead: 24 * time.Hour, link: func() *domain.ShareLink {
+			link := newLink(ownerUser.ID, time.Hour)
+			link.ReminderSentAt = &sentAt
+			return link
+		}},
+		{name: "reminders disabled", lead: 0, link: func() *domain.ShareLink { return newLink(ownerUser.ID, time.Hour) }},
+		{name: "owner no longer exists", lead: 24 * time.Hour, link: func() *domain.ShareLink { return newLink(uuid.New(), time.Hour) }},
+		{name: "notifier fails", lead: 24 * time.Hour, link: func() *domain.ShareLink { return newLink(ownerUser.ID, time.Hour) }, fail: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			link := tt.link()
+			repo := newFakeRepository(link)
+			notifier := &recordingNotifier{fail: tt.fail}
+			svc := NewService(repo, usertest.NewRepository(ownerUser), nil, notifier, Config{ReminderLead: tt.lead})
+
+			sent, err := svc.SendExpiryReminders(context.Background())
+			if err != nil {
+				t.Fatalf("SendExpiryReminders() error = %v", err)
+			}
+
+			wantCount := 0
+			if tt.wantSent {
+				wantCount = 1
+			}
+			if sent != wantCount || len(notifier.reminded) != wantCount {
+				t.Fatalf("SendExpiryReminders() = %d sent, %d notified; want %d", sent, len(notifier.reminded), wantCount)
+			}
+
+			// A sent reminder is recorded so the next pass skips the link; a failed one is retried
+			if tt.wantSent {
+				again, err := svc.SendExpiryReminders(context.Background())
+				if err != nil || again != 0 {
+					t.Fatalf("second pass = %d sent, error %v; want 0", again, err)
+				}
+			}
+			if tt.fail && link.ReminderSentAt != nil {
+				t.Fatal("failed reminder was marked as sent")
+			}
+		})
+	}
+}
+
+func TestListActiveLinks(t *testing.T) {
+	director := domain.User{ID: uuid.New(), Role: domain.RoleDirector}
+	employee := domain.User{ID: uuid.New(), Role: domain.RoleEmployee}
+	link := newLink(employee.ID, time.Hour)
+	svc := NewService(newFakeRepository(link), usertest.NewRepository(director, employee), nil, &recordingNotifier{}, Config{})
+
+	tests := []struct {
+		name       string
+		requester  uuid.UUID
+		wantStatus int
+	}{
+		{name: "director", requester: director.ID},
+		{name: "employee is refused", requester: employee.ID, wantStatus: 403},
+		{name: "unknown requester", requester: uuid.New(), wantStatus: 401},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			links, total, err := svc.ListActiveLinks(context.Background(), tt.requester.String(), 1, 20)
+			if tt.wantStatus != 0 {
+				assertStatus(t, err, tt.wantStatus)
+				return
+			}
+			if err != nil {
+				t.Fatalf("ListActiveLinks() error = %v", err)
+			}
+			if total != 1 || len(links) != 1 {
+				t.Fatalf("ListActiveLinks() = %d links, total %d; want 1", len(links), total)
+			}
+		})
+	}
+}
+
+func TestShareLinkSummaryHidesToken(t *testing.T) {
+	link := newLink(uuid.New(), time.Hour)
+	summary := link.ToSummary()
+	if summary.TokenPrefix != link.Token[:8] {
+		t.Fatalf("token prefix = %q, want %q", summary.TokenPrefix, link.Token[:8])
+	}
+	if summary.ID != link.ID || summary.DocumentID != link.DocumentID {
+		t.Fatalf("summary = %+v, want the link's IDs", summary)
+	}
+}
+
+func TestCreateShareLinkRequestExpiry(t *testing.T) {
+	tests := []struct {
+		name      string
+		expiresIn int64
+		wantErr   bool
+	}{
+		{name: "default", expiresIn: 0},
+		{name: "minimum", expiresIn: 60},
+		{name: "thirty days", expiresIn: 30 * 24 * 60 * 60},
+		{name: "below minimum", expiresIn: 59, wantErr: true},
+		{name: "above maximum", expiresIn: 30*24*60*60 + 1, wantErr: true},
+		{name: "practically forever", expiresIn: 1 << 40, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := util.ValidateStruct(&domain.CreateShareLinkRequest{ExpiresIn: tt.expiresIn})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateStruct() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func assertStatus(t *testing.T, err error, status int) {
+	t.Helper()
+	customErr, ok := err.(*util.CustomError)
+	if !ok {
+		t.Fatalf("error = %v, want a CustomError with status %d", err, status)
+	}
+	if customErr.StatusCode != status {
+		t.Fatalf("status = %d, want %d (%s)", customErr.StatusCode, status, customErr.Detail)
+	}
+}
@@ -0,0 +1,97 @@
+package upload
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/rs/zerolog/log"
+)
+
+// tusInfoSuffix is appended by the tusd S3 store to the object key of an upload's metadata file
+const tusInfoSuffix = ".info"
+
+// tusPartSuffix is used by the tusd S3 store for incomplete parts of an upload
+const tusPartSuffix = ".part"
+
+// removeUploadObject deletes an uploaded object and its tusd metadata file from MinIO
+// Used as a compensating action when the database side of an upload fails
+func (h *Handler) removeUploadObject(ctx context.Context, objectKey string) {
+	for _, key := range []string{objectKey, objectKey + tusInfoSuffix} {
+		err := h.minioClient.RemoveObject(ctx, h.bucket, key, minio.RemoveObjectOptions{})
+		if err != nil {
+			log.Error().Err(err).
+				Str("object_key", key).
+				Msg("Failed to remove orphaned upload object")
+			continue
+		}
+		log.Info().
+			Str("object_key", key).
+			Msg("Removed orphaned upload object")
+	}
+}
+
+// runOrphanCleanup periodically removes objects that no attachment references
+func (h *Handler) runOrphanCleanup(ctx context.Context) {
+	ticker := time.NewTicker(h.tusConfig.OrphanCleanupInterval)
+	defer ticker.Stop()
+
+	log.Info().
+		Dur("interval", h.tusConfig.OrphanCleanupInterval).
+		Dur("grace_period", h.tusConfig.OrphanGracePeriod).
+		Msg("Starting orphaned object cleanup job")
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info().Msg("Stopped orphaned object cleanup job")
+			return
+		case <-ticker.C:
+		}
+
+		removed, err := h.cleanupOrphanedObjects(ctx)
+		if err != nil {
+			log.Error().Err(err).Msg("Orphaned object cleanup failed")
+			continue
+		}
+		log.Info().Int("removed", removed).Msg("Orphaned object cleanup completed")
+	}
+}
+
+// cleanupOrphanedObjects lists upload objects in the bucket and removes the ones
+// older than the grace period that have no attachment row
+func (h *Handler) cleanupOrphanedObjects(ctx context.Context) (int, error) {
+	cutoff := time.Now().Add(-h.tusConfig.OrphanGracePeriod)
+	removed := 0
+
+	for object := range h.minioClient.ListObjects(ctx, h.bucket, minio.ListObjectsOptions{Recursive: true}) {
+		if object.Err != nil {
+			return removed, object.Err
+		}
+
+		// Only tusd upload objects live at the bucket root; profile pictures etc. use a folder prefix
+		if strings.Contains(object.Key, "/") ||
+			strings.HasSuffix(object.Key, tusInfoSuffix) ||
+			strings.HasSuffix(object.Key, tusPartSuffix) {
+			continue
+		}
+
+		if object.LastModified.After(cutoff) {
+			continue
+		}
+
+		referenced, err := h.service.IsFilePathReferenced(ctx, object.Key)
+		if err != nil {
+			return removed, err
+		}
+		if referenced {
+			continue
+		}
+
+		h.removeUploadObject(ctx, object.Key)
+		removed++
+	}
+
+	return removed, nil
+}
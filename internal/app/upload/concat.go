@@ -0,0 +1,91 @@
+package upload
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+	tusd "github.com/tus/tusd/v2/pkg/handler"
+)
+
+// fileMetadataKeys are copied from the first partial upload when a final upload was created without them
+var fileMetadataKeys = []string{"relative_path", "filename", "file_type", "parent_folder_id", duplicateStrategyKey}
+
+// getPartialUploadInfos loads the tusd info of the partial uploads that make up a final upload
+func (h *Handler) getPartialUploadInfos(ctx context.Context, uploadIDs []string) ([]tusd.FileInfo, error) {
+	infos := make([]tusd.FileInfo, 0, len(uploadIDs))
+	for _, id := range uploadIDs {
+		upload, err := h.composer.Core.GetUpload(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get partial upload %s: %w", id, err)
+		}
+		info, err := upload.GetInfo(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get partial upload info %s: %w", id, err)
+		}
+		infos = append(infos, info)
+	}
+
+	return infos, nil
+}
+
+// checkPartialUploadsOwner rejects a final upload that concatenates partial uploads of another user
+func (h *Handler) checkPartialUploadsOwner(ctx context.Context, ownerID string, uploadIDs []string) error {
+	partials, err := h.getPartialUploadInfos(ctx, uploadIDs)
+	if err != nil {
+		return tusd.NewError("ERR_PARTIAL_UPLOAD_NOT_FOUND", err.Error(), http.StatusNotFound)
+	}
+
+	for _, partial := range partials {
+		if partial.MetaData["owner_id"] != ownerID {
+			return tusd.NewError("ERR_PARTIAL_UPLOAD_FORBIDDEN", "partial upload belongs to another user", http.StatusForbidden)
+		}
+	}
+
+	return nil
+}
+
+// resolveFinalUploadMetadata fills file metadata missing from a final upload with the first partial upload's
+// Clients usually send the file name with every part, but tus only requires metadata on the final request
+func (h *Handler) resolveFinalUploadMetadata(ctx context.Context, upload *tusd.FileInfo) {
+	if len(upload.PartialUploads) == 0 {
+		return
+	}
+
+	partials, err := h.getPartialUploadInfos(ctx, upload.PartialUploads[:1])
+	if err != nil {
+		log.Warn().Err(err).Str("upload_id", upload.ID).Msg("Failed to load partial upload metadata")
+		return
+	}
+
+	if upload.MetaData == nil {
+		upload.MetaData = tusd.MetaData{}
+	}
+	for _, key := range fileMetadataKeys {
+		if upload.MetaData[key] == "" && partials[0].MetaData[key] != "" {
+			upload.MetaData[key] = partials[0].MetaData[key]
+		}
+	}
+
+	// The upload started when its parts were created, not when they were concatenated
+	if createdAt := partials[0].MetaData[uploadCreatedAtKey]; createdAt != "" {
+		upload.MetaData[uploadCreatedAtKey] = createdAt
+	}
+}
+
+// removePartialUploads terminates the partial uploads of a processed final upload
+// Their data was copied into the final object, so keeping them would only take up storage
+func (h *Handler) removePartialUploads(ctx context.Context, uploadIDs []string) {
+	for _, id := range uploadIDs {
+		upload, err := h.composer.Core.GetUpload(ctx, id)
+		if err == nil {
+			err = h.composer.Terminater.AsTerminatableUpload(upload).Terminate(ctx)
+		}
+		if err != nil {
+			log.Error().Err(err).Str("upload_id", id).Msg("Failed to remove partial upload")
+			continue
+		}
+		log.Info().Str("upload_id", id).Msg("Removed partial upload after concatenation")
+	}
+}
@@ -0,0 +1,204 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"e-document-backend/internal/domain"
+	"e-document-backend/internal/middleware"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// completionService records the uploads the completion pipeline turns into documents
+type completionService struct {
+	Service
+	processed chan ProcessUploadParams
+}
+
+func (s *completionService) IsUploadProcessed(ctx context.Context, uploadID string) (bool, error) {
+	return false, nil
+}
+
+func (s *completionService) ProcessUploadComplete(ctx context.Context, params ProcessUploadParams) (*ProcessUploadResult, error) {
+	s.processed <- params
+	return &ProcessUploadResult{
+		Document:   &domain.Document{ID: uuid.New()},
+		Attachment: &domain.DocumentAttachment{ID: uuid.New(), FilePath: params.FilePath},
+	}, nil
+}
+
+// testJSONBodyLimit is smaller than the test uploads, so tus requests show they are exempt
+const testJSONBodyLimit = 1 << 10
+
+// newTusTestServer serves the tus routes of a handler whose tusd S3 store writes to the fake storage
+// configure may adjust the tus configuration before the tusd handler is created
+func newTusTestServer(t *testing.T, service Service, ownerID uuid.UUID, configure ...func(cfg *TusConfig)) (*Handler, *fakeStorage, *httptest.Server) {
+	t.Helper()
+	tusConfig := TusConfig{
+		BasePath:          "/api/v1/upload",
+		S3AccessKey:       "access",
+		S3SecretKey:       "secret",
+		S3Bucket:          testBucket,
+		StorageDir:        t.TempDir(),
+		StorageDirMode:    "0755",
+		DuplicateStrategy: DuplicateRename,
+	}
+	for _, fn := range configure {
+		fn(&tusConfig)
+	}
+	h, storage := newTestHandler(t, service, nil, tusConfig)
+	h.tusConfig.S3Endpoint = storage.endpoint
+	h.events = newEventBroker()
+	h.stopCompletions = make(chan struct{})
+	h.completionsDone = make(chan struct{})
+	h.jobsCtx, h.cancelJobs = context.WithCancel(context.Background())
+	if err := h.initTusHandler(); err != nil {
+		t.Fatalf("initTusHandler() error = %v", err)
+	}
+
+	e := echo.New()
+	authenticate := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("user_id", ownerID.String())
+			return next(c)
+		}
+	}
+	h.RegisterRoutes(e.Group("/api"), authenticate, middleware.BodyLimitMiddleware(testJSONBodyLimit))
+
+	server := httptest.NewServer(e)
+	t.Cleanup(server.Close)
+	return h, storage, server
+}
+
+// sendTus sends a tus protocol request and checks its status
+func sendTus(method, url string, headers map[string]string, body []byte, wantStatus int) (*http.Response, error) {
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Tus-Resumable", "1.0.0")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != wantStatus {
+		return nil, fmt.Errorf("%s %s = %d, want %d", method, url, resp.StatusCode, wantStatus)
+	}
+	return resp, nil
+}
+
+// tusRequest is sendTus for the test goroutine, failing the test on error
+func tusRequest(t *testing.T, method, url string, headers map[string]string, body []byte, wantStatus int) *http.Response {
+	t.Helper()
+	resp, err := sendTus(method, url, headers, body, wantStatus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestConcatenatedUpload(t *testing.T) {
+	ownerID := uuid.New()
+	service := &completionService{processed: make(chan ProcessUploadParams, 1)}
+	h, storage, server := newTusTestServer(t, service, ownerID)
+	filesURL := server.URL + "/api/v1/upload/files"
+
+	parts := [][]byte{
+		bytes.Repeat([]byte("a"), 3000),
+		bytes.Repeat([]byte("b"), 1000),
+		bytes.Repeat([]byte("c"), 2000),
+	}
+
+	// Create every partial upload, then send their data in parallel
+	locations := make([]string, len(parts))
+	for i, part := range parts {
+		resp := tusRequest(t, http.MethodPost, filesURL, map[string]string{
+			"Upload-Concat": "partial",
+			"Upload-Length": strconv.Itoa(len(part)),
+		}, nil, http.StatusCreated)
+		locations[i] = resp.Header.Get("Location")
+		if !strings.Contains(locations[i], "/api/v1/upload/files/") {
+			t.Fatalf("partial upload location = %q, want one under the files endpoint", locations[i])
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i, part := range parts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := sendTus(http.MethodPatch, locations[i], map[string]string{
+				"Content-Type":  "application/offset+octet-stream",
+				"Upload-Offset": "0",
+			}, part, http.StatusNoContent)
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if t.Failed() {
+		t.FailNow()
+	}
+
+	metadata := "relative_path " + base64.StdEncoding.EncodeToString([]byte("Reports/2026/budget.pdf")) +
+		",file_type " + base64.StdEncoding.EncodeToString([]byte("application/pdf"))
+	final := tusRequest(t, http.MethodPost, filesURL, map[string]string{
+		"Upload-Concat":   "final;" + strings.Join(locations, " "),
+		"Upload-Metadata": metadata,
+	}, nil, http.StatusCreated)
+
+	var params ProcessUploadParams
+	select {
+	case params = <-service.processed:
+	case <-time.After(10 * time.Second):
+		t.Fatal("the final upload was never processed")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := h.Drain(ctx); err != nil {
+		t.Fatalf("Drain() error = %v", err)
+	}
+
+	if params.OwnerID != ownerID || params.RelativePath != "Reports/2026/budget.pdf" || params.FileType != "application/pdf" {
+		t.Fatalf("processed %+v, want the final upload's owner, path and type", params)
+	}
+	want := bytes.Join(parts, nil)
+	if params.FileSize != int64(len(want)) {
+		t.Fatalf("processed size = %d, want %d", params.FileSize, len(want))
+	}
+
+	// The attachment points at the final object, which holds the parts in order
+	finalID := final.Header.Get("Location")[strings.LastIndex(final.Header.Get("Location"), "/")+1:]
+	if !strings.HasPrefix(finalID, params.FilePath) {
+		t.Fatalf("attachment object %q is not the final upload %q", params.FilePath, finalID)
+	}
+	got, ok := storage.object(params.FilePath)
+	if !ok || !bytes.Equal(got, want) {
+		t.Fatalf("final object holds %d bytes, want the %d concatenated bytes", len(got), len(want))
+	}
+
+	// The parts were copied into the final object and are removed
+	for _, location := range locations {
+		partID := location[strings.LastIndex(location, "/")+1:]
+		objectKey, _, _ := strings.Cut(partID, "+")
+		if _, ok := storage.object(objectKey); ok {
+			t.Fatalf("partial upload %s was not removed", objectKey)
+		}
+	}
+}
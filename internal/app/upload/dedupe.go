@@ -0,0 +1,66 @@
+package upload
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/rs/zerolog/log"
+)
+
+// hashObject streams an object from MinIO and returns its hex-encoded SHA-256
+func (h *Handler) hashObject(ctx context.Context, objectKey string) (string, error) {
+	object, err := h.minioClient.GetObject(ctx, h.bucket, objectKey, minio.GetObjectOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to open object: %w", err)
+	}
+	defer object.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, object); err != nil {
+		return "", fmt.Errorf("failed to read object: %w", err)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// deduplicateUpload hashes a completed upload and looks for an identical stored object
+// knownHash is the upload's verified checksum, which saves reading the object again when set
+// It returns the content hash (empty if hashing failed) and the key of an existing object
+// with the same content, or an empty string if the upload is the first copy; whether the
+// existing object is still referenced is checked again when the attachment is created
+func (h *Handler) deduplicateUpload(ctx context.Context, objectKey string, size int64, knownHash string) (string, string) {
+	contentHash := knownHash
+	if contentHash == "" {
+		var err error
+		contentHash, err = h.hashObject(ctx, objectKey)
+		if err != nil {
+			log.Warn().Err(err).Str("object_key", objectKey).Msg("Failed to hash upload, storing without deduplication")
+			return "", ""
+		}
+	}
+
+	existingKey, err := h.service.FindFilePathByContentHash(ctx, contentHash, size)
+	if err != nil {
+		log.Warn().Err(err).Str("object_key", objectKey).Msg("Failed to look up duplicate upload, storing as is")
+		return contentHash, ""
+	}
+	if existingKey == objectKey || existingKey == "" {
+		return contentHash, ""
+	}
+
+	// The attachment row may outlive its object (a failed removal, a bucket restored from an
+	// older backup), so only reuse an object that is actually stored
+	if _, err := h.minioClient.StatObject(ctx, h.bucket, existingKey, minio.StatObjectOptions{}); err != nil {
+		log.Warn().Err(err).
+			Str("object_key", objectKey).
+			Str("existing_object", existingKey).
+			Msg("Duplicate object is not readable, storing the upload as is")
+		return contentHash, ""
+	}
+
+	return contentHash, existingKey
+}
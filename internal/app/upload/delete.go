@@ -0,0 +1,173 @@
+package upload
+
+import (
+	"context"
+	"e-document-backend/internal/platform/postgres"
+	"e-document-backend/internal/util"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// GetDocumentRegistrantForUpdate locks a document row and returns its registrant
+func (r *postgresRepository) GetDocumentRegistrantForUpdate(ctx context.Context, tx pgx.Tx, documentID uuid.UUID) (*uuid.UUID, error) {
+	query := `SELECT registrant_id FROM documents WHERE id = $1 FOR UPDATE`
+
+	var registrantID *uuid.UUID
+	err := tx.QueryRow(ctx, query, documentID).Scan(&registrantID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("document not found")
+		}
+		return nil, fmt.Errorf("failed to get document: %w", err)
+	}
+
+	return registrantID, nil
+}
+
+// DeleteDocument deletes a document and, through cascading keys, its attachments, shares, routes
+// and tags; it returns the object paths of every version of the document
+func (r *postgresRepository) DeleteDocument(ctx context.Context, tx pgx.Tx, documentID uuid.UUID) ([]string, error) {
+	rows, err := tx.Query(ctx, `SELECT DISTINCT file_path FROM document_attachments WHERE document_id = $1`, documentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get document files: %w", err)
+	}
+	filePaths, err := scanFilePaths(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan document files: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM documents WHERE id = $1`, documentID); err != nil {
+		return nil, fmt.Errorf("failed to delete document: %w", err)
+	}
+
+	return filePaths, nil
+}
+
+// LockFilePaths serialises transactions that add or drop references to the objects until the
+// transaction ends; see postgres.LockObjectPaths
+func (r *postgresRepository) LockFilePaths(ctx context.Context, tx pgx.Tx, filePaths []string) error {
+	return postgres.LockObjectPaths(ctx, tx, filePaths)
+}
+
+// FilterUnreferencedFilePaths returns the object paths no attachment references any more
+// Deduplicated uploads and document copies share objects, which must outlive this document;
+// callers lock the paths with LockFilePaths first so the answer holds until they commit
+func (r *postgresRepository) FilterUnreferencedFilePaths(ctx context.Context, tx pgx.Tx, filePaths []string) ([]string, error) {
+	query := `
+		SELECT p FROM unnest($1::text[]) AS p
+		WHERE NOT EXISTS (SELECT 1 FROM document_attachments WHERE file_path = p)
+	`
+
+	rows, err := tx.Query(ctx, query, filePaths)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check file references: %w", err)
+	}
+	unreferenced, err := scanFilePaths(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan file references: %w", err)
+	}
+
+	return unreferenced, nil
+}
+
+// scanFilePaths reads a single text column from every row and closes the rows
+func scanFilePaths(rows pgx.Rows) ([]string, error) {
+	defer rows.Close()
+
+	var filePaths []string
+	for rows.Next() {
+		var filePath string
+		if err := rows.Scan(&filePath); err != nil {
+			return nil, err
+		}
+		filePaths = append(filePaths, filePath)
+	}
+
+	return filePaths, rows.Err()
+}
+
+// DeleteDocument deletes a document owned by the requester with all its attachment rows in one
+// transaction and returns the storage objects left without any attachment
+func (s *service) DeleteDocument(ctx context.Context, documentID, requesterID uuid.UUID) ([]string, error) {
+	var unreferenced []string
+	err := s.repo.WithTx(ctx, func(tx pgx.Tx) error {
+		registrantID, err := s.repo.GetDocumentRegistrantForUpdate(ctx, tx, documentID)
+		if err != nil {
+			return util.NewNotFoundError("Document", documentID.String())
+		}
+		if registrantID == nil || *registrantID != requesterID {
+			return util.NewForbiddenError("you can only delete your own documents")
+		}
+
+		filePaths, err := s.repo.DeleteDocument(ctx, tx, documentID)
+		if err != nil {
+			return util.NewDatabaseError("delete document", err)
+		}
+
+		// Wait for uploads and copies that are about to reuse one of these objects
+		if err := s.repo.LockFilePaths(ctx, tx, filePaths); err != nil {
+			return util.NewDatabaseError("lock document files", err)
+		}
+		unreferenced, err = s.repo.FilterUnreferencedFilePaths(ctx, tx, filePaths)
+		if err != nil {
+			return util.NewDatabaseError("check file references", err)
+		}
+		return nil
+	})
+
+	return unreferenced, err
+}
+
+// DeleteUpload godoc
+// @Summary		Delete an uploaded file
+// @Description	Deletes a document you own together with every version of its file. Stored objects shared with other documents (identical uploads, copies) are kept
+// @Tags		Upload
+// @Produce		json
+// @Security	BearerAuth
+// @Param		documentId	path		string	true	"Document ID"
+// @Success		200			{object}	util.Response
+// @Failure		400			{object}	util.ErrorEnvelope
+// @Failure		401			{object}	util.ErrorEnvelope
+// @Failure		403			{object}	util.ErrorEnvelope
+// @Failure		404			{object}	util.ErrorEnvelope
+// @Failure		500			{object}	util.ErrorEnvelope
+// @Router		/v1/upload/{documentId} [delete]
+func (h *Handler) DeleteUpload(c echo.Context) error {
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+	requesterID, err := uuid.Parse(userID)
+	if err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid user ID", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	documentID, err := uuid.Parse(c.Param("documentId"))
+	if err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid document ID", util.INVALID_INPUT, 400, "The provided document ID is not a valid UUID"))
+	}
+
+	filePaths, err := h.service.DeleteDocument(c.Request().Context(), documentID, requesterID)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	// The rows are gone, so the objects go too even if the client disconnects; an object that
+	// cannot be removed now is unreferenced and left to the orphan cleanup
+	ctx := context.WithoutCancel(c.Request().Context())
+	for _, filePath := range filePaths {
+		h.removeUploadObject(ctx, filePath)
+	}
+
+	log.Info().
+		Str("document_id", documentID.String()).
+		Str("user_id", userID).
+		Int("removed_objects", len(filePaths)).
+		Msg("Deleted uploaded document")
+
+	return util.OKResponse(c, "File deleted successfully", nil)
+}
@@ -0,0 +1,141 @@
+package upload
+
+import (
+	"context"
+	"e-document-backend/internal/platform/postgres/pgtest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// seedAttachment registers a document with one attachment stored at filePath
+func seedAttachment(t *testing.T, pool *pgxpool.Pool, ownerID uuid.UUID, filePath string) uuid.UUID {
+	t.Helper()
+	var documentID uuid.UUID
+	err := pool.QueryRow(context.Background(), `
+		INSERT INTO documents (title, registrant_id) VALUES ($1, $2) RETURNING id
+	`, filePath, ownerID).Scan(&documentID)
+	if err != nil {
+		t.Fatalf("failed to seed document: %v", err)
+	}
+	_, err = pool.Exec(context.Background(), `
+		INSERT INTO document_attachments (document_id, file_name, file_path, file_size, file_type, version, is_current, uploaded_by)
+		VALUES ($1, 'report.pdf', $2, 1024, 'application/pdf', 1, true, $3)
+	`, documentID, filePath, ownerID)
+	if err != nil {
+		t.Fatalf("failed to seed attachment: %v", err)
+	}
+	return documentID
+}
+
+func seedUploader(t *testing.T, pool *pgxpool.Pool) uuid.UUID {
+	t.Helper()
+	id := uuid.New()
+	_, err := pool.Exec(context.Background(), `
+		INSERT INTO users (id, username, email, phone, first_name, last_name, password, role)
+		VALUES ($1, $2, $3, '+66812345678', 'Somchai', 'Jaidee', 'hash', 'Employee')
+	`, id, "user-"+id.String()[:8], id.String()[:8]+"@example.com")
+	if err != nil {
+		t.Fatalf("failed to seed user: %v", err)
+	}
+	return id
+}
+
+func TestDeleteDocumentWaitsForReuse(t *testing.T) {
+	pool := pgtest.NewPool(t)
+	svc := NewService(NewPostgresRepository(pool))
+	ctx := context.Background()
+
+	ownerID := seedUploader(t, pool)
+	documentID := seedAttachment(t, pool, ownerID, "uploads/shared.pdf")
+	otherDocumentID := seedAttachment(t, pool, ownerID, "uploads/other.pdf")
+
+	// Stand in for an upload reusing the object: it holds the lock while it adds its reference
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatalf("failed to begin: %v", err)
+	}
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('uploads/shared.pdf'))`); err != nil {
+		t.Fatalf("failed to lock: %v", err)
+	}
+
+	type deleted struct {
+		paths []string
+		err   error
+	}
+	done := make(chan deleted, 1)
+	go func() {
+		paths, err := svc.DeleteDocument(ctx, documentID, ownerID)
+		done <- deleted{paths, err}
+	}()
+
+	select {
+	case got := <-done:
+		t.Fatalf("DeleteDocument() = %v, %v before the reusing transaction committed", got.paths, got.err)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO document_attachments (document_id, file_name, file_path, file_size, file_type, version, is_current, uploaded_by)
+		VALUES ($1, 'copy.pdf', 'uploads/shared.pdf', 1024, 'application/pdf', 2, false, $2)
+	`, otherDocumentID, ownerID); err != nil {
+		t.Fatalf("failed to add reference: %v", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+
+	got := <-done
+	if got.err != nil {
+		t.Fatalf("DeleteDocument() error = %v", got.err)
+	}
+	if len(got.paths) != 0 {
+		t.Fatalf("DeleteDocument() would remove %v, still referenced by the reusing upload", got.paths)
+	}
+}
+
+func TestProcessUploadReusesDuplicate(t *testing.T) {
+	pool := pgtest.NewPool(t)
+	svc := NewService(NewPostgresRepository(pool))
+	ctx := context.Background()
+
+	ownerID := seedUploader(t, pool)
+	seedAttachment(t, pool, ownerID, "uploads/stored.pdf")
+	deletedID := seedAttachment(t, pool, ownerID, "uploads/deleted.pdf")
+	if _, err := svc.DeleteDocument(ctx, deletedID, ownerID); err != nil {
+		t.Fatalf("DeleteDocument() error = %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		duplicateOf  string
+		wantFilePath string
+	}{
+		{name: "duplicate still referenced", duplicateOf: "uploads/stored.pdf", wantFilePath: "uploads/stored.pdf"},
+		{name: "duplicate deleted meanwhile", duplicateOf: "uploads/deleted.pdf", wantFilePath: "uploads/new.pdf"},
+		{name: "no duplicate", wantFilePath: "uploads/new.pdf"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := svc.ProcessUploadComplete(ctx, ProcessUploadParams{
+				RelativePath: "Inbox/report.pdf",
+				OwnerID:      ownerID,
+				FilePath:     "uploads/new.pdf",
+				FileSize:     1024,
+				FileType:     "application/pdf",
+				UploadID:     uuid.NewString(),
+				DuplicateOf:  tt.duplicateOf,
+				OnDuplicate:  DuplicateRename,
+			})
+			if err != nil {
+				t.Fatalf("ProcessUploadComplete() error = %v", err)
+			}
+			if result.Attachment.FilePath != tt.wantFilePath {
+				t.Fatalf("attachment stored at %q, want %q", result.Attachment.FilePath, tt.wantFilePath)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+	tusd "github.com/tus/tusd/v2/pkg/handler"
+)
+
+// DuplicateStrategy decides what happens to an upload whose file name is already taken by a
+// document in the same folder
+type DuplicateStrategy string
+
+const (
+	DuplicateVersion DuplicateStrategy = "version" // the upload becomes a new version of the existing document
+	DuplicateRename  DuplicateStrategy = "rename"  // the upload becomes a new document named "report (1).pdf"
+	DuplicateReject  DuplicateStrategy = "reject"  // the upload is refused with 409 Conflict
+)
+
+// duplicateStrategyKey is the upload metadata key choosing the strategy of a single upload
+const duplicateStrategyKey = "on_duplicate"
+
+// maxRenameAttempts bounds the search for a free "name (n).ext" when renaming a duplicate
+const maxRenameAttempts = 1000
+
+// ErrDuplicateFileName is returned by ProcessUploadComplete when the reject strategy applies
+var ErrDuplicateFileName = errors.New("file name already exists in folder")
+
+// parseDuplicateStrategy checks a strategy name
+func parseDuplicateStrategy(value string) (DuplicateStrategy, error) {
+	switch strategy := DuplicateStrategy(strings.ToLower(strings.TrimSpace(value))); strategy {
+	case DuplicateVersion, DuplicateRename, DuplicateReject:
+		return strategy, nil
+	}
+	return "", fmt.Errorf("%s must be one of %s, %s or %s", duplicateStrategyKey, DuplicateVersion, DuplicateRename, DuplicateReject)
+}
+
+// duplicateStrategy returns the strategy an upload asked for, or the configured default
+func (h *Handler) duplicateStrategy(metaData tusd.MetaData) (DuplicateStrategy, error) {
+	if value := metaData[duplicateStrategyKey]; value != "" {
+		return parseDuplicateStrategy(value)
+	}
+	return h.tusConfig.DuplicateStrategy, nil
+}
+
+// checkDuplicateFileName refuses an upload with an unknown strategy, or one using the reject
+// strategy whose file name is already taken, before any bytes are sent
+// Another upload may still take the name meanwhile, so completion checks again
+func (h *Handler) checkDuplicateFileName(ctx context.Context, upload tusd.FileInfo) error {
+	strategy, err := h.duplicateStrategy(upload.MetaData)
+	if err != nil {
+		return tusd.NewError("ERR_INVALID_DUPLICATE_STRATEGY", err.Error(), http.StatusBadRequest)
+	}
+	if strategy != DuplicateReject {
+		return nil
+	}
+
+	// Final uploads may only get their metadata from the partial uploads on completion
+	meta, err := parseUploadMetadata(upload.MetaData, nil)
+	if err != nil {
+		return nil
+	}
+
+	taken, err := h.service.IsFileNameTaken(ctx, meta.OwnerID, meta.ParentFolderID, meta.RelativePath)
+	if err != nil {
+		log.Warn().Err(err).Str("relative_path", meta.RelativePath).Msg("Failed to check for duplicate file name, deferring to completion")
+		return nil
+	}
+	if taken {
+		return tusd.NewError("ERR_DUPLICATE_FILE_NAME", fmt.Sprintf("%s already exists in this folder", meta.RelativePath), http.StatusConflict)
+	}
+
+	return nil
+}
+
+// IsFileNameTaken reports whether a document in the folder relativePath points into already has
+// its file name; folders that do not exist yet cannot hold a duplicate and are not created
+func (s *service) IsFileNameTaken(ctx context.Context, ownerID uuid.UUID, parentFolderID *uuid.UUID, relativePath string) (bool, error) {
+	pathParts, err := parsePath(relativePath)
+	if err != nil || len(pathParts) == 0 {
+		return false, nil
+	}
+
+	taken := false
+	err = s.repo.WithTx(ctx, func(tx pgx.Tx) error {
+		folderID := parentFolderID
+		for _, folderName := range pathParts[:len(pathParts)-1] {
+			folder, err := s.repo.FindFolderByNameAndParent(ctx, tx, folderName, folderID, ownerID)
+			if err != nil {
+				return err
+			}
+			if folder == nil {
+				return nil
+			}
+			folderID = &folder.ID
+		}
+
+		existing, err := s.repo.FindDocumentByFileName(ctx, tx, folderID, ownerID, pathParts[len(pathParts)-1])
+		if err != nil {
+			return err
+		}
+		taken = existing != nil
+		return nil
+	})
+
+	return taken, err
+}
+
+// freeFileName returns the first of "name (1).ext", "name (2).ext", ... not taken in the folder
+func (s *service) freeFileName(ctx context.Context, tx pgx.Tx, folderID *uuid.UUID, ownerID uuid.UUID, fileName string) (string, error) {
+	ext := filepath.Ext(fileName)
+	base := strings.TrimSuffix(fileName, ext)
+
+	for i := 1; i <= maxRenameAttempts; i++ {
+		candidate := fmt.Sprintf("%s (%d)%s", base, i, ext)
+		existing, err := s.repo.FindDocumentByFileName(ctx, tx, folderID, ownerID, candidate)
+		if err != nil {
+			return "", err
+		}
+		if existing == nil {
+			return candidate, nil
+		}
+	}
+
+	return "", fmt.Errorf("no free name for %s after %d attempts", fileName, maxRenameAttempts)
+}
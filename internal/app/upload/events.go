@@ -0,0 +1,141 @@
+package upload
+
+import (
+	"e-document-backend/internal/util"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	sseHeartbeatInterval = 15 * time.Second // keeps proxies from closing idle streams
+	sseBufferSize        = 16               // events buffered per subscriber before dropping
+)
+
+// eventBroker is an in-memory pub/sub of upload events keyed by owner ID
+// Events are only delivered to subscribers connected to this instance
+type eventBroker struct {
+	mu          sync.RWMutex
+	subscribers map[string]map[chan UploadCompletedEvent]struct{}
+	done        chan struct{} // closed on shutdown to end all streams
+	closeOnce   sync.Once
+}
+
+// newEventBroker creates an empty broker
+func newEventBroker() *eventBroker {
+	return &eventBroker{
+		subscribers: make(map[string]map[chan UploadCompletedEvent]struct{}),
+		done:        make(chan struct{}),
+	}
+}
+
+// close signals all streams to end
+func (b *eventBroker) close() {
+	b.closeOnce.Do(func() {
+		close(b.done)
+	})
+}
+
+// subscribe registers a new subscriber for an owner and returns its channel and an unsubscribe func
+func (b *eventBroker) subscribe(ownerID string) (<-chan UploadCompletedEvent, func()) {
+	ch := make(chan UploadCompletedEvent, sseBufferSize)
+
+	b.mu.Lock()
+	if b.subscribers[ownerID] == nil {
+		b.subscribers[ownerID] = make(map[chan UploadCompletedEvent]struct{})
+	}
+	b.subscribers[ownerID][ch] = struct{}{}
+	b.mu.Unlock()
+
+	unsubscribe := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		delete(b.subscribers[ownerID], ch)
+		if len(b.subscribers[ownerID]) == 0 {
+			delete(b.subscribers, ownerID)
+		}
+	}
+
+	return ch, unsubscribe
+}
+
+// publish sends an event to every subscriber of the owner without blocking;
+// a subscriber whose buffer is full misses the event
+func (b *eventBroker) publish(ownerID string, event UploadCompletedEvent) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for ch := range b.subscribers[ownerID] {
+		select {
+		case ch <- event:
+		default:
+			log.Warn().
+				Str("owner_id", ownerID).
+				Str("upload_id", event.UploadID).
+				Msg("Upload event subscriber is too slow, dropping event")
+		}
+	}
+}
+
+// StreamEvents godoc
+// @Summary		Stream upload events
+// @Description	Server-Sent Events stream that pushes an "upload.completed" event whenever one of the authenticated user's uploads finishes processing. Heartbeat comments are sent every 15 seconds
+// @Tags		Upload
+// @Produce		text/event-stream
+// @Security	BearerAuth
+// @Success		200	{object}	UploadCompletedEvent
+// @Failure		401	{object}	util.ErrorEnvelope
+// @Router		/v1/upload/events [get]
+func (h *Handler) StreamEvents(c echo.Context) error {
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	events, unsubscribe := h.events.subscribe(userID)
+	defer unsubscribe()
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set("Cache-Control", "no-cache")
+	res.Header().Set("Connection", "keep-alive")
+	res.Header().Set("X-Accel-Buffering", "no") // disable nginx response buffering
+	res.WriteHeader(http.StatusOK)
+	res.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	ctx := c.Request().Context()
+	for {
+		select {
+		case <-ctx.Done():
+			// Client disconnected
+			return nil
+		case <-h.events.done:
+			// Server is shutting down
+			return nil
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(res, ": heartbeat\n\n"); err != nil {
+				return nil
+			}
+			res.Flush()
+		case event := <-events:
+			data, err := json.Marshal(event)
+			if err != nil {
+				log.Error().Err(err).Str("upload_id", event.UploadID).Msg("Failed to encode upload event")
+				continue
+			}
+			if _, err := fmt.Fprintf(res, "id: %s\nevent: %s\ndata: %s\n\n", event.UploadID, event.Event, data); err != nil {
+				return nil
+			}
+			res.Flush()
+		}
+	}
+}
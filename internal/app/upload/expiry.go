@@ -0,0 +1,201 @@
+package upload
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/rs/zerolog/log"
+	tusd "github.com/tus/tusd/v2/pkg/handler"
+)
+
+// uploadExpiryInterval is how often abandoned uploads are looked for
+const uploadExpiryInterval = time.Hour
+
+// uploadLockTimeout bounds how long the expiry waits for a request writing to an upload to let go of it
+var uploadLockTimeout = 30 * time.Second
+
+// runUploadExpiry periodically reclaims uploads that were never completed within the upload TTL
+func (h *Handler) runUploadExpiry(ctx context.Context) {
+	ticker := time.NewTicker(uploadExpiryInterval)
+	defer ticker.Stop()
+
+	log.Info().
+		Dur("interval", uploadExpiryInterval).
+		Dur("ttl", h.tusConfig.UploadTTL).
+		Msg("Starting abandoned upload expiry job")
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info().Msg("Stopped abandoned upload expiry job")
+			return
+		case <-ticker.C:
+		}
+
+		expired, err := h.expireAbandonedUploads(ctx)
+		if err != nil {
+			log.Error().Err(err).Msg("Abandoned upload expiry failed")
+		}
+		locks := h.removeStaleLockFiles()
+		log.Info().
+			Int("expired", expired).
+			Int("lock_files_removed", locks).
+			Msg("Abandoned upload expiry completed")
+	}
+}
+
+// expireAbandonedUploads terminates uploads created before the TTL that are still incomplete,
+// and partial uploads that were never concatenated into a final upload
+// Each upload is locked like tusd locks it for a DELETE, so a PATCH or a concatenation in progress
+// is asked to stop first; tusd's terminater aborts the S3 multipart upload and removes the .info
+// and .part objects
+func (h *Handler) expireAbandonedUploads(ctx context.Context) (int, error) {
+	cutoff := time.Now().Add(-h.tusConfig.UploadTTL)
+	expired := 0
+
+	for object := range h.minioClient.ListObjects(ctx, h.bucket, minio.ListObjectsOptions{Recursive: true}) {
+		if object.Err != nil {
+			return expired, object.Err
+		}
+
+		// Every tusd upload has an .info object at the bucket root, written when the upload is created
+		if strings.Contains(object.Key, "/") || !strings.HasSuffix(object.Key, tusInfoSuffix) {
+			continue
+		}
+		if object.LastModified.After(cutoff) {
+			continue
+		}
+
+		uploadID, err := h.readUploadID(ctx, object.Key)
+		if err != nil {
+			log.Warn().Err(err).Str("object_key", object.Key).Msg("Failed to read upload info")
+			continue
+		}
+
+		info, terminated, err := h.terminateIfAbandoned(ctx, uploadID)
+		if err != nil {
+			log.Warn().Err(err).Str("upload_id", uploadID).Msg("Failed to expire abandoned upload")
+			continue
+		}
+		if !terminated {
+			continue
+		}
+		h.removeLockFiles(uploadID)
+
+		log.Info().
+			Str("upload_id", uploadID).
+			Int64("offset", info.Offset).
+			Int64("size", info.Size).
+			Msg("Expired abandoned upload")
+		expired++
+	}
+
+	return expired, ctx.Err()
+}
+
+// terminateIfAbandoned terminates an upload under its tusd lock unless it is complete and not a
+// partial upload; the info is read after locking, so an upload finished meanwhile is kept
+func (h *Handler) terminateIfAbandoned(ctx context.Context, uploadID string) (tusd.FileInfo, bool, error) {
+	lock, err := h.composer.Locker.NewLock(uploadID)
+	if err != nil {
+		return tusd.FileInfo{}, false, fmt.Errorf("failed to create lock: %w", err)
+	}
+	lockCtx, cancel := context.WithTimeout(ctx, uploadLockTimeout)
+	defer cancel()
+	// The expiry holds the lock only briefly, so it ignores requests to release it
+	if err := lock.Lock(lockCtx, func() {}); err != nil {
+		return tusd.FileInfo{}, false, fmt.Errorf("failed to lock upload: %w", err)
+	}
+	defer func() {
+		if err := lock.Unlock(); err != nil {
+			log.Warn().Err(err).Str("upload_id", uploadID).Msg("Failed to unlock upload")
+		}
+	}()
+
+	upload, err := h.composer.Core.GetUpload(ctx, uploadID)
+	if err != nil {
+		return tusd.FileInfo{}, false, fmt.Errorf("failed to get upload: %w", err)
+	}
+	info, err := upload.GetInfo(ctx)
+	if err != nil {
+		return tusd.FileInfo{}, false, fmt.Errorf("failed to get upload info: %w", err)
+	}
+
+	complete := !info.SizeIsDeferred && info.Offset == info.Size
+	if complete && !info.IsPartial {
+		return info, false, nil
+	}
+
+	if err := h.composer.Terminater.AsTerminatableUpload(upload).Terminate(ctx); err != nil {
+		return info, false, fmt.Errorf("failed to terminate upload: %w", err)
+	}
+
+	return info, true, nil
+}
+
+// readUploadID reads the tusd upload ID from an upload's .info object
+func (h *Handler) readUploadID(ctx context.Context, infoKey string) (string, error) {
+	object, err := h.minioClient.GetObject(ctx, h.bucket, infoKey, minio.GetObjectOptions{})
+	if err != nil {
+		return "", err
+	}
+	defer object.Close()
+
+	var info tusd.FileInfo
+	if err := json.NewDecoder(object).Decode(&info); err != nil {
+		return "", err
+	}
+	if info.ID == "" {
+		return "", errors.New("upload info has no id")
+	}
+
+	return info.ID, nil
+}
+
+// removeLockFiles deletes the file locker's files for an upload
+func (h *Handler) removeLockFiles(uploadID string) {
+	for _, suffix := range []string{".lock", ".stop"} {
+		path := filepath.Join(h.tusConfig.StorageDir, uploadID+suffix)
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Warn().Err(err).Str("path", path).Msg("Failed to remove upload lock file")
+		}
+	}
+}
+
+// removeStaleLockFiles deletes lock files older than the TTL, left behind when the server stopped mid-upload
+func (h *Handler) removeStaleLockFiles() int {
+	entries, err := os.ReadDir(h.tusConfig.StorageDir)
+	if err != nil {
+		log.Warn().Err(err).Str("dir", h.tusConfig.StorageDir).Msg("Failed to read upload lock directory")
+		return 0
+	}
+
+	cutoff := time.Now().Add(-h.tusConfig.UploadTTL)
+	removed := 0
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || (!strings.HasSuffix(name, ".lock") && !strings.HasSuffix(name, ".stop")) {
+			continue
+		}
+
+		fileInfo, err := entry.Info()
+		if err != nil || fileInfo.ModTime().After(cutoff) {
+			continue
+		}
+
+		if err := os.Remove(filepath.Join(h.tusConfig.StorageDir, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Warn().Err(err).Str("file", name).Msg("Failed to remove stale upload lock file")
+			continue
+		}
+		removed++
+	}
+
+	return removed
+}
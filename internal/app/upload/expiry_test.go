@@ -0,0 +1,105 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// uploadIDFromLocation returns the tusd upload ID at the end of an upload URL
+func uploadIDFromLocation(location string) string {
+	return location[strings.LastIndex(location, "/")+1:]
+}
+
+func TestExpireAbandonedUploads(t *testing.T) {
+	uploadLockTimeout = 50 * time.Millisecond
+	t.Cleanup(func() { uploadLockTimeout = 30 * time.Second })
+
+	service := &completionService{processed: make(chan ProcessUploadParams, 1)}
+	h, storage, server := newTusTestServer(t, service, uuid.New())
+	filesURL := server.URL + "/api/v1/upload/files"
+	metadata := "relative_path " + base64.StdEncoding.EncodeToString([]byte("report.pdf"))
+	ctx := context.Background()
+
+	create := func(headers map[string]string, body []byte) string {
+		headers["Upload-Metadata"] = metadata
+		location := tusRequest(t, http.MethodPost, filesURL, headers, nil, http.StatusCreated).Header.Get("Location")
+		if body != nil {
+			tusRequest(t, http.MethodPatch, location, map[string]string{
+				"Content-Type":  "application/offset+octet-stream",
+				"Upload-Offset": "0",
+			}, body, http.StatusNoContent)
+		}
+		return uploadIDFromLocation(location)
+	}
+
+	incomplete := create(map[string]string{"Upload-Length": "100"}, bytes.Repeat([]byte("x"), 40))
+	unconcatenated := create(map[string]string{"Upload-Length": "10", "Upload-Concat": "partial"}, bytes.Repeat([]byte("y"), 10))
+	complete := create(map[string]string{"Upload-Length": "10"}, bytes.Repeat([]byte("z"), 10))
+	busy := create(map[string]string{"Upload-Length": "100"}, nil)
+	<-service.processed
+
+	// A request writing to an upload holds its lock and does not let go in time
+	lock, err := h.composer.Locker.NewLock(busy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := lock.Lock(ctx, func() {}); err != nil {
+		t.Fatal(err)
+	}
+	defer lock.Unlock()
+
+	// A negative TTL puts every upload past it
+	h.tusConfig.UploadTTL = -time.Hour
+	expired, err := h.expireAbandonedUploads(ctx)
+	if err != nil {
+		t.Fatalf("expireAbandonedUploads() error = %v", err)
+	}
+	if expired != 2 {
+		t.Fatalf("expireAbandonedUploads() = %d, want 2", expired)
+	}
+
+	tests := []struct {
+		name     string
+		uploadID string
+		wantKept bool
+	}{
+		{name: "incomplete upload", uploadID: incomplete},
+		{name: "partial upload never concatenated", uploadID: unconcatenated},
+		{name: "complete upload", uploadID: complete, wantKept: true},
+		{name: "upload locked by a request", uploadID: busy, wantKept: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objectKey, _, _ := strings.Cut(tt.uploadID, "+")
+			if _, kept := storage.object(objectKey + tusInfoSuffix); kept != tt.wantKept {
+				t.Fatalf("upload info kept = %v, want %v", kept, tt.wantKept)
+			}
+		})
+	}
+}
+
+func TestPeriodicJobsStopOnDrain(t *testing.T) {
+	h, _ := newTestHandler(t, &fakeService{}, nil, TusConfig{OrphanCleanupInterval: time.Hour, UploadTTL: time.Hour})
+	h.stopCompletions = make(chan struct{})
+	h.completionsDone = make(chan struct{})
+	close(h.completionsDone)
+	h.jobsCtx, h.cancelJobs = context.WithCancel(context.Background())
+
+	h.startPeriodicJob(h.runOrphanCleanup)
+	h.startPeriodicJob(h.runUploadExpiry)
+	h.startPeriodicJob(h.runExportJobExpiry)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := h.Drain(ctx); err != nil {
+		t.Fatalf("Drain() error = %v, want the periodic jobs to stop", err)
+	}
+}
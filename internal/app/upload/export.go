@@ -0,0 +1,200 @@
+package upload
+
+import (
+	"archive/zip"
+	"e-document-backend/internal/util"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/minio/minio-go/v7"
+	"github.com/rs/zerolog/log"
+)
+
+// exportManifestEntry is the ZIP entry describing the contents of an account export
+const exportManifestEntry = "manifest.json"
+
+// exportLimiter bounds how many exports run at once and allows one export per user
+type exportLimiter struct {
+	mu     sync.Mutex
+	max    int
+	active map[string]bool
+}
+
+// newExportLimiter creates a limiter; max <= 0 means one export at a time
+func newExportLimiter(max int) *exportLimiter {
+	if max <= 0 {
+		max = 1
+	}
+	return &exportLimiter{
+		max:    max,
+		active: make(map[string]bool),
+	}
+}
+
+// acquire reserves an export slot for the user; it returns false if none is available
+func (l *exportLimiter) acquire(userID string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.active[userID] || len(l.active) >= l.max {
+		return false
+	}
+	l.active[userID] = true
+	return true
+}
+
+// release frees the user's export slot
+func (l *exportLimiter) release(userID string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.active, userID)
+}
+
+// ExportManifest describes an account export archive
+type ExportManifest struct {
+	OwnerID    string                `json:"owner_id"`
+	ExportedAt time.Time             `json:"exported_at"`
+	FileCount  int                   `json:"file_count"`
+	TotalSize  int64                 `json:"total_size"`
+	Files      []ExportManifestFile  `json:"files"`
+	Errors     []ExportManifestError `json:"errors,omitempty"`
+}
+
+// ExportManifestFile describes one file in an account export
+type ExportManifestFile struct {
+	Path         string    `json:"path"`
+	DocumentID   uuid.UUID `json:"document_id"`
+	AttachmentID uuid.UUID `json:"attachment_id"`
+	FileName     string    `json:"file_name"`
+	FileType     string    `json:"file_type"`
+	FileSize     int64     `json:"file_size"`
+	Version      int       `json:"version"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// ExportManifestError describes a file that could not be exported
+type ExportManifestError struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
+// ExportArchive godoc
+// @Summary		Export all documents as a ZIP
+// @Description	Streams the authenticated user's entire storage tree as a ZIP archive with folder structure preserved and a manifest.json describing every file. Only one export per user runs at a time and the total size is capped
+// @Tags		Storage
+// @Produce		application/zip
+// @Security	BearerAuth
+// @Success		200	{file}		binary
+// @Failure		401	{object}	util.ErrorEnvelope
+// @Failure		404	{object}	util.ErrorEnvelope
+// @Failure		413	{object}	util.ErrorEnvelope
+// @Failure		429	{object}	util.ErrorEnvelope
+// @Failure		500	{object}	util.ErrorEnvelope
+// @Router		/v1/storage/export/archive [get]
+func (h *Handler) ExportArchive(c echo.Context) error {
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+	ownerID, err := uuid.Parse(userID)
+	if err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid user ID", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	if !h.exports.acquire(userID) {
+		return util.HandleError(c, util.ErrorResponse("Export already in progress", util.RATE_LIMITED, http.StatusTooManyRequests, "Too many exports are running, please try again later"))
+	}
+	defer h.exports.release(userID)
+
+	attachments, err := h.service.GetOwnerAttachments(c.Request().Context(), ownerID)
+	if err != nil {
+		log.Error().Err(err).Str("owner_id", userID).Msg("Failed to get owner attachments")
+		return util.HandleError(c, util.ErrorResponse("Failed to get storage contents", util.INTERNAL_SERVER_ERROR, 500, "Could not retrieve storage contents"))
+	}
+
+	if len(attachments) == 0 {
+		return util.HandleError(c, util.ErrorResponse("Nothing to export", util.NOT_FOUND, 404, "No files found in your storage"))
+	}
+
+	var totalSize int64
+	for _, attachment := range attachments {
+		totalSize += attachment.FileSize
+	}
+	if h.tusConfig.ExportMaxBytes > 0 && totalSize > h.tusConfig.ExportMaxBytes {
+		return util.HandleError(c, util.NewPayloadTooLargeError("Export too large", fmt.Sprintf("Your storage holds %d bytes, the export limit is %d bytes; download folders individually instead", totalSize, h.tusConfig.ExportMaxBytes)))
+	}
+
+	manifest := ExportManifest{
+		OwnerID:    userID,
+		ExportedAt: time.Now().UTC(),
+		Files:      make([]ExportManifestFile, 0, len(attachments)),
+	}
+
+	// ZIP is built on the fly so its size is unknown up front: stream it chunked
+	c.Response().Header().Set("Content-Type", "application/zip")
+	c.Response().Header().Set("Content-Disposition", encodeFilename(fmt.Sprintf("export-%s.zip", manifest.ExportedAt.Format("20060102-150405"))))
+	c.Response().Header().Set("Transfer-Encoding", "chunked")
+	c.Response().WriteHeader(200)
+
+	zipWriter := zip.NewWriter(c.Response().Writer)
+	defer zipWriter.Close()
+
+	usedNames := map[string]bool{exportManifestEntry: true}
+	for _, attachment := range attachments {
+		entryName := uniqueEntryName(zipEntryName(attachment), usedNames)
+
+		if _, err := h.minioClient.StatObject(c.Request().Context(), h.bucket, attachment.FilePath, minio.StatObjectOptions{}); err != nil {
+			manifest.Errors = append(manifest.Errors, ExportManifestError{Path: entryName, Error: "not found in storage"})
+			continue
+		}
+
+		if err := h.addObjectToZip(c.Request().Context(), zipWriter, entryName, attachment.FilePath); err != nil {
+			log.Error().Err(err).
+				Str("file_path", attachment.FilePath).
+				Str("entry", entryName).
+				Msg("Failed to add file to export")
+			manifest.Errors = append(manifest.Errors, ExportManifestError{Path: entryName, Error: "could not be read from storage"})
+			continue
+		}
+
+		manifest.Files = append(manifest.Files, ExportManifestFile{
+			Path:         entryName,
+			DocumentID:   attachment.DocumentID,
+			AttachmentID: attachment.ID,
+			FileName:     attachment.FileName,
+			FileType:     attachment.FileType,
+			FileSize:     attachment.FileSize,
+			Version:      attachment.Version,
+			CreatedAt:    attachment.CreatedAt,
+		})
+		manifest.TotalSize += attachment.FileSize
+	}
+	manifest.FileCount = len(manifest.Files)
+
+	// Manifest goes last so it can list what actually made it into the archive
+	writer, err := zipWriter.Create(exportManifestEntry)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create export manifest entry")
+		return nil
+	}
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(manifest); err != nil {
+		log.Error().Err(err).Msg("Failed to write export manifest")
+	}
+
+	log.Info().
+		Str("owner_id", userID).
+		Int("files_count", manifest.FileCount).
+		Int("errors_count", len(manifest.Errors)).
+		Int64("total_size", manifest.TotalSize).
+		Msg("Account export completed")
+
+	return nil
+}
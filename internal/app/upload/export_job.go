@@ -0,0 +1,437 @@
+package upload
+
+import (
+	"archive/zip"
+	"context"
+	"e-document-backend/internal/domain"
+	"e-document-backend/internal/pkg/storage"
+	"e-document-backend/internal/util"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/minio/minio-go/v7"
+	"github.com/rs/zerolog/log"
+)
+
+// exportJobPrefix is the object key prefix of background export archives
+// The orphan cleanup only looks at the bucket root, so archives are removed by expiry alone
+const exportJobPrefix = "exports/"
+
+// exportJobPartSize is the multipart chunk size used to stream an archive of unknown size into MinIO
+const exportJobPartSize = 16 << 20 // 16 MiB
+
+// exportJobProgressInterval bounds how often a running job saves its progress
+const exportJobProgressInterval = 2 * time.Second
+
+// exportJobExpiryInterval is how often expired archives and abandoned jobs are looked for
+const exportJobExpiryInterval = time.Hour
+
+// exportJobStaleAfter is how long an unfinished job may go without progress before it is
+// considered abandoned by a server that stopped while running it
+const exportJobStaleAfter = time.Hour
+
+// maxPresignExpiry is the longest expiry MinIO accepts for a presigned URL
+const maxPresignExpiry = 7 * 24 * time.Hour
+
+// StartFolderExport godoc
+// @Summary		Export a folder as ZIP in the background
+// @Description	Starts a job that builds a ZIP of the folder (including subfolders) in storage, for folders too large to download directly. Poll GET /v1/exports/{jobId} for progress and the download link. Only one export per user runs at a time and the total size is capped
+// @Tags		Storage
+// @Produce		json
+// @Security	BearerAuth
+// @Param		id	path		string	true	"Folder ID"
+// @Success		202	{object}	util.Response{data=domain.ExportJob}
+// @Failure		400	{object}	util.ErrorEnvelope
+// @Failure		401	{object}	util.ErrorEnvelope
+// @Failure		403	{object}	util.ErrorEnvelope
+// @Failure		404	{object}	util.ErrorEnvelope
+// @Failure		413	{object}	util.ErrorEnvelope
+// @Failure		429	{object}	util.ErrorEnvelope
+// @Failure		500	{object}	util.ErrorEnvelope
+// @Router		/v1/storage/folders/{id}/export [post]
+func (h *Handler) StartFolderExport(c echo.Context) error {
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+	requesterID, err := uuid.Parse(userID)
+	if err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid user ID", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	folderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid folder ID", util.INVALID_INPUT, 400, "The provided folder ID is not a valid UUID"))
+	}
+
+	folder, err := h.service.GetFolder(c.Request().Context(), folderID, requesterID)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	attachments, err := h.service.GetFolderAttachments(c.Request().Context(), folderID)
+	if err != nil {
+		log.Error().Err(err).Str("folder_id", folderID.String()).Msg("Failed to get folder attachments")
+		return util.HandleError(c, util.ErrorResponse("Failed to get folder contents", util.INTERNAL_SERVER_ERROR, 500, "Could not retrieve folder contents"))
+	}
+
+	if len(attachments) == 0 {
+		return util.HandleError(c, util.ErrorResponse("Empty folder", util.NOT_FOUND, 404, "No files found in this folder"))
+	}
+
+	var totalSize int64
+	for _, attachment := range attachments {
+		totalSize += attachment.FileSize
+	}
+	if h.tusConfig.ExportMaxBytes > 0 && totalSize > h.tusConfig.ExportMaxBytes {
+		return util.HandleError(c, util.NewPayloadTooLargeError("Export too large", fmt.Sprintf("The folder holds %d bytes, the export limit is %d bytes; export its subfolders individually instead", totalSize, h.tusConfig.ExportMaxBytes)))
+	}
+
+	// The slot is shared with the account export and held until the job finishes
+	if !h.exports.acquire(userID) {
+		return util.HandleError(c, util.ErrorResponse("Export already in progress", util.RATE_LIMITED, http.StatusTooManyRequests, "Too many exports are running, please try again later"))
+	}
+
+	job := &domain.ExportJob{
+		OwnerID:    requesterID,
+		FolderID:   folderID,
+		FileName:   folder.Name + ".zip",
+		Status:     domain.ExportJobPending,
+		TotalFiles: len(attachments),
+		TotalBytes: totalSize,
+	}
+	if err := h.service.CreateExportJob(c.Request().Context(), job); err != nil {
+		h.exports.release(userID)
+		return util.HandleError(c, err)
+	}
+
+	// The worker gets its own copy so the response below does not race with its progress updates
+	worker := *job
+	h.background.Add(1)
+	go func() {
+		defer h.background.Done()
+		defer h.exports.release(userID)
+		h.runExportJob(&worker, attachments)
+	}()
+
+	log.Info().
+		Str("job_id", job.ID.String()).
+		Str("folder_id", folderID.String()).
+		Int("files_count", job.TotalFiles).
+		Int64("total_size", job.TotalBytes).
+		Msg("Folder export job started")
+
+	return util.OKResponse(c, "Export started", job, http.StatusAccepted)
+}
+
+// GetExportJob godoc
+// @Summary		Get a background export job
+// @Description	Reports the status and progress of a folder export started by the authenticated user. Once completed, download_url is a presigned link to the archive, valid for the configured URL lifetime or until the archive expires, whichever comes first
+// @Tags		Storage
+// @Produce		json
+// @Security	BearerAuth
+// @Param		jobId	path		string	true	"Export job ID"
+// @Success		200	{object}	util.Response{data=domain.ExportJobResponse}
+// @Failure		400	{object}	util.ErrorEnvelope
+// @Failure		401	{object}	util.ErrorEnvelope
+// @Failure		403	{object}	util.ErrorEnvelope
+// @Failure		404	{object}	util.ErrorEnvelope
+// @Failure		500	{object}	util.ErrorEnvelope
+// @Router		/v1/exports/{jobId} [get]
+func (h *Handler) GetExportJob(c echo.Context) error {
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+	requesterID, err := uuid.Parse(userID)
+	if err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid user ID", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	jobID, err := uuid.Parse(c.Param("jobId"))
+	if err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid export job ID", util.INVALID_INPUT, 400, "The provided export job ID is not a valid UUID"))
+	}
+
+	job, err := h.service.GetExportJob(c.Request().Context(), jobID, requesterID)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	// The expiry job may not have run yet; an archive past its expiry is never handed out
+	if job.Status == domain.ExportJobCompleted && job.ExpiresAt != nil && time.Now().After(*job.ExpiresAt) {
+		job.Status = domain.ExportJobExpired
+	}
+
+	response := domain.ExportJobResponse{ExportJob: job}
+	if job.Status == domain.ExportJobCompleted && job.ObjectKey != nil {
+		downloadURL, err := h.presignExport(c.Request().Context(), job)
+		if err != nil {
+			log.Error().Err(err).Str("job_id", jobID.String()).Msg("Failed to presign export archive")
+			return util.HandleError(c, util.ErrorResponse("Failed to create download link", util.STORAGE_ERROR, 500, "Could not create a download link for the archive"))
+		}
+		response.DownloadURL = downloadURL
+	}
+
+	return util.OKResponse(c, "Export job retrieved", response)
+}
+
+// presignExport creates a download link for a finished archive that never outlives the archive
+func (h *Handler) presignExport(ctx context.Context, job *domain.ExportJob) (string, error) {
+	expiry := h.tusConfig.ExportJobURLExpiry
+	if job.ExpiresAt != nil {
+		if remaining := time.Until(*job.ExpiresAt); remaining < expiry {
+			expiry = remaining
+		}
+	}
+	if expiry > maxPresignExpiry {
+		expiry = maxPresignExpiry
+	}
+	if expiry < time.Second {
+		expiry = time.Second
+	}
+
+	params := url.Values{}
+	params.Set("response-content-disposition", encodeFilename(job.FileName))
+
+	presignedURL, err := h.minioClient.PresignedGetObject(ctx, h.bucket, *job.ObjectKey, expiry, params)
+	if err != nil {
+		return "", err
+	}
+	return presignedURL.String(), nil
+}
+
+// runExportJob builds the folder archive into MinIO and records the outcome on the job
+// Drain cancels the job; its final state is still saved so it does not look like it is running
+func (h *Handler) runExportJob(job *domain.ExportJob, attachments []*FolderAttachment) {
+	ctx := h.jobsCtx
+	saveCtx := context.WithoutCancel(ctx)
+
+	objectKey := exportJobPrefix + job.ID.String() + ".zip"
+	job.Status = domain.ExportJobRunning
+	job.ObjectKey = &objectKey
+	if err := h.service.UpdateExportJob(ctx, job); err != nil {
+		log.Warn().Err(err).Str("job_id", job.ID.String()).Msg("Failed to mark export job running")
+	}
+
+	skipped, err := h.writeExportArchive(ctx, job, objectKey, attachments)
+	if err != nil {
+		h.removeExportObject(saveCtx, objectKey)
+
+		reason := "the archive could not be built, please try again"
+		if ctx.Err() != nil {
+			reason = "interrupted by a server shutdown, please try again"
+		}
+		job.Status = domain.ExportJobFailed
+		job.ObjectKey = nil
+		job.Error = &reason
+		if err := h.service.UpdateExportJob(saveCtx, job); err != nil {
+			log.Error().Err(err).Str("job_id", job.ID.String()).Msg("Failed to mark export job failed")
+		}
+
+		log.Error().Err(err).
+			Str("job_id", job.ID.String()).
+			Str("folder_id", job.FolderID.String()).
+			Int("processed_files", job.ProcessedFiles).
+			Msg("Folder export job failed")
+		return
+	}
+
+	now := time.Now()
+	job.Status = domain.ExportJobCompleted
+	job.ProcessedFiles = job.TotalFiles
+	job.CompletedAt = &now
+	if h.tusConfig.ExportJobTTL > 0 {
+		expiresAt := now.Add(h.tusConfig.ExportJobTTL)
+		job.ExpiresAt = &expiresAt
+	}
+	if skipped > 0 {
+		note := fmt.Sprintf("%d file(s) could not be included, see %s in the archive", skipped, downloadErrorsEntry)
+		job.Error = &note
+	}
+	if err := h.service.UpdateExportJob(saveCtx, job); err != nil {
+		log.Error().Err(err).Str("job_id", job.ID.String()).Msg("Failed to mark export job completed")
+		return
+	}
+
+	log.Info().
+		Str("job_id", job.ID.String()).
+		Str("folder_id", job.FolderID.String()).
+		Int("files_count", job.TotalFiles-skipped).
+		Int("errors_count", skipped).
+		Msg("Folder export job completed")
+}
+
+// writeExportArchive streams the ZIP into MinIO while it is built, so neither memory nor local disk
+// has to hold the whole archive; it returns how many files had to be left out
+func (h *Handler) writeExportArchive(ctx context.Context, job *domain.ExportJob, objectKey string, attachments []*FolderAttachment) (int, error) {
+	reader, writer := io.Pipe()
+
+	type buildResult struct {
+		skipped int
+		err     error
+	}
+	built := make(chan buildResult, 1)
+	go func() {
+		skipped, err := h.buildExportZip(ctx, job, writer, attachments)
+		writer.CloseWithError(err)
+		built <- buildResult{skipped: skipped, err: err}
+	}()
+
+	_, putErr := h.minioClient.PutObject(ctx, h.bucket, objectKey, reader, -1, minio.PutObjectOptions{
+		ContentType: "application/zip",
+		PartSize:    exportJobPartSize,
+	})
+	// Unblocks the builder if the upload stopped reading early
+	reader.CloseWithError(putErr)
+
+	result := <-built
+	if result.err != nil {
+		return 0, result.err
+	}
+	if putErr != nil {
+		return 0, fmt.Errorf("failed to store archive: %w", putErr)
+	}
+
+	return result.skipped, nil
+}
+
+// buildExportZip writes the attachments to w as a ZIP, saving the job's progress as it goes
+// Like DownloadFolder, files that cannot be read are listed in _download_errors.txt instead of
+// failing the whole export
+func (h *Handler) buildExportZip(ctx context.Context, job *domain.ExportJob, w io.Writer, attachments []*FolderAttachment) (int, error) {
+	zipWriter := zip.NewWriter(w)
+
+	var downloadErrors []string
+	usedNames := make(map[string]bool, len(attachments))
+	lastProgress := time.Now()
+
+	for i, attachment := range attachments {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+
+		entryName := uniqueEntryName(zipEntryName(attachment), usedNames)
+		if _, err := h.minioClient.StatObject(ctx, h.bucket, attachment.FilePath, minio.StatObjectOptions{}); err != nil {
+			log.Warn().Err(err).
+				Str("job_id", job.ID.String()).
+				Str("file_path", attachment.FilePath).
+				Str("entry", entryName).
+				Msg("Failed to stat object, excluding from export")
+			if storage.IsObjectNotFound(err) {
+				downloadErrors = append(downloadErrors, fmt.Sprintf("%s: %s: recorded in the database but missing from storage", entryName, util.STORAGE_OBJECT_MISSING))
+			} else {
+				downloadErrors = append(downloadErrors, fmt.Sprintf("%s: %s: could not be read from storage", entryName, util.STORAGE_ERROR))
+			}
+		} else if err := h.addObjectToZip(ctx, zipWriter, entryName, attachment.FilePath); err != nil {
+			log.Error().Err(err).
+				Str("job_id", job.ID.String()).
+				Str("file_path", attachment.FilePath).
+				Str("entry", entryName).
+				Msg("Failed to add file to export")
+			downloadErrors = append(downloadErrors, fmt.Sprintf("%s: %v", entryName, err))
+		}
+
+		job.ProcessedFiles = i + 1
+		if time.Since(lastProgress) >= exportJobProgressInterval {
+			if err := h.service.UpdateExportJob(ctx, job); err != nil {
+				log.Warn().Err(err).Str("job_id", job.ID.String()).Msg("Failed to save export job progress")
+			}
+			lastProgress = time.Now()
+		}
+	}
+
+	if len(downloadErrors) > 0 {
+		writer, err := zipWriter.Create(downloadErrorsEntry)
+		if err != nil {
+			return 0, fmt.Errorf("failed to create download errors entry: %w", err)
+		}
+		if _, err := io.WriteString(writer, "The following files could not be included in this archive:\n\n"+strings.Join(downloadErrors, "\n")+"\n"); err != nil {
+			return 0, fmt.Errorf("failed to write download errors entry: %w", err)
+		}
+	}
+
+	if err := zipWriter.Close(); err != nil {
+		return 0, fmt.Errorf("failed to finish archive: %w", err)
+	}
+
+	return len(downloadErrors), nil
+}
+
+// removeExportObject deletes an export archive from MinIO
+func (h *Handler) removeExportObject(ctx context.Context, objectKey string) {
+	if err := h.minioClient.RemoveObject(ctx, h.bucket, objectKey, minio.RemoveObjectOptions{}); err != nil {
+		log.Error().Err(err).Str("object_key", objectKey).Msg("Failed to remove export archive")
+	}
+}
+
+// runExportJobExpiry periodically removes archives past their retention period and fails jobs
+// whose server stopped before finishing them
+func (h *Handler) runExportJobExpiry(ctx context.Context) {
+	ticker := time.NewTicker(exportJobExpiryInterval)
+	defer ticker.Stop()
+
+	log.Info().
+		Dur("interval", exportJobExpiryInterval).
+		Dur("ttl", h.tusConfig.ExportJobTTL).
+		Msg("Starting export job expiry job")
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info().Msg("Stopped export job expiry job")
+			return
+		case <-ticker.C:
+		}
+
+		expired, err := h.expireExportJobs(ctx)
+		if err != nil {
+			log.Error().Err(err).Msg("Export archive expiry failed")
+		}
+
+		stale, err := h.service.FailStaleExportJobs(ctx, time.Now().Add(-exportJobStaleAfter), "interrupted: the server stopped before the export finished, please try again")
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to fail abandoned export jobs")
+		}
+
+		log.Info().
+			Int("expired", expired).
+			Int64("abandoned", stale).
+			Msg("Export job expiry completed")
+	}
+}
+
+// expireExportJobs removes the archives of completed jobs past their expiry and marks the jobs expired
+func (h *Handler) expireExportJobs(ctx context.Context) (int, error) {
+	jobs, err := h.service.GetExpiredExportJobs(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, job := range jobs {
+		if job.ObjectKey != nil {
+			err := h.minioClient.RemoveObject(ctx, h.bucket, *job.ObjectKey, minio.RemoveObjectOptions{})
+			if err != nil {
+				log.Error().Err(err).Str("job_id", job.ID.String()).Msg("Failed to remove expired export archive")
+				continue
+			}
+		}
+
+		job.Status = domain.ExportJobExpired
+		job.ObjectKey = nil
+		if err := h.service.UpdateExportJob(ctx, job); err != nil {
+			log.Error().Err(err).Str("job_id", job.ID.String()).Msg("Failed to mark export job expired")
+			continue
+		}
+		expired++
+	}
+
+	return expired, nil
+}
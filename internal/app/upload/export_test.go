@@ -0,0 +1,190 @@
+package upload
+
+import (
+	"archive/zip"
+	"bytes"
+	"e-document-backend/internal/domain"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+func newFolderAttachment(relativePath, fileName, filePath string, size int64) *FolderAttachment {
+	return &FolderAttachment{
+		DocumentAttachment: &domain.DocumentAttachment{
+			ID:         uuid.New(),
+			DocumentID: uuid.New(),
+			FileName:   fileName,
+			FilePath:   filePath,
+			FileSize:   size,
+			FileType:   "application/pdf",
+			Version:    1,
+			IsCurrent:  true,
+			CreatedAt:  time.Now(),
+		},
+		RelativePath: relativePath,
+	}
+}
+
+// serveExport runs ExportArchive for a user and returns the response
+func serveExport(h *Handler, userID uuid.UUID) *httptest.ResponseRecorder {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/v1/storage/export/archive", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set("user_id", userID.String())
+	_ = h.ExportArchive(c)
+	return rec
+}
+
+// readArchive returns the contents of every entry of a ZIP by name
+func readArchive(t *testing.T, body []byte) map[string][]byte {
+	t.Helper()
+	reader, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		t.Fatalf("response is not a ZIP archive: %v", err)
+	}
+	entries := make(map[string][]byte)
+	for _, file := range reader.File {
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatalf("failed to open entry %s: %v", file.Name, err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("failed to read entry %s: %v", file.Name, err)
+		}
+		entries[file.Name] = data
+	}
+	return entries
+}
+
+func TestExportArchive(t *testing.T) {
+	ownerID := uuid.New()
+	attachments := []*FolderAttachment{
+		newFolderAttachment("", "readme.txt", "uploads/readme", 6),
+		newFolderAttachment("Finance", "budget.pdf", "uploads/budget", 6),
+		newFolderAttachment("Finance/2026", "q1.pdf", "uploads/q1", 5),
+		newFolderAttachment("Finance/2026", "q1.pdf", "uploads/q1-copy", 7),
+		newFolderAttachment("Legal", "contract.pdf", "uploads/contract", 8),
+		newFolderAttachment("Legal", "missing.pdf", "uploads/missing", 4),
+	}
+	objects := map[string][]byte{
+		"uploads/readme":    []byte("readme"),
+		"uploads/budget":    []byte("budget"),
+		"uploads/q1":        []byte("q1-v1"),
+		"uploads/q1-copy":   []byte("q1-copy"),
+		"uploads/contract":  []byte("contract"),
+		"uploads/unrelated": []byte("not exported"),
+	}
+	svc := &fakeService{attachments: map[uuid.UUID][]*FolderAttachment{ownerID: attachments}}
+	h, _ := newTestHandler(t, svc, objects, TusConfig{ExportMaxConcurrent: 1})
+
+	rec := serveExport(h, ownerID)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/zip" {
+		t.Fatalf("Content-Type = %q, want application/zip", ct)
+	}
+
+	entries := readArchive(t, rec.Body.Bytes())
+
+	wantFiles := map[string]string{
+		"readme.txt":              "readme",
+		"Finance/budget.pdf":      "budget",
+		"Finance/2026/q1.pdf":     "q1-v1",
+		"Finance/2026/q1 (1).pdf": "q1-copy",
+		"Legal/contract.pdf":      "contract",
+		exportManifestEntry:       "",
+	}
+	for name, content := range wantFiles {
+		data, ok := entries[name]
+		if !ok {
+			t.Errorf("archive is missing %s", name)
+			continue
+		}
+		if name != exportManifestEntry && string(data) != content {
+			t.Errorf("%s = %q, want %q", name, data, content)
+		}
+	}
+	if len(entries) != len(wantFiles) {
+		names := make([]string, 0, len(entries))
+		for name := range entries {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		t.Fatalf("archive entries = %v, want %d entries", names, len(wantFiles))
+	}
+
+	var manifest ExportManifest
+	if err := json.Unmarshal(entries[exportManifestEntry], &manifest); err != nil {
+		t.Fatalf("manifest is not valid JSON: %v", err)
+	}
+	if manifest.OwnerID != ownerID.String() {
+		t.Errorf("manifest owner = %s, want %s", manifest.OwnerID, ownerID)
+	}
+	if manifest.FileCount != 5 || len(manifest.Files) != 5 {
+		t.Errorf("manifest lists %d files (file_count %d), want 5", len(manifest.Files), manifest.FileCount)
+	}
+	if manifest.TotalSize != 32 {
+		t.Errorf("manifest total size = %d, want 32", manifest.TotalSize)
+	}
+	for _, file := range manifest.Files {
+		if _, ok := entries[file.Path]; !ok {
+			t.Errorf("manifest lists %s, which is not in the archive", file.Path)
+		}
+	}
+	if len(manifest.Errors) != 1 || manifest.Errors[0].Path != "Legal/missing.pdf" {
+		t.Errorf("manifest errors = %+v, want Legal/missing.pdf only", manifest.Errors)
+	}
+}
+
+func TestExportArchiveGuards(t *testing.T) {
+	ownerID := uuid.New()
+	emptyID := uuid.New()
+	svc := &fakeService{attachments: map[uuid.UUID][]*FolderAttachment{
+		ownerID: {newFolderAttachment("Finance", "budget.pdf", "uploads/budget", 6)},
+	}}
+	objects := map[string][]byte{"uploads/budget": []byte("budget")}
+
+	tests := []struct {
+		name       string
+		config     TusConfig
+		userID     uuid.UUID
+		busy       bool
+		wantStatus int
+	}{
+		{name: "within the size limit", config: TusConfig{ExportMaxBytes: 6}, userID: ownerID, wantStatus: http.StatusOK},
+		{name: "over the size limit", config: TusConfig{ExportMaxBytes: 5}, userID: ownerID, wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "export already running", config: TusConfig{}, userID: ownerID, busy: true, wantStatus: http.StatusTooManyRequests},
+		{name: "nothing to export", config: TusConfig{}, userID: emptyID, wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _ := newTestHandler(t, svc, objects, tt.config)
+			if tt.busy {
+				h.exports.acquire(tt.userID.String())
+			}
+
+			rec := serveExport(h, tt.userID)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+
+			// The slot is released once the export ends
+			if !tt.busy && !h.exports.acquire(tt.userID.String()) {
+				t.Fatal("export slot was not released")
+			}
+		})
+	}
+}
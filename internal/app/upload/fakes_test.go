@@ -0,0 +1,320 @@
+package upload
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+const testBucket = "documents"
+
+// fakeStorage is an S3 endpoint holding objects in memory, enough for the object reads,
+// writes and deletes the handler makes and the multipart uploads of the tusd S3 store
+type fakeStorage struct {
+	mu       sync.Mutex
+	objects  map[string][]byte
+	uploads  map[string]map[int32][]byte // multipart upload ID -> part number -> data
+	endpoint string                      // host:port of the server
+}
+
+// newFakeStorage starts a fake S3 server with the given objects and returns a client for it
+func newFakeStorage(t *testing.T, objects map[string][]byte) (*fakeStorage, *minio.Client) {
+	t.Helper()
+	s := &fakeStorage{objects: make(map[string][]byte), uploads: make(map[string]map[int32][]byte)}
+	for key, data := range objects {
+		s.objects[key] = data
+	}
+
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+
+	endpoint, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.endpoint = endpoint.Host
+	client, err := minio.New(endpoint.Host, &minio.Options{
+		Creds:  credentials.NewStaticV4("access", "secret", ""),
+		Region: "us-east-1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, client
+}
+
+func (s *fakeStorage) object(key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.objects[key]
+	return data, ok
+}
+
+func (s *fakeStorage) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, "/"+testBucket+"/")
+	if key == r.URL.Path || key == "" {
+		if r.Method == http.MethodPost && r.URL.Query().Has("delete") {
+			s.deleteObjects(w, r)
+			return
+		}
+		if r.Method == http.MethodGet && r.URL.Query().Get("list-type") == "2" {
+			s.listObjects(w)
+			return
+		}
+		// Other bucket-level requests: the bucket always exists
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	query := r.URL.Query()
+	switch {
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		s.createMultipartUpload(w, key)
+		return
+	case query.Has("uploadId"):
+		s.serveMultipartUpload(w, r, key, query.Get("uploadId"))
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		s.mu.Lock()
+		s.objects[key] = data
+		s.mu.Unlock()
+		w.Header().Set("ETag", etag(data))
+		w.WriteHeader(http.StatusOK)
+	case http.MethodDelete:
+		s.mu.Lock()
+		delete(s.objects, key)
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodHead, http.MethodGet:
+		data, ok := s.object(key)
+		if !ok {
+			w.Header().Set("x-minio-error-code", "NoSuchKey")
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusNotFound)
+			if r.Method == http.MethodGet {
+				_, _ = io.WriteString(w, `<?xml version="1.0" encoding="UTF-8"?><Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>`)
+			}
+			return
+		}
+		w.Header().Set("ETag", etag(data))
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		w.Header().Set("Content-Type", "application/octet-stream")
+		http.ServeContent(w, r, "", time.Time{}, strings.NewReader(string(data)))
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// writeS3Error answers with an S3 error document the AWS SDK and minio-go both understand
+func writeS3Error(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("x-minio-error-code", code)
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	_, _ = fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?><Error><Code>%s</Code><Message>%s</Message></Error>`, code, code)
+}
+
+func writeXML(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/xml")
+	_ = xml.NewEncoder(w).Encode(v)
+}
+
+func (s *fakeStorage) createMultipartUpload(w http.ResponseWriter, key string) {
+	uploadID := uuid.NewString()
+	s.mu.Lock()
+	s.uploads[uploadID] = make(map[int32][]byte)
+	s.mu.Unlock()
+
+	writeXML(w, struct {
+		XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+		Bucket   string
+		Key      string
+		UploadId string
+	}{Bucket: testBucket, Key: key, UploadId: uploadID})
+}
+
+// serveMultipartUpload handles part uploads and copies, part listings, completion and aborts
+func (s *fakeStorage) serveMultipartUpload(w http.ResponseWriter, r *http.Request, key, uploadID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	parts, ok := s.uploads[uploadID]
+	if !ok {
+		writeS3Error(w, http.StatusNotFound, "NoSuchUpload")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		partNumber, _ := strconv.Atoi(r.URL.Query().Get("partNumber"))
+		if source := r.Header.Get("x-amz-copy-source"); source != "" {
+			source, _ = url.PathUnescape(strings.TrimPrefix(source, "/"))
+			data := s.objects[strings.TrimPrefix(source, testBucket+"/")]
+			parts[int32(partNumber)] = data
+			writeXML(w, struct {
+				XMLName xml.Name `xml:"CopyPartResult"`
+				ETag    string
+			}{ETag: etag(data)})
+			return
+		}
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		parts[int32(partNumber)] = data
+		w.Header().Set("ETag", etag(data))
+	case http.MethodGet:
+		type part struct {
+			PartNumber int32
+			ETag       string
+			Size       int64
+		}
+		result := struct {
+			XMLName     xml.Name `xml:"ListPartsResult"`
+			Bucket      string
+			Key         string
+			UploadId    string
+			IsTruncated bool
+			Parts       []part `xml:"Part"`
+		}{Bucket: testBucket, Key: key, UploadId: uploadID}
+		for _, number := range sortedPartNumbers(parts) {
+			result.Parts = append(result.Parts, part{PartNumber: number, ETag: etag(parts[number]), Size: int64(len(parts[number]))})
+		}
+		writeXML(w, result)
+	case http.MethodPost:
+		var object []byte
+		for _, number := range sortedPartNumbers(parts) {
+			object = append(object, parts[number]...)
+		}
+		s.objects[key] = object
+		delete(s.uploads, uploadID)
+		writeXML(w, struct {
+			XMLName xml.Name `xml:"CompleteMultipartUploadResult"`
+			Bucket  string
+			Key     string
+			ETag    string
+		}{Bucket: testBucket, Key: key, ETag: etag(object)})
+	case http.MethodDelete:
+		delete(s.uploads, uploadID)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func sortedPartNumbers(parts map[int32][]byte) []int32 {
+	numbers := make([]int32, 0, len(parts))
+	for number := range parts {
+		numbers = append(numbers, number)
+	}
+	slices.Sort(numbers)
+	return numbers
+}
+
+// listObjects lists every object in one page, all modified now
+func (s *fakeStorage) listObjects(w http.ResponseWriter) {
+	type content struct {
+		Key          string
+		LastModified string
+		ETag         string
+		Size         int64
+	}
+	result := struct {
+		XMLName     xml.Name `xml:"ListBucketResult"`
+		Name        string
+		IsTruncated bool
+		Contents    []content
+	}{Name: testBucket}
+
+	s.mu.Lock()
+	for key, data := range s.objects {
+		result.Contents = append(result.Contents, content{
+			Key:          key,
+			LastModified: time.Now().UTC().Format(time.RFC3339),
+			ETag:         etag(data),
+			Size:         int64(len(data)),
+		})
+	}
+	s.mu.Unlock()
+	slices.SortFunc(result.Contents, func(a, b content) int { return strings.Compare(a.Key, b.Key) })
+	writeXML(w, result)
+}
+
+func (s *fakeStorage) deleteObjects(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Objects []struct {
+			Key string
+		} `xml:"Object"`
+	}
+	if err := xml.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeS3Error(w, http.StatusBadRequest, "MalformedXML")
+		return
+	}
+
+	type deleted struct {
+		Key string
+	}
+	result := struct {
+		XMLName xml.Name  `xml:"DeleteResult"`
+		Deleted []deleted `xml:"Deleted"`
+	}{}
+	s.mu.Lock()
+	for _, object := range request.Objects {
+		delete(s.objects, object.Key)
+		result.Deleted = append(result.Deleted, deleted{Key: object.Key})
+	}
+	s.mu.Unlock()
+	writeXML(w, result)
+}
+
+func etag(data []byte) string {
+	sum := md5.Sum(data)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+// fakeService serves attachments from memory; other Service methods panic
+type fakeService struct {
+	Service
+	attachments map[uuid.UUID][]*FolderAttachment // by owner
+}
+
+func (s *fakeService) GetOwnerAttachments(ctx context.Context, ownerID uuid.UUID) ([]*FolderAttachment, error) {
+	return s.attachments[ownerID], nil
+}
+
+// newTestHandler builds a handler over a fake service and fake storage, without tusd
+func newTestHandler(t *testing.T, service Service, objects map[string][]byte, tusConfig TusConfig) (*Handler, *fakeStorage) {
+	t.Helper()
+	storage, client := newFakeStorage(t, objects)
+	return &Handler{
+		service:     service,
+		tusConfig:   tusConfig,
+		bucket:      testBucket,
+		minioClient: client,
+		exports:     newExportLimiter(tusConfig.ExportMaxConcurrent),
+	}, storage
+}
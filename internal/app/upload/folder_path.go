@@ -0,0 +1,122 @@
+package upload
+
+import (
+	"context"
+	"e-document-backend/internal/domain"
+	"e-document-backend/internal/util"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// maxFolderNameLength is the longest folder name, matching folders.name
+const maxFolderNameLength = 255
+
+// ensureFolderChain finds or creates each folder of folderNames below parentID, outermost first
+// Paths of new folders start at basePath; concurrent calls creating the same folders share them
+func (s *service) ensureFolderChain(ctx context.Context, tx pgx.Tx, ownerID uuid.UUID, parentID *uuid.UUID, basePath string, folderNames []string) ([]*domain.Folder, int, error) {
+	folders := make([]*domain.Folder, 0, len(folderNames))
+	created := 0
+	currentParentID := parentID
+	currentPath := basePath
+
+	for i, folderName := range folderNames {
+		// Build the path for this folder level
+		if currentPath == "" {
+			currentPath = folderName
+		} else {
+			currentPath = currentPath + "/" + folderName
+		}
+
+		// Determine if this is a root folder
+		// It's a root folder ONLY if:
+		// 1. It's the first folder in the path AND
+		// 2. No parent folder was given
+		isRootFolder := i == 0 && parentID == nil
+
+		// Try to find existing folder
+		folder, err := s.repo.FindFolderByNameAndParent(ctx, tx, folderName, currentParentID, ownerID)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		if folder == nil {
+			// Create new folder
+			folder = &domain.Folder{
+				Name:           folderName,
+				Path:           currentPath,
+				IsRootFolder:   isRootFolder,
+				ParentFolderID: currentParentID,
+				OwnerID:        ownerID,
+			}
+
+			// A concurrent upload may create the same folder first; it is reused then
+			isNew, err := s.repo.CreateFolder(ctx, tx, folder)
+			if err != nil {
+				return nil, 0, err
+			}
+
+			if isNew {
+				created++
+				log.Info().
+					Str("folder_name", folderName).
+					Str("path", currentPath).
+					Bool("is_root", isRootFolder).
+					Msg("Created new folder")
+			}
+		}
+
+		folders = append(folders, folder)
+		currentParentID = &folder.ID
+	}
+
+	return folders, created, nil
+}
+
+// folderBasePath returns the path that new folders below parentID start at: the parent folder's
+// own path, or "" at the root. The parent must belong to the owner
+func (s *service) folderBasePath(ctx context.Context, ownerID uuid.UUID, parentID *uuid.UUID) (string, error) {
+	if parentID == nil {
+		return "", nil
+	}
+	parent, err := s.GetFolder(ctx, *parentID, ownerID)
+	if err != nil {
+		return "", err
+	}
+	return parent.Path, nil
+}
+
+// EnsureFolderPath makes sure every folder of path exists below the parent folder (or at the
+// root) in one transaction, creating only the missing ones
+func (s *service) EnsureFolderPath(ctx context.Context, ownerID uuid.UUID, req domain.EnsureFolderPathRequest) (*domain.EnsureFolderPathResult, error) {
+	folderNames, err := parsePath(req.Path)
+	if err != nil {
+		return nil, util.NewInvalidInputError("path", err.Error())
+	}
+	if len(folderNames) == 0 {
+		return nil, util.NewInvalidInputError("path", "must name at least one folder")
+	}
+
+	basePath, err := s.folderBasePath(ctx, ownerID, req.ParentFolderID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &domain.EnsureFolderPathResult{}
+	err = s.repo.WithTx(ctx, func(tx pgx.Tx) error {
+		folders, created, err := s.ensureFolderChain(ctx, tx, ownerID, req.ParentFolderID, basePath, folderNames)
+		if err != nil {
+			return err
+		}
+		result.Folders = folders
+		result.Created = created
+		result.FolderID = folders[len(folders)-1].ID
+		return nil
+	})
+	if err != nil {
+		return nil, util.NewDatabaseError("create folder path", err)
+	}
+
+	return result, nil
+}
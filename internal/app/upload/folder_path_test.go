@@ -0,0 +1,64 @@
+package upload
+
+import (
+	"context"
+	"e-document-backend/internal/domain"
+	"e-document-backend/internal/platform/postgres/pgtest"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestFolderPathsBelowParent(t *testing.T) {
+	pool := pgtest.NewPool(t)
+	svc := NewService(NewPostgresRepository(pool))
+	ctx := context.Background()
+
+	ownerID := seedUploader(t, pool)
+	root, err := svc.EnsureFolderPath(ctx, ownerID, domain.EnsureFolderPathRequest{Path: "Projects/Alpha"})
+	if err != nil {
+		t.Fatalf("EnsureFolderPath() error = %v", err)
+	}
+	parentID := root.FolderID
+
+	// New folders continue the parent's path, whether an upload or the endpoint creates them
+	result, err := svc.ProcessUploadComplete(ctx, ProcessUploadParams{
+		RelativePath:   "2026/Q1/report.pdf",
+		ParentFolderID: &parentID,
+		OwnerID:        ownerID,
+		FilePath:       "uploads/report.pdf",
+		FileSize:       1024,
+		FileType:       "application/pdf",
+		UploadID:       uuid.NewString(),
+		OnDuplicate:    DuplicateRename,
+	})
+	if err != nil {
+		t.Fatalf("ProcessUploadComplete() error = %v", err)
+	}
+	ensured, err := svc.EnsureFolderPath(ctx, ownerID, domain.EnsureFolderPathRequest{Path: "2026/Q2", ParentFolderID: &parentID})
+	if err != nil {
+		t.Fatalf("EnsureFolderPath() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		folders  []*domain.Folder
+		wantPath []string
+	}{
+		{name: "upload", folders: result.Folders, wantPath: []string{"Projects/Alpha/2026", "Projects/Alpha/2026/Q1"}},
+		{name: "endpoint", folders: ensured.Folders, wantPath: []string{"Projects/Alpha/2026", "Projects/Alpha/2026/Q2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if len(tt.folders) != len(tt.wantPath) {
+				t.Fatalf("got %d folders, want %d", len(tt.folders), len(tt.wantPath))
+			}
+			for i, folder := range tt.folders {
+				if folder.Path != tt.wantPath[i] || folder.IsRootFolder {
+					t.Fatalf("folder %d = %q (root %v), want %q below the parent", i, folder.Path, folder.IsRootFolder, tt.wantPath[i])
+				}
+			}
+		})
+	}
+}
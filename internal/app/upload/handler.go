@@ -3,15 +3,23 @@ package upload
 import (
 	"archive/zip"
 	"context"
+	"e-document-backend/internal/domain"
+	"e-document-backend/internal/pkg/metrics"
+	"e-document-backend/internal/pkg/storage"
 	"e-document-backend/internal/util"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
@@ -32,9 +40,32 @@ import (
 type Handler struct {
 	service     Service
 	tusHandler  *tusd.UnroutedHandler
+	composer    *tusd.StoreComposer
 	tusConfig   TusConfig
 	bucket      string
 	minioClient *minio.Client
+	exports     *exportLimiter
+	events      *eventBroker
+	transport   *http.Transport
+	background  sync.WaitGroup // upload post-processing, webhook deliveries, export jobs and periodic jobs in flight
+	quota       QuotaChecker
+	scanner     Scanner // nil when malware scanning is disabled
+
+	// Drain closes stopCompletions so no new completion events are consumed;
+	// completionsDone is closed once handleCompleteUploads has returned
+	stopCompletions chan struct{}
+	completionsDone chan struct{}
+	stopOnce        sync.Once
+
+	// jobsCtx is cancelled by Drain so export jobs, webhook retries and the periodic cleanup jobs stop
+	// instead of holding up shutdown
+	jobsCtx    context.Context
+	cancelJobs context.CancelFunc
+}
+
+// QuotaChecker enforces storage quotas before an upload is accepted
+type QuotaChecker interface {
+	CheckUpload(ctx context.Context, ownerID string, size int64) error
 }
 
 // TusConfig holds tusd configuration
@@ -46,6 +77,61 @@ type TusConfig struct {
 	S3Bucket    string
 	S3UseSSL    bool
 	StorageDir  string // Local storage directory for file locker
+
+	StorageBaseDir string // StorageDir must lie inside this directory; empty allows any path
+	StorageDirMode string // octal permissions StorageDir is created with, e.g. "0755"
+
+	// Orphan cleanup: removes objects in the bucket that no attachment references
+	OrphanCleanupInterval time.Duration // 0 disables the periodic job
+	OrphanGracePeriod     time.Duration // objects younger than this are never removed
+
+	// Upload expiry: incomplete uploads older than this are terminated (0 disables)
+	UploadTTL time.Duration
+
+	// Account export: streams a user's whole storage tree as one ZIP
+	ExportMaxBytes      int64 // exports larger than this are refused; 0 means unlimited
+	ExportMaxConcurrent int   // exports running at the same time across all users
+
+	// Background folder export: the ZIP is built into MinIO and downloaded through a presigned URL
+	ExportJobTTL       time.Duration // finished archives are removed after this long; 0 keeps them
+	ExportJobURLExpiry time.Duration // lifetime of the presigned download URL
+
+	// Folder download: folders with more files or bytes than this are refused; 0 means unlimited
+	FolderZipMaxFiles int
+	FolderZipMaxBytes int64
+
+	// Outbound webhook fired after an upload is processed; empty URL disables it
+	WebhookURL         string
+	WebhookSecret      string // HMAC-SHA256 key for the X-Webhook-Signature header
+	WebhookTimeout     time.Duration
+	WebhookMaxAttempts int // values below 1 deliver once
+
+	// Deduplication: identical uploads point at one stored object (costs one extra read per upload)
+	DeduplicateUploads bool
+
+	// Malware scanning with ClamAV before an upload becomes an attachment
+	ScanEnabled  bool
+	ClamdAddress string // host:port of clamd
+	ScanTimeout  time.Duration
+	ScanFailOpen bool               // keep uploads when clamd is unreachable instead of rejecting them
+	ScanOversize ScanOversizePolicy // uploads larger than clamd's StreamMaxLength are rejected or kept unscanned
+
+	// Content type verification: uploads whose bytes contradict their declared type or extension are deleted
+	VerifyContentType bool
+
+	// What happens when an upload's file name is taken in its folder, unless the upload's
+	// on_duplicate metadata says otherwise
+	DuplicateStrategy DuplicateStrategy
+
+	// Metadata keys every upload must send besides owner_id and relative_path or filename
+	RequiredMetadataKeys []string
+
+	// Optional tusd hooks; pre-create and the completion pipeline always run
+	// PreFinishValidation checks metadata and quota before the last request is answered, so those
+	// rejections reach the client instead of the upload being deleted afterwards. Checksum, malware
+	// and content type checks read the whole object and always run in the completion pipeline
+	PreFinishValidation  bool
+	NotifyCreatedUploads bool // log every created upload
 }
 
 // LoadTusConfigFromEnv loads tusd configuration from environment variables
@@ -58,6 +144,45 @@ func LoadTusConfigFromEnv() TusConfig {
 		S3Bucket:    os.Getenv("MINIO_BUCKET"),
 		S3UseSSL:    os.Getenv("MINIO_USE_SSL") == "true",
 		StorageDir:  getEnvWithDefault("TUSD_STORAGE_DIR", "./tmp/tusd"),
+
+		StorageBaseDir: os.Getenv("TUSD_STORAGE_BASE_DIR"),
+		StorageDirMode: getEnvWithDefault("TUSD_STORAGE_DIR_MODE", "0755"),
+
+		OrphanCleanupInterval: getEnvAsDuration("ORPHAN_CLEANUP_INTERVAL", 6*time.Hour),
+		OrphanGracePeriod:     getEnvAsDuration("ORPHAN_GRACE_PERIOD", 24*time.Hour),
+
+		UploadTTL: getEnvAsDuration("TUSD_UPLOAD_TTL", 24*time.Hour),
+
+		ExportMaxBytes:      getEnvAsInt64("EXPORT_MAX_BYTES", 5<<30), // 5 GiB
+		ExportMaxConcurrent: int(getEnvAsInt64("EXPORT_MAX_CONCURRENT", 2)),
+
+		ExportJobTTL:       getEnvAsDuration("EXPORT_JOB_TTL", 24*time.Hour),
+		ExportJobURLExpiry: getEnvAsDuration("EXPORT_JOB_URL_EXPIRY", time.Hour),
+
+		FolderZipMaxFiles: int(getEnvAsInt64("FOLDER_ZIP_MAX_FILES", 5000)),
+		FolderZipMaxBytes: getEnvAsInt64("FOLDER_ZIP_MAX_BYTES", 2<<30), // 2 GiB
+
+		WebhookURL:         os.Getenv("WEBHOOK_URL"),
+		WebhookSecret:      os.Getenv("WEBHOOK_SECRET"),
+		WebhookTimeout:     getEnvAsDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+		WebhookMaxAttempts: int(getEnvAsInt64("WEBHOOK_MAX_ATTEMPTS", 5)),
+
+		DeduplicateUploads: getEnvWithDefault("UPLOAD_DEDUPLICATE", "true") == "true",
+
+		ScanEnabled:  os.Getenv("UPLOAD_SCAN_ENABLED") == "true",
+		ClamdAddress: getEnvWithDefault("CLAMD_ADDRESS", "localhost:3310"),
+		ScanTimeout:  getEnvAsDuration("UPLOAD_SCAN_TIMEOUT", 2*time.Minute),
+		ScanFailOpen: os.Getenv("UPLOAD_SCAN_FAIL_OPEN") == "true",
+		ScanOversize: ScanOversizePolicy(getEnvWithDefault("UPLOAD_SCAN_OVERSIZE", string(ScanOversizeReject))),
+
+		VerifyContentType: os.Getenv("UPLOAD_VERIFY_CONTENT_TYPE") == "true",
+
+		DuplicateStrategy: DuplicateStrategy(getEnvWithDefault("UPLOAD_DUPLICATE_STRATEGY", string(DuplicateRename))),
+
+		RequiredMetadataKeys: getEnvAsList("UPLOAD_REQUIRED_METADATA"),
+
+		PreFinishValidation:  getEnvWithDefault("UPLOAD_PRE_FINISH_VALIDATION", "true") == "true",
+		NotifyCreatedUploads: os.Getenv("UPLOAD_NOTIFY_CREATED") == "true",
 	}
 }
 
@@ -68,32 +193,175 @@ func getEnvWithDefault(key, defaultValue string) string {
 	return defaultValue
 }
 
+// getEnvAsDuration parses a duration (e.g. "30m", "6h") from env or returns a default value
+func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
+
+// getEnvAsInt64 parses an integer from env or returns a default value
+func getEnvAsInt64(key string, defaultValue int64) int64 {
+	if value := os.Getenv(key); value != "" {
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return n
+		}
+	}
+	return defaultValue
+}
+
+// getEnvAsList parses a comma-separated list from env, skipping empty items
+func getEnvAsList(key string) []string {
+	var items []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 // NewHandler creates a new upload handler with tusd integration
-func NewHandler(service Service, tusConfig TusConfig) (*Handler, error) {
+// Its periodic jobs, export jobs and webhook retries stop when ctx is cancelled or Drain is called
+// quota may be nil to accept uploads of any size
+func NewHandler(ctx context.Context, service Service, tusConfig TusConfig, quota QuotaChecker) (*Handler, error) {
+	strategy, err := parseDuplicateStrategy(string(tusConfig.DuplicateStrategy))
+	if err != nil {
+		return nil, fmt.Errorf("invalid UPLOAD_DUPLICATE_STRATEGY: %w", err)
+	}
+	tusConfig.DuplicateStrategy = strategy
+
+	oversize, err := parseScanOversizePolicy(string(tusConfig.ScanOversize))
+	if err != nil {
+		return nil, fmt.Errorf("invalid UPLOAD_SCAN_OVERSIZE: %w", err)
+	}
+	tusConfig.ScanOversize = oversize
+
 	h := &Handler{
 		service:   service,
+		quota:     quota,
 		tusConfig: tusConfig,
 		bucket:    tusConfig.S3Bucket,
+		exports:   newExportLimiter(tusConfig.ExportMaxConcurrent),
+		events:    newEventBroker(),
+
+		stopCompletions: make(chan struct{}),
+		completionsDone: make(chan struct{}),
 	}
+	h.jobsCtx, h.cancelJobs = context.WithCancel(ctx)
+
+	// Keep our own transport so idle connections can be closed on shutdown
+	transport, err := minio.DefaultTransport(tusConfig.S3UseSSL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create minio transport: %w", err)
+	}
+	h.transport = transport
 
 	// Initialize MinIO client
 	minioClient, err := minio.New(tusConfig.S3Endpoint, &minio.Options{
-		Creds:  credentials.NewStaticV4(tusConfig.S3AccessKey, tusConfig.S3SecretKey, ""),
-		Secure: tusConfig.S3UseSSL,
+		Creds:     credentials.NewStaticV4(tusConfig.S3AccessKey, tusConfig.S3SecretKey, ""),
+		Secure:    tusConfig.S3UseSSL,
+		Transport: transport,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create minio client: %w", err)
 	}
 	h.minioClient = minioClient
 
+	if tusConfig.ScanEnabled {
+		h.scanner = NewClamdScanner(tusConfig.ClamdAddress, tusConfig.ScanTimeout)
+		log.Info().
+			Str("clamd_address", tusConfig.ClamdAddress).
+			Bool("fail_open", tusConfig.ScanFailOpen).
+			Str("oversize", string(tusConfig.ScanOversize)).
+			Msg("Upload malware scanning enabled")
+	}
+
 	// Initialize tusd handler
 	if err := h.initTusHandler(); err != nil {
 		return nil, fmt.Errorf("failed to initialize tusd handler: %w", err)
 	}
 
+	// Start periodic reconciliation of orphaned objects
+	if tusConfig.OrphanCleanupInterval > 0 {
+		h.startPeriodicJob(h.runOrphanCleanup)
+	}
+
+	// Start periodic expiry of abandoned partial uploads
+	if tusConfig.UploadTTL > 0 {
+		h.startPeriodicJob(h.runUploadExpiry)
+	}
+
+	// Start periodic expiry of finished export archives and abandoned export jobs
+	h.startPeriodicJob(h.runExportJobExpiry)
+
 	return h, nil
 }
 
+// startPeriodicJob runs a periodic job until Drain cancels jobsCtx; Drain waits for it to return
+func (h *Handler) startPeriodicJob(run func(ctx context.Context)) {
+	h.background.Add(1)
+	go func() {
+		defer h.background.Done()
+		run(h.jobsCtx)
+	}()
+}
+
+// Ping verifies that the upload bucket is reachable and still exists
+func (h *Handler) Ping(ctx context.Context) error {
+	exists, err := h.minioClient.BucketExists(ctx, h.bucket)
+	if err != nil {
+		return fmt.Errorf("failed to reach MinIO: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("bucket %s does not exist", h.bucket)
+	}
+	return nil
+}
+
+// CloseEventStreams ends all open upload event streams so the HTTP server can shut down
+func (h *Handler) CloseEventStreams() {
+	h.events.close()
+}
+
+// Drain stops consuming upload completion events and cancels running export jobs, webhook retries and periodic
+// jobs, then waits for upload post-processing and webhook deliveries in flight to finish
+func (h *Handler) Drain(ctx context.Context) error {
+	h.stopOnce.Do(func() {
+		close(h.stopCompletions)
+		h.cancelJobs()
+	})
+
+	// No processing may be added to the wait group once Wait has started
+	select {
+	case <-h.completionsDone:
+	case <-ctx.Done():
+		return fmt.Errorf("upload completion listener still running: %w", ctx.Err())
+	}
+
+	done := make(chan struct{})
+	go func() {
+		h.background.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("upload processing still running: %w", ctx.Err())
+	}
+}
+
+// Close releases idle connections to MinIO
+func (h *Handler) Close() {
+	h.transport.CloseIdleConnections()
+	log.Info().Msg("Upload MinIO idle connections closed")
+}
+
 // initTusHandler initializes the tusd handler with S3 store
 func (h *Handler) initTusHandler() error {
 	// Create AWS config for MinIO
@@ -125,10 +393,12 @@ func (h *Handler) initTusHandler() error {
 	// Create S3 store for tusd
 	store := s3store.New(h.tusConfig.S3Bucket, s3Client)
 
-	// Create storage directory for file locker if it doesn't exist
-	if err := os.MkdirAll(h.tusConfig.StorageDir, 0755); err != nil {
-		return fmt.Errorf("failed to create storage directory: %w", err)
+	// Create storage directory for file locker if it doesn't exist, and check it is safe and writable
+	storageDir, err := prepareStorageDir(h.tusConfig.StorageDir, h.tusConfig.StorageBaseDir, h.tusConfig.StorageDirMode)
+	if err != nil {
+		return err
 	}
+	h.tusConfig.StorageDir = storageDir
 
 	// Create file locker for concurrent upload handling
 	locker := filelocker.New(h.tusConfig.StorageDir)
@@ -138,11 +408,25 @@ func (h *Handler) initTusHandler() error {
 	store.UseIn(composer)
 	locker.UseIn(composer)
 
-	tusHandler, err := tusd.NewUnroutedHandler(tusd.Config{
+	// The concatenation and termination extensions are advertised to clients and used for parallel uploads
+	if !composer.UsesConcater || !composer.UsesTerminater {
+		return fmt.Errorf("tusd store does not support concatenation and termination")
+	}
+	h.composer = composer
+
+	handlerConfig := tusd.Config{
+		BasePath:                h.filesPath(), // used by tusd to build the Location of new uploads
 		StoreComposer:           composer,
+		PreUploadCreateCallback: h.preUploadCreate,
 		NotifyCompleteUploads:   true,
+		NotifyCreatedUploads:    h.tusConfig.NotifyCreatedUploads,
 		RespectForwardedHeaders: true,
-	})
+	}
+	if h.tusConfig.PreFinishValidation {
+		handlerConfig.PreFinishResponseCallback = h.preFinishUpload
+	}
+
+	tusHandler, err := tusd.NewUnroutedHandler(handlerConfig)
 	if err != nil {
 		return fmt.Errorf("failed to create tusd unrouted handler: %w", err)
 	}
@@ -152,108 +436,282 @@ func (h *Handler) initTusHandler() error {
 	// Start goroutine to handle completed uploads
 	go h.handleCompleteUploads()
 
+	// tusd blocks creating uploads until each notification is received
+	if h.tusConfig.NotifyCreatedUploads {
+		go h.handleCreatedUploads()
+	}
+
 	log.Info().
 		Str("base_path", h.tusConfig.BasePath).
 		Str("bucket", h.tusConfig.S3Bucket).
+		Bool("pre_finish_validation", h.tusConfig.PreFinishValidation).
+		Bool("notify_created", h.tusConfig.NotifyCreatedUploads).
 		Msg("tusd handler initialized successfully")
 
 	return nil
 }
 
-// handleCompleteUploads processes completed uploads
+// preUploadCreate rejects uploads that would exceed the owner's storage quota, final uploads
+// that concatenate another user's partial uploads, and uploads refused by their duplicate strategy
+// Uploads with a deferred length are let through since their size is not known yet
+func (h *Handler) preUploadCreate(hook tusd.HookEvent) (tusd.HTTPResponse, tusd.FileInfoChanges, error) {
+	ownerID := hook.Upload.MetaData["owner_id"]
+
+	if hook.Upload.IsFinal {
+		if err := h.checkPartialUploadsOwner(hook.Context, ownerID, hook.Upload.PartialUploads); err != nil {
+			return tusd.HTTPResponse{}, tusd.FileInfoChanges{}, err
+		}
+	}
+
+	// Single uploads are refused before any bytes are sent when their metadata is invalid
+	// Partial uploads may omit it and final uploads inherit it, so both are checked on completion
+	if !hook.Upload.IsPartial && !hook.Upload.IsFinal {
+		if _, err := parseUploadMetadata(hook.Upload.MetaData, h.tusConfig.RequiredMetadataKeys); err != nil {
+			return tusd.HTTPResponse{}, tusd.FileInfoChanges{}, tusd.NewError("ERR_INVALID_METADATA", err.Error(), http.StatusBadRequest)
+		}
+	}
+
+	// Partial uploads carry no document of their own; the final upload is checked
+	if !hook.Upload.IsPartial {
+		if err := h.checkDuplicateFileName(hook.Context, hook.Upload); err != nil {
+			return tusd.HTTPResponse{}, tusd.FileInfoChanges{}, err
+		}
+	}
+
+	if h.quota == nil || hook.Upload.SizeIsDeferred {
+		return tusd.HTTPResponse{}, acceptUpload(hook.Upload), nil
+	}
+
+	if ownerID == "" {
+		return tusd.HTTPResponse{}, tusd.FileInfoChanges{}, tusd.NewError("ERR_MISSING_OWNER", "owner_id is required", http.StatusUnauthorized)
+	}
+
+	if err := h.quota.CheckUpload(hook.Context, ownerID, hook.Upload.Size); err != nil {
+		statusCode := http.StatusInternalServerError
+		if customErr, ok := err.(*util.CustomError); ok {
+			statusCode = customErr.StatusCode
+		}
+		log.Warn().Err(err).
+			Str("owner_id", ownerID).
+			Int64("size", hook.Upload.Size).
+			Msg("Upload rejected by quota check")
+		return tusd.HTTPResponse{}, tusd.FileInfoChanges{}, tusd.NewError("ERR_QUOTA_EXCEEDED", err.Error(), statusCode)
+	}
+
+	return tusd.HTTPResponse{}, acceptUpload(hook.Upload), nil
+}
+
+// uploadCreatedAtKey is the metadata key holding when an upload was created (Unix milliseconds)
+const uploadCreatedAtKey = "created_at"
+
+// acceptUpload counts an accepted upload and stamps its creation time into the metadata
+// so the upload duration can be measured once it completes
+func acceptUpload(upload tusd.FileInfo) tusd.FileInfoChanges {
+	if !upload.IsPartial {
+		metrics.UploadsStartedTotal.Inc()
+	}
+
+	// The changed metadata replaces the client's, so keep every existing key
+	metaData := make(tusd.MetaData, len(upload.MetaData)+1)
+	for key, value := range upload.MetaData {
+		metaData[key] = value
+	}
+	metaData[uploadCreatedAtKey] = strconv.FormatInt(time.Now().UnixMilli(), 10)
+
+	return tusd.FileInfoChanges{MetaData: metaData}
+}
+
+// uploadDuration returns the time since the upload was created, or false if the creation time is unknown
+func uploadDuration(upload tusd.FileInfo) (time.Duration, bool) {
+	createdAt, err := strconv.ParseInt(upload.MetaData[uploadCreatedAtKey], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return time.Since(time.UnixMilli(createdAt)), true
+}
+
+// handleCompleteUploads processes completed uploads until Drain stops it
 func (h *Handler) handleCompleteUploads() {
+	defer close(h.completionsDone)
+
 	log.Info().Msg("Starting to listen for completed uploads...")
 	for {
 		log.Debug().Msg("Waiting for upload completion event...")
-		event := <-h.tusHandler.CompleteUploads
+		var event tusd.HookEvent
+		select {
+		case <-h.stopCompletions:
+			log.Info().Msg("Stopped listening for completed uploads")
+			return
+		case event = <-h.tusHandler.CompleteUploads:
+		}
+
 		log.Info().
 			Str("upload_id", event.Upload.ID).
 			Int64("size", event.Upload.Size).
 			Msg("Received upload completion event")
-		go h.processCompletedUpload(event)
+		h.background.Add(1)
+		go func() {
+			defer h.background.Done()
+			h.processCompletedUpload(event)
+		}()
 	}
 }
 
 // processCompletedUpload handles the post-upload logic
+// Partial uploads are only parts of a parallel upload; the document is created for the final upload
 func (h *Handler) processCompletedUpload(event tusd.HookEvent) {
 	ctx := context.Background()
 	upload := event.Upload
 
-	log.Info().
-		Str("upload_id", upload.ID).
-		Int64("size", upload.Size).
-		Interface("metadata", upload.MetaData).
-		Msg("Processing completed upload")
-
-	// Extract metadata
-	relativePath := upload.MetaData["relative_path"]
-	ownerIDStr := upload.MetaData["owner_id"]
-	parentFolderIDStr := upload.MetaData["parent_folder_id"]
-	fileType := upload.MetaData["file_type"]
-	fileName := upload.MetaData["filename"]
-
-	// Validate required metadata
-	if ownerIDStr == "" {
-		log.Error().Str("upload_id", upload.ID).Msg("Missing owner_id in metadata")
+	if upload.IsPartial {
+		log.Debug().Str("upload_id", upload.ID).Msg("Partial upload completed, waiting for concatenation")
 		return
 	}
 
-	ownerID, err := uuid.Parse(ownerIDStr)
-	if err != nil {
-		log.Error().Err(err).Str("owner_id", ownerIDStr).Msg("Invalid owner_id format")
+	// tusd may deliver the same completion again (restart, retried request)
+	// If the check fails, the unique upload_id index still rejects a second attachment
+	if processed, err := h.service.IsUploadProcessed(ctx, upload.ID); err != nil {
+		log.Warn().Err(err).Str("upload_id", upload.ID).Msg("Failed to check whether upload was already processed")
+	} else if processed {
+		log.Debug().Str("upload_id", upload.ID).Msg("Upload already processed, skipping duplicate completion event")
 		return
 	}
 
-	// Parse parent_folder_id if provided
-	var parentFolderID *uuid.UUID
-	if parentFolderIDStr != "" {
-		if parsed, err := uuid.Parse(parentFolderIDStr); err == nil {
-			parentFolderID = &parsed
-		}
+	if upload.IsFinal {
+		h.resolveFinalUploadMetadata(ctx, &upload)
 	}
 
-	// Use relative_path if provided, otherwise use filename
-	if relativePath == "" && fileName != "" {
-		relativePath = fileName
-	}
+	// Every early return below counts as a failure unless outcome is set to "success"
+	outcome := "failure"
+	started := time.Now()
+	metrics.UploadsProcessing.Inc()
+	defer func() {
+		metrics.UploadsProcessing.Dec()
+		metrics.UploadsTotal.WithLabelValues(outcome).Inc()
+		metrics.UploadProcessingDuration.WithLabelValues(outcome).Observe(time.Since(started).Seconds())
+	}()
 
-	if relativePath == "" {
-		log.Error().Str("upload_id", upload.ID).Msg("Missing relative_path and filename in metadata")
-		return
-	}
+	log.Info().
+		Str("upload_id", upload.ID).
+		Int64("size", upload.Size).
+		Interface("metadata", upload.MetaData).
+		Msg("Processing completed upload")
 
-	// Build the MinIO file path (the actual location in S3)
-	// tusd stores files with the upload ID as the object key
-	filePath := upload.ID
+	filePath := uploadObjectKey(upload)
 
-	// If storage has prefix info, use it
-	if upload.Storage != nil {
-		if key, ok := upload.Storage["Key"]; ok {
-			filePath = key
+	// Metadata that cannot be trusted never becomes a document; the upload is removed so a
+	// client cannot retry it with the same bad path
+	meta, rejection := h.checkUploadMetadata(upload)
+
+	// The pre-finish hook already checked the quota before the client was answered
+	if rejection == nil && !h.tusConfig.PreFinishValidation {
+		rejection = h.checkUploadQuota(ctx, upload, meta)
+	}
+	if rejection == nil {
+		rejection = h.checkUploadContent(ctx, upload, meta)
+	}
+	if rejection != nil {
+		outcome = rejection.outcome
+		h.removeUploadObject(ctx, filePath)
+		if upload.IsFinal {
+			h.removePartialUploads(ctx, upload.PartialUploads)
 		}
+		return
 	}
 
+	ownerIDStr := meta.OwnerID.String()
+	relativePath := meta.RelativePath
+	fileType := meta.FileType
+
 	// Process the upload
 	params := ProcessUploadParams{
 		RelativePath:   relativePath,
-		ParentFolderID: parentFolderID,
-		OwnerID:        ownerID,
+		ParentFolderID: meta.ParentFolderID,
+		OwnerID:        meta.OwnerID,
 		FilePath:       filePath,
 		FileSize:       upload.Size,
 		FileType:       fileType,
 		UploadID:       upload.ID,
 	}
 
+	// An invalid strategy was refused when the upload was created, so this only falls back for
+	// uploads created before the strategy existed
+	if strategy, err := h.duplicateStrategy(upload.MetaData); err == nil {
+		params.OnDuplicate = strategy
+	} else {
+		params.OnDuplicate = h.tusConfig.DuplicateStrategy
+	}
+
+	// Point identical content at the object that is already stored
+	if h.tusConfig.DeduplicateUploads {
+		params.ContentHash, params.DuplicateOf = h.deduplicateUpload(ctx, filePath, upload.Size, meta.SHA256)
+	}
+
 	result, err := h.service.ProcessUploadComplete(ctx, params)
+	if errors.Is(err, ErrUploadAlreadyProcessed) {
+		// A concurrent event for the same upload won; its attachment references the stored object
+		outcome = "duplicate"
+		log.Debug().Str("upload_id", upload.ID).Msg("Upload already processed, skipping duplicate completion event")
+		return
+	}
+	if errors.Is(err, ErrDuplicateFileName) {
+		// Another upload took the name after this one was accepted
+		outcome = "name_conflict"
+		log.Warn().
+			Str("event", "upload_name_conflict").
+			Str("upload_id", upload.ID).
+			Str("owner_id", ownerIDStr).
+			Str("relative_path", relativePath).
+			Msg("Upload rejected, file name already exists in folder; removing stored object")
+		h.removeUploadObject(ctx, filePath)
+		if upload.IsFinal {
+			h.removePartialUploads(ctx, upload.PartialUploads)
+		}
+		return
+	}
 	if err != nil {
 		log.Error().Err(err).
 			Str("upload_id", upload.ID).
 			Str("relative_path", relativePath).
 			Msg("Failed to process upload")
+
+		// Transaction was rolled back, so nothing references the stored object anymore
+		h.removeUploadObject(ctx, filePath)
 		return
 	}
 
-	log.Info().
+	outcome = "success"
+	metrics.UploadBytesTotal.Add(float64(upload.Size))
+
+	processedLog := log.Info().Dur("processing_duration", time.Since(started))
+	if duration, ok := uploadDuration(upload); ok {
+		metrics.UploadDuration.Observe(duration.Seconds())
+		processedLog = processedLog.Dur("upload_duration", duration)
+	}
+
+	// The attachment references the existing object, so the new copy is no longer needed
+	if result.Attachment.FilePath != filePath {
+		log.Info().
+			Str("upload_id", upload.ID).
+			Str("existing_object", result.Attachment.FilePath).
+			Msg("Upload is a duplicate, reusing stored object")
+		h.removeUploadObject(ctx, filePath)
+	}
+
+	if upload.IsFinal {
+		h.removePartialUploads(ctx, upload.PartialUploads)
+	}
+
+	// Notify the owner's open event streams and downstream systems without blocking upload processing
+	completed := newUploadCompletedEvent(upload.ID, meta.OwnerID, result)
+	h.events.publish(meta.OwnerID.String(), completed)
+	h.background.Add(1)
+	go func() {
+		defer h.background.Done()
+		h.sendWebhook(h.jobsCtx, completed)
+	}()
+
+	processedLog.
 		Str("upload_id", upload.ID).
 		Str("document_id", result.Document.ID.String()).
 		Str("attachment_id", result.Attachment.ID.String()).
@@ -264,59 +722,66 @@ func (h *Handler) processCompletedUpload(event tusd.HookEvent) {
 // locationFixerWriter wraps http.ResponseWriter to fix Location header
 type locationFixerWriter struct {
 	http.ResponseWriter
-	req *http.Request
+	req       *http.Request
+	filesPath string // public path of the files endpoint, e.g. "/api/v1/upload/files/"
 }
 
 func (w *locationFixerWriter) WriteHeader(statusCode int) {
 	// Fix Location header for 201 Created responses
-	if statusCode == 201 && w.req.Method == "POST" {
+	if statusCode == http.StatusCreated && w.req.Method == http.MethodPost {
 		location := w.Header().Get("Location")
-		log.Info().
-			Str("original_location", location).
-			Msg("locationFixerWriter: Original Location header")
-
 		if location != "" {
-			var uploadID string
-
-			// Extract upload ID from location (could be absolute or relative URL)
-			if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
-				// Absolute URL - malformed like: http://localhost:5000uploadid
-				// Find ":5000" and extract everything after it
-				idx := strings.Index(location, ":5000")
-				if idx >= 0 {
-					// Everything after ":5000" is the upload ID
-					uploadID = location[idx+5:] // +5 to skip ":5000"
-				} else {
-					// Try normal URL parsing
-					if u, err := url.Parse(location); err == nil {
-						uploadID = strings.TrimPrefix(u.Path, "/")
-					}
-				}
-			} else {
-				// Relative URL
-				uploadID = strings.TrimPrefix(location, "/")
-			}
-
-			// Set corrected Location header (always relative)
-			if uploadID != "" {
-				fixedLocation := "/api/v1/upload/files/" + uploadID
-				w.Header().Set("Location", fixedLocation)
-				log.Info().
-					Str("upload_id", uploadID).
-					Str("fixed_location", fixedLocation).
+			if fixed, ok := rebuildUploadLocation(location, w.filesPath); ok {
+				w.Header().Set("Location", fixed)
+				log.Debug().
+					Str("original_location", location).
+					Str("fixed_location", fixed).
 					Msg("locationFixerWriter: Fixed Location header")
+			} else {
+				log.Warn().
+					Str("original_location", location).
+					Msg("locationFixerWriter: Could not extract upload ID from Location header")
 			}
 		}
 	}
 	w.ResponseWriter.WriteHeader(statusCode)
 }
 
+// rebuildUploadLocation extracts the upload ID from the Location emitted by tusd and
+// rebuilds it under filesPath. Absolute URLs keep their scheme and host (tusd already
+// derived them from the request and X-Forwarded-* headers); relative ones stay relative.
+func rebuildUploadLocation(location, filesPath string) (string, bool) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return "", false
+	}
+
+	uploadID := path.Base(strings.TrimRight(u.Path, "/"))
+	if uploadID == "" || uploadID == "." || uploadID == "/" {
+		return "", false
+	}
+
+	rebuilt := &url.URL{
+		Scheme: u.Scheme,
+		Host:   u.Host,
+		Path:   filesPath + uploadID,
+	}
+	return rebuilt.String(), true
+}
+
+// filesPath returns the public path of the tus files endpoint with a trailing slash
+func (h *Handler) filesPath() string {
+	return strings.TrimRight(h.tusConfig.BasePath, "/") + "/files/"
+}
+
 // RegisterRoutes registers upload routes with tusd handler
-func (h *Handler) RegisterRoutes(e *echo.Group, authMiddleware echo.MiddlewareFunc) {
+// tus requests stream file chunks and have no body limit; jsonBodyLimit guards the JSON endpoints
+func (h *Handler) RegisterRoutes(e *echo.Group, authMiddleware, jsonBodyLimit echo.MiddlewareFunc) {
 	// Create upload group WITH auth middleware
 	upload := e.Group("/v1/upload", authMiddleware)
 
 	// Middleware to inject owner_id from JWT (extracted by authMiddleware)
+	// Any owner_id the client sent is replaced, so uploads always belong to the authenticated user
 	injectOwnerID := func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			if c.Request().Method == "POST" {
@@ -327,15 +792,8 @@ func (h *Handler) RegisterRoutes(e *echo.Group, authMiddleware echo.MiddlewareFu
 				}
 
 				// Inject owner_id into Upload-Metadata header
-				metadata := c.Request().Header.Get("Upload-Metadata")
-				if !strings.Contains(metadata, "owner_id") {
-					ownerIDEncoded := base64.StdEncoding.EncodeToString([]byte(userID))
-					if metadata != "" {
-						metadata += ", "
-					}
-					metadata += "owner_id " + ownerIDEncoded
-					c.Request().Header.Set("Upload-Metadata", metadata)
-				}
+				metadata := withOwnerID(c.Request().Header.Get("Upload-Metadata"), userID)
+				c.Request().Header.Set("Upload-Metadata", metadata)
 				log.Debug().
 					Str("user_id", userID).
 					Str("metadata", metadata).
@@ -352,6 +810,7 @@ func (h *Handler) RegisterRoutes(e *echo.Group, authMiddleware echo.MiddlewareFu
 			wrapped := &locationFixerWriter{
 				ResponseWriter: c.Response().Writer,
 				req:            c.Request(),
+				filesPath:      h.filesPath(),
 			}
 			c.Response().Writer = wrapped
 
@@ -393,14 +852,34 @@ func (h *Handler) RegisterRoutes(e *echo.Group, authMiddleware echo.MiddlewareFu
 	// DELETE /files/:id - Terminate upload
 	upload.DELETE("/files/:id", wrapTusHandler(http.HandlerFunc(h.tusHandler.DelFile)))
 
+	// DELETE /:documentId - Delete a processed upload's document, attachments and stored objects
+	upload.DELETE("/:documentId", h.DeleteUpload)
+
 	// Info endpoint
 	upload.GET("/info", h.GetUploadInfo)
 
+	// Server-Sent Events stream of the user's upload completions
+	upload.GET("/events", h.StreamEvents)
+
 	// Download endpoint
 	upload.GET("/download/:id", h.DownloadFile)
 
+	// Inline preview endpoint (PDF, images, text)
+	upload.GET("/preview/:id", h.PreviewFile)
+
 	// Download folder as ZIP endpoint
 	upload.GET("/download/folder/:id", h.DownloadFolder)
+
+	// Download selected files, possibly from different folders, as ZIP
+	upload.POST("/download/zip", h.DownloadSelection, jsonBodyLimit)
+
+	// Account export: the user's whole storage tree as one ZIP
+	export := e.Group("/v1/storage/export", authMiddleware)
+	export.GET("/archive", h.ExportArchive)
+
+	// Background folder export: start a job, then poll it for progress and the download link
+	e.POST("/v1/storage/folders/:id/export", h.StartFolderExport, authMiddleware, jsonBodyLimit)
+	e.GET("/v1/exports/:jobId", h.GetExportJob, authMiddleware)
 }
 
 // UploadInfoResponse represents the response for upload info endpoint
@@ -418,7 +897,7 @@ type UploadInfoResponse struct {
 // @Produce		json
 // @Security	BearerAuth
 // @Success		200		{object}	util.Response{data=UploadInfoResponse}
-// @Failure		401		{object}	util.Response
+// @Failure		401		{object}	util.ErrorEnvelope
 // @Router		/v1/upload/info [get]
 func (h *Handler) GetUploadInfo(c echo.Context) error {
 	return util.OKResponse(c, "Upload service info", UploadInfoResponse{
@@ -431,17 +910,79 @@ func (h *Handler) GetUploadInfo(c echo.Context) error {
 
 // DownloadFile godoc
 // @Summary		Download a file
-// @Description	Downloads a file by attachment ID with original filename
+// @Description	Downloads a file by attachment ID with original filename. Supports a single HTTP Range for resumable downloads and seeking, and If-Modified-Since against the attachment's creation time
 // @Tags		Upload
 // @Produce		application/octet-stream
 // @Security	BearerAuth
-// @Param		id	path		string	true	"Attachment ID"
+// @Param		id					path		string	true	"Attachment ID"
+// @Param		Range				header		string	false	"Byte range, e.g. bytes=0-1023"
+// @Param		If-Modified-Since	header		string	false	"Last-Modified of a previously downloaded copy"
 // @Success		200	{file}		binary
-// @Failure		400	{object}	util.Response
-// @Failure		404	{object}	util.Response
-// @Failure		500	{object}	util.Response
+// @Success		206	{file}		binary
+// @Success		304	"Not modified"
+// @Failure		400	{object}	util.ErrorEnvelope
+// @Failure		403	{object}	util.ErrorEnvelope
+// @Failure		404	{object}	util.ErrorEnvelope
+// @Failure		416	{object}	util.ErrorEnvelope
+// @Failure		500	{object}	util.ErrorEnvelope
 // @Router		/v1/upload/download/{id} [get]
 func (h *Handler) DownloadFile(c echo.Context) error {
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+	requesterID, err := uuid.Parse(userID)
+	if err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid user ID", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	// Get attachment ID from URL parameter
+	attachmentIDStr := c.Param("id")
+	attachmentID, err := uuid.Parse(attachmentIDStr)
+	if err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid attachment ID", util.INVALID_INPUT, 400, "The provided attachment ID is not a valid UUID"))
+	}
+
+	// Get attachment details from database, checking the requester may read it
+	attachment, err := h.service.GetAttachment(c.Request().Context(), attachmentID, requesterID)
+	if err != nil {
+		log.Error().Err(err).Str("attachment_id", attachmentIDStr).Msg("Failed to get attachment")
+		return util.HandleError(c, err)
+	}
+
+	// Attachments are never modified after upload, so a cached copy stays valid
+	if util.NotModifiedSince(c, attachment.CreatedAt) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	return h.serveAttachment(c, attachment, attachment.FileType, encodeFilename(attachment.FileName))
+}
+
+// PreviewFile godoc
+// @Summary		Preview a file inline
+// @Description	Streams a file by attachment ID with inline disposition so it can be embedded in an iframe or img. Only PDF, image and plain text files can be previewed. Supports a single HTTP Range
+// @Tags		Upload
+// @Produce		application/pdf,image/png,image/jpeg,image/gif,image/webp,text/plain
+// @Security	BearerAuth
+// @Param		id		path		string	true	"Attachment ID"
+// @Param		Range	header		string	false	"Byte range, e.g. bytes=0-1023"
+// @Success		200	{file}		binary
+// @Success		206	{file}		binary
+// @Failure		400	{object}	util.ErrorEnvelope
+// @Failure		403	{object}	util.ErrorEnvelope
+// @Failure		404	{object}	util.ErrorEnvelope
+// @Failure		415	{object}	util.ErrorEnvelope
+// @Router		/v1/upload/preview/{id} [get]
+func (h *Handler) PreviewFile(c echo.Context) error {
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+	requesterID, err := uuid.Parse(userID)
+	if err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid user ID", util.INVALID_INPUT, 400, err.Error()))
+	}
+
 	// Get attachment ID from URL parameter
 	attachmentIDStr := c.Param("id")
 	attachmentID, err := uuid.Parse(attachmentIDStr)
@@ -449,11 +990,74 @@ func (h *Handler) DownloadFile(c echo.Context) error {
 		return util.HandleError(c, util.ErrorResponse("Invalid attachment ID", util.INVALID_INPUT, 400, "The provided attachment ID is not a valid UUID"))
 	}
 
-	// Get attachment details from database
-	attachment, err := h.service.GetAttachment(c.Request().Context(), attachmentID)
+	// Get attachment details from database, checking the requester may read it
+	attachment, err := h.service.GetAttachment(c.Request().Context(), attachmentID, requesterID)
 	if err != nil {
 		log.Error().Err(err).Str("attachment_id", attachmentIDStr).Msg("Failed to get attachment")
-		return util.HandleError(c, util.ErrorResponse("Attachment not found", util.VALIDATION_ERROR, 404, fmt.Sprintf("No attachment found with ID: %s", attachmentIDStr)))
+		return util.HandleError(c, err)
+	}
+
+	// Stored type may be empty or generic; fall back to the file extension
+	contentType := attachment.FileType
+	if contentType == "" || contentType == "application/octet-stream" {
+		contentType = GetFileTypeFromPath(attachment.FileName)
+	}
+
+	if !isPreviewable(contentType) {
+		return util.HandleError(c, util.ErrorResponse("Preview not supported", util.INVALID_INPUT, http.StatusUnsupportedMediaType, fmt.Sprintf("Files of type %s cannot be previewed, download them instead", contentType)))
+	}
+
+	// Never let the browser reinterpret the content as something executable
+	c.Response().Header().Set("X-Content-Type-Options", "nosniff")
+
+	return h.serveAttachment(c, attachment, contentType, encodeInlineFilename(attachment.FileName))
+}
+
+// isPreviewable reports whether a content type is safe to render inline in the browser
+// SVG is excluded because it can carry scripts
+func isPreviewable(contentType string) bool {
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	switch mediaType {
+	case "application/pdf", "text/plain",
+		"image/jpeg", "image/png", "image/gif", "image/webp":
+		return true
+	}
+	return false
+}
+
+// serveAttachment streams an attachment from MinIO with the given content type and disposition,
+// honoring a single HTTP Range so downloads can resume and media can seek
+func (h *Handler) serveAttachment(c echo.Context, attachment *domain.DocumentAttachment, contentType, disposition string) error {
+	attachmentIDStr := attachment.ID.String()
+
+	// Get object info first: the size is needed to interpret a Range header
+	stat, err := h.minioClient.StatObject(c.Request().Context(), h.bucket, attachment.FilePath, minio.StatObjectOptions{})
+	if err != nil {
+		log.Error().Err(err).
+			Str("attachment_id", attachmentIDStr).
+			Str("file_path", attachment.FilePath).
+			Msg("Failed to get object stat from MinIO")
+		if storage.IsBucketNotFound(err) {
+			return util.HandleError(c, util.NewStorageUnavailableError(fmt.Sprintf("bucket %s does not exist", h.bucket)))
+		}
+		if storage.IsObjectNotFound(err) {
+			return util.HandleError(c, util.NewStorageObjectMissingError(attachment.FilePath))
+		}
+		return util.HandleError(c, util.ErrorResponse("Failed to download file", util.STORAGE_ERROR, 500, "Could not read file information from storage"))
+	}
+
+	// Honor single byte ranges
+	byteRange, err := parseRange(c.Request().Header.Get("Range"), stat.Size)
+	if err != nil {
+		c.Response().Header().Set("Content-Range", fmt.Sprintf("bytes */%d", stat.Size))
+		return util.HandleError(c, util.ErrorResponse("Range not satisfiable", util.INVALID_INPUT, http.StatusRequestedRangeNotSatisfiable, fmt.Sprintf("Requested range is outside the file size of %d bytes", stat.Size)))
+	}
+
+	opts := minio.GetObjectOptions{}
+	if byteRange != nil {
+		if err := opts.SetRange(byteRange.start, byteRange.end); err != nil {
+			return util.HandleError(c, util.ErrorResponse("Invalid range", util.INVALID_INPUT, 400, err.Error()))
+		}
 	}
 
 	// Download file from MinIO using file_path (upload ID)
@@ -461,7 +1065,7 @@ func (h *Handler) DownloadFile(c echo.Context) error {
 		c.Request().Context(),
 		h.bucket,
 		attachment.FilePath, // This is the upload ID
-		minio.GetObjectOptions{},
+		opts,
 	)
 	if err != nil {
 		log.Error().Err(err).
@@ -472,38 +1076,47 @@ func (h *Handler) DownloadFile(c echo.Context) error {
 	}
 	defer object.Close()
 
-	// Get object info to verify it exists
-	stat, err := object.Stat()
-	if err != nil {
-		log.Error().Err(err).
-			Str("attachment_id", attachmentIDStr).
-			Str("file_path", attachment.FilePath).
-			Msg("Failed to get object stat from MinIO")
-		return util.HandleError(c, util.ErrorResponse("File not found in storage", util.VALIDATION_ERROR, 404, "The file exists in database but not found in storage"))
+	// Set response headers with original filename
+	c.Response().Header().Set("Content-Type", contentType)
+	c.Response().Header().Set("Content-Disposition", disposition)
+	c.Response().Header().Set("Accept-Ranges", "bytes")
+
+	if byteRange != nil {
+		c.Response().Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", byteRange.start, byteRange.end, stat.Size))
+		c.Response().Header().Set("Content-Length", fmt.Sprintf("%d", byteRange.length()))
+		return c.Stream(http.StatusPartialContent, contentType, object)
 	}
 
-	// Set response headers with original filename
-	c.Response().Header().Set("Content-Type", attachment.FileType)
-	c.Response().Header().Set("Content-Disposition", encodeFilename(attachment.FileName))
 	c.Response().Header().Set("Content-Length", fmt.Sprintf("%d", stat.Size))
 
 	// Stream the file to client
-	return c.Stream(200, attachment.FileType, object)
+	return c.Stream(200, contentType, object)
 }
 
 // DownloadFolder godoc
 // @Summary		Download a folder as ZIP
-// @Description	Downloads all files in a folder (including subfolders) as a ZIP archive
+// @Description	Downloads all files in a folder (including subfolders) as a ZIP archive streamed with chunked encoding. Subfolder structure is preserved; files that could not be read are listed in _download_errors.txt. Folders above the configured file count or size are refused with 413
 // @Tags		Upload
 // @Produce		application/zip
 // @Security	BearerAuth
 // @Param		id	path		string	true	"Folder ID"
 // @Success		200	{file}		binary
-// @Failure		400	{object}	util.Response
-// @Failure		404	{object}	util.Response
-// @Failure		500	{object}	util.Response
+// @Failure		400	{object}	util.ErrorEnvelope
+// @Failure		403	{object}	util.ErrorEnvelope
+// @Failure		404	{object}	util.ErrorEnvelope
+// @Failure		413	{object}	util.ErrorEnvelope
+// @Failure		500	{object}	util.ErrorEnvelope
 // @Router		/v1/upload/download/folder/{id} [get]
 func (h *Handler) DownloadFolder(c echo.Context) error {
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+	requesterID, err := uuid.Parse(userID)
+	if err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid user ID", util.INVALID_INPUT, 400, err.Error()))
+	}
+
 	// Get folder ID from URL parameter
 	folderIDStr := c.Param("id")
 	folderID, err := uuid.Parse(folderIDStr)
@@ -511,11 +1124,11 @@ func (h *Handler) DownloadFolder(c echo.Context) error {
 		return util.HandleError(c, util.ErrorResponse("Invalid folder ID", util.INVALID_INPUT, 400, "The provided folder ID is not a valid UUID"))
 	}
 
-	// Get folder details to use the folder name
-	folder, err := h.service.GetFolder(c.Request().Context(), folderID)
+	// Get folder details to use the folder name, checking the requester owns it
+	folder, err := h.service.GetFolder(c.Request().Context(), folderID, requesterID)
 	if err != nil {
 		log.Error().Err(err).Str("folder_id", folderIDStr).Msg("Failed to get folder details")
-		return util.HandleError(c, util.ErrorResponse("Folder not found", util.VALIDATION_ERROR, 404, fmt.Sprintf("No folder found with ID: %s", folderIDStr)))
+		return util.HandleError(c, err)
 	}
 
 	// Get all attachments in the folder (recursively)
@@ -526,84 +1139,169 @@ func (h *Handler) DownloadFolder(c echo.Context) error {
 	}
 
 	if len(attachments) == 0 {
-		return util.HandleError(c, util.ErrorResponse("Empty folder", util.VALIDATION_ERROR, 404, "No files found in this folder"))
+		return util.HandleError(c, util.ErrorResponse("Empty folder", util.NOT_FOUND, 404, "No files found in this folder"))
+	}
+
+	// Refuse huge trees before touching storage; streaming them would hold the connection for hours
+	if err := h.checkFolderZipLimits(attachments); err != nil {
+		return util.HandleError(c, err)
+	}
+
+	// Pre-pass: verify every object exists before any bytes are sent,
+	// so a missing object can still be reported instead of silently truncating the archive
+	var downloadErrors []string
+	available := make([]zipEntry, 0, len(attachments))
+	usedNames := make(map[string]bool, len(attachments))
+	for _, attachment := range attachments {
+		entryName := uniqueEntryName(zipEntryName(attachment), usedNames)
+		if _, err := h.minioClient.StatObject(c.Request().Context(), h.bucket, attachment.FilePath, minio.StatObjectOptions{}); err != nil {
+			if storage.IsBucketNotFound(err) {
+				return util.HandleError(c, util.NewStorageUnavailableError(fmt.Sprintf("bucket %s does not exist", h.bucket)))
+			}
+			if storage.IsObjectNotFound(err) {
+				log.Warn().Err(err).
+					Str("error_code", string(util.STORAGE_OBJECT_MISSING)).
+					Str("file_path", attachment.FilePath).
+					Str("entry", entryName).
+					Msg("Object missing from storage, excluding from ZIP")
+				downloadErrors = append(downloadErrors, fmt.Sprintf("%s: %s: recorded in the database but missing from storage", entryName, util.STORAGE_OBJECT_MISSING))
+				continue
+			}
+			log.Warn().Err(err).
+				Str("file_path", attachment.FilePath).
+				Str("entry", entryName).
+				Msg("Failed to stat object, excluding from ZIP")
+			downloadErrors = append(downloadErrors, fmt.Sprintf("%s: %s: could not be read from storage", entryName, util.STORAGE_ERROR))
+			continue
+		}
+		available = append(available, zipEntry{attachment: attachment, name: entryName})
 	}
 
-	// Set response headers for ZIP download using folder name
+	if len(available) == 0 {
+		return util.HandleError(c, util.ErrorResponse("Files missing from storage", util.STORAGE_OBJECT_MISSING, 404, "None of the files in this folder could be read from storage: "+strings.Join(downloadErrors, "; ")))
+	}
+
+	// ZIP is built on the fly so its size is unknown up front: stream it chunked
 	c.Response().Header().Set("Content-Type", "application/zip")
 	c.Response().Header().Set("Content-Disposition", encodeFilename(folder.Name+".zip"))
+	c.Response().Header().Set("Transfer-Encoding", "chunked")
 	c.Response().WriteHeader(200)
 
 	// Create ZIP writer that writes directly to response
 	zipWriter := zip.NewWriter(c.Response().Writer)
 	defer zipWriter.Close()
 
-	// Track added files to avoid duplicates
-	addedFiles := make(map[string]bool)
-
-	// Add each file to the ZIP
-	for _, attachment := range attachments {
-		// Skip if already added (shouldn't happen, but just in case)
-		if addedFiles[attachment.FileName] {
-			log.Warn().Str("filename", attachment.FileName).Msg("Duplicate filename detected, skipping")
-			continue
-		}
-
-		// Download file from MinIO
-		object, err := h.minioClient.GetObject(
-			c.Request().Context(),
-			h.bucket,
-			attachment.FilePath,
-			minio.GetObjectOptions{},
-		)
-		if err != nil {
+	filesAdded := 0
+	for _, entry := range available {
+		if err := h.addObjectToZip(c.Request().Context(), zipWriter, entry.name, entry.attachment.FilePath); err != nil {
 			log.Error().Err(err).
-				Str("file_path", attachment.FilePath).
-				Str("filename", attachment.FileName).
-				Msg("Failed to get object from MinIO, skipping file")
-			continue // Skip this file and continue with others
-		}
-
-		// Create file in ZIP
-		writer, err := zipWriter.Create(attachment.FileName)
-		if err != nil {
-			log.Error().Err(err).Str("filename", attachment.FileName).Msg("Failed to create file in ZIP")
-			object.Close()
+				Str("file_path", entry.attachment.FilePath).
+				Str("entry", entry.name).
+				Msg("Failed to add file to ZIP")
+			downloadErrors = append(downloadErrors, fmt.Sprintf("%s: %v", entry.name, err))
 			continue
 		}
+		filesAdded++
+		log.Debug().Str("entry", entry.name).Msg("Added file to ZIP")
+	}
 
-		// Copy file content to ZIP
-		_, err = io.Copy(writer, object)
-		object.Close()
-
-		if err != nil {
-			log.Error().Err(err).Str("filename", attachment.FileName).Msg("Failed to copy file to ZIP")
-			continue
+	// Tell the user what is missing instead of handing over a silently incomplete archive
+	if len(downloadErrors) > 0 {
+		if writer, err := zipWriter.Create(downloadErrorsEntry); err == nil {
+			_, _ = io.WriteString(writer, "The following files could not be included in this archive:\n\n"+strings.Join(downloadErrors, "\n")+"\n")
+		} else {
+			log.Error().Err(err).Msg("Failed to write download errors entry to ZIP")
 		}
-
-		addedFiles[attachment.FileName] = true
-		log.Debug().Str("filename", attachment.FileName).Msg("Added file to ZIP")
 	}
 
 	log.Info().
 		Str("folder_id", folderIDStr).
-		Int("files_count", len(addedFiles)).
+		Int("files_count", filesAdded).
+		Int("errors_count", len(downloadErrors)).
 		Msg("Folder download completed")
 
 	return nil
 }
 
+// downloadErrorsEntry is the ZIP entry listing files that could not be included
+const downloadErrorsEntry = "_download_errors.txt"
+
+// zipEntry pairs an attachment with its unique path inside the ZIP
+type zipEntry struct {
+	attachment *FolderAttachment
+	name       string
+}
+
+// checkFolderZipLimits returns a 413 error if a folder download exceeds the configured file count or size
+func (h *Handler) checkFolderZipLimits(attachments []*FolderAttachment) error {
+	const guidance = "export it in the background (POST /api/v1/storage/folders/{id}/export) or download its subfolders individually instead"
+
+	if h.tusConfig.FolderZipMaxFiles > 0 && len(attachments) > h.tusConfig.FolderZipMaxFiles {
+		return util.NewPayloadTooLargeError("Folder too large to download", fmt.Sprintf("The folder holds %d files, the limit is %d; %s", len(attachments), h.tusConfig.FolderZipMaxFiles, guidance))
+	}
+
+	var totalSize int64
+	for _, attachment := range attachments {
+		totalSize += attachment.FileSize
+	}
+	if h.tusConfig.FolderZipMaxBytes > 0 && totalSize > h.tusConfig.FolderZipMaxBytes {
+		return util.NewPayloadTooLargeError("Folder too large to download", fmt.Sprintf("The folder holds %d bytes, the limit is %d bytes; %s", totalSize, h.tusConfig.FolderZipMaxBytes, guidance))
+	}
+
+	return nil
+}
+
+// zipEntryName returns the path of an attachment inside the folder ZIP, preserving subfolders
+func zipEntryName(attachment *FolderAttachment) string {
+	if attachment.RelativePath == "" {
+		return attachment.FileName
+	}
+	return attachment.RelativePath + "/" + attachment.FileName
+}
+
+// uniqueEntryName disambiguates files with the same name in the same folder
+// ("report.pdf", "report (1).pdf", ...) so no file is dropped from the archive
+func uniqueEntryName(name string, used map[string]bool) string {
+	candidate := name
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i := 1; used[candidate]; i++ {
+		candidate = fmt.Sprintf("%s (%d)%s", base, i, ext)
+	}
+	used[candidate] = true
+	return candidate
+}
+
+// addObjectToZip streams a MinIO object into a new ZIP entry
+func (h *Handler) addObjectToZip(ctx context.Context, zipWriter *zip.Writer, entryName, objectPath string) error {
+	object, err := h.minioClient.GetObject(ctx, h.bucket, objectPath, minio.GetObjectOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get object: %w", err)
+	}
+	defer object.Close()
+
+	writer, err := zipWriter.Create(entryName)
+	if err != nil {
+		return fmt.Errorf("failed to create ZIP entry: %w", err)
+	}
+
+	if _, err := io.Copy(writer, object); err != nil {
+		return fmt.Errorf("transfer interrupted, file is incomplete: %w", err)
+	}
+
+	return nil
+}
+
 // PreCreateMiddleware is called before creating an upload
 // Can be used to validate metadata and inject owner_id from JWT
 func (h *Handler) PreCreateMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		// Get user ID from context (set by auth middleware)
-		userID := c.Get("user_id")
-		if userID != nil {
+		if userID, ok := c.Get("user_id").(string); ok && userID != "" {
 			// Add owner_id to the Upload-Metadata header if not present
 			metadata := c.Request().Header.Get("Upload-Metadata")
 			if !strings.Contains(metadata, "owner_id") {
-				ownerIDEncoded := base64.StdEncoding.EncodeToString([]byte(userID.(string)))
+				ownerIDEncoded := base64.StdEncoding.EncodeToString([]byte(userID))
 				if metadata != "" {
 					metadata += ", "
 				}
@@ -651,3 +1349,8 @@ func encodeFilename(filename string) string {
 	// This properly handles Unicode characters
 	return fmt.Sprintf(`attachment; filename*=UTF-8''%s`, url.PathEscape(filename))
 }
+
+// encodeInlineFilename is like encodeFilename but asks the browser to display the file instead of saving it
+func encodeInlineFilename(filename string) string {
+	return fmt.Sprintf(`inline; filename*=UTF-8''%s`, url.PathEscape(filename))
+}
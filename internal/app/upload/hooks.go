@@ -0,0 +1,229 @@
+package upload
+
+import (
+	"context"
+	"e-document-backend/internal/pkg/metrics"
+	"e-document-backend/internal/util"
+	"errors"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+	tusd "github.com/tus/tusd/v2/pkg/handler"
+)
+
+// uploadRejection is why a stored upload may not become a document
+type uploadRejection struct {
+	outcome string // result label of the uploads_total metric
+	err     error  // tusd error the pre-finish hook answers the client with
+}
+
+// uploadObjectKey returns the key of an upload's object in the bucket
+// tusd stores files with the upload ID as the object key unless the store reports another one
+func uploadObjectKey(upload tusd.FileInfo) string {
+	if upload.Storage != nil {
+		if key, ok := upload.Storage["Key"]; ok {
+			return key
+		}
+	}
+	return upload.ID
+}
+
+// checkUploadMetadata parses the metadata of a stored upload, logging why it cannot be trusted
+func (h *Handler) checkUploadMetadata(upload tusd.FileInfo) (*UploadMetadata, *uploadRejection) {
+	meta, err := parseUploadMetadata(upload.MetaData, h.tusConfig.RequiredMetadataKeys)
+	if err == nil {
+		return meta, nil
+	}
+
+	event := log.Error().Err(err).
+		Str("event", "upload_invalid_metadata").
+		Str("upload_id", upload.ID)
+	var metaErr *MetadataError
+	if errors.As(err, &metaErr) {
+		event = event.Str("key", metaErr.Key).Str("reason", metaErr.Reason)
+	}
+	event.Msg("Upload metadata is invalid, removing stored object")
+
+	return nil, &uploadRejection{
+		outcome: "invalid_metadata",
+		err:     tusd.NewError("ERR_INVALID_METADATA", err.Error(), http.StatusBadRequest),
+	}
+}
+
+// checkUploadQuota checks an upload's final size against the owner's quota: deferred lengths
+// were unknown on creation and concurrent uploads may have used the quota up since
+func (h *Handler) checkUploadQuota(ctx context.Context, upload tusd.FileInfo, meta *UploadMetadata) *uploadRejection {
+	if h.quota == nil {
+		return nil
+	}
+
+	ownerID := meta.OwnerID.String()
+	if err := h.quota.CheckUpload(ctx, ownerID, upload.Size); err != nil {
+		statusCode := http.StatusInternalServerError
+		if customErr, ok := err.(*util.CustomError); ok {
+			statusCode = customErr.StatusCode
+		}
+		log.Warn().Err(err).
+			Str("event", "upload_quota_exceeded").
+			Str("upload_id", upload.ID).
+			Str("owner_id", ownerID).
+			Int64("size", upload.Size).
+			Msg("Upload rejected by quota check, removing stored object")
+		return &uploadRejection{
+			outcome: "quota_exceeded",
+			err:     tusd.NewError("ERR_QUOTA_EXCEEDED", err.Error(), statusCode),
+		}
+	}
+
+	return nil
+}
+
+// checkUploadContent runs the checks that read an upload's stored bytes: the client's checksum,
+// the malware scan and the content type. They take as long as the file is large, so they run
+// in the completion pipeline rather than in the request that completes the upload
+func (h *Handler) checkUploadContent(ctx context.Context, upload tusd.FileInfo, meta *UploadMetadata) *uploadRejection {
+	objectKey := uploadObjectKey(upload)
+	ownerID := meta.OwnerID.String()
+
+	// A client that sent the SHA-256 of its file learns about corruption in transit
+	if meta.SHA256 != "" {
+		contentHash, err := h.hashObject(ctx, objectKey)
+		if err != nil {
+			log.Error().Err(err).Str("upload_id", upload.ID).Msg("Failed to hash upload for checksum verification")
+			return &uploadRejection{
+				outcome: "failure",
+				err:     tusd.NewError("ERR_CHECKSUM_UNVERIFIED", "failed to verify upload checksum", http.StatusInternalServerError),
+			}
+		}
+		if contentHash != meta.SHA256 {
+			log.Warn().
+				Str("event", "upload_checksum_mismatch").
+				Str("upload_id", upload.ID).
+				Str("owner_id", ownerID).
+				Str("filename", meta.FileName).
+				Str("expected_sha256", meta.SHA256).
+				Str("actual_sha256", contentHash).
+				Msg("Upload content does not match its checksum, removing stored object")
+			return &uploadRejection{
+				outcome: "checksum_mismatch",
+				err:     tusd.NewError("ERR_CHECKSUM_MISMATCH", "upload content does not match its sha256 checksum", 460),
+			}
+		}
+	}
+
+	// Infected files never become attachments
+	if h.scanner != nil {
+		clean, signature, err := h.scanUpload(ctx, objectKey)
+		if errors.Is(err, ErrScanSizeLimit) {
+			return &uploadRejection{
+				outcome: "scan_size_limit",
+				err:     tusd.NewError("ERR_UPLOAD_TOO_LARGE_TO_SCAN", "upload is larger than the malware scan accepts", http.StatusRequestEntityTooLarge),
+			}
+		}
+		if !clean {
+			log.Warn().
+				Str("event", "upload_quarantined").
+				Str("upload_id", upload.ID).
+				Str("owner_id", ownerID).
+				Str("filename", meta.FileName).
+				Str("signature", signature).
+				Msg("Upload rejected by malware scan, removing stored object")
+			return &uploadRejection{
+				outcome: "quarantined",
+				err:     tusd.NewError("ERR_UPLOAD_QUARANTINED", "upload was rejected by the malware scan", http.StatusUnprocessableEntity),
+			}
+		}
+	}
+
+	// A file renamed to look like another type (an executable sent as .pdf) never becomes an attachment
+	if h.tusConfig.VerifyContentType {
+		sniffedType, err := h.sniffUpload(ctx, objectKey)
+		if err != nil {
+			// Unreadable objects fail later when the attachment is served; the malware scan is the gate
+			log.Warn().Err(err).Str("upload_id", upload.ID).Msg("Failed to sniff upload content type, skipping verification")
+		} else if sniffedType != "" {
+			if claimedType, mismatch := contentTypeMismatch(meta.FileType, meta.FileName, sniffedType); mismatch {
+				log.Warn().
+					Str("event", "upload_type_mismatch").
+					Str("upload_id", upload.ID).
+					Str("owner_id", ownerID).
+					Str("filename", meta.FileName).
+					Str("claimed_type", claimedType).
+					Str("sniffed_type", sniffedType).
+					Msg("Upload content does not match its declared type, removing stored object")
+				return &uploadRejection{
+					outcome: "type_mismatch",
+					err:     tusd.NewError("ERR_TYPE_MISMATCH", "upload content does not match its declared type "+claimedType, http.StatusUnsupportedMediaType),
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// preFinishUpload checks a stored upload before tusd answers the request that completed it, so
+// the client is told why it was refused and no completion event is sent for it
+// Only the metadata and quota checks run here; they need no reads of the stored object, so the
+// last request is not held up by hashing, scanning or sniffing a large file
+// Partial uploads are checked as part of their final upload
+func (h *Handler) preFinishUpload(hook tusd.HookEvent) (tusd.HTTPResponse, error) {
+	upload := hook.Upload
+	if upload.IsPartial {
+		return tusd.HTTPResponse{}, nil
+	}
+
+	ctx := hook.Context
+	if upload.IsFinal {
+		h.resolveFinalUploadMetadata(ctx, &upload)
+	}
+
+	meta, rejection := h.checkUploadMetadata(upload)
+	if rejection == nil {
+		rejection = h.checkUploadQuota(ctx, upload, meta)
+	}
+	if rejection == nil {
+		return tusd.HTTPResponse{}, nil
+	}
+
+	metrics.UploadsTotal.WithLabelValues(rejection.outcome).Inc()
+
+	// The client may already be gone; the upload is removed regardless
+	h.discardRejectedUpload(context.WithoutCancel(ctx), upload)
+	return tusd.HTTPResponse{}, rejection.err
+}
+
+// discardRejectedUpload terminates an upload refused by the pre-finish hook and its partial
+// uploads, removing the object and tusd's info file so the client cannot resume it
+func (h *Handler) discardRejectedUpload(ctx context.Context, upload tusd.FileInfo) {
+	stored, err := h.composer.Core.GetUpload(ctx, upload.ID)
+	if err == nil {
+		err = h.composer.Terminater.AsTerminatableUpload(stored).Terminate(ctx)
+	}
+	if err != nil {
+		log.Error().Err(err).Str("upload_id", upload.ID).Msg("Failed to terminate rejected upload, removing its object")
+		h.removeUploadObject(ctx, uploadObjectKey(upload))
+	}
+
+	if upload.IsFinal {
+		h.removePartialUploads(ctx, upload.PartialUploads)
+	}
+}
+
+// handleCreatedUploads logs every upload tusd created, leaving an audit trail of uploads that
+// were started, including those that never complete
+// tusd blocks creating an upload until its notification is received, so this keeps reading after
+// Drain for as long as the tusd handler exists; logging holds up nothing Drain waits for
+func (h *Handler) handleCreatedUploads() {
+	for event := range h.tusHandler.CreatedUploads {
+		log.Info().
+			Str("event", "upload_created").
+			Str("upload_id", event.Upload.ID).
+			Str("owner_id", event.Upload.MetaData["owner_id"]).
+			Int64("size", event.Upload.Size).
+			Bool("size_deferred", event.Upload.SizeIsDeferred).
+			Bool("is_partial", event.Upload.IsPartial).
+			Bool("is_final", event.Upload.IsFinal).
+			Msg("Upload created")
+	}
+}
@@ -0,0 +1,183 @@
+package upload
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// blockingScanner holds every scan until release is closed, then reports signature
+type blockingScanner struct {
+	started   chan struct{}
+	release   chan struct{}
+	signature string
+}
+
+func (s *blockingScanner) Scan(ctx context.Context, r io.Reader) (string, error) {
+	_, _ = io.Copy(io.Discard, r)
+	s.started <- struct{}{}
+	<-s.release
+	return s.signature, nil
+}
+
+func TestPreFinishLeavesContentChecksToCompletion(t *testing.T) {
+	service := &completionService{processed: make(chan ProcessUploadParams, 1)}
+	h, storage, server := newTusTestServer(t, service, uuid.New(), func(cfg *TusConfig) { cfg.PreFinishValidation = true })
+	scanner := &blockingScanner{started: make(chan struct{}, 1), release: make(chan struct{}), signature: "Eicar-Signature"}
+	h.scanner = scanner
+
+	body := []byte("X5O!P%@AP[4\\PZX54(P^)7CC)7}$EICAR")
+	metadata := "relative_path " + base64.StdEncoding.EncodeToString([]byte("Reports/eicar.pdf")) +
+		",file_type " + base64.StdEncoding.EncodeToString([]byte("application/pdf"))
+	created := tusRequest(t, http.MethodPost, server.URL+"/api/v1/upload/files", map[string]string{
+		"Upload-Length":   "33",
+		"Upload-Metadata": metadata,
+	}, nil, http.StatusCreated)
+	location := created.Header.Get("Location")
+
+	// The last PATCH is answered while the malware scan is still running
+	patched := make(chan error, 1)
+	go func() {
+		_, err := sendTus(http.MethodPatch, location, map[string]string{
+			"Content-Type":  "application/offset+octet-stream",
+			"Upload-Offset": "0",
+		}, body, http.StatusNoContent)
+		patched <- err
+	}()
+	select {
+	case err := <-patched:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(10 * time.Second):
+		close(scanner.release)
+		t.Fatal("the request completing the upload waited for the malware scan")
+	}
+
+	select {
+	case <-scanner.started:
+	case <-time.After(10 * time.Second):
+		t.Fatal("the completed upload was never scanned")
+	}
+	close(scanner.release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := h.Drain(ctx); err != nil {
+		t.Fatalf("Drain() error = %v", err)
+	}
+
+	// The infected upload is rejected by the completion pipeline instead
+	select {
+	case params := <-service.processed:
+		t.Fatalf("infected upload became a document: %+v", params)
+	default:
+	}
+	uploadID := location[strings.LastIndex(location, "/")+1:]
+	objectKey, _, _ := strings.Cut(uploadID, "+")
+	if _, ok := storage.object(objectKey); ok {
+		t.Fatalf("infected upload %s was not removed", objectKey)
+	}
+}
+
+func TestCreatedUploadsAfterDrain(t *testing.T) {
+	h, _, server := newTusTestServer(t, &completionService{}, uuid.New(), func(cfg *TusConfig) { cfg.NotifyCreatedUploads = true })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := h.Drain(ctx); err != nil {
+		t.Fatalf("Drain() error = %v", err)
+	}
+
+	// tusd waits for every created-upload notification to be received before it answers
+	created := make(chan error, 1)
+	go func() {
+		_, err := sendTus(http.MethodPost, server.URL+"/api/v1/upload/files", map[string]string{
+			"Upload-Concat": "partial",
+			"Upload-Length": "10",
+		}, nil, http.StatusCreated)
+		created <- err
+	}()
+	select {
+	case err := <-created:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(10 * time.Second):
+		// Release the stuck request so the test server can shut down
+		go func() { <-h.tusHandler.CreatedUploads }()
+		t.Fatal("creating an upload after Drain hung on the created-upload notification")
+	}
+}
+
+// ownerQuota accepts every upload and records the owner each quota check was made for
+type ownerQuota struct {
+	owners chan string
+}
+
+func (q *ownerQuota) CheckUpload(ctx context.Context, ownerID string, size int64) error {
+	q.owners <- ownerID
+	return nil
+}
+
+func TestUploadOwnerIsAuthenticatedUser(t *testing.T) {
+	ownerID, victimID := uuid.New(), uuid.New()
+	encode := func(value string) string { return base64.StdEncoding.EncodeToString([]byte(value)) }
+	pathAndType := "relative_path " + encode("Reports/budget.pdf") + ",file_type " + encode("application/pdf")
+
+	tests := []struct {
+		name     string
+		metadata string
+	}{
+		{name: "no owner_id", metadata: pathAndType},
+		{name: "spoofed owner_id", metadata: "owner_id " + encode(victimID.String()) + "," + pathAndType},
+		{name: "spoofed owner_id last", metadata: pathAndType + ", owner_id " + encode(victimID.String())},
+		{name: "owner_id repeated", metadata: "owner_id " + encode(victimID.String()) + "," + pathAndType + ",owner_id " + encode(uuid.NewString())},
+		{name: "owner_id without a value", metadata: "owner_id," + pathAndType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &completionService{processed: make(chan ProcessUploadParams, 1)}
+			h, _, server := newTusTestServer(t, service, ownerID)
+			quota := &ownerQuota{owners: make(chan string, 1)}
+			h.quota = quota
+
+			created := tusRequest(t, http.MethodPost, server.URL+"/api/v1/upload/files", map[string]string{
+				"Upload-Length":   "4",
+				"Upload-Metadata": tt.metadata,
+			}, nil, http.StatusCreated)
+			tusRequest(t, http.MethodPatch, created.Header.Get("Location"), map[string]string{
+				"Content-Type":  "application/offset+octet-stream",
+				"Upload-Offset": "0",
+			}, []byte("%PDF"), http.StatusNoContent)
+
+			if owner := <-quota.owners; owner != ownerID.String() {
+				t.Fatalf("quota checked for %s, want the authenticated user %s", owner, ownerID)
+			}
+
+			var params ProcessUploadParams
+			select {
+			case params = <-service.processed:
+			case <-time.After(10 * time.Second):
+				t.Fatal("the upload was never processed")
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := h.Drain(ctx); err != nil {
+				t.Fatalf("Drain() error = %v", err)
+			}
+
+			if params.OwnerID != ownerID {
+				t.Fatalf("upload processed for %s, want the authenticated user %s", params.OwnerID, ownerID)
+			}
+		})
+	}
+}
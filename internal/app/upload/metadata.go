@@ -0,0 +1,116 @@
+package upload
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	tusd "github.com/tus/tusd/v2/pkg/handler"
+)
+
+// UploadMetadata is the checked metadata of an upload that becomes a document
+type UploadMetadata struct {
+	OwnerID        uuid.UUID
+	ParentFolderID *uuid.UUID // nil uploads into the owner's root
+	RelativePath   string     // relative_path, or filename when no path was sent
+	FileName       string
+	FileType       string
+	SHA256         string // lowercase hex checksum the stored bytes must match; empty skips the check
+}
+
+// checksumKey is the metadata key of the hex SHA-256 a client may send for its whole file
+const checksumKey = "sha256"
+
+// MetadataError reports the metadata key that made an upload unusable
+type MetadataError struct {
+	Key    string
+	Reason string
+}
+
+func (e *MetadataError) Error() string {
+	return fmt.Sprintf("invalid upload metadata %s: %s", e.Key, e.Reason)
+}
+
+// withOwnerID returns an Upload-Metadata header whose owner_id is userID
+// Every owner_id pair of the header is dropped, the same way tusd splits the header into pairs
+func withOwnerID(header, userID string) string {
+	var pairs []string
+	for _, pair := range strings.Split(header, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		if key, _, _ := strings.Cut(pair, " "); key == "owner_id" {
+			continue
+		}
+		pairs = append(pairs, pair)
+	}
+	pairs = append(pairs, "owner_id "+base64.StdEncoding.EncodeToString([]byte(userID)))
+	return strings.Join(pairs, ",")
+}
+
+// parseUploadMetadata checks the metadata of an upload and the extra keys the configuration
+// requires; the path must name a file and may not step out of the parent folder
+func parseUploadMetadata(metaData tusd.MetaData, requiredKeys []string) (*UploadMetadata, error) {
+	for _, key := range requiredKeys {
+		if strings.TrimSpace(metaData[key]) == "" {
+			return nil, &MetadataError{Key: key, Reason: "is required"}
+		}
+	}
+
+	ownerIDStr := metaData["owner_id"]
+	if ownerIDStr == "" {
+		return nil, &MetadataError{Key: "owner_id", Reason: "is required"}
+	}
+	ownerID, err := uuid.Parse(ownerIDStr)
+	if err != nil {
+		return nil, &MetadataError{Key: "owner_id", Reason: "must be a UUID"}
+	}
+
+	meta := &UploadMetadata{
+		OwnerID:  ownerID,
+		FileName: metaData["filename"],
+		FileType: metaData["file_type"],
+	}
+
+	// A parent folder that cannot be parsed used to be ignored, uploading into the root instead
+	if value := metaData["parent_folder_id"]; value != "" {
+		parentFolderID, err := uuid.Parse(value)
+		if err != nil {
+			return nil, &MetadataError{Key: "parent_folder_id", Reason: "must be a UUID"}
+		}
+		meta.ParentFolderID = &parentFolderID
+	}
+
+	// Use relative_path if provided, otherwise use filename
+	pathKey := "relative_path"
+	meta.RelativePath = metaData["relative_path"]
+	if meta.RelativePath == "" {
+		pathKey = "filename"
+		meta.RelativePath = meta.FileName
+	}
+	if meta.RelativePath == "" {
+		return nil, &MetadataError{Key: "relative_path", Reason: "relative_path or filename is required"}
+	}
+
+	if value := metaData[checksumKey]; value != "" {
+		checksum, err := hex.DecodeString(value)
+		if err != nil || len(checksum) != sha256.Size {
+			return nil, &MetadataError{Key: checksumKey, Reason: "must be a hex SHA-256 digest"}
+		}
+		meta.SHA256 = hex.EncodeToString(checksum)
+	}
+
+	parts, err := parsePath(meta.RelativePath)
+	if err != nil {
+		return nil, &MetadataError{Key: pathKey, Reason: err.Error()}
+	}
+	if len(parts) == 0 {
+		return nil, &MetadataError{Key: pathKey, Reason: "must name a file"}
+	}
+
+	return meta, nil
+}
@@ -0,0 +1,79 @@
+package upload
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// errRangeNotSatisfiable is returned when a Range header lies outside the object
+var errRangeNotSatisfiable = errors.New("range not satisfiable")
+
+// byteRange is an inclusive byte range within an object
+type byteRange struct {
+	start int64
+	end   int64
+}
+
+// length returns the number of bytes covered by the range
+func (r byteRange) length() int64 {
+	return r.end - r.start + 1
+}
+
+// parseRange parses a single-range HTTP Range header ("bytes=0-499", "bytes=500-", "bytes=-500")
+// against an object of the given size. It returns nil when the header is absent, malformed or
+// asks for multiple ranges, in which case the whole object should be served.
+func parseRange(header string, size int64) (*byteRange, error) {
+	const prefix = "bytes="
+	if header == "" || !strings.HasPrefix(header, prefix) {
+		return nil, nil
+	}
+
+	spec := strings.TrimSpace(strings.TrimPrefix(header, prefix))
+	if strings.Contains(spec, ",") {
+		return nil, nil // multipart/byteranges is not supported
+	}
+
+	startStr, endStr, found := strings.Cut(spec, "-")
+	if !found {
+		return nil, nil
+	}
+	startStr = strings.TrimSpace(startStr)
+	endStr = strings.TrimSpace(endStr)
+
+	// Suffix range: the last N bytes
+	if startStr == "" {
+		suffix, err := strconv.ParseInt(endStr, 10, 64)
+		if err != nil || suffix < 0 {
+			return nil, nil
+		}
+		if suffix == 0 || size == 0 {
+			return nil, errRangeNotSatisfiable
+		}
+		if suffix > size {
+			suffix = size
+		}
+		return &byteRange{start: size - suffix, end: size - 1}, nil
+	}
+
+	start, err := strconv.ParseInt(startStr, 10, 64)
+	if err != nil || start < 0 {
+		return nil, nil
+	}
+	if start >= size {
+		return nil, errRangeNotSatisfiable
+	}
+
+	end := size - 1
+	if endStr != "" {
+		end, err = strconv.ParseInt(endStr, 10, 64)
+		if err != nil || end < start {
+			return nil, nil
+		}
+		if end >= size {
+			end = size - 1
+		}
+	}
+
+	return &byteRange{start: start, end: end}, nil
+}
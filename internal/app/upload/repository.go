@@ -3,6 +3,7 @@ package upload
 import (
 	"context"
 	"e-document-backend/internal/domain"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -10,12 +11,12 @@ import (
 
 // Repository defines the interface for upload-related database operations
 type Repository interface {
-	// Transaction management
-	BeginTx(ctx context.Context) (pgx.Tx, error)
+	// Transaction management: fn runs in a transaction committed only if it returns nil
+	WithTx(ctx context.Context, fn func(tx pgx.Tx) error) error
 
 	// Folder operations (within transaction)
 	FindFolderByNameAndParent(ctx context.Context, tx pgx.Tx, name string, parentID *uuid.UUID, ownerID uuid.UUID) (*domain.Folder, error)
-	CreateFolder(ctx context.Context, tx pgx.Tx, folder *domain.Folder) error
+	CreateFolder(ctx context.Context, tx pgx.Tx, folder *domain.Folder) (bool, error)
 
 	// Folder operations (without transaction)
 	GetFolderByID(ctx context.Context, folderID uuid.UUID) (*domain.Folder, error)
@@ -28,7 +29,39 @@ type Repository interface {
 	GetLatestVersionByDocumentID(ctx context.Context, tx pgx.Tx, documentID uuid.UUID) (int, error)
 	SetPreviousVersionsNotCurrent(ctx context.Context, tx pgx.Tx, documentID uuid.UUID) error
 
+	// Duplicate file names (within transaction)
+	LockFolderForUpload(ctx context.Context, tx pgx.Tx, folderID *uuid.UUID, ownerID uuid.UUID) error
+	FindDocumentByFileName(ctx context.Context, tx pgx.Tx, folderID *uuid.UUID, ownerID uuid.UUID, fileName string) (*domain.Document, error)
+
+	// Document deletion (within transaction)
+	GetDocumentRegistrantForUpdate(ctx context.Context, tx pgx.Tx, documentID uuid.UUID) (*uuid.UUID, error)
+	DeleteDocument(ctx context.Context, tx pgx.Tx, documentID uuid.UUID) ([]string, error)
+	FilterUnreferencedFilePaths(ctx context.Context, tx pgx.Tx, filePaths []string) ([]string, error)
+
+	// Shared storage objects (within transaction)
+	LockFilePaths(ctx context.Context, tx pgx.Tx, filePaths []string) error
+
 	// Attachment operations (without transaction)
 	GetAttachmentByID(ctx context.Context, attachmentID uuid.UUID) (*domain.DocumentAttachment, error)
-	GetAttachmentsByFolderID(ctx context.Context, folderID uuid.UUID) ([]*domain.DocumentAttachment, error)
+	GetAttachmentsByFolderID(ctx context.Context, folderID uuid.UUID) ([]*FolderAttachment, error)
+	GetAttachmentsByOwnerID(ctx context.Context, ownerID uuid.UUID) ([]*FolderAttachment, error)
+	AttachmentExistsByFilePath(ctx context.Context, filePath string) (bool, error)
+	AttachmentExistsByUploadID(ctx context.Context, uploadID string) (bool, error)
+	FindFilePathByContentHash(ctx context.Context, contentHash string, size int64) (string, error)
+
+	// Access checks (without transaction)
+	CanAccessDocument(ctx context.Context, documentID, userID uuid.UUID) (bool, error)
+
+	// Export jobs (without transaction)
+	CreateExportJob(ctx context.Context, job *domain.ExportJob) error
+	GetExportJobByID(ctx context.Context, jobID uuid.UUID) (*domain.ExportJob, error)
+	UpdateExportJob(ctx context.Context, job *domain.ExportJob) error
+	GetExpiredExportJobs(ctx context.Context, before time.Time) ([]*domain.ExportJob, error)
+	FailStaleExportJobs(ctx context.Context, before time.Time, reason string) (int64, error)
+}
+
+// FolderAttachment is an attachment found while walking a folder tree
+type FolderAttachment struct {
+	*domain.DocumentAttachment
+	RelativePath string `json:"relative_path"` // folder path relative to the requested folder ("" for direct children)
 }
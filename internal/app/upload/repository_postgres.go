@@ -3,11 +3,14 @@ package upload
 import (
 	"context"
 	"e-document-backend/internal/domain"
+	"e-document-backend/internal/platform/postgres"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -23,9 +26,9 @@ func NewPostgresRepository(pool *pgxpool.Pool) Repository {
 	}
 }
 
-// BeginTx starts a new database transaction
-func (r *postgresRepository) BeginTx(ctx context.Context) (pgx.Tx, error) {
-	return r.pool.Begin(ctx)
+// WithTx runs fn inside a database transaction
+func (r *postgresRepository) WithTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	return postgres.WithTx(ctx, r.pool, fn)
 }
 
 // FindFolderByNameAndParent finds a folder by name, parent, and owner
@@ -35,14 +38,14 @@ func (r *postgresRepository) FindFolderByNameAndParent(ctx context.Context, tx p
 
 	if parentID == nil {
 		query = `
-			SELECT id, name, path, is_root_folder, parent_folder_id, owner_id, created_at, updated_at
+			SELECT id, name, path, is_root_folder, parent_folder_id, owner_id, color, icon, created_at, updated_at
 			FROM folders
 			WHERE name = $1 AND parent_folder_id IS NULL AND owner_id = $2
 		`
 		args = []interface{}{name, ownerID}
 	} else {
 		query = `
-			SELECT id, name, path, is_root_folder, parent_folder_id, owner_id, created_at, updated_at
+			SELECT id, name, path, is_root_folder, parent_folder_id, owner_id, color, icon, created_at, updated_at
 			FROM folders
 			WHERE name = $1 AND parent_folder_id = $2 AND owner_id = $3
 		`
@@ -57,6 +60,8 @@ func (r *postgresRepository) FindFolderByNameAndParent(ctx context.Context, tx p
 		&folder.IsRootFolder,
 		&folder.ParentFolderID,
 		&folder.OwnerID,
+		&folder.Color,
+		&folder.Icon,
 		&folder.CreatedAt,
 		&folder.UpdatedAt,
 	)
@@ -71,11 +76,16 @@ func (r *postgresRepository) FindFolderByNameAndParent(ctx context.Context, tx p
 	return &folder, nil
 }
 
-// CreateFolder creates a new folder in the database
-func (r *postgresRepository) CreateFolder(ctx context.Context, tx pgx.Tx, folder *domain.Folder) error {
+// CreateFolder creates a new folder in the database, or loads the existing one
+// Concurrent uploads of the same hierarchy race to create the same folders; the unique index
+// idx_folders_unique_name (NULL-safe for root folders) lets only one insert win, and the others
+// wait for it and then load its folder into folder. Returns whether this call created the folder
+func (r *postgresRepository) CreateFolder(ctx context.Context, tx pgx.Tx, folder *domain.Folder) (bool, error) {
+	// A unique violation would abort the whole upload transaction, so conflicts are skipped instead
 	query := `
-		INSERT INTO folders (id, name, path, is_root_folder, parent_folder_id, owner_id, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO folders (id, name, path, is_root_folder, parent_folder_id, owner_id, color, icon, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (name, COALESCE(parent_folder_id, '00000000-0000-0000-0000-000000000000'::uuid), owner_id) DO NOTHING
 		RETURNING id, created_at, updated_at
 	`
 
@@ -90,21 +100,34 @@ func (r *postgresRepository) CreateFolder(ctx context.Context, tx pgx.Tx, folder
 		folder.IsRootFolder,
 		folder.ParentFolderID,
 		folder.OwnerID,
+		folder.Color,
+		folder.Icon,
 		folder.CreatedAt,
 		folder.UpdatedAt,
 	).Scan(&folder.ID, &folder.CreatedAt, &folder.UpdatedAt)
 
+	if err == pgx.ErrNoRows {
+		existing, findErr := r.FindFolderByNameAndParent(ctx, tx, folder.Name, folder.ParentFolderID, folder.OwnerID)
+		if findErr != nil {
+			return false, findErr
+		}
+		if existing == nil {
+			return false, fmt.Errorf("failed to create folder: conflicting folder %q disappeared", folder.Name)
+		}
+		*folder = *existing
+		return false, nil
+	}
 	if err != nil {
-		return fmt.Errorf("failed to create folder: %w", err)
+		return false, fmt.Errorf("failed to create folder: %w", err)
 	}
 
-	return nil
+	return true, nil
 }
 
 // GetFolderByID retrieves a folder by its ID (without transaction)
 func (r *postgresRepository) GetFolderByID(ctx context.Context, folderID uuid.UUID) (*domain.Folder, error) {
 	query := `
-		SELECT id, name, path, is_root_folder, parent_folder_id, owner_id, created_at, updated_at
+		SELECT id, name, path, is_root_folder, parent_folder_id, owner_id, color, icon, created_at, updated_at
 		FROM folders
 		WHERE id = $1
 	`
@@ -117,6 +140,8 @@ func (r *postgresRepository) GetFolderByID(ctx context.Context, folderID uuid.UU
 		&folder.IsRootFolder,
 		&folder.ParentFolderID,
 		&folder.OwnerID,
+		&folder.Color,
+		&folder.Icon,
 		&folder.CreatedAt,
 		&folder.UpdatedAt,
 	)
@@ -173,9 +198,9 @@ func (r *postgresRepository) CreateAttachment(ctx context.Context, tx pgx.Tx, at
 	query := `
 		INSERT INTO document_attachments (
 			id, document_id, file_name, file_path, file_size, file_type,
-			version, is_current, uploaded_by, created_at
+			version, is_current, uploaded_by, created_at, content_hash, upload_id
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 		RETURNING id, created_at
 	`
 
@@ -193,9 +218,15 @@ func (r *postgresRepository) CreateAttachment(ctx context.Context, tx pgx.Tx, at
 		attachment.IsCurrent,
 		attachment.UploadedBy,
 		attachment.CreatedAt,
+		attachment.ContentHash,
+		attachment.UploadID,
 	).Scan(&attachment.ID, &attachment.CreatedAt)
 
 	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "idx_attachments_upload_id" {
+			return ErrUploadAlreadyProcessed
+		}
 		return fmt.Errorf("failed to create attachment: %w", err)
 	}
 
@@ -235,6 +266,77 @@ func (r *postgresRepository) SetPreviousVersionsNotCurrent(ctx context.Context,
 	return nil
 }
 
+// LockFolderForUpload serialises uploads into one folder until the transaction ends, so two
+// uploads of the same file name cannot both miss each other; documents outside any folder are
+// locked per owner
+func (r *postgresRepository) LockFolderForUpload(ctx context.Context, tx pgx.Tx, folderID *uuid.UUID, ownerID uuid.UUID) error {
+	key := "upload-owner:" + ownerID.String()
+	if folderID != nil {
+		key = "upload-folder:" + folderID.String()
+	}
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, key); err != nil {
+		return fmt.Errorf("failed to lock folder: %w", err)
+	}
+
+	return nil
+}
+
+// FindDocumentByFileName finds a document in the folder whose current attachment has the file name
+// Documents outside any folder only match those registered by the owner
+// Returns nil, nil when there is none
+func (r *postgresRepository) FindDocumentByFileName(ctx context.Context, tx pgx.Tx, folderID *uuid.UUID, ownerID uuid.UUID, fileName string) (*domain.Document, error) {
+	var query string
+	var args []interface{}
+
+	const columns = `
+		SELECT d.id, d.title, COALESCE(d.description, ''), d.type, d.category_id, d.folder_id, d.barcode,
+			d.registrant_id, d.current_department_id, d.status, d.created_at, d.updated_at
+		FROM documents d
+		JOIN document_attachments a ON a.document_id = d.id AND a.is_current = true
+	`
+	if folderID == nil {
+		query = columns + `
+			WHERE d.folder_id IS NULL AND d.registrant_id = $1 AND a.file_name = $2
+			ORDER BY d.created_at
+			LIMIT 1
+		`
+		args = []interface{}{ownerID, fileName}
+	} else {
+		query = columns + `
+			WHERE d.folder_id = $1 AND a.file_name = $2
+			ORDER BY d.created_at
+			LIMIT 1
+		`
+		args = []interface{}{*folderID, fileName}
+	}
+
+	var doc domain.Document
+	err := tx.QueryRow(ctx, query, args...).Scan(
+		&doc.ID,
+		&doc.Title,
+		&doc.Description,
+		&doc.Type,
+		&doc.CategoryID,
+		&doc.FolderID,
+		&doc.Barcode,
+		&doc.RegistrantID,
+		&doc.CurrentDepartmentID,
+		&doc.Status,
+		&doc.CreatedAt,
+		&doc.UpdatedAt,
+	)
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find document by file name: %w", err)
+	}
+
+	return &doc, nil
+}
+
 // GetAttachmentByID retrieves an attachment by its ID (without transaction)
 func (r *postgresRepository) GetAttachmentByID(ctx context.Context, attachmentID uuid.UUID) (*domain.DocumentAttachment, error) {
 	query := `
@@ -269,24 +371,27 @@ func (r *postgresRepository) GetAttachmentByID(ctx context.Context, attachmentID
 }
 
 // GetAttachmentsByFolderID retrieves all attachments in a folder (recursively including subfolders)
-func (r *postgresRepository) GetAttachmentsByFolderID(ctx context.Context, folderID uuid.UUID) ([]*domain.DocumentAttachment, error) {
+// Each attachment carries its folder path relative to the requested folder
+func (r *postgresRepository) GetAttachmentsByFolderID(ctx context.Context, folderID uuid.UUID) ([]*FolderAttachment, error) {
 	query := `
 		WITH RECURSIVE folder_tree AS (
 			-- Base case: the specified folder
-			SELECT id FROM folders WHERE id = $1
+			SELECT id, ''::text AS relative_path FROM folders WHERE id = $1
 			UNION ALL
 			-- Recursive case: all subfolders
-			SELECT f.id FROM folders f
+			SELECT f.id,
+				CASE WHEN ft.relative_path = '' THEN f.name ELSE ft.relative_path || '/' || f.name END
+			FROM folders f
 			INNER JOIN folder_tree ft ON f.parent_folder_id = ft.id
 		)
 		SELECT DISTINCT
 			da.id, da.document_id, da.file_name, da.file_path, da.file_size, da.file_type,
-			da.version, da.is_current, da.uploaded_by, da.created_at
+			da.version, da.is_current, da.uploaded_by, da.created_at, ft.relative_path
 		FROM document_attachments da
 		INNER JOIN documents d ON d.id = da.document_id
 		INNER JOIN folder_tree ft ON d.folder_id = ft.id
 		WHERE da.is_current = true
-		ORDER BY da.created_at DESC
+		ORDER BY ft.relative_path, da.created_at DESC
 	`
 
 	rows, err := r.pool.Query(ctx, query, folderID)
@@ -295,9 +400,74 @@ func (r *postgresRepository) GetAttachmentsByFolderID(ctx context.Context, folde
 	}
 	defer rows.Close()
 
-	var attachments []*domain.DocumentAttachment
+	var attachments []*FolderAttachment
+	for rows.Next() {
+		var attachment domain.DocumentAttachment
+		var relativePath string
+		err := rows.Scan(
+			&attachment.ID,
+			&attachment.DocumentID,
+			&attachment.FileName,
+			&attachment.FilePath,
+			&attachment.FileSize,
+			&attachment.FileType,
+			&attachment.Version,
+			&attachment.IsCurrent,
+			&attachment.UploadedBy,
+			&attachment.CreatedAt,
+			&relativePath,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan attachment: %w", err)
+		}
+		attachments = append(attachments, &FolderAttachment{
+			DocumentAttachment: &attachment,
+			RelativePath:       relativePath,
+		})
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating attachments: %w", err)
+	}
+
+	return attachments, nil
+}
+
+// GetAttachmentsByOwnerID retrieves the current attachments of all documents in a user's storage tree
+// Each attachment carries its folder path from the user's root ("" for documents outside any folder)
+func (r *postgresRepository) GetAttachmentsByOwnerID(ctx context.Context, ownerID uuid.UUID) ([]*FolderAttachment, error) {
+	query := `
+		WITH RECURSIVE folder_tree AS (
+			-- Base case: the owner's root folders
+			SELECT id, name::text AS relative_path FROM folders WHERE owner_id = $1 AND parent_folder_id IS NULL
+			UNION ALL
+			-- Recursive case: all subfolders
+			SELECT f.id,
+				CASE WHEN ft.relative_path = '' THEN f.name ELSE ft.relative_path || '/' || f.name END
+			FROM folders f
+			INNER JOIN folder_tree ft ON f.parent_folder_id = ft.id
+		)
+		SELECT DISTINCT
+			da.id, da.document_id, da.file_name, da.file_path, da.file_size, da.file_type,
+			da.version, da.is_current, da.uploaded_by, da.created_at, COALESCE(ft.relative_path, '') AS relative_path
+		FROM document_attachments da
+		INNER JOIN documents d ON d.id = da.document_id
+		LEFT JOIN folder_tree ft ON d.folder_id = ft.id
+		WHERE da.is_current = true
+			AND (ft.id IS NOT NULL OR (d.folder_id IS NULL AND d.registrant_id = $1))
+		ORDER BY relative_path, da.created_at DESC
+	`
+
+	rows, err := r.pool.Query(ctx, query, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attachments by owner: %w", err)
+	}
+	defer rows.Close()
+
+	var attachments []*FolderAttachment
 	for rows.Next() {
 		var attachment domain.DocumentAttachment
+		var relativePath string
 		err := rows.Scan(
 			&attachment.ID,
 			&attachment.DocumentID,
@@ -309,11 +479,15 @@ func (r *postgresRepository) GetAttachmentsByFolderID(ctx context.Context, folde
 			&attachment.IsCurrent,
 			&attachment.UploadedBy,
 			&attachment.CreatedAt,
+			&relativePath,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan attachment: %w", err)
 		}
-		attachments = append(attachments, &attachment)
+		attachments = append(attachments, &FolderAttachment{
+			DocumentAttachment: &attachment,
+			RelativePath:       relativePath,
+		})
 	}
 
 	if err = rows.Err(); err != nil {
@@ -322,3 +496,226 @@ func (r *postgresRepository) GetAttachmentsByFolderID(ctx context.Context, folde
 
 	return attachments, nil
 }
+
+// AttachmentExistsByFilePath reports whether any attachment references the given storage object path
+func (r *postgresRepository) AttachmentExistsByFilePath(ctx context.Context, filePath string) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM document_attachments WHERE file_path = $1
+		)
+	`
+
+	var exists bool
+	if err := r.pool.QueryRow(ctx, query, filePath).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check attachment by file path: %w", err)
+	}
+
+	return exists, nil
+}
+
+// AttachmentExistsByUploadID reports whether an attachment was already created by the given tusd upload
+func (r *postgresRepository) AttachmentExistsByUploadID(ctx context.Context, uploadID string) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM document_attachments WHERE upload_id = $1
+		)
+	`
+
+	var exists bool
+	if err := r.pool.QueryRow(ctx, query, uploadID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check attachment by upload ID: %w", err)
+	}
+
+	return exists, nil
+}
+
+// FindFilePathByContentHash returns the object path of an attachment with the same content,
+// or an empty string if there is none
+func (r *postgresRepository) FindFilePathByContentHash(ctx context.Context, contentHash string, size int64) (string, error) {
+	query := `
+		SELECT file_path
+		FROM document_attachments
+		WHERE content_hash = $1 AND file_size = $2
+		ORDER BY created_at
+		LIMIT 1
+	`
+
+	var filePath string
+	err := r.pool.QueryRow(ctx, query, contentHash, size).Scan(&filePath)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to find attachment by content hash: %w", err)
+	}
+
+	return filePath, nil
+}
+
+// CanAccessDocument reports whether the user is the document's registrant, the document is shared with them,
+// or it is held by their department
+func (r *postgresRepository) CanAccessDocument(ctx context.Context, documentID, userID uuid.UUID) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM documents d
+			WHERE d.id = $1 AND (
+				d.registrant_id = $2 OR EXISTS (
+					SELECT 1 FROM document_shares ds
+					WHERE ds.document_id = d.id AND ds.shared_with_user_id = $2
+				) OR EXISTS (
+					SELECT 1 FROM users u
+					WHERE u.id = $2 AND u.department_id = d.current_department_id
+				)
+			)
+		)
+	`
+
+	var allowed bool
+	if err := r.pool.QueryRow(ctx, query, documentID, userID).Scan(&allowed); err != nil {
+		return false, fmt.Errorf("failed to check document access: %w", err)
+	}
+
+	return allowed, nil
+}
+
+// exportJobColumns lists the export_jobs columns in the order scanExportJob reads them
+const exportJobColumns = `id, owner_id, folder_id, file_name, status, total_files, processed_files, total_bytes,
+	object_key, error, created_at, updated_at, completed_at, expires_at`
+
+// scanExportJob scans a row selected with exportJobColumns
+func scanExportJob(row pgx.Row) (*domain.ExportJob, error) {
+	var job domain.ExportJob
+	err := row.Scan(
+		&job.ID,
+		&job.OwnerID,
+		&job.FolderID,
+		&job.FileName,
+		&job.Status,
+		&job.TotalFiles,
+		&job.ProcessedFiles,
+		&job.TotalBytes,
+		&job.ObjectKey,
+		&job.Error,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+		&job.CompletedAt,
+		&job.ExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// CreateExportJob inserts a new export job
+func (r *postgresRepository) CreateExportJob(ctx context.Context, job *domain.ExportJob) error {
+	query := `
+		INSERT INTO export_jobs (owner_id, folder_id, file_name, status, total_files, total_bytes)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at
+	`
+
+	err := r.pool.QueryRow(ctx, query,
+		job.OwnerID,
+		job.FolderID,
+		job.FileName,
+		job.Status,
+		job.TotalFiles,
+		job.TotalBytes,
+	).Scan(&job.ID, &job.CreatedAt, &job.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create export job: %w", err)
+	}
+
+	return nil
+}
+
+// GetExportJobByID retrieves an export job by its ID
+func (r *postgresRepository) GetExportJobByID(ctx context.Context, jobID uuid.UUID) (*domain.ExportJob, error) {
+	query := `SELECT ` + exportJobColumns + ` FROM export_jobs WHERE id = $1`
+
+	job, err := scanExportJob(r.pool.QueryRow(ctx, query, jobID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("export job not found")
+		}
+		return nil, fmt.Errorf("failed to get export job: %w", err)
+	}
+
+	return job, nil
+}
+
+// UpdateExportJob saves the status, progress and result of an export job
+func (r *postgresRepository) UpdateExportJob(ctx context.Context, job *domain.ExportJob) error {
+	query := `
+		UPDATE export_jobs
+		SET status = $2, processed_files = $3, object_key = $4, error = $5,
+			completed_at = $6, expires_at = $7, updated_at = NOW()
+		WHERE id = $1
+		RETURNING updated_at
+	`
+
+	err := r.pool.QueryRow(ctx, query,
+		job.ID,
+		job.Status,
+		job.ProcessedFiles,
+		job.ObjectKey,
+		job.Error,
+		job.CompletedAt,
+		job.ExpiresAt,
+	).Scan(&job.UpdatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return fmt.Errorf("export job not found")
+		}
+		return fmt.Errorf("failed to update export job: %w", err)
+	}
+
+	return nil
+}
+
+// GetExpiredExportJobs retrieves completed export jobs whose archive expired before the given time
+func (r *postgresRepository) GetExpiredExportJobs(ctx context.Context, before time.Time) ([]*domain.ExportJob, error) {
+	query := `
+		SELECT ` + exportJobColumns + `
+		FROM export_jobs
+		WHERE status = 'completed' AND expires_at < $1
+	`
+
+	rows, err := r.pool.Query(ctx, query, before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expired export jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []*domain.ExportJob
+	for rows.Next() {
+		job, err := scanExportJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan export job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating export jobs: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// FailStaleExportJobs marks pending and running jobs not updated since the given time as failed
+func (r *postgresRepository) FailStaleExportJobs(ctx context.Context, before time.Time, reason string) (int64, error) {
+	query := `
+		UPDATE export_jobs
+		SET status = 'failed', error = $2, updated_at = NOW()
+		WHERE status IN ('pending', 'running') AND updated_at < $1
+	`
+
+	result, err := r.pool.Exec(ctx, query, before, reason)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fail stale export jobs: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
@@ -0,0 +1,164 @@
+package upload
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/rs/zerolog/log"
+)
+
+// scanChunkSize is the size of each chunk streamed to clamd; clamd's StreamMaxLength caps the total
+const scanChunkSize = 64 * 1024
+
+// ErrScanSizeLimit is returned by Scan when the file is larger than clamd's StreamMaxLength
+var ErrScanSizeLimit = errors.New("file exceeds the clamd stream size limit")
+
+// ScanOversizePolicy decides what happens to an upload too large for clamd to scan
+type ScanOversizePolicy string
+
+const (
+	ScanOversizeReject ScanOversizePolicy = "reject" // the upload is refused with 413
+	ScanOversizeSkip   ScanOversizePolicy = "skip"   // the upload is kept without a scan
+)
+
+// parseScanOversizePolicy checks a policy name
+func parseScanOversizePolicy(value string) (ScanOversizePolicy, error) {
+	switch policy := ScanOversizePolicy(strings.ToLower(strings.TrimSpace(value))); policy {
+	case ScanOversizeReject, ScanOversizeSkip:
+		return policy, nil
+	}
+	return "", fmt.Errorf("must be %s or %s", ScanOversizeReject, ScanOversizeSkip)
+}
+
+// Scanner checks file contents for malware
+type Scanner interface {
+	// Scan reads r to the end and returns the name of the detected signature, or "" if clean
+	Scan(ctx context.Context, r io.Reader) (string, error)
+}
+
+// clamdScanner scans streams with a ClamAV daemon using the INSTREAM command
+type clamdScanner struct {
+	address string // host:port of clamd's TCP socket
+	timeout time.Duration
+}
+
+// NewClamdScanner creates a scanner talking to clamd at address (host:port)
+func NewClamdScanner(address string, timeout time.Duration) Scanner {
+	return &clamdScanner{
+		address: address,
+		timeout: timeout,
+	}
+}
+
+// Scan streams r to clamd in length-prefixed chunks and parses its verdict
+func (s *clamdScanner) Scan(ctx context.Context, r io.Reader) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", s.address)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to clamd: %w", err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return "", fmt.Errorf("failed to start clamd stream: %w", err)
+	}
+
+	buf := make([]byte, scanChunkSize)
+	size := make([]byte, 4)
+	for {
+		n, readErr := r.Read(buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size, uint32(n))
+			if _, err := conn.Write(size); err != nil {
+				return "", fmt.Errorf("failed to send chunk to clamd: %w", err)
+			}
+			if _, err := conn.Write(buf[:n]); err != nil {
+				return "", fmt.Errorf("failed to send chunk to clamd: %w", err)
+			}
+		}
+		if errors.Is(readErr, io.EOF) {
+			break
+		}
+		if readErr != nil {
+			return "", fmt.Errorf("failed to read file: %w", readErr)
+		}
+	}
+
+	// A zero-length chunk ends the stream
+	binary.BigEndian.PutUint32(size, 0)
+	if _, err := conn.Write(size); err != nil {
+		return "", fmt.Errorf("failed to end clamd stream: %w", err)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("failed to read clamd reply: %w", err)
+	}
+
+	return parseClamdReply(strings.TrimRight(reply, "\x00\n"))
+}
+
+// parseClamdReply interprets an INSTREAM reply such as "stream: OK" or "stream: Eicar-Signature FOUND"
+func parseClamdReply(reply string) (string, error) {
+	result := strings.TrimSpace(strings.TrimPrefix(reply, "stream:"))
+	switch {
+	case result == "OK":
+		return "", nil
+	case strings.HasSuffix(result, " FOUND"):
+		return strings.TrimSuffix(result, " FOUND"), nil
+	case strings.Contains(result, "size limit exceeded"):
+		// "INSTREAM size limit exceeded. ERROR": clamd stopped reading at StreamMaxLength
+		return "", ErrScanSizeLimit
+	default:
+		return "", fmt.Errorf("clamd error: %s", reply)
+	}
+}
+
+// scanUpload scans a stored upload and reports whether it may be kept
+// When the scanner cannot give a verdict the upload is kept only if ScanFailOpen is set; uploads too
+// large for clamd follow ScanOversize instead, and return ErrScanSizeLimit when they are rejected
+func (h *Handler) scanUpload(ctx context.Context, objectKey string) (bool, string, error) {
+	object, err := h.minioClient.GetObject(ctx, h.bucket, objectKey, minio.GetObjectOptions{})
+	if err != nil {
+		log.Error().Err(err).Str("object_key", objectKey).Msg("Failed to open upload for malware scan")
+		return h.tusConfig.ScanFailOpen, "", nil
+	}
+	defer object.Close()
+
+	signature, err := h.scanner.Scan(ctx, object)
+	if errors.Is(err, ErrScanSizeLimit) {
+		log.Warn().
+			Str("event", "upload_scan_size_limit").
+			Str("object_key", objectKey).
+			Str("policy", string(h.tusConfig.ScanOversize)).
+			Msg("Upload is larger than clamd scans")
+		if h.tusConfig.ScanOversize == ScanOversizeSkip {
+			return true, "", nil
+		}
+		return false, "", ErrScanSizeLimit
+	}
+	if err != nil {
+		log.Error().Err(err).
+			Str("object_key", objectKey).
+			Bool("fail_open", h.tusConfig.ScanFailOpen).
+			Msg("Malware scan failed")
+		return h.tusConfig.ScanFailOpen, "", nil
+	}
+
+	return signature == "", signature, nil
+}
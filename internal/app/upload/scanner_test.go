@@ -0,0 +1,83 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestParseClamdReply(t *testing.T) {
+	tests := []struct {
+		name          string
+		reply         string
+		wantSignature string
+		wantErr       error
+		wantAnyErr    bool
+	}{
+		{name: "clean", reply: "stream: OK"},
+		{name: "infected", reply: "stream: Eicar-Signature FOUND", wantSignature: "Eicar-Signature"},
+		{name: "size limit", reply: "INSTREAM size limit exceeded. ERROR", wantErr: ErrScanSizeLimit, wantAnyErr: true},
+		{name: "other error", reply: "stream: Can't allocate memory ERROR", wantAnyErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signature, err := parseClamdReply(tt.reply)
+			if signature != tt.wantSignature || (err != nil) != tt.wantAnyErr {
+				t.Fatalf("parseClamdReply() = %q, %v; want %q, error %v", signature, err, tt.wantSignature, tt.wantAnyErr)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("parseClamdReply() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && errors.Is(err, ErrScanSizeLimit) {
+				t.Fatalf("parseClamdReply() reported a size limit for %q", tt.reply)
+			}
+		})
+	}
+}
+
+// fixedScanner drains the stream and returns a fixed verdict
+type fixedScanner struct {
+	signature string
+	err       error
+}
+
+func (s fixedScanner) Scan(ctx context.Context, r io.Reader) (string, error) {
+	_, _ = io.Copy(io.Discard, r)
+	return s.signature, s.err
+}
+
+func TestScanUploadPolicies(t *testing.T) {
+	tests := []struct {
+		name          string
+		scanner       fixedScanner
+		config        TusConfig
+		wantClean     bool
+		wantSizeLimit bool
+	}{
+		{name: "clean", scanner: fixedScanner{}, wantClean: true},
+		{name: "infected", scanner: fixedScanner{signature: "Eicar-Signature"}},
+		{name: "too large, rejected", scanner: fixedScanner{err: ErrScanSizeLimit},
+			config: TusConfig{ScanOversize: ScanOversizeReject}, wantSizeLimit: true},
+		{name: "too large, kept unscanned", scanner: fixedScanner{err: ErrScanSizeLimit},
+			config: TusConfig{ScanOversize: ScanOversizeSkip}, wantClean: true},
+		{name: "too large is not a scan failure", scanner: fixedScanner{err: ErrScanSizeLimit},
+			config: TusConfig{ScanOversize: ScanOversizeReject, ScanFailOpen: true}, wantSizeLimit: true},
+		{name: "scanner down, fail safe", scanner: fixedScanner{err: errors.New("connection refused")}},
+		{name: "scanner down, fail open", scanner: fixedScanner{err: errors.New("connection refused")},
+			config: TusConfig{ScanFailOpen: true}, wantClean: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _ := newTestHandler(t, &fakeService{}, map[string][]byte{"uploads/file": []byte("content")}, tt.config)
+			h.scanner = tt.scanner
+
+			clean, _, err := h.scanUpload(context.Background(), "uploads/file")
+			if clean != tt.wantClean || errors.Is(err, ErrScanSizeLimit) != tt.wantSizeLimit {
+				t.Fatalf("scanUpload() = clean %v, error %v; want clean %v, size limit %v", clean, err, tt.wantClean, tt.wantSizeLimit)
+			}
+		})
+	}
+}
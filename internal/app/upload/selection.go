@@ -0,0 +1,197 @@
+package upload
+
+import (
+	"archive/zip"
+	"e-document-backend/internal/pkg/storage"
+	"e-document-backend/internal/util"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/minio/minio-go/v7"
+	"github.com/rs/zerolog/log"
+)
+
+// selectionManifestEntry is the ZIP entry describing a selection download
+const selectionManifestEntry = "manifest.json"
+
+// DownloadSelectionRequest is the request body of a multi-select download
+// At most 500 attachments can be zipped in one request
+type DownloadSelectionRequest struct {
+	AttachmentIDs []uuid.UUID `json:"attachment_ids" validate:"required,min=1,max=500"`
+}
+
+// SelectionManifest describes a selection download archive
+type SelectionManifest struct {
+	Files  []SelectionManifestFile  `json:"files"`
+	Failed []SelectionManifestError `json:"failed"`
+}
+
+// SelectionManifestFile describes one file in a selection download
+type SelectionManifestFile struct {
+	AttachmentID uuid.UUID `json:"attachment_id"`
+	Path         string    `json:"path"`
+}
+
+// SelectionManifestError describes an attachment that could not be included
+type SelectionManifestError struct {
+	AttachmentID uuid.UUID      `json:"attachment_id"`
+	ErrorCode    util.ErrorCode `json:"error_code"`
+	Error        string         `json:"error"`
+}
+
+// DownloadSelection godoc
+// @Summary		Download selected files as ZIP
+// @Description	Streams the current files of the selected attachments, which may live in different folders, as one ZIP archive. Each attachment is checked like a single download and the selection is capped like a folder ZIP; manifest.json lists the included files and any IDs that could not be fetched
+// @Tags		Upload
+// @Accept		json
+// @Produce		application/zip
+// @Security	BearerAuth
+// @Param		body	body		DownloadSelectionRequest	true	"Attachment IDs (at most 500)"
+// @Success		200		{file}		binary
+// @Failure		400		{object}	util.ErrorEnvelope
+// @Failure		401		{object}	util.ErrorEnvelope
+// @Failure		404		{object}	util.ErrorEnvelope
+// @Failure		413		{object}	util.ErrorEnvelope
+// @Router		/v1/upload/download/zip [post]
+func (h *Handler) DownloadSelection(c echo.Context) error {
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+	requesterID, err := uuid.Parse(userID)
+	if err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid user ID", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	var req DownloadSelectionRequest
+	if err := c.Bind(&req); err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid request body", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	if err := util.ValidateStruct(&req); err != nil {
+		return util.HandleError(c, err)
+	}
+
+	ctx := c.Request().Context()
+	manifest := SelectionManifest{
+		Files:  make([]SelectionManifestFile, 0, len(req.AttachmentIDs)),
+		Failed: []SelectionManifestError{},
+	}
+
+	// Pre-pass: check access and storage for every ID before any bytes are sent
+	available := make([]zipEntry, 0, len(req.AttachmentIDs))
+	availableIDs := make([]uuid.UUID, 0, len(req.AttachmentIDs))
+	seen := make(map[uuid.UUID]bool, len(req.AttachmentIDs))
+	usedNames := map[string]bool{selectionManifestEntry: true}
+	for _, attachmentID := range req.AttachmentIDs {
+		if seen[attachmentID] {
+			continue
+		}
+		seen[attachmentID] = true
+
+		attachment, err := h.service.GetAttachment(ctx, attachmentID, requesterID)
+		if err != nil {
+			// Only the public message of a known error goes into the manifest, never the raw error
+			failure := SelectionManifestError{AttachmentID: attachmentID, ErrorCode: util.INTERNAL_SERVER_ERROR, Error: "could not be fetched"}
+			if customErr, ok := util.GetCustomError(err); ok {
+				failure.ErrorCode = customErr.ErrorCode
+				failure.Error = customErr.Message
+			} else {
+				log.Error().Err(err).Str("attachment_id", attachmentID.String()).Msg("Failed to get attachment for selection ZIP")
+			}
+			manifest.Failed = append(manifest.Failed, failure)
+			continue
+		}
+
+		if _, err := h.minioClient.StatObject(ctx, h.bucket, attachment.FilePath, minio.StatObjectOptions{}); err != nil {
+			if storage.IsBucketNotFound(err) {
+				return util.HandleError(c, util.NewStorageUnavailableError(fmt.Sprintf("bucket %s does not exist", h.bucket)))
+			}
+			failure := SelectionManifestError{AttachmentID: attachmentID, ErrorCode: util.STORAGE_ERROR, Error: "could not be read from storage"}
+			if storage.IsObjectNotFound(err) {
+				failure.ErrorCode = util.STORAGE_OBJECT_MISSING
+				failure.Error = "recorded in the database but missing from storage"
+			}
+			log.Warn().Err(err).
+				Str("attachment_id", attachmentID.String()).
+				Str("file_path", attachment.FilePath).
+				Msg("Excluding attachment from selection ZIP")
+			manifest.Failed = append(manifest.Failed, failure)
+			continue
+		}
+
+		entry := &FolderAttachment{DocumentAttachment: attachment}
+		available = append(available, zipEntry{attachment: entry, name: uniqueEntryName(attachment.FileName, usedNames)})
+		availableIDs = append(availableIDs, attachmentID)
+	}
+
+	if len(available) == 0 {
+		return util.HandleError(c, util.ErrorResponse("No files to download", util.NOT_FOUND, 404, "None of the selected files could be fetched"))
+	}
+	if err := h.checkSelectionZipLimits(available); err != nil {
+		return util.HandleError(c, err)
+	}
+
+	// ZIP is built on the fly so its size is unknown up front: stream it chunked
+	c.Response().Header().Set("Content-Type", "application/zip")
+	c.Response().Header().Set("Content-Disposition", encodeFilename(fmt.Sprintf("documents-%s.zip", time.Now().UTC().Format("20060102-150405"))))
+	c.Response().Header().Set("Transfer-Encoding", "chunked")
+	c.Response().WriteHeader(200)
+
+	zipWriter := zip.NewWriter(c.Response().Writer)
+	defer zipWriter.Close()
+
+	for i, entry := range available {
+		if err := h.addObjectToZip(ctx, zipWriter, entry.name, entry.attachment.FilePath); err != nil {
+			log.Error().Err(err).
+				Str("file_path", entry.attachment.FilePath).
+				Str("entry", entry.name).
+				Msg("Failed to add file to selection ZIP")
+			manifest.Failed = append(manifest.Failed, SelectionManifestError{AttachmentID: availableIDs[i], ErrorCode: util.STORAGE_ERROR, Error: "could not be read from storage"})
+			continue
+		}
+		manifest.Files = append(manifest.Files, SelectionManifestFile{AttachmentID: availableIDs[i], Path: entry.name})
+	}
+
+	// Manifest goes last so it can list what actually made it into the archive
+	writer, err := zipWriter.Create(selectionManifestEntry)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create selection manifest entry")
+		return nil
+	}
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(manifest); err != nil {
+		log.Error().Err(err).Msg("Failed to write selection manifest")
+	}
+
+	log.Info().
+		Str("user_id", userID).
+		Int("files_count", len(manifest.Files)).
+		Int("errors_count", len(manifest.Failed)).
+		Msg("Selection download completed")
+
+	return nil
+}
+
+// checkSelectionZipLimits returns a 413 error if a selection exceeds the folder ZIP file count or size
+func (h *Handler) checkSelectionZipLimits(entries []zipEntry) error {
+	const guidance = "select fewer files or download them in several archives"
+
+	if h.tusConfig.FolderZipMaxFiles > 0 && len(entries) > h.tusConfig.FolderZipMaxFiles {
+		return util.NewPayloadTooLargeError("Selection too large to download", fmt.Sprintf("%d files were selected, the limit is %d; %s", len(entries), h.tusConfig.FolderZipMaxFiles, guidance))
+	}
+
+	var totalSize int64
+	for _, entry := range entries {
+		totalSize += entry.attachment.FileSize
+	}
+	if h.tusConfig.FolderZipMaxBytes > 0 && totalSize > h.tusConfig.FolderZipMaxBytes {
+		return util.NewPayloadTooLargeError("Selection too large to download", fmt.Sprintf("The selected files hold %d bytes, the limit is %d bytes; %s", totalSize, h.tusConfig.FolderZipMaxBytes, guidance))
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package upload
+
+import (
+	"context"
+	"e-document-backend/internal/domain"
+	"e-document-backend/internal/util"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// selectionService returns attachments, or errors, by ID
+type selectionService struct {
+	Service
+	attachments map[uuid.UUID]*domain.DocumentAttachment
+	errs        map[uuid.UUID]error
+}
+
+func (s *selectionService) GetAttachment(ctx context.Context, attachmentID, requesterID uuid.UUID) (*domain.DocumentAttachment, error) {
+	if err, ok := s.errs[attachmentID]; ok {
+		return nil, err
+	}
+	return s.attachments[attachmentID], nil
+}
+
+// serveSelection runs DownloadSelection for the given attachment IDs and returns the response
+func serveSelection(h *Handler, ids ...uuid.UUID) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(DownloadSelectionRequest{AttachmentIDs: ids})
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/v1/upload/download/zip", strings.NewReader(string(body)))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set("user_id", uuid.NewString())
+	_ = h.DownloadSelection(c)
+	return rec
+}
+
+func TestDownloadSelectionManifest(t *testing.T) {
+	readable := newFolderAttachment("", "budget.pdf", "uploads/budget", 6).DocumentAttachment
+	missing := newFolderAttachment("", "missing.pdf", "uploads/missing", 4).DocumentAttachment
+	brokenID, forbiddenID := uuid.New(), uuid.New()
+	svc := &selectionService{
+		attachments: map[uuid.UUID]*domain.DocumentAttachment{readable.ID: readable, missing.ID: missing},
+		errs: map[uuid.UUID]error{
+			brokenID:    errors.New("failed to get attachment: dial tcp 10.0.0.5:5432: connection refused"),
+			forbiddenID: util.NewForbiddenError("You do not have access to this document"),
+		},
+	}
+	h, _ := newTestHandler(t, svc, map[string][]byte{"uploads/budget": []byte("budget")}, TusConfig{})
+
+	rec := serveSelection(h, readable.ID, missing.ID, brokenID, forbiddenID)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+	entries := readArchive(t, rec.Body.Bytes())
+	if string(entries["budget.pdf"]) != "budget" {
+		t.Fatalf("archive entries = %v, want budget.pdf", entries)
+	}
+
+	raw := string(entries[selectionManifestEntry])
+	if strings.Contains(raw, "10.0.0.5") || strings.Contains(raw, "dial tcp") {
+		t.Fatalf("manifest leaks an internal error: %s", raw)
+	}
+	var manifest SelectionManifest
+	if err := json.Unmarshal(entries[selectionManifestEntry], &manifest); err != nil {
+		t.Fatalf("manifest is not valid JSON: %v", err)
+	}
+	if len(manifest.Files) != 1 || len(manifest.Failed) != 3 {
+		t.Fatalf("manifest = %d files, %d failed; want 1 and 3", len(manifest.Files), len(manifest.Failed))
+	}
+	wantCodes := map[uuid.UUID]util.ErrorCode{
+		missing.ID:  util.STORAGE_OBJECT_MISSING,
+		brokenID:    util.INTERNAL_SERVER_ERROR,
+		forbiddenID: util.FORBIDDEN,
+	}
+	for _, failure := range manifest.Failed {
+		if failure.ErrorCode != wantCodes[failure.AttachmentID] {
+			t.Errorf("failure of %s = %s, want %s", failure.AttachmentID, failure.ErrorCode, wantCodes[failure.AttachmentID])
+		}
+	}
+}
+
+func TestDownloadSelectionLimits(t *testing.T) {
+	first := newFolderAttachment("", "a.pdf", "uploads/a", 6).DocumentAttachment
+	second := newFolderAttachment("", "b.pdf", "uploads/b", 6).DocumentAttachment
+	svc := &selectionService{attachments: map[uuid.UUID]*domain.DocumentAttachment{first.ID: first, second.ID: second}}
+	objects := map[string][]byte{"uploads/a": []byte("aaaaaa"), "uploads/b": []byte("bbbbbb")}
+
+	tests := []struct {
+		name       string
+		config     TusConfig
+		wantStatus int
+	}{
+		{name: "within the limits", config: TusConfig{FolderZipMaxFiles: 2, FolderZipMaxBytes: 12}, wantStatus: http.StatusOK},
+		{name: "too many files", config: TusConfig{FolderZipMaxFiles: 1}, wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "too many bytes", config: TusConfig{FolderZipMaxBytes: 11}, wantStatus: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _ := newTestHandler(t, svc, objects, tt.config)
+			rec := serveSelection(h, first.ID, second.ID)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestUploadRouteBodyLimit(t *testing.T) {
+	_, _, server := newTusTestServer(t, &completionService{}, uuid.New())
+
+	// Well past the JSON body limit, which still applies next to the tus routes
+	ids := make([]uuid.UUID, 100)
+	for i := range ids {
+		ids[i] = uuid.New()
+	}
+	body, _ := json.Marshal(DownloadSelectionRequest{AttachmentIDs: ids})
+	if len(body) <= testJSONBodyLimit {
+		t.Fatalf("request body of %d bytes does not exceed the limit", len(body))
+	}
+
+	resp, err := http.Post(server.URL+"/api/v1/upload/download/zip", echo.MIMEApplicationJSON, strings.NewReader(string(body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want 413", resp.StatusCode)
+	}
+}
@@ -3,11 +3,17 @@ package upload
 import (
 	"context"
 	"e-document-backend/internal/domain"
+	"e-document-backend/internal/util"
+	"errors"
 	"fmt"
 	"path/filepath"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/rs/zerolog/log"
 )
 
@@ -16,16 +22,56 @@ type Service interface {
 	// ProcessUploadComplete handles the post-upload logic: folder creation, document, attachment
 	ProcessUploadComplete(ctx context.Context, params ProcessUploadParams) (*ProcessUploadResult, error)
 
-	// GetAttachment retrieves attachment details by ID
-	GetAttachment(ctx context.Context, attachmentID uuid.UUID) (*domain.DocumentAttachment, error)
+	// GetAttachment retrieves attachment details by ID if the requester may read its document
+	GetAttachment(ctx context.Context, attachmentID, requesterID uuid.UUID) (*domain.DocumentAttachment, error)
 
 	// GetFolderAttachments retrieves all attachments in a folder (recursively)
-	GetFolderAttachments(ctx context.Context, folderID uuid.UUID) ([]*domain.DocumentAttachment, error)
+	GetFolderAttachments(ctx context.Context, folderID uuid.UUID) ([]*FolderAttachment, error)
 
-	// GetFolder retrieves folder details by ID
-	GetFolder(ctx context.Context, folderID uuid.UUID) (*domain.Folder, error)
+	// GetOwnerAttachments retrieves the current attachments of all documents owned by a user
+	GetOwnerAttachments(ctx context.Context, ownerID uuid.UUID) ([]*FolderAttachment, error)
+
+	// EnsureFolderPath finds or creates every folder of a path below an optional parent folder
+	EnsureFolderPath(ctx context.Context, ownerID uuid.UUID, req domain.EnsureFolderPathRequest) (*domain.EnsureFolderPathResult, error)
+
+	// GetFolder retrieves details of a folder owned by the requester
+	GetFolder(ctx context.Context, folderID, requesterID uuid.UUID) (*domain.Folder, error)
+
+	// DeleteDocument deletes a document owned by the requester and returns the object paths no longer referenced
+	DeleteDocument(ctx context.Context, documentID, requesterID uuid.UUID) ([]string, error)
+
+	// IsFilePathReferenced reports whether a storage object is referenced by an attachment
+	IsFilePathReferenced(ctx context.Context, filePath string) (bool, error)
+
+	// FindFilePathByContentHash returns the object path of an attachment with identical content, if any
+	FindFilePathByContentHash(ctx context.Context, contentHash string, size int64) (string, error)
+
+	// IsUploadProcessed reports whether a tusd upload already created its document and attachment
+	IsUploadProcessed(ctx context.Context, uploadID string) (bool, error)
+
+	// IsFileNameTaken reports whether an upload to relativePath would collide with an existing document
+	IsFileNameTaken(ctx context.Context, ownerID uuid.UUID, parentFolderID *uuid.UUID, relativePath string) (bool, error)
+
+	// CreateExportJob records a new background folder export
+	CreateExportJob(ctx context.Context, job *domain.ExportJob) error
+
+	// GetExportJob retrieves an export job started by the requester
+	GetExportJob(ctx context.Context, jobID, requesterID uuid.UUID) (*domain.ExportJob, error)
+
+	// UpdateExportJob saves the status, progress and result of an export job
+	UpdateExportJob(ctx context.Context, job *domain.ExportJob) error
+
+	// GetExpiredExportJobs retrieves completed export jobs whose archive expired before the given time
+	GetExpiredExportJobs(ctx context.Context, before time.Time) ([]*domain.ExportJob, error)
+
+	// FailStaleExportJobs marks unfinished export jobs without progress since the given time as failed
+	FailStaleExportJobs(ctx context.Context, before time.Time, reason string) (int64, error)
 }
 
+// ErrUploadAlreadyProcessed is returned by ProcessUploadComplete when another completion event
+// for the same upload created the attachment first
+var ErrUploadAlreadyProcessed = errors.New("upload already processed")
+
 // ProcessUploadParams contains parameters for processing an upload
 type ProcessUploadParams struct {
 	RelativePath   string     // e.g., "Photos/2024/beach.jpg"
@@ -35,6 +81,10 @@ type ProcessUploadParams struct {
 	FileSize       int64      // file size in bytes
 	FileType       string     // file MIME type
 	UploadID       string     // tusd upload ID
+	ContentHash    string     // SHA-256 of the file, empty if unknown
+	DuplicateOf    string     // stored object with identical content, reused instead of FilePath while still referenced
+
+	OnDuplicate DuplicateStrategy // what to do if the file name is taken in the folder; empty renames
 }
 
 // ProcessUploadResult contains the result of processing an upload
@@ -57,180 +107,324 @@ func NewService(repo Repository) Service {
 }
 
 // ProcessUploadComplete handles the complete upload processing with transaction
+// A file name already taken in the target folder is resolved by params.OnDuplicate
 func (s *service) ProcessUploadComplete(ctx context.Context, params ProcessUploadParams) (*ProcessUploadResult, error) {
-	// Start transaction
-	tx, err := s.repo.BeginTx(ctx)
+	// Parse the relative path
+	pathParts, err := parsePath(params.RelativePath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+		return nil, fmt.Errorf("invalid relative path %s: %w", params.RelativePath, err)
+	}
+	if len(pathParts) == 0 {
+		return nil, fmt.Errorf("invalid relative path: %s", params.RelativePath)
 	}
 
-	// Ensure transaction is rolled back on error
-	defer func() {
-		if err != nil {
-			if rbErr := tx.Rollback(ctx); rbErr != nil {
-				log.Error().Err(rbErr).Msg("failed to rollback transaction")
-			}
-		}
-	}()
+	// The last part is the filename, everything before is folder path
+	fileName := pathParts[len(pathParts)-1]
+	folderParts := pathParts[:len(pathParts)-1]
+
+	// New folders continue the path of the folder the upload goes into
+	basePath, err := s.folderBasePath(ctx, params.OwnerID, params.ParentFolderID)
+	if err != nil {
+		return nil, err
+	}
 
 	result := &ProcessUploadResult{
 		Folders: make([]*domain.Folder, 0),
 	}
 
-	// Parse the relative path
-	pathParts := parsePath(params.RelativePath)
-	if len(pathParts) == 0 {
-		err = fmt.Errorf("invalid relative path: %s", params.RelativePath)
-		return nil, err
-	}
+	err = s.repo.WithTx(ctx, func(tx pgx.Tx) error {
+		// Process folder hierarchy
+		folders, _, err := s.ensureFolderChain(ctx, tx, params.OwnerID, params.ParentFolderID, basePath, folderParts)
+		if err != nil {
+			return err
+		}
+		result.Folders = folders
 
-	// The last part is the filename, everything before is folder path
-	fileName := pathParts[len(pathParts)-1]
-	folderParts := pathParts[:len(pathParts)-1]
+		// The document goes into the last folder of the hierarchy
+		currentParentID := params.ParentFolderID
+		if len(folders) > 0 {
+			currentParentID = &folders[len(folders)-1].ID
+		}
 
-	// Process folder hierarchy
-	var currentParentID *uuid.UUID = params.ParentFolderID
-	var currentPath string
+		// Uploads into the same folder are serialised until the transaction ends, so concurrent
+		// uploads of one file name see each other
+		if err := s.repo.LockFolderForUpload(ctx, tx, currentParentID, params.OwnerID); err != nil {
+			return err
+		}
 
-	for i, folderName := range folderParts {
-		// Build the path for this folder level
-		if currentPath == "" {
-			currentPath = folderName
-		} else {
-			currentPath = currentPath + "/" + folderName
+		existing, err := s.repo.FindDocumentByFileName(ctx, tx, currentParentID, params.OwnerID, fileName)
+		if err != nil {
+			return err
 		}
 
-		// Determine if this is a root folder
-		// It's a root folder ONLY if:
-		// 1. It's the first folder in the path AND
-		// 2. No parent_folder_id was provided from the client
-		isRootFolder := i == 0 && params.ParentFolderID == nil
-
-		// Try to find existing folder
-		folder, findErr := s.repo.FindFolderByNameAndParent(ctx, tx, folderName, currentParentID, params.OwnerID)
-		if findErr != nil {
-			err = findErr
-			return nil, err
+		version := 1
+		if existing != nil {
+			switch params.OnDuplicate {
+			case DuplicateReject:
+				return ErrDuplicateFileName
+			case DuplicateVersion:
+				latest, err := s.repo.GetLatestVersionByDocumentID(ctx, tx, existing.ID)
+				if err != nil {
+					return err
+				}
+				if err := s.repo.SetPreviousVersionsNotCurrent(ctx, tx, existing.ID); err != nil {
+					return err
+				}
+				version = latest + 1
+			default:
+				renamed, err := s.freeFileName(ctx, tx, currentParentID, params.OwnerID, fileName)
+				if err != nil {
+					return err
+				}
+				log.Info().
+					Str("file_name", fileName).
+					Str("renamed_to", renamed).
+					Msg("File name already taken in folder, renamed upload")
+				fileName = renamed
+				existing = nil
+			}
 		}
 
-		if folder == nil {
-			// Create new folder
-			folder = &domain.Folder{
-				Name:           folderName,
-				Path:           currentPath,
-				IsRootFolder:   isRootFolder,
-				ParentFolderID: currentParentID,
-				OwnerID:        params.OwnerID,
+		if existing != nil {
+			result.Document = existing
+
+			log.Info().
+				Str("document_id", existing.ID.String()).
+				Int("version", version).
+				Msg("Adding new version to existing document")
+		} else {
+			// Create document - use the filename as title
+			titleWithoutExt := strings.TrimSuffix(fileName, filepath.Ext(fileName))
+			doc := &domain.Document{
+				Title:        titleWithoutExt,
+				Type:         domain.DocumentTypeGeneral,
+				FolderID:     currentParentID, // Last folder in the hierarchy
+				RegistrantID: &params.OwnerID,
+				Status:       domain.DocumentStatusDraft,
 			}
 
-			if createErr := s.repo.CreateFolder(ctx, tx, folder); createErr != nil {
-				err = createErr
-				return nil, err
+			if err := s.repo.CreateDocument(ctx, tx, doc); err != nil {
+				return err
 			}
+			result.Document = doc
 
 			log.Info().
-				Str("folder_name", folderName).
-				Str("path", currentPath).
-				Bool("is_root", isRootFolder).
-				Msg("Created new folder")
+				Str("document_id", doc.ID.String()).
+				Str("title", doc.Title).
+				Msg("Created new document")
 		}
 
-		result.Folders = append(result.Folders, folder)
-		currentParentID = &folder.ID
-	}
+		filePath, err := s.reuseDuplicate(ctx, tx, params)
+		if err != nil {
+			return err
+		}
 
-	// Create document - use the filename as title
-	titleWithoutExt := strings.TrimSuffix(fileName, filepath.Ext(fileName))
-	doc := &domain.Document{
-		Title:        titleWithoutExt,
-		Type:         domain.DocumentTypeGeneral,
-		FolderID:     currentParentID, // Last folder in the hierarchy
-		RegistrantID: &params.OwnerID,
-		Status:       domain.DocumentStatusDraft,
-	}
+		// Create attachment
+		attachment := &domain.DocumentAttachment{
+			DocumentID: result.Document.ID,
+			FileName:   fileName,
+			FilePath:   filePath,
+			FileSize:   params.FileSize,
+			FileType:   params.FileType,
+			Version:    version,
+			IsCurrent:  true,
+			UploadedBy: &params.OwnerID,
+		}
+		if params.ContentHash != "" {
+			attachment.ContentHash = &params.ContentHash
+		}
+		if params.UploadID != "" {
+			attachment.UploadID = &params.UploadID
+		}
+
+		if err := s.repo.CreateAttachment(ctx, tx, attachment); err != nil {
+			return err
+		}
+		result.Attachment = attachment
+
+		log.Info().
+			Str("attachment_id", attachment.ID.String()).
+			Str("file_name", attachment.FileName).
+			Str("file_path", attachment.FilePath).
+			Int64("file_size", attachment.FileSize).
+			Msg("Created new attachment")
 
-	if createErr := s.repo.CreateDocument(ctx, tx, doc); createErr != nil {
-		err = createErr
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
-	result.Document = doc
 
-	log.Info().
-		Str("document_id", doc.ID.String()).
-		Str("title", doc.Title).
-		Msg("Created new document")
+	return result, nil
+}
 
-	// Create attachment
-	attachment := &domain.DocumentAttachment{
-		DocumentID: doc.ID,
-		FileName:   fileName,
-		FilePath:   params.FilePath,
-		FileSize:   params.FileSize,
-		FileType:   params.FileType,
-		Version:    1,
-		IsCurrent:  true,
-		UploadedBy: &params.OwnerID,
+// reuseDuplicate returns the object the attachment should reference: params.DuplicateOf if
+// another attachment still references it, otherwise the uploaded object. The lock keeps a
+// concurrent delete from removing the duplicate until this transaction commits
+func (s *service) reuseDuplicate(ctx context.Context, tx pgx.Tx, params ProcessUploadParams) (string, error) {
+	if params.DuplicateOf == "" {
+		return params.FilePath, nil
 	}
 
-	if createErr := s.repo.CreateAttachment(ctx, tx, attachment); createErr != nil {
-		err = createErr
-		return nil, err
+	if err := s.repo.LockFilePaths(ctx, tx, []string{params.DuplicateOf}); err != nil {
+		return "", err
 	}
-	result.Attachment = attachment
-
-	log.Info().
-		Str("attachment_id", attachment.ID.String()).
-		Str("file_name", attachment.FileName).
-		Str("file_path", attachment.FilePath).
-		Int64("file_size", attachment.FileSize).
-		Msg("Created new attachment")
-
-	// Commit transaction
-	if commitErr := tx.Commit(ctx); commitErr != nil {
-		err = commitErr
-		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	unreferenced, err := s.repo.FilterUnreferencedFilePaths(ctx, tx, []string{params.DuplicateOf})
+	if err != nil {
+		return "", err
+	}
+	if len(unreferenced) > 0 {
+		log.Info().
+			Str("upload_id", params.UploadID).
+			Str("existing_object", params.DuplicateOf).
+			Msg("Duplicate object was deleted meanwhile, storing the upload as is")
+		return params.FilePath, nil
 	}
 
-	return result, nil
+	return params.DuplicateOf, nil
 }
 
 // parsePath splits a path string into individual parts, handling both / and \ separators
-func parsePath(path string) []string {
+// Paths must be relative: absolute paths, drive letters, empty segments and . or .. are refused
+// so a path can never leave the folder it is resolved against; a trailing separator is ignored
+func parsePath(path string) ([]string, error) {
 	// Normalize path separators
 	normalized := strings.ReplaceAll(path, "\\", "/")
 
-	// Remove leading/trailing slashes
-	normalized = strings.Trim(normalized, "/")
+	if strings.HasPrefix(normalized, "/") {
+		return nil, fmt.Errorf("must be relative, not start with a separator")
+	}
+	if len(normalized) >= 2 && normalized[1] == ':' && unicode.IsLetter(rune(normalized[0])) {
+		return nil, fmt.Errorf("must be relative, not start with a drive letter")
+	}
+
+	// Remove trailing slashes
+	normalized = strings.TrimRight(normalized, "/")
 
 	if normalized == "" {
-		return []string{}
+		return []string{}, nil
 	}
 
 	// Split by /
 	parts := strings.Split(normalized, "/")
+	if err := checkPathSegments(parts); err != nil {
+		return nil, err
+	}
 
-	// Filter out empty parts
-	result := make([]string, 0, len(parts))
-	for _, part := range parts {
-		if part != "" {
-			result = append(result, part)
+	return parts, nil
+}
+
+// checkPathSegments refuses path segments that could escape the intended folder or that no
+// folder or file name could hold
+func checkPathSegments(names []string) error {
+	for _, name := range names {
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("must not contain empty segments")
+		}
+		if name == "." || name == ".." {
+			return fmt.Errorf("must not contain . or .. segments")
+		}
+		if strings.ContainsFunc(name, unicode.IsControl) {
+			return fmt.Errorf("must not contain control characters")
+		}
+		if utf8.RuneCountInString(name) > maxFolderNameLength {
+			return fmt.Errorf("names must be at most %d characters", maxFolderNameLength)
 		}
 	}
-
-	return result
+	return nil
 }
 
 // GetAttachment retrieves attachment details by ID
-func (s *service) GetAttachment(ctx context.Context, attachmentID uuid.UUID) (*domain.DocumentAttachment, error) {
-	return s.repo.GetAttachmentByID(ctx, attachmentID)
+// Only the document's registrant, users it is shared with and members of the department holding it may read it
+func (s *service) GetAttachment(ctx context.Context, attachmentID, requesterID uuid.UUID) (*domain.DocumentAttachment, error) {
+	attachment, err := s.repo.GetAttachmentByID(ctx, attachmentID)
+	if err != nil {
+		return nil, util.NewNotFoundError("Attachment", attachmentID.String())
+	}
+
+	allowed, err := s.repo.CanAccessDocument(ctx, attachment.DocumentID, requesterID)
+	if err != nil {
+		return nil, util.NewDatabaseError("check document access", err)
+	}
+	if !allowed {
+		return nil, util.NewForbiddenError("you do not have access to this file")
+	}
+
+	return attachment, nil
 }
 
 // GetFolderAttachments retrieves all attachments in a folder (recursively)
-func (s *service) GetFolderAttachments(ctx context.Context, folderID uuid.UUID) ([]*domain.DocumentAttachment, error) {
+func (s *service) GetFolderAttachments(ctx context.Context, folderID uuid.UUID) ([]*FolderAttachment, error) {
 	return s.repo.GetAttachmentsByFolderID(ctx, folderID)
 }
 
-// GetFolder retrieves folder details by ID
-func (s *service) GetFolder(ctx context.Context, folderID uuid.UUID) (*domain.Folder, error) {
-	return s.repo.GetFolderByID(ctx, folderID)
+// GetOwnerAttachments retrieves the current attachments of all documents owned by a user
+func (s *service) GetOwnerAttachments(ctx context.Context, ownerID uuid.UUID) ([]*FolderAttachment, error) {
+	return s.repo.GetAttachmentsByOwnerID(ctx, ownerID)
+}
+
+// GetFolder retrieves details of a folder owned by the requester
+func (s *service) GetFolder(ctx context.Context, folderID, requesterID uuid.UUID) (*domain.Folder, error) {
+	folder, err := s.repo.GetFolderByID(ctx, folderID)
+	if err != nil {
+		return nil, util.NewNotFoundError("Folder", folderID.String())
+	}
+	if folder.OwnerID != requesterID {
+		return nil, util.NewForbiddenError("you do not own this folder")
+	}
+
+	return folder, nil
+}
+
+// IsFilePathReferenced reports whether a storage object is referenced by an attachment
+// Deduplicated uploads share one object, so it stays referenced until its last attachment is gone
+func (s *service) IsFilePathReferenced(ctx context.Context, filePath string) (bool, error) {
+	return s.repo.AttachmentExistsByFilePath(ctx, filePath)
+}
+
+// IsUploadProcessed reports whether a tusd upload already created its document and attachment
+func (s *service) IsUploadProcessed(ctx context.Context, uploadID string) (bool, error) {
+	return s.repo.AttachmentExistsByUploadID(ctx, uploadID)
+}
+
+// FindFilePathByContentHash returns the object path of an attachment with identical content, if any
+func (s *service) FindFilePathByContentHash(ctx context.Context, contentHash string, size int64) (string, error) {
+	return s.repo.FindFilePathByContentHash(ctx, contentHash, size)
+}
+
+// CreateExportJob records a new background folder export
+func (s *service) CreateExportJob(ctx context.Context, job *domain.ExportJob) error {
+	if err := s.repo.CreateExportJob(ctx, job); err != nil {
+		return util.NewDatabaseError("create export job", err)
+	}
+	return nil
+}
+
+// GetExportJob retrieves an export job; only the user who started it may see it
+func (s *service) GetExportJob(ctx context.Context, jobID, requesterID uuid.UUID) (*domain.ExportJob, error) {
+	job, err := s.repo.GetExportJobByID(ctx, jobID)
+	if err != nil {
+		return nil, util.NewNotFoundError("Export job", jobID.String())
+	}
+	if job.OwnerID != requesterID {
+		return nil, util.NewForbiddenError("you did not start this export")
+	}
+
+	return job, nil
+}
+
+// UpdateExportJob saves the status, progress and result of an export job
+func (s *service) UpdateExportJob(ctx context.Context, job *domain.ExportJob) error {
+	return s.repo.UpdateExportJob(ctx, job)
+}
+
+// GetExpiredExportJobs retrieves completed export jobs whose archive expired before the given time
+func (s *service) GetExpiredExportJobs(ctx context.Context, before time.Time) ([]*domain.ExportJob, error) {
+	return s.repo.GetExpiredExportJobs(ctx, before)
+}
+
+// FailStaleExportJobs marks unfinished export jobs without progress since the given time as failed
+// Their worker died with the process that ran it, so nothing else will ever finish them
+func (s *service) FailStaleExportJobs(ctx context.Context, before time.Time, reason string) (int64, error) {
+	return s.repo.FailStaleExportJobs(ctx, before, reason)
 }
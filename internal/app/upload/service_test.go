@@ -0,0 +1,57 @@
+package upload
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		name      string
+		path      string
+		wantParts []string
+		wantErr   bool
+	}{
+		{name: "file only", path: "report.pdf", wantParts: []string{"report.pdf"}},
+		{name: "nested folders", path: "A/B/report.pdf", wantParts: []string{"A", "B", "report.pdf"}},
+		{name: "backslashes", path: `A\B\report.pdf`, wantParts: []string{"A", "B", "report.pdf"}},
+		{name: "mixed separators", path: `A\B/C\report.pdf`, wantParts: []string{"A", "B", "C", "report.pdf"}},
+		{name: "trailing separators", path: `A/B\/`, wantParts: []string{"A", "B"}},
+		{name: "dots inside names", path: "v1..2/.hidden/report.v2.pdf", wantParts: []string{"v1..2", ".hidden", "report.v2.pdf"}},
+		{name: "empty", path: "", wantParts: []string{}},
+
+		{name: "parent segment", path: "../../etc/passwd", wantErr: true},
+		{name: "parent segment in the middle", path: "A/../B/report.pdf", wantErr: true},
+		{name: "parent segment behind a backslash", path: `A\..\report.pdf`, wantErr: true},
+		{name: "current segment", path: "A/./report.pdf", wantErr: true},
+		{name: "parent segment only", path: "..", wantErr: true},
+		{name: "leading slash", path: "/etc/passwd", wantErr: true},
+		{name: "leading backslash", path: `\A\report.pdf`, wantErr: true},
+		{name: "UNC path", path: `\\server\share\report.pdf`, wantErr: true},
+		{name: "drive letter", path: `C:\Users\report.pdf`, wantErr: true},
+		{name: "drive letter with slashes", path: "d:/Users/report.pdf", wantErr: true},
+		{name: "drive-relative", path: "C:report.pdf", wantErr: true},
+		{name: "empty segment", path: "A//report.pdf", wantErr: true},
+		{name: "blank segment", path: "A/ /report.pdf", wantErr: true},
+		{name: "control character", path: "A/re\x00port.pdf", wantErr: true},
+		{name: "name too long", path: "A/" + strings.Repeat("a", maxFolderNameLength+1), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts, err := parsePath(tt.path)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parsePath(%q) = %q, want an error", tt.path, parts)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parsePath(%q) error = %v", tt.path, err)
+			}
+			if strings.Join(parts, "|") != strings.Join(tt.wantParts, "|") || len(parts) != len(tt.wantParts) {
+				t.Fatalf("parsePath(%q) = %q, want %q", tt.path, parts, tt.wantParts)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// sniffLength is the number of leading bytes http.DetectContentType considers
+const sniffLength = 512
+
+// sniffedAs lists, per declared type, the types http.DetectContentType reports for genuine files
+// Declared types missing here are not verified, since Go cannot recognise them reliably
+// (plain MP4/MP3 variants, CSV, proprietary formats) and a false rejection would lose the upload
+var sniffedAs = map[string][]string{
+	"application/pdf":              {"application/pdf"},
+	"image/jpeg":                   {"image/jpeg"},
+	"image/png":                    {"image/png"},
+	"image/gif":                    {"image/gif"},
+	"image/webp":                   {"image/webp"},
+	"image/bmp":                    {"image/bmp"},
+	"application/zip":              {"application/zip"},
+	"application/x-rar-compressed": {"application/x-rar-compressed"},
+	"text/plain":                   {"text/plain"},
+
+	// Office Open XML documents are ZIP archives
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document":   {"application/zip"},
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":         {"application/zip"},
+	"application/vnd.openxmlformats-officedocument.presentationml.presentation": {"application/zip"},
+
+	// Legacy Office documents are OLE2 compound files, which Go does not recognise
+	"application/msword":            {"application/octet-stream"},
+	"application/vnd.ms-excel":      {"application/octet-stream"},
+	"application/vnd.ms-powerpoint": {"application/octet-stream"},
+}
+
+// sniffUpload detects the content type of a stored object from its first bytes
+// An empty object yields "" since there is nothing to compare
+func (h *Handler) sniffUpload(ctx context.Context, objectKey string) (string, error) {
+	opts := minio.GetObjectOptions{}
+	if err := opts.SetRange(0, sniffLength-1); err != nil {
+		return "", err
+	}
+
+	object, err := h.minioClient.GetObject(ctx, h.bucket, objectKey, opts)
+	if err != nil {
+		return "", fmt.Errorf("failed to open object: %w", err)
+	}
+	defer object.Close()
+
+	buf := make([]byte, sniffLength)
+	n, err := io.ReadFull(object, buf)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("failed to read object: %w", err)
+	}
+	if n == 0 {
+		return "", nil
+	}
+
+	return baseMediaType(http.DetectContentType(buf[:n])), nil
+}
+
+// contentTypeMismatch checks the sniffed type against both the declared file_type and the type
+// implied by the filename's extension, returning the first claimed type the content contradicts
+func contentTypeMismatch(declaredType, fileName, sniffedType string) (string, bool) {
+	claims := []string{baseMediaType(declaredType), GetFileTypeFromPath(fileName)}
+	for _, claimed := range claims {
+		allowed, ok := sniffedAs[claimed]
+		if !ok {
+			continue
+		}
+		matched := false
+		for _, candidate := range allowed {
+			if candidate == sniffedType {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return claimed, true
+		}
+	}
+	return "", false
+}
+
+// baseMediaType strips parameters such as charset from a media type and lowercases it
+func baseMediaType(contentType string) string {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		return mediaType
+	}
+	return strings.ToLower(strings.TrimSpace(contentType))
+}
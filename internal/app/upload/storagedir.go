@@ -0,0 +1,97 @@
+package upload
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// prepareStorageDir creates the tusd lock directory and checks it can be used, returning its absolute path
+// When base is set the directory must lie inside it, also after resolving symlinks, so a
+// misconfigured TUSD_STORAGE_DIR cannot make the file locker write elsewhere on the host
+func prepareStorageDir(dir, base, modeValue string) (string, error) {
+	if strings.TrimSpace(dir) == "" {
+		return "", fmt.Errorf("TUSD_STORAGE_DIR must not be empty")
+	}
+	mode, err := parseDirMode(modeValue)
+	if err != nil {
+		return "", err
+	}
+	// The file locker creates and removes lock files in the directory
+	if mode&0700 != 0700 {
+		return "", fmt.Errorf("TUSD_STORAGE_DIR_MODE %04o must give the owner read, write and execute permission", mode)
+	}
+
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("invalid TUSD_STORAGE_DIR %q: %w", dir, err)
+	}
+
+	var absBase string
+	if base != "" {
+		if absBase, err = filepath.Abs(base); err != nil {
+			return "", fmt.Errorf("invalid TUSD_STORAGE_BASE_DIR %q: %w", base, err)
+		}
+		if !isWithinDir(absBase, absDir) {
+			return "", fmt.Errorf("TUSD_STORAGE_DIR %s is outside TUSD_STORAGE_BASE_DIR %s", absDir, absBase)
+		}
+	}
+
+	if err := os.MkdirAll(absDir, mode); err != nil {
+		return "", fmt.Errorf("failed to create TUSD_STORAGE_DIR %s: %w", absDir, err)
+	}
+
+	info, err := os.Stat(absDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat TUSD_STORAGE_DIR %s: %w", absDir, err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("TUSD_STORAGE_DIR %s is not a directory", absDir)
+	}
+
+	// A symlink inside the base could still point outside it
+	if absBase != "" {
+		realDir, err := filepath.EvalSymlinks(absDir)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve TUSD_STORAGE_DIR %s: %w", absDir, err)
+		}
+		realBase, err := filepath.EvalSymlinks(absBase)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve TUSD_STORAGE_BASE_DIR %s: %w", absBase, err)
+		}
+		if !isWithinDir(realBase, realDir) {
+			return "", fmt.Errorf("TUSD_STORAGE_DIR %s resolves to %s, outside TUSD_STORAGE_BASE_DIR %s", absDir, realDir, realBase)
+		}
+	}
+
+	// Fail now rather than on the first upload when the directory is read-only
+	probe, err := os.CreateTemp(absDir, ".write-check-*")
+	if err != nil {
+		return "", fmt.Errorf("TUSD_STORAGE_DIR %s is not writable: %w", absDir, err)
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+
+	return absDir, nil
+}
+
+// isWithinDir reports whether path is dir itself or lies below it; both must be absolute and clean
+func isWithinDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+// parseDirMode parses an octal permission mode such as "0750"; anything else is rejected
+// rather than replaced with a default, so a typo cannot silently change the permissions
+func parseDirMode(value string) (os.FileMode, error) {
+	n, err := strconv.ParseUint(strings.TrimSpace(value), 8, 32)
+	if err != nil || n > 0777 {
+		return 0, fmt.Errorf("TUSD_STORAGE_DIR_MODE %q is not an octal permission mode such as 0750", value)
+	}
+	return os.FileMode(n), nil
+}
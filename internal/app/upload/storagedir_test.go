@@ -0,0 +1,58 @@
+package upload
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPrepareStorageDir(t *testing.T) {
+	base := t.TempDir()
+	outside := t.TempDir()
+
+	tests := []struct {
+		name     string
+		dir      string
+		base     string
+		mode     string
+		wantMode os.FileMode
+		wantErr  string
+	}{
+		{name: "default mode", dir: filepath.Join(base, "default"), mode: "0755", wantMode: 0755},
+		{name: "restricted mode", dir: filepath.Join(base, "restricted"), mode: "0700", wantMode: 0700},
+		{name: "inside the base", dir: filepath.Join(base, "inside"), base: base, mode: "0750", wantMode: 0750},
+		{name: "outside the base", dir: filepath.Join(outside, "escaped"), base: base, mode: "0755", wantErr: "outside TUSD_STORAGE_BASE_DIR"},
+		{name: "mode that is not octal", dir: filepath.Join(base, "typo"), mode: "0x755", wantErr: "not an octal permission mode"},
+		{name: "mode with digits beyond octal", dir: filepath.Join(base, "decimal"), mode: "0789", wantErr: "not an octal permission mode"},
+		{name: "mode with extra bits", dir: filepath.Join(base, "sticky"), mode: "1755", wantErr: "not an octal permission mode"},
+		{name: "empty mode", dir: filepath.Join(base, "empty"), mode: "", wantErr: "not an octal permission mode"},
+		{name: "mode the owner cannot write", dir: filepath.Join(base, "readonly"), mode: "0555", wantErr: "read, write and execute"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := prepareStorageDir(tt.dir, tt.base, tt.mode)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("prepareStorageDir() error = %v, want one containing %q", err, tt.wantErr)
+				}
+				if _, statErr := os.Stat(tt.dir); !os.IsNotExist(statErr) {
+					t.Fatalf("rejected directory %s was created", tt.dir)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("prepareStorageDir() error = %v", err)
+			}
+
+			info, err := os.Stat(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if perm := info.Mode().Perm() &^ 0022; perm != tt.wantMode&^0022 {
+				t.Fatalf("directory mode = %04o, want %04o (ignoring the umask)", info.Mode().Perm(), tt.wantMode)
+			}
+		})
+	}
+}
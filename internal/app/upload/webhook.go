@@ -0,0 +1,147 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// webhookSignatureHeader carries the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with WEBHOOK_SECRET
+const webhookSignatureHeader = "X-Webhook-Signature"
+
+// webhookTimestampHeader carries the unix time the payload was signed, to let receivers reject replays
+const webhookTimestampHeader = "X-Webhook-Timestamp"
+
+// webhookInitialBackoff is the delay before the first retry, doubled on each subsequent one
+var webhookInitialBackoff = time.Second
+
+// UploadCompletedEvent is the payload POSTed to WEBHOOK_URL after an upload is processed
+type UploadCompletedEvent struct {
+	Event        string     `json:"event"` // always "upload.completed"
+	UploadID     string     `json:"upload_id"`
+	DocumentID   uuid.UUID  `json:"document_id"`
+	AttachmentID uuid.UUID  `json:"attachment_id"`
+	OwnerID      uuid.UUID  `json:"owner_id"`
+	FolderID     *uuid.UUID `json:"folder_id,omitempty"`
+	FileName     string     `json:"file_name"`
+	FileSize     int64      `json:"file_size"`
+	OccurredAt   time.Time  `json:"occurred_at"`
+}
+
+// newUploadCompletedEvent builds the webhook payload for a processed upload
+func newUploadCompletedEvent(uploadID string, ownerID uuid.UUID, result *ProcessUploadResult) UploadCompletedEvent {
+	return UploadCompletedEvent{
+		Event:        "upload.completed",
+		UploadID:     uploadID,
+		DocumentID:   result.Document.ID,
+		AttachmentID: result.Attachment.ID,
+		OwnerID:      ownerID,
+		FolderID:     result.Document.FolderID,
+		FileName:     result.Attachment.FileName,
+		FileSize:     result.Attachment.FileSize,
+		OccurredAt:   time.Now().UTC(),
+	}
+}
+
+// sendWebhook delivers an event to the configured webhook URL, retrying with exponential backoff
+// until ctx is cancelled; meant to be run in its own goroutine, failures are only logged
+func (h *Handler) sendWebhook(ctx context.Context, event UploadCompletedEvent) {
+	if h.tusConfig.WebhookURL == "" {
+		return
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.Error().Err(err).Str("upload_id", event.UploadID).Msg("Failed to encode webhook payload")
+		return
+	}
+
+	// A misconfigured attempt count still delivers once
+	maxAttempts := max(h.tusConfig.WebhookMaxAttempts, 1)
+	client := &http.Client{Timeout: h.tusConfig.WebhookTimeout}
+	backoff := webhookInitialBackoff
+
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		err = h.postWebhook(ctx, client, body)
+		if err == nil {
+			log.Info().
+				Str("upload_id", event.UploadID).
+				Int("attempt", attempt).
+				Msg("Upload webhook delivered")
+			return
+		}
+
+		log.Warn().Err(err).
+			Str("upload_id", event.UploadID).
+			Int("attempt", attempt).
+			Msg("Upload webhook delivery failed")
+
+		if attempt == maxAttempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			log.Warn().
+				Str("upload_id", event.UploadID).
+				Int("attempts", attempt).
+				Msg("Shutting down, abandoning upload webhook")
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+
+	log.Error().
+		Str("upload_id", event.UploadID).
+		Int("attempts", maxAttempts).
+		Msg("Giving up on upload webhook")
+}
+
+// postWebhook sends one signed webhook request
+func (h *Handler) postWebhook(ctx context.Context, client *http.Client, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, h.tusConfig.WebhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.tusConfig.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhookTimestampHeader, timestamp)
+	if h.tusConfig.WebhookSecret != "" {
+		req.Header.Set(webhookSignatureHeader, "sha256="+signWebhook(h.tusConfig.WebhookSecret, timestamp, body))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook endpoint returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// signWebhook computes the hex HMAC-SHA256 of "<timestamp>.<body>"
+func signWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
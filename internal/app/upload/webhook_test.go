@@ -0,0 +1,109 @@
+package upload
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// newWebhookServer answers the first failures requests with 500 and the rest with 204,
+// checking every request's signature
+func newWebhookServer(t *testing.T, secret string, failures int32) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := requests.Add(1)
+
+		body, _ := io.ReadAll(r.Body)
+		want := "sha256=" + signWebhook(secret, r.Header.Get(webhookTimestampHeader), body)
+		if got := r.Header.Get(webhookSignatureHeader); got != want {
+			t.Errorf("signature = %q, want %q", got, want)
+		}
+
+		if n <= failures {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestSendWebhook(t *testing.T) {
+	webhookInitialBackoff = time.Millisecond
+	t.Cleanup(func() { webhookInitialBackoff = time.Second })
+
+	tests := []struct {
+		name         string
+		maxAttempts  int
+		failures     int32
+		wantRequests int32
+	}{
+		{name: "delivered first time", maxAttempts: 3, failures: 0, wantRequests: 1},
+		{name: "delivered after retries", maxAttempts: 3, failures: 2, wantRequests: 3},
+		{name: "gives up after max attempts", maxAttempts: 3, failures: 10, wantRequests: 3},
+		{name: "zero attempts delivers once", maxAttempts: 0, failures: 10, wantRequests: 1},
+		{name: "negative attempts delivers once", maxAttempts: -1, failures: 0, wantRequests: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := newWebhookServer(t, "secret", tt.failures)
+			h := &Handler{tusConfig: TusConfig{
+				WebhookURL:         server.URL,
+				WebhookSecret:      "secret",
+				WebhookTimeout:     time.Second,
+				WebhookMaxAttempts: tt.maxAttempts,
+			}}
+
+			h.sendWebhook(context.Background(), UploadCompletedEvent{Event: "upload.completed", UploadID: "upload-1", DocumentID: uuid.New()})
+
+			if got := requests.Load(); got != tt.wantRequests {
+				t.Fatalf("webhook received %d requests, want %d", got, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestSendWebhookStopsOnCancel(t *testing.T) {
+	webhookInitialBackoff = time.Hour
+	t.Cleanup(func() { webhookInitialBackoff = time.Second })
+
+	server, requests := newWebhookServer(t, "secret", 10)
+	h := &Handler{tusConfig: TusConfig{
+		WebhookURL:         server.URL,
+		WebhookSecret:      "secret",
+		WebhookTimeout:     time.Second,
+		WebhookMaxAttempts: 5,
+	}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		h.sendWebhook(ctx, UploadCompletedEvent{Event: "upload.completed", UploadID: "upload-1"})
+		close(done)
+	}()
+
+	// Wait for the first attempt, then shut down during the backoff
+	deadline := time.Now().Add(5 * time.Second)
+	for requests.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("sendWebhook kept waiting to retry after its context was cancelled")
+	}
+	if got := requests.Load(); got != 1 {
+		t.Fatalf("webhook received %d requests, want 1", got)
+	}
+}
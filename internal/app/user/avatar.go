@@ -0,0 +1,127 @@
+package user
+
+import (
+	"bytes"
+	"context"
+	"e-document-backend/internal/util"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"io"
+	"mime/multipart"
+	"path/filepath"
+	"strings"
+
+	// Register the decoders accepted by validateImageFile
+	_ "image/gif"
+	_ "image/png"
+)
+
+const (
+	// avatarSize is the width and height of stored profile pictures
+	avatarSize = 256
+	// avatarJPEGQuality is the quality normalized avatars are re-encoded with
+	avatarJPEGQuality = 85
+	// maxAvatarPixels rejects images whose decoded size would exhaust memory (e.g. 10000x4000)
+	maxAvatarPixels = 40_000_000
+)
+
+// uploadAvatar normalizes an uploaded profile picture and stores it, returning the object path
+// The image is center-cropped to a square, scaled to avatarSize and re-encoded as JPEG
+func (h *Handler) uploadAvatar(ctx context.Context, file *multipart.FileHeader) (string, error) {
+	data, err := normalizeAvatar(file)
+	if err != nil {
+		return "", err
+	}
+
+	filename := strings.TrimSuffix(file.Filename, filepath.Ext(file.Filename)) + ".jpg"
+	objectPath, err := h.storageClient.UploadData(ctx, data, filename, "image/jpeg", "profiles")
+	if err != nil {
+		return "", storageError("Failed to upload profile picture", err)
+	}
+
+	return objectPath, nil
+}
+
+// normalizeAvatar decodes an image and returns it as an avatarSize x avatarSize JPEG
+// Images that cannot be decoded are rejected with INVALID_INPUT
+func normalizeAvatar(file *multipart.FileHeader) ([]byte, error) {
+	src, err := file.Open()
+	if err != nil {
+		return nil, util.NewInternalError(fmt.Sprintf("failed to open file: %v", err))
+	}
+	defer src.Close()
+
+	// Check the dimensions before decoding the pixels
+	cfg, _, err := image.DecodeConfig(src)
+	if err != nil {
+		return nil, util.ErrorResponse("Invalid profile picture", util.INVALID_INPUT, 400, "file is not a decodable image")
+	}
+	if cfg.Width*cfg.Height > maxAvatarPixels {
+		return nil, util.ErrorResponse("Invalid profile picture", util.INVALID_INPUT, 400, "image dimensions are too large")
+	}
+
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return nil, util.NewInternalError(fmt.Sprintf("failed to rewind file: %v", err))
+	}
+	img, _, err := image.Decode(src)
+	if err != nil {
+		return nil, util.ErrorResponse("Invalid profile picture", util.INVALID_INPUT, 400, "file is not a decodable image")
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, resizeSquare(img, avatarSize), &jpeg.Options{Quality: avatarJPEGQuality}); err != nil {
+		return nil, util.NewInternalError(fmt.Sprintf("failed to encode profile picture: %v", err))
+	}
+
+	return buf.Bytes(), nil
+}
+
+// resizeSquare center-crops img to a square and scales it to size x size
+// Each output pixel averages the source pixels it covers, so downscaling doesn't alias;
+// transparent areas are flattened onto white since JPEG has no alpha channel
+func resizeSquare(img image.Image, size int) *image.RGBA {
+	bounds := img.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+	crop := image.Rect(0, 0, side, side).Add(image.Point{
+		X: bounds.Min.X + (bounds.Dx()-side)/2,
+		Y: bounds.Min.Y + (bounds.Dy()-side)/2,
+	})
+
+	// Flatten the crop into RGBA over a white background
+	square := image.NewRGBA(image.Rect(0, 0, side, side))
+	draw.Draw(square, square.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(square, square.Bounds(), img, crop.Min, draw.Over)
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		y0 := y * side / size
+		y1 := max((y+1)*side/size, y0+1)
+		for x := 0; x < size; x++ {
+			x0 := x * side / size
+			x1 := max((x+1)*side/size, x0+1)
+
+			var r, g, b, a, n int
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					i := square.PixOffset(sx, sy)
+					r += int(square.Pix[i])
+					g += int(square.Pix[i+1])
+					b += int(square.Pix[i+2])
+					a += int(square.Pix[i+3])
+					n++
+				}
+			}
+
+			i := dst.PixOffset(x, y)
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+
+	return dst
+}
@@ -2,10 +2,12 @@ package user
 
 import (
 	"context"
+	"e-document-backend/internal/config"
 	"e-document-backend/internal/domain"
+	"e-document-backend/internal/pkg/storage"
 	"e-document-backend/internal/util"
 	"mime/multipart"
-	"strconv"
+	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
@@ -15,21 +17,23 @@ import (
 type Handler struct {
 	service       Service
 	storageClient interface {
-		UploadFile(ctx context.Context, file *multipart.FileHeader, folder string) (string, error)
+		UploadData(ctx context.Context, data []byte, filename string, contentType string, folder string) (string, error)
 		DeleteFile(ctx context.Context, objectPath string) error
 		GetPresignedURL(ctx context.Context, objectPath string, expiry time.Duration) (string, error)
 	}
+	presign config.PresignConfig
 }
 
 // NewHandler creates a new user handler
 func NewHandler(service Service, storageClient interface {
-	UploadFile(ctx context.Context, file *multipart.FileHeader, folder string) (string, error)
+	UploadData(ctx context.Context, data []byte, filename string, contentType string, folder string) (string, error)
 	DeleteFile(ctx context.Context, objectPath string) error
 	GetPresignedURL(ctx context.Context, objectPath string, expiry time.Duration) (string, error)
-}) *Handler {
+}, presign config.PresignConfig) *Handler {
 	return &Handler{
 		service:       service,
 		storageClient: storageClient,
+		presign:       presign,
 	}
 }
 
@@ -38,12 +42,16 @@ func (h *Handler) RegisterRoutes(e *echo.Group, authMiddleware echo.MiddlewareFu
 	users := e.Group("/v1/users", authMiddleware)
 	users.POST("", h.CreateUser)
 	users.GET("", h.GetAllUsers)
+	users.GET("/me", h.GetMe)
+	users.PUT("/me", h.UpdateProfile)
 	users.GET("/:id", h.GetUserByID)
 	users.PUT("/:id", h.UpdateUser)
 	users.GET("/:id/profile-picture", h.GetProfilePicture)
 	users.POST("/:id/profile-picture", h.UploadProfilePicture)
 	users.DELETE("/:id/profile-picture", h.DeleteProfilePicture)
 	users.DELETE("/:id", h.DeleteUser)
+	users.POST("/:id/disable", h.DisableUser)
+	users.POST("/:id/enable", h.EnableUser)
 }
 
 // CreateUser godoc
@@ -56,19 +64,26 @@ func (h *Handler) RegisterRoutes(e *echo.Group, authMiddleware echo.MiddlewareFu
 //	@Security		BearerAuth
 //	@Param			username		formData	string	true	"Username"
 //	@Param			email			formData	string	true	"Email"
-//	@Param			password		formData	string	true	"Password (min 6 characters)"
+//	@Param			password		formData	string	true	"Password (must satisfy the password policy)"
 //	@Param			first_name		formData	string	false	"First name"
 //	@Param			last_name		formData	string	false	"Last name"
 //	@Param			phone			formData	string	false	"Phone number (E.164 format)"
-//	@Param			role			formData	string	true	"Role (Director, DepartmentManager, SectorManager, Employee)"
-//	@Param			department_id	formData	string	false	"Department ID"
-//	@Param			sector_id		formData	string	false	"Sector ID"
-//	@Param			profile_picture	formData	file	false	"Profile picture (max 5MB, jpg/png/gif/webp)"
+//	@Param			role			formData	string	false	"Role (Director, DepartmentManager, SectorManager, Employee); defaults to USER_DEFAULT_ROLE. Only Directors may assign their own role or higher"
+//	@Param			department_id	formData	string	false	"Registered department ID (must belong to the sector when one is given)"
+//	@Param			sector_id		formData	string	false	"Sector ID (must exist)"
+//	@Param			profile_picture	formData	file	false	"Profile picture (max 5MB, jpg/png/gif; stored as a 256x256 JPEG)"
 //	@Success		201				{object}	util.Response{data=domain.UserResponse}
-//	@Failure		400				{object}	util.Response
-//	@Failure		401				{object}	util.Response
+//	@Failure		400				{object}	util.ErrorEnvelope
+//	@Failure		401				{object}	util.ErrorEnvelope
+//	@Failure		403				{object}	util.ErrorEnvelope
 //	@Router			/v1/users [post]
 func (h *Handler) CreateUser(c echo.Context) error {
+	// The requester's own role bounds the role the new user may get
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
 	// Parse form data
 	req := domain.CreateUserRequest{
 		Username:     c.FormValue("username"),
@@ -82,10 +97,7 @@ func (h *Handler) CreateUser(c echo.Context) error {
 		SectorID:     c.FormValue("sector_id"),
 	}
 
-	// Validate request using validator
-	if err := util.ValidateStruct(&req); err != nil {
-		return util.HandleError(c, err)
-	}
+	// The request is validated by the service once the phone number is normalized
 
 	// Check if profile picture is uploaded
 	var profilePictureURL string
@@ -96,15 +108,15 @@ func (h *Handler) CreateUser(c echo.Context) error {
 			return util.HandleError(c, util.ErrorResponse("Invalid profile picture", util.INVALID_INPUT, 400, err.Error()))
 		}
 
-		// Upload to MinIO (returns object path, not full URL)
-		profilePictureURL, err = h.storageClient.UploadFile(c.Request().Context(), file, "profiles")
+		// Resize and upload to MinIO (returns object path, not full URL)
+		profilePictureURL, err = h.uploadAvatar(c.Request().Context(), file)
 		if err != nil {
-			return util.HandleError(c, util.ErrorResponse("Failed to upload profile picture", util.INTERNAL_SERVER_ERROR, 500, err.Error()))
+			return util.HandleError(c, err)
 		}
 	}
 
 	// Create user
-	user, err := h.service.CreateUser(c.Request().Context(), req)
+	user, err := h.service.CreateUser(c.Request().Context(), userID, req)
 	if err != nil {
 		// If user creation fails and we uploaded a file, delete it
 		if profilePictureURL != "" {
@@ -125,7 +137,7 @@ func (h *Handler) CreateUser(c echo.Context) error {
 		user = updatedUser
 	}
 
-	return util.OKResponse(c, "User created successfully", user, 201)
+	return util.CreatedResponse(c, "User created successfully", user)
 }
 
 // GetAllUsers godoc
@@ -136,58 +148,41 @@ func (h *Handler) CreateUser(c echo.Context) error {
 //	@Accept			json
 //	@Produce		json
 //	@Security		BearerAuth
-//	@Param			page	query		int		false	"Page number"	default(1)
-//	@Param			limit	query		int		false	"Items per page"	default(10)
-//	@Param			search	query		string	false	"Search by username or email"
-//	@Success		200		{object}	util.Response{data=util.PaginatedData}
-//	@Failure		401		{object}	util.Response
-//	@Failure		500		{object}	util.Response
+//	@Param			page			query		int		false	"Page number"	default(1)
+//	@Param			limit			query		int		false	"Items per page"	default(10)
+//	@Param			search			query		string	false	"Search by username, email, first/last name or phone"
+//	@Param			role			query		string	false	"Filter by role"	Enums(Director, DepartmentManager, SectorManager, Employee)
+//	@Param			department_id	query		string	false	"Filter by department"
+//	@Success		200				{object}	util.Response{data=util.PaginatedData}
+//	@Failure		400				{object}	util.ErrorEnvelope
+//	@Failure		401				{object}	util.ErrorEnvelope
+//	@Failure		500				{object}	util.ErrorEnvelope
 //	@Router			/v1/users [get]
 func (h *Handler) GetAllUsers(c echo.Context) error {
-	// Get pagination params from query
-	page := c.QueryParam("page")
-	limit := c.QueryParam("limit")
+	pagination := util.ParsePagination(c, util.PaginationDefaults{Limit: 10})
 	search := c.QueryParam("search")
 
-	// Default values
-	pageNum := 1
-	limitNum := 10
-
-	// Parse page
-	if page != "" {
-		if p, err := strconv.Atoi(page); err == nil && p > 0 {
-			pageNum = p
-		}
-	}
-
-	// Parse limit
-	if limit != "" {
-		if l, err := strconv.Atoi(limit); err == nil && l > 0 {
-			limitNum = l
-		}
+	role := domain.UserRole(c.QueryParam("role"))
+	if role != "" && !role.IsValid() {
+		return util.HandleError(c, util.NewInvalidInputError("role", "must be Director, DepartmentManager, SectorManager, or Employee"))
 	}
 
 	// Get current user ID from JWT context
-	currentUserID := ""
-	if userID := c.Get("user_id"); userID != nil {
-		currentUserID = userID.(string)
+	currentUserID, _ := c.Get("user_id").(string)
+
+	filter := UserFilter{
+		Search:        strings.TrimSpace(search),
+		Role:          role,
+		DepartmentID:  c.QueryParam("department_id"),
+		ExcludeUserID: currentUserID,
 	}
 
-	users, total, err := h.service.GetAllUsers(c.Request().Context(), pageNum, limitNum, search, currentUserID)
+	users, total, err := h.service.GetAllUsers(c.Request().Context(), pagination.Page, pagination.Limit, filter)
 	if err != nil {
 		return util.HandleError(c, err)
 	}
 
-	// Calculate pagination info
-	totalPages := (total + limitNum - 1) / limitNum
-	pagination := util.PaginationInfo{
-		CurrentPage:  pageNum,
-		TotalPages:   totalPages,
-		TotalItems:   total,
-		ItemsPerPage: limitNum,
-	}
-
-	return util.OKResponseWithPagination(c, "Users retrieved successfully", users, pagination)
+	return util.OKResponseWithPagination(c, "Users retrieved successfully", users, pagination.Info(total))
 }
 
 // GetUserByID godoc
@@ -200,8 +195,8 @@ func (h *Handler) GetAllUsers(c echo.Context) error {
 //	@Security		BearerAuth
 //	@Param			id	path		string	true	"User ID"
 //	@Success		200	{object}	util.Response{data=domain.UserResponse}
-//	@Failure		401	{object}	util.Response
-//	@Failure		404	{object}	util.Response
+//	@Failure		401	{object}	util.ErrorEnvelope
+//	@Failure		404	{object}	util.ErrorEnvelope
 //	@Router			/v1/users/{id} [get]
 func (h *Handler) GetUserByID(c echo.Context) error {
 	id := c.Param("id")
@@ -214,6 +209,32 @@ func (h *Handler) GetUserByID(c echo.Context) error {
 	return util.OKResponse(c, "User retrieved successfully", user)
 }
 
+// GetMe godoc
+//
+//	@Summary		Get current user
+//	@Description	Get the authenticated user's information (same as /v1/auth/profile)
+//	@Tags			Users
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	util.Response{data=domain.UserResponse}
+//	@Failure		401	{object}	util.ErrorEnvelope
+//	@Failure		404	{object}	util.ErrorEnvelope
+//	@Router			/v1/users/me [get]
+func (h *Handler) GetMe(c echo.Context) error {
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	user, err := h.service.GetUserByID(c.Request().Context(), userID)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	return util.OKResponse(c, "User retrieved successfully", user)
+}
+
 // UpdateUser godoc
 //
 //	@Summary		Update user
@@ -225,22 +246,32 @@ func (h *Handler) GetUserByID(c echo.Context) error {
 //	@Param			id				path		string	true	"User ID"
 //	@Param			username		formData	string	false	"Username"
 //	@Param			email			formData	string	false	"Email"
-//	@Param			password		formData	string	false	"Password (min 6 characters)"
+//	@Param			password		formData	string	false	"Password (must satisfy the password policy)"
 //	@Param			first_name		formData	string	false	"First name"
 //	@Param			last_name		formData	string	false	"Last name"
 //	@Param			phone			formData	string	false	"Phone number (E.164 format)"
-//	@Param			role			formData	string	false	"Role (Director, DepartmentManager, SectorManager, Employee)"
-//	@Param			department_id	formData	string	false	"Department ID"
-//	@Param			sector_id		formData	string	false	"Sector ID"
-//	@Param			profile_picture	formData	file	false	"Profile picture (max 5MB, jpg/png/gif/webp)"
+//	@Param			role			formData	string	false	"Role (Director, DepartmentManager, SectorManager, Employee); the caller must be allowed to assign both the current and the new role"
+//	@Param			department_id	formData	string	false	"Registered department ID (must belong to the sector when one is given)"
+//	@Param			sector_id		formData	string	false	"Sector ID (must exist)"
+//	@Param			profile_picture	formData	file	false	"Profile picture (max 5MB, jpg/png/gif; stored as a 256x256 JPEG)"
 //	@Success		200				{object}	util.Response{data=domain.UserResponse}
-//	@Failure		400				{object}	util.Response
-//	@Failure		401				{object}	util.Response
-//	@Failure		404				{object}	util.Response
+//	@Failure		400				{object}	util.ErrorEnvelope
+//	@Failure		401				{object}	util.ErrorEnvelope
+//	@Failure		403				{object}	util.ErrorEnvelope
+//	@Failure		404				{object}	util.ErrorEnvelope
 //	@Router			/v1/users/{id} [put]
 func (h *Handler) UpdateUser(c echo.Context) error {
 	id := c.Param("id")
 
+	// Updating arbitrary users (including role and department) is reserved for Directors
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+	if err := h.service.RequireDirector(c.Request().Context(), userID); err != nil {
+		return util.HandleError(c, err)
+	}
+
 	// Parse form data
 	req := domain.UpdateUserRequest{
 		Username:     c.FormValue("username"),
@@ -258,10 +289,7 @@ func (h *Handler) UpdateUser(c echo.Context) error {
 		req.Role = domain.UserRole(roleStr)
 	}
 
-	// Validate request using validator
-	if err := util.ValidateStruct(&req); err != nil {
-		return util.HandleError(c, err)
-	}
+	// The request is validated by the service once the phone number is normalized
 
 	// Get existing user to check profile picture
 	existingUser, err := h.service.GetUserByID(c.Request().Context(), id)
@@ -278,15 +306,15 @@ func (h *Handler) UpdateUser(c echo.Context) error {
 			return util.HandleError(c, util.ErrorResponse("Invalid profile picture", util.INVALID_INPUT, 400, err.Error()))
 		}
 
-		// Upload to MinIO
-		newProfilePictureURL, err = h.storageClient.UploadFile(c.Request().Context(), file, "profiles")
+		// Resize and upload to MinIO
+		newProfilePictureURL, err = h.uploadAvatar(c.Request().Context(), file)
 		if err != nil {
-			return util.HandleError(c, util.ErrorResponse("Failed to upload profile picture", util.INTERNAL_SERVER_ERROR, 500, err.Error()))
+			return util.HandleError(c, err)
 		}
 	}
 
 	// Update user
-	user, err := h.service.UpdateUser(c.Request().Context(), id, req)
+	user, err := h.service.UpdateUser(c.Request().Context(), userID, id, req)
 	if err != nil {
 		// If user update fails and we uploaded a new file, delete it
 		if newProfilePictureURL != "" {
@@ -313,6 +341,45 @@ func (h *Handler) UpdateUser(c echo.Context) error {
 	return util.OKResponse(c, "User updated successfully", user)
 }
 
+// UpdateProfile godoc
+//
+//	@Summary		Update own profile
+//	@Description	Update the authenticated user's name, phone, email and password. Role, department and sector cannot be changed here; sending a different role is refused with 403. Changing the password requires current_password
+//	@Tags			Users
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			body	body		domain.UpdateProfileRequest	true	"Profile fields to update"
+//	@Success		200		{object}	util.Response{data=domain.UserResponse}
+//	@Failure		400		{object}	util.ErrorEnvelope
+//	@Failure		401		{object}	util.ErrorEnvelope
+//	@Failure		403		{object}	util.ErrorEnvelope
+//	@Failure		404		{object}	util.ErrorEnvelope
+//	@Router			/v1/users/me [put]
+func (h *Handler) UpdateProfile(c echo.Context) error {
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	var req domain.UpdateProfileRequest
+	if err := c.Bind(&req); err != nil {
+		return util.HandleError(c, util.ErrorResponse("Invalid request body", util.INVALID_INPUT, 400, err.Error()))
+	}
+
+	// Support acting as the user may fix their details but not take over their credentials
+	if _, _, impersonating := util.GetImpersonation(c); impersonating && (req.Email != "" || req.Password != "") {
+		return util.HandleError(c, util.NewForbiddenError("email and password cannot be changed while impersonating a user"))
+	}
+
+	user, err := h.service.UpdateProfile(c.Request().Context(), userID, req)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	return util.OKResponse(c, "Profile updated successfully", user)
+}
+
 // UploadProfilePicture godoc
 //
 //	@Summary		Upload profile picture
@@ -322,11 +389,11 @@ func (h *Handler) UpdateUser(c echo.Context) error {
 //	@Produce		json
 //	@Security		BearerAuth
 //	@Param			id		path		string	true	"User ID"
-//	@Param			file	formData	file	true	"Profile picture (max 5MB, jpg/png/gif/webp)"
+//	@Param			file	formData	file	true	"Profile picture (max 5MB, jpg/png/gif; stored as a 256x256 JPEG)"
 //	@Success		200		{object}	util.Response{data=domain.UserResponse}
-//	@Failure		400		{object}	util.Response
-//	@Failure		401		{object}	util.Response
-//	@Failure		404		{object}	util.Response
+//	@Failure		400		{object}	util.ErrorEnvelope
+//	@Failure		401		{object}	util.ErrorEnvelope
+//	@Failure		404		{object}	util.ErrorEnvelope
 //	@Router			/v1/users/{id}/profile-picture [post]
 func (h *Handler) UploadProfilePicture(c echo.Context) error {
 	id := c.Param("id")
@@ -348,10 +415,10 @@ func (h *Handler) UploadProfilePicture(c echo.Context) error {
 		return util.HandleError(c, err)
 	}
 
-	// Upload new file to MinIO
-	fileURL, err := h.storageClient.UploadFile(c.Request().Context(), file, "profiles")
+	// Resize and upload new file to MinIO
+	fileURL, err := h.uploadAvatar(c.Request().Context(), file)
 	if err != nil {
-		return util.HandleError(c, util.ErrorResponse("Failed to upload file", util.INTERNAL_SERVER_ERROR, 500, err.Error()))
+		return util.HandleError(c, err)
 	}
 
 	// Update user profile picture in database
@@ -380,8 +447,8 @@ func (h *Handler) UploadProfilePicture(c echo.Context) error {
 //	@Security		BearerAuth
 //	@Param			id	path		string	true	"User ID"
 //	@Success		200	{object}	util.Response{data=domain.UserResponse}
-//	@Failure		401	{object}	util.Response
-//	@Failure		404	{object}	util.Response
+//	@Failure		401	{object}	util.ErrorEnvelope
+//	@Failure		404	{object}	util.ErrorEnvelope
 //	@Router			/v1/users/{id}/profile-picture [delete]
 func (h *Handler) DeleteProfilePicture(c echo.Context) error {
 	id := c.Param("id")
@@ -398,7 +465,7 @@ func (h *Handler) DeleteProfilePicture(c echo.Context) error {
 
 	// Delete file from MinIO
 	if err := h.storageClient.DeleteFile(c.Request().Context(), existingUser.ProfilePicture); err != nil {
-		return util.HandleError(c, util.ErrorResponse("Failed to delete file", util.INTERNAL_SERVER_ERROR, 500, err.Error()))
+		return util.HandleError(c, storageError("Failed to delete file", err))
 	}
 
 	// Update user profile picture in database (set to empty)
@@ -413,15 +480,16 @@ func (h *Handler) DeleteProfilePicture(c echo.Context) error {
 // DeleteUser godoc
 //
 //	@Summary		Delete user
-//	@Description	Delete a user account
+//	@Description	Delete a user account. Users who still own documents or folders cannot be deleted (USER_HAS_CONTENT)
 //	@Tags			Users
 //	@Accept			json
 //	@Produce		json
 //	@Security		BearerAuth
 //	@Param			id	path		string	true	"User ID"
 //	@Success		200	{object}	util.Response
-//	@Failure		401	{object}	util.Response
-//	@Failure		404	{object}	util.Response
+//	@Failure		401	{object}	util.ErrorEnvelope
+//	@Failure		404	{object}	util.ErrorEnvelope
+//	@Failure		409	{object}	util.ErrorEnvelope
 //	@Router			/v1/users/{id} [delete]
 func (h *Handler) DeleteUser(c echo.Context) error {
 	id := c.Param("id")
@@ -433,6 +501,56 @@ func (h *Handler) DeleteUser(c echo.Context) error {
 	return util.OKResponse(c, "User deleted successfully", nil)
 }
 
+// DisableUser godoc
+//
+//	@Summary		Disable user
+//	@Description	Disable a compromised account (Director only). The user can no longer log in or refresh tokens, and every issued token is revoked, including access tokens that have not expired yet
+//	@Tags			Users
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"User ID"
+//	@Success		200	{object}	util.Response{data=domain.UserResponse}
+//	@Failure		400	{object}	util.ErrorEnvelope
+//	@Failure		401	{object}	util.ErrorEnvelope
+//	@Failure		403	{object}	util.ErrorEnvelope
+//	@Failure		404	{object}	util.ErrorEnvelope
+//	@Router			/v1/users/{id}/disable [post]
+func (h *Handler) DisableUser(c echo.Context) error {
+	return h.setDisabled(c, true, "User disabled successfully")
+}
+
+// EnableUser godoc
+//
+//	@Summary		Enable user
+//	@Description	Re-enable a disabled account (Director only). The user has to log in again
+//	@Tags			Users
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"User ID"
+//	@Success		200	{object}	util.Response{data=domain.UserResponse}
+//	@Failure		401	{object}	util.ErrorEnvelope
+//	@Failure		403	{object}	util.ErrorEnvelope
+//	@Failure		404	{object}	util.ErrorEnvelope
+//	@Router			/v1/users/{id}/enable [post]
+func (h *Handler) EnableUser(c echo.Context) error {
+	return h.setDisabled(c, false, "User enabled successfully")
+}
+
+// setDisabled applies the disabled flag of the user in the path on behalf of the requester
+func (h *Handler) setDisabled(c echo.Context, disabled bool, message string) error {
+	requesterID, err := util.GetUserID(c)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	user, err := h.service.SetDisabled(c.Request().Context(), requesterID, c.Param("id"), disabled)
+	if err != nil {
+		return util.HandleError(c, err)
+	}
+
+	return util.OKResponse(c, message, user)
+}
+
 // Helper function to validate image files
 func validateImageFile(file *multipart.FileHeader) error {
 	// Check file size (max 5MB)
@@ -448,12 +566,19 @@ func validateImageFile(file *multipart.FileHeader) error {
 		"image/jpg":  true,
 		"image/png":  true,
 		"image/gif":  true,
-		"image/webp": true,
 	}
 
 	if !validMimeTypes[contentType] {
-		return util.ErrorResponse("Invalid file type. Allowed: jpg, jpeg, png, gif, webp", util.INVALID_INPUT, 400, "")
+		return util.ErrorResponse("Invalid file type. Allowed: jpg, jpeg, png, gif", util.INVALID_INPUT, 400, "")
 	}
 
 	return nil
 }
+
+// storageError maps a file storage failure to a 503 when the bucket is missing, otherwise a 500
+func storageError(message string, err error) error {
+	if storage.IsBucketNotFound(err) {
+		return util.NewStorageUnavailableError(err.Error())
+	}
+	return util.ErrorResponse(message, util.INTERNAL_SERVER_ERROR, 500, err.Error())
+}
@@ -3,6 +3,7 @@ package user
 import (
 	"e-document-backend/internal/util"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/labstack/echo/v4"
@@ -11,20 +12,51 @@ import (
 // GetProfilePicture godoc
 //
 //	@Summary		Get profile picture URL
-//	@Description	Get a temporary presigned URL to access user's profile picture (valid for 1 hour)
+//	@Description	Get a temporary presigned URL to access user's profile picture (valid for PRESIGN_DEFAULT_EXPIRY seconds, 1 hour by default).
+//	@Description	Redirects by default so the endpoint can be used as an <img> source; format=json returns the URL instead.
+//	@Description	Without format, an "Accept: application/json" header also selects JSON.
 //	@Tags			Users
 //	@Produce		json
 //	@Security		BearerAuth
-//	@Param			id	path		string	true	"User ID"
-//	@Success		307	{string}	string	"Redirects to presigned URL"
-//	@Success		200	{object}	map[string]string{url=string}	"Returns presigned URL"
-//	@Failure		400	{object}	util.Response
-//	@Failure		401	{object}	util.Response
-//	@Failure		404	{object}	util.Response
+//	@Param			id		path		string	true	"User ID"
+//	@Param			expiry	query		int		false	"Expiry time in seconds within the configured bounds (default: PRESIGN_DEFAULT_EXPIRY)"
+//	@Param			format	query		string	false	"Response form"	Enums(json, redirect)
+//	@Success		307		{string}	string	"Redirects to presigned URL"
+//	@Success		200		{object}	map[string]interface{}{url=string,expires_in=int}	"Returns presigned URL"
+//	@Failure		400		{object}	util.ErrorEnvelope
+//	@Failure		401		{object}	util.ErrorEnvelope
+//	@Failure		404		{object}	util.ErrorEnvelope
 //	@Router			/v1/users/{id}/profile-picture [get]
 func (h *Handler) GetProfilePicture(c echo.Context) error {
 	id := c.Param("id")
 
+	var expirySeconds int64
+	if raw := c.QueryParam("expiry"); raw != "" {
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return util.HandleError(c, util.NewInvalidInputError("expiry", "must be a number of seconds"))
+		}
+		expirySeconds = parsed
+	}
+	expirySeconds, err := h.presign.ResolveExpiry(expirySeconds)
+	if err != nil {
+		return util.HandleError(c, util.NewInvalidInputError("expiry", err.Error()))
+	}
+
+	// An explicit format wins over the Accept header
+	format := c.QueryParam("format")
+	switch format {
+	case "json", "redirect":
+	case "":
+		if c.Request().Header.Get("Accept") == "application/json" {
+			format = "json"
+		} else {
+			format = "redirect"
+		}
+	default:
+		return util.HandleError(c, util.NewInvalidInputError("format", "must be json or redirect"))
+	}
+
 	// Get user
 	user, err := h.service.GetUserByID(c.Request().Context(), id)
 	if err != nil {
@@ -42,8 +74,8 @@ func (h *Handler) GetProfilePicture(c echo.Context) error {
 		))
 	}
 
-	// Generate presigned URL (valid for 1 hour)
-	presignedURL, err := h.storageClient.GetPresignedURL(c.Request().Context(), user.ProfilePicture, 1*time.Hour)
+	// Generate presigned URL valid for the resolved expiry
+	presignedURL, err := h.storageClient.GetPresignedURL(c.Request().Context(), user.ProfilePicture, time.Duration(expirySeconds)*time.Second)
 	if err != nil {
 		return util.HandleError(c, util.ErrorResponse(
 			"Failed to generate presigned URL",
@@ -53,16 +85,14 @@ func (h *Handler) GetProfilePicture(c echo.Context) error {
 		))
 	}
 
-	// Check if client wants redirect or JSON
-	acceptHeader := c.Request().Header.Get("Accept")
-	if acceptHeader == "application/json" {
+	if format == "json" {
 		// Return JSON with URL
 		return c.JSON(http.StatusOK, map[string]interface{}{
 			"success": true,
 			"message": "Profile picture URL retrieved successfully",
-			"data": map[string]string{
+			"data": map[string]interface{}{
 				"url":        presignedURL,
-				"expires_in": "1 hour",
+				"expires_in": expirySeconds, // seconds
 			},
 		})
 	}
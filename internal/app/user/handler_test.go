@@ -0,0 +1,101 @@
+package user
+
+import (
+	"context"
+	"e-document-backend/internal/config"
+	"e-document-backend/internal/domain"
+	"e-document-backend/internal/pkg/storage"
+	"e-document-backend/internal/util"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestStorageError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   util.ErrorCode
+	}{
+		{name: "missing bucket", err: fmt.Errorf("failed to upload file: %w: documents", storage.ErrBucketNotFound), wantStatus: 503, wantCode: util.STORAGE_ERROR},
+		{name: "other failure", err: errors.New("connection reset"), wantStatus: 500, wantCode: util.INTERNAL_SERVER_ERROR},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			customErr, ok := storageError("Failed to upload file", tt.err).(*util.CustomError)
+			if !ok {
+				t.Fatal("storageError() did not return a CustomError")
+			}
+			if customErr.StatusCode != tt.wantStatus || customErr.ErrorCode != tt.wantCode {
+				t.Fatalf("storageError() = %d %s, want %d %s", customErr.StatusCode, customErr.ErrorCode, tt.wantStatus, tt.wantCode)
+			}
+		})
+	}
+}
+
+// profileService returns a user with a profile picture
+type profileService struct {
+	Service
+}
+
+func (s *profileService) GetUserByID(ctx context.Context, id string) (*domain.UserResponse, error) {
+	return &domain.UserResponse{ProfilePicture: "profile-pictures/" + id + ".jpg"}, nil
+}
+
+// presignRecorder records the expiry each URL was presigned with
+type presignRecorder struct {
+	expiry time.Duration
+}
+
+func (r *presignRecorder) UploadData(ctx context.Context, data []byte, filename string, contentType string, folder string) (string, error) {
+	return "", nil
+}
+
+func (r *presignRecorder) DeleteFile(ctx context.Context, objectPath string) error { return nil }
+
+func (r *presignRecorder) GetPresignedURL(ctx context.Context, objectPath string, expiry time.Duration) (string, error) {
+	r.expiry = expiry
+	return "https://storage.example.com/" + objectPath, nil
+}
+
+func TestGetProfilePictureExpiry(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		wantExpiry time.Duration
+	}{
+		{name: "default expiry", query: "", wantExpiry: 15 * time.Minute},
+		{name: "requested expiry", query: "?expiry=120", wantExpiry: 2 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storageClient := &presignRecorder{}
+			h := NewHandler(&profileService{}, storageClient, config.PresignConfig{DefaultExpiry: 900, MinExpiry: 60, MaxExpiry: 3600})
+
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/v1/users/u1/profile-picture"+tt.query, nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues("u1")
+			if err := h.GetProfilePicture(c); err != nil {
+				t.Fatalf("GetProfilePicture() error = %v", err)
+			}
+
+			if rec.Code != http.StatusTemporaryRedirect {
+				t.Fatalf("status = %d, want 307: %s", rec.Code, rec.Body.String())
+			}
+			if storageClient.expiry != tt.wantExpiry {
+				t.Fatalf("URL presigned for %v, want %v", storageClient.expiry, tt.wantExpiry)
+			}
+		})
+	}
+}
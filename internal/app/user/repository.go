@@ -11,8 +11,26 @@ type Repository interface {
 	FindByID(ctx context.Context, id string) (*domain.User, error)
 	FindByEmail(ctx context.Context, email string) (*domain.User, error)
 	FindByUsername(ctx context.Context, username string) (*domain.User, error)
-	FindAll(ctx context.Context, skip int, limit int, search string, currentUserID string) ([]domain.User, error)
-	Count(ctx context.Context, search string, currentUserID string) (int, error)
+	FindAll(ctx context.Context, skip int, limit int, filter UserFilter) ([]domain.User, error)
+	Count(ctx context.Context, filter UserFilter) (int, error)
+	FindByDepartment(ctx context.Context, departmentID string, skip int, limit int) ([]domain.User, error)
+	CountByDepartment(ctx context.Context, departmentID string) (int, error)
 	Update(ctx context.Context, id string, user *domain.User) error
+	IncrementTokenVersion(ctx context.Context, id string) error
+	SetDisabled(ctx context.Context, id string, disabled bool) error
+	MarkEmailVerified(ctx context.Context, id string) error
+	GetTOTP(ctx context.Context, id string) (*domain.UserTOTP, error)
+	SetTOTPSecret(ctx context.Context, id string, secret string) error
+	EnableTOTP(ctx context.Context, id string, backupCodes []string) error
+	RemoveTOTPBackupCode(ctx context.Context, id string, backupCode string) (bool, error)
+	AcceptTOTPCounter(ctx context.Context, id string, counter int64) (bool, error)
 	Delete(ctx context.Context, id string) error
 }
+
+// UserFilter narrows the user listing; empty fields are not applied
+type UserFilter struct {
+	Search        string          // matches username, email, first/last/full name or phone
+	Role          domain.UserRole // exact match
+	DepartmentID  string          // exact match
+	ExcludeUserID string          // typically the requesting user
+}
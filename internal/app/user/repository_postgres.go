@@ -3,11 +3,15 @@ package user
 import (
 	"context"
 	"e-document-backend/internal/domain"
+	"e-document-backend/internal/util"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -29,9 +33,9 @@ func (r *postgresRepository) Create(ctx context.Context, user *domain.User) erro
 		INSERT INTO users (
 			id, username, email, phone, first_name, last_name,
 			password, role, department_id, sector_id, profile_picture,
-			created_at, updated_at
+			email_verified, created_at, updated_at
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
+			$1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, ''), $10, $11, $12, $13, $14
 		)
 		RETURNING id, created_at, updated_at
 	`
@@ -52,23 +56,45 @@ func (r *postgresRepository) Create(ctx context.Context, user *domain.User) erro
 		user.DepartmentID,
 		user.SectorID,
 		user.ProfilePicture,
+		user.EmailVerified,
 		user.CreatedAt,
 		user.UpdatedAt,
 	).Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
 
 	if err != nil {
+		if conflict := uniqueViolationError(err, user); conflict != nil {
+			return conflict
+		}
 		return fmt.Errorf("failed to create user: %w", err)
 	}
 
 	return nil
 }
 
+// uniqueViolationError translates a unique-violation on the email or username into an already-exists error
+// Returns nil for any other error
+func uniqueViolationError(err error, user *domain.User) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != "23505" {
+		return nil
+	}
+
+	switch pgErr.ConstraintName {
+	case "idx_users_email_lower", "users_email_key":
+		return util.NewAlreadyExistsError("User", "email", user.Email)
+	case "idx_users_username_lower", "users_username_key":
+		return util.NewAlreadyExistsError("User", "username", user.Username)
+	default:
+		return nil
+	}
+}
+
 // FindByID retrieves a user by ID
 func (r *postgresRepository) FindByID(ctx context.Context, id string) (*domain.User, error) {
 	query := `
 		SELECT id, username, email, phone, first_name, last_name,
-		       password, role, department_id, sector_id, profile_picture,
-		       created_at, updated_at
+		       password, role, COALESCE(department_id, ''), sector_id, profile_picture,
+		       token_version, email_verified, totp_enabled, disabled, created_at, updated_at
 		FROM users
 		WHERE id = $1
 	`
@@ -91,6 +117,10 @@ func (r *postgresRepository) FindByID(ctx context.Context, id string) (*domain.U
 		&user.DepartmentID,
 		&user.SectorID,
 		&user.ProfilePicture,
+		&user.TokenVersion,
+		&user.EmailVerified,
+		&user.TOTPEnabled,
+		&user.Disabled,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -109,8 +139,8 @@ func (r *postgresRepository) FindByID(ctx context.Context, id string) (*domain.U
 func (r *postgresRepository) FindByUsername(ctx context.Context, username string) (*domain.User, error) {
 	query := `
 		SELECT id, username, email, phone, first_name, last_name,
-		       password, role, department_id, sector_id, profile_picture,
-		       created_at, updated_at
+		       password, role, COALESCE(department_id, ''), sector_id, profile_picture,
+		       token_version, email_verified, totp_enabled, disabled, created_at, updated_at
 		FROM users
 		WHERE username = $1
 	`
@@ -128,6 +158,10 @@ func (r *postgresRepository) FindByUsername(ctx context.Context, username string
 		&user.DepartmentID,
 		&user.SectorID,
 		&user.ProfilePicture,
+		&user.TokenVersion,
+		&user.EmailVerified,
+		&user.TOTPEnabled,
+		&user.Disabled,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -146,8 +180,8 @@ func (r *postgresRepository) FindByUsername(ctx context.Context, username string
 func (r *postgresRepository) FindByEmail(ctx context.Context, email string) (*domain.User, error) {
 	query := `
 		SELECT id, username, email, phone, first_name, last_name,
-		       password, role, department_id, sector_id, profile_picture,
-		       created_at, updated_at
+		       password, role, COALESCE(department_id, ''), sector_id, profile_picture,
+		       token_version, email_verified, totp_enabled, disabled, created_at, updated_at
 		FROM users
 		WHERE email = $1
 	`
@@ -165,6 +199,10 @@ func (r *postgresRepository) FindByEmail(ctx context.Context, email string) (*do
 		&user.DepartmentID,
 		&user.SectorID,
 		&user.ProfilePicture,
+		&user.TokenVersion,
+		&user.EmailVerified,
+		&user.TOTPEnabled,
+		&user.Disabled,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -180,34 +218,17 @@ func (r *postgresRepository) FindByEmail(ctx context.Context, email string) (*do
 }
 
 // FindAll retrieves all users with pagination and search (excluding current user)
-func (r *postgresRepository) FindAll(ctx context.Context, skip int, limit int, search string, currentUserID string) ([]domain.User, error) {
+func (r *postgresRepository) FindAll(ctx context.Context, skip int, limit int, filter UserFilter) ([]domain.User, error) {
 	query := `
 		SELECT id, username, email, phone, first_name, last_name,
-		       password, role, department_id, sector_id, profile_picture,
-		       created_at, updated_at
+		       password, role, COALESCE(department_id, ''), sector_id, profile_picture,
+		       token_version, email_verified, totp_enabled, disabled, created_at, updated_at
 		FROM users
-		WHERE 1=1
 	`
 
-	args := make([]interface{}, 0)
-	argCount := 1
-
-	// Add search filter
-	if search != "" {
-		query += fmt.Sprintf(" AND (username ILIKE $%d OR email ILIKE $%d)", argCount, argCount)
-		args = append(args, "%"+search+"%")
-		argCount++
-	}
-
-	// Exclude current user
-	if currentUserID != "" {
-		userID, err := uuid.Parse(currentUserID)
-		if err == nil {
-			query += fmt.Sprintf(" AND id != $%d", argCount)
-			args = append(args, userID)
-			argCount++
-		}
-	}
+	where, args := buildUserFilter(filter)
+	query += where
+	argCount := len(args) + 1
 
 	// Add ordering and pagination
 	query += " ORDER BY created_at DESC"
@@ -235,6 +256,10 @@ func (r *postgresRepository) FindAll(ctx context.Context, skip int, limit int, s
 			&user.DepartmentID,
 			&user.SectorID,
 			&user.ProfilePicture,
+			&user.TokenVersion,
+			&user.EmailVerified,
+			&user.TOTPEnabled,
+			&user.Disabled,
 			&user.CreatedAt,
 			&user.UpdatedAt,
 		)
@@ -252,38 +277,133 @@ func (r *postgresRepository) FindAll(ctx context.Context, skip int, limit int, s
 }
 
 // Count returns the total number of users (excluding current user)
-func (r *postgresRepository) Count(ctx context.Context, search string, currentUserID string) (int, error) {
-	query := "SELECT COUNT(*) FROM users WHERE 1=1"
+func (r *postgresRepository) Count(ctx context.Context, filter UserFilter) (int, error) {
+	where, args := buildUserFilter(filter)
+	query := "SELECT COUNT(*) FROM users" + where
 
+	var count int
+	err := r.pool.QueryRow(ctx, query, args...).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	return count, nil
+}
+
+// buildUserFilter builds the WHERE clause shared by FindAll and Count
+// The search term is matched literally with ILIKE, backed by the trigram indexes of migration 000017
+func buildUserFilter(filter UserFilter) (string, []interface{}) {
+	where := " WHERE 1=1"
 	args := make([]interface{}, 0)
 	argCount := 1
 
 	// Add search filter
-	if search != "" {
-		query += fmt.Sprintf(" AND (username ILIKE $%d OR email ILIKE $%d)", argCount, argCount)
-		args = append(args, "%"+search+"%")
+	if filter.Search != "" {
+		where += fmt.Sprintf(` AND (
+			username ILIKE $%[1]d ESCAPE '\' OR email ILIKE $%[1]d ESCAPE '\'
+			OR first_name ILIKE $%[1]d ESCAPE '\' OR last_name ILIKE $%[1]d ESCAPE '\'
+			OR (first_name || ' ' || last_name) ILIKE $%[1]d ESCAPE '\'
+			OR phone ILIKE $%[1]d ESCAPE '\'
+		)`, argCount)
+		args = append(args, "%"+escapeLike(filter.Search)+"%")
+		argCount++
+	}
+
+	if filter.Role != "" {
+		where += fmt.Sprintf(" AND role = $%d", argCount)
+		args = append(args, filter.Role)
+		argCount++
+	}
+
+	if filter.DepartmentID != "" {
+		where += fmt.Sprintf(" AND department_id = $%d", argCount)
+		args = append(args, filter.DepartmentID)
 		argCount++
 	}
 
 	// Exclude current user
-	if currentUserID != "" {
-		userID, err := uuid.Parse(currentUserID)
-		if err == nil {
-			query += fmt.Sprintf(" AND id != $%d", argCount)
+	if filter.ExcludeUserID != "" {
+		if userID, err := uuid.Parse(filter.ExcludeUserID); err == nil {
+			where += fmt.Sprintf(" AND id != $%d", argCount)
 			args = append(args, userID)
 		}
 	}
 
-	var count int
-	err := r.pool.QueryRow(ctx, query, args...).Scan(&count)
+	return where, args
+}
+
+// escapeLike escapes the LIKE wildcards in s so it matches literally with ESCAPE '\'
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// FindByDepartment retrieves users belonging to a department with pagination
+func (r *postgresRepository) FindByDepartment(ctx context.Context, departmentID string, skip int, limit int) ([]domain.User, error) {
+	query := `
+		SELECT id, username, email, phone, first_name, last_name,
+		       password, role, COALESCE(department_id, ''), sector_id, profile_picture,
+		       token_version, email_verified, totp_enabled, disabled, created_at, updated_at
+		FROM users
+		WHERE department_id = $1
+		ORDER BY first_name ASC, last_name ASC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.pool.Query(ctx, query, departmentID, limit, skip)
 	if err != nil {
-		return 0, fmt.Errorf("failed to count users: %w", err)
+		return nil, fmt.Errorf("failed to find users by department: %w", err)
+	}
+	defer rows.Close()
+
+	var users []domain.User
+	for rows.Next() {
+		var user domain.User
+		err := rows.Scan(
+			&user.ID,
+			&user.Username,
+			&user.Email,
+			&user.Phone,
+			&user.FirstName,
+			&user.LastName,
+			&user.Password,
+			&user.Role,
+			&user.DepartmentID,
+			&user.SectorID,
+			&user.ProfilePicture,
+			&user.TokenVersion,
+			&user.EmailVerified,
+			&user.TOTPEnabled,
+			&user.Disabled,
+			&user.CreatedAt,
+			&user.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return users, nil
+}
+
+// CountByDepartment returns the number of users belonging to a department
+func (r *postgresRepository) CountByDepartment(ctx context.Context, departmentID string) (int, error) {
+	query := "SELECT COUNT(*) FROM users WHERE department_id = $1"
+
+	var count int
+	if err := r.pool.QueryRow(ctx, query, departmentID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count users by department: %w", err)
 	}
 
 	return count, nil
 }
 
 // Update updates a user by ID
+// A changed email address is no longer verified; email_verified is otherwise left as it is
 func (r *postgresRepository) Update(ctx context.Context, id string, user *domain.User) error {
 	query := `
 		UPDATE users
@@ -294,9 +414,10 @@ func (r *postgresRepository) Update(ctx context.Context, id string, user *domain
 		    last_name = $5,
 		    password = $6,
 		    role = $7,
-		    department_id = $8,
+		    department_id = NULLIF($8, ''),
 		    sector_id = $9,
 		    profile_picture = $10,
+		    email_verified = email_verified AND email = $2,
 		    updated_at = $11
 		WHERE id = $12
 	`
@@ -324,6 +445,9 @@ func (r *postgresRepository) Update(ctx context.Context, id string, user *domain
 	)
 
 	if err != nil {
+		if conflict := uniqueViolationError(err, user); conflict != nil {
+			return conflict
+		}
 		return fmt.Errorf("failed to update user: %w", err)
 	}
 
@@ -334,6 +458,187 @@ func (r *postgresRepository) Update(ctx context.Context, id string, user *domain
 	return nil
 }
 
+// IncrementTokenVersion bumps a user's token version, revoking every token issued before
+func (r *postgresRepository) IncrementTokenVersion(ctx context.Context, id string) error {
+	query := "UPDATE users SET token_version = token_version + 1 WHERE id = $1"
+
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	result, err := r.pool.Exec(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("failed to increment token version: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+// SetDisabled disables or re-enables a user; disabling also bumps the token version so every
+// token issued before, including refresh tokens, is revoked
+func (r *postgresRepository) SetDisabled(ctx context.Context, id string, disabled bool) error {
+	query := `
+		UPDATE users
+		SET disabled = $2,
+		    token_version = token_version + CASE WHEN $2 THEN 1 ELSE 0 END,
+		    updated_at = NOW()
+		WHERE id = $1
+	`
+
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	result, err := r.pool.Exec(ctx, query, userID, disabled)
+	if err != nil {
+		return fmt.Errorf("failed to update disabled flag: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+// MarkEmailVerified records that a user confirmed their email address
+func (r *postgresRepository) MarkEmailVerified(ctx context.Context, id string) error {
+	query := "UPDATE users SET email_verified = true, updated_at = NOW() WHERE id = $1"
+
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	result, err := r.pool.Exec(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("failed to mark email verified: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+// GetTOTP retrieves a user's encrypted TOTP secret and hashed backup codes
+func (r *postgresRepository) GetTOTP(ctx context.Context, id string) (*domain.UserTOTP, error) {
+	query := `
+		SELECT COALESCE(totp_secret, ''), totp_enabled, totp_backup_codes, totp_last_counter
+		FROM users
+		WHERE id = $1
+	`
+
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	var totp domain.UserTOTP
+	err = r.pool.QueryRow(ctx, query, userID).Scan(&totp.Secret, &totp.Enabled, &totp.BackupCodes, &totp.LastCounter)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("user not found")
+		}
+		return nil, fmt.Errorf("failed to get totp: %w", err)
+	}
+
+	return &totp, nil
+}
+
+// SetTOTPSecret stores a pending TOTP secret; two-factor stays disabled until a code is confirmed
+func (r *postgresRepository) SetTOTPSecret(ctx context.Context, id string, secret string) error {
+	query := "UPDATE users SET totp_secret = $2, updated_at = NOW() WHERE id = $1 AND totp_enabled = false"
+
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	result, err := r.pool.Exec(ctx, query, userID, secret)
+	if err != nil {
+		return fmt.Errorf("failed to set totp secret: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+// EnableTOTP turns on two-factor authentication with the given hashed backup codes
+func (r *postgresRepository) EnableTOTP(ctx context.Context, id string, backupCodes []string) error {
+	query := "UPDATE users SET totp_enabled = true, totp_backup_codes = $2, updated_at = NOW() WHERE id = $1"
+
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	result, err := r.pool.Exec(ctx, query, userID, backupCodes)
+	if err != nil {
+		return fmt.Errorf("failed to enable totp: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+// RemoveTOTPBackupCode consumes a hashed backup code so it cannot be used again
+// Returns false if the code was already used
+func (r *postgresRepository) RemoveTOTPBackupCode(ctx context.Context, id string, backupCode string) (bool, error) {
+	query := `
+		UPDATE users
+		SET totp_backup_codes = array_remove(totp_backup_codes, $2)
+		WHERE id = $1 AND $2 = ANY(totp_backup_codes)
+	`
+
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		return false, fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	result, err := r.pool.Exec(ctx, query, userID, backupCode)
+	if err != nil {
+		return false, fmt.Errorf("failed to remove totp backup code: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+// AcceptTOTPCounter records the time step of an accepted TOTP code
+// Returns false if a code from the same or a later step was already accepted
+func (r *postgresRepository) AcceptTOTPCounter(ctx context.Context, id string, counter int64) (bool, error) {
+	query := `
+		UPDATE users
+		SET totp_last_counter = $2
+		WHERE id = $1 AND totp_last_counter < $2
+	`
+
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		return false, fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	result, err := r.pool.Exec(ctx, query, userID, counter)
+	if err != nil {
+		return false, fmt.Errorf("failed to accept totp counter: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
 // Delete deletes a user by ID
 func (r *postgresRepository) Delete(ctx context.Context, id string) error {
 	query := "DELETE FROM users WHERE id = $1"
@@ -345,6 +650,16 @@ func (r *postgresRepository) Delete(ctx context.Context, id string) error {
 
 	result, err := r.pool.Exec(ctx, query, userID)
 	if err != nil {
+		// Documents and folders reference their owner with ON DELETE RESTRICT
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return util.ErrorResponse(
+				"User still owns documents or folders",
+				util.USER_HAS_CONTENT,
+				409,
+				fmt.Sprintf("user %s owns documents or folders that must be removed or reassigned first", id),
+			)
+		}
 		return fmt.Errorf("failed to delete user: %w", err)
 	}
 
@@ -0,0 +1,31 @@
+package user
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildUserFilterSearch(t *testing.T) {
+	tests := []struct {
+		name     string
+		search   string
+		wantTerm string
+	}{
+		{name: "plain term", search: "somchai", wantTerm: "%somchai%"},
+		{name: "percent matches literally", search: "100%", wantTerm: `%100\%%`},
+		{name: "underscore matches literally", search: "som_chai", wantTerm: `%som\_chai%`},
+		{name: "backslash matches literally", search: `a\b`, wantTerm: `%a\\b%`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			where, args := buildUserFilter(UserFilter{Search: tt.search})
+			if len(args) != 1 || args[0] != tt.wantTerm {
+				t.Fatalf("search args = %v, want [%s]", args, tt.wantTerm)
+			}
+			if strings.Count(where, "ILIKE") != strings.Count(where, `ESCAPE '\'`) {
+				t.Fatalf("every ILIKE must declare its escape character: %s", where)
+			}
+		})
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/rs/zerolog/log"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -18,28 +19,64 @@ const (
 
 // Service defines the interface for user business logic
 type Service interface {
-	CreateUser(ctx context.Context, req domain.CreateUserRequest) (*domain.UserResponse, error)
+	CreateUser(ctx context.Context, requesterID string, req domain.CreateUserRequest) (*domain.UserResponse, error)
 	GetUserByID(ctx context.Context, id string) (*domain.UserResponse, error)
-	GetAllUsers(ctx context.Context, page, limit int, search string, currentUserID string) ([]domain.UserResponse, int, error)
-	UpdateUser(ctx context.Context, id string, req domain.UpdateUserRequest) (*domain.UserResponse, error)
+	GetAllUsers(ctx context.Context, page, limit int, filter UserFilter) ([]domain.UserResponse, int, error)
+	UpdateUser(ctx context.Context, requesterID, id string, req domain.UpdateUserRequest) (*domain.UserResponse, error)
+	UpdateProfile(ctx context.Context, id string, req domain.UpdateProfileRequest) (*domain.UserResponse, error)
+	RequireDirector(ctx context.Context, requesterID string) error
 	UpdateProfilePicture(ctx context.Context, id string, profilePictureURL string) (*domain.UserResponse, error)
 	DeleteUser(ctx context.Context, id string) error
+	SetDisabled(ctx context.Context, requesterID, id string, disabled bool) (*domain.UserResponse, error)
+}
+
+// EmailVerifier sends an email verification token to a newly created user
+type EmailVerifier interface {
+	SendEmailVerification(ctx context.Context, user *domain.User) error
+}
+
+// SectorValidator checks the sector and department assigned to a user exist in the org hierarchy
+type SectorValidator interface {
+	ValidateAssignment(ctx context.Context, sectorID, departmentID string) error
+}
+
+// SessionInvalidator forgets cached authentication state of a user so account changes apply at once
+type SessionInvalidator interface {
+	InvalidateUserState(userID string)
 }
 
 // service implements the Service interface
 type service struct {
-	repo Repository
+	repo        Repository
+	bcryptCost  int
+	defaultRole domain.UserRole
+	verifier    EmailVerifier
+	sectors     SectorValidator
+	sessions    SessionInvalidator
 }
 
-// NewService creates a new user service
-func NewService(repo Repository) Service {
+// NewService creates a new user service that hashes passwords with the given bcrypt cost
+// New users created without a role get defaultRole
+func NewService(repo Repository, bcryptCost int, defaultRole domain.UserRole, verifier EmailVerifier, sectors SectorValidator, sessions SessionInvalidator) Service {
 	return &service{
-		repo: repo,
+		repo:        repo,
+		bcryptCost:  bcryptCost,
+		defaultRole: defaultRole,
+		verifier:    verifier,
+		sectors:     sectors,
+		sessions:    sessions,
 	}
 }
 
 // NOTE CreateUser creates a new user
-func (s *service) CreateUser(ctx context.Context, req domain.CreateUserRequest) (*domain.UserResponse, error) {
+// The requester cannot give the new user a role they are not allowed to assign
+func (s *service) CreateUser(ctx context.Context, requesterID string, req domain.CreateUserRequest) (*domain.UserResponse, error) {
+	// Strip phone formatting before validating it as E.164
+	req.Phone = normalizePhone(req.Phone)
+	if err := util.ValidateStructFields(&req); err != nil {
+		return nil, err
+	}
+
 	// Create context with timeout for database operations
 	dbCtx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
@@ -60,13 +97,24 @@ func (s *service) CreateUser(ctx context.Context, req domain.CreateUserRequest)
 		return nil, util.NewAlreadyExistsError("User", "username", normalizedUsername)
 	}
 
-	// Validate role
+	// Validate role; an empty role falls back to the configured default
+	if req.Role == "" {
+		req.Role = s.defaultRole
+	}
 	if !req.Role.IsValid() {
 		return nil, util.NewInvalidInputError("Role", "must be Director, DepartmentManager, SectorManager, or Employee")
 	}
+	if err := s.requireRoleAssignable(dbCtx, requesterID, req.Role, ""); err != nil {
+		return nil, err
+	}
+
+	// The sector must exist and contain the department
+	if err := s.sectors.ValidateAssignment(dbCtx, req.SectorID, req.DepartmentID); err != nil {
+		return nil, err
+	}
 
 	// Hash the password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), s.bcryptCost)
 	if err != nil {
 		return nil, util.NewInternalError(fmt.Sprintf("failed to hash password: %v", err))
 	}
@@ -86,9 +134,18 @@ func (s *service) CreateUser(ctx context.Context, req domain.CreateUserRequest)
 
 	// Save to database
 	if err := s.repo.Create(dbCtx, user); err != nil {
+		// A concurrent signup can still take the email or username after the checks above
+		if util.IsCustomError(err) {
+			return nil, err
+		}
 		return nil, util.NewDatabaseError("create user", err)
 	}
 
+	// The account exists either way; a failed send can be retried by an administrator
+	if err := s.verifier.SendEmailVerification(ctx, user); err != nil {
+		log.Warn().Err(err).Str("user_id", user.ID.String()).Msg("Failed to send email verification")
+	}
+
 	response := user.ToResponse()
 	return &response, nil
 }
@@ -108,8 +165,8 @@ func (s *service) GetUserByID(ctx context.Context, id string) (*domain.UserRespo
 	return &response, nil
 }
 
-// NOTE GetAllUsers retrieves all users with pagination (excluding current user)
-func (s *service) GetAllUsers(ctx context.Context, page, limit int, search string, currentUserID string) ([]domain.UserResponse, int, error) {
+// NOTE GetAllUsers retrieves all users matching the filter with pagination
+func (s *service) GetAllUsers(ctx context.Context, page, limit int, filter UserFilter) ([]domain.UserResponse, int, error) {
 	// Create context with timeout for database operations
 	dbCtx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
@@ -128,13 +185,13 @@ func (s *service) GetAllUsers(ctx context.Context, page, limit int, search strin
 	// Get total count in parallel (excluding current user)
 	go func() {
 		defer wg.Done()
-		total, countErr = s.repo.Count(dbCtx, search, currentUserID)
+		total, countErr = s.repo.Count(dbCtx, filter)
 	}()
 
 	// Get paginated users in parallel (excluding current user)
 	go func() {
 		defer wg.Done()
-		users, findErr = s.repo.FindAll(dbCtx, skip, limit, search, currentUserID)
+		users, findErr = s.repo.FindAll(dbCtx, skip, limit, filter)
 	}()
 
 	// Wait for both operations to complete
@@ -169,7 +226,15 @@ func (s *service) GetAllUsers(ctx context.Context, page, limit int, search strin
 }
 
 // NOTE UpdateUser updates a user by ID
-func (s *service) UpdateUser(ctx context.Context, id string, req domain.UpdateUserRequest) (*domain.UserResponse, error) {
+// A role change must be one the requester may make: they must be able to assign both the
+// user's current role and the new one
+func (s *service) UpdateUser(ctx context.Context, requesterID, id string, req domain.UpdateUserRequest) (*domain.UserResponse, error) {
+	// Strip phone formatting before validating it as E.164
+	req.Phone = normalizePhone(req.Phone)
+	if err := util.ValidateStructFields(&req); err != nil {
+		return nil, err
+	}
+
 	// Create context with timeout for database operations
 	dbCtx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
@@ -186,6 +251,8 @@ func (s *service) UpdateUser(ctx context.Context, id string, req domain.UpdateUs
 	}
 
 	// Check if email is being changed and if it already exists
+	// The repository clears email_verified for a new address, which is sent a new token below
+	emailChanged := false
 	if req.Email != "" {
 		normalizedEmail := strings.ToLower(strings.TrimSpace(req.Email))
 		if normalizedEmail != existingUser.Email {
@@ -199,6 +266,7 @@ func (s *service) UpdateUser(ctx context.Context, id string, req domain.UpdateUs
 				)
 			}
 			existingUser.Email = normalizedEmail
+			emailChanged = true
 		}
 	}
 
@@ -229,9 +297,27 @@ func (s *service) UpdateUser(ctx context.Context, id string, req domain.UpdateUs
 				"role must be Director, DepartmentManager, SectorManager, or Employee",
 			)
 		}
+		if req.Role != existingUser.Role {
+			if err := s.requireRoleAssignable(dbCtx, requesterID, req.Role, existingUser.Role); err != nil {
+				return nil, err
+			}
+		}
 		existingUser.Role = req.Role
 	}
 
+	// Check if department or sector is being changed and validate the resulting assignment
+	if req.DepartmentID != "" || req.SectorID != "" {
+		if req.DepartmentID != "" {
+			existingUser.DepartmentID = req.DepartmentID
+		}
+		if req.SectorID != "" {
+			existingUser.SectorID = req.SectorID
+		}
+		if err := s.sectors.ValidateAssignment(dbCtx, existingUser.SectorID, existingUser.DepartmentID); err != nil {
+			return nil, err
+		}
+	}
+
 	// Update phone if provided
 	if req.Phone != "" {
 		existingUser.Phone = req.Phone
@@ -249,7 +335,7 @@ func (s *service) UpdateUser(ctx context.Context, id string, req domain.UpdateUs
 
 	// Update password if provided
 	if req.Password != "" {
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), s.bcryptCost)
 		if err != nil {
 			return nil, util.ErrorResponse(
 				"Failed to hash password",
@@ -263,6 +349,9 @@ func (s *service) UpdateUser(ctx context.Context, id string, req domain.UpdateUs
 
 	// Update in database
 	if err := s.repo.Update(dbCtx, id, existingUser); err != nil {
+		if util.IsCustomError(err) {
+			return nil, err
+		}
 		return nil, util.ErrorResponse(
 			"Failed to update user",
 			util.DATABASE_ERROR,
@@ -282,10 +371,96 @@ func (s *service) UpdateUser(ctx context.Context, id string, req domain.UpdateUs
 		)
 	}
 
+	// The change is saved either way; the user can ask for another token
+	if emailChanged {
+		if err := s.verifier.SendEmailVerification(ctx, updatedUser); err != nil {
+			log.Warn().Err(err).Str("user_id", id).Msg("Failed to send email verification")
+		}
+	}
+
 	response := updatedUser.ToResponse()
 	return &response, nil
 }
 
+// NOTE UpdateProfile lets a user update their own name, phone, email and password
+// Changing the password requires the current password
+func (s *service) UpdateProfile(ctx context.Context, id string, req domain.UpdateProfileRequest) (*domain.UserResponse, error) {
+	// Strip phone formatting before validating it as E.164
+	req.Phone = normalizePhone(req.Phone)
+	if err := util.ValidateStructFields(&req); err != nil {
+		return nil, err
+	}
+
+	if req.Password != "" || req.Role != "" {
+		dbCtx, cancel := context.WithTimeout(ctx, dbTimeout)
+		existingUser, err := s.repo.FindByID(dbCtx, id)
+		cancel()
+		if err != nil {
+			return nil, util.ErrorResponse(
+				"User not found",
+				util.USER_NOT_FOUND,
+				404,
+				fmt.Sprintf("user with id %s not found", id),
+			)
+		}
+
+		// Sending back the current role is harmless; changing it here would let users promote themselves
+		if req.Role != "" && req.Role != existingUser.Role {
+			return nil, util.NewForbiddenError("you cannot change your own role")
+		}
+
+		if req.Password != "" {
+			if err := bcrypt.CompareHashAndPassword([]byte(existingUser.Password), []byte(req.CurrentPassword)); err != nil {
+				return nil, util.NewInvalidInputError("current_password", "is incorrect")
+			}
+		}
+	}
+
+	// Only the self-service fields are passed on; role, department and sector stay unchanged
+	return s.UpdateUser(ctx, id, id, domain.UpdateUserRequest{
+		Email:     req.Email,
+		Phone:     req.Phone,
+		FirstName: req.FirstName,
+		LastName:  req.LastName,
+		Password:  req.Password,
+	})
+}
+
+// requireRoleAssignable checks the requester may give role to a user whose role is currently
+// current (empty for a new user), so nobody can hand out more authority than they hold
+func (s *service) requireRoleAssignable(ctx context.Context, requesterID string, role, current domain.UserRole) error {
+	requester, err := s.repo.FindByID(ctx, requesterID)
+	if err != nil {
+		return util.NewUnauthorizedError("requesting user not found")
+	}
+
+	if !requester.Role.CanAssign(role) {
+		return util.NewForbiddenError(fmt.Sprintf("a %s cannot assign the %s role", requester.Role, role))
+	}
+	if current != "" && !requester.Role.CanAssign(current) {
+		return util.NewForbiddenError(fmt.Sprintf("a %s cannot change the role of a %s", requester.Role, current))
+	}
+
+	return nil
+}
+
+// NOTE RequireDirector checks that the requesting user is a Director
+func (s *service) RequireDirector(ctx context.Context, requesterID string) error {
+	dbCtx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	requester, err := s.repo.FindByID(dbCtx, requesterID)
+	if err != nil {
+		return util.NewUnauthorizedError("requesting user not found")
+	}
+
+	if requester.Role != domain.RoleDirector {
+		return util.NewForbiddenError("only directors can update other users")
+	}
+
+	return nil
+}
+
 // NOTE UpdateProfilePicture updates a user's profile picture
 func (s *service) UpdateProfilePicture(ctx context.Context, id string, profilePictureURL string) (*domain.UserResponse, error) {
 	// Create context with timeout for database operations
@@ -308,6 +483,9 @@ func (s *service) UpdateProfilePicture(ctx context.Context, id string, profilePi
 	fmt.Println(existingUser)
 	// Update in database
 	if err := s.repo.Update(dbCtx, id, existingUser); err != nil {
+		if util.IsCustomError(err) {
+			return nil, err
+		}
 		return nil, util.ErrorResponse(
 			"Failed to update profile picture",
 			util.DATABASE_ERROR,
@@ -349,6 +527,9 @@ func (s *service) DeleteUser(ctx context.Context, id string) error {
 	}
 
 	if err := s.repo.Delete(dbCtx, id); err != nil {
+		if util.IsCustomError(err) {
+			return err
+		}
 		return util.ErrorResponse(
 			"Failed to delete user",
 			util.DATABASE_ERROR,
@@ -359,3 +540,52 @@ func (s *service) DeleteUser(ctx context.Context, id string) error {
 
 	return nil
 }
+
+// SetDisabled disables or re-enables a user account on behalf of a Director
+// Disabling revokes every token of the user; Directors cannot disable themselves so an
+// organization is never left without anyone able to re-enable accounts
+func (s *service) SetDisabled(ctx context.Context, requesterID, id string, disabled bool) (*domain.UserResponse, error) {
+	if err := s.RequireDirector(ctx, requesterID); err != nil {
+		return nil, err
+	}
+	if disabled && requesterID == id {
+		return nil, util.NewInvalidInputError("id", "you cannot disable your own account")
+	}
+
+	dbCtx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	if _, err := s.repo.FindByID(dbCtx, id); err != nil {
+		return nil, util.ErrorResponse(
+			"User not found",
+			util.USER_NOT_FOUND,
+			404,
+			fmt.Sprintf("user with id %s not found", id),
+		)
+	}
+
+	if err := s.repo.SetDisabled(dbCtx, id, disabled); err != nil {
+		return nil, util.NewDatabaseError("update disabled flag", err)
+	}
+	s.sessions.InvalidateUserState(id)
+
+	user, err := s.repo.FindByID(dbCtx, id)
+	if err != nil {
+		return nil, util.NewDatabaseError("find user", err)
+	}
+
+	response := user.ToResponse()
+	return &response, nil
+}
+
+// normalizePhone strips the spaces, dashes, dots and parentheses people type into phone numbers
+// e.g. "+66 81-234-5678" becomes "+66812345678"
+func normalizePhone(phone string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.', '(', ')':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(phone))
+}
@@ -0,0 +1,54 @@
+package user_test
+
+import (
+	"context"
+	"e-document-backend/internal/app/user"
+	"e-document-backend/internal/app/user/usertest"
+	"e-document-backend/internal/domain"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// recordingVerifier records the users it was asked to send a verification token to
+type recordingVerifier struct {
+	sent []string // emails
+}
+
+func (v *recordingVerifier) SendEmailVerification(ctx context.Context, user *domain.User) error {
+	v.sent = append(v.sent, user.Email)
+	return nil
+}
+
+func TestUpdateUserEmailVerification(t *testing.T) {
+	tests := []struct {
+		name         string
+		email        string
+		wantVerified bool
+		wantSent     bool
+	}{
+		{name: "new address", email: "new@example.com", wantSent: true},
+		{name: "same address in another case", email: "Somchai@Example.com", wantVerified: true},
+		{name: "no address", email: "", wantVerified: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := domain.User{ID: uuid.New(), Username: "somchai", Email: "somchai@example.com", Role: domain.RoleEmployee, EmailVerified: true}
+			repo := usertest.NewRepository(u)
+			verifier := &recordingVerifier{}
+			svc := user.NewService(repo, 4, domain.RoleEmployee, verifier, nil, nil)
+
+			updated, err := svc.UpdateUser(context.Background(), u.ID.String(), u.ID.String(), domain.UpdateUserRequest{Email: tt.email, FirstName: "Somchai"})
+			if err != nil {
+				t.Fatalf("UpdateUser() error = %v", err)
+			}
+			if updated.EmailVerified != tt.wantVerified || repo.Get(u.ID.String()).EmailVerified != tt.wantVerified {
+				t.Fatalf("email_verified = %v, want %v", updated.EmailVerified, tt.wantVerified)
+			}
+			if sent := len(verifier.sent) == 1 && verifier.sent[0] == tt.email; sent != tt.wantSent {
+				t.Fatalf("verification sent to %v, want sent = %v", verifier.sent, tt.wantSent)
+			}
+		})
+	}
+}
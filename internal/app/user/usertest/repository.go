@@ -0,0 +1,277 @@
+// Package usertest provides an in-memory user.Repository for tests of the services built on it
+package usertest
+
+import (
+	"context"
+	"e-document-backend/internal/app/user"
+	"e-document-backend/internal/domain"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Repository keeps users in memory; the zero value is not usable, use NewRepository
+type Repository struct {
+	mu    sync.Mutex
+	users map[string]*domain.User
+	totp  map[string]*domain.UserTOTP
+}
+
+var _ user.Repository = (*Repository)(nil)
+
+// NewRepository creates a repository holding copies of the given users
+func NewRepository(users ...domain.User) *Repository {
+	r := &Repository{
+		users: make(map[string]*domain.User),
+		totp:  make(map[string]*domain.UserTOTP),
+	}
+	for i := range users {
+		u := users[i]
+		if u.ID == uuid.Nil {
+			u.ID = uuid.New()
+		}
+		r.users[u.ID.String()] = &u
+	}
+	return r
+}
+
+// Get returns a copy of a stored user, or nil
+func (r *Repository) Get(id string) *domain.User {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if u, ok := r.users[id]; ok {
+		copied := *u
+		return &copied
+	}
+	return nil
+}
+
+func (r *Repository) Create(ctx context.Context, u *domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if u.ID == uuid.Nil {
+		u.ID = uuid.New()
+	}
+	for _, existing := range r.users {
+		if strings.EqualFold(existing.Email, u.Email) || strings.EqualFold(existing.Username, u.Username) {
+			return fmt.Errorf("user already exists")
+		}
+	}
+	u.CreatedAt = time.Now()
+	u.UpdatedAt = u.CreatedAt
+	copied := *u
+	r.users[u.ID.String()] = &copied
+	return nil
+}
+
+func (r *Repository) FindByID(ctx context.Context, id string) (*domain.User, error) {
+	if u := r.Get(id); u != nil {
+		return u, nil
+	}
+	return nil, fmt.Errorf("user not found")
+}
+
+func (r *Repository) FindByEmail(ctx context.Context, email string) (*domain.User, error) {
+	return r.findFirst(func(u *domain.User) bool { return strings.EqualFold(u.Email, email) })
+}
+
+func (r *Repository) FindByUsername(ctx context.Context, username string) (*domain.User, error) {
+	return r.findFirst(func(u *domain.User) bool { return strings.EqualFold(u.Username, username) })
+}
+
+func (r *Repository) findFirst(match func(u *domain.User) bool) (*domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.users {
+		if match(u) {
+			copied := *u
+			return &copied, nil
+		}
+	}
+	return nil, fmt.Errorf("user not found")
+}
+
+// list returns copies of the users matching a predicate, newest first like the Postgres repository
+func (r *Repository) list(match func(u *domain.User) bool) []domain.User {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var users []domain.User
+	for _, u := range r.users {
+		if match(u) {
+			users = append(users, *u)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool {
+		if users[i].CreatedAt.Equal(users[j].CreatedAt) {
+			return users[i].Username < users[j].Username
+		}
+		return users[i].CreatedAt.After(users[j].CreatedAt)
+	})
+	return users
+}
+
+func page(users []domain.User, skip, limit int) []domain.User {
+	if skip >= len(users) {
+		return nil
+	}
+	end := skip + limit
+	if end > len(users) {
+		end = len(users)
+	}
+	return users[skip:end]
+}
+
+func matchesFilter(u *domain.User, filter user.UserFilter) bool {
+	if filter.Search != "" {
+		search := strings.ToLower(filter.Search)
+		fields := []string{u.Username, u.Email, u.FirstName, u.LastName, u.FirstName + " " + u.LastName, u.Phone}
+		found := false
+		for _, field := range fields {
+			if strings.Contains(strings.ToLower(field), search) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if filter.Role != "" && u.Role != filter.Role {
+		return false
+	}
+	if filter.DepartmentID != "" && u.DepartmentID != filter.DepartmentID {
+		return false
+	}
+	return filter.ExcludeUserID == "" || u.ID.String() != filter.ExcludeUserID
+}
+
+func (r *Repository) FindAll(ctx context.Context, skip int, limit int, filter user.UserFilter) ([]domain.User, error) {
+	return page(r.list(func(u *domain.User) bool { return matchesFilter(u, filter) }), skip, limit), nil
+}
+
+func (r *Repository) Count(ctx context.Context, filter user.UserFilter) (int, error) {
+	return len(r.list(func(u *domain.User) bool { return matchesFilter(u, filter) })), nil
+}
+
+func (r *Repository) FindByDepartment(ctx context.Context, departmentID string, skip int, limit int) ([]domain.User, error) {
+	return page(r.list(func(u *domain.User) bool { return u.DepartmentID == departmentID }), skip, limit), nil
+}
+
+func (r *Repository) CountByDepartment(ctx context.Context, departmentID string) (int, error) {
+	return len(r.list(func(u *domain.User) bool { return u.DepartmentID == departmentID })), nil
+}
+
+// modify applies fn to a stored user under the lock
+func (r *Repository) modify(id string, fn func(u *domain.User)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[id]
+	if !ok {
+		return fmt.Errorf("user not found")
+	}
+	fn(u)
+	u.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *Repository) Update(ctx context.Context, id string, updated *domain.User) error {
+	return r.modify(id, func(u *domain.User) {
+		createdAt := u.CreatedAt
+		verified := u.EmailVerified && u.Email == updated.Email
+		*u = *updated
+		u.CreatedAt = createdAt
+		u.EmailVerified = verified
+	})
+}
+
+func (r *Repository) IncrementTokenVersion(ctx context.Context, id string) error {
+	return r.modify(id, func(u *domain.User) { u.TokenVersion++ })
+}
+
+func (r *Repository) SetDisabled(ctx context.Context, id string, disabled bool) error {
+	return r.modify(id, func(u *domain.User) { u.Disabled = disabled })
+}
+
+func (r *Repository) MarkEmailVerified(ctx context.Context, id string) error {
+	return r.modify(id, func(u *domain.User) { u.EmailVerified = true })
+}
+
+func (r *Repository) GetTOTP(ctx context.Context, id string) (*domain.UserTOTP, error) {
+	if r.Get(id) == nil {
+		return nil, fmt.Errorf("user not found")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	totp := domain.UserTOTP{LastCounter: -1}
+	if stored, ok := r.totp[id]; ok {
+		totp = *stored
+		totp.BackupCodes = append([]string(nil), stored.BackupCodes...)
+	}
+	return &totp, nil
+}
+
+// updateTOTP applies fn to a user's two-factor settings under the lock
+func (r *Repository) updateTOTP(id string, fn func(t *domain.UserTOTP, u *domain.User)) error {
+	return r.modify(id, func(u *domain.User) {
+		t, ok := r.totp[id]
+		if !ok {
+			t = &domain.UserTOTP{LastCounter: -1}
+			r.totp[id] = t
+		}
+		fn(t, u)
+	})
+}
+
+func (r *Repository) SetTOTPSecret(ctx context.Context, id string, secret string) error {
+	return r.updateTOTP(id, func(t *domain.UserTOTP, u *domain.User) {
+		t.Secret = secret
+	})
+}
+
+func (r *Repository) EnableTOTP(ctx context.Context, id string, backupCodes []string) error {
+	return r.updateTOTP(id, func(t *domain.UserTOTP, u *domain.User) {
+		t.Enabled = true
+		t.BackupCodes = append([]string(nil), backupCodes...)
+		u.TOTPEnabled = true
+	})
+}
+
+func (r *Repository) RemoveTOTPBackupCode(ctx context.Context, id string, backupCode string) (bool, error) {
+	removed := false
+	err := r.updateTOTP(id, func(t *domain.UserTOTP, u *domain.User) {
+		for i, code := range t.BackupCodes {
+			if code == backupCode {
+				t.BackupCodes = append(t.BackupCodes[:i], t.BackupCodes[i+1:]...)
+				removed = true
+				return
+			}
+		}
+	})
+	return removed, err
+}
+
+func (r *Repository) AcceptTOTPCounter(ctx context.Context, id string, counter int64) (bool, error) {
+	accepted := false
+	err := r.updateTOTP(id, func(t *domain.UserTOTP, u *domain.User) {
+		if t.LastCounter < counter {
+			t.LastCounter = counter
+			accepted = true
+		}
+	})
+	return accepted, err
+}
+
+func (r *Repository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.users[id]; !ok {
+		return fmt.Errorf("user not found")
+	}
+	delete(r.users, id)
+	delete(r.totp, id)
+	return nil
+}
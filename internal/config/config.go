@@ -1,29 +1,58 @@
 package config
 
 import (
+	"e-document-backend/internal/domain"
+	"fmt"
+	"math"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
+	"golang.org/x/crypto/bcrypt"
 )
 
 // Config holds all configuration for the application
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	Admin    AdminConfig
-	Logger   LoggerConfig
-	JWT      JWTConfig
+	Server     ServerConfig
+	Database   DatabaseConfig
+	Admin      AdminConfig
+	Logger     LoggerConfig
+	JWT        JWTConfig
+	Shutdown   ShutdownConfig
+	Password   PasswordConfig
+	TOTP       TOTPConfig
+	Presign    PresignConfig
+	Pagination PaginationConfig
+	Compress   CompressConfig
+	User       UserConfig
 }
 
 // ServerConfig holds server configuration
 type ServerConfig struct {
-	Port string
+	Port               string
+	CORSAllowedOrigins []string // origins allowed to call the API with credentials
+	CORSAllowHeaders   []string // request headers allowed besides the API's and TUS's own
+	CORSExposeHeaders  []string // response headers exposed besides the API's and TUS's own
+
+	// Request body limits in bytes, applied per route group
+	AuthBodyLimit int64 // login, refresh and other auth requests
+	JSONBodyLimit int64 // JSON API requests
+	FormBodyLimit int64 // multipart forms carrying a profile picture
 }
 
 // DatabaseConfig holds database configuration
 type DatabaseConfig struct {
-	PostgresDSN string // PostgreSQL connection string
+	PostgresDSN        string // PostgreSQL connection string
+	MaintenanceEnabled bool   // allows Directors to run ANALYZE/VACUUM through the admin API
+
+	// Connection pool sizing; durations are in seconds
+	MaxConns          int64
+	MinConns          int64 // connections kept open even when idle
+	MaxConnLifetime   int64
+	MaxConnIdleTime   int64
+	HealthCheckPeriod int64
 }
 
 // AdminConfig holds admin user configuration for seeding
@@ -35,8 +64,10 @@ type AdminConfig struct {
 
 // LoggerConfig holds logger configuration
 type LoggerConfig struct {
-	Level  string
-	Pretty bool
+	Level         string
+	Pretty        bool
+	SensitiveKeys []string // body and query keys masked in request logs on top of the built-in ones
+	SampleRate    int      // log 1 in this many successful requests; failed requests are always logged
 }
 
 // JWTConfig holds JWT configuration
@@ -45,6 +76,86 @@ type JWTConfig struct {
 	RefreshTokenSecret string
 	AccessTokenExpiry  int64 // in seconds
 	RefreshTokenExpiry int64 // in seconds
+	// IncludeProfileClaims embeds username, email, phone, names, department and sector
+	// in tokens for clients that read them; by default tokens only carry what authorization needs
+	IncludeProfileClaims bool
+	// Every authenticated request loads the user (cached briefly) to reject tokens of deleted or
+	// disabled accounts, changed roles and revoked token versions
+	UserStateCacheTTL int64 // in seconds, 0 disables caching
+	// Email verification: new users receive a "verify" token; login can be blocked until it is used
+	VerifyTokenExpiry    int64 // in seconds
+	RequireVerifiedEmail bool
+
+	// Algorithm is HS256 (the secrets above) or RS256/ES256, which sign every token type with
+	// PrivateKeyFile so other services can verify tokens with the public key alone
+	Algorithm      string
+	PrivateKeyFile string // PEM private key for RS256/ES256
+	KeyID          string // kid header of newly signed tokens; empty omits it
+	// Key rotation: tokens signed with the previous key stay valid until PreviousKeyValidUntil
+	PreviousKeyID              string
+	PreviousAccessTokenSecret  string // HS256
+	PreviousRefreshTokenSecret string // HS256
+	PreviousPublicKeyFile      string // RS256/ES256
+	PreviousKeyValidUntil      string // RFC 3339; empty keeps the previous key until it is removed
+
+	// Impersonation lets directors act as another user for support with a short-lived, audited
+	// access token; each director may start at most ImpersonationMaxPerHour sessions an hour
+	ImpersonationEnabled    bool
+	ImpersonationExpiry     int64 // in seconds
+	ImpersonationMaxPerHour int64
+}
+
+// Supported JWT signing algorithms
+const (
+	JWTAlgorithmHS256 = "HS256"
+	JWTAlgorithmRS256 = "RS256"
+	JWTAlgorithmES256 = "ES256"
+)
+
+// PasswordConfig holds password hashing and strength settings
+type PasswordConfig struct {
+	BcryptCost   int  // higher is slower to hash and to brute-force
+	MinLength    int  // minimum number of characters
+	RequireMixed bool // requires upper-case and lower-case letters and a digit
+}
+
+// TOTPConfig holds two-factor authentication configuration
+type TOTPConfig struct {
+	EncryptionKey string // encrypts stored TOTP secrets; two-factor cannot be enabled without it
+	Issuer        string // shown next to the account in authenticator apps
+}
+
+// PresignConfig holds the expiry bounds of presigned MinIO URLs (in seconds)
+type PresignConfig struct {
+	DefaultExpiry int64 // used when the client does not ask for an expiry
+	MinExpiry     int64
+	MaxExpiry     int64 // MinIO rejects anything above 7 days
+}
+
+// PaginationConfig holds the page sizes of listings that don't set their own
+type PaginationConfig struct {
+	DefaultLimit int // used when the client sends no limit
+	MaxLimit     int // larger limits are capped to this
+}
+
+// CompressConfig holds gzip response compression settings
+type CompressConfig struct {
+	Enabled   bool
+	Level     int // gzip level, 1 (fastest) to 9 (smallest)
+	MinLength int // responses shorter than this many bytes are sent uncompressed
+}
+
+// UserConfig holds user account settings
+type UserConfig struct {
+	DefaultRole string // role of users created without one
+}
+
+// ShutdownConfig holds the timeout budget of each graceful shutdown step (in seconds)
+type ShutdownConfig struct {
+	HTTPTimeout    int64 // stop accepting requests and finish in-flight ones
+	DrainTimeout   int64 // wait for background work such as upload post-processing
+	StorageTimeout int64 // close MinIO idle connections
+	DBTimeout      int64 // close the PostgreSQL pool
 }
 
 // Load loads configuration from .env file and environment variables
@@ -54,10 +165,22 @@ func Load() *Config {
 
 	return &Config{
 		Server: ServerConfig{
-			Port: getEnv("PORT", "8080"),
+			Port:               getEnv("PORT", "8080"),
+			CORSAllowedOrigins: getEnvAsSlice("CORS_ALLOWED_ORIGINS", []string{"http://localhost:5173"}),
+			CORSAllowHeaders:   getEnvAsSlice("CORS_ALLOW_HEADERS", []string{"ngrok-skip-browser-warning"}),
+			CORSExposeHeaders:  getEnvAsSlice("CORS_EXPOSE_HEADERS", nil),
+			AuthBodyLimit:      getEnvAsInt64("BODY_LIMIT_AUTH", 64*1024),      // 64 KB
+			JSONBodyLimit:      getEnvAsInt64("BODY_LIMIT_JSON", 1024*1024),    // 1 MB
+			FormBodyLimit:      getEnvAsInt64("BODY_LIMIT_FORM", 10*1024*1024), // 10 MB (5 MB image plus fields)
 		},
 		Database: DatabaseConfig{
-			PostgresDSN: getEnv("POSTGRES_DSN", ""),
+			PostgresDSN:        getEnv("POSTGRES_DSN", ""),
+			MaintenanceEnabled: getEnv("DB_MAINTENANCE_ENABLED", "false") == "true",
+			MaxConns:           getEnvAsInt64("DB_MAX_CONNS", 25),
+			MinConns:           getEnvAsInt64("DB_MIN_CONNS", 5),
+			MaxConnLifetime:    getEnvAsInt64("DB_MAX_CONN_LIFETIME", 3600),  // 1 hour
+			MaxConnIdleTime:    getEnvAsInt64("DB_MAX_CONN_IDLE_TIME", 1800), // 30 minutes
+			HealthCheckPeriod:  getEnvAsInt64("DB_HEALTH_CHECK_PERIOD", 60),
 		},
 		Admin: AdminConfig{
 			Username: getEnv("ADMIN_USERNAME", ""),
@@ -65,14 +188,66 @@ func Load() *Config {
 			Password: getEnv("ADMIN_PASSWORD", ""),
 		},
 		Logger: LoggerConfig{
-			Level:  getEnv("LOG_LEVEL", "info"),
-			Pretty: getEnv("LOG_PRETTY", "true") == "true",
+			Level:         getEnv("LOG_LEVEL", "info"),
+			Pretty:        getEnv("LOG_PRETTY", "true") == "true",
+			SensitiveKeys: getEnvAsSlice("LOG_SENSITIVE_KEYS", nil),
+			SampleRate:    int(getEnvAsInt64("LOG_REQUEST_SAMPLE_RATE", 1)),
 		},
 		JWT: JWTConfig{
 			AccessTokenSecret:  getEnv("JWT_ACCESS_SECRET", ""),
 			RefreshTokenSecret: getEnv("JWT_REFRESH_SECRET", ""),
 			AccessTokenExpiry:  getEnvAsInt64("JWT_ACCESS_EXPIRY", 3600),    // 1 hour
 			RefreshTokenExpiry: getEnvAsInt64("JWT_REFRESH_EXPIRY", 604800), // 7 days
+
+			IncludeProfileClaims: getEnv("JWT_INCLUDE_PROFILE_CLAIMS", "false") == "true",
+			UserStateCacheTTL:    getEnvAsInt64("JWT_USER_STATE_CACHE_TTL", 30),
+			VerifyTokenExpiry:    getEnvAsInt64("JWT_VERIFY_EXPIRY", 259200), // 3 days
+			RequireVerifiedEmail: getEnv("AUTH_REQUIRE_VERIFIED_EMAIL", "false") == "true",
+
+			Algorithm:                  strings.ToUpper(getEnv("JWT_ALGORITHM", JWTAlgorithmHS256)),
+			PrivateKeyFile:             getEnv("JWT_PRIVATE_KEY_FILE", ""),
+			KeyID:                      getEnv("JWT_KEY_ID", ""),
+			PreviousKeyID:              getEnv("JWT_PREVIOUS_KEY_ID", ""),
+			PreviousAccessTokenSecret:  getEnv("JWT_PREVIOUS_ACCESS_SECRET", ""),
+			PreviousRefreshTokenSecret: getEnv("JWT_PREVIOUS_REFRESH_SECRET", ""),
+			PreviousPublicKeyFile:      getEnv("JWT_PREVIOUS_PUBLIC_KEY_FILE", ""),
+			PreviousKeyValidUntil:      getEnv("JWT_PREVIOUS_KEY_VALID_UNTIL", ""),
+
+			ImpersonationEnabled:    getEnv("JWT_IMPERSONATION_ENABLED", "false") == "true",
+			ImpersonationExpiry:     getEnvAsInt64("JWT_IMPERSONATION_EXPIRY", 900), // 15 minutes
+			ImpersonationMaxPerHour: getEnvAsInt64("JWT_IMPERSONATION_MAX_PER_HOUR", 5),
+		},
+		Shutdown: ShutdownConfig{
+			HTTPTimeout:    getEnvAsInt64("SHUTDOWN_HTTP_TIMEOUT", 10),
+			DrainTimeout:   getEnvAsInt64("SHUTDOWN_DRAIN_TIMEOUT", 30),
+			StorageTimeout: getEnvAsInt64("SHUTDOWN_STORAGE_TIMEOUT", 5),
+			DBTimeout:      getEnvAsInt64("SHUTDOWN_DB_TIMEOUT", 5),
+		},
+		Password: PasswordConfig{
+			BcryptCost:   int(getEnvAsInt64("BCRYPT_COST", int64(bcrypt.DefaultCost))),
+			MinLength:    int(getEnvAsInt64("PASSWORD_MIN_LENGTH", 6)),
+			RequireMixed: getEnv("PASSWORD_REQUIRE_MIXED", "false") == "true",
+		},
+		TOTP: TOTPConfig{
+			EncryptionKey: getEnv("TOTP_ENCRYPTION_KEY", ""),
+			Issuer:        getEnv("TOTP_ISSUER", "E-Document"),
+		},
+		Pagination: PaginationConfig{
+			DefaultLimit: int(getEnvAsInt64("PAGINATION_DEFAULT_LIMIT", 20)),
+			MaxLimit:     int(getEnvAsInt64("PAGINATION_MAX_LIMIT", 100)),
+		},
+		Presign: PresignConfig{
+			DefaultExpiry: getEnvAsInt64("PRESIGN_DEFAULT_EXPIRY", 3600), // 1 hour
+			MinExpiry:     getEnvAsInt64("PRESIGN_MIN_EXPIRY", 60),
+			MaxExpiry:     getEnvAsInt64("PRESIGN_MAX_EXPIRY", 86400), // 1 day
+		},
+		Compress: CompressConfig{
+			Enabled:   getEnv("COMPRESS_ENABLED", "true") == "true",
+			Level:     int(getEnvAsInt64("COMPRESS_LEVEL", 5)),
+			MinLength: int(getEnvAsInt64("COMPRESS_MIN_LENGTH", 1024)), // 1 KB
+		},
+		User: UserConfig{
+			DefaultRole: getEnv("USER_DEFAULT_ROLE", "Employee"),
 		},
 	}
 }
@@ -86,6 +261,174 @@ func getEnv(key, defaultValue string) string {
 	return value
 }
 
+// getEnvAsSlice gets a comma-separated environment variable as a slice or returns a default value
+// Surrounding whitespace and empty entries are dropped
+func getEnvAsSlice(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	if len(items) == 0 {
+		return defaultValue
+	}
+	return items
+}
+
+// ValidateCORS checks the allowed origins can be used with credentialed requests
+// Browsers reject a wildcard origin when credentials are allowed
+func (c ServerConfig) ValidateCORS() error {
+	for _, origin := range c.CORSAllowedOrigins {
+		if origin == "*" {
+			return fmt.Errorf("CORS_ALLOWED_ORIGINS must list explicit origins: \"*\" cannot be combined with credentials")
+		}
+	}
+	return nil
+}
+
+// ValidateBodyLimits checks every request body limit is positive
+func (c ServerConfig) ValidateBodyLimits() error {
+	if c.AuthBodyLimit < 1 || c.JSONBodyLimit < 1 || c.FormBodyLimit < 1 {
+		return fmt.Errorf("BODY_LIMIT_AUTH, BODY_LIMIT_JSON and BODY_LIMIT_FORM must be at least 1 byte")
+	}
+	return nil
+}
+
+// Validate checks the signing algorithm has the keys it needs
+func (c JWTConfig) Validate() error {
+	switch c.Algorithm {
+	case JWTAlgorithmHS256:
+		if c.AccessTokenSecret == "" || c.RefreshTokenSecret == "" {
+			return fmt.Errorf("JWT_ACCESS_SECRET and JWT_REFRESH_SECRET are required with HS256")
+		}
+		if (c.PreviousAccessTokenSecret == "") != (c.PreviousRefreshTokenSecret == "") {
+			return fmt.Errorf("JWT_PREVIOUS_ACCESS_SECRET and JWT_PREVIOUS_REFRESH_SECRET must be set together")
+		}
+	case JWTAlgorithmRS256, JWTAlgorithmES256:
+		if c.PrivateKeyFile == "" {
+			return fmt.Errorf("JWT_PRIVATE_KEY_FILE is required with %s", c.Algorithm)
+		}
+	default:
+		return fmt.Errorf("JWT_ALGORITHM must be HS256, RS256 or ES256")
+	}
+
+	if c.KeyID != "" && c.KeyID == c.PreviousKeyID {
+		return fmt.Errorf("JWT_PREVIOUS_KEY_ID must differ from JWT_KEY_ID")
+	}
+	if c.PreviousKeyValidUntil != "" {
+		if _, err := time.Parse(time.RFC3339, c.PreviousKeyValidUntil); err != nil {
+			return fmt.Errorf("JWT_PREVIOUS_KEY_VALID_UNTIL must be an RFC 3339 timestamp: %w", err)
+		}
+	}
+	if c.ImpersonationEnabled {
+		if c.ImpersonationExpiry < 60 || c.ImpersonationExpiry > c.AccessTokenExpiry {
+			return fmt.Errorf("JWT_IMPERSONATION_EXPIRY must be between 60 seconds and JWT_ACCESS_EXPIRY")
+		}
+		if c.ImpersonationMaxPerHour < 1 {
+			return fmt.Errorf("JWT_IMPERSONATION_MAX_PER_HOUR must be at least 1")
+		}
+	}
+	return nil
+}
+
+// Validate checks the connection pool sizes are ordered and the durations usable
+func (c DatabaseConfig) Validate() error {
+	if c.MaxConns < 1 || c.MaxConns > math.MaxInt32 {
+		return fmt.Errorf("DB_MAX_CONNS must be between 1 and %d", math.MaxInt32)
+	}
+	if c.MinConns < 0 || c.MinConns > c.MaxConns {
+		return fmt.Errorf("DB_MIN_CONNS must be between 0 and DB_MAX_CONNS")
+	}
+	if c.MaxConnLifetime < 1 || c.MaxConnIdleTime < 1 || c.HealthCheckPeriod < 1 {
+		return fmt.Errorf("DB_MAX_CONN_LIFETIME, DB_MAX_CONN_IDLE_TIME and DB_HEALTH_CHECK_PERIOD must be at least 1 second")
+	}
+	return nil
+}
+
+// Validate checks the bcrypt cost and minimum length are usable
+func (c PasswordConfig) Validate() error {
+	if c.BcryptCost < bcrypt.MinCost || c.BcryptCost > bcrypt.MaxCost {
+		return fmt.Errorf("BCRYPT_COST must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+	if c.MinLength < 1 {
+		return fmt.Errorf("PASSWORD_MIN_LENGTH must be at least 1")
+	}
+	return nil
+}
+
+// Validate checks the default role is a known role
+func (c UserConfig) Validate() error {
+	if !domain.UserRole(c.DefaultRole).IsValid() {
+		return fmt.Errorf("USER_DEFAULT_ROLE must be Director, DepartmentManager, SectorManager or Employee")
+	}
+	return nil
+}
+
+// Validate checks the request log sample rate is usable
+func (c LoggerConfig) Validate() error {
+	if c.SampleRate < 1 {
+		return fmt.Errorf("LOG_REQUEST_SAMPLE_RATE must be at least 1")
+	}
+	return nil
+}
+
+// Validate checks the default page size is positive and within the cap
+func (c PaginationConfig) Validate() error {
+	if c.DefaultLimit < 1 || c.DefaultLimit > c.MaxLimit {
+		return fmt.Errorf("PAGINATION_DEFAULT_LIMIT must be between 1 and PAGINATION_MAX_LIMIT")
+	}
+	return nil
+}
+
+// Validate checks the gzip level and minimum length are usable
+func (c CompressConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Level < 1 || c.Level > 9 {
+		return fmt.Errorf("COMPRESS_LEVEL must be between 1 and 9")
+	}
+	if c.MinLength < 0 {
+		return fmt.Errorf("COMPRESS_MIN_LENGTH must not be negative")
+	}
+	return nil
+}
+
+// maxPresignExpiry is the longest expiry MinIO accepts for a presigned URL (7 days)
+const maxPresignExpiry = 604800
+
+// Validate checks the presign bounds are ordered and within what MinIO accepts
+func (c PresignConfig) Validate() error {
+	if c.MinExpiry < 1 {
+		return fmt.Errorf("PRESIGN_MIN_EXPIRY must be at least 1")
+	}
+	if c.MaxExpiry < c.MinExpiry || c.MaxExpiry > maxPresignExpiry {
+		return fmt.Errorf("PRESIGN_MAX_EXPIRY must be between PRESIGN_MIN_EXPIRY and %d", maxPresignExpiry)
+	}
+	if c.DefaultExpiry < c.MinExpiry || c.DefaultExpiry > c.MaxExpiry {
+		return fmt.Errorf("PRESIGN_DEFAULT_EXPIRY must be between PRESIGN_MIN_EXPIRY and PRESIGN_MAX_EXPIRY")
+	}
+	return nil
+}
+
+// ResolveExpiry returns the expiry to presign with: the default when seconds is 0,
+// otherwise seconds itself if it lies within the configured bounds
+func (c PresignConfig) ResolveExpiry(seconds int64) (int64, error) {
+	if seconds == 0 {
+		return c.DefaultExpiry, nil
+	}
+	if seconds < c.MinExpiry || seconds > c.MaxExpiry {
+		return 0, fmt.Errorf("must be between %d and %d seconds", c.MinExpiry, c.MaxExpiry)
+	}
+	return seconds, nil
+}
+
 // getEnvAsInt64 gets an environment variable as int64 or returns a default value
 func getEnvAsInt64(key string, defaultValue int64) int64 {
 	value := os.Getenv(key)
@@ -0,0 +1,27 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DocumentRoute records a document being sent from one department to another
+type DocumentRoute struct {
+	ID               uuid.UUID      `json:"id" db:"id"`
+	DocumentID       uuid.UUID      `json:"document_id" db:"document_id"`
+	FromDepartmentID *string        `json:"from_department_id,omitempty" db:"from_department_id"`
+	ToDepartmentID   string         `json:"to_department_id" db:"to_department_id"`
+	FromStatus       DocumentStatus `json:"from_status" db:"from_status"`
+	ToStatus         DocumentStatus `json:"to_status" db:"to_status"`
+	RoutedBy         uuid.UUID      `json:"routed_by" db:"routed_by"`
+	Note             string         `json:"note,omitempty" db:"note"`
+	CreatedAt        time.Time      `json:"created_at" db:"created_at"`
+}
+
+// RouteDocumentRequest represents the request body for sending a document to another department
+type RouteDocumentRequest struct {
+	DepartmentID string `json:"department_id" validate:"required,max=255"`
+	SetPending   bool   `json:"set_pending"` // also move the document to Pending
+	Note         string `json:"note,omitempty" validate:"max=1000"`
+}
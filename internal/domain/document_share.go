@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SharePermission represents what a user may do with a document shared with them
+type SharePermission string
+
+const (
+	SharePermissionRead SharePermission = "read"
+)
+
+// IsValid checks if the share permission is valid
+func (sp SharePermission) IsValid() bool {
+	switch sp {
+	case SharePermissionRead:
+		return true
+	}
+	return false
+}
+
+// DocumentShare grants another user access to a document
+type DocumentShare struct {
+	ID               uuid.UUID       `json:"id" db:"id"`
+	DocumentID       uuid.UUID       `json:"document_id" db:"document_id"`
+	SharedWithUserID uuid.UUID       `json:"shared_with_user_id" db:"shared_with_user_id"`
+	SharedBy         uuid.UUID       `json:"shared_by" db:"shared_by"`
+	Permission       SharePermission `json:"permission" db:"permission"`
+	CreatedAt        time.Time       `json:"created_at" db:"created_at"`
+}
+
+// ShareDocumentRequest represents the request body for sharing a document with a user
+type ShareDocumentRequest struct {
+	UserID     uuid.UUID       `json:"user_id" validate:"required"`
+	Permission SharePermission `json:"permission,omitempty"` // defaults to read
+}
@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ExportJobStatus is the state of a background folder export
+type ExportJobStatus string
+
+const (
+	ExportJobPending   ExportJobStatus = "pending"
+	ExportJobRunning   ExportJobStatus = "running"
+	ExportJobCompleted ExportJobStatus = "completed"
+	ExportJobFailed    ExportJobStatus = "failed"
+	ExportJobExpired   ExportJobStatus = "expired" // the archive was removed after its retention period
+)
+
+// ExportJob is a folder ZIP archive built in the background into MinIO
+type ExportJob struct {
+	ID             uuid.UUID       `json:"id" db:"id"`
+	OwnerID        uuid.UUID       `json:"owner_id" db:"owner_id"`
+	FolderID       uuid.UUID       `json:"folder_id" db:"folder_id"`
+	FileName       string          `json:"file_name" db:"file_name"`
+	Status         ExportJobStatus `json:"status" db:"status"`
+	TotalFiles     int             `json:"total_files" db:"total_files"`
+	ProcessedFiles int             `json:"processed_files" db:"processed_files"`
+	TotalBytes     int64           `json:"total_bytes" db:"total_bytes"`
+	ObjectKey      *string         `json:"-" db:"object_key"`
+	Error          *string         `json:"error,omitempty" db:"error"`
+	CreatedAt      time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at" db:"updated_at"`
+	CompletedAt    *time.Time      `json:"completed_at,omitempty" db:"completed_at"`
+	ExpiresAt      *time.Time      `json:"expires_at,omitempty" db:"expires_at"`
+}
+
+// ExportJobResponse is an export job as reported to its owner, with the download link once it is done
+type ExportJobResponse struct {
+	*ExportJob
+	DownloadURL string `json:"download_url,omitempty"`
+}
@@ -42,6 +42,54 @@ func (ds DocumentStatus) IsValid() bool {
 	return false
 }
 
+// FolderColor represents a color from the allowed folder palette
+type FolderColor string
+
+const (
+	FolderColorGray   FolderColor = "gray"
+	FolderColorRed    FolderColor = "red"
+	FolderColorOrange FolderColor = "orange"
+	FolderColorYellow FolderColor = "yellow"
+	FolderColorGreen  FolderColor = "green"
+	FolderColorBlue   FolderColor = "blue"
+	FolderColorPurple FolderColor = "purple"
+	FolderColorPink   FolderColor = "pink"
+)
+
+// IsValid checks if the folder color is part of the palette
+func (fc FolderColor) IsValid() bool {
+	switch fc {
+	case FolderColorGray, FolderColorRed, FolderColorOrange, FolderColorYellow,
+		FolderColorGreen, FolderColorBlue, FolderColorPurple, FolderColorPink:
+		return true
+	}
+	return false
+}
+
+// FolderIcon represents an icon from the allowed folder icon set
+type FolderIcon string
+
+const (
+	FolderIconFolder    FolderIcon = "folder"
+	FolderIconStar      FolderIcon = "star"
+	FolderIconArchive   FolderIcon = "archive"
+	FolderIconBriefcase FolderIcon = "briefcase"
+	FolderIconDocument  FolderIcon = "document"
+	FolderIconImage     FolderIcon = "image"
+	FolderIconLock      FolderIcon = "lock"
+	FolderIconUsers     FolderIcon = "users"
+)
+
+// IsValid checks if the folder icon is part of the icon set
+func (fi FolderIcon) IsValid() bool {
+	switch fi {
+	case FolderIconFolder, FolderIconStar, FolderIconArchive, FolderIconBriefcase,
+		FolderIconDocument, FolderIconImage, FolderIconLock, FolderIconUsers:
+		return true
+	}
+	return false
+}
+
 // Folder represents a folder in the hierarchical structure
 type Folder struct {
 	ID             uuid.UUID  `json:"id" db:"id"`
@@ -50,6 +98,8 @@ type Folder struct {
 	IsRootFolder   bool       `json:"is_root_folder" db:"is_root_folder"`
 	ParentFolderID *uuid.UUID `json:"parent_folder_id,omitempty" db:"parent_folder_id"`
 	OwnerID        uuid.UUID  `json:"owner_id" db:"owner_id"`
+	Color          *string    `json:"color,omitempty" db:"color"`
+	Icon           *string    `json:"icon,omitempty" db:"icon"`
 	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
 }
@@ -64,7 +114,7 @@ type Document struct {
 	FolderID            *uuid.UUID     `json:"folder_id,omitempty" db:"folder_id"`
 	Barcode             *string        `json:"barcode,omitempty" db:"barcode"`
 	RegistrantID        *uuid.UUID     `json:"registrant_id,omitempty" db:"registrant_id"`
-	CurrentDepartmentID *uuid.UUID     `json:"current_department_id,omitempty" db:"current_department_id"`
+	CurrentDepartmentID *string        `json:"current_department_id,omitempty" db:"current_department_id"`
 	Status              DocumentStatus `json:"status" db:"status"`
 	CreatedAt           time.Time      `json:"created_at" db:"created_at"`
 	UpdatedAt           time.Time      `json:"updated_at" db:"updated_at"`
@@ -82,6 +132,9 @@ type DocumentAttachment struct {
 	IsCurrent  bool       `json:"is_current" db:"is_current"`
 	UploadedBy *uuid.UUID `json:"uploaded_by,omitempty" db:"uploaded_by"`
 	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+
+	ContentHash *string `json:"-" db:"content_hash"` // SHA-256 of the stored object, shared by deduplicated uploads
+	UploadID    *string `json:"-" db:"upload_id"`    // tusd upload that created the attachment, unique
 }
 
 // FolderResponse represents the folder response
@@ -92,10 +145,59 @@ type FolderResponse struct {
 	IsRootFolder   bool       `json:"is_root_folder"`
 	ParentFolderID *uuid.UUID `json:"parent_folder_id,omitempty"`
 	OwnerID        uuid.UUID  `json:"owner_id"`
+	Color          *string    `json:"color,omitempty"`
+	Icon           *string    `json:"icon,omitempty"`
 	CreatedAt      time.Time  `json:"created_at"`
 	UpdatedAt      time.Time  `json:"updated_at"`
 }
 
+// CreateFolderRequest represents the request body for creating a folder
+type CreateFolderRequest struct {
+	Name           string     `json:"name" validate:"required,max=255"`
+	ParentFolderID *uuid.UUID `json:"parent_folder_id,omitempty"`
+	Color          *string    `json:"color,omitempty"`
+	Icon           *string    `json:"icon,omitempty"`
+}
+
+// EnsureFolderPathRequest represents the request body for creating a nested folder path
+// Path segments are separated by / or \; existing folders along the path are reused
+type EnsureFolderPathRequest struct {
+	Path           string     `json:"path" validate:"required,max=4096"`
+	ParentFolderID *uuid.UUID `json:"parent_folder_id,omitempty"`
+}
+
+// EnsureFolderPathResult represents the folder chain a nested folder path resolved to
+type EnsureFolderPathResult struct {
+	FolderID uuid.UUID `json:"folder_id"` // the last folder of the path
+	Folders  []*Folder `json:"folders"`   // every folder of the path, outermost first
+	Created  int       `json:"created"`   // how many of them did not exist before
+}
+
+// UpdateFolderRequest represents the request body for updating folder appearance
+// A nil field is left unchanged; an empty string resets it to the default
+type UpdateFolderRequest struct {
+	Color *string `json:"color,omitempty"`
+	Icon  *string `json:"icon,omitempty"`
+}
+
+// UpdateDocumentRequest represents the request body for editing a document's details
+// A nil field is left unchanged; the title cannot be blank
+type UpdateDocumentRequest struct {
+	Title       *string `json:"title,omitempty" validate:"omitempty,max=255"`
+	Description *string `json:"description,omitempty"`
+}
+
+// CopyDocumentRequest represents the request body for duplicating a document into a folder
+type CopyDocumentRequest struct {
+	FolderID uuid.UUID `json:"folder_id" validate:"required"`
+}
+
+// AddDocumentTagsRequest represents the request body for tagging a document
+// Tags that do not exist yet are created; names are matched without regard to case
+type AddDocumentTagsRequest struct {
+	Tags []string `json:"tags" validate:"required,min=1,max=20,dive,required,max=50"`
+}
+
 // DocumentResponse represents the document response
 type DocumentResponse struct {
 	ID                  uuid.UUID      `json:"id"`
@@ -106,7 +208,7 @@ type DocumentResponse struct {
 	FolderID            *uuid.UUID     `json:"folder_id,omitempty"`
 	Barcode             *string        `json:"barcode,omitempty"`
 	RegistrantID        *uuid.UUID     `json:"registrant_id,omitempty"`
-	CurrentDepartmentID *uuid.UUID     `json:"current_department_id,omitempty"`
+	CurrentDepartmentID *string        `json:"current_department_id,omitempty"`
 	Status              DocumentStatus `json:"status"`
 	CreatedAt           time.Time      `json:"created_at"`
 	UpdatedAt           time.Time      `json:"updated_at"`
@@ -135,6 +237,8 @@ func (f *Folder) ToResponse() FolderResponse {
 		IsRootFolder:   f.IsRootFolder,
 		ParentFolderID: f.ParentFolderID,
 		OwnerID:        f.OwnerID,
+		Color:          f.Color,
+		Icon:           f.Icon,
 		CreatedAt:      f.CreatedAt,
 		UpdatedAt:      f.UpdatedAt,
 	}
@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Sector represents a sector of the organization, grouping several departments
+// Sector managers oversee a sector; department managers oversee one of its departments
+type Sector struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Department represents a department belonging to a sector
+// The ID is the department_id stored on users
+type Department struct {
+	ID        string    `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	SectorID  uuid.UUID `json:"sector_id" db:"sector_id"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// CreateSectorRequest represents the request body for creating a sector
+type CreateSectorRequest struct {
+	Name string `json:"name" validate:"required,max=255"`
+}
+
+// CreateDepartmentRequest represents the request body for adding a department to a sector
+type CreateDepartmentRequest struct {
+	ID   string `json:"id" validate:"required,max=255"`
+	Name string `json:"name" validate:"required,max=255"`
+}
@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ShareLink represents a read-only public link to a document
+type ShareLink struct {
+	ID             uuid.UUID  `json:"id" db:"id"`
+	Token          string     `json:"token" db:"token"`
+	DocumentID     uuid.UUID  `json:"document_id" db:"document_id"`
+	CreatedBy      uuid.UUID  `json:"created_by" db:"created_by"`
+	ExpiresAt      time.Time  `json:"expires_at" db:"expires_at"`
+	ReminderSentAt *time.Time `json:"reminder_sent_at,omitempty" db:"reminder_sent_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+}
+
+// CreateShareLinkRequest represents the request body for creating a share link
+type CreateShareLinkRequest struct {
+	ExpiresIn int64 `json:"expires_in" validate:"omitempty,gte=60,lte=2592000"` // seconds, optional (default 7 days, at most 30 days)
+}
+
+// shareLinkTokenPrefixLength is how much of a token listings show to tell links apart
+const shareLinkTokenPrefixLength = 8
+
+// ShareLinkResponse represents the share link response
+type ShareLinkResponse struct {
+	ID         uuid.UUID `json:"id"`
+	Token      string    `json:"token"`
+	DocumentID uuid.UUID `json:"document_id"`
+	CreatedBy  uuid.UUID `json:"created_by"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ShareLinkSummary represents a share link in listings; the token is a bearer credential,
+// so only its prefix is shown
+type ShareLinkSummary struct {
+	ID          uuid.UUID `json:"id"`
+	TokenPrefix string    `json:"token_prefix"`
+	DocumentID  uuid.UUID `json:"document_id"`
+	CreatedBy   uuid.UUID `json:"created_by"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// IsExpired checks if the share link is past its expiry
+func (l *ShareLink) IsExpired() bool {
+	return time.Now().After(l.ExpiresAt)
+}
+
+// ToResponse converts ShareLink to ShareLinkResponse
+func (l *ShareLink) ToResponse() ShareLinkResponse {
+	return ShareLinkResponse{
+		ID:         l.ID,
+		Token:      l.Token,
+		DocumentID: l.DocumentID,
+		CreatedBy:  l.CreatedBy,
+		ExpiresAt:  l.ExpiresAt,
+		CreatedAt:  l.CreatedAt,
+	}
+}
+
+// ToSummary converts ShareLink to ShareLinkSummary
+func (l *ShareLink) ToSummary() ShareLinkSummary {
+	prefix := l.Token
+	if len(prefix) > shareLinkTokenPrefixLength {
+		prefix = prefix[:shareLinkTokenPrefixLength]
+	}
+	return ShareLinkSummary{
+		ID:          l.ID,
+		TokenPrefix: prefix,
+		DocumentID:  l.DocumentID,
+		CreatedBy:   l.CreatedBy,
+		ExpiresAt:   l.ExpiresAt,
+		CreatedAt:   l.CreatedAt,
+	}
+}
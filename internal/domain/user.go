@@ -32,6 +32,27 @@ func (r UserRole) String() string {
 	return string(r)
 }
 
+// Rank orders roles by authority; unknown roles rank lowest
+func (r UserRole) Rank() int {
+	switch r {
+	case RoleDirector:
+		return 4
+	case RoleDepartmentManager:
+		return 3
+	case RoleSectorManager:
+		return 2
+	case RoleEmployee:
+		return 1
+	}
+	return 0
+}
+
+// CanAssign reports whether a user with this role may give role to someone
+// Directors may assign any role; everyone else only roles below their own
+func (r UserRole) CanAssign(role UserRole) bool {
+	return r == RoleDirector || r.Rank() > role.Rank()
+}
+
 // ValidateRole validates if a string is a valid role
 func ValidateRole(role string) (UserRole, error) {
 	r := UserRole(role)
@@ -54,6 +75,10 @@ type User struct {
 	DepartmentID   string    `json:"department_id" db:"department_id"`
 	SectorID       string    `json:"sector_id" db:"sector_id"`
 	ProfilePicture string    `json:"profile_picture,omitempty" db:"profile_picture"`
+	TokenVersion   int       `json:"-" db:"token_version"` // Incremented to invalidate issued tokens
+	EmailVerified  bool      `json:"email_verified" db:"email_verified"`
+	TOTPEnabled    bool      `json:"totp_enabled" db:"totp_enabled"`
+	Disabled       bool      `json:"disabled" db:"disabled"` // Disabled users cannot log in or use their tokens
 	CreatedAt      time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
 }
@@ -62,9 +87,9 @@ type User struct {
 type CreateUserRequest struct {
 	Username     string   `json:"username" validate:"required"`
 	Email        string   `json:"email" validate:"required,email"`
-	Password     string   `json:"password" validate:"required,min=6"`
-	Role         UserRole `json:"role" validate:"required,oneof=Director DepartmentManager SectorManager Employee"`
-	Phone        string   `json:"phone"`
+	Password     string   `json:"password" validate:"required,password"`
+	Role         UserRole `json:"role" validate:"omitempty,oneof=Director DepartmentManager SectorManager Employee"` // empty uses the configured default role
+	Phone        string   `json:"phone" validate:"required,e164"`
 	FirstName    string   `json:"first_name"`
 	LastName     string   `json:"last_name"`
 	DepartmentID string   `json:"department_id"`
@@ -76,12 +101,24 @@ type UpdateUserRequest struct {
 	Username     string   `json:"username,omitempty"`
 	Email        string   `json:"email,omitempty"`
 	Role         UserRole `json:"role,omitempty" validate:"omitempty,oneof=Director DepartmentManager SectorManager Employee"`
-	Phone        string   `json:"phone,omitempty"`
+	Phone        string   `json:"phone,omitempty" validate:"omitempty,e164"`
 	FirstName    string   `json:"first_name,omitempty"`
 	LastName     string   `json:"last_name,omitempty"`
 	DepartmentID string   `json:"department_id,omitempty"`
 	SectorID     string   `json:"sector_id,omitempty"`
-	Password     string   `json:"password,omitempty" validate:"omitempty,min=6"`
+	Password     string   `json:"password,omitempty" validate:"omitempty,password"`
+}
+
+// UpdateProfileRequest represents the request body for a user updating their own profile
+// Role, department and sector can only be changed by an administrator
+type UpdateProfileRequest struct {
+	Role            UserRole `json:"role,omitempty"` // accepted only so a role change is refused instead of silently ignored
+	Email           string   `json:"email,omitempty" validate:"omitempty,email"`
+	Phone           string   `json:"phone,omitempty" validate:"omitempty,e164"`
+	FirstName       string   `json:"first_name,omitempty"`
+	LastName        string   `json:"last_name,omitempty"`
+	Password        string   `json:"password,omitempty" validate:"omitempty,password"`
+	CurrentPassword string   `json:"current_password,omitempty" validate:"required_with=Password"`
 }
 
 // UserResponse represents the user response (without password)
@@ -96,6 +133,9 @@ type UserResponse struct {
 	ProfilePicture string    `json:"profile_picture,omitempty"`
 	DepartmentID   string    `json:"department_id"`
 	SectorID       string    `json:"sector_id"`
+	EmailVerified  bool      `json:"email_verified"`
+	TOTPEnabled    bool      `json:"totp_enabled"`
+	Disabled       bool      `json:"disabled"`
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
 }
@@ -113,17 +153,55 @@ func (u *User) ToResponse() UserResponse {
 		ProfilePicture: u.ProfilePicture,
 		DepartmentID:   u.DepartmentID,
 		SectorID:       u.SectorID,
+		EmailVerified:  u.EmailVerified,
+		TOTPEnabled:    u.TOTPEnabled,
+		Disabled:       u.Disabled,
 		CreatedAt:      u.CreatedAt,
 		UpdatedAt:      u.UpdatedAt,
 	}
 }
 
+// UserSummary represents a minimal user view for listings (e.g. department rosters)
+type UserSummary struct {
+	ID             uuid.UUID `json:"id"`
+	Username       string    `json:"username"`
+	FirstName      string    `json:"first_name"`
+	LastName       string    `json:"last_name"`
+	Role           UserRole  `json:"role"`
+	ProfilePicture string    `json:"profile_picture,omitempty"`
+}
+
+// UserRef is the smallest user view, embedded in other resources to name a user without a lookup
+type UserRef struct {
+	ID        uuid.UUID `json:"id"`
+	FirstName string    `json:"first_name"`
+	LastName  string    `json:"last_name"`
+}
+
+// ToSummary converts User to UserSummary
+func (u *User) ToSummary() UserSummary {
+	return UserSummary{
+		ID:             u.ID,
+		Username:       u.Username,
+		FirstName:      u.FirstName,
+		LastName:       u.LastName,
+		Role:           u.Role,
+		ProfilePicture: u.ProfilePicture,
+	}
+}
+
 // Auth-related structs
 
 // LoginRequest represents the request body for user login
 type LoginRequest struct {
 	UsernameOrEmail string `json:"usernameOrEmail" validate:"required"`
 	Password        string `json:"password" validate:"required"`
+	Code            string `json:"code,omitempty"` // TOTP or backup code, required when two-factor is enabled
+}
+
+// ResendVerificationRequest represents the request body for resending an email verification token
+type ResendVerificationRequest struct {
+	Email string `json:"email" validate:"required,email"`
 }
 
 // RefreshTokenRequest represents the request body for refreshing token
@@ -136,9 +214,38 @@ type AuthResponse struct {
 	User         UserResponse `json:"user"`
 	AccessToken  string       `json:"accessToken,omitempty"`  // For mobile apps
 	RefreshToken string       `json:"refreshToken,omitempty"` // For mobile apps
+	// Token lifetimes in seconds so clients can schedule a refresh before the access token expires
+	ExpiresIn        int64 `json:"expires_in"`
+	RefreshExpiresIn int64 `json:"refresh_expires_in"`
+}
+
+// UserTOTP holds a user's two-factor authentication state
+type UserTOTP struct {
+	Secret      string   // encrypted TOTP secret, empty if two-factor was never set up
+	Enabled     bool     // set once a code generated from the secret has been confirmed
+	BackupCodes []string // bcrypt hashes of the unused backup codes
+	LastCounter int64    // time step of the last accepted code, -1 if none was accepted yet
+}
+
+// EnableTwoFactorResponse contains the secret to add to an authenticator app
+type EnableTwoFactorResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauth_url"`
+}
+
+// VerifyTwoFactorRequest represents the request body for confirming two-factor setup
+type VerifyTwoFactorRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// VerifyTwoFactorResponse contains the one-time backup codes, shown only once
+type VerifyTwoFactorResponse struct {
+	BackupCodes []string `json:"backup_codes"`
 }
 
 // TokenClaims represents JWT token claims
+// Profile fields (username, email, names, department, sector) are only present
+// when profile claims are enabled; lean tokens carry just user_id, role, version and type
 type TokenClaims struct {
 	UserID       string `json:"user_id"`
 	Username     string `json:"username"`
@@ -149,5 +256,37 @@ type TokenClaims struct {
 	Role         string `json:"role"`
 	DepartmentID string `json:"department_id"`
 	SectorID     string `json:"sector_id"`
+	TokenVersion int    `json:"ver"`
 	Type         string `json:"type"` // "access" or "refresh"
+
+	// Set only on tokens a director obtained by impersonating this user
+	ImpersonatedBy  string `json:"impersonated_by,omitempty"` // the director's user ID
+	ImpersonationID string `json:"imp_sid,omitempty"`         // the audited impersonation session
+}
+
+// ImpersonateRequest represents the request body for starting an impersonation
+type ImpersonateRequest struct {
+	Reason string `json:"reason" validate:"required,max=500"` // why support needs the user's view; kept in the audit trail
+}
+
+// ImpersonationSession is the audit record of a director acting as another user
+type ImpersonationSession struct {
+	ID           uuid.UUID  `json:"id"`
+	DirectorID   uuid.UUID  `json:"director_id"`
+	TargetUserID uuid.UUID  `json:"target_user_id"`
+	Reason       string     `json:"reason"`
+	IPAddress    string     `json:"ip_address"`
+	UserAgent    string     `json:"user_agent"`
+	StartedAt    time.Time  `json:"started_at"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	EndedAt      *time.Time `json:"ended_at,omitempty"`
+}
+
+// ImpersonationResponse carries the short-lived access token acting as the impersonated user
+// No refresh token is issued, so the impersonation ends when the access token expires
+type ImpersonationResponse struct {
+	AccessToken string                `json:"access_token"`
+	ExpiresIn   int64                 `json:"expires_in"` // seconds
+	Session     *ImpersonationSession `json:"session"`
+	User        UserResponse          `json:"user"`
 }
@@ -50,11 +50,13 @@ func AuthMiddleware(authService auth.Service) echo.MiddlewareFunc {
 				))
 			}
 
+			// Optionally confirm the token still matches the user's current state
+			if err := authService.VerifyUserState(c.Request().Context(), claims); err != nil {
+				return util.HandleError(c, err)
+			}
+
 			// Store user information in context
-			c.Set("user_id", claims.UserID)
-			c.Set("username", claims.Username)
-			c.Set("email", claims.Email)
-			c.Set("token", token)
+			setAuthContext(c, claims, token)
 
 			return next(c)
 		}
@@ -80,12 +82,9 @@ func OptionalAuthMiddleware(authService auth.Service) echo.MiddlewareFunc {
 
 			if token != "" {
 				// Validate token if present
-				if claims, err := authService.ValidateAccessToken(token); err == nil {
+				if claims, err := authService.ValidateAccessToken(token); err == nil && authService.VerifyUserState(c.Request().Context(), claims) == nil {
 					// Store user information in context
-					c.Set("user_id", claims.UserID)
-					c.Set("username", claims.Username)
-					c.Set("email", claims.Email)
-					c.Set("token", token)
+					setAuthContext(c, claims, token)
 				}
 			}
 
@@ -0,0 +1,186 @@
+package middleware
+
+import (
+	"context"
+	"e-document-backend/internal/app/auth"
+	"e-document-backend/internal/app/user/usertest"
+	"e-document-backend/internal/config"
+	"e-document-backend/internal/domain"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// newTestAuthService returns an HS256 auth service with lean tokens and a user who can log in;
+// options adjust the configuration
+func newTestAuthService(t *testing.T, options ...func(cfg *config.Config)) (auth.Service, *usertest.Repository, domain.User) {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte("Secret123"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := domain.User{
+		ID:           uuid.New(),
+		Username:     "somchai",
+		Email:        "somchai@example.com",
+		Password:     string(hash),
+		Role:         domain.RoleDepartmentManager,
+		DepartmentID: "finance",
+	}
+	repo := usertest.NewRepository(u)
+
+	cfg := &config.Config{JWT: config.JWTConfig{
+		AccessTokenSecret:  "test-access-secret",
+		RefreshTokenSecret: "test-refresh-secret",
+		AccessTokenExpiry:  900,
+		RefreshTokenExpiry: 3600,
+		Algorithm:          config.JWTAlgorithmHS256,
+	}}
+	for _, option := range options {
+		option(cfg)
+	}
+	svc, err := auth.NewService(repo, nil, cfg, auth.NewLogVerificationNotifier())
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+	return svc, repo, u
+}
+
+func login(t *testing.T, svc auth.Service, u domain.User) string {
+	t.Helper()
+	result, err := svc.Login(context.Background(), domain.LoginRequest{UsernameOrEmail: u.Username, Password: "Secret123"})
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	return result.AccessToken
+}
+
+// serve runs a request through AuthMiddleware and returns the response and the user it authenticated
+func serve(svc auth.Service, setup func(req *http.Request)) (*httptest.ResponseRecorder, string) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/v1/users/me", nil)
+	setup(req)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	var userID string
+	handler := AuthMiddleware(svc)(func(c echo.Context) error {
+		userID, _ = c.Get("user_id").(string)
+		return c.NoContent(http.StatusOK)
+	})
+	_ = handler(c)
+	return rec, userID
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	svc, _, u := newTestAuthService(t)
+	token := login(t, svc, u)
+
+	tests := []struct {
+		name       string
+		setup      func(req *http.Request)
+		wantStatus int
+	}{
+		{
+			name:       "lean bearer token",
+			setup:      func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+token) },
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "lean token in cookie",
+			setup:      func(req *http.Request) { req.AddCookie(&http.Cookie{Name: "accessToken", Value: token}) },
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "missing token",
+			setup:      func(req *http.Request) {},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "tampered token",
+			setup:      func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+token+"x") },
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, userID := serve(svc, tt.setup)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus == http.StatusOK && userID != u.ID.String() {
+				t.Fatalf("user_id = %q, want %s", userID, u.ID)
+			}
+		})
+	}
+}
+
+func TestAuthMiddlewareUserState(t *testing.T) {
+	disable := func(repo *usertest.Repository, id string) { _ = repo.SetDisabled(context.Background(), id, true) }
+	tests := []struct {
+		name       string
+		change     func(repo *usertest.Repository, id string)
+		wantStatus int
+	}{
+		{name: "active user", change: func(repo *usertest.Repository, id string) {}, wantStatus: http.StatusOK},
+		{name: "disabled user", change: disable, wantStatus: http.StatusForbidden},
+		{name: "deleted user", change: func(repo *usertest.Repository, id string) {
+			_ = repo.Delete(context.Background(), id)
+		}, wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo, u := newTestAuthService(t)
+			token := login(t, svc, u)
+			tt.change(repo, u.ID.String())
+
+			rec, _ := serve(svc, func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+token) })
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestAuthMiddlewareContext(t *testing.T) {
+	tests := []struct {
+		name           string
+		profileClaims  bool
+		wantDepartment string
+	}{
+		{name: "lean token filled from the user state lookup", profileClaims: false, wantDepartment: "finance"},
+		{name: "token with profile claims", profileClaims: true, wantDepartment: "finance"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, _, u := newTestAuthService(t, func(cfg *config.Config) { cfg.JWT.IncludeProfileClaims = tt.profileClaims })
+			token := login(t, svc, u)
+
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/v1/users/me", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			c := e.NewContext(req, httptest.NewRecorder())
+
+			var role domain.UserRole
+			var department string
+			handler := AuthMiddleware(svc)(func(c echo.Context) error {
+				role, _ = UserRole(c)
+				department = DepartmentID(c)
+				return nil
+			})
+			if err := handler(c); err != nil {
+				t.Fatalf("AuthMiddleware() error = %v", err)
+			}
+			if role != u.Role || department != tt.wantDepartment {
+				t.Fatalf("context = role %q, department %q; want %q, %q", role, department, u.Role, tt.wantDepartment)
+			}
+		})
+	}
+}
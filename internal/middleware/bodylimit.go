@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"e-document-backend/internal/util"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// BodyLimitMiddleware rejects request bodies larger than limit bytes with a 413
+// Requests that declare a Content-Length above the limit are rejected before the body is read;
+// chunked bodies are cut off once they exceed the limit, which fails binding or form parsing
+func BodyLimitMiddleware(limit int64) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+
+			if req.ContentLength > limit {
+				return util.HandleError(c, util.NewPayloadTooLargeError(
+					"Request body too large",
+					fmt.Sprintf("request body of %d bytes exceeds the limit of %d bytes", req.ContentLength, limit),
+				))
+			}
+
+			req.Body = http.MaxBytesReader(c.Response(), req.Body, limit)
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// CompressConfig holds the configuration for gzip response compression
+type CompressConfig struct {
+	Level     int
+	MinLength int
+}
+
+// uncompressedPrefixes are request paths whose responses are never compressed:
+// TUS uploads, file downloads and previews, the upload event stream and ZIP archives
+// already carry binary or compressed data, and the metrics endpoint compresses itself
+var uncompressedPrefixes = []string{
+	"/api/v1/upload",
+	"/api/v1/storage/export",
+	"/metrics",
+}
+
+// CompressMiddleware creates a gzip compression middleware
+// Only clients sending "Accept-Encoding: gzip" get compressed responses
+func CompressMiddleware(config CompressConfig) echo.MiddlewareFunc {
+	// Default configuration
+	if config.Level == 0 {
+		config.Level = 5
+	}
+
+	return middleware.GzipWithConfig(middleware.GzipConfig{
+		Level:     config.Level,
+		MinLength: config.MinLength,
+		Skipper: func(c echo.Context) bool {
+			path := c.Request().URL.Path
+			for _, prefix := range uncompressedPrefixes {
+				if strings.HasPrefix(path, prefix) {
+					return true
+				}
+			}
+			return false
+		},
+	})
+}
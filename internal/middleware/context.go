@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"e-document-backend/internal/domain"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// setAuthContext stores the authenticated user's claims in the request context
+// Department and sector come from the user state lookup of every authenticated request
+func setAuthContext(c echo.Context, claims *domain.TokenClaims, token string) {
+	c.Set("user_id", claims.UserID)
+	c.Set("username", claims.Username)
+	c.Set("email", claims.Email)
+	c.Set("role", claims.Role)
+	c.Set("department_id", claims.DepartmentID)
+	c.Set("sector_id", claims.SectorID)
+	c.Set("token", token)
+
+	// Every request made while impersonating is audited with the director behind it
+	if claims.ImpersonatedBy != "" {
+		c.Set("impersonated_by", claims.ImpersonatedBy)
+		c.Set("impersonation_id", claims.ImpersonationID)
+		log.Info().
+			Str("event", "impersonated_request").
+			Str("session_id", claims.ImpersonationID).
+			Str("director_id", claims.ImpersonatedBy).
+			Str("user_id", claims.UserID).
+			Str("method", c.Request().Method).
+			Str("path", c.Request().URL.Path).
+			Msg("Request made while impersonating a user")
+	}
+}
+
+// UserRole returns the authenticated user's role, or false if the request is not authenticated
+func UserRole(c echo.Context) (domain.UserRole, bool) {
+	role, ok := c.Get("role").(string)
+	if !ok || role == "" {
+		return "", false
+	}
+	return domain.UserRole(role), true
+}
+
+// DepartmentID returns the authenticated user's department, or an empty string if unknown
+func DepartmentID(c echo.Context) string {
+	departmentID, _ := c.Get("department_id").(string)
+	return departmentID
+}
+
+// SectorID returns the authenticated user's sector, or an empty string if unknown
+func SectorID(c echo.Context) string {
+	sectorID, _ := c.Get("sector_id").(string)
+	return sectorID
+}
@@ -3,58 +3,36 @@ package middleware
 import (
 	"bytes"
 	"e-document-backend/internal/logger"
-	"encoding/json"
 	"io"
+	"sync/atomic"
 	"time"
 
 	"github.com/labstack/echo/v4"
 )
 
-// maskSensitiveFields masks sensitive fields in request body
-func maskSensitiveFields(body []byte) string {
-	if len(body) == 0 {
-		return ""
-	}
-
-	var data map[string]interface{}
-	if err := json.Unmarshal(body, &data); err != nil {
-		// If not JSON, return as is (but truncate if too long)
-		bodyStr := string(body)
-		if len(bodyStr) > 500 {
-			return bodyStr[:500] + "... (truncated)"
-		}
-		return bodyStr
-	}
-
-	// Sensitive field names to mask
-	sensitiveFields := []string{
-		"password", "Password", "PASSWORD",
-		"passwd", "Passwd", "PASSWD",
-		"secret", "Secret", "SECRET",
-		"token", "Token", "TOKEN",
-		"api_key", "apiKey", "API_KEY",
-		"access_token", "accessToken", "ACCESS_TOKEN",
-		"refresh_token", "refreshToken", "REFRESH_TOKEN",
-	}
-
-	// Mask sensitive fields
-	for _, field := range sensitiveFields {
-		if _, exists := data[field]; exists {
-			data[field] = "***MASKED***"
-		}
-	}
+// LoggerConfig holds the configuration of the request logger
+type LoggerConfig struct {
+	SensitiveKeys []string // body and query keys masked on top of the built-in ones
+	SampleRate    int      // log 1 in this many successful requests; 0 or 1 logs every request
+}
 
-	// Convert back to JSON string
-	maskedBody, err := json.Marshal(data)
-	if err != nil {
-		return string(body)
-	}
+// requestSampler decides which successful requests are logged
+type requestSampler struct {
+	rate  uint64
+	count atomic.Uint64
+}
 
-	return string(maskedBody)
+// sample reports whether the next request is logged
+func (s *requestSampler) sample() bool {
+	return s.rate <= 1 || s.count.Add(1)%s.rate == 1
 }
 
 // LoggerMiddleware logs HTTP requests and responses
-func LoggerMiddleware() echo.MiddlewareFunc {
+// With sampling, skipped requests are still logged when they fail
+func LoggerMiddleware(config LoggerConfig) echo.MiddlewareFunc {
+	redact := newRedactor(config.SensitiveKeys)
+	sampler := &requestSampler{rate: uint64(max(config.SampleRate, 1))}
+
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			req := c.Request()
@@ -62,31 +40,34 @@ func LoggerMiddleware() echo.MiddlewareFunc {
 
 			// Start timer
 			start := time.Now()
+			sampled := sampler.sample()
 
 			// Read request body
 			var requestBody []byte
 			var maskedBody string
-			if req.Body != nil && req.Method != "GET" && req.Method != "DELETE" {
+			if sampled && req.Body != nil && req.Method != "GET" && req.Method != "DELETE" {
 				requestBody, _ = io.ReadAll(req.Body)
 				// Restore request body for next middleware
 				req.Body = io.NopCloser(bytes.NewBuffer(requestBody))
 				// Mask sensitive fields
-				maskedBody = maskSensitiveFields(requestBody)
+				maskedBody = redact.body(requestBody, req.Header.Get(echo.HeaderContentType))
 			}
 
 			// Log request
-			logEvent := logger.Logger.Info().
-				Str("method", req.Method).
-				Str("path", req.URL.Path).
-				Str("query", req.URL.RawQuery).
-				Str("ip", c.RealIP()).
-				Str("user_agent", req.UserAgent())
-
-			if maskedBody != "" {
-				logEvent.Str("body", maskedBody)
-			}
+			if sampled {
+				logEvent := logger.Logger.Info().
+					Str("method", req.Method).
+					Str("path", req.URL.Path).
+					Str("query", redact.query(req.URL.RawQuery)).
+					Str("ip", c.RealIP()).
+					Str("user_agent", req.UserAgent())
+
+				if maskedBody != "" {
+					logEvent.Str("body", maskedBody)
+				}
 
-			logEvent.Msg("Incoming request")
+				logEvent.Msg("Incoming request")
+			}
 
 			// Process request
 			err := next(c)
@@ -105,7 +86,7 @@ func LoggerMiddleware() echo.MiddlewareFunc {
 					Dur("duration", duration).
 					Str("duration_human", duration.String()).
 					Msg("Request failed")
-			} else {
+			} else if sampled || res.Status >= 400 {
 				// Log success response
 				logEvent := logger.Logger.Info().
 					Str("method", req.Method).
@@ -140,7 +121,10 @@ func LoggerMiddleware() echo.MiddlewareFunc {
 }
 
 // DetailedLoggerMiddleware logs HTTP requests and responses with body (for debugging)
-func DetailedLoggerMiddleware() echo.MiddlewareFunc {
+func DetailedLoggerMiddleware(config LoggerConfig) echo.MiddlewareFunc {
+	redact := newRedactor(config.SensitiveKeys)
+	sampler := &requestSampler{rate: uint64(max(config.SampleRate, 1))}
+
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			req := c.Request()
@@ -148,27 +132,30 @@ func DetailedLoggerMiddleware() echo.MiddlewareFunc {
 
 			// Start timer
 			start := time.Now()
+			sampled := sampler.sample()
 
 			// Read request body
 			var requestBody []byte
 			var maskedBody string
-			if req.Body != nil {
+			if sampled && req.Body != nil {
 				requestBody, _ = io.ReadAll(req.Body)
 				// Restore request body for next middleware
 				req.Body = io.NopCloser(bytes.NewBuffer(requestBody))
 				// Mask sensitive fields
-				maskedBody = maskSensitiveFields(requestBody)
+				maskedBody = redact.body(requestBody, req.Header.Get(echo.HeaderContentType))
 			}
 
 			// Log request with body
-			logger.Logger.Debug().
-				Str("method", req.Method).
-				Str("path", req.URL.Path).
-				Str("query", req.URL.RawQuery).
-				Str("ip", c.RealIP()).
-				Str("user_agent", req.UserAgent()).
-				Str("body", maskedBody).
-				Msg("Incoming request (detailed)")
+			if sampled {
+				logger.Logger.Debug().
+					Str("method", req.Method).
+					Str("path", req.URL.Path).
+					Str("query", redact.query(req.URL.RawQuery)).
+					Str("ip", c.RealIP()).
+					Str("user_agent", req.UserAgent()).
+					Str("body", maskedBody).
+					Msg("Incoming request (detailed)")
+			}
 
 			// Process request
 			err := next(c)
@@ -186,7 +173,7 @@ func DetailedLoggerMiddleware() echo.MiddlewareFunc {
 					Dur("duration", duration).
 					Str("duration_human", duration.String()).
 					Msg("Request failed (detailed)")
-			} else {
+			} else if sampled || res.Status >= 400 {
 				logger.Logger.Debug().
 					Str("method", req.Method).
 					Str("path", req.URL.Path).
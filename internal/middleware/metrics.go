@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"e-document-backend/internal/pkg/metrics"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// MetricsMiddleware records request counts and latencies for Prometheus
+func MetricsMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+
+			err := next(c)
+
+			// Use the route template (e.g. /api/v1/users/:id) to keep label cardinality bounded
+			path := c.Path()
+			if path == "" {
+				path = "unmatched"
+			}
+
+			status := c.Response().Status
+			if err != nil {
+				if httpErr, ok := err.(*echo.HTTPError); ok {
+					status = httpErr.Code
+				}
+			}
+
+			labels := []string{c.Request().Method, path, strconv.Itoa(status)}
+			metrics.HTTPRequestsTotal.WithLabelValues(labels...).Inc()
+			metrics.HTTPRequestDuration.WithLabelValues(labels...).Observe(time.Since(start).Seconds())
+
+			return err
+		}
+	}
+}
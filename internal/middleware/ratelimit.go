@@ -1,7 +1,7 @@
 package middleware
 
 import (
-	"net/http"
+	"e-document-backend/internal/util"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
@@ -37,18 +37,10 @@ func RateLimitMiddleware(config RateLimitConfig) echo.MiddlewareFunc {
 			return c.RealIP(), nil
 		},
 		ErrorHandler: func(c echo.Context, err error) error {
-			return c.JSON(http.StatusTooManyRequests, map[string]interface{}{
-				"success": false,
-				"message": "Rate limit exceeded",
-				"error":   "Too many requests, please try again later",
-			})
+			return util.HandleError(c, util.NewRateLimitedError("Too many requests, please try again later"))
 		},
 		DenyHandler: func(c echo.Context, identifier string, err error) error {
-			return c.JSON(http.StatusTooManyRequests, map[string]interface{}{
-				"success": false,
-				"message": "Rate limit exceeded",
-				"error":   "Too many requests, please try again later",
-			})
+			return util.HandleError(c, util.NewRateLimitedError("Too many requests, please try again later"))
 		},
 	})
 }
@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+)
+
+// maskedValue replaces the value of a sensitive field in request logs
+const maskedValue = "***MASKED***"
+
+// maxLoggedBodyLength is how much of a body that cannot be parsed is logged
+const maxLoggedBodyLength = 500
+
+// sensitiveKeyWords mask any key containing them, such as new_password or client_secret
+var sensitiveKeyWords = []string{"password", "passwd", "secret", "token", "apikey"}
+
+// redactor masks sensitive values in request bodies and query strings before they are logged
+type redactor struct {
+	extraKeys map[string]bool // normalized keys masked on an exact match
+}
+
+// newRedactor creates a redactor masking the built-in key words and the given extra keys
+func newRedactor(extraKeys []string) *redactor {
+	r := &redactor{extraKeys: make(map[string]bool, len(extraKeys))}
+	for _, key := range extraKeys {
+		if key = normalizeKey(key); key != "" {
+			r.extraKeys[key] = true
+		}
+	}
+	return r
+}
+
+// normalizeKey lowercases a key and drops separators so api_key, apiKey and API-KEY compare equal
+func normalizeKey(key string) string {
+	return strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(strings.TrimSpace(key)))
+}
+
+// isSensitive reports whether the value of a key must be masked
+func (r *redactor) isSensitive(key string) bool {
+	key = normalizeKey(key)
+	if r.extraKeys[key] {
+		return true
+	}
+	for _, word := range sensitiveKeyWords {
+		if strings.Contains(key, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// body masks sensitive fields at any depth of a JSON body, or in a form-encoded body
+// Other bodies are logged as is but truncated
+func (r *redactor) body(body []byte, contentType string) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	var data interface{}
+	if err := json.Unmarshal(body, &data); err == nil {
+		maskedBody, err := json.Marshal(r.value(data))
+		if err != nil {
+			return string(body)
+		}
+		return string(maskedBody)
+	}
+
+	if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+		return r.query(string(body))
+	}
+
+	bodyStr := string(body)
+	if len(bodyStr) > maxLoggedBodyLength {
+		return bodyStr[:maxLoggedBodyLength] + "... (truncated)"
+	}
+	return bodyStr
+}
+
+// value masks sensitive fields of a decoded JSON value, recursing into objects and arrays
+func (r *redactor) value(data interface{}) interface{} {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if r.isSensitive(key) {
+				v[key] = maskedValue
+			} else {
+				v[key] = r.value(field)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = r.value(item)
+		}
+	}
+	return data
+}
+
+// query masks the values of sensitive parameters in a raw query string
+func (r *redactor) query(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+
+	// Malformed parameters are dropped so they cannot carry a secret past the check
+	values, err := url.ParseQuery(rawQuery)
+	masked := false
+	for key, items := range values {
+		if r.isSensitive(key) {
+			for i := range items {
+				items[i] = maskedValue
+			}
+			masked = true
+		}
+	}
+	if !masked && err == nil {
+		return rawQuery
+	}
+	return values.Encode()
+}
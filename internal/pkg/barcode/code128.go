@@ -0,0 +1,138 @@
+// Package barcode renders Code 128 barcodes as SVG or PNG images
+package barcode
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+)
+
+const (
+	startB    = 104
+	stopValue = 106
+
+	quietZone    = 10 // modules of white space on each side, as required by the symbology
+	moduleWidth  = 2  // pixels per module
+	barHeight    = 80 // pixels
+	maxValueSize = 80
+)
+
+// patterns holds the bar/space widths of each Code 128 symbol, starting with a bar
+var patterns = [...]string{
+	"212222", "222122", "222221", "121223", "121322", "131222", "122213", "122312", "132212", "221213",
+	"221312", "231212", "112232", "122132", "122231", "113222", "123122", "123221", "223211", "221132",
+	"221231", "213212", "223112", "312131", "311222", "321122", "321221", "312212", "322112", "322211",
+	"212123", "212321", "232121", "111323", "131123", "131321", "112313", "132113", "132311", "211313",
+	"231113", "231311", "112133", "112331", "132131", "113123", "113321", "133121", "313121", "211331",
+	"231131", "213113", "213311", "213131", "311123", "311321", "331121", "312113", "312311", "332111",
+	"314111", "221411", "431111", "111224", "111422", "121124", "121421", "141122", "141221", "112214",
+	"112412", "122114", "122411", "142112", "142211", "241211", "221114", "413111", "241112", "134111",
+	"111242", "121142", "121241", "114212", "124112", "124211", "411212", "421112", "421211", "212141",
+	"214121", "412121", "111143", "111341", "131141", "114113", "114311", "411113", "411311", "113141",
+	"114131", "311141", "411131", "211412", "211214", "211232", "2331112",
+}
+
+// Encode converts value into Code 128 (code set B) modules, true meaning a bar
+// Only printable ASCII is supported
+func Encode(value string) ([]bool, error) {
+	if value == "" {
+		return nil, fmt.Errorf("barcode value is empty")
+	}
+	if len(value) > maxValueSize {
+		return nil, fmt.Errorf("barcode value exceeds %d characters", maxValueSize)
+	}
+
+	symbols := make([]int, 0, len(value)+3)
+	symbols = append(symbols, startB)
+	checksum := startB
+	for i, r := range value {
+		if r < 32 || r > 126 {
+			return nil, fmt.Errorf("barcode value contains unsupported character %q", r)
+		}
+		symbol := int(r) - 32
+		symbols = append(symbols, symbol)
+		checksum += symbol * (i + 1)
+	}
+	symbols = append(symbols, checksum%103, stopValue)
+
+	var modules []bool
+	for _, symbol := range symbols {
+		bar := true
+		for _, width := range patterns[symbol] {
+			for n := 0; n < int(width-'0'); n++ {
+				modules = append(modules, bar)
+			}
+			bar = !bar
+		}
+	}
+
+	return modules, nil
+}
+
+// SVG renders value as an SVG barcode with the value printed underneath
+func SVG(value string) ([]byte, error) {
+	modules, err := Encode(value)
+	if err != nil {
+		return nil, err
+	}
+
+	width := (len(modules) + 2*quietZone) * moduleWidth
+	height := barHeight + 24
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`, width, height, width, height)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="#fff"/>`, width, height)
+	for i := 0; i < len(modules); {
+		if !modules[i] {
+			i++
+			continue
+		}
+		start := i
+		for i < len(modules) && modules[i] {
+			i++
+		}
+		fmt.Fprintf(&buf, `<rect x="%d" y="0" width="%d" height="%d" fill="#000"/>`,
+			(quietZone+start)*moduleWidth, (i-start)*moduleWidth, barHeight)
+	}
+	fmt.Fprintf(&buf, `<text x="%d" y="%d" font-family="monospace" font-size="16" text-anchor="middle">%s</text>`,
+		width/2, barHeight+18, escapeXML(value))
+	buf.WriteString(`</svg>`)
+
+	return buf.Bytes(), nil
+}
+
+// PNG renders value as a PNG barcode
+func PNG(value string) ([]byte, error) {
+	modules, err := Encode(value)
+	if err != nil {
+		return nil, err
+	}
+
+	width := (len(modules) + 2*quietZone) * moduleWidth
+	img := image.NewGray(image.Rect(0, 0, width, barHeight))
+	for x := 0; x < width; x++ {
+		module := x/moduleWidth - quietZone
+		c := color.Gray{Y: 0xff}
+		if module >= 0 && module < len(modules) && modules[module] {
+			c = color.Gray{Y: 0}
+		}
+		for y := 0; y < barHeight; y++ {
+			img.SetGray(x, y, c)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode barcode png: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// escapeXML escapes characters that are not allowed in SVG text content
+func escapeXML(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
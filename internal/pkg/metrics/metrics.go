@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "edocument"
+
+var (
+	// HTTPRequestsTotal counts handled HTTP requests by method, route and status
+	HTTPRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Total number of HTTP requests",
+	}, []string{"method", "path", "status"})
+
+	// HTTPRequestDuration observes HTTP request latencies by method, route and status
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency in seconds",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "path", "status"})
+
+	// UploadsTotal counts processed uploads by result ("success", "failure", "duplicate", "quarantined", "type_mismatch", "name_conflict", "invalid_metadata", "quota_exceeded" or "checksum_mismatch")
+	UploadsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "uploads_total",
+		Help:      "Total number of completed uploads processed",
+	}, []string{"result"})
+
+	// UploadsStartedTotal counts uploads accepted by tusd
+	// A parallel upload is counted once, when its final upload is created
+	UploadsStartedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "uploads_started_total",
+		Help:      "Total number of uploads created",
+	})
+
+	// UploadsProcessing is the number of completed uploads currently being processed
+	UploadsProcessing = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "uploads_processing",
+		Help:      "Number of completed uploads being processed",
+	})
+
+	// UploadProcessingDuration observes how long post-upload processing takes by result
+	UploadProcessingDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "upload_processing_duration_seconds",
+		Help:      "Time spent processing a completed upload in seconds",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"result"})
+
+	// UploadDuration observes the time from upload creation to completion (1s to ~4.5h)
+	UploadDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "upload_duration_seconds",
+		Help:      "Time from upload creation to successful processing in seconds",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 15),
+	})
+
+	// UploadBytesTotal sums the size of successfully processed uploads
+	UploadBytesTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "upload_bytes_total",
+		Help:      "Total bytes of successfully processed uploads",
+	})
+)
+
+// Handler returns the HTTP handler exposing metrics in Prometheus format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
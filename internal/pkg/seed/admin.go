@@ -20,20 +20,21 @@ func SeedAdmin(ctx context.Context, userRepo user.Repository, cfg *config.Config
 	}
 
 	// Hash the admin password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(cfg.Admin.Password), bcrypt.DefaultCost)
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(cfg.Admin.Password), cfg.Password.BcryptCost)
 	if err != nil {
 		return err
 	}
 
 	// Create admin user
 	adminUser := &domain.User{
-		Username:  cfg.Admin.Username,
-		Email:     cfg.Admin.Email,
-		Password:  string(hashedPassword),
-		FirstName: "Admin",
-		LastName:  "User",
-		Phone:     "000-000-0000",
-		Role:      domain.RoleDirector, // Admin has Director role
+		Username:      cfg.Admin.Username,
+		Email:         cfg.Admin.Email,
+		Password:      string(hashedPassword),
+		FirstName:     "Admin",
+		LastName:      "User",
+		Phone:         "000-000-0000",
+		Role:          domain.RoleDirector, // Admin has Director role
+		EmailVerified: true,                // Seeded from configuration, there is no inbox to confirm
 	}
 
 	if err := userRepo.Create(ctx, adminUser); err != nil {
@@ -0,0 +1,62 @@
+package shutdown
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Step is one stage of the shutdown sequence with its own timeout budget
+type Step struct {
+	Name    string
+	Timeout time.Duration
+	Run     func(ctx context.Context) error
+}
+
+// Sequence runs shutdown steps strictly in the order they were added
+type Sequence struct {
+	steps []Step
+}
+
+// NewSequence creates an empty shutdown sequence
+func NewSequence() *Sequence {
+	return &Sequence{}
+}
+
+// Add appends a step to the sequence
+func (s *Sequence) Add(name string, timeout time.Duration, run func(ctx context.Context) error) *Sequence {
+	s.steps = append(s.steps, Step{Name: name, Timeout: timeout, Run: run})
+	return s
+}
+
+// Run executes every step in order, each under its own timeout
+// A failing step is logged and does not stop later steps, so resources are always released
+// Returns the number of steps that failed
+func (s *Sequence) Run() int {
+	failed := 0
+
+	for _, step := range s.steps {
+		ctx, cancel := context.WithTimeout(context.Background(), step.Timeout)
+		start := time.Now()
+
+		err := step.Run(ctx)
+		cancel()
+
+		if err != nil {
+			failed++
+			log.Error().Err(err).
+				Str("step", step.Name).
+				Dur("elapsed", time.Since(start)).
+				Msg("Shutdown step failed")
+			continue
+		}
+
+		log.Info().
+			Str("step", step.Name).
+			Dur("elapsed", time.Since(start)).
+			Msg("Shutdown step completed")
+	}
+
+	return failed
+}
@@ -0,0 +1,111 @@
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder collects the hooks the steps of a sequence ran, in order
+type recorder struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *recorder) record(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, name)
+}
+
+// hook returns a step that records its start and end around run
+func (r *recorder) hook(name string, run func(ctx context.Context) error) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		r.record(name + " start")
+		defer r.record(name + " end")
+		if run == nil {
+			return nil
+		}
+		return run(ctx)
+	}
+}
+
+func TestSequenceOrder(t *testing.T) {
+	stuck := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	failing := func(ctx context.Context) error { return errors.New("close failed") }
+
+	tests := []struct {
+		name       string
+		http       func(ctx context.Context) error
+		drain      func(ctx context.Context) error
+		minio      func(ctx context.Context) error
+		wantFailed int
+	}{
+		{name: "every step succeeds"},
+		{name: "draining times out", drain: stuck, wantFailed: 1},
+		{name: "http server and minio fail", http: failing, minio: failing, wantFailed: 2},
+	}
+
+	// Stop accepting requests, drain in-flight work, then release storage and finally the database
+	wantCalls := []string{
+		"http server start", "http server end",
+		"background jobs start", "background jobs end",
+		"minio start", "minio end",
+		"postgres start", "postgres end",
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &recorder{}
+			failed := NewSequence().
+				Add("http server", time.Second, r.hook("http server", tt.http)).
+				Add("background jobs", 20*time.Millisecond, r.hook("background jobs", tt.drain)).
+				Add("minio", time.Second, r.hook("minio", tt.minio)).
+				Add("postgres", time.Second, r.hook("postgres", nil)).
+				Run()
+
+			if failed != tt.wantFailed {
+				t.Fatalf("Run() = %d failed steps, want %d", failed, tt.wantFailed)
+			}
+			if !reflect.DeepEqual(r.calls, wantCalls) {
+				t.Fatalf("steps ran as %v, want %v", r.calls, wantCalls)
+			}
+		})
+	}
+}
+
+func TestSequenceBudgets(t *testing.T) {
+	budgets := []time.Duration{50 * time.Millisecond, time.Second}
+	remaining := make([]time.Duration, len(budgets))
+
+	seq := NewSequence()
+	for i, budget := range budgets {
+		i := i
+		seq.Add("step", budget, func(ctx context.Context) error {
+			deadline, ok := ctx.Deadline()
+			if !ok {
+				t.Errorf("step %d has no deadline", i)
+				return nil
+			}
+			remaining[i] = time.Until(deadline)
+			if i == 0 {
+				// Use up the whole budget; the next step must still get all of its own
+				<-ctx.Done()
+			}
+			return nil
+		})
+	}
+	seq.Run()
+
+	for i, budget := range budgets {
+		if remaining[i] > budget || remaining[i] < budget-20*time.Millisecond {
+			t.Errorf("step %d started with %v left, want about %v", i, remaining[i], budget)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// ErrBucketNotFound is returned when the configured bucket no longer exists
+var ErrBucketNotFound = errors.New("storage bucket not found")
+
+// IsBucketNotFound reports whether err means the bucket is missing,
+// either as ErrBucketNotFound or as a raw NoSuchBucket response from MinIO
+func IsBucketNotFound(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrBucketNotFound) {
+		return true
+	}
+
+	var resp minio.ErrorResponse
+	if errors.As(err, &resp) {
+		return resp.Code == "NoSuchBucket"
+	}
+	return false
+}
+
+// IsObjectNotFound reports whether err is a NoSuchKey response from MinIO
+func IsObjectNotFound(err error) bool {
+	var resp minio.ErrorResponse
+	if errors.As(err, &resp) {
+		return resp.Code == "NoSuchKey"
+	}
+	return false
+}
+
+// wrapError wraps a MinIO error with the failed operation, translating a missing bucket to ErrBucketNotFound
+func (m *MinIOClient) wrapError(operation string, err error) error {
+	if IsBucketNotFound(err) {
+		return fmt.Errorf("failed to %s: %w: %s", operation, ErrBucketNotFound, m.bucket)
+	}
+	return fmt.Errorf("failed to %s: %w", operation, err)
+}
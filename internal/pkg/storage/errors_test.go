@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// newMissingBucketClient points a client at a server answering every request as MinIO does once
+// the bucket has been deleted; responses to HEAD have no body and carry the code in a header
+func newMissingBucketClient(t *testing.T) *MinIOClient {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		w.Header().Set("x-minio-error-code", "NoSuchBucket")
+		w.WriteHeader(http.StatusNotFound)
+		if r.Method == http.MethodHead {
+			return
+		}
+		fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>
+<Error><Code>NoSuchBucket</Code><Message>The specified bucket does not exist</Message><BucketName>documents</BucketName><Resource>%s</Resource></Error>`, r.URL.Path)
+	}))
+	t.Cleanup(server.Close)
+
+	endpoint, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := minio.New(endpoint.Host, &minio.Options{
+		Creds:  credentials.NewStaticV4("access", "secret", ""),
+		Region: "us-east-1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &MinIOClient{
+		client:    client,
+		transport: http.DefaultTransport.(*http.Transport),
+		bucket:    "documents",
+	}
+}
+
+func TestMissingBucket(t *testing.T) {
+	m := newMissingBucketClient(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		op   func() error
+	}{
+		{name: "ping", op: func() error { return m.Ping(ctx) }},
+		{name: "upload", op: func() error {
+			_, err := m.UploadData(ctx, []byte("content"), "report.pdf", "application/pdf", "documents")
+			return err
+		}},
+		{name: "delete", op: func() error { return m.DeleteFile(ctx, "documents/report.pdf") }},
+		{name: "read", op: func() error {
+			object, err := m.GetFile(ctx, "documents/report.pdf")
+			if err != nil {
+				return m.wrapError("get file", err)
+			}
+			defer object.Close()
+			if _, err := object.Stat(); err != nil {
+				return m.wrapError("get file", err)
+			}
+			return nil
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.op()
+			if err == nil {
+				t.Fatal("operation on a missing bucket succeeded")
+			}
+			if !IsBucketNotFound(err) {
+				t.Fatalf("IsBucketNotFound(%v) = false, want true", err)
+			}
+			if !errors.Is(err, ErrBucketNotFound) {
+				t.Fatalf("error %v does not wrap ErrBucketNotFound", err)
+			}
+		})
+	}
+}
+
+func TestIsBucketNotFound(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "sentinel", err: ErrBucketNotFound, want: true},
+		{name: "wrapped sentinel", err: fmt.Errorf("failed to upload file: %w", ErrBucketNotFound), want: true},
+		{name: "raw NoSuchBucket response", err: minio.ErrorResponse{Code: "NoSuchBucket"}, want: true},
+		{name: "wrapped NoSuchBucket response", err: fmt.Errorf("get: %w", minio.ErrorResponse{Code: "NoSuchBucket"}), want: true},
+		{name: "missing object", err: minio.ErrorResponse{Code: "NoSuchKey"}, want: false},
+		{name: "other error", err: errors.New("connection refused"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsBucketNotFound(tt.err); got != tt.want {
+				t.Fatalf("IsBucketNotFound(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
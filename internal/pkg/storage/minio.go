@@ -1,12 +1,16 @@
 package storage
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
 	"mime/multipart"
+	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -23,21 +27,35 @@ type MinIOConfig struct {
 	Bucket    string
 	UseSSL    bool
 	PublicURL string
+
+	MaxRetries     int           // retries after the first attempt for transient errors; 0 disables
+	RetryBaseDelay time.Duration // delay before the first retry, doubled on each subsequent one
 }
 
 // MinIOClient handles file operations with MinIO
 type MinIOClient struct {
 	client    *minio.Client
+	transport *http.Transport
 	bucket    string
 	publicURL string
+
+	maxRetries     int
+	retryBaseDelay time.Duration
 }
 
 // NewMinIOClient creates a new MinIO client
 func NewMinIOClient(config MinIOConfig) (*MinIOClient, error) {
+	// Keep our own transport so idle connections can be closed on shutdown
+	transport, err := minio.DefaultTransport(config.UseSSL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create MinIO transport: %w", err)
+	}
+
 	// Initialize MinIO client
 	minioClient, err := minio.New(config.Endpoint, &minio.Options{
-		Creds:  credentials.NewStaticV4(config.AccessKey, config.SecretKey, ""),
-		Secure: config.UseSSL,
+		Creds:     credentials.NewStaticV4(config.AccessKey, config.SecretKey, ""),
+		Secure:    config.UseSSL,
+		Transport: transport,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize MinIO client: %w", err)
@@ -60,8 +78,12 @@ func NewMinIOClient(config MinIOConfig) (*MinIOClient, error) {
 
 	return &MinIOClient{
 		client:    minioClient,
+		transport: transport,
 		bucket:    config.Bucket,
 		publicURL: config.PublicURL,
+
+		maxRetries:     config.MaxRetries,
+		retryBaseDelay: config.RetryBaseDelay,
 	}, nil
 }
 
@@ -72,6 +94,16 @@ func LoadConfigFromEnv() MinIOConfig {
 		useSSL = true
 	}
 
+	maxRetries := 3
+	if value, err := strconv.Atoi(os.Getenv("MINIO_MAX_RETRIES")); err == nil && value >= 0 {
+		maxRetries = value
+	}
+
+	retryBaseDelay := 200 * time.Millisecond
+	if value, err := time.ParseDuration(os.Getenv("MINIO_RETRY_BASE_DELAY")); err == nil && value > 0 {
+		retryBaseDelay = value
+	}
+
 	return MinIOConfig{
 		Endpoint:  os.Getenv("MINIO_ENDPOINT"),
 		AccessKey: os.Getenv("MINIO_ACCESS_KEY"),
@@ -79,6 +111,9 @@ func LoadConfigFromEnv() MinIOConfig {
 		Bucket:    os.Getenv("MINIO_BUCKET"),
 		UseSSL:    useSSL,
 		PublicURL: os.Getenv("MINIO_PUBLIC_URL"),
+
+		MaxRetries:     maxRetries,
+		RetryBaseDelay: retryBaseDelay,
 	}
 }
 
@@ -104,12 +139,18 @@ func (m *MinIOClient) UploadFile(ctx context.Context, file *multipart.FileHeader
 		contentType = "application/octet-stream"
 	}
 
-	// Upload to MinIO
-	_, err = m.client.PutObject(ctx, m.bucket, filename, src, file.Size, minio.PutObjectOptions{
-		ContentType: contentType,
+	// Upload to MinIO, rewinding the file before every attempt
+	err = m.withRetry(ctx, "upload file", func() error {
+		if _, err := src.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		_, err := m.client.PutObject(ctx, m.bucket, filename, src, file.Size, minio.PutObjectOptions{
+			ContentType: contentType,
+		})
+		return err
 	})
 	if err != nil {
-		return "", fmt.Errorf("failed to upload file: %w", err)
+		return "", m.wrapError("upload file", err)
 	}
 
 	// Return only the object path (not full URL)
@@ -134,7 +175,7 @@ func (m *MinIOClient) UploadFileFromReader(ctx context.Context, reader io.Reader
 		ContentType: contentType,
 	})
 	if err != nil {
-		return "", fmt.Errorf("failed to upload file: %w", err)
+		return "", m.wrapError("upload file", err)
 	}
 
 	// Return the file URL
@@ -142,15 +183,39 @@ func (m *MinIOClient) UploadFileFromReader(ctx context.Context, reader io.Reader
 	return fileURL, nil
 }
 
+// UploadData uploads an in-memory file to MinIO and returns the object path (not full URL)
+func (m *MinIOClient) UploadData(ctx context.Context, data []byte, filename string, contentType string, folder string) (string, error) {
+	objectPath := fmt.Sprintf("%s/%d_%s", folder, time.Now().Unix(), strings.ReplaceAll(filename, " ", "_"))
+
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	// A fresh reader per attempt so retries upload the whole file
+	err := m.withRetry(ctx, "upload file", func() error {
+		_, err := m.client.PutObject(ctx, m.bucket, objectPath, bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{
+			ContentType: contentType,
+		})
+		return err
+	})
+	if err != nil {
+		return "", m.wrapError("upload file", err)
+	}
+
+	return objectPath, nil
+}
+
 // DeleteFile deletes a file from MinIO using object path
 func (m *MinIOClient) DeleteFile(ctx context.Context, objectPath string) error {
 	if objectPath == "" {
 		return fmt.Errorf("empty object path")
 	}
 
-	err := m.client.RemoveObject(ctx, m.bucket, objectPath, minio.RemoveObjectOptions{})
+	err := m.withRetry(ctx, "delete file", func() error {
+		return m.client.RemoveObject(ctx, m.bucket, objectPath, minio.RemoveObjectOptions{})
+	})
 	if err != nil {
-		return fmt.Errorf("failed to delete file: %w", err)
+		return m.wrapError("delete file", err)
 	}
 
 	return nil
@@ -162,9 +227,14 @@ func (m *MinIOClient) GetFile(ctx context.Context, objectPath string) (*minio.Ob
 		return nil, fmt.Errorf("empty object path")
 	}
 
-	object, err := m.client.GetObject(ctx, m.bucket, objectPath, minio.GetObjectOptions{})
+	var object *minio.Object
+	err := m.withRetry(ctx, "get file", func() error {
+		var err error
+		object, err = m.client.GetObject(ctx, m.bucket, objectPath, minio.GetObjectOptions{})
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get file: %w", err)
+		return nil, m.wrapError("get file", err)
 	}
 
 	return object, nil
@@ -177,7 +247,12 @@ func (m *MinIOClient) GetPresignedURL(ctx context.Context, objectPath string, ex
 	}
 
 	// Generate presigned URL with expiry time
-	presignedURL, err := m.client.PresignedGetObject(ctx, m.bucket, objectPath, expiry, nil)
+	var presignedURL *url.URL
+	err := m.withRetry(ctx, "generate presigned URL", func() error {
+		var err error
+		presignedURL, err = m.client.PresignedGetObject(ctx, m.bucket, objectPath, expiry, nil)
+		return err
+	})
 	if err != nil {
 		return "", fmt.Errorf("failed to generate presigned URL: %w", err)
 	}
@@ -185,6 +260,24 @@ func (m *MinIOClient) GetPresignedURL(ctx context.Context, objectPath string, ex
 	return presignedURL.String(), nil
 }
 
+// Close releases idle connections to MinIO
+func (m *MinIOClient) Close() {
+	m.transport.CloseIdleConnections()
+	log.Info().Msg("MinIO idle connections closed")
+}
+
+// Ping verifies that MinIO is reachable and the configured bucket exists
+func (m *MinIOClient) Ping(ctx context.Context) error {
+	exists, err := m.client.BucketExists(ctx, m.bucket)
+	if err != nil {
+		return fmt.Errorf("failed to reach MinIO: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrBucketNotFound, m.bucket)
+	}
+	return nil
+}
+
 // ValidateImageFile checks if the uploaded file is a valid image
 func ValidateImageFile(file *multipart.FileHeader) error {
 	// Check file size (max 5MB)
@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/rs/zerolog/log"
+)
+
+// retryableCodes are MinIO/S3 error codes that indicate a transient condition
+var retryableCodes = map[string]bool{
+	"InternalError":      true,
+	"RequestTimeout":     true,
+	"ServiceUnavailable": true,
+	"SlowDown":           true,
+}
+
+// isRetryable reports whether an error from MinIO is worth retrying
+// Missing buckets/objects and permission errors are never retried
+func isRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var resp minio.ErrorResponse
+	if errors.As(err, &resp) {
+		return retryableCodes[resp.Code] || resp.StatusCode >= http.StatusInternalServerError
+	}
+
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// withRetry runs fn, retrying transient failures with exponential backoff
+// At most maxRetries retries are made after the first attempt
+func (m *MinIOClient) withRetry(ctx context.Context, operation string, fn func() error) error {
+	delay := m.retryBaseDelay
+
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= m.maxRetries || !isRetryable(err) {
+			return err
+		}
+
+		log.Warn().Err(err).
+			Str("operation", operation).
+			Int("attempt", attempt+1).
+			Dur("retry_in", delay).
+			Msg("Transient MinIO error, retrying")
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
@@ -0,0 +1,47 @@
+package totp
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := encoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Unix(1_700_000_000, 0)
+	step := now.Unix() / period
+
+	tests := []struct {
+		name        string
+		code        string
+		lastCounter int64
+		wantCounter int64
+		wantOK      bool
+	}{
+		{name: "current step", code: generate(key, step), lastCounter: -1, wantCounter: step, wantOK: true},
+		{name: "previous step within skew", code: generate(key, step-1), lastCounter: -1, wantCounter: step - 1, wantOK: true},
+		{name: "next step within skew", code: generate(key, step+1), lastCounter: -1, wantCounter: step + 1, wantOK: true},
+		{name: "outside the skew", code: generate(key, step-2), lastCounter: -1},
+		{name: "replayed step", code: generate(key, step), lastCounter: step},
+		{name: "step before the last accepted one", code: generate(key, step-1), lastCounter: step},
+		{name: "step after the last accepted one", code: generate(key, step+1), lastCounter: step, wantCounter: step + 1, wantOK: true},
+		{name: "wrong length", code: "12345", lastCounter: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter, ok := Validate(tt.code, secret, now, tt.lastCounter)
+			if ok != tt.wantOK || counter != tt.wantCounter {
+				t.Fatalf("Validate() = %d, %v; want %d, %v", counter, ok, tt.wantCounter, tt.wantOK)
+			}
+		})
+	}
+}
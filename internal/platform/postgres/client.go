@@ -14,8 +14,17 @@ type Client struct {
 	Pool *pgxpool.Pool
 }
 
+// PoolConfig holds the connection pool settings
+type PoolConfig struct {
+	MaxConns          int32
+	MinConns          int32
+	MaxConnLifetime   time.Duration
+	MaxConnIdleTime   time.Duration
+	HealthCheckPeriod time.Duration
+}
+
 // NewClient creates a new PostgreSQL client with connection pooling
-func NewClient(ctx context.Context, dsn string) (*Client, error) {
+func NewClient(ctx context.Context, dsn string, poolConfig PoolConfig) (*Client, error) {
 	// Parse config with defaults
 	config, err := pgxpool.ParseConfig(dsn)
 	if err != nil {
@@ -23,11 +32,11 @@ func NewClient(ctx context.Context, dsn string) (*Client, error) {
 	}
 
 	// Set connection pool settings
-	config.MaxConns = 25
-	config.MinConns = 5
-	config.MaxConnLifetime = time.Hour
-	config.MaxConnIdleTime = 30 * time.Minute
-	config.HealthCheckPeriod = time.Minute
+	config.MaxConns = poolConfig.MaxConns
+	config.MinConns = poolConfig.MinConns
+	config.MaxConnLifetime = poolConfig.MaxConnLifetime
+	config.MaxConnIdleTime = poolConfig.MaxConnIdleTime
+	config.HealthCheckPeriod = poolConfig.HealthCheckPeriod
 
 	// Create connection pool
 	pool, err := pgxpool.NewWithConfig(ctx, config)
@@ -40,7 +49,13 @@ func NewClient(ctx context.Context, dsn string) (*Client, error) {
 		return nil, fmt.Errorf("failed to ping PostgreSQL: %w", err)
 	}
 
-	log.Info().Msg("Successfully connected to PostgreSQL!")
+	log.Info().
+		Int32("max_conns", config.MaxConns).
+		Int32("min_conns", config.MinConns).
+		Dur("max_conn_lifetime", config.MaxConnLifetime).
+		Dur("max_conn_idle_time", config.MaxConnIdleTime).
+		Dur("health_check_period", config.HealthCheckPeriod).
+		Msg("Successfully connected to PostgreSQL!")
 
 	return &Client{Pool: pool}, nil
 }
@@ -0,0 +1,65 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog/log"
+)
+
+// WithTx runs fn inside a transaction on the pool
+// The transaction is committed when fn returns nil and rolled back when it returns an error or
+// panics; the panic is re-raised after the rollback. fn's error is returned unwrapped so callers
+// can still match sentinel errors with errors.Is
+func WithTx(ctx context.Context, pool *pgxpool.Pool, fn func(tx pgx.Tx) error) (err error) {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			rollback(ctx, tx)
+			panic(p)
+		}
+		if err != nil {
+			rollback(ctx, tx)
+		}
+	}()
+
+	if err = fn(tx); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// rollback aborts a transaction, logging failures since the caller already has an error to report
+func rollback(ctx context.Context, tx pgx.Tx) {
+	// A cancelled request context would abort the rollback itself
+	if err := tx.Rollback(context.WithoutCancel(ctx)); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+		log.Error().Err(err).Msg("failed to rollback transaction")
+	}
+}
+
+// LockObjectPaths takes a transaction-scoped advisory lock on each storage object path
+// Every transaction that adds or drops a reference to a shared object takes it first, so a
+// document delete cannot find an object unreferenced while an upload or a copy starts using it.
+// Paths are locked in sorted order to avoid deadlocks between transactions locking several
+func LockObjectPaths(ctx context.Context, tx pgx.Tx, filePaths []string) error {
+	query := `
+		SELECT pg_advisory_xact_lock(hashtext(p))
+		FROM (SELECT DISTINCT p FROM unnest($1::text[]) AS p ORDER BY p) AS paths
+	`
+	if _, err := tx.Exec(ctx, query, filePaths); err != nil {
+		return fmt.Errorf("failed to lock object paths: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package postgres
+
+import (
+	"context"
+	"e-document-backend/internal/platform/postgres/pgtest"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func TestWithTx(t *testing.T) {
+	pool := pgtest.NewPool(t)
+	ctx := context.Background()
+	if _, err := pool.Exec(ctx, "CREATE TABLE tx_probe (name TEXT PRIMARY KEY)"); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+
+	errSentinel := errors.New("stop")
+	tests := []struct {
+		name       string
+		fn         func(tx pgx.Tx) error
+		wantErr    error
+		wantPanic  bool
+		wantStored bool
+	}{
+		{name: "commits on success", fn: func(tx pgx.Tx) error { return nil }, wantStored: true},
+		{name: "rolls back on error", fn: func(tx pgx.Tx) error { return errSentinel }, wantErr: errSentinel},
+		{name: "rolls back on panic", fn: func(tx pgx.Tx) error { panic("boom") }, wantPanic: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			panicked := func() (panicked bool) {
+				defer func() {
+					if p := recover(); p != nil {
+						panicked = true
+					}
+				}()
+				err = WithTx(ctx, pool, func(tx pgx.Tx) error {
+					if _, err := tx.Exec(ctx, "INSERT INTO tx_probe (name) VALUES ($1)", tt.name); err != nil {
+						t.Fatalf("insert failed: %v", err)
+					}
+					return tt.fn(tx)
+				})
+				return false
+			}()
+
+			if panicked != tt.wantPanic {
+				t.Fatalf("panicked = %v, want %v", panicked, tt.wantPanic)
+			}
+			if !tt.wantPanic && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("WithTx() error = %v, want %v", err, tt.wantErr)
+			}
+
+			var stored bool
+			if err := pool.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM tx_probe WHERE name = $1)", tt.name).Scan(&stored); err != nil {
+				t.Fatalf("failed to check the row: %v", err)
+			}
+			if stored != tt.wantStored {
+				t.Fatalf("row stored = %v, want %v", stored, tt.wantStored)
+			}
+		})
+	}
+
+	// Every connection went back to the pool, none was left inside an open transaction
+	if stat := pool.Stat(); stat.AcquiredConns() != 0 {
+		t.Fatalf("%d connections are still acquired", stat.AcquiredConns())
+	}
+}
@@ -0,0 +1,68 @@
+package util
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// WeakETag builds a weak entity tag from the values that identify a version of a resource,
+// typically IDs, updated_at timestamps and versions
+func WeakETag(parts ...string) string {
+	hash := sha256.New()
+	for _, part := range parts {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return `W/"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+}
+
+// ETagPart formats a timestamp for WeakETag with full precision
+func ETagPart(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// NotModified sets the ETag of the response and reports whether the request's If-None-Match
+// already matches it, in which case the handler should reply 304 instead of the body
+// Clients must revalidate before reusing a cached copy since responses depend on the requester
+func NotModified(c echo.Context, etag string) bool {
+	header := c.Response().Header()
+	header.Set("ETag", etag)
+	header.Set("Cache-Control", "private, no-cache")
+
+	ifNoneMatch := c.Request().Header.Get("If-None-Match")
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		// If-None-Match uses weak comparison, so W/ prefixes are ignored
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// NotModifiedSince sets the Last-Modified of the response and reports whether the request's
+// If-Modified-Since is at or after it, in which case the handler should reply 304
+// If-Modified-Since is ignored when the request also sends If-None-Match
+func NotModifiedSince(c echo.Context, lastModified time.Time) bool {
+	// HTTP dates have second precision
+	lastModified = lastModified.UTC().Truncate(time.Second)
+	c.Response().Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+
+	req := c.Request()
+	if req.Header.Get("If-None-Match") != "" {
+		return false
+	}
+	since, err := http.ParseTime(req.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	return !lastModified.After(since)
+}
@@ -0,0 +1,23 @@
+package util
+
+import (
+	"github.com/labstack/echo/v4"
+)
+
+// GetUserID returns the authenticated user's ID stored in the context by the auth middleware
+// A missing or non-string value yields a 401 instead of panicking on the type assertion
+func GetUserID(c echo.Context) (string, error) {
+	userID, ok := c.Get("user_id").(string)
+	if !ok || userID == "" {
+		return "", ErrorResponse("Unauthorized", UNAUTHORIZED, 401, "user not authenticated")
+	}
+	return userID, nil
+}
+
+// GetImpersonation returns the director and session behind an impersonation token stored in the
+// context by the auth middleware; ok is false for a user's own login
+func GetImpersonation(c echo.Context) (directorID, sessionID string, ok bool) {
+	directorID, _ = c.Get("impersonated_by").(string)
+	sessionID, _ = c.Get("impersonation_id").(string)
+	return directorID, sessionID, directorID != "" && sessionID != ""
+}
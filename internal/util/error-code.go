@@ -10,7 +10,19 @@ const (
 	INVALID_CREDENTIALS ErrorCode = "INVALID_CREDENTIALS"
 	TOKEN_EXPIRED       ErrorCode = "TOKEN_EXPIRED"
 	INVALID_TOKEN       ErrorCode = "INVALID_TOKEN"
+	MISSING_TOKEN       ErrorCode = "MISSING_TOKEN"
 	FORBIDDEN           ErrorCode = "FORBIDDEN"
+	EMAIL_NOT_VERIFIED  ErrorCode = "EMAIL_NOT_VERIFIED"
+	TWO_FA_REQUIRED     ErrorCode = "2FA_REQUIRED"
+	INVALID_2FA_CODE    ErrorCode = "INVALID_2FA_CODE"
+	ACCOUNT_DISABLED    ErrorCode = "ACCOUNT_DISABLED"
+
+	//NOTE - Generic resource & request errors (used when no resource-specific code exists)
+	NOT_FOUND         ErrorCode = "NOT_FOUND"
+	ALREADY_EXISTS    ErrorCode = "ALREADY_EXISTS"
+	CONFLICT          ErrorCode = "CONFLICT"
+	RATE_LIMITED      ErrorCode = "RATE_LIMITED"
+	PAYLOAD_TOO_LARGE ErrorCode = "PAYLOAD_TOO_LARGE"
 
 	//NOTE - Validation errors
 	VALIDATION_ERROR       ErrorCode = "VALIDATION_ERROR"
@@ -18,25 +30,23 @@ const (
 	INVALID_INPUT          ErrorCode = "INVALID_INPUT"
 
 	//NOTE - Server errors
-	INTERNAL_SERVER_ERROR ErrorCode = "INTERNAL_SERVER_ERROR"
-	DATABASE_ERROR        ErrorCode = "DATABASE_ERROR"
-	CONFIG_NOT_SET        ErrorCode = "CONFIG_NOT_SET"
+	INTERNAL_SERVER_ERROR  ErrorCode = "INTERNAL_SERVER_ERROR"
+	DATABASE_ERROR         ErrorCode = "DATABASE_ERROR"
+	CONFIG_NOT_SET         ErrorCode = "CONFIG_NOT_SET"
+	STORAGE_ERROR          ErrorCode = "STORAGE_ERROR"
+	STORAGE_OBJECT_MISSING ErrorCode = "STORAGE_OBJECT_MISSING"
 
 	//NOTE - User errors
 	USER_NOT_FOUND       ErrorCode = "USER_NOT_FOUND"
 	USER_ALREADY_EXISTS  ErrorCode = "USER_ALREADY_EXISTS"
 	EMAIL_ALREADY_EXISTS ErrorCode = "EMAIL_ALREADY_EXISTS"
+	USER_HAS_CONTENT     ErrorCode = "USER_HAS_CONTENT"
 
 	//NOTE - Role errors
 	ROLE_NOT_FOUND      ErrorCode = "ROLE_NOT_FOUND"
 	ROLE_ALREADY_EXISTS ErrorCode = "ROLE_ALREADY_EXISTS"
 )
 
-// ErrorDetail represents detailed error information
-type ErrorDetail struct {
-	Detail string `json:"detail"`
-}
-
 // CustomError is a custom error type that includes error code and status code
 type CustomError struct {
 	Message    string
@@ -50,6 +60,21 @@ func (e *CustomError) Error() string {
 	return e.Detail
 }
 
+// ValidationErrorResponse is a validation error that carries a message per field
+// HandleError returns Fields in the response error so clients can map messages back to form inputs
+type ValidationErrorResponse struct {
+	Message    string
+	ErrorCode  ErrorCode
+	StatusCode int
+	Detail     string
+	Fields     map[string]string
+}
+
+// Error implements the error interface
+func (e *ValidationErrorResponse) Error() string {
+	return e.Detail
+}
+
 // ErrorResponse creates a new CustomError
 // Usage in service: return nil, util.ErrorResponse("Email already exists", util.EMAIL_ALREADY_EXISTS, 400, "email user@example.com is already in use")
 // Usage in handler: return util.HandleError(c, util.ErrorResponse("Validation failed", util.INVALID_INPUT, 400, "Email is required"))
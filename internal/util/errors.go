@@ -4,20 +4,42 @@ import "fmt"
 
 // Common error creation helpers for better DX (Developer Experience)
 
+// notFoundCodes maps resources that have their own not found code; others use NOT_FOUND
+var notFoundCodes = map[string]ErrorCode{
+	"User": USER_NOT_FOUND,
+	"Role": ROLE_NOT_FOUND,
+}
+
+// alreadyExistsCodes maps resources that have their own already exists code; others use ALREADY_EXISTS
+var alreadyExistsCodes = map[string]ErrorCode{
+	"User": USER_ALREADY_EXISTS,
+	"Role": ROLE_ALREADY_EXISTS,
+}
+
 // NewNotFoundError creates a not found error
+// The error code is derived from the resource, e.g. "User" -> USER_NOT_FOUND, "Folder" -> NOT_FOUND
 func NewNotFoundError(resource string, identifier string) error {
+	errorCode, ok := notFoundCodes[resource]
+	if !ok {
+		errorCode = NOT_FOUND
+	}
+
 	return &CustomError{
 		Message:    fmt.Sprintf("%s not found", resource),
-		ErrorCode:  USER_NOT_FOUND, // Can be made generic
+		ErrorCode:  errorCode,
 		StatusCode: 404,
 		Detail:     fmt.Sprintf("%s with identifier %s was not found", resource, identifier),
 	}
 }
 
 // NewAlreadyExistsError creates an already exists error
+// The error code is derived from the resource, with EMAIL_ALREADY_EXISTS for a user's email
 func NewAlreadyExistsError(resource string, field string, value string) error {
-	errorCode := USER_ALREADY_EXISTS
-	if field == "email" {
+	errorCode, ok := alreadyExistsCodes[resource]
+	if !ok {
+		errorCode = ALREADY_EXISTS
+	}
+	if resource == "User" && field == "email" {
 		errorCode = EMAIL_ALREADY_EXISTS
 	}
 
@@ -29,6 +51,36 @@ func NewAlreadyExistsError(resource string, field string, value string) error {
 	}
 }
 
+// NewConflictError creates an error for a request that conflicts with the current state of a resource
+func NewConflictError(message string, detail string) error {
+	return &CustomError{
+		Message:    message,
+		ErrorCode:  CONFLICT,
+		StatusCode: 409,
+		Detail:     detail,
+	}
+}
+
+// NewRateLimitedError creates an error for a client that sent too many requests
+func NewRateLimitedError(detail string) error {
+	return &CustomError{
+		Message:    "Rate limit exceeded",
+		ErrorCode:  RATE_LIMITED,
+		StatusCode: 429,
+		Detail:     detail,
+	}
+}
+
+// NewPayloadTooLargeError creates an error for an upload or export above a size limit
+func NewPayloadTooLargeError(message string, detail string) error {
+	return &CustomError{
+		Message:    message,
+		ErrorCode:  PAYLOAD_TOO_LARGE,
+		StatusCode: 413,
+		Detail:     detail,
+	}
+}
+
 // NewValidationError creates a validation error
 func NewValidationError(detail string) error {
 	return &CustomError{
@@ -89,6 +141,27 @@ func NewInternalError(detail string) error {
 	}
 }
 
+// NewStorageUnavailableError creates an error for an unavailable object store (e.g. missing bucket)
+func NewStorageUnavailableError(detail string) error {
+	return &CustomError{
+		Message:    "File storage unavailable",
+		ErrorCode:  STORAGE_ERROR,
+		StatusCode: 503,
+		Detail:     detail,
+	}
+}
+
+// NewStorageObjectMissingError creates an error for a file whose database record exists
+// but whose object is missing from storage, meaning the two have drifted out of sync
+func NewStorageObjectMissingError(objectPath string) error {
+	return &CustomError{
+		Message:    "File missing from storage",
+		ErrorCode:  STORAGE_OBJECT_MISSING,
+		StatusCode: 404,
+		Detail:     fmt.Sprintf("object %s is recorded in the database but missing from storage", objectPath),
+	}
+}
+
 // IsCustomError checks if an error is a CustomError
 func IsCustomError(err error) bool {
 	_, ok := err.(*CustomError)
@@ -0,0 +1,104 @@
+package util
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// PaginationLimits holds the page sizes applied to listings that don't set their own
+type PaginationLimits struct {
+	DefaultLimit int // used when the client sends no limit
+	MaxLimit     int // larger limits are capped to this
+}
+
+// maxPaginationOffset bounds the rows a page may skip, so huge page numbers can neither overflow
+// page × limit nor reach the database as out-of-range offsets
+const maxPaginationOffset = math.MaxInt32
+
+// paginationLimits is the active global limits, replaced at startup from configuration
+var paginationLimits = PaginationLimits{DefaultLimit: 20, MaxLimit: 100}
+
+// SetPaginationLimits replaces the global default and maximum page size
+func SetPaginationLimits(limits PaginationLimits) {
+	paginationLimits = limits
+}
+
+// PaginationDefaults overrides the global limits for one listing; zero fields use the global value
+type PaginationDefaults struct {
+	Limit    int
+	MaxLimit int
+}
+
+// Pagination holds normalized pagination parameters
+type Pagination struct {
+	Page   int // 1-based
+	Limit  int
+	Offset int // rows to skip before the page
+}
+
+// ParsePagination reads the page and page size of a listing from the query string
+// The page size may be sent as "limit" or "page_size". Missing or invalid values fall back to
+// the defaults and sizes above the maximum are capped, so the result is always usable
+func ParsePagination(c echo.Context, defaults PaginationDefaults) Pagination {
+	limit := defaults.Limit
+	if limit <= 0 {
+		limit = paginationLimits.DefaultLimit
+	}
+	maxLimit := defaults.MaxLimit
+	if maxLimit <= 0 {
+		maxLimit = paginationLimits.MaxLimit
+	}
+
+	raw := c.QueryParam("limit")
+	if raw == "" {
+		raw = c.QueryParam("page_size")
+	}
+	if parsed, err := strconv.Atoi(raw); err == nil && parsed > 0 {
+		limit = parsed
+	}
+	limit = min(limit, maxLimit)
+
+	return NewPagination(ParsePageParam(c, "page"), limit)
+}
+
+// NewPagination builds the pagination of a 1-based page of a positive size
+// Pages past maxPaginationOffset are clamped to the last page that can be reached
+func NewPagination(page, limit int) Pagination {
+	if maxPage := maxPaginationOffset/limit + 1; page > maxPage {
+		page = maxPage
+	}
+	return Pagination{
+		Page:   page,
+		Limit:  limit,
+		Offset: (page - 1) * limit,
+	}
+}
+
+// ParsePageParam reads a 1-based page number from the named query parameter, defaulting to 1
+func ParsePageParam(c echo.Context, name string) int {
+	if parsed, err := strconv.Atoi(c.QueryParam(name)); err == nil && parsed > 0 {
+		return parsed
+	}
+	return 1
+}
+
+// ParsePageSizeParam reads a named page size such as "doc_page_size", capped to the maximum
+// Missing or invalid values fall back to the given size
+func ParsePageSizeParam(c echo.Context, name string, fallback int) int {
+	if parsed, err := strconv.Atoi(c.QueryParam(name)); err == nil && parsed > 0 {
+		return min(parsed, paginationLimits.MaxLimit)
+	}
+	return fallback
+}
+
+// Info builds the pagination metadata of a page given the total number of items
+func (p Pagination) Info(total int) PaginationInfo {
+	return PaginationInfo{
+		CurrentPage:  p.Page,
+		TotalPages:   (total + p.Limit - 1) / p.Limit,
+		TotalItems:   total,
+		ItemsPerPage: p.Limit,
+	}
+}
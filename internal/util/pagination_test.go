@@ -0,0 +1,43 @@
+package util
+
+import (
+	"math"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestParsePagination(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		wantPage   int
+		wantLimit  int
+		wantOffset int
+	}{
+		{name: "defaults", query: "", wantPage: 1, wantLimit: 20, wantOffset: 0},
+		{name: "page and limit", query: "?page=3&limit=10", wantPage: 3, wantLimit: 10, wantOffset: 20},
+		{name: "page_size alias", query: "?page=2&page_size=5", wantPage: 2, wantLimit: 5, wantOffset: 5},
+		{name: "limit capped", query: "?limit=1000", wantPage: 1, wantLimit: 100, wantOffset: 0},
+		{name: "invalid values fall back", query: "?page=-1&limit=abc", wantPage: 1, wantLimit: 20, wantOffset: 0},
+		{name: "page that would overflow the offset", query: "?page=" + strconv.Itoa(math.MaxInt) + "&limit=100",
+			wantPage: maxPaginationOffset/100 + 1, wantLimit: 100, wantOffset: maxPaginationOffset / 100 * 100},
+		{name: "page beyond the largest offset", query: "?page=100000000&limit=100",
+			wantPage: maxPaginationOffset/100 + 1, wantLimit: 100, wantOffset: maxPaginationOffset / 100 * 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := echo.New().NewContext(httptest.NewRequest("GET", "/"+tt.query, nil), httptest.NewRecorder())
+			got := ParsePagination(c, PaginationDefaults{})
+			if got.Page != tt.wantPage || got.Limit != tt.wantLimit || got.Offset != tt.wantOffset {
+				t.Fatalf("ParsePagination() = %+v, want page %d, limit %d, offset %d", got, tt.wantPage, tt.wantLimit, tt.wantOffset)
+			}
+			if got.Offset < 0 || got.Offset > maxPaginationOffset {
+				t.Fatalf("offset %d is out of range", got.Offset)
+			}
+		})
+	}
+}
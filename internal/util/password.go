@@ -0,0 +1,54 @@
+package util
+
+import (
+	"fmt"
+	"unicode"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// PasswordPolicy holds the rules checked by the "password" validation tag
+type PasswordPolicy struct {
+	MinLength    int
+	RequireMixed bool // requires upper-case and lower-case letters and a digit
+}
+
+// passwordPolicy is the active policy, replaced at startup from configuration
+var passwordPolicy = PasswordPolicy{MinLength: 6}
+
+// SetPasswordPolicy replaces the policy enforced on password fields
+func SetPasswordPolicy(policy PasswordPolicy) {
+	passwordPolicy = policy
+}
+
+// validatePassword implements the "password" validation tag
+func validatePassword(fl validator.FieldLevel) bool {
+	password := fl.Field().String()
+	if len([]rune(password)) < passwordPolicy.MinLength {
+		return false
+	}
+	if !passwordPolicy.RequireMixed {
+		return true
+	}
+
+	var hasUpper, hasLower, hasDigit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+	return hasUpper && hasLower && hasDigit
+}
+
+// describe returns the policy as a validation message for the given field
+func (p PasswordPolicy) describe(field string) string {
+	if p.RequireMixed {
+		return fmt.Sprintf("%s must be at least %d characters and contain upper-case and lower-case letters and a digit", field, p.MinLength)
+	}
+	return fmt.Sprintf("%s must be at least %d characters", field, p.MinLength)
+}
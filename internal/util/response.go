@@ -7,12 +7,28 @@ import (
 )
 
 // Response represents a standard API response structure
+// Success tells the two shapes apart: successful responses carry data, failed ones carry error
 type Response struct {
 	Success    bool           `json:"success"`
 	Message    string         `json:"message,omitempty"`
-	ErrorCode  ErrorCode      `json:"error_code"`
 	Data       interface{}    `json:"data,omitempty"`
 	Pagination PaginationInfo `json:"pagination,omitempty"`
+	Error      *ErrorBody     `json:"error,omitempty"`
+}
+
+// ErrorBody describes why a request failed
+type ErrorBody struct {
+	Code    ErrorCode         `json:"code" example:"INVALID_INPUT"`
+	Message string            `json:"message" example:"Validation failed"`
+	Detail  string            `json:"detail,omitempty" example:"Email must be a valid email address"`
+	Fields  map[string]string `json:"fields,omitempty"` // per-field messages keyed by JSON name, validation errors only
+}
+
+// ErrorEnvelope documents the shape of every error response for Swagger
+type ErrorEnvelope struct {
+	Success bool      `json:"success" example:"false"`
+	Message string    `json:"message" example:"Validation failed"`
+	Error   ErrorBody `json:"error"`
 }
 
 // SuccessResponse returns a successful response
@@ -21,7 +37,6 @@ func SuccessResponse(c echo.Context, statusCode int, message string, data interf
 	return c.JSON(statusCode, Response{
 		Success:    true,
 		Message:    message,
-		ErrorCode:  "",
 		Data:       data,
 		Pagination: pagination,
 	})
@@ -36,6 +51,11 @@ func OKResponse(c echo.Context, message string, data interface{}, statusCode ...
 	return SuccessResponse(c, code, message, data, PaginationInfo{})
 }
 
+// CreatedResponse returns a 201 Created response
+func CreatedResponse(c echo.Context, message string, data interface{}) error {
+	return SuccessResponse(c, http.StatusCreated, message, data, PaginationInfo{})
+}
+
 // PaginationInfo represents pagination metadata
 type PaginationInfo struct {
 	CurrentPage  int `json:"currentPage"`
@@ -57,26 +77,60 @@ func OKResponseWithPagination(c echo.Context, message string, items interface{},
 	return SuccessResponse(c, http.StatusOK, message, data, pagination)
 }
 
+// CursorPaginatedData wraps items with the cursor of the next page
+// NextCursor is null on the last page
+type CursorPaginatedData struct {
+	Items      interface{} `json:"items"`
+	NextCursor *string     `json:"next_cursor"`
+}
+
+// OKResponseWithCursor returns a 200 OK response with cursor pagination
+// An empty nextCursor means there are no more pages
+func OKResponseWithCursor(c echo.Context, message string, items interface{}, nextCursor string) error {
+	data := CursorPaginatedData{
+		Items: items,
+	}
+	if nextCursor != "" {
+		data.NextCursor = &nextCursor
+	}
+	return SuccessResponse(c, http.StatusOK, message, data, PaginationInfo{})
+}
+
 // HandleError handles error and returns appropriate response
-// If error is CustomError, use its info; otherwise return 500
+// If error is ValidationErrorResponse or CustomError, use its info; otherwise return 500
 func HandleError(c echo.Context, err error) error {
+	if validationErr, ok := err.(*ValidationErrorResponse); ok {
+		// Per-field messages for form validation
+		return errorJSON(c, validationErr.StatusCode, ErrorBody{
+			Code:    validationErr.ErrorCode,
+			Message: validationErr.Message,
+			Detail:  validationErr.Detail,
+			Fields:  validationErr.Fields,
+		})
+	}
+
 	if customErr, ok := err.(*CustomError); ok {
 		// Use CustomError info
-		data := ErrorDetail{Detail: customErr.Detail}
-		return c.JSON(customErr.StatusCode, Response{
-			Success:   false,
-			Message:   customErr.Message,
-			ErrorCode: customErr.ErrorCode,
-			Data:      data,
+		return errorJSON(c, customErr.StatusCode, ErrorBody{
+			Code:    customErr.ErrorCode,
+			Message: customErr.Message,
+			Detail:  customErr.Detail,
 		})
 	}
 
 	// Regular error - return 500
-	data := ErrorDetail{Detail: err.Error()}
-	return c.JSON(http.StatusInternalServerError, Response{
-		Success:   false,
-		Message:   "Internal server error",
-		ErrorCode: INTERNAL_SERVER_ERROR,
-		Data:      data,
+	return errorJSON(c, http.StatusInternalServerError, ErrorBody{
+		Code:    INTERNAL_SERVER_ERROR,
+		Message: "Internal server error",
+		Detail:  err.Error(),
+	})
+}
+
+// errorJSON writes an error response; the message is repeated at the top level for older clients
+func errorJSON(c echo.Context, statusCode int, body ErrorBody) error {
+	return c.JSON(statusCode, Response{
+		Success: false,
+		Message: body.Message,
+		Error:   &body,
 	})
 }
@@ -2,6 +2,7 @@ package util
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 
 	"github.com/go-playground/validator/v10"
@@ -11,6 +12,7 @@ var validate *validator.Validate
 
 func init() {
 	validate = validator.New()
+	_ = validate.RegisterValidation("password", validatePassword)
 }
 
 // ValidateStruct validates a struct and returns formatted error messages
@@ -34,6 +36,59 @@ func ValidateStruct(data interface{}) error {
 	)
 }
 
+// ValidateStructFields validates a struct like ValidateStruct but reports each failing field separately
+// Keys are the fields' JSON names so form clients can show messages inline
+func ValidateStructFields(data interface{}) error {
+	err := validate.Struct(data)
+	if err == nil {
+		return nil
+	}
+
+	validationErrors, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return ErrorResponse("Validation failed", INVALID_INPUT, 400, err.Error())
+	}
+
+	dataType := reflect.TypeOf(data)
+	for dataType.Kind() == reflect.Ptr {
+		dataType = dataType.Elem()
+	}
+
+	fields := make(map[string]string, len(validationErrors))
+	var errorMessages []string
+	for _, fieldErr := range validationErrors {
+		message := formatValidationError(fieldErr)
+		fields[jsonFieldName(dataType, fieldErr)] = message
+		errorMessages = append(errorMessages, message)
+	}
+
+	return &ValidationErrorResponse{
+		Message:    "Validation failed",
+		ErrorCode:  INVALID_INPUT,
+		StatusCode: 400,
+		Detail:     strings.Join(errorMessages, "; "),
+		Fields:     fields,
+	}
+}
+
+// jsonFieldName returns the JSON name of a top-level field, falling back to the Go field name
+func jsonFieldName(dataType reflect.Type, fieldErr validator.FieldError) string {
+	if dataType.Kind() != reflect.Struct {
+		return fieldErr.Field()
+	}
+
+	field, ok := dataType.FieldByName(fieldErr.StructField())
+	if !ok {
+		return fieldErr.Field()
+	}
+
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "" || name == "-" {
+		return fieldErr.Field()
+	}
+	return name
+}
+
 // formatValidationError formats a single validation error into a user-friendly message
 func formatValidationError(err validator.FieldError) string {
 	field := err.Field()
@@ -41,6 +96,10 @@ func formatValidationError(err validator.FieldError) string {
 	switch err.Tag() {
 	case "required":
 		return fmt.Sprintf("%s is required", field)
+	case "required_with":
+		return fmt.Sprintf("%s is required when %s is set", field, err.Param())
+	case "password":
+		return passwordPolicy.describe(field)
 	case "email":
 		return fmt.Sprintf("%s must be a valid email address", field)
 	case "min":
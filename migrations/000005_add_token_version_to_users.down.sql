@@ -0,0 +1,2 @@
+-- Drop token_version column
+ALTER TABLE users DROP COLUMN IF EXISTS token_version;
@@ -0,0 +1,2 @@
+-- Token version is embedded in JWTs; incrementing it invalidates previously issued tokens
+ALTER TABLE users ADD COLUMN IF NOT EXISTS token_version INT NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+-- Drop folder color/icon columns
+ALTER TABLE folders DROP COLUMN IF EXISTS icon;
+ALTER TABLE folders DROP COLUMN IF EXISTS color;
@@ -0,0 +1,3 @@
+-- Optional cosmetic metadata for folders (NULL renders with client defaults)
+ALTER TABLE folders ADD COLUMN IF NOT EXISTS color VARCHAR(20);
+ALTER TABLE folders ADD COLUMN IF NOT EXISTS icon VARCHAR(50);
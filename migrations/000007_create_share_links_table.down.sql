@@ -0,0 +1,2 @@
+-- Drop share_links table
+DROP TABLE IF EXISTS share_links;
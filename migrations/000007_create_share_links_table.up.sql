@@ -0,0 +1,15 @@
+-- Create share_links table for read-only public links to documents
+CREATE TABLE share_links (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    token VARCHAR(64) UNIQUE NOT NULL,
+    document_id UUID NOT NULL REFERENCES documents(id) ON DELETE CASCADE,
+    created_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    reminder_sent_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Indexes for performance
+CREATE INDEX idx_share_links_document ON share_links(document_id);
+CREATE INDEX idx_share_links_created_by ON share_links(created_by);
+CREATE INDEX idx_share_links_expires_at ON share_links(expires_at);
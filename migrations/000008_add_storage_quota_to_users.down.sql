@@ -0,0 +1,2 @@
+-- Drop storage_quota_bytes column
+ALTER TABLE users DROP COLUMN IF EXISTS storage_quota_bytes;
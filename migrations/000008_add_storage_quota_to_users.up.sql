@@ -0,0 +1,2 @@
+-- Per-user storage quota override in bytes; NULL falls back to the role default, negative means unlimited
+ALTER TABLE users ADD COLUMN IF NOT EXISTS storage_quota_bytes BIGINT;
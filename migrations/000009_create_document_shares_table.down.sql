@@ -0,0 +1,2 @@
+-- Drop document_shares table
+DROP TABLE IF EXISTS document_shares;
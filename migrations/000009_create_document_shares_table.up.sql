@@ -0,0 +1,13 @@
+-- Create document_shares table for sharing documents with other users
+CREATE TABLE document_shares (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    document_id UUID NOT NULL REFERENCES documents(id) ON DELETE CASCADE,
+    shared_with_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    shared_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    permission VARCHAR(20) NOT NULL DEFAULT 'read' CHECK (permission IN ('read')),
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (document_id, shared_with_user_id)
+);
+
+-- Indexes for performance
+CREATE INDEX idx_document_shares_shared_with ON document_shares(shared_with_user_id);
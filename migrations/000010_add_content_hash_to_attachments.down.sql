@@ -0,0 +1,3 @@
+-- Remove content hash from document_attachments
+DROP INDEX IF EXISTS idx_attachments_content_hash;
+ALTER TABLE document_attachments DROP COLUMN IF EXISTS content_hash;
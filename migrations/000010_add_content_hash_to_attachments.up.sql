@@ -0,0 +1,4 @@
+-- SHA-256 of the stored object, used to point identical uploads at one object
+ALTER TABLE document_attachments ADD COLUMN content_hash CHAR(64);
+
+CREATE INDEX idx_attachments_content_hash ON document_attachments(content_hash) WHERE content_hash IS NOT NULL;
@@ -0,0 +1,2 @@
+-- Drop email_verified column
+ALTER TABLE users DROP COLUMN IF EXISTS email_verified;
@@ -0,0 +1,5 @@
+-- Add email_verified column; new users confirm their address through a verification link
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified BOOLEAN NOT NULL DEFAULT false;
+
+-- Existing accounts predate verification and must not be locked out
+UPDATE users SET email_verified = true;
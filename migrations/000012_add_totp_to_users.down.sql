@@ -0,0 +1,4 @@
+-- Drop TOTP columns
+ALTER TABLE users DROP COLUMN IF EXISTS totp_backup_codes;
+ALTER TABLE users DROP COLUMN IF EXISTS totp_enabled;
+ALTER TABLE users DROP COLUMN IF EXISTS totp_secret;
@@ -0,0 +1,3 @@
+-- Drop case-insensitive unique indexes
+DROP INDEX IF EXISTS idx_users_username_lower;
+DROP INDEX IF EXISTS idx_users_email_lower;
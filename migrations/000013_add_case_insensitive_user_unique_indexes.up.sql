@@ -0,0 +1,5 @@
+-- Enforce case-insensitive uniqueness of emails and usernames at the database level
+-- The service lowercases both before checking, but two concurrent signups can still race past the check
+-- Rows that differ only by case must be merged before this migration can be applied
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_lower ON users(lower(email));
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username_lower ON users(lower(username));
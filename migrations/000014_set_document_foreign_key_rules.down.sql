@@ -0,0 +1,14 @@
+-- Restore the original foreign key rules
+ALTER TABLE document_attachments DROP CONSTRAINT IF EXISTS document_attachments_uploaded_by_fkey;
+ALTER TABLE document_attachments ADD CONSTRAINT document_attachments_uploaded_by_fkey
+    FOREIGN KEY (uploaded_by) REFERENCES users(id);
+
+ALTER TABLE folders DROP CONSTRAINT IF EXISTS folders_owner_id_fkey;
+ALTER TABLE folders ADD CONSTRAINT folders_owner_id_fkey
+    FOREIGN KEY (owner_id) REFERENCES users(id);
+
+ALTER TABLE documents DROP CONSTRAINT IF EXISTS documents_registrant_id_fkey;
+ALTER TABLE documents ADD CONSTRAINT documents_registrant_id_fkey
+    FOREIGN KEY (registrant_id) REFERENCES users(id);
+
+-- folder_id and document_id keep the rules they were created with
@@ -0,0 +1,26 @@
+-- Make the delete behaviour of every document, attachment and folder foreign key explicit
+-- Constraints are recreated so databases created from older schemas end up identical
+
+-- Attachments are removed together with their document
+ALTER TABLE document_attachments DROP CONSTRAINT IF EXISTS document_attachments_document_id_fkey;
+ALTER TABLE document_attachments ADD CONSTRAINT document_attachments_document_id_fkey
+    FOREIGN KEY (document_id) REFERENCES documents(id) ON DELETE CASCADE;
+
+-- Deleting a folder moves its documents to the root instead of deleting them
+ALTER TABLE documents DROP CONSTRAINT IF EXISTS documents_folder_id_fkey;
+ALTER TABLE documents ADD CONSTRAINT documents_folder_id_fkey
+    FOREIGN KEY (folder_id) REFERENCES folders(id) ON DELETE SET NULL;
+
+-- A user who still owns documents or folders cannot be deleted
+ALTER TABLE documents DROP CONSTRAINT IF EXISTS documents_registrant_id_fkey;
+ALTER TABLE documents ADD CONSTRAINT documents_registrant_id_fkey
+    FOREIGN KEY (registrant_id) REFERENCES users(id) ON DELETE RESTRICT;
+
+ALTER TABLE folders DROP CONSTRAINT IF EXISTS folders_owner_id_fkey;
+ALTER TABLE folders ADD CONSTRAINT folders_owner_id_fkey
+    FOREIGN KEY (owner_id) REFERENCES users(id) ON DELETE RESTRICT;
+
+-- Versions uploaded by a deleted user stay with the document
+ALTER TABLE document_attachments DROP CONSTRAINT IF EXISTS document_attachments_uploaded_by_fkey;
+ALTER TABLE document_attachments ADD CONSTRAINT document_attachments_uploaded_by_fkey
+    FOREIGN KEY (uploaded_by) REFERENCES users(id) ON DELETE SET NULL;
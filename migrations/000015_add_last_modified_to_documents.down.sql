@@ -0,0 +1,7 @@
+-- Drop last_modified and the triggers maintaining it
+DROP TRIGGER IF EXISTS touch_document_last_modified_on_attachment ON document_attachments;
+DROP FUNCTION IF EXISTS touch_document_last_modified();
+DROP TRIGGER IF EXISTS set_documents_last_modified ON documents;
+DROP FUNCTION IF EXISTS set_document_last_modified();
+DROP INDEX IF EXISTS idx_documents_registrant_last_modified;
+ALTER TABLE documents DROP COLUMN IF EXISTS last_modified;
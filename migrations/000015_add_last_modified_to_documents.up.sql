@@ -0,0 +1,45 @@
+-- Materialize when a document was last modified (metadata update or new file version)
+-- so recent-files can be served from an index instead of sorting GREATEST(...) over a join
+ALTER TABLE documents ADD COLUMN IF NOT EXISTS last_modified TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+UPDATE documents d
+SET last_modified = GREATEST(
+    d.updated_at,
+    (SELECT MAX(da.created_at) FROM document_attachments da WHERE da.document_id = d.id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_documents_registrant_last_modified ON documents(registrant_id, last_modified DESC);
+
+-- Keep last_modified in step with updated_at
+CREATE OR REPLACE FUNCTION set_document_last_modified()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        NEW.last_modified = COALESCE(NEW.updated_at, NOW());
+    ELSE
+        NEW.last_modified = GREATEST(OLD.last_modified, NEW.updated_at);
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER set_documents_last_modified
+    BEFORE INSERT OR UPDATE OF updated_at ON documents
+    FOR EACH ROW
+    EXECUTE FUNCTION set_document_last_modified();
+
+-- Uploading a new version counts as modifying the document
+CREATE OR REPLACE FUNCTION touch_document_last_modified()
+RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE documents
+    SET last_modified = GREATEST(last_modified, NEW.created_at)
+    WHERE id = NEW.document_id;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER touch_document_last_modified_on_attachment
+    AFTER INSERT ON document_attachments
+    FOR EACH ROW
+    EXECUTE FUNCTION touch_document_last_modified();
@@ -0,0 +1,3 @@
+-- Remove upload ID from document_attachments
+DROP INDEX IF EXISTS idx_attachments_upload_id;
+ALTER TABLE document_attachments DROP COLUMN IF EXISTS upload_id;
@@ -0,0 +1,4 @@
+-- tusd upload ID of the upload that created the attachment; a completion event is processed only once
+ALTER TABLE document_attachments ADD COLUMN IF NOT EXISTS upload_id VARCHAR(255);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_attachments_upload_id ON document_attachments(upload_id) WHERE upload_id IS NOT NULL;
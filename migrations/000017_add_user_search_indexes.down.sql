@@ -0,0 +1,7 @@
+-- Remove user search indexes (the pg_trgm extension is left installed)
+DROP INDEX IF EXISTS idx_users_phone_trgm;
+DROP INDEX IF EXISTS idx_users_full_name_trgm;
+DROP INDEX IF EXISTS idx_users_last_name_trgm;
+DROP INDEX IF EXISTS idx_users_first_name_trgm;
+DROP INDEX IF EXISTS idx_users_email_trgm;
+DROP INDEX IF EXISTS idx_users_username_trgm;
@@ -0,0 +1,8 @@
+-- Drop the department foreign keys and restore the original document column type
+ALTER TABLE documents DROP CONSTRAINT IF EXISTS documents_current_department_id_fkey;
+ALTER TABLE documents ALTER COLUMN current_department_id TYPE UUID USING NULL::uuid;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_department_id_fkey;
+
+-- Drop departments and sectors tables
+DROP TABLE IF EXISTS departments;
+DROP TABLE IF EXISTS sectors;
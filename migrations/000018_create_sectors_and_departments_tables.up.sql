@@ -0,0 +1,54 @@
+-- Create sectors table: the top level of the organization under the Director
+CREATE TABLE sectors (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_sectors_name_lower ON sectors(LOWER(name));
+
+-- Create departments table; each department belongs to one sector
+-- The ID is free-form text, the same type as users.department_id, and every column holding a
+-- department ID references it
+CREATE TABLE departments (
+    id VARCHAR(255) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    sector_id UUID NOT NULL REFERENCES sectors(id) ON DELETE RESTRICT,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_departments_sector ON departments(sector_id);
+
+CREATE TRIGGER update_sectors_updated_at
+    BEFORE UPDATE ON sectors
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_departments_updated_at
+    BEFORE UPDATE ON departments
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Register the departments already assigned to users under a placeholder sector so the foreign key
+-- below holds for existing rows; blank department IDs mean no department
+UPDATE users SET department_id = NULL WHERE department_id = '';
+
+INSERT INTO sectors (name)
+SELECT 'Unassigned'
+WHERE EXISTS (SELECT 1 FROM users WHERE department_id IS NOT NULL);
+
+INSERT INTO departments (id, name, sector_id)
+SELECT DISTINCT u.department_id, u.department_id, s.id
+FROM users u
+JOIN sectors s ON s.name = 'Unassigned'
+WHERE u.department_id IS NOT NULL;
+
+ALTER TABLE users ADD CONSTRAINT users_department_id_fkey
+    FOREIGN KEY (department_id) REFERENCES departments(id) ON DELETE RESTRICT;
+
+-- Documents hold the same text department IDs; the column was never written before
+ALTER TABLE documents ALTER COLUMN current_department_id TYPE VARCHAR(255) USING current_department_id::text;
+ALTER TABLE documents ADD CONSTRAINT documents_current_department_id_fkey
+    FOREIGN KEY (current_department_id) REFERENCES departments(id) ON DELETE RESTRICT;
@@ -0,0 +1,3 @@
+-- Drop document_routes table
+DROP INDEX IF EXISTS idx_documents_current_department;
+DROP TABLE IF EXISTS document_routes;
@@ -0,0 +1,16 @@
+-- Create document_routes table: the history of a document moving between departments
+CREATE TABLE document_routes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    document_id UUID NOT NULL REFERENCES documents(id) ON DELETE CASCADE,
+    from_department_id VARCHAR(255) REFERENCES departments(id) ON DELETE RESTRICT,
+    to_department_id VARCHAR(255) NOT NULL REFERENCES departments(id) ON DELETE RESTRICT,
+    from_status document_status,
+    to_status document_status,
+    routed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    note TEXT,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Indexes for performance
+CREATE INDEX idx_document_routes_document ON document_routes(document_id, created_at DESC);
+CREATE INDEX idx_documents_current_department ON documents(current_department_id) WHERE current_department_id IS NOT NULL;
@@ -0,0 +1,2 @@
+-- Drop disabled column
+ALTER TABLE users DROP COLUMN IF EXISTS disabled;
@@ -0,0 +1,2 @@
+-- Add disabled column; Directors disable compromised accounts, which then cannot log in or refresh tokens
+ALTER TABLE users ADD COLUMN IF NOT EXISTS disabled BOOLEAN NOT NULL DEFAULT false;
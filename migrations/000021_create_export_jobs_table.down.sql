@@ -0,0 +1,2 @@
+-- Drop export_jobs table
+DROP TABLE IF EXISTS export_jobs;
@@ -0,0 +1,3 @@
+-- Drop tags tables
+DROP TABLE IF EXISTS document_tags;
+DROP TABLE IF EXISTS tags;
@@ -0,0 +1,21 @@
+-- Create tags table: free-form labels, each user has their own set
+CREATE TABLE tags (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    owner_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Tag names are unique per user regardless of case
+CREATE UNIQUE INDEX idx_tags_owner_name ON tags(owner_id, (lower(name)));
+
+-- Create document_tags table: which tags are on which document
+CREATE TABLE document_tags (
+    document_id UUID NOT NULL REFERENCES documents(id) ON DELETE CASCADE,
+    tag_id UUID NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (document_id, tag_id)
+);
+
+-- Indexes for performance
+CREATE INDEX idx_document_tags_tag ON document_tags(tag_id);
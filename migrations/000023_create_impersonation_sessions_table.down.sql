@@ -0,0 +1,2 @@
+-- Drop impersonation_sessions table
+DROP TABLE IF EXISTS impersonation_sessions;
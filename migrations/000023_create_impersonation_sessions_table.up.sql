@@ -0,0 +1,17 @@
+-- Create impersonation_sessions table: audit trail of directors acting as another user for support
+-- User IDs carry no foreign keys so the record outlives deleted accounts
+CREATE TABLE impersonation_sessions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    director_id UUID NOT NULL,
+    target_user_id UUID NOT NULL,
+    reason TEXT NOT NULL,
+    ip_address TEXT NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    ended_at TIMESTAMPTZ
+);
+
+-- Indexes for performance
+CREATE INDEX idx_impersonation_sessions_director ON impersonation_sessions(director_id, started_at DESC);
+CREATE INDEX idx_impersonation_sessions_target ON impersonation_sessions(target_user_id, started_at DESC);
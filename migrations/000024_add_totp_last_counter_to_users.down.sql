@@ -0,0 +1,2 @@
+-- Drop the last accepted TOTP time step
+ALTER TABLE users DROP COLUMN IF EXISTS totp_last_counter;
@@ -0,0 +1,2 @@
+-- Time step of the last accepted TOTP code, so the same code cannot be used twice
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_last_counter BIGINT NOT NULL DEFAULT -1;